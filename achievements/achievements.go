@@ -0,0 +1,304 @@
+// Package achievements fetches the RetroAchievements progress of the user:
+// the achievements of the running game, locked or unlocked, and a summary of
+// the profile. Games are identified by the MD5 hash of their content.
+package achievements
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/libretro/ludo/settings"
+)
+
+const (
+	apiURL     = "https://retroachievements.org/API/"
+	connectURL = "https://retroachievements.org/dorequest.php"
+	badgesURL  = "https://media.retroachievements.org/Badge/"
+)
+
+// Achievement is a challenge of a game. The dates are empty while it is
+// locked.
+type Achievement struct {
+	ID                 int    `json:"ID"`
+	Title              string `json:"Title"`
+	Description        string `json:"Description"`
+	Points             int    `json:"Points"`
+	BadgeName          string `json:"BadgeName"`
+	DisplayOrder       int    `json:"DisplayOrder"`
+	DateEarned         string `json:"DateEarned"`
+	DateEarnedHardcore string `json:"DateEarnedHardcore"`
+}
+
+// Game is a game and the progress of the user in it
+type Game struct {
+	ID           int                    `json:"ID"`
+	Title        string                 `json:"Title"`
+	Achievements map[string]Achievement `json:"Achievements"`
+}
+
+// Profile is the summary of the achievements of the user
+type Profile struct {
+	User            string `json:"User"`
+	TotalPoints     int    `json:"TotalPoints"`
+	TotalTruePoints int    `json:"TotalTruePoints"`
+	Rank            int    `json:"Rank"`
+}
+
+var (
+	current *Game
+	profile *Profile
+	mu      sync.Mutex
+	// loads counts the games loaded, so that the progress of a game fetched
+	// after another game was loaded is dropped
+	loads int
+)
+
+var client = &http.Client{Timeout: 15 * time.Second}
+
+// Enabled tells if the RetroAchievements account is set
+func Enabled() bool {
+	return settings.Current.AchievementsUsername != "" && settings.Current.AchievementsAPIKey != ""
+}
+
+// Current returns the progress in the running game, if it has achievements
+func Current() *Game {
+	mu.Lock()
+	defer mu.Unlock()
+	return current
+}
+
+// CurrentProfile returns the profile summary, once fetched
+func CurrentProfile() *Profile {
+	mu.Lock()
+	defer mu.Unlock()
+	return profile
+}
+
+// Unlocked tells if the user earned an achievement
+func (a Achievement) Unlocked() bool {
+	return a.DateEarned != "" || a.DateEarnedHardcore != ""
+}
+
+// BadgeURL is the location of the badge image of an achievement, greyed out
+// while it is locked
+func (a Achievement) BadgeURL() string {
+	if a.Unlocked() {
+		return badgesURL + a.BadgeName + ".png"
+	}
+	return badgesURL + a.BadgeName + "_lock.png"
+}
+
+// BadgePath is where the badge image of an achievement is cached
+func (a Achievement) BadgePath() string {
+	return filepath.Join(xdg.CacheHome, "ludo", "badges", filepath.Base(a.BadgeURL()))
+}
+
+// List returns the achievements of a game in their display order
+func (g Game) List() []Achievement {
+	var list []Achievement
+	for _, a := range g.Achievements {
+		list = append(list, a)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].DisplayOrder != list[j].DisplayOrder {
+			return list[i].DisplayOrder < list[j].DisplayOrder
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// Progress counts the unlocked achievements of a game and their points
+func (g Game) Progress() (unlocked, total, points, totalPoints int) {
+	for _, a := range g.Achievements {
+		total++
+		totalPoints += a.Points
+		if a.Unlocked() {
+			unlocked++
+			points += a.Points
+		}
+	}
+	return
+}
+
+// Completion is the percentage of unlocked achievements of a game
+func (g Game) Completion() float64 {
+	unlocked, total, _, _ := g.Progress()
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(unlocked) / float64(total)
+}
+
+// Hash computes the identifier of a game content. The header of iNES files
+// isn't part of the hash.
+func Hash(r io.Reader) (string, error) {
+	br := bufio.NewReader(r)
+	if header, err := br.Peek(16); err == nil && bytes.HasPrefix(header, []byte("NES\x1a")) {
+		br.Discard(16)
+	}
+	h := md5.New()
+	if _, err := io.Copy(h, br); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// get fetches a JSON document
+func get(u string, v interface{}) error {
+	resp, err := client.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("RetroAchievements returned %v", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// post sends a form to the connect API and decodes the JSON answer. The
+// credentials are sent in the body, they don't end up in the logs of proxies.
+func post(form url.Values, v interface{}) error {
+	resp, err := client.PostForm(connectURL, form)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("RetroAchievements returned %v", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// apiQuery builds the query of a web API call, authenticated with the
+// account of the user
+func apiQuery(params url.Values) string {
+	params.Set("z", settings.Current.AchievementsUsername)
+	params.Set("y", settings.Current.AchievementsAPIKey)
+	params.Set("u", settings.Current.AchievementsUsername)
+	return params.Encode()
+}
+
+// gameID finds the RetroAchievements identifier of a game from its hash
+func gameID(hash string) (int, error) {
+	var resp struct {
+		Success bool `json:"Success"`
+		GameID  int  `json:"GameID"`
+	}
+	q := url.Values{"r": {"gameid"}, "m": {hash}}
+	if err := get(connectURL+"?"+q.Encode(), &resp); err != nil {
+		return 0, err
+	}
+	if !resp.Success {
+		return 0, errors.New("could not identify the game")
+	}
+	return resp.GameID, nil
+}
+
+// Load fetches the progress of the user in a game. It blocks, it is meant
+// to be run in a goroutine.
+func Load(gamePath string) error {
+	mu.Lock()
+	current = nil
+	attempts = nil
+	loads++
+	n := loads
+	mu.Unlock()
+
+	if !Enabled() {
+		return nil
+	}
+
+	fd, err := os.Open(gamePath)
+	if err != nil {
+		return err
+	}
+	h, err := Hash(fd)
+	fd.Close()
+	if err != nil {
+		return err
+	}
+
+	id, err := gameID(h)
+	if err != nil {
+		return err
+	}
+	if id == 0 {
+		return nil
+	}
+
+	var g Game
+	q := apiQuery(url.Values{"g": {fmt.Sprint(id)}})
+	if err := get(apiURL+"API_GetGameInfoAndUserProgress.php?"+q, &g); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	if n == loads {
+		current = &g
+		hash = h
+	}
+	tok := token
+	mu.Unlock()
+
+	if tok == "" {
+		tok = settings.Current.AchievementsToken
+	}
+	if tok == "" {
+		return nil
+	}
+	lbs, err := loadLeaderboards(id, tok)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	token = tok
+	if n == loads {
+		attempts = lbs
+	}
+	mu.Unlock()
+	return nil
+}
+
+// Unload forgets the progress of the game that was running
+func Unload() {
+	mu.Lock()
+	current = nil
+	attempts = nil
+	loads++
+	mu.Unlock()
+}
+
+// LoadProfile fetches the profile summary of the user
+func LoadProfile() error {
+	if !Enabled() {
+		return errors.New("no RetroAchievements account")
+	}
+
+	var p Profile
+	q := apiQuery(url.Values{"g": {"0"}})
+	if err := get(apiURL+"API_GetUserSummary.php?"+q, &p); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	profile = &p
+	mu.Unlock()
+	return nil
+}
@@ -0,0 +1,78 @@
+package achievements
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	t.Run("Hashes the content", func(t *testing.T) {
+		got, err := Hash(bytes.NewReader([]byte("hello")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "5d41402abc4b2a76b9719d911017c592"; got != want {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Skips the iNES header", func(t *testing.T) {
+		rom := append([]byte("NES\x1a"), make([]byte, 12)...)
+		rom = append(rom, []byte("hello")...)
+		got, err := Hash(bytes.NewReader(rom))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "5d41402abc4b2a76b9719d911017c592"; got != want {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+}
+
+func testGame() Game {
+	return Game{
+		ID:    1,
+		Title: "Sonic the Hedgehog",
+		Achievements: map[string]Achievement{
+			"3": {ID: 3, Title: "Third", Points: 25, DisplayOrder: 2, BadgeName: "00003"},
+			"1": {ID: 1, Title: "First", Points: 5, DisplayOrder: 1, BadgeName: "00001", DateEarned: "2020-01-01 10:00:00"},
+			"2": {ID: 2, Title: "Second", Points: 10, DisplayOrder: 1, BadgeName: "00002", DateEarnedHardcore: "2020-01-02 10:00:00"},
+			"4": {ID: 4, Title: "Fourth", Points: 10, DisplayOrder: 3, BadgeName: "00004"},
+		},
+	}
+}
+
+func TestGame_List(t *testing.T) {
+	var got []int
+	for _, a := range testGame().List() {
+		got = append(got, a.ID)
+	}
+	if want := []int{1, 2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestGame_Progress(t *testing.T) {
+	g := testGame()
+	unlocked, total, points, totalPoints := g.Progress()
+	if unlocked != 2 || total != 4 || points != 15 || totalPoints != 50 {
+		t.Errorf("got %v, %v, %v, %v", unlocked, total, points, totalPoints)
+	}
+	if got := g.Completion(); got != 50 {
+		t.Errorf("got %v%%, want 50%%", got)
+	}
+	if got := (Game{}).Completion(); got != 0 {
+		t.Errorf("got %v%% for a game without achievements, want 0%%", got)
+	}
+}
+
+func TestAchievement_BadgeURL(t *testing.T) {
+	g := testGame()
+	if got, want := g.Achievements["1"].BadgeURL(), badgesURL+"00001.png"; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, want := g.Achievements["3"].BadgeURL(), badgesURL+"00003_lock.png"; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,467 @@
+package achievements
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// memory is the RAM of the running game, as exposed by the core
+type memory []byte
+
+// size is the width of a memory read
+type size int
+
+const (
+	size8 size = iota
+	size16
+	size24
+	size32
+	sizeLow
+	sizeHigh
+	sizeBit0          // followed by the 7 other bits
+	sizeBitCount size = sizeBit0 + 8
+)
+
+// sizes are the prefixes of memory reads after 0x, the 16 bits reads having
+// none
+var sizes = map[byte]size{
+	'H': size8, 'W': size24, 'X': size32, 'L': sizeLow, 'U': sizeHigh, 'K': sizeBitCount,
+	'M': sizeBit0, 'N': sizeBit0 + 1, 'O': sizeBit0 + 2, 'P': sizeBit0 + 3,
+	'Q': sizeBit0 + 4, 'R': sizeBit0 + 5, 'S': sizeBit0 + 6, 'T': sizeBit0 + 7,
+}
+
+// peek reads a little endian value, the bytes out of the memory being zero
+func (m memory) peek(addr uint32, s size) uint32 {
+	at := func(a uint32) uint32 {
+		if int64(a) >= int64(len(m)) {
+			return 0
+		}
+		return uint32(m[a])
+	}
+	switch s {
+	case size16:
+		return at(addr) | at(addr+1)<<8
+	case size24:
+		return at(addr) | at(addr+1)<<8 | at(addr+2)<<16
+	case size32:
+		return at(addr) | at(addr+1)<<8 | at(addr+2)<<16 | at(addr+3)<<24
+	case sizeLow:
+		return at(addr) & 0xf
+	case sizeHigh:
+		return at(addr) >> 4
+	case sizeBitCount:
+		return uint32(bits.OnesCount8(uint8(at(addr))))
+	case size8:
+		return at(addr)
+	}
+	return at(addr) >> uint(s-sizeBit0) & 1
+}
+
+// operand is a constant or a memory read. Delta reads return the value read
+// on the previous frame.
+type operand struct {
+	mem   bool
+	delta bool
+	size  size
+	value uint32
+	last  uint32
+}
+
+// read returns the value of an operand and remembers the current value for
+// the delta reads of the next frame
+func (o *operand) read(m memory) uint32 {
+	if !o.mem {
+		return o.value
+	}
+	v := m.peek(o.value, o.size)
+	prev := o.last
+	o.last = v
+	if o.delta {
+		return prev
+	}
+	return v
+}
+
+// condition compares two operands. It has to be true during hits frames to
+// be met, or only on the current frame if hits is zero.
+type condition struct {
+	flag    byte
+	left    operand
+	op      string
+	right   operand
+	mult    float64
+	target  uint32
+	hits    uint32
+	current bool
+}
+
+// group is a list of conditions that must all be met
+type group []*condition
+
+// trigger is true when its core group and one of its alternative groups, if
+// it has any, are true
+type trigger struct {
+	core group
+	alts []group
+}
+
+// parser reads a condition string
+type parser struct {
+	s string
+	i int
+}
+
+func (p *parser) peek() byte {
+	if p.i >= len(p.s) {
+		return 0
+	}
+	return p.s[p.i]
+}
+
+func (p *parser) accept(prefix string) bool {
+	if strings.HasPrefix(p.s[p.i:], prefix) {
+		p.i += len(prefix)
+		return true
+	}
+	return false
+}
+
+// isDigit tells if c is a digit in base 10 or 16
+func isDigit(c byte, base int) bool {
+	if c >= '0' && c <= '9' {
+		return true
+	}
+	return base == 16 && (c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F')
+}
+
+// number reads an unsigned integer in base 10 or 16
+func (p *parser) number(base int) (uint32, error) {
+	start := p.i
+	for p.i < len(p.s) && isDigit(p.s[p.i], base) {
+		p.i++
+	}
+	v, err := strconv.ParseUint(p.s[start:p.i], base, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number at %d in %q", start, p.s)
+	}
+	return uint32(v), nil
+}
+
+// operand reads a constant, like 12 or h0C, or a memory read, like 0xH00C0
+// or d0xH00C0
+func (p *parser) operand() (operand, error) {
+	var o operand
+	switch {
+	case p.accept("d0x"):
+		o.mem, o.delta = true, true
+	case p.accept("0x"):
+		o.mem = true
+	case p.accept("h"):
+		v, err := p.number(16)
+		return operand{value: v}, err
+	default:
+		v, err := p.number(10)
+		return operand{value: v}, err
+	}
+
+	o.size = size16
+	if s, ok := sizes[p.peek()]; ok {
+		o.size = s
+		p.i++
+	} else if p.peek() == ' ' {
+		p.i++
+	}
+	v, err := p.number(16)
+	o.value = v
+	return o, err
+}
+
+// operators are the comparisons, longest first
+var operators = []string{"!=", "<=", ">=", "=", "<", ">"}
+
+// condition reads a condition like R:0xH00C0=3.10.
+func (p *parser) condition() (*condition, error) {
+	c := condition{mult: 1}
+	if len(p.s)-p.i > 1 && p.s[p.i+1] == ':' {
+		c.flag = p.s[p.i]
+		if !strings.ContainsRune("RPABM", rune(c.flag)) {
+			return nil, fmt.Errorf("unsupported condition flag %c", c.flag)
+		}
+		p.i += 2
+	}
+
+	var err error
+	if c.left, err = p.operand(); err != nil {
+		return nil, err
+	}
+
+	if c.flag == 'A' || c.flag == 'B' {
+		if p.accept("*") {
+			start := p.i
+			for p.i < len(p.s) && strings.IndexByte("0123456789.", p.s[p.i]) >= 0 {
+				p.i++
+			}
+			if c.mult, err = strconv.ParseFloat(p.s[start:p.i], 64); err != nil {
+				return nil, fmt.Errorf("invalid multiplier in %q", p.s)
+			}
+		}
+		return &c, nil
+	}
+
+	for _, op := range operators {
+		if p.accept(op) {
+			c.op = op
+			break
+		}
+	}
+	if c.op == "" {
+		return nil, fmt.Errorf("missing comparison at %d in %q", p.i, p.s)
+	}
+	if c.right, err = p.operand(); err != nil {
+		return nil, err
+	}
+
+	if p.accept(".") {
+		if c.target, err = p.number(10); err != nil {
+			return nil, err
+		}
+		if !p.accept(".") {
+			return nil, errors.New("unterminated hit count in " + p.s)
+		}
+	} else if p.accept("(") {
+		if c.target, err = p.number(10); err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, errors.New("unterminated hit count in " + p.s)
+		}
+	}
+	return &c, nil
+}
+
+// parseGroup reads conditions separated by underscores
+func parseGroup(s string) (group, error) {
+	var g group
+	if s == "" || s == "1=1" {
+		return g, nil
+	}
+	p := parser{s: s}
+	for {
+		c, err := p.condition()
+		if err != nil {
+			return nil, err
+		}
+		g = append(g, c)
+		if p.i == len(s) {
+			return g, nil
+		}
+		if !p.accept("_") {
+			return nil, fmt.Errorf("unexpected %q at %d in %q", p.peek(), p.i, s)
+		}
+	}
+}
+
+// splitAlts splits a trigger in groups at the S separators, leaving the 0xS
+// bit reads alone
+func splitAlts(s string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == 'S' && !strings.HasSuffix(s[:i], "0x") {
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// parseTrigger reads a core group followed by alternative groups, separated
+// by S
+func parseTrigger(s string) (*trigger, error) {
+	var t trigger
+	for i, part := range splitAlts(s) {
+		g, err := parseGroup(part)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			t.core = g
+		} else {
+			t.alts = append(t.alts, g)
+		}
+	}
+	return &t, nil
+}
+
+// compare applies a comparison operator
+func compare(l uint32, op string, r uint32) bool {
+	switch op {
+	case "=":
+		return l == r
+	case "!=":
+		return l != r
+	case "<":
+		return l < r
+	case "<=":
+		return l <= r
+	case ">":
+		return l > r
+	case ">=":
+		return l >= r
+	}
+	return false
+}
+
+// eval reads the operands of every condition of a group and finds which are
+// true on this frame. It reports whether a PauseIf condition is true, and
+// whether a ResetIf one is.
+func (g group) eval(m memory) (paused, reset bool) {
+	var add float64
+	for _, c := range g {
+		l := float64(c.left.read(m))
+		r := c.right.read(m)
+		switch c.flag {
+		case 'A':
+			add += l * c.mult
+			continue
+		case 'B':
+			add -= l * c.mult
+			continue
+		}
+		l += add
+		add = 0
+		if l < 0 {
+			l = 0
+		}
+		c.current = compare(uint32(l), c.op, r)
+		if c.flag == 'P' && c.current {
+			paused = true
+		}
+		if c.flag == 'R' && c.current {
+			reset = true
+		}
+	}
+	return
+}
+
+// met tells if all the conditions of a group are met, counting a hit for
+// those true on this frame
+func (g group) met() bool {
+	ok := true
+	for _, c := range g {
+		switch c.flag {
+		case 'A', 'B':
+			continue
+		case 'R', 'P':
+			// already handled by eval
+			continue
+		}
+		if c.current && (c.target == 0 || c.hits < c.target) {
+			c.hits++
+		}
+		if c.target == 0 && !c.current || c.target > 0 && c.hits < c.target {
+			ok = false
+		}
+	}
+	return ok
+}
+
+func (g group) reset() {
+	for _, c := range g {
+		c.hits = 0
+	}
+}
+
+// reset clears the hit counts of all the groups of a trigger
+func (t *trigger) reset() {
+	t.core.reset()
+	for _, g := range t.alts {
+		g.reset()
+	}
+}
+
+// test evaluates a trigger for the current frame
+func (t *trigger) test(m memory) bool {
+	groups := append([]group{t.core}, t.alts...)
+	paused := make([]bool, len(groups))
+	reset := false
+	for i, g := range groups {
+		var r bool
+		paused[i], r = g.eval(m)
+		reset = reset || r && !paused[i]
+	}
+	if reset {
+		t.reset()
+		return false
+	}
+
+	ok := !paused[0] && t.core.met()
+	if len(t.alts) == 0 {
+		return ok
+	}
+	alt := false
+	for i, g := range t.alts {
+		if !paused[i+1] && g.met() {
+			alt = true
+		}
+	}
+	return ok && alt
+}
+
+// value is a sum of memory reads scaled by multipliers, like
+// 0xH00C0*10_0xH00C1. The highest of several values separated by $ is taken.
+type value [][]*condition
+
+// parseValue reads the value of a leaderboard
+func parseValue(s string) (value, error) {
+	var v value
+	for _, part := range strings.Split(s, "$") {
+		var terms []*condition
+		for _, term := range strings.Split(part, "_") {
+			flag := byte('A')
+			if len(term) > 1 && term[1] == ':' {
+				switch term[0] {
+				case 'B':
+					flag = 'B'
+				case 'A', 'M':
+				default:
+					return nil, fmt.Errorf("unsupported value flag %c", term[0])
+				}
+				term = term[2:]
+			}
+			p := parser{s: string(flag) + ":" + term}
+			c, err := p.condition()
+			if err != nil {
+				return nil, err
+			}
+			if p.i != len(p.s) {
+				return nil, fmt.Errorf("unexpected %q in value %q", p.peek(), s)
+			}
+			terms = append(terms, c)
+		}
+		v = append(v, terms)
+	}
+	return v, nil
+}
+
+// eval computes a value for the current frame
+func (v value) eval(m memory) int {
+	best := 0
+	for i, terms := range v {
+		var sum float64
+		for _, c := range terms {
+			x := float64(c.left.read(m)) * c.mult
+			if c.flag == 'B' {
+				x = -x
+			}
+			sum += x
+		}
+		if i == 0 || int(sum) > best {
+			best = int(sum)
+		}
+	}
+	return best
+}
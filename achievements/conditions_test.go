@@ -0,0 +1,136 @@
+package achievements
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_memory_peek(t *testing.T) {
+	m := memory{0x34, 0x12, 0xf0, 0x0f}
+	tests := []struct {
+		name string
+		addr uint32
+		size size
+		want uint32
+	}{
+		{"8 bits", 0, size8, 0x34},
+		{"16 bits", 0, size16, 0x1234},
+		{"24 bits", 0, size24, 0xf01234},
+		{"32 bits", 0, size32, 0x0ff01234},
+		{"Lower nibble", 0, sizeLow, 0x4},
+		{"Upper nibble", 0, sizeHigh, 0x3},
+		{"Bit", 0, sizeBit0 + 2, 1},
+		{"Bit count", 2, sizeBitCount, 4},
+		{"Out of the memory", 3, size16, 0x0f},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.peek(tt.addr, tt.size); got != tt.want {
+				t.Errorf("got %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseGroup(t *testing.T) {
+	t.Run("Parses conditions", func(t *testing.T) {
+		g, err := parseGroup("R:0xH00c0=3_d0x 0010!=h1F.10._0xS0002>12")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := group{
+			{flag: 'R', left: operand{mem: true, size: size8, value: 0xc0}, op: "=", right: operand{value: 3}, mult: 1},
+			{left: operand{mem: true, delta: true, size: size16, value: 0x10}, op: "!=", right: operand{value: 0x1f}, mult: 1, target: 10},
+			{left: operand{mem: true, size: sizeBit0 + 6, value: 2}, op: ">", right: operand{value: 12}, mult: 1},
+		}
+		if !reflect.DeepEqual(g, want) {
+			t.Errorf("got %+v, want %+v", g, want)
+		}
+	})
+
+	t.Run("Rejects unsupported flags", func(t *testing.T) {
+		if _, err := parseGroup("N:0xH00c0=3"); err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("Rejects a missing comparison", func(t *testing.T) {
+		if _, err := parseGroup("0xH00c0"); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func Test_splitAlts(t *testing.T) {
+	got := splitAlts("0xH0001=1S0xS0002=1S0xH0003=1")
+	want := []string{"0xH0001=1", "0xS0002=1", "0xH0003=1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func Test_trigger_test(t *testing.T) {
+	test := func(t *testing.T, tr *trigger, frames []memory, want []bool) {
+		t.Helper()
+		for i, m := range frames {
+			if got := tr.test(m); got != want[i] {
+				t.Errorf("frame %d: got %v, want %v", i, got, want[i])
+			}
+		}
+	}
+
+	t.Run("Compares with the previous frame", func(t *testing.T) {
+		tr, _ := parseTrigger("0xH0000>d0xH0000")
+		test(t, tr, []memory{{1}, {2}, {2}, {3}}, []bool{true, true, false, true})
+	})
+
+	t.Run("Counts hits", func(t *testing.T) {
+		tr, _ := parseTrigger("0xH0000=1.2.")
+		test(t, tr, []memory{{1}, {0}, {1}, {0}}, []bool{false, false, true, true})
+	})
+
+	t.Run("Resets hits", func(t *testing.T) {
+		tr, _ := parseTrigger("0xH0000=1.2._R:0xH0001=1")
+		test(t, tr, []memory{{1, 0}, {1, 1}, {1, 0}, {1, 0}}, []bool{false, false, false, true})
+	})
+
+	t.Run("Pauses", func(t *testing.T) {
+		tr, _ := parseTrigger("0xH0000=1.2._P:0xH0001=1")
+		test(t, tr, []memory{{1, 0}, {1, 1}, {1, 0}}, []bool{false, false, true})
+	})
+
+	t.Run("Adds sources", func(t *testing.T) {
+		tr, _ := parseTrigger("A:0xH0000*2_0xH0001=5")
+		test(t, tr, []memory{{2, 1}, {1, 3}}, []bool{true, true})
+	})
+
+	t.Run("Needs one alternative", func(t *testing.T) {
+		tr, _ := parseTrigger("0xH0000=1S0xH0001=1S0xH0001=2")
+		test(t, tr, []memory{{1, 0}, {1, 2}, {0, 1}}, []bool{false, true, false})
+	})
+}
+
+func Test_value_eval(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		m    memory
+		want int
+	}{
+		{"Sum", "0xH0000*10_0xH0001", memory{3, 4}, 34},
+		{"Flags", "A:0xH0000*10_B:0xH0001", memory{3, 4}, 26},
+		{"Float multiplier", "0xH0000*0.5", memory{9}, 4},
+		{"Max of values", "0xH0000$0xH0001", memory{3, 4}, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := parseValue(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := v.eval(tt.m); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package achievements
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/libretro/ludo/crash"
+	"github.com/libretro/ludo/libretro"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+)
+
+// Leaderboard is a leaderboard of a game, as described by the RetroAchievements
+// patch data. Mem holds the start, cancel and submit conditions and the
+// value of the score.
+type Leaderboard struct {
+	ID          int    `json:"ID"`
+	Title       string `json:"Title"`
+	Description string `json:"Description"`
+	Format      string `json:"Format"`
+	Mem         string `json:"Mem"`
+}
+
+// Tracker is the score of a leaderboard attempt in progress
+type Tracker struct {
+	Title string
+	Value string
+}
+
+// attempt is a leaderboard whose conditions are evaluated during play
+type attempt struct {
+	Leaderboard
+	start, cancel, submit *trigger
+	value                 value
+	active                bool
+	score                 int
+}
+
+// event is what happened to a leaderboard attempt on a frame
+type event int
+
+const (
+	none event = iota
+	started
+	canceled
+	submitted
+)
+
+var (
+	attempts []*attempt
+	token    string
+	hash     string
+)
+
+// parseLeaderboard reads the conditions of a leaderboard, like
+// STA:0xH00C0=1::CAN:0xH00C1=0::SUB:0xH00C0=2::VAL:0xH00C2
+func parseLeaderboard(l Leaderboard) (*attempt, error) {
+	a := attempt{Leaderboard: l}
+	var err error
+	for _, part := range strings.Split(l.Mem, "::") {
+		if len(part) < 4 || part[3] != ':' {
+			return nil, fmt.Errorf("invalid leaderboard part %q", part)
+		}
+		switch body := part[4:]; part[:3] {
+		case "STA":
+			a.start, err = parseTrigger(body)
+		case "CAN":
+			a.cancel, err = parseTrigger(body)
+		case "SUB":
+			a.submit, err = parseTrigger(body)
+		case "VAL":
+			a.value, err = parseValue(body)
+		default:
+			err = fmt.Errorf("unknown leaderboard part %q", part)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if a.start == nil || a.cancel == nil || a.submit == nil || a.value == nil {
+		return nil, errors.New("incomplete leaderboard " + l.Title)
+	}
+	return &a, nil
+}
+
+// formatScore displays a score in the format of its leaderboard. Times in
+// frames assume 60 frames per second.
+func formatScore(format string, v int) string {
+	clock := func(cents int) string {
+		return fmt.Sprintf("%d:%02d.%02d", cents/6000, cents/100%60, cents%100)
+	}
+	switch format {
+	case "TIME", "FRAMES":
+		return clock(v * 100 / 60)
+	case "MILLISECS":
+		return clock(v)
+	case "SECS":
+		return fmt.Sprintf("%d:%02d", v/60, v%60)
+	case "MINUTES":
+		return fmt.Sprintf("%dh%02d", v/60, v%60)
+	case "SCORE", "POINTS":
+		return fmt.Sprintf("%06d", v)
+	}
+	return fmt.Sprint(v)
+}
+
+// frame evaluates the conditions of a leaderboard for the current frame. All
+// of them are evaluated on every frame to keep the delta reads up to date.
+func (a *attempt) frame(m memory) event {
+	start := a.start.test(m)
+	cancel := a.cancel.test(m)
+	submit := a.submit.test(m)
+	a.score = a.value.eval(m)
+
+	if !a.active {
+		if !start || cancel {
+			return none
+		}
+		a.active = true
+		a.cancel.reset()
+		a.submit.reset()
+		if submit {
+			a.active = false
+			return submitted
+		}
+		return started
+	}
+
+	switch {
+	case cancel:
+		a.active = false
+		a.start.reset()
+		return canceled
+	case submit:
+		a.active = false
+		a.start.reset()
+		return submitted
+	}
+	return none
+}
+
+// Trackers returns the scores of the leaderboard attempts in progress
+func Trackers() []Tracker {
+	mu.Lock()
+	defer mu.Unlock()
+	var list []Tracker
+	for _, a := range attempts {
+		if a.active {
+			list = append(list, Tracker{a.Title, formatScore(a.Format, a.score)})
+		}
+	}
+	return list
+}
+
+// systemRAM returns the memory of the running game
+func systemRAM() memory {
+	size := state.Core.GetMemorySize(libretro.MemorySystemRAM)
+	ptr := state.Core.GetMemoryData(libretro.MemorySystemRAM)
+	if ptr == nil || size == 0 {
+		return nil
+	}
+	return memory((*[1 << 30]byte)(ptr)[:size:size])
+}
+
+// Frame evaluates the leaderboards of the running game, it is called after
+// each frame run by the core
+func Frame() {
+	mu.Lock()
+	defer mu.Unlock()
+	if len(attempts) == 0 || state.Core == nil {
+		return
+	}
+	m := systemRAM()
+	if m == nil {
+		return
+	}
+
+	for _, a := range attempts {
+		switch a.frame(m) {
+		case started:
+			ntf.DisplayAndLog(ntf.Info, "Leaderboards", "Leaderboard attempt started: %s", a.Title)
+		case canceled:
+			ntf.DisplayAndLog(ntf.Warning, "Leaderboards", "Leaderboard attempt failed: %s", a.Title)
+		case submitted:
+			go submit(a.Leaderboard, a.score)
+		}
+	}
+}
+
+// Login fetches the connect token of the user. Only the token is stored, the
+// password is forgotten once the user is logged in.
+func Login(user, password string) (string, error) {
+	var resp struct {
+		Success bool   `json:"Success"`
+		Token   string `json:"Token"`
+		Error   string `json:"Error"`
+	}
+	form := url.Values{
+		"r": {"login"},
+		"u": {user},
+		"p": {password},
+	}
+	if err := post(form, &resp); err != nil {
+		return "", err
+	}
+	if !resp.Success {
+		return "", errors.New(resp.Error)
+	}
+	mu.Lock()
+	token = resp.Token
+	mu.Unlock()
+	return resp.Token, nil
+}
+
+// loadLeaderboards fetches the leaderboards of a game. Leaderboards using
+// conditions that aren't supported are skipped.
+func loadLeaderboards(id int, tok string) ([]*attempt, error) {
+	var resp struct {
+		Success   bool   `json:"Success"`
+		Error     string `json:"Error"`
+		PatchData struct {
+			Leaderboards []Leaderboard `json:"Leaderboards"`
+		} `json:"PatchData"`
+	}
+	form := url.Values{
+		"r": {"patch"},
+		"u": {settings.Current.AchievementsUsername},
+		"t": {tok},
+		"g": {fmt.Sprint(id)},
+	}
+	if err := post(form, &resp); err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, errors.New(resp.Error)
+	}
+
+	var list []*attempt
+	for _, l := range resp.PatchData.Leaderboards {
+		a, err := parseLeaderboard(l)
+		if err != nil {
+			ntf.DisplayAndLog(ntf.Warning, "Leaderboards", "Skipping %s: %v", l.Title, err)
+			continue
+		}
+		list = append(list, a)
+	}
+	return list, nil
+}
+
+// submit sends the score of a leaderboard attempt. Scores only count in
+// hardcore mode.
+func submit(l Leaderboard, score int) {
+	defer crash.Recover()
+	s := formatScore(l.Format, score)
+	if !settings.Current.AchievementsHardcore {
+		ntf.DisplayAndLog(ntf.Info, "Leaderboards", "%s: %s, not submitted outside of hardcore mode", l.Title, s)
+		return
+	}
+
+	mu.Lock()
+	tok, h := token, hash
+	mu.Unlock()
+	if tok == "" {
+		ntf.DisplayAndLog(ntf.Warning, "Leaderboards", "%s: %s, not submitted while offline", l.Title, s)
+		return
+	}
+
+	user := settings.Current.AchievementsUsername
+	sig := md5.Sum([]byte(fmt.Sprintf("%d%s%d", l.ID, user, score)))
+	var resp struct {
+		Success bool   `json:"Success"`
+		Error   string `json:"Error"`
+	}
+	form := url.Values{
+		"r": {"submitlbentry"},
+		"u": {user},
+		"t": {tok},
+		"i": {fmt.Sprint(l.ID)},
+		"s": {fmt.Sprint(score)},
+		"m": {h},
+		"v": {hex.EncodeToString(sig[:])},
+	}
+	if err := post(form, &resp); err != nil {
+		ntf.DisplayAndLog(ntf.Error, "Leaderboards", "%s: %v", l.Title, err)
+		return
+	}
+	if !resp.Success {
+		ntf.DisplayAndLog(ntf.Error, "Leaderboards", "%s: %s", l.Title, resp.Error)
+		return
+	}
+	ntf.DisplayAndLog(ntf.Success, "Leaderboards", "%s: %s submitted", l.Title, s)
+}
@@ -0,0 +1,61 @@
+package achievements
+
+import "testing"
+
+func Test_formatScore(t *testing.T) {
+	tests := []struct {
+		format string
+		v      int
+		want   string
+	}{
+		{"VALUE", 42, "42"},
+		{"SCORE", 42, "000042"},
+		{"FRAMES", 3690, "1:01.50"},
+		{"MILLISECS", 6150, "1:01.50"},
+		{"SECS", 61, "1:01"},
+		{"MINUTES", 61, "1h01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if got := formatScore(tt.format, tt.v); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_attempt_frame(t *testing.T) {
+	a, err := parseLeaderboard(Leaderboard{
+		Title: "Race",
+		Mem:   "STA:0xH0000=1::CAN:0xH0000=3::SUB:0xH0000=2::VAL:0xH0001",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames := []struct {
+		m     memory
+		event event
+	}{
+		{memory{0, 0}, none},
+		{memory{1, 5}, started},
+		{memory{1, 6}, none},
+		{memory{3, 7}, canceled},
+		{memory{1, 0}, started},
+		{memory{2, 9}, submitted},
+	}
+	for i, f := range frames {
+		if got := a.frame(f.m); got != f.event {
+			t.Errorf("frame %d: got %v, want %v", i, got, f.event)
+		}
+	}
+	if a.score != 9 {
+		t.Errorf("got score %v, want 9", a.score)
+	}
+
+	t.Run("Rejects incomplete leaderboards", func(t *testing.T) {
+		if _, err := parseLeaderboard(Leaderboard{Mem: "STA:0xH0000=1::VAL:0xH0001"}); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
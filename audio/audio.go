@@ -3,27 +3,38 @@
 package audio
 
 import (
-	"log"
+	"math"
 	"path/filepath"
+	"sync/atomic"
 	"time"
 	"unsafe"
 
+	"github.com/libretro/ludo/logs"
+	"github.com/libretro/ludo/record"
 	"github.com/libretro/ludo/settings"
 	"github.com/libretro/ludo/state"
 	"github.com/libretro/ludo/utils"
 	"golang.org/x/mobile/exp/audio/al"
 )
 
-const bufSize = 1024 * 8
+// BufferSizes are the sizes of audio buffers that can be set, in bytes.
+// Smaller buffers lower the latency but may cause crackles.
+var BufferSizes = []int{2048, 4096, 8192, 16384}
+
+var bufSize int32 = 1024 * 8
 
 var (
 	source     al.Source
 	buffers    []al.Buffer
 	rate       int32
 	numBuffers int32
-	tmpBuf     [bufSize]byte
+	tmpBuf     = make([]byte, bufSize)
 	tmpBufPtr  int32
 	resPtr     int32
+	res        *resampler
+	// skew holds the bits of the rate skew, set by the main thread and read
+	// by the core thread, see SetRateSkew
+	skew = math.Float64bits(1)
 )
 
 // Effects are sound effects
@@ -31,16 +42,31 @@ var Effects map[string]*Effect
 
 // SetVolume sets the audio volume
 func SetVolume(vol float32) {
-	source.SetGain(vol)
+	source.SetGain(gain(vol, offset, muted))
 }
 
 // Init initializes the audio device
 func Init() {
-	err := al.OpenDevice()
+	restore := func() {}
+	if settings.Current.AudioLatencyMode == "Exclusive" {
+		restore = useExclusive(settings.Current.AudioDevice)
+	}
+
+	err := openDevice(settings.Current.AudioDevice)
 	if err != nil {
-		log.Println(err)
+		logs.Warnf("OpenAL", "%v", err)
+		if err := openDevice(DefaultDevice); err != nil {
+			logs.Errorf("OpenAL", "%v", err)
+		}
 	}
+	restore()
 
+	loadEffects()
+	loadMusic()
+}
+
+// loadEffects loads the sound effects of the menu
+func loadEffects() {
 	Effects = map[string]*Effect{}
 
 	assets := settings.Current.AssetsDirectory
@@ -57,16 +83,59 @@ func Init() {
 func Reconfigure(r int32) {
 	rate = r
 	numBuffers = 4
+	if settings.Current.AudioLatencyMode == "Exclusive" {
+		numBuffers = 2
+	}
+	for _, s := range BufferSizes {
+		if s == settings.Current.AudioBufferSize {
+			bufSize = int32(s)
+		}
+	}
 
-	log.Printf("[OpenAL]: Using %v buffers of %v bytes.\n", numBuffers, bufSize)
+	logs.Infof("OpenAL", "Using %v buffers of %v bytes.", numBuffers, bufSize)
 
 	source = al.GenSources(1)[0]
 	buffers = al.GenBuffers(int(numBuffers))
 	resPtr = numBuffers
 	tmpBufPtr = 0
-	tmpBuf = [bufSize]byte{}
+	tmpBuf = make([]byte, bufSize)
+	res = newResampler()
+
+	applyGain()
+}
 
-	source.SetGain(settings.Current.AudioVolume)
+// SetRateSkew tells how much faster than its framerate the game runs, when
+// it follows a display slightly off its framerate. The samples are played as
+// much faster, so the audio buffer neither drains nor fills up.
+func SetRateSkew(s float64) {
+	if s <= 0 {
+		s = 1
+	}
+	atomic.StoreUint64(&skew, math.Float64bits(s))
+}
+
+// Pause stops playing the game audio. Playback restarts with the next
+// samples sent by the core.
+func Pause() {
+	al.PauseSources(source)
+}
+
+// SetDevice switches to another audio device. The sounds are loaded again
+// in the new device.
+func SetDevice(name string) error {
+	closeDevice()
+	err := openDevice(name)
+	if err != nil {
+		if err := openDevice(DefaultDevice); err != nil {
+			logs.Errorf("OpenAL", "%v", err)
+		}
+	}
+	loadEffects()
+	loadMusic()
+	if rate > 0 {
+		Reconfigure(rate)
+	}
+	return err
 }
 
 func min(a, b int32) int32 {
@@ -109,19 +178,48 @@ func fillInternalBuf(buf []byte) int32 {
 	return readSize
 }
 
+// fill returns how much of the audio buffers is waiting to be played,
+// from 0 to 1
+func fill() float64 {
+	queued := source.BuffersQueued() - source.BuffersProcessed()
+	total := float64(numBuffers * bufSize)
+	return (float64(queued*bufSize) + float64(tmpBufPtr)) / total
+}
+
+// resample converts the samples sent by the core to the output rate
+func resample(buf []byte) []byte {
+	if res == nil {
+		res = newResampler()
+	}
+	n := len(buf) / 2
+	if n == 0 || rate <= 0 {
+		return nil
+	}
+	in := (*[1 << 28]int16)(unsafe.Pointer(&buf[0]))[:n:n]
+
+	ratio := float64(outputRate) / (float64(rate) * math.Float64frombits(atomic.LoadUint64(&skew)))
+	ratio *= rateControl(fill(), float64(settings.Current.AudioRateControl))
+
+	out := res.process(in, ratio, settings.Current.AudioResampler)
+	if len(out) == 0 {
+		return nil
+	}
+	return (*[1 << 29]byte)(unsafe.Pointer(&out[0]))[: len(out)*2 : len(out)*2]
+}
+
 func write(buf []byte, size int32) int32 {
-	written := int32(0)
+	record.Audio(buf[:size])
 
-	if state.FastForward {
+	if state.FastForward || state.Paused {
 		return size
 	}
 
-	for size > 0 {
+	out := resample(buf[:size])
 
-		rc := fillInternalBuf(buf[written:])
+	for len(out) > 0 {
 
-		written += rc
-		size -= rc
+		rc := fillInternalBuf(out)
+		out = out[rc:]
 
 		if tmpBufPtr != bufSize {
 			break
@@ -129,7 +227,7 @@ func write(buf []byte, size int32) int32 {
 
 		buffer := alGetBuffer()
 
-		buffer.BufferData(al.FormatStereo16, tmpBuf[:], rate)
+		buffer.BufferData(al.FormatStereo16, tmpBuf[:], outputRate)
 		tmpBufPtr = 0
 		source.QueueBuffers(buffer)
 
@@ -138,7 +236,7 @@ func write(buf []byte, size int32) int32 {
 		}
 	}
 
-	return written
+	return size
 }
 
 // Sample renders a single audio frame.
@@ -0,0 +1,134 @@
+package audio
+
+/*
+#cgo darwin  LDFLAGS: -framework OpenAL
+#cgo linux   LDFLAGS: -lopenal
+#cgo windows LDFLAGS: -lOpenAL32
+
+#include <stdlib.h>
+#ifdef __APPLE__
+#include <OpenAL/alc.h>
+#else
+#include <AL/alc.h>
+#endif
+
+#ifndef ALC_ALL_DEVICES_SPECIFIER
+#define ALC_ALL_DEVICES_SPECIFIER 0x1013
+#endif
+
+#ifndef ALC_DEVICE_LATENCY_SOFT
+#define ALC_DEVICE_LATENCY_SOFT 0x1601
+#endif
+
+typedef void (*getInteger64v)(ALCdevice *device, ALCenum pname, ALCsizei size, long long *values);
+
+// deviceLatency returns the latency of the current device in nanoseconds,
+// or -1 if the device can't measure it
+static long long deviceLatency() {
+	ALCcontext *ctx = alcGetCurrentContext();
+	if (!ctx)
+		return -1;
+	ALCdevice *dev = alcGetContextsDevice(ctx);
+	if (!dev || !alcIsExtensionPresent(dev, "ALC_SOFT_device_clock"))
+		return -1;
+	getInteger64v get = (getInteger64v)alcGetProcAddress(dev, "alcGetInteger64vSOFT");
+	if (!get)
+		return -1;
+	long long latency = -1;
+	get(dev, ALC_DEVICE_LATENCY_SOFT, 1, &latency);
+	return latency;
+}
+*/
+import "C"
+import (
+	"errors"
+	"time"
+	"unsafe"
+
+	"golang.org/x/mobile/exp/audio/al"
+)
+
+// DefaultDevice is the name of the device chosen by the system
+const DefaultDevice = "Default"
+
+// device and context are set when a device other than the default one is
+// used. The al package only opens the default device, but sends its calls to
+// the current context, whichever device it belongs to.
+var (
+	device  *C.ALCdevice
+	context *C.ALCcontext
+)
+
+// Devices lists the names of the audio output devices
+func Devices() []string {
+	all := C.CString("ALC_ENUMERATE_ALL_EXT")
+	defer C.free(unsafe.Pointer(all))
+
+	param := C.ALCenum(C.ALC_DEVICE_SPECIFIER)
+	if C.alcIsExtensionPresent(nil, all) == C.ALC_TRUE {
+		param = C.ALC_ALL_DEVICES_SPECIFIER
+	}
+
+	// The names are separated by a null character, and the list ends with
+	// two of them
+	var names []string
+	p := unsafe.Pointer(C.alcGetString(nil, param))
+	for p != nil {
+		name := C.GoString((*C.char)(p))
+		if name == "" {
+			break
+		}
+		names = append(names, name)
+		p = unsafe.Pointer(uintptr(p) + uintptr(len(name)+1))
+	}
+	return names
+}
+
+// openDevice opens an audio device by name and makes it current
+func openDevice(name string) error {
+	if name == "" || name == DefaultDevice {
+		return al.OpenDevice()
+	}
+
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	dev := C.alcOpenDevice(cname)
+	if dev == nil {
+		return errors.New("cannot open the audio device " + name)
+	}
+	ctx := C.alcCreateContext(dev, nil)
+	if ctx == nil {
+		C.alcCloseDevice(dev)
+		return errors.New("cannot create an audio context")
+	}
+	if C.alcMakeContextCurrent(ctx) != C.ALC_TRUE {
+		C.alcDestroyContext(ctx)
+		C.alcCloseDevice(dev)
+		return errors.New("cannot make the audio context current")
+	}
+	device, context = dev, ctx
+	return nil
+}
+
+// closeDevice closes the audio device in use
+func closeDevice() {
+	if device == nil {
+		al.CloseDevice()
+		return
+	}
+	C.alcMakeContextCurrent(nil)
+	C.alcDestroyContext(context)
+	C.alcCloseDevice(device)
+	device, context = nil, nil
+}
+
+// DeviceLatency returns the latency of the audio device, measured by OpenAL
+// Soft. It is false with OpenAL implementations that can't measure it.
+func DeviceLatency() (time.Duration, bool) {
+	ns := C.deviceLatency()
+	if ns < 0 {
+		return 0, false
+	}
+	return time.Duration(ns), true
+}
@@ -50,8 +50,11 @@ func LoadEffect(filename string) (*Effect, error) {
 	return &e, nil
 }
 
-// PlayEffect plays a sound effect
+// PlayEffect plays a sound effect, unless the menu sounds are disabled
 func PlayEffect(e *Effect) {
+	if !settings.Current.MenuSounds {
+		return
+	}
 	al.PlaySources(e.source)
 }
 
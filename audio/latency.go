@@ -0,0 +1,149 @@
+package audio
+
+import (
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/libretro/ludo/logs"
+	ntf "github.com/libretro/ludo/notifications"
+)
+
+// LatencyModes are the ways of sharing the audio device. Exclusive asks
+// OpenAL Soft for small periods and for direct access to the device, WASAPI
+// exclusive mode on Windows, the ALSA hardware device on Linux, bypassing
+// the system mixer. It is applied when Ludo starts.
+var LatencyModes = []string{"Shared", "Exclusive"}
+
+// alsaDevice returns the ALSA hardware device of an OpenAL Soft device name,
+// like "HDA Intel PCH, ALC3246 Analog (CARD=PCH,DEV=0)". The first card is
+// used for the default device, and no device is forced for the devices of
+// other backends.
+func alsaDevice(name string) string {
+	if name == "" || name == DefaultDevice {
+		return "hw:0"
+	}
+	i := strings.LastIndex(name, "(CARD=")
+	if i < 0 || !strings.HasSuffix(name, ")") {
+		return ""
+	}
+	return "hw:" + name[i+1:len(name)-1]
+}
+
+// exclusiveConfig returns the OpenAL Soft driver and config used for the
+// exclusive mode on an OS, for the selected device. The warning tells the
+// user when the device can't be opened as expected.
+func exclusiveConfig(goos, device string) (driver, conf, warning string) {
+	conf = "[general]\nperiod_size = 256\nperiods = 2\n"
+	switch goos {
+	case "linux":
+		conf += "\n[alsa]\nmmap = true\n"
+		hw := alsaDevice(device)
+		switch hw {
+		case "":
+			warning = "The audio device isn't an ALSA card, it stays shared with the system mixer."
+		case "hw:0":
+			warning = "The default audio device is opened as the first sound card."
+		}
+		if hw != "" {
+			conf += "device = " + hw + "\n"
+		}
+		return "alsa", conf, warning
+	case "windows":
+		return "wasapi", conf + "\n[wasapi]\nexclusive-mode = true\n", ""
+	}
+	return "", conf, ""
+}
+
+// mergeConfig appends the exclusive config to a config of the user. OpenAL
+// Soft keeps the last value of a key, the sections can be repeated.
+func mergeConfig(user, conf string) string {
+	if user == "" {
+		return conf
+	}
+	if !strings.HasSuffix(user, "\n") {
+		user += "\n"
+	}
+	return user + "\n" + conf
+}
+
+// useExclusive configures OpenAL Soft for the exclusive mode on a device.
+// OpenAL Soft reads its config once, this has to be done before opening the
+// device. The config files of the user are still read, the one set with
+// ALSOFT_CONF is merged. The returned function restores the environment and
+// removes the config once the device is opened.
+func useExclusive(device string) func() {
+	restore := func() {}
+	driver, conf, warning := exclusiveConfig(runtime.GOOS, device)
+	if warning != "" {
+		ntf.DisplayAndLog(ntf.Warning, "OpenAL", warning)
+	}
+	prevConf, hasConf := os.LookupEnv("ALSOFT_CONF")
+	if hasConf {
+		if user, err := ioutil.ReadFile(prevConf); err == nil {
+			conf = mergeConfig(string(user), conf)
+		}
+	}
+
+	// TempFile creates the file readable by the user only
+	fd, err := ioutil.TempFile(xdg.RuntimeDir, "ludo-alsoft*.conf")
+	if err != nil {
+		logs.Warnf("OpenAL", "%v", err)
+		return restore
+	}
+	path := fd.Name()
+	_, err = fd.WriteString(conf)
+	if cerr := fd.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		logs.Warnf("OpenAL", "%v", err)
+		os.Remove(path)
+		return restore
+	}
+	prevDrivers, hasDrivers := os.LookupEnv("ALSOFT_DRIVERS")
+	os.Setenv("ALSOFT_CONF", path)
+	// A driver list of the user takes precedence
+	if driver != "" && !hasDrivers {
+		os.Setenv("ALSOFT_DRIVERS", driver)
+	}
+
+	return func() {
+		restoreEnv("ALSOFT_CONF", prevConf, hasConf)
+		restoreEnv("ALSOFT_DRIVERS", prevDrivers, hasDrivers)
+		os.Remove(path)
+	}
+}
+
+// restoreEnv sets an environment variable back to its previous value
+func restoreEnv(key, value string, set bool) {
+	if set {
+		os.Setenv(key, value)
+	} else {
+		os.Unsetenv(key)
+	}
+}
+
+// queueLatency is the time it takes to play the audio waiting in the queued
+// buffers and in the buffer being filled
+func queueLatency(queued, pending, size int32) time.Duration {
+	frames := int64(queued*size+pending) / 4
+	return time.Duration(frames) * time.Second / outputRate
+}
+
+// Buffered returns the duration of the audio sent by the core and waiting to
+// be played
+func Buffered() time.Duration {
+	queued := source.BuffersQueued() - source.BuffersProcessed()
+	return queueLatency(queued, tmpBufPtr, bufSize)
+}
+
+// Latency returns the delay between the moment the core sends audio and the
+// moment it is heard, and tells if the latency of the device is included.
+func Latency() (time.Duration, bool) {
+	d, ok := DeviceLatency()
+	return Buffered() + d, ok
+}
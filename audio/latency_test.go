@@ -0,0 +1,76 @@
+package audio
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_exclusiveConfig(t *testing.T) {
+	t.Run("Uses the ALSA hardware device on Linux", func(t *testing.T) {
+		driver, conf, warning := exclusiveConfig("linux", DefaultDevice)
+		if driver != "alsa" || !strings.Contains(conf, "device = hw:0") || warning == "" {
+			t.Errorf("got %v, %q, %q", driver, conf, warning)
+		}
+	})
+
+	t.Run("Uses the hardware device of the selected device", func(t *testing.T) {
+		_, conf, warning := exclusiveConfig("linux", "USB Audio, USB Audio (CARD=Device,DEV=0)")
+		if !strings.Contains(conf, "device = hw:CARD=Device,DEV=0") || warning != "" {
+			t.Errorf("got %q, %q", conf, warning)
+		}
+	})
+
+	t.Run("Doesn't force a device for other backends, and warns", func(t *testing.T) {
+		_, conf, warning := exclusiveConfig("linux", "Built-in Audio Analog Stereo")
+		if strings.Contains(conf, "device =") || warning == "" {
+			t.Errorf("got %q, %q", conf, warning)
+		}
+	})
+
+	t.Run("Uses WASAPI exclusive mode on Windows", func(t *testing.T) {
+		driver, conf, _ := exclusiveConfig("windows", DefaultDevice)
+		if driver != "wasapi" || !strings.Contains(conf, "exclusive-mode = true") {
+			t.Errorf("got %v, %q", driver, conf)
+		}
+	})
+
+	t.Run("Only asks for small periods elsewhere", func(t *testing.T) {
+		driver, conf, _ := exclusiveConfig("darwin", DefaultDevice)
+		if driver != "" || !strings.Contains(conf, "period_size = 256") {
+			t.Errorf("got %v, %q", driver, conf)
+		}
+	})
+}
+
+func Test_mergeConfig(t *testing.T) {
+	got := mergeConfig("[general]\nchannels = stereo", "[general]\nperiods = 2\n")
+	want := "[general]\nchannels = stereo\n\n[general]\nperiods = 2\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := mergeConfig("", "[general]\n"); got != "[general]\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func Test_queueLatency(t *testing.T) {
+	tests := []struct {
+		name    string
+		queued  int32
+		pending int32
+		size    int32
+		want    time.Duration
+	}{
+		{name: "Empty queue", size: 8192, want: 0},
+		{name: "One buffer", queued: 1, size: 9600, want: 50 * time.Millisecond},
+		{name: "Buffers and pending audio", queued: 2, pending: 4800, size: 9600, want: 125 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := queueLatency(tt.queued, tt.pending, tt.size); got != tt.want {
+				t.Errorf("queueLatency() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package audio
+
+import (
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	"github.com/libretro/ludo/logs"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+	wav "github.com/youpy/go-wav"
+	"golang.org/x/mobile/exp/audio/al"
+)
+
+// The music is streamed through a few buffers, refilled as they are played
+const (
+	musicBuffers = 4
+	musicBufSize = 16384
+)
+
+// duckGain is the share of the music volume kept in the menu while a game is
+// running
+const duckGain = 0.25
+
+// fadeSpeed is how fast the music fades in and out, in volume per second
+const fadeSpeed = 1
+
+var (
+	musicSource al.Source
+	musicFree   []al.Buffer // buffers that can be filled with samples
+	musicDir    string
+	musicTracks []string
+	musicTrack  int
+	musicFile   *os.File
+	musicReader *wav.Reader
+	musicFormat uint32
+	musicRate   int32
+	musicLevel  float32 // current gain of the music, fading to its target
+	musicReady  bool
+)
+
+// musicFiles lists the music tracks of a directory in a random order
+func musicFiles(dir string) []string {
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.wav"))
+	rand.Shuffle(len(paths), func(i, j int) {
+		paths[i], paths[j] = paths[j], paths[i]
+	})
+	return paths
+}
+
+// musicTarget is the gain the music fades to: the music volume in the menu,
+// lowered when a game is running behind the menu, and silent during play
+func musicTarget(vol float32, coreRunning, menuActive bool) float32 {
+	if !menuActive {
+		return 0
+	}
+	if coreRunning {
+		return vol * duckGain
+	}
+	return vol
+}
+
+// fade moves a gain towards its target
+func fade(level, target, dt float32) float32 {
+	step := fadeSpeed * dt
+	if level < target {
+		level += step
+		if level > target {
+			level = target
+		}
+	} else if level > target {
+		level -= step
+		if level < target {
+			level = target
+		}
+	}
+	return level
+}
+
+// loadMusic prepares the music source of the audio device
+func loadMusic() {
+	closeTrack()
+	musicSource = al.GenSources(1)[0]
+	musicFree = al.GenBuffers(musicBuffers)
+	musicLevel = 0
+	musicReady = true
+	scanMusic()
+}
+
+// scanMusic stops the music and lists the tracks of the music directory
+func scanMusic() {
+	al.StopSources(musicSource)
+	if n := musicSource.BuffersProcessed(); n > 0 {
+		done := make([]al.Buffer, n)
+		musicSource.UnqueueBuffers(done...)
+		musicFree = append(musicFree, done...)
+	}
+	closeTrack()
+	musicDir = settings.Current.MusicDirectory
+	musicTracks = musicFiles(musicDir)
+	musicTrack = -1
+}
+
+func closeTrack() {
+	if musicFile != nil {
+		musicFile.Close()
+		musicFile = nil
+		musicReader = nil
+	}
+}
+
+// openTrack opens the next music track. Only 16 bits tracks are supported.
+func openTrack() error {
+	closeTrack()
+	musicTrack = (musicTrack + 1) % len(musicTracks)
+
+	fd, err := os.Open(musicTracks[musicTrack])
+	if err != nil {
+		return err
+	}
+	r := wav.NewReader(fd)
+	f, err := r.Format()
+	if err != nil {
+		fd.Close()
+		return err
+	}
+	if f.BitsPerSample != 16 || f.NumChannels < 1 || f.NumChannels > 2 {
+		fd.Close()
+		return errors.New("unsupported format in " + musicTracks[musicTrack])
+	}
+
+	musicFormat = al.FormatStereo16
+	if f.NumChannels == 1 {
+		musicFormat = al.FormatMono16
+	}
+	musicRate = int32(f.SampleRate)
+	musicFile, musicReader = fd, r
+	return nil
+}
+
+// readMusic reads the next samples of the music, looping through the tracks
+func readMusic(buf []byte) int {
+	for tries := 0; tries <= len(musicTracks); tries++ {
+		if musicReader != nil {
+			n, _ := musicReader.Read(buf)
+			if n > 0 {
+				return n
+			}
+		}
+		if err := openTrack(); err != nil {
+			logs.Warnf("Music", "%v", err)
+		}
+	}
+	return 0
+}
+
+// refillMusic queues the samples of the buffers that were played
+func refillMusic() {
+	if n := musicSource.BuffersProcessed(); n > 0 {
+		done := make([]al.Buffer, n)
+		musicSource.UnqueueBuffers(done...)
+		musicFree = append(musicFree, done...)
+	}
+
+	buf := make([]byte, musicBufSize)
+	for len(musicFree) > 0 {
+		n := readMusic(buf)
+		if n == 0 {
+			return
+		}
+		b := musicFree[len(musicFree)-1]
+		musicFree = musicFree[:len(musicFree)-1]
+		b.BufferData(musicFormat, buf[:n], musicRate)
+		musicSource.QueueBuffers(b)
+	}
+}
+
+// UpdateMusic plays the music of the menu, from the music directory. It fades
+// out when a game starts, and is lowered while the menu is shown over a game.
+func UpdateMusic(dt float32) {
+	if !musicReady {
+		return
+	}
+	if musicDir != settings.Current.MusicDirectory {
+		scanMusic()
+	}
+	if len(musicTracks) == 0 {
+		return
+	}
+
+	target := musicTarget(settings.Current.MenuMusicVolume, state.CoreRunning, state.MenuActive)
+	musicLevel = fade(musicLevel, target, dt)
+	musicSource.SetGain(musicLevel)
+
+	if musicLevel == 0 {
+		if musicSource.State() == al.Playing {
+			al.PauseSources(musicSource)
+		}
+		return
+	}
+
+	refillMusic()
+	if musicSource.State() != al.Playing {
+		al.PlaySources(musicSource)
+	}
+}
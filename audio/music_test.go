@@ -0,0 +1,46 @@
+package audio
+
+import "testing"
+
+func Test_musicTarget(t *testing.T) {
+	tests := []struct {
+		name        string
+		coreRunning bool
+		menuActive  bool
+		want        float32
+	}{
+		{name: "Plays in the menu", menuActive: true, want: 0.8},
+		{name: "Ducks over a running game", coreRunning: true, menuActive: true, want: 0.2},
+		{name: "Is silent during play", coreRunning: true, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := musicTarget(0.8, tt.coreRunning, tt.menuActive)
+			if d := got - tt.want; d > 0.0001 || d < -0.0001 {
+				t.Errorf("musicTarget() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_fade(t *testing.T) {
+	tests := []struct {
+		name   string
+		level  float32
+		target float32
+		want   float32
+	}{
+		{name: "Fades in", level: 0, target: 1, want: 0.5},
+		{name: "Fades out", level: 1, target: 0, want: 0.5},
+		{name: "Stops at the target", level: 0.4, target: 0.6, want: 0.6},
+		{name: "Stays at the target", level: 0.3, target: 0.3, want: 0.3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fade(tt.level, tt.target, 0.5)
+			if d := got - tt.want; d > 0.0001 || d < -0.0001 {
+				t.Errorf("fade() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package audio
+
+import (
+	"math"
+)
+
+// Resamplers are the available resampling qualities, from the fastest to the
+// most accurate
+var Resamplers = []string{"Nearest", "Linear", "Sinc"}
+
+// outputRate is the rate the game audio is resampled to before being played
+const outputRate = 48000
+
+// sincTaps is the number of input frames used on each side of an output
+// frame by the sinc resampler
+const sincTaps = 8
+
+// sincPhases is the number of positions between two input frames the sinc
+// kernel is computed for, the positions in between are interpolated
+const sincPhases = 256
+
+// resampler converts interleaved stereo samples from one rate to another.
+// It keeps the input frames needed to compute the next output frames.
+type resampler struct {
+	hist  []int16    // input frames not consumed yet, with sincTaps frames of context
+	pos   float64    // position of the next output frame in hist, in frames
+	table *sincTable // kernel of the sinc resampler, for the cutoff in use
+}
+
+// sincTable holds the weights of the input frames around an output frame,
+// for sincPhases+1 positions between two input frames. Computing the kernel
+// for each tap is too slow for small devices.
+type sincTable struct {
+	fc float64
+	w  [][2 * sincTaps]float64
+}
+
+func newSincTable(fc float64) *sincTable {
+	t := &sincTable{fc: fc, w: make([][2 * sincTaps]float64, sincPhases+1)}
+	for p := range t.w {
+		x := float64(p) / sincPhases
+		for k := -sincTaps + 1; k <= sincTaps; k++ {
+			t.w[p][k+sincTaps-1] = lanczos(x-float64(k), fc)
+		}
+	}
+	return t
+}
+
+// weights interpolates the weights of the input frames for an output frame
+// at distance x of the previous input frame
+func (t *sincTable) weights(x float64, w *[2 * sincTaps]float64) {
+	x *= sincPhases
+	p := int(x)
+	if p >= sincPhases {
+		p = sincPhases - 1
+	}
+	f := x - float64(p)
+	a, b := &t.w[p], &t.w[p+1]
+	for k := range w {
+		w[k] = a[k] + (b[k]-a[k])*f
+	}
+}
+
+func newResampler() *resampler {
+	return &resampler{
+		hist: make([]int16, 2*sincTaps),
+		pos:  sincTaps,
+	}
+}
+
+// lanczos is the windowed sinc kernel, fc being the cutoff frequency
+// relative to the input rate
+func lanczos(x, fc float64) float64 {
+	if x == 0 {
+		return fc
+	}
+	if x <= -sincTaps || x >= sincTaps {
+		return 0
+	}
+	px := math.Pi * x
+	return math.Sin(px*fc) / px * math.Sin(px/sincTaps) / (px / sincTaps)
+}
+
+func clamp16(v float64) int16 {
+	if v > math.MaxInt16 {
+		return math.MaxInt16
+	}
+	if v < math.MinInt16 {
+		return math.MinInt16
+	}
+	return int16(math.Round(v))
+}
+
+// sample computes the value of channel c between input frames i and i+1, t
+// being the distance to frame i, w the weights of the sinc kernel
+func (r *resampler) sample(kind string, i int, t float64, c int, w *[2 * sincTaps]float64) int16 {
+	at := func(j int) float64 { return float64(r.hist[2*j+c]) }
+	switch kind {
+	case "Nearest":
+		if t < 0.5 {
+			return r.hist[2*i+c]
+		}
+		return r.hist[2*(i+1)+c]
+	case "Linear":
+		return clamp16(at(i) + (at(i+1)-at(i))*t)
+	default:
+		var v float64
+		for k := -sincTaps + 1; k <= sincTaps; k++ {
+			v += at(i+k) * w[k+sincTaps-1]
+		}
+		return clamp16(v)
+	}
+}
+
+// process resamples interleaved stereo samples, producing ratio output
+// frames for each input frame
+func (r *resampler) process(in []int16, ratio float64, kind string) []int16 {
+	r.hist = append(r.hist, in...)
+	frames := len(r.hist) / 2
+
+	// Lower the cutoff frequency when downsampling to avoid aliasing. The table
+	// is only rebuilt for a real change of rate, not for the rate control.
+	fc := math.Min(1, ratio)
+	sinc := kind != "Nearest" && kind != "Linear"
+	if sinc && (r.table == nil || math.Abs(r.table.fc-fc) > 0.01) {
+		r.table = newSincTable(fc)
+	}
+
+	var w [2 * sincTaps]float64
+	step := 1 / ratio
+	out := make([]int16, 0, int(float64(len(in))*ratio)+2)
+	for int(r.pos)+sincTaps < frames {
+		i := int(r.pos)
+		t := r.pos - float64(i)
+		if sinc {
+			r.table.weights(t, &w)
+		}
+		out = append(out, r.sample(kind, i, t, 0, &w), r.sample(kind, i, t, 1, &w))
+		r.pos += step
+	}
+
+	// Forget the consumed frames, but keep the context of the next ones
+	if drop := int(r.pos) - sincTaps; drop > 0 {
+		r.hist = append(r.hist[:0], r.hist[2*drop:]...)
+		r.pos -= float64(drop)
+	}
+
+	return out
+}
+
+// rateControl returns the correction applied to the resampling ratio for a
+// buffer filled at fill (0 is empty, 1 is full), delta being the maximum
+// correction. The audio is stretched when the buffer runs low and shrunk
+// when it fills up, so the playback never underruns nor lags behind.
+func rateControl(fill, delta float64) float64 {
+	if fill < 0 {
+		fill = 0
+	}
+	if fill > 1 {
+		fill = 1
+	}
+	return 1 + delta*(1-2*fill)
+}
@@ -0,0 +1,93 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+// sine returns n interleaved stereo frames of a sine wave
+func sine(n int, freq, rate float64) []int16 {
+	s := make([]int16, 2*n)
+	for i := 0; i < n; i++ {
+		v := int16(10000 * math.Sin(2*math.Pi*freq*float64(i)/rate))
+		s[2*i], s[2*i+1] = v, v
+	}
+	return s
+}
+
+func Test_resampler(t *testing.T) {
+	for _, kind := range Resamplers {
+		t.Run(kind+" produces the requested number of frames", func(t *testing.T) {
+			r := newResampler()
+			frames := 0
+			for i := 0; i < 100; i++ {
+				frames += len(r.process(sine(441, 440, 44100), 48000.0/44100.0, kind)) / 2
+			}
+			want := 48000
+			if frames < want-sincTaps*2 || frames > want {
+				t.Errorf("got %v frames, want about %v", frames, want)
+			}
+		})
+	}
+
+	t.Run("Linear interpolates between frames", func(t *testing.T) {
+		r := newResampler()
+		in := []int16{}
+		for i := 0; i < 2*sincTaps; i++ {
+			in = append(in, int16(100*i), int16(-100*i))
+		}
+		out := r.process(in, 2, "Linear")
+		// The second output frame is halfway between the first two input frames
+		got := out[2:4]
+		want := []int16{50, -50}
+		if got[0] != want[0] || got[1] != want[1] {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Sinc keeps the signal", func(t *testing.T) {
+		r := newResampler()
+		out := r.process(sine(4800, 1000, 48000), 1, "Sinc")
+		in := sine(4800, 1000, 48000)
+		for i := 100; i < 1000; i++ {
+			if d := math.Abs(float64(out[2*i]) - float64(in[2*i])); d > 2 {
+				t.Fatalf("frame %d: got %v, want %v", i, out[2*i], in[2*i])
+			}
+		}
+	})
+}
+
+func Test_sincTable(t *testing.T) {
+	table := newSincTable(0.9)
+	var w [2 * sincTaps]float64
+	for _, x := range []float64{0, 0.25, 0.3337, 0.999} {
+		table.weights(x, &w)
+		for k := -sincTaps + 1; k <= sincTaps; k++ {
+			want := lanczos(x-float64(k), 0.9)
+			if got := w[k+sincTaps-1]; math.Abs(got-want) > 1e-4 {
+				t.Errorf("x=%v, k=%d: got %v, want %v", x, k, got, want)
+			}
+		}
+	}
+}
+
+func Test_rateControl(t *testing.T) {
+	tests := []struct {
+		name  string
+		fill  float64
+		delta float64
+		want  float64
+	}{
+		{"Half full", 0.5, 0.005, 1},
+		{"Empty", 0, 0.005, 1.005},
+		{"Full", 1, 0.005, 0.995},
+		{"Disabled", 0, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rateControl(tt.fill, tt.delta); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("rateControl() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package audio
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/adrg/xdg"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/utils"
+	"github.com/pelletier/go-toml"
+)
+
+// MaxVolumeOffset is the largest change of volume a game can have compared
+// to the audio volume setting
+const MaxVolumeOffset = 0.5
+
+var (
+	muted  bool
+	offset float32
+)
+
+// volumeFile is the volume config of a game
+type volumeFile struct {
+	Offset float32 `toml:"volume_offset"`
+}
+
+// gain computes the gain of the game audio from the volume setting and the
+// offset of the game
+func gain(vol, offset float32, muted bool) float32 {
+	if muted {
+		return 0
+	}
+	g := vol + offset
+	if g < 0 {
+		return 0
+	}
+	if g > 1 {
+		return 1
+	}
+	return g
+}
+
+// applyGain sets the gain of the game audio source
+func applyGain() {
+	source.SetGain(gain(settings.Current.AudioVolume, offset, muted))
+}
+
+// Muted tells if the game audio is muted
+func Muted() bool {
+	return muted
+}
+
+// ToggleMute mutes or unmutes the game audio and returns the new state
+func ToggleMute() bool {
+	muted = !muted
+	applyGain()
+	return muted
+}
+
+// VolumeOffset returns the volume offset of the running game
+func VolumeOffset() float32 {
+	return offset
+}
+
+// SetVolumeOffset changes the volume offset of the running game
+func SetVolumeOffset(v float32) {
+	if v < -MaxVolumeOffset {
+		v = -MaxVolumeOffset
+	}
+	if v > MaxVolumeOffset {
+		v = MaxVolumeOffset
+	}
+	offset = v
+	applyGain()
+}
+
+// volumePath returns the location of the volume config of a game
+func volumePath(corePath string, crc uint32) string {
+	name := utils.FileName(corePath)
+	return filepath.Join(xdg.ConfigHome, "ludo", "volumes", name, fmt.Sprintf("%08X.toml", crc))
+}
+
+// LoadVolume reads the volume offset of a game, or resets it if the game has
+// none
+func LoadVolume(core string, crc uint32) {
+	offset = 0
+
+	b, err := ioutil.ReadFile(volumePath(core, crc))
+	if err != nil {
+		return
+	}
+	var f volumeFile
+	if err := toml.Unmarshal(b, &f); err != nil {
+		return
+	}
+	SetVolumeOffset(f.Offset)
+}
+
+// SaveVolume saves the volume offset of a game
+func SaveVolume(core string, crc uint32) error {
+	p := volumePath(core, crc)
+	if offset == 0 {
+		err := os.Remove(p)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	b, err := toml.Marshal(volumeFile{Offset: offset})
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(p), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(p, b, 0644)
+}
@@ -0,0 +1,28 @@
+package audio
+
+import "testing"
+
+func Test_gain(t *testing.T) {
+	tests := []struct {
+		name   string
+		vol    float32
+		offset float32
+		muted  bool
+		want   float32
+	}{
+		{name: "Uses the volume setting", vol: 0.5, want: 0.5},
+		{name: "Adds the game offset", vol: 0.5, offset: 0.2, want: 0.7},
+		{name: "Subtracts the game offset", vol: 0.5, offset: -0.2, want: 0.3},
+		{name: "Never goes below silence", vol: 0.2, offset: -0.5, want: 0},
+		{name: "Never goes above full volume", vol: 0.9, offset: 0.5, want: 1},
+		{name: "Mutes", vol: 0.5, offset: 0.2, muted: true, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := gain(tt.vol, tt.offset, tt.muted)
+			if d := got - tt.want; d > 0.0001 || d < -0.0001 {
+				t.Errorf("gain() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+// Package bench runs a core as fast as it can, without audio or video sync,
+// and measures how long its frames take. It helps comparing the performance
+// of cores on a given hardware and bisecting regressions.
+package bench
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/libretro/ludo/core"
+	"github.com/libretro/ludo/crash"
+	"github.com/libretro/ludo/input"
+	"github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/movie"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/video"
+)
+
+// Stats sums up the frame times of a benchmark. Speed is the average FPS
+// relative to the FPS of the core, zero if the core didn't tell it.
+type Stats struct {
+	Frames  int
+	Elapsed time.Duration
+	FPS     float64
+	Speed   float64
+	Min     time.Duration
+	Median  time.Duration
+	P99     time.Duration
+	Max     time.Duration
+}
+
+// Summarize computes the statistics of the frame times of a benchmark
+func Summarize(times []time.Duration, coreFPS float64) Stats {
+	s := Stats{Frames: len(times)}
+	if len(times) == 0 {
+		return s
+	}
+	sorted := make([]time.Duration, len(times))
+	copy(sorted, times)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	for _, t := range sorted {
+		s.Elapsed += t
+	}
+	s.Min = sorted[0]
+	s.Max = sorted[len(sorted)-1]
+	s.Median = sorted[len(sorted)/2]
+	s.P99 = sorted[(len(sorted)-1)*99/100]
+	if s.Elapsed > 0 {
+		s.FPS = float64(s.Frames) / s.Elapsed.Seconds()
+	}
+	if coreFPS > 0 {
+		s.Speed = s.FPS / coreFPS
+	}
+	return s
+}
+
+// String formats the statistics for the terminal
+func (s Stats) String() string {
+	ms := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Millisecond)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Frames:      %d\n", s.Frames)
+	fmt.Fprintf(&b, "Time:        %.3fs\n", s.Elapsed.Seconds())
+	fmt.Fprintf(&b, "Average FPS: %.1f", s.FPS)
+	if s.Speed > 0 {
+		fmt.Fprintf(&b, " (%.2fx real time)", s.Speed)
+	}
+	fmt.Fprintf(&b, "\nFrame time:  min %.3f ms, median %.3f ms, 99th %.3f ms, max %.3f ms\n",
+		ms(s.Min), ms(s.Median), ms(s.P99), ms(s.Max))
+	return b.String()
+}
+
+// Result is the outcome of a benchmark, with the performance counters the
+// core registered, if any
+type Result struct {
+	Stats
+	Counters []libretro.PerfCounter
+}
+
+// Run loads a core and a game and runs the given number of frames. The audio
+// is dropped and nothing is drawn, frames are only uploaded to the texture of
+// the game. The RAM of the game isn't saved, to leave the saves untouched.
+// The input of a movie is replayed if moviePath isn't empty.
+func Run(vid *video.Video, corePath, gamePath, moviePath string, frames int) (*Result, error) {
+	if frames <= 0 {
+		return nil, errors.New("the number of frames has to be positive")
+	}
+
+	core.Init(vid)
+	if err := core.Load(corePath); err != nil {
+		return nil, err
+	}
+	defer core.Unload()
+	if err := core.LoadGame(gamePath); err != nil {
+		return nil, err
+	}
+	state.Core.SetAudioSample(func(int16, int16) {})
+	state.Core.SetAudioSampleBatch(func(buf []byte, size int32) int32 {
+		return size * 4
+	})
+	if moviePath != "" {
+		m, err := movie.Open(moviePath)
+		if err != nil {
+			return nil, err
+		}
+		if err := movie.Replay(m); err != nil {
+			return nil, err
+		}
+	}
+
+	times := make([]time.Duration, 0, frames)
+	for len(times) < frames && !vid.Window.ShouldClose() {
+		input.StepMovie()
+		start := time.Now()
+		state.Core.Run()
+		if state.Core.FrameTimeCallback != nil {
+			state.Core.FrameTimeCallback.Callback(state.Core.FrameTimeCallback.Reference)
+		}
+		if state.Core.AudioCallback != nil {
+			state.Core.AudioCallback.Callback()
+		}
+		times = append(times, time.Since(start))
+	}
+
+	r := Result{
+		Stats:    Summarize(times, vid.Timing.FPS),
+		Counters: libretro.PerfCounters(),
+	}
+
+	state.CoreRunning = false
+	state.Core.UnloadGame()
+	crash.ClearGame()
+	return &r, nil
+}
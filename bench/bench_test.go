@@ -0,0 +1,45 @@
+package bench
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_Summarize(t *testing.T) {
+	ms := time.Millisecond
+	t.Run("Computes the frame times and the speed", func(t *testing.T) {
+		var times []time.Duration
+		for i := 1; i <= 100; i++ {
+			times = append(times, time.Duration(i)*ms/10)
+		}
+		got := Summarize(times, 60)
+		want := Stats{
+			Frames:  100,
+			Elapsed: 505 * ms,
+			FPS:     100 / 0.505,
+			Speed:   100 / 0.505 / 60,
+			Min:     ms / 10,
+			Median:  51 * ms / 10,
+			P99:     99 * ms / 10,
+			Max:     10 * ms,
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("Leaves the speed out when the core FPS is unknown", func(t *testing.T) {
+		got := Summarize([]time.Duration{10 * ms, 30 * ms}, 0)
+		if got.Speed != 0 || got.FPS != 50 || got.Median != 30*ms {
+			t.Errorf("got = %+v", got)
+		}
+	})
+
+	t.Run("Handles an empty run", func(t *testing.T) {
+		got := Summarize(nil, 60)
+		if !reflect.DeepEqual(got, Stats{}) {
+			t.Errorf("got = %+v", got)
+		}
+	})
+}
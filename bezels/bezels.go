@@ -0,0 +1,311 @@
+// Package bezels finds the decorative image, like a console bezel or a
+// handheld frame, drawn around the running game. A bezel is a PNG image with
+// a transparent cutout where the game is displayed. Bezels are looked up by
+// game name first, then by system name, anywhere in the bezels directory.
+package bezels
+
+import (
+	"errors"
+	"image"
+	_ "image/png" // bezels are PNG files
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cavaliercoder/grab"
+	"github.com/libretro/ludo/crash"
+	"github.com/libretro/ludo/mainthread"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/playlists"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+	"github.com/mholt/archiver/v3"
+	"github.com/pelletier/go-toml"
+)
+
+// Bezel is an image of size Width*Height, Cutout being the area where the
+// game is displayed, in pixels of the image
+type Bezel struct {
+	Path          string
+	Width, Height int
+	Cutout        image.Rectangle
+}
+
+var (
+	current *Bezel // bezel of the running game, if it has one
+	mu      sync.Mutex
+)
+
+// Current returns the bezel of the running game, nil if it has none
+func Current() *Bezel {
+	mu.Lock()
+	defer mu.Unlock()
+	return current
+}
+
+// Unload forgets the bezel of the game being closed
+func Unload() {
+	mu.Lock()
+	current = nil
+	mu.Unlock()
+}
+
+// cutoutFile describes the cutout of a bezel whose transparent area can't
+// be detected. It is stored next to the image with the toml extension.
+type cutoutFile struct {
+	X      int `toml:"x"`
+	Y      int `toml:"y"`
+	Width  int `toml:"width"`
+	Height int `toml:"height"`
+}
+
+// Active tells if a bezel is displayed around the game
+func Active() bool {
+	return settings.Current.BezelEnable && Current() != nil
+}
+
+// transparent tells if a pixel of the bezel lets the game be seen
+func transparent(img image.Image, x, y int) bool {
+	_, _, _, a := img.At(x, y).RGBA()
+	return a < 0x8000
+}
+
+// detectCutout finds the transparent area in the middle of an image, by
+// walking from the center to the borders
+func detectCutout(img image.Image) image.Rectangle {
+	b := img.Bounds()
+	cx, cy := (b.Min.X+b.Max.X)/2, (b.Min.Y+b.Max.Y)/2
+	if !transparent(img, cx, cy) {
+		return image.Rectangle{}
+	}
+
+	x0, x1, y0, y1 := cx, cx, cy, cy
+	for x0 > b.Min.X && transparent(img, x0-1, cy) {
+		x0--
+	}
+	for x1 < b.Max.X-1 && transparent(img, x1+1, cy) {
+		x1++
+	}
+	for y0 > b.Min.Y && transparent(img, cx, y0-1) {
+		y0--
+	}
+	for y1 < b.Max.Y-1 && transparent(img, cx, y1+1) {
+		y1++
+	}
+
+	return image.Rect(x0, y0, x1+1, y1+1).Sub(b.Min)
+}
+
+// open reads a bezel image and its cutout
+func open(path string) (*Bezel, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	img, _, err := image.Decode(fd)
+	if err != nil {
+		return nil, err
+	}
+
+	b := Bezel{
+		Path:   path,
+		Width:  img.Bounds().Dx(),
+		Height: img.Bounds().Dy(),
+	}
+
+	if data, err := ioutil.ReadFile(strings.TrimSuffix(path, filepath.Ext(path)) + ".toml"); err == nil {
+		var c cutoutFile
+		if err := toml.Unmarshal(data, &c); err != nil {
+			return nil, err
+		}
+		b.Cutout = image.Rect(c.X, c.Y, c.X+c.Width, c.Y+c.Height)
+	} else {
+		b.Cutout = detectCutout(img)
+	}
+
+	if b.Cutout.Empty() {
+		return nil, errors.New("no cutout in " + path)
+	}
+
+	return &b, nil
+}
+
+// systems returns the names of the systems a game may belong to: the
+// playlists containing it, then the playlists using the running core
+func systems(gamePath string, crc uint32, corePath string) []string {
+	var names []string
+
+	var csvs []string
+	for csv := range playlists.Playlists {
+		csvs = append(csvs, csv)
+	}
+	sort.Strings(csvs)
+	for _, csv := range csvs {
+		if playlists.Contains(csv, gamePath, crc) {
+			names = append(names, utils.FileName(csv))
+		}
+	}
+
+	var byCore []string
+	core := utils.FileName(corePath)
+	for system, c := range settings.Current.CoreForPlaylist {
+		if c == core {
+			byCore = append(byCore, system)
+		}
+	}
+	sort.Strings(byCore)
+
+	return append(names, byCore...)
+}
+
+// find looks for the images named after one of names in the bezels
+// directory, the first name taking precedence
+func find(dir string, names []string) string {
+	found := map[string]string{}
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.EqualFold(filepath.Ext(path), ".png") {
+			return nil
+		}
+		name := strings.ToLower(utils.FileName(path))
+		if _, ok := found[name]; !ok {
+			found[name] = path
+		}
+		return nil
+	})
+
+	for _, name := range names {
+		if path, ok := found[strings.ToLower(name)]; ok {
+			return path
+		}
+	}
+	return ""
+}
+
+// Load finds the bezel of a game
+func Load(gamePath string, crc uint32, corePath string) {
+	Unload()
+
+	names := append([]string{utils.FileName(gamePath)}, systems(gamePath, crc, corePath)...)
+	path := find(settings.Current.BezelsDirectory, names)
+	if path == "" {
+		return
+	}
+
+	b, err := open(path)
+	if err != nil {
+		ntf.DisplayAndLog(ntf.Warning, "Bezels", err.Error())
+		return
+	}
+	mu.Lock()
+	current = b
+	mu.Unlock()
+}
+
+// Rect returns where the bezel image is drawn in a framebuffer of size
+// fbw*fbh, keeping its aspect ratio
+func (b Bezel) Rect(fbw, fbh float32) (x, y, w, h float32) {
+	if b.Width == 0 || b.Height == 0 {
+		return 0, 0, fbw, fbh
+	}
+	aspect := float32(b.Width) / float32(b.Height)
+	h = fbh
+	w = fbh * aspect
+	if w > fbw {
+		w = fbw
+		h = fbw / aspect
+	}
+	return (fbw - w) / 2, (fbh - h) / 2, w, h
+}
+
+// CutoutRect returns the area of the framebuffer where the game is seen
+// through the bezel
+func (b Bezel) CutoutRect(fbw, fbh float32) (x, y, w, h float32) {
+	bx, by, bw, bh := b.Rect(fbw, fbh)
+	if b.Width == 0 || b.Height == 0 {
+		return bx, by, bw, bh
+	}
+	sx := bw / float32(b.Width)
+	sy := bh / float32(b.Height)
+	return bx + float32(b.Cutout.Min.X)*sx,
+		by + float32(b.Cutout.Min.Y)*sy,
+		float32(b.Cutout.Dx()) * sx,
+		float32(b.Cutout.Dy()) * sy
+}
+
+// downloading is set while Download runs, it is accessed atomically
+var downloading int32
+
+// Download fetches an archive of bezels and extracts it in the bezels
+// directory
+func Download(url string) {
+	defer crash.Recover()
+	if !atomic.CompareAndSwapInt32(&downloading, 0, 1) {
+		ntf.DisplayAndLog(ntf.Error, "Bezels", "A download is already in progress")
+		return
+	}
+	defer atomic.StoreInt32(&downloading, 0)
+
+	n := ntf.DisplayAndLog(ntf.Info, "Bezels", "Downloading bezels 0%%")
+
+	fd, err := ioutil.TempFile("", "ludo-bezels*.zip")
+	if err != nil {
+		n.Update(ntf.Error, err.Error())
+		return
+	}
+	tmp := fd.Name()
+	fd.Close()
+	defer os.Remove(tmp)
+
+	req, err := grab.NewRequest(tmp, url)
+	if err != nil {
+		n.Update(ntf.Error, err.Error())
+		return
+	}
+	req.NoResume = true
+
+	resp := grab.NewClient().Do(req)
+
+	t := time.NewTicker(500 * time.Millisecond)
+	defer t.Stop()
+
+Loop:
+	for {
+		select {
+		case <-t.C:
+			n.Update(ntf.Info, "Downloading bezels %.0f%%%%", 100*resp.Progress())
+		case <-resp.Done:
+			break Loop
+		}
+	}
+
+	if err := resp.Err(); err != nil {
+		n.Update(ntf.Error, err.Error())
+		return
+	}
+
+	n.Update(ntf.Info, "Extracting bezels")
+	un := archiver.Zip{
+		OverwriteExisting: true,
+		MkdirAll:          true,
+	}
+	if err := un.Unarchive(tmp, settings.Current.BezelsDirectory); err != nil {
+		n.Update(ntf.Error, err.Error())
+		return
+	}
+
+	// The running game is reloaded on the main thread, between two frames
+	mainthread.Post(func() {
+		if state.CoreRunning {
+			Load(state.GamePath, state.GameCRC, state.CorePath)
+		}
+	})
+	n.Update(ntf.Success, "Bezels downloaded.")
+}
@@ -0,0 +1,57 @@
+package bezels
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func Test_detectCutout(t *testing.T) {
+	t.Run("Finds the transparent area", func(t *testing.T) {
+		img := image.NewNRGBA(image.Rect(0, 0, 16, 9))
+		for y := 0; y < 9; y++ {
+			for x := 0; x < 16; x++ {
+				if x < 3 || x >= 13 || y < 1 || y >= 8 {
+					img.Set(x, y, color.Black)
+				}
+			}
+		}
+		got := detectCutout(img)
+		want := image.Rect(3, 1, 13, 8)
+		if got != want {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Returns an empty cutout for opaque images", func(t *testing.T) {
+		img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+		for i := range img.Pix {
+			img.Pix[i] = 0xff
+		}
+		if got := detectCutout(img); !got.Empty() {
+			t.Errorf("got %v, want an empty rectangle", got)
+		}
+	})
+}
+
+func TestBezel_CutoutRect(t *testing.T) {
+	b := Bezel{Width: 1920, Height: 1080, Cutout: image.Rect(240, 0, 1680, 1080)}
+
+	tests := []struct {
+		name       string
+		fbw, fbh   float32
+		x, y, w, h float32
+	}{
+		{"Same size", 1920, 1080, 240, 0, 1440, 1080},
+		{"Half size", 960, 540, 120, 0, 720, 540},
+		{"Taller window", 960, 1080, 120, 270, 720, 540},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, y, w, h := b.CutoutRect(tt.fbw, tt.fbh)
+			if x != tt.x || y != tt.y || w != tt.w || h != tt.h {
+				t.Errorf("got %v %v %v %v, want %v %v %v %v", x, y, w, h, tt.x, tt.y, tt.w, tt.h)
+			}
+		})
+	}
+}
@@ -0,0 +1,385 @@
+// Package buildbot downloads the libretro cores from the libretro buildbot,
+// and the game databases used by the scanner. The recommended cores are the
+// default cores of the playlists.
+package buildbot
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cavaliercoder/grab"
+	"github.com/libretro/ludo/crash"
+	"github.com/libretro/ludo/logs"
+	"github.com/libretro/ludo/mainthread"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/scanner"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+	"github.com/mholt/archiver/v3"
+)
+
+const (
+	nightlyURL  = "http://buildbot.libretro.com/nightly"
+	databaseURL = "https://github.com/kivutar/ludo-database/archive/refs/heads/master.zip"
+	// datsURL serves the dats of the game database one by one
+	datsURL = "https://raw.githubusercontent.com/kivutar/ludo-database/master"
+)
+
+// downloading is set while cores or databases are downloaded, it is only
+// accessed atomically
+var downloading int32
+
+// archs are the buildbot names of the cpu architectures
+var archs = map[string]string{
+	"386":   "x86",
+	"amd64": "x86_64",
+	"arm":   "armv7-neon-hf",
+	"arm64": "arm64",
+}
+
+// platformURL returns the location of the nightly cores of a platform, or ""
+// if the buildbot doesn't provide cores for it
+func platformURL(goos, goarch string) string {
+	arch, ok := archs[goarch]
+	if !ok {
+		return ""
+	}
+	switch goos {
+	case "darwin":
+		return nightlyURL + "/apple/osx/" + arch + "/latest"
+	case "linux":
+		if arch == "arm64" {
+			arch = "aarch64"
+		}
+		return nightlyURL + "/linux/" + arch + "/latest"
+	case "windows":
+		return nightlyURL + "/windows/" + arch + "/latest"
+	}
+	return ""
+}
+
+// Recommended returns the names of the default cores of the playlists
+func Recommended() []string {
+	var list []string
+	for _, c := range settings.Current.CoreForPlaylist {
+		if !utils.StringInSlice(c, list) {
+			list = append(list, c)
+		}
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Downloading tells if cores or databases are being downloaded
+func Downloading() bool {
+	return atomic.LoadInt32(&downloading) == 1
+}
+
+// begin marks the start of a download, it returns false if one is already in
+// progress
+func begin() bool {
+	if !atomic.CompareAndSwapInt32(&downloading, 0, 1) {
+		ntf.DisplayAndLog(ntf.Error, "Buildbot", "A download is already in progress")
+		return false
+	}
+	return true
+}
+
+// end marks the end of a download
+func end() {
+	atomic.StoreInt32(&downloading, 0)
+}
+
+// fetch downloads a file, reporting the progress in a notification
+func fetch(dest, url string, n *ntf.Notification, msg string) error {
+	req, err := grab.NewRequest(dest, url)
+	if err != nil {
+		return err
+	}
+
+	resp := grab.NewClient().Do(req)
+
+	t := time.NewTicker(500 * time.Millisecond)
+	defer t.Stop()
+
+Loop:
+	for {
+		select {
+		case <-t.C:
+			n.Update(ntf.Info, "%s %.0f%%%%", msg, 100*resp.Progress())
+		case <-resp.Done:
+			break Loop
+		}
+	}
+
+	return resp.Err()
+}
+
+// unzip extracts an archive in a directory
+func unzip(archive, dir string) error {
+	un := archiver.Zip{
+		OverwriteExisting: true,
+		MkdirAll:          true,
+	}
+	return un.Unarchive(archive, dir)
+}
+
+// installCore extracts the archive of a core next to the cores, then renames
+// the core into place, so that a failed download never leaves a broken core
+// behind
+func installCore(archive, dir, name string) error {
+	stage, err := ioutil.TempDir(dir, ".download")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stage)
+	if err := unzip(archive, stage); err != nil {
+		return err
+	}
+	return os.Rename(filepath.Join(stage, name), filepath.Join(dir, name))
+}
+
+// DownloadCores downloads the cores that are missing from the cores
+// directory. It blocks, it is meant to be run in a goroutine.
+func DownloadCores(cores []string) {
+	defer crash.Recover()
+	download(cores, false, "Downloading cores")
+}
+
+// download fetches cores from the buildbot, the installed ones are only
+// replaced if force is set. The builds of the cores are remembered to check
+// their updates. It returns the cores downloaded.
+func download(cores []string, force bool, label string) []string {
+	if !begin() {
+		return nil
+	}
+	defer end()
+
+	base := platformURL(runtime.GOOS, runtime.GOARCH)
+	if base == "" {
+		ntf.DisplayAndLog(ntf.Error, "Buildbot", "No cores available for %s/%s", runtime.GOOS, runtime.GOARCH)
+		return nil
+	}
+
+	n := ntf.DisplayAndLog(ntf.Info, "Buildbot", label)
+	dir := settings.Current.CoresDirectory
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		n.Update(ntf.Error, err.Error())
+		return nil
+	}
+
+	index, _ := fetchIndex()
+	installed := loadInstalled(installedPath())
+
+	var failed int
+	var done []string
+	for i, c := range cores {
+		name := c + utils.CoreExt()
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil && !force {
+			continue
+		}
+		tmp := filepath.Join(os.TempDir(), name+".zip")
+		msg := fmt.Sprintf("%s %d/%d", label, i+1, len(cores))
+		err := fetch(tmp, base+"/"+name+".zip", n, msg)
+		if err == nil {
+			err = installCore(tmp, dir, name)
+		}
+		os.Remove(tmp)
+		if err != nil {
+			ntf.DisplayAndLog(ntf.Warning, "Buildbot", "%s: %v", c, err)
+			failed++
+			continue
+		}
+		done = append(done, c)
+		if b, ok := index[c]; ok {
+			installed[c] = b
+		}
+	}
+
+	if err := saveInstalled(installedPath(), installed); err != nil {
+		logs.Warnf("Buildbot", "Could not save the builds of the cores: %v", err)
+	}
+
+	if failed > 0 {
+		n.Update(ntf.Warning, "%d cores could not be downloaded.", failed)
+		return done
+	}
+	n.Update(ntf.Success, "Cores downloaded.")
+	return done
+}
+
+// DownloadDatabase downloads the game databases in the database directory
+// and reloads them. It blocks, it is meant to be run in a goroutine.
+func DownloadDatabase() {
+	defer crash.Recover()
+	if !begin() {
+		return
+	}
+	defer end()
+
+	n := ntf.DisplayAndLog(ntf.Info, "Buildbot", "Downloading databases 0%%")
+
+	tmp := filepath.Join(os.TempDir(), "ludo-database.zip")
+	defer os.Remove(tmp)
+	if err := fetch(tmp, databaseURL, n, "Downloading databases"); err != nil {
+		n.Update(ntf.Error, err.Error())
+		return
+	}
+
+	n.Update(ntf.Info, "Extracting databases")
+	out, err := ioutil.TempDir("", "ludo-database")
+	if err != nil {
+		n.Update(ntf.Error, err.Error())
+		return
+	}
+	defer os.RemoveAll(out)
+	if err := unzip(tmp, out); err != nil {
+		n.Update(ntf.Error, err.Error())
+		return
+	}
+	if err := install(out, settings.Current.DatabaseDirectory); err != nil {
+		n.Update(ntf.Error, err.Error())
+		return
+	}
+
+	db, err := scanner.LoadLayeredDB(settings.Current.DatabaseDirectory, settings.Current.UserDatsDirectory)
+	if err != nil {
+		n.Update(ntf.Error, err.Error())
+		return
+	}
+	// The scanner and the menu read the database on the main thread
+	mainthread.Post(func() {
+		state.DB = db
+		n.Update(ntf.Success, "Databases downloaded.")
+	})
+}
+
+// datURL returns the location of the dat of a system in the game database
+func datURL(system string) string {
+	return datsURL + "/" + url.PathEscape(system+".dat")
+}
+
+// DownloadDats downloads the dats of some systems in the database directory,
+// like the ones of the games lacking metadata, and reloads the databases. It
+// blocks, it is meant to be run in a goroutine.
+func DownloadDats(systems []string) {
+	defer crash.Recover()
+	if !begin() {
+		return
+	}
+	defer end()
+
+	n := ntf.DisplayAndLog(ntf.Info, "Buildbot", "Downloading databases 0%%")
+	if err := os.MkdirAll(settings.Current.DatabaseDirectory, os.ModePerm); err != nil {
+		n.Update(ntf.Error, err.Error())
+		return
+	}
+	updated := 0
+	for _, system := range systems {
+		dest := filepath.Join(settings.Current.DatabaseDirectory, system+".dat")
+		// The download isn't written over the previous dat until it succeeds
+		tmp := dest + ".part"
+		if err := fetch(tmp, datURL(system), n, "Downloading "+system); err != nil {
+			logs.Warnf("Buildbot", "Can't download the database of %s: %v", system, err)
+			os.Remove(tmp)
+			continue
+		}
+		if err := os.Rename(tmp, dest); err != nil {
+			n.Update(ntf.Error, err.Error())
+			os.Remove(tmp)
+			return
+		}
+		updated++
+	}
+
+	db, err := scanner.LoadLayeredDB(settings.Current.DatabaseDirectory, settings.Current.UserDatsDirectory)
+	if err != nil {
+		n.Update(ntf.Error, err.Error())
+		return
+	}
+	mainthread.Post(func() {
+		state.DB = db
+		n.Update(ntf.Success, "%d of %d databases downloaded.", updated, len(systems))
+	})
+}
+
+// DownloadHacks downloads the dats of ROM hacks and translations in the user
+// dats directory and reloads the databases. The zip is kept as is, each of its
+// dats is named after a system. It blocks, it is meant to be run in a
+// goroutine.
+func DownloadHacks() {
+	defer crash.Recover()
+	if settings.Current.HackPackURL == "" {
+		ntf.DisplayAndLog(ntf.Error, "Buildbot", "Set the Hacks Database URL in the settings first.")
+		return
+	}
+	if !begin() {
+		return
+	}
+	defer end()
+
+	n := ntf.DisplayAndLog(ntf.Info, "Buildbot", "Downloading hacks 0%%")
+
+	if err := os.MkdirAll(settings.Current.UserDatsDirectory, os.ModePerm); err != nil {
+		n.Update(ntf.Error, err.Error())
+		return
+	}
+	dest := filepath.Join(settings.Current.UserDatsDirectory, "hacks.zip")
+	// The download isn't written over the previous pack until it succeeds
+	tmp := dest + ".part"
+	defer os.Remove(tmp)
+	if err := fetch(tmp, settings.Current.HackPackURL, n, "Downloading hacks"); err != nil {
+		n.Update(ntf.Error, err.Error())
+		return
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		n.Update(ntf.Error, err.Error())
+		return
+	}
+
+	db, err := scanner.LoadLayeredDB(settings.Current.DatabaseDirectory, settings.Current.UserDatsDirectory)
+	if err != nil {
+		n.Update(ntf.Error, err.Error())
+		return
+	}
+	mainthread.Post(func() {
+		state.DB = db
+		n.Update(ntf.Success, "Hacks downloaded, scan your games again to recognize them.")
+	})
+}
+
+// install copies the dat files of an extracted archive to dir. The archive
+// has a top level folder named after the repository, it is left out.
+func install(extracted, dir string) error {
+	return filepath.Walk(extracted, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != ".dat" {
+			return err
+		}
+		rel, err := filepath.Rel(extracted, path)
+		if err != nil {
+			return err
+		}
+		parts := strings.SplitN(filepath.ToSlash(rel), "/", 2)
+		if len(parts) < 2 {
+			return nil
+		}
+		dest := filepath.Join(dir, filepath.FromSlash(parts[1]))
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), os.ModePerm); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(dest, b, 0644)
+	})
+}
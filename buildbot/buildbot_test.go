@@ -0,0 +1,262 @@
+package buildbot
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/libretro/ludo/settings"
+)
+
+func Test_platformURL(t *testing.T) {
+	tests := []struct {
+		goos, goarch string
+		want         string
+	}{
+		{"linux", "amd64", nightlyURL + "/linux/x86_64/latest"},
+		{"linux", "arm", nightlyURL + "/linux/armv7-neon-hf/latest"},
+		{"linux", "arm64", nightlyURL + "/linux/aarch64/latest"},
+		{"darwin", "arm64", nightlyURL + "/apple/osx/arm64/latest"},
+		{"windows", "386", nightlyURL + "/windows/x86/latest"},
+		{"freebsd", "amd64", ""},
+		{"linux", "mips", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.goos+"/"+tt.goarch, func(t *testing.T) {
+			if got := platformURL(tt.goos, tt.goarch); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecommended(t *testing.T) {
+	settings.Current.CoreForPlaylist = map[string]string{
+		"Sega - Mega Drive - Genesis": "genesis_plus_gx_libretro",
+		"Sega - Master System":        "genesis_plus_gx_libretro",
+		"Nintendo - Game Boy":         "gambatte_libretro",
+	}
+	defer func() { settings.Current.CoreForPlaylist = nil }()
+
+	want := []string{"gambatte_libretro", "genesis_plus_gx_libretro"}
+	if got := Recommended(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func Test_install(t *testing.T) {
+	src, err := ioutil.TempDir("", "ludo-extracted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+	dst, err := ioutil.TempDir("", "ludo-database")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+
+	files := map[string]string{
+		"ludo-database-master/Nintendo - Game Boy.dat": "gb",
+		"ludo-database-master/esrb/Sega - Saturn.dat":  "esrb",
+		"ludo-database-master/README.md":               "readme",
+	}
+	for name, content := range files {
+		p := filepath.Join(src, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), os.ModePerm)
+		ioutil.WriteFile(p, []byte(content), 0644)
+	}
+
+	if err := install(src, dst); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		"Nintendo - Game Boy.dat": "gb",
+		"esrb/Sega - Saturn.dat":  "esrb",
+	} {
+		b, err := ioutil.ReadFile(filepath.Join(dst, filepath.FromSlash(name)))
+		if err != nil || string(b) != want {
+			t.Errorf("%s: got %q, %v", name, b, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dst, "README.md")); err == nil {
+		t.Error("README.md should not be installed")
+	}
+}
+
+func Test_installCore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ludo-cores")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	core := filepath.Join(dir, "snes9x_libretro.so")
+	ioutil.WriteFile(core, []byte("old"), 0644)
+
+	archive := filepath.Join(dir, "snes9x_libretro.so.zip")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, _ := zw.Create("snes9x_libretro.so")
+	w.Write([]byte("new"))
+	zw.Close()
+	f.Close()
+
+	if err := installCore(filepath.Join(dir, "broken.zip"), dir, "snes9x_libretro.so"); err == nil {
+		t.Error("installed a missing archive")
+	}
+	if b, _ := ioutil.ReadFile(core); string(b) != "old" {
+		t.Errorf("got %q, a failed install should keep the core", b)
+	}
+
+	if err := installCore(archive, dir, "snes9x_libretro.so"); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(core); string(b) != "new" {
+		t.Errorf("got %q, want the new core", b)
+	}
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 2 {
+		t.Errorf("got %d files, the staging folder should be removed", len(files))
+	}
+}
+
+func Test_parseIndex(t *testing.T) {
+	index := parseIndex([]byte(`2024-01-15 4e1c1f6a snes9x_libretro.so.zip
+2024-01-10 0badf00d mgba_libretro.so.zip
+2024-01-12 12345678 mgba_libretro.dll.zip
+garbage
+`), ".so")
+	want := map[string]Build{
+		"snes9x_libretro": {Name: "snes9x_libretro", Date: "2024-01-15", CRC: "4e1c1f6a"},
+		"mgba_libretro":   {Name: "mgba_libretro", Date: "2024-01-10", CRC: "0badf00d"},
+	}
+	if !reflect.DeepEqual(index, want) {
+		t.Errorf("got %v, want %v", index, want)
+	}
+}
+
+func Test_pending(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ludo-cores")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"snes9x_libretro.so", "mgba_libretro.so", "gambatte_libretro.so", "local_libretro.so", "notes.txt"} {
+		ioutil.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+	old := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	os.Chtimes(filepath.Join(dir, "gambatte_libretro.so"), old, old)
+
+	index := map[string]Build{
+		"snes9x_libretro":   {Name: "snes9x_libretro", Date: "2024-01-15", CRC: "aaaa"},
+		"mgba_libretro":     {Name: "mgba_libretro", Date: "2024-01-15", CRC: "bbbb"},
+		"gambatte_libretro": {Name: "gambatte_libretro", Date: "2024-01-15", CRC: "cccc"},
+	}
+	installed := map[string]Build{
+		"snes9x_libretro": {Name: "snes9x_libretro", Date: "2024-01-02", CRC: "0000"},
+		"mgba_libretro":   {Name: "mgba_libretro", Date: "2024-01-15", CRC: "bbbb"},
+	}
+
+	got := pending(dir, ".so", index, installed)
+	want := []Update{
+		{Core: "gambatte_libretro", Since: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Latest: index["gambatte_libretro"]},
+		{Core: "snes9x_libretro", Since: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), Latest: index["snes9x_libretro"]},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func Test_installed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ludo-cores")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "cores.csv")
+	m := map[string]Build{
+		"snes9x_libretro": {Name: "snes9x_libretro", Date: "2024-01-15", CRC: "aaaa"},
+	}
+	if err := saveInstalled(path, m); err != nil {
+		t.Fatal(err)
+	}
+	if got := loadInstalled(path); !reflect.DeepEqual(got, m) {
+		t.Errorf("got %v, want %v", got, m)
+	}
+	if got := loadInstalled(filepath.Join(dir, "missing.csv")); len(got) != 0 {
+		t.Errorf("got %v for a missing file", got)
+	}
+}
+
+func TestForget(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ludo-cores")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(home string) { xdg.DataHome = home }(xdg.DataHome)
+	xdg.DataHome = dir
+
+	m := map[string]Build{
+		"snes9x_libretro": {Name: "snes9x_libretro", Date: "2024-01-15", CRC: "aaaa"},
+		"mgba_libretro":   {Name: "mgba_libretro", Date: "2024-01-16", CRC: "bbbb"},
+	}
+	if err := saveInstalled(installedPath(), m); err != nil {
+		t.Fatal(err)
+	}
+	if err := Forget("snes9x_libretro"); err != nil {
+		t.Fatal(err)
+	}
+	got := loadInstalled(installedPath())
+	if _, ok := got["snes9x_libretro"]; ok || len(got) != 1 {
+		t.Errorf("got %v, want the other core only", got)
+	}
+}
+
+func Test_datURL(t *testing.T) {
+	want := "https://raw.githubusercontent.com/kivutar/ludo-database/master/Nintendo%20-%20Game%20Boy.dat"
+	if got := datURL("Nintendo - Game Boy"); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func Test_changesURL(t *testing.T) {
+	since := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	for core, repo := range map[string]string{
+		"genesis_plus_gx_libretro": "Genesis-Plus-GX",
+		"snes9x_libretro":          "snes9x",
+	} {
+		want := "https://api.github.com/repos/libretro/" + repo + "/commits?per_page=20&since=2024-01-02T00:00:00Z"
+		if got := changesURL(core, since); got != want {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func Test_parseCommits(t *testing.T) {
+	got, err := parseCommits(strings.NewReader(`[
+		{"commit": {"message": "Fix the audio sync\n\nLong description"}},
+		{"commit": {"message": ""}},
+		{"commit": {"message": "Update the core info"}}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Fix the audio sync", "Update the core info"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,335 @@
+package buildbot
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/libretro/ludo/crash"
+	"github.com/libretro/ludo/logs"
+	"github.com/libretro/ludo/mainthread"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/utils"
+)
+
+// Build is a core build of the buildbot. Name is the file name of the core,
+// without extension.
+type Build struct {
+	Name string
+	Date string // day of the build, like 2006-01-02
+	CRC  string
+}
+
+// Update is an installed core with a newer build on the buildbot. Since is
+// the day of the installed build.
+type Update struct {
+	Core   string
+	Since  time.Time
+	Latest Build
+}
+
+// CheckIntervals are the choices of the background update check
+var CheckIntervals = []string{"Off", "Daily", "Weekly"}
+
+var intervals = map[string]time.Duration{
+	"Daily":  24 * time.Hour,
+	"Weekly": 7 * 24 * time.Hour,
+}
+
+var (
+	mu        sync.Mutex
+	available []Update
+	client    = &http.Client{Timeout: 30 * time.Second}
+)
+
+// repos are the GitHub repositories of the cores not named like their file
+var repos = map[string]string{
+	"bluemsx":             "blueMSX-libretro",
+	"fbneo":               "FBNeo",
+	"fceumm":              "libretro-fceumm",
+	"gambatte":            "gambatte-libretro",
+	"genesis_plus_gx":     "Genesis-Plus-GX",
+	"handy":               "libretro-handy",
+	"mame2003_plus":       "mame2003-plus-libretro",
+	"mednafen_lynx":       "beetle-lynx-libretro",
+	"mednafen_ngp":        "beetle-ngp-libretro",
+	"mednafen_pce_fast":   "beetle-pce-fast-libretro",
+	"mednafen_psx":        "beetle-psx-libretro",
+	"mednafen_psx_hw":     "beetle-psx-libretro",
+	"mednafen_saturn":     "beetle-saturn-libretro",
+	"mednafen_supergrafx": "beetle-supergrafx-libretro",
+	"mednafen_vb":         "beetle-vb-libretro",
+	"mednafen_wswan":      "beetle-wswan-libretro",
+	"melonds":             "melonDS",
+	"mupen64plus_next":    "mupen64plus-libretro-nx",
+	"o2em":                "libretro-o2em",
+	"parallel_n64":        "parallel-n64",
+	"prosystem":           "prosystem-libretro",
+	"sameboy":             "SameBoy",
+	"vecx":                "libretro-vecx",
+	"virtualjaguar":       "virtualjaguar-libretro",
+}
+
+// parseIndex reads the extended index of a buildbot folder, lines like
+// "2006-01-02 crc name.so.zip", and returns the builds by name. ext is the
+// extension of the cores of the platform.
+func parseIndex(b []byte, ext string) map[string]Build {
+	m := map[string]Build{}
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 || !strings.HasSuffix(fields[2], ext+".zip") {
+			continue
+		}
+		name := strings.TrimSuffix(fields[2], ext+".zip")
+		m[name] = Build{Name: name, Date: fields[0], CRC: fields[1]}
+	}
+	return m
+}
+
+// fetchIndex downloads the builds of the buildbot folder of the platform
+func fetchIndex() (map[string]Build, error) {
+	base := platformURL(runtime.GOOS, runtime.GOARCH)
+	if base == "" {
+		return nil, fmt.Errorf("no cores available for %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+	r, err := client.Get(base + "/.index-extended")
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+	if r.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("buildbot index: %s", r.Status)
+	}
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	return parseIndex(b, utils.CoreExt()), nil
+}
+
+// installedPath is the location of the builds of the installed cores
+func installedPath() string {
+	return filepath.Join(xdg.DataHome, "ludo", "cores.csv")
+}
+
+// loadInstalled reads the builds of the installed cores, a CSV file with a
+// line per core
+func loadInstalled(path string) map[string]Build {
+	m := map[string]Build{}
+	file, err := os.Open(path)
+	if err != nil {
+		return m
+	}
+	defer file.Close()
+	r := csv.NewReader(bufio.NewReader(file))
+	r.FieldsPerRecord = 3
+	for {
+		record, err := r.Read()
+		if err == io.EOF || err != nil {
+			return m
+		}
+		m[record[0]] = Build{Name: record[0], Date: record[1], CRC: record[2]}
+	}
+}
+
+// saveInstalled writes the builds of the installed cores
+func saveInstalled(path string, m map[string]Build) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	w := csv.NewWriter(bufio.NewWriter(file))
+	for _, name := range names {
+		b := m[name]
+		w.Write([]string{name, b.Date, b.CRC})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// Forget removes the build of an uninstalled core, named like its file
+func Forget(core string) error {
+	installed := loadInstalled(installedPath())
+	if _, ok := installed[core]; !ok {
+		return nil
+	}
+	delete(installed, core)
+	return saveInstalled(installedPath(), installed)
+}
+
+// pending compares the cores of dir to the builds of the index. The cores
+// installed by Ludo are compared by checksum, the others by date.
+func pending(dir, ext string, index, installed map[string]Build) []Update {
+	files, _ := ioutil.ReadDir(dir)
+	var updates []Update
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ext) {
+			continue
+		}
+		name := strings.TrimSuffix(f.Name(), ext)
+		latest, ok := index[name]
+		if !ok {
+			continue
+		}
+		if b, ok := installed[name]; ok {
+			if b.CRC == latest.CRC {
+				continue
+			}
+			since, _ := time.Parse("2006-01-02", b.Date)
+			updates = append(updates, Update{Core: name, Since: since, Latest: latest})
+			continue
+		}
+		built, err := time.Parse("2006-01-02", latest.Date)
+		since := f.ModTime().UTC().Truncate(24 * time.Hour)
+		if err == nil && since.Before(built) {
+			updates = append(updates, Update{Core: name, Since: since, Latest: latest})
+		}
+	}
+	sort.Slice(updates, func(i, j int) bool { return updates[i].Core < updates[j].Core })
+	return updates
+}
+
+// CheckUpdates lists the installed cores that have a newer build on the
+// buildbot. The result is also kept for Updates.
+func CheckUpdates() ([]Update, error) {
+	index, err := fetchIndex()
+	if err != nil {
+		return nil, err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	available = pending(settings.Current.CoresDirectory, utils.CoreExt(), index, loadInstalled(installedPath()))
+	return append([]Update{}, available...), nil
+}
+
+// Updates returns the core updates found by the last check
+func Updates() []Update {
+	mu.Lock()
+	defer mu.Unlock()
+	return append([]Update{}, available...)
+}
+
+// WatchUpdates checks the core updates at the interval of the settings, and
+// notifies the user when there are some. It never returns, it is meant to be
+// run in a goroutine.
+func WatchUpdates() {
+	defer crash.Recover()
+	for {
+		interval := intervals[settings.Current.CoreUpdatesCheck]
+		last, _ := time.Parse(time.RFC3339, settings.Current.CoreUpdatesLastCheck)
+		if interval > 0 && time.Since(last) >= interval && !Downloading() {
+			updates, err := CheckUpdates()
+			if err != nil {
+				logs.Warnf("Buildbot", "Could not check the core updates: %v", err)
+			} else {
+				now := time.Now().Format(time.RFC3339)
+				// The settings are written on the main thread
+				mainthread.Post(func() {
+					settings.Current.CoreUpdatesLastCheck = now
+					settings.Save()
+				})
+				if len(updates) > 0 {
+					ntf.DisplayAndLog(ntf.Info, "Buildbot", "%d core updates available.", len(updates))
+				}
+			}
+		}
+		time.Sleep(time.Minute)
+	}
+}
+
+// changesURL returns the location of the commits of a core since a day
+func changesURL(core string, since time.Time) string {
+	name := strings.TrimSuffix(core, "_libretro")
+	repo, ok := repos[name]
+	if !ok {
+		repo = name
+	}
+	return fmt.Sprintf("https://api.github.com/repos/libretro/%s/commits?per_page=20&since=%s",
+		repo, since.Format(time.RFC3339))
+}
+
+// parseCommits returns the first line of the messages of GitHub commits
+func parseCommits(r io.Reader) ([]string, error) {
+	var commits []struct {
+		Commit struct {
+			Message string `json:"message"`
+		} `json:"commit"`
+	}
+	if err := json.NewDecoder(r).Decode(&commits); err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, c := range commits {
+		line := strings.TrimSpace(strings.SplitN(c.Commit.Message, "\n", 2)[0])
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// Changelog returns the summaries of the commits of a core since its
+// installed build, the most recent first
+func Changelog(u Update) ([]string, error) {
+	r, err := client.Get(changesURL(u.Core, u.Since))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+	if r.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("changelog: %s", r.Status)
+	}
+	return parseCommits(r.Body)
+}
+
+// UpdateCores downloads the new builds of cores, replacing the installed
+// ones. The running core, named like its file, is left for later, its library
+// is loaded. It blocks, it is meant to be run in a goroutine.
+func UpdateCores(updates []Update, running string) {
+	var cores []string
+	for _, u := range updates {
+		if u.Core == running {
+			ntf.DisplayAndLog(ntf.Warning, "Buildbot", "%s is running, close it to update it.", running)
+			continue
+		}
+		cores = append(cores, u.Core)
+	}
+	if len(cores) == 0 {
+		return
+	}
+	done := download(cores, true, "Updating cores")
+	mu.Lock()
+	var left []Update
+	for _, u := range available {
+		if !utils.StringInSlice(u.Core, done) {
+			left = append(left, u)
+		}
+	}
+	available = left
+	mu.Unlock()
+}
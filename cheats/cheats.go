@@ -0,0 +1,289 @@
+// Package cheats reads the cheats of a game from the RetroArch .cht files
+// and sends the enabled ones to the core. The cheat files are looked up by
+// game name in the cheats directory. Which cheats are enabled is saved per
+// game.
+package cheats
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/adrg/xdg"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+	"github.com/pelletier/go-toml"
+)
+
+// Cheat is a code in the format expected by the core, like Game Genie or
+// Action Replay codes
+type Cheat struct {
+	Desc    string
+	Code    string
+	Enabled bool
+}
+
+// ErrHardcore is returned when changing cheats in hardcore mode
+var ErrHardcore = errors.New("cheats are disabled in hardcore mode")
+
+// Current are the cheats of the running game
+var Current []Cheat
+
+// chtPath is the cheat file of the running game, if it has one
+var chtPath string
+
+// file is the list of the enabled cheats of a game, by description
+type file struct {
+	Enabled []string `toml:"enabled"`
+}
+
+// unquote removes the quotes around a value of a .cht file
+func unquote(v string) string {
+	v = strings.TrimSpace(v)
+	if len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"' {
+		return v[1 : len(v)-1]
+	}
+	return v
+}
+
+// Parse reads the cheats of a .cht file
+func Parse(data []byte) ([]Cheat, error) {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.Index(line, "=")
+		if i < 0 {
+			continue
+		}
+		values[strings.TrimSpace(line[:i])] = unquote(line[i+1:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	n, err := strconv.Atoi(values["cheats"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid cheat count: %v", err)
+	}
+
+	var cheats []Cheat
+	for i := 0; i < n; i++ {
+		key := fmt.Sprintf("cheat%d_", i)
+		c := Cheat{
+			Desc:    values[key+"desc"],
+			Code:    values[key+"code"],
+			Enabled: values[key+"enable"] == "true",
+		}
+		if c.Desc == "" {
+			c.Desc = c.Code
+		}
+		cheats = append(cheats, c)
+	}
+	return cheats, nil
+}
+
+// Format writes cheats in the .cht format. Values can't contain double
+// quotes, they are replaced by single quotes.
+func Format(cheats []Cheat) []byte {
+	q := strings.NewReplacer(`"`, "'")
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "cheats = %d\n", len(cheats))
+	for i, c := range cheats {
+		fmt.Fprintf(&b, "\ncheat%d_desc = \"%s\"\n", i, q.Replace(c.Desc))
+		fmt.Fprintf(&b, "cheat%d_code = \"%s\"\n", i, q.Replace(c.Code))
+		fmt.Fprintf(&b, "cheat%d_enable = %t\n", i, c.Enabled)
+	}
+	return b.Bytes()
+}
+
+// appendCheat adds a cheat to the content of a .cht file. The rest of the file
+// is left untouched, the keys Ludo doesn't use included.
+func appendCheat(data []byte, count int, c Cheat) []byte {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return Format([]Cheat{c})
+	}
+	q := strings.NewReplacer(`"`, "'")
+	var b bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "="); i > 0 && strings.TrimSpace(line[:i]) == "cheats" {
+			line = fmt.Sprintf("cheats = %d", count+1)
+		}
+		b.WriteString(line + "\n")
+	}
+	fmt.Fprintf(&b, "\ncheat%d_desc = \"%s\"\n", count, q.Replace(c.Desc))
+	fmt.Fprintf(&b, "cheat%d_code = \"%s\"\n", count, q.Replace(c.Code))
+	fmt.Fprintf(&b, "cheat%d_enable = %t\n", count, c.Enabled)
+	return b.Bytes()
+}
+
+// Normalize converts a code typed by the user, like a Game Genie, GameShark
+// or Action Replay code, to the format of the cheat files. Letters are upper
+// case, and the lines of a code spanning several lines are joined by a +.
+func Normalize(code string) string {
+	code = strings.ToUpper(code)
+	code = strings.NewReplacer(";", "+", ",", "+", "\n", "+").Replace(code)
+	var parts []string
+	for _, p := range strings.Split(code, "+") {
+		p = strings.Join(strings.Fields(p), " ")
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, "+")
+}
+
+// find looks for the cheat file of a game in the cheats directory
+func find(dir, game string) string {
+	var found string
+	name := strings.ToLower(game) + ".cht"
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || found != "" {
+			return nil
+		}
+		if strings.ToLower(filepath.Base(path)) == name {
+			found = path
+		}
+		return nil
+	})
+	return found
+}
+
+// path returns the location of the list of enabled cheats of a game
+func path(corePath string, crc uint32) string {
+	name := utils.FileName(corePath)
+	return filepath.Join(xdg.ConfigHome, "ludo", "cheats", name, fmt.Sprintf("%08X.toml", crc))
+}
+
+// Load reads the cheats of a game and which ones were enabled
+func Load(gamePath, corePath string, crc uint32) {
+	Current = nil
+
+	p := find(settings.Current.CheatsDirectory, utils.FileName(gamePath))
+	chtPath = p
+	if p == "" {
+		return
+	}
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		return
+	}
+	cheats, err := Parse(b)
+	if err != nil {
+		return
+	}
+	Current = cheats
+
+	b, err = ioutil.ReadFile(path(corePath, crc))
+	if err != nil {
+		return
+	}
+	var f file
+	if err := toml.Unmarshal(b, &f); err != nil {
+		return
+	}
+	for i := range Current {
+		Current[i].Enabled = utils.StringInSlice(Current[i].Desc, f.Enabled)
+	}
+}
+
+// Save saves which cheats are enabled for a game
+func Save(corePath string, crc uint32) error {
+	f := file{Enabled: []string{}}
+	for _, c := range Current {
+		if c.Enabled {
+			f.Enabled = append(f.Enabled, c.Desc)
+		}
+	}
+	b, err := toml.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	p := path(corePath, crc)
+	err = os.MkdirAll(filepath.Dir(p), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(p, b, 0644)
+}
+
+// Apply sends the enabled cheats to the core. They are all disabled in
+// hardcore mode.
+func Apply() {
+	if state.Core == nil {
+		return
+	}
+	state.Core.CheatReset()
+	if settings.Current.AchievementsHardcore {
+		return
+	}
+	for i, c := range Current {
+		if c.Enabled {
+			state.Core.CheatSet(uint(i), true, c.Code)
+		}
+	}
+}
+
+// Toggle enables or disables a cheat of the running game
+func Toggle(i int) error {
+	if settings.Current.AchievementsHardcore {
+		return ErrHardcore
+	}
+	if i < 0 || i >= len(Current) {
+		return nil
+	}
+	Current[i].Enabled = !Current[i].Enabled
+	Apply()
+	return Save(state.CorePath, state.GameCRC)
+}
+
+// Add saves a new cheat in the cheat file of the running game and enables it
+func Add(desc, code string) error {
+	if settings.Current.AchievementsHardcore {
+		return ErrHardcore
+	}
+	code = Normalize(code)
+	if code == "" {
+		return errors.New("empty cheat code")
+	}
+	if desc == "" {
+		desc = code
+	}
+
+	p := chtPath
+	if p == "" {
+		p = filepath.Join(settings.Current.CheatsDirectory, utils.FileName(state.GamePath)+".cht")
+	}
+	if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+		return err
+	}
+
+	c := Cheat{Desc: desc, Code: code, Enabled: true}
+	data, err := ioutil.ReadFile(p)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := ioutil.WriteFile(p, appendCheat(data, len(Current), c), 0644); err != nil {
+		return err
+	}
+	cheats := append(Current, c)
+	chtPath = p
+	Current = cheats
+
+	Apply()
+	return Save(state.CorePath, state.GameCRC)
+}
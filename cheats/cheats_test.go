@@ -0,0 +1,111 @@
+package cheats
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("Reads the cheats", func(t *testing.T) {
+		got, err := Parse([]byte(`cheats = 2
+
+cheat0_desc = "Infinite Lives"
+cheat0_code = "SXIOPO"
+cheat0_enable = false
+
+cheat1_desc = "Start On Level 8"
+cheat1_code = "PAAGAA+AEAGAA"
+cheat1_enable = true
+`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []Cheat{
+			{Desc: "Infinite Lives", Code: "SXIOPO"},
+			{Desc: "Start On Level 8", Code: "PAAGAA+AEAGAA", Enabled: true},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Uses the code when there is no description", func(t *testing.T) {
+		got, err := Parse([]byte("cheats = 1\ncheat0_code = \"7E0DBE05\"\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 || got[0].Desc != "7E0DBE05" {
+			t.Errorf("got %v", got)
+		}
+	})
+
+	t.Run("Fails without a cheat count", func(t *testing.T) {
+		if _, err := Parse([]byte("cheat0_code = \"7E0DBE05\"\n")); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestFormat(t *testing.T) {
+	cheats := []Cheat{
+		{Desc: "Infinite Lives", Code: "SXIOPO"},
+		{Desc: "Max \"Gold\"", Code: "8009C6E4 03E7", Enabled: true},
+	}
+	got, err := Parse(Format(cheats))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Cheat{
+		{Desc: "Infinite Lives", Code: "SXIOPO"},
+		{Desc: "Max 'Gold'", Code: "8009C6E4 03E7", Enabled: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestAppendCheat(t *testing.T) {
+	data := []byte("cheats = 1\n\ncheat0_desc = \"Infinite Lives\"\ncheat0_code = \"SXIOPO\"\ncheat0_enable = false\ncheat0_handler = \"1\"\n")
+	got := appendCheat(data, 1, Cheat{Desc: "Max Gold", Code: "8009C6E4 03E7", Enabled: true})
+	if !bytes.Contains(got, []byte("cheat0_handler = \"1\"")) {
+		t.Errorf("lost the other keys: %s", got)
+	}
+	cheats, err := Parse(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Cheat{
+		{Desc: "Infinite Lives", Code: "SXIOPO"},
+		{Desc: "Max Gold", Code: "8009C6E4 03E7", Enabled: true},
+	}
+	if !reflect.DeepEqual(cheats, want) {
+		t.Errorf("got %v, want %v", cheats, want)
+	}
+
+	cheats, err = Parse(appendCheat(nil, 0, want[0]))
+	if err != nil || !reflect.DeepEqual(cheats, want[:1]) {
+		t.Errorf("got %v, %v", cheats, err)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{code: "sxiopo", want: "SXIOPO"},
+		{code: " dd62-6dad ", want: "DD62-6DAD"},
+		{code: "8009c6e4  03e7", want: "8009C6E4 03E7"},
+		{code: "7E0DBE05; 7e0dbf05", want: "7E0DBE05+7E0DBF05"},
+		{code: "8009C6E4 03E7,\n8009C6E6 0000+", want: "8009C6E4 03E7+8009C6E6 0000"},
+		{code: " + ", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			if got := Normalize(tt.code); got != tt.want {
+				t.Errorf("Normalize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
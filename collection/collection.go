@@ -0,0 +1,163 @@
+// Package collection exports the playlists as a static HTML page, with the
+// thumbnails and the metadata of the games, to share a collection list or to
+// browse it from any device without the remote API.
+package collection
+
+import (
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/libretro/ludo/utils"
+)
+
+// Game is a game of the page. Image is the path of its thumbnail, empty if
+// it has none.
+type Game struct {
+	Name    string
+	Tags    []string
+	Details []string
+	Image   string
+}
+
+// System is a section of the page
+type System struct {
+	Name  string
+	Games []Game
+}
+
+// page is what the template renders, the images are relative to the page
+type page struct {
+	Title   string
+	Count   int
+	Systems []System
+}
+
+// copyImage copies an image next to the page, unless it is already there
+func copyImage(src, dst string) error {
+	if s, err := os.Stat(src); err == nil {
+		if d, err := os.Stat(dst); err == nil && d.Size() == s.Size() {
+			return nil
+		}
+	}
+	return utils.CopyFile(src, dst)
+}
+
+// prune removes the images of a system that the page no longer shows, like
+// the ones of the games removed from the playlists
+func prune(folder string, used map[string]bool) error {
+	files, err := ioutil.ReadDir(folder)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if !f.IsDir() && !used[f.Name()] {
+			if err := os.Remove(filepath.Join(folder, f.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Export writes the page named file in dir, and copies the thumbnails of the
+// games to an images folder next to it. The games whose thumbnail can't be
+// copied are listed without one. The images of the exported systems that
+// are no longer shown are removed. It returns the path of the page.
+func Export(dir, file, title string, systems []System) (string, error) {
+	p := page{Title: title}
+	for _, s := range systems {
+		out := System{Name: s.Name}
+		used := map[string]bool{}
+		for _, g := range s.Games {
+			if g.Image != "" {
+				rel := filepath.Join("images", s.Name, filepath.Base(g.Image))
+				if err := copyImage(g.Image, filepath.Join(dir, rel)); err != nil {
+					g.Image = ""
+				} else {
+					g.Image = filepath.ToSlash(rel)
+					used[filepath.Base(rel)] = true
+				}
+			}
+			out.Games = append(out.Games, g)
+		}
+		if err := prune(filepath.Join(dir, "images", s.Name), used); err != nil {
+			return "", err
+		}
+		p.Count += len(out.Games)
+		p.Systems = append(p.Systems, out)
+	}
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, file)
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	if err := tmpl.Execute(f, p); err != nil {
+		f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
+
+var tmpl = template.Must(template.New("collection").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { margin: 0; font-family: sans-serif; background: #1a1a1a; color: #eee; }
+header { position: sticky; top: 0; padding: 10px; background: #333; display: flex; gap: 8px; flex-wrap: wrap; align-items: center; }
+header h1 { margin: 0; font-size: 18px; }
+header input { flex: 1; min-width: 150px; padding: 8px; font-size: 16px; border: 0; border-radius: 4px; }
+h2 { margin: 16px 10px 6px; font-size: 16px; }
+.games { display: grid; grid-template-columns: repeat(auto-fill, minmax(160px, 1fr)); gap: 10px; padding: 0 10px; }
+.game { background: #2a2a2a; border-radius: 4px; overflow: hidden; }
+.game img, .game .none { width: 100%; aspect-ratio: 4 / 3; object-fit: cover; background: #000; display: block; }
+.game div { padding: 6px; font-size: 13px; }
+.game .tags, .game .details { padding-top: 0; color: #aaa; font-size: 12px; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+<input id="search" type="search" placeholder="Search {{.Count}} games">
+</header>
+<main>
+{{range .Systems}}<section>
+<h2>{{.Name}}</h2>
+<div class="games">
+{{range .Games}}<div class="game" data-name="{{.Name}}">
+{{if .Image}}<img loading="lazy" src="{{.Image}}" alt="">{{else}}<span class="none"></span>{{end}}
+<div>{{.Name}}</div>
+{{if .Tags}}<div class="tags">{{range $i, $t := .Tags}}{{if $i}}, {{end}}{{$t}}{{end}}</div>{{end}}
+{{range .Details}}<div class="details">{{.}}</div>{{end}}
+</div>
+{{end}}</div>
+</section>
+{{end}}</main>
+<script>
+document.getElementById('search').oninput = function () {
+	var query = this.value.toLowerCase();
+	document.querySelectorAll('section').forEach(function (s) {
+		var shown = 0;
+		s.querySelectorAll('.game').forEach(function (g) {
+			var match = g.dataset.name.toLowerCase().indexOf(query) >= 0;
+			g.style.display = match ? '' : 'none';
+			if (match) shown++;
+		});
+		s.style.display = shown ? '' : 'none';
+	});
+};
+</script>
+</body>
+</html>
+`))
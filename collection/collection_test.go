@@ -0,0 +1,93 @@
+package collection
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "collection")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	thumb := filepath.Join(dir, "thumbnails", "Tetris (World).png")
+	os.MkdirAll(filepath.Dir(thumb), os.ModePerm)
+	ioutil.WriteFile(thumb, []byte("png"), 0644)
+
+	out := filepath.Join(dir, "out")
+	path, err := Export(out, "index.html", "My <Games>", []System{
+		{Name: "Nintendo - Game Boy", Games: []Game{
+			{Name: "Tetris", Tags: []string{"World", "Rev 1"}, Details: []string{"Played 2h 05m"}, Image: thumb},
+			{Name: "Missing", Image: filepath.Join(dir, "nope.png")},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(out, "index.html") {
+		t.Errorf("path = %v", path)
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(out, "images", "Nintendo - Game Boy", "Tetris (World).png")); err != nil || string(b) != "png" {
+		t.Errorf("thumbnail not copied: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(b)
+	for _, want := range []string{
+		"<title>My &lt;Games&gt;</title>",
+		"Search 2 games",
+		"<h2>Nintendo - Game Boy</h2>",
+		`src="images/Nintendo%20-%20Game%20Boy/Tetris%20%28World%29.png"`,
+		`<div class="tags">World, Rev 1</div>`,
+		`<div class="details">Played 2h 05m</div>`,
+		`<span class="none"></span>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("page lacks %q", want)
+		}
+	}
+}
+
+func TestExportPrunes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "collection")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	thumb := filepath.Join(dir, "thumbnails", "Tetris (World).png")
+	os.MkdirAll(filepath.Dir(thumb), os.ModePerm)
+	ioutil.WriteFile(thumb, []byte("png"), 0644)
+
+	out := filepath.Join(dir, "out")
+	removed := filepath.Join(out, "images", "Nintendo - Game Boy", "Alleyway (World).png")
+	other := filepath.Join(out, "images", "Atari - Lynx", "Chip.png")
+	for _, path := range []string{removed, other} {
+		os.MkdirAll(filepath.Dir(path), os.ModePerm)
+		ioutil.WriteFile(path, []byte("png"), 0644)
+	}
+
+	_, err = Export(out, "gb.html", "Game Boy", []System{
+		{Name: "Nintendo - Game Boy", Games: []Game{{Name: "Tetris", Image: thumb}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(removed); !os.IsNotExist(err) {
+		t.Error("the image of a removed game was kept")
+	}
+	if _, err := os.Stat(filepath.Join(out, "images", "Nintendo - Game Boy", "Tetris (World).png")); err != nil {
+		t.Error("the image of the page was removed")
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Error("the image of another system was removed")
+	}
+}
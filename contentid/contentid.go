@@ -0,0 +1,195 @@
+// Package contentid names the files of a game, its save, its savestates and
+// its screenshots, after the CRC of its content, so that renaming or moving
+// the ROM never orphans them. The human names of the games are kept in a
+// metadata file, to display them next to the files named after a CRC.
+package contentid
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/adrg/xdg"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/utils"
+)
+
+var (
+	mu    sync.Mutex
+	names map[string]string
+)
+
+// Key is the name of the files of a game, the CRC of its content. The file
+// name of the ROM is used when the CRC is unknown.
+func Key(gamePath string, crc uint32) string {
+	if crc == 0 {
+		return utils.FileName(gamePath)
+	}
+	return fmt.Sprintf("%08X", crc)
+}
+
+// namesPath is the location of the human names of the keys
+func namesPath() string {
+	return filepath.Join(xdg.DataHome, "ludo", "names.csv")
+}
+
+// loadNames reads the human names of the keys, once
+func loadNames() {
+	if names != nil {
+		return
+	}
+	names = map[string]string{}
+	file, err := os.Open(namesPath())
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	r := csv.NewReader(bufio.NewReader(file))
+	r.FieldsPerRecord = 2
+	for {
+		record, err := r.Read()
+		if err == io.EOF || err != nil {
+			return
+		}
+		names[record[0]] = record[1]
+	}
+}
+
+// saveNames writes the human names of the keys
+func saveNames() error {
+	if err := os.MkdirAll(filepath.Dir(namesPath()), os.ModePerm); err != nil {
+		return err
+	}
+	file, err := os.Create(namesPath())
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var keys []string
+	for k := range names {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	w := csv.NewWriter(bufio.NewWriter(file))
+	for _, k := range keys {
+		w.Write([]string{k, names[k]})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// remember stores the human name of a game, the file name of its ROM, and
+// tells if it changed
+func remember(gamePath string, crc uint32) bool {
+	key := Key(gamePath, crc)
+	name := utils.FileName(gamePath)
+	loadNames()
+	if key == name || names[key] == name {
+		return false
+	}
+	names[key] = name
+	return true
+}
+
+// Remember stores the human name of a game, the file name of its ROM
+func Remember(gamePath string, crc uint32) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if !remember(gamePath, crc) {
+		return nil
+	}
+	return saveNames()
+}
+
+// Name returns the human name of a key, or the key if it isn't known
+func Name(key string) string {
+	mu.Lock()
+	defer mu.Unlock()
+	loadNames()
+	if name, ok := names[key]; ok {
+		return name
+	}
+	return key
+}
+
+// Dirs are the folders holding the files of the games
+type Dirs struct {
+	Savefiles   string
+	Savestates  string
+	Screenshots string
+}
+
+// SettingsDirs returns the folders of the settings
+func SettingsDirs() Dirs {
+	return Dirs{
+		Savefiles:   settings.Current.SavefilesDirectory,
+		Savestates:  settings.Current.SavestatesDirectory,
+		Screenshots: settings.Current.ScreenshotsDirectory,
+	}
+}
+
+// rename moves a file, unless its destination exists already
+func rename(from, to string) bool {
+	if _, err := os.Stat(to); err == nil {
+		return false
+	}
+	return os.Rename(from, to) == nil
+}
+
+// renameDated moves the files of dir named like old@date.ext to key@date.ext
+// in the folder to. The folders aren't globbed, ROM names often have
+// brackets.
+func renameDated(dir, to, old, key, ext string) int {
+	files, _ := ioutil.ReadDir(dir)
+	var n int
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || !strings.HasPrefix(name, old+"@") || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		date := strings.TrimPrefix(name, old+"@")
+		if rename(filepath.Join(dir, name), filepath.Join(to, key+"@"+date)) {
+			n++
+		}
+	}
+	return n
+}
+
+// Migrate renames the files of a game named after the file name of its ROM,
+// the naming of the previous versions, to its key. The files already named
+// after the key are kept. It returns the number of files renamed.
+func Migrate(d Dirs, gamePath string, crc uint32) int {
+	old := utils.FileName(gamePath)
+	key := Key(gamePath, crc)
+	if old == key {
+		return 0
+	}
+
+	var n int
+	if d.Savefiles != "" && rename(filepath.Join(d.Savefiles, old+".srm"), filepath.Join(d.Savefiles, key+".srm")) {
+		n++
+	}
+	if d.Savestates != "" {
+		n += renameDated(d.Savestates, d.Savestates, old, key, ".state")
+	}
+	if d.Screenshots != "" {
+		// The thumbnails of the savestates
+		n += renameDated(d.Screenshots, d.Screenshots, old, key, ".png")
+		from, to := filepath.Join(d.Screenshots, old), filepath.Join(d.Screenshots, key)
+		if _, err := os.Stat(from); err == nil {
+			if err := os.MkdirAll(to, os.ModePerm); err == nil {
+				n += renameDated(from, to, old, key, ".png")
+				os.Remove(from)
+			}
+		}
+	}
+	return n
+}
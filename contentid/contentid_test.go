@@ -0,0 +1,87 @@
+package contentid
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	if got := Key("/roms/Tetris (World).gb", 0x46df91ad); got != "46DF91AD" {
+		t.Errorf("Key() = %v, want the CRC", got)
+	}
+	if got := Key("/roms/Tetris (World).gb", 0); got != "Tetris (World)" {
+		t.Errorf("Key() = %v, want the file name without a CRC", got)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "contentid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	d := Dirs{
+		Savefiles:   filepath.Join(dir, "savefiles"),
+		Savestates:  filepath.Join(dir, "savestates"),
+		Screenshots: filepath.Join(dir, "screenshots"),
+	}
+	old := "Tetris (World) [!]"
+	files := []string{
+		filepath.Join(d.Savefiles, old+".srm"),
+		filepath.Join(d.Savestates, old+"@2020-01-02-10-00-00.state"),
+		filepath.Join(d.Savestates, old+"@slot-01.state"),
+		filepath.Join(d.Savestates, "Other@2020-01-02-10-00-00.state"),
+		filepath.Join(d.Screenshots, old+"@2020-01-02-10-00-00.png"),
+		filepath.Join(d.Screenshots, old, old+"@2020-01-03-10-00-00.png"),
+		// Already migrated, kept as is
+		filepath.Join(d.Savestates, "46DF91AD@slot-01.state"),
+	}
+	for _, f := range files {
+		os.MkdirAll(filepath.Dir(f), os.ModePerm)
+		ioutil.WriteFile(f, []byte(filepath.Base(f)), 0644)
+	}
+
+	if n := Migrate(d, "/roms/"+old+".gb", 0x46df91ad); n != 4 {
+		t.Errorf("Migrate() = %d, want 4", n)
+	}
+
+	var got []string
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			rel, _ := filepath.Rel(dir, path)
+			got = append(got, rel)
+		}
+		return nil
+	})
+	sort.Strings(got)
+	want := []string{
+		filepath.Join("savefiles", "46DF91AD.srm"),
+		filepath.Join("savestates", "46DF91AD@2020-01-02-10-00-00.state"),
+		filepath.Join("savestates", "46DF91AD@slot-01.state"),
+		filepath.Join("savestates", "Other@2020-01-02-10-00-00.state"),
+		filepath.Join("savestates", old+"@slot-01.state"),
+		filepath.Join("screenshots", "46DF91AD", "46DF91AD@2020-01-03-10-00-00.png"),
+		filepath.Join("screenshots", "46DF91AD@2020-01-02-10-00-00.png"),
+	}
+	sort.Strings(want)
+	if len(got) != len(want) {
+		t.Fatalf("files = %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("files = %v, want %v", got, want)
+			break
+		}
+	}
+	if b, _ := ioutil.ReadFile(filepath.Join(d.Savestates, "46DF91AD@slot-01.state")); string(b) != "46DF91AD@slot-01.state" {
+		t.Errorf("the migrated slot was overwritten")
+	}
+
+	if n := Migrate(d, "/roms/"+old+".gb", 0); n != 0 {
+		t.Errorf("Migrate() without a CRC = %d, want 0", n)
+	}
+}
@@ -5,20 +5,41 @@ package core
 
 import (
 	"errors"
+	"hash/crc32"
+	"io"
 	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"unsafe"
 
+	"github.com/libretro/ludo/achievements"
 	"github.com/libretro/ludo/audio"
+	"github.com/libretro/ludo/bezels"
+	"github.com/libretro/ludo/cheats"
+	"github.com/libretro/ludo/contentid"
+	"github.com/libretro/ludo/coreinfo"
+	"github.com/libretro/ludo/corethread"
+	"github.com/libretro/ludo/crash"
+	"github.com/libretro/ludo/discord"
+	"github.com/libretro/ludo/envaudit"
 	"github.com/libretro/ludo/input"
 	"github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/logs"
+	"github.com/libretro/ludo/macro"
+	"github.com/libretro/ludo/movie"
 	"github.com/libretro/ludo/options"
 	"github.com/libretro/ludo/patch"
+	"github.com/libretro/ludo/record"
+	"github.com/libretro/ludo/remap"
 	"github.com/libretro/ludo/savefiles"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/shaders"
 	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/stats"
+	"github.com/libretro/ludo/utils"
 	"github.com/libretro/ludo/video"
+	"github.com/libretro/ludo/viewport"
 
 	"github.com/mholt/archiver/v3"
 )
@@ -34,6 +55,16 @@ func Init(v *video.Video) {
 	vid = v
 }
 
+// videoRefresh queues the frames for the renderer when the core runs on the
+// core thread, the video can only be used from the main thread
+func videoRefresh(data unsafe.Pointer, width int32, height int32, pitch int32) {
+	if corethread.Running() {
+		corethread.Refresh(data, width, height, pitch)
+		return
+	}
+	vid.Refresh(data, width, height, pitch)
+}
+
 // Load loads a libretro core
 func Load(sofile string) error {
 	// In case a core is already loaded, we need to close it properly
@@ -42,15 +73,21 @@ func Load(sofile string) error {
 
 	// This must be set before the environment callback is called
 	state.CorePath = sofile
+	coreName = utils.FileName(sofile)
+	viewport.Load(sofile)
+	if settings.Current.EnvironmentAudit {
+		envaudit.Start(sofile)
+	}
 
 	var err error
 	state.Core, err = libretro.Load(sofile)
 	if err != nil {
+		envaudit.Cancel()
 		return err
 	}
 	state.Core.SetEnvironment(environment)
 	state.Core.Init()
-	state.Core.SetVideoRefresh(vid.Refresh)
+	state.Core.SetVideoRefresh(videoRefresh)
 	state.Core.SetInputPoll(func() {})
 	state.Core.SetInputState(input.State)
 	state.Core.SetAudioSample(audio.Sample)
@@ -60,49 +97,57 @@ func Load(sofile string) error {
 	si := state.Core.GetSystemInfo()
 	if len(si.LibraryName) > 0 {
 		vid.SetTitle("Ludo - " + si.LibraryName)
-		if state.Verbose {
-			log.Println("[Core]: Name:", si.LibraryName)
-			log.Println("[Core]: Version:", si.LibraryVersion)
-			log.Println("[Core]: Valid extensions:", si.ValidExtensions)
-			log.Println("[Core]: Need fullpath:", si.NeedFullpath)
-			log.Println("[Core]: Block extract:", si.BlockExtract)
+		coreName = si.LibraryName
+		logs.Debugf("Core", "Name: %s", si.LibraryName)
+		logs.Debugf("Core", "Version: %s", si.LibraryVersion)
+		logs.Debugf("Core", "Valid extensions: %s", si.ValidExtensions)
+		logs.Debugf("Core", "Need fullpath: %t", si.NeedFullpath)
+		logs.Debugf("Core", "Block extract: %t", si.BlockExtract)
+		if err := coreinfo.Remember(sofile, si.LibraryName, si.ValidExtensions); err != nil {
+			logs.Warnf("Core", "Can't cache the core extensions: %v", err)
+		}
+		if err := coreinfo.Touch(sofile, si.LibraryVersion); err != nil {
+			logs.Warnf("Core", "Can't save the last use of the core: %v", err)
 		}
 	}
 
 	return nil
 }
 
-// unarchiveGame unarchives a rom to tmpdir and returns the path and size of the extracted ROM.
-// In case the archive contains more than one file, they are all extracted and the
-// first one or a better match (cue for CDrom) is passed to the libretro core.
-func unarchiveGame(filename string) (string, int64, error) {
-	path := ""
-	size := int64(0)
-	dst := os.TempDir()
-
-	var err error
+// Unarchive extracts all the files of an archive in dst, replacing the files
+// extracted before
+func Unarchive(filename, dst string) error {
 	// What a wonderful API, all this boilerplate to set an option
 	switch filepath.Ext(filename) {
 	case ".zip":
 		un := archiver.Zip{
 			OverwriteExisting: true,
 		}
-		err = un.Unarchive(filename, dst)
+		return un.Unarchive(filename, dst)
 	case ".tar":
 		un := archiver.Tar{
 			OverwriteExisting: true,
 		}
-		err = un.Unarchive(filename, dst)
+		return un.Unarchive(filename, dst)
 	case ".rar":
 		un := archiver.Rar{
 			OverwriteExisting: true,
 		}
-		err = un.Unarchive(filename, dst)
-	default:
-		// Unarchive with default option
-		err = archiver.Unarchive(filename, dst)
+		return un.Unarchive(filename, dst)
 	}
-	if err != nil {
+	// Unarchive with default option
+	return archiver.Unarchive(filename, dst)
+}
+
+// unarchiveGame unarchives a rom to tmpdir and returns the path and size of the extracted ROM.
+// In case the archive contains more than one file, they are all extracted and the
+// first one or a better match (cue for CDrom) is passed to the libretro core.
+func unarchiveGame(filename string) (string, int64, error) {
+	path := ""
+	size := int64(0)
+	dst := os.TempDir()
+
+	if err := Unarchive(filename, dst); err != nil {
 		return path, size, err
 	}
 
@@ -113,14 +158,14 @@ func unarchiveGame(filename string) (string, int64, error) {
 	extPrefered[".m3u"] = 2
 	extPrefered[".pbp"] = 3
 
-	err = archiver.Walk(filename, func(f archiver.File) error {
+	err := archiver.Walk(filename, func(f archiver.File) error {
 		fname := f.Name()
 		ext := filepath.Ext(fname)
 		if size == 0 {
 			// By default select the first file of the archive
 			path = filepath.Join(dst, fname)
 			size = f.Size()
-			log.Println("first file in archive:", path, size)
+			logs.Debugf("Core", "First file in archive: %s %d", path, size)
 		}
 		// Check if a file (based on extension) has a higher priority
 		priority, ok := extPrefered[strings.ToLower(ext)]
@@ -128,7 +173,7 @@ func unarchiveGame(filename string) (string, int64, error) {
 			extPriority = priority
 			path = filepath.Join(dst, fname)
 			size = f.Size()
-			log.Println("find a better file in archive:", path, size)
+			logs.Debugf("Core", "Found a better file in archive: %s %d", path, size)
 		}
 		return nil
 	})
@@ -177,6 +222,7 @@ func LoadGame(gamePath string) error {
 	avi := state.Core.GetSystemAVInfo()
 
 	vid.Geom = avi.Geometry
+	vid.Timing = avi.Timing
 
 	// Append the library name to the window title.
 	if len(si.LibraryName) > 0 {
@@ -191,25 +237,76 @@ func LoadGame(gamePath string) error {
 
 	state.CoreRunning = true
 	state.FastForward = false
+	state.Paused = false
+	state.FrameAdvance = false
+	state.GameFocus = false
 	state.GamePath = gamePath
+	state.GameCRC, _ = checksum(gi.Path)
+	if n := contentid.Migrate(contentid.SettingsDirs(), gamePath, state.GameCRC); n > 0 {
+		logs.Infof("Core", "%d saves renamed after the CRC of the game", n)
+	}
+	if err := contentid.Remember(gamePath, state.GameCRC); err != nil {
+		logs.Warnf("Core", "Failed to remember the name of the game: %v", err)
+	}
+	settings.ApplyOverrides(state.CorePath, state.GameCRC)
+	crash.SetGame(si.LibraryName, si.LibraryVersion, gamePath, state.GameCRC)
+	remap.Load(state.CorePath, state.GameCRC)
+	macro.Load(state.CorePath, state.GameCRC)
+	audio.LoadVolume(state.CorePath, state.GameCRC)
+	stats.Start(gamePath)
+	cheats.Load(gamePath, state.CorePath, state.GameCRC)
+	go func() {
+		defer crash.Recover()
+		if err := achievements.Load(gamePath); err != nil {
+			logs.Warnf("Achievements", "%v", err)
+		}
+	}()
+	cheats.Apply()
+	shaders.LoadConfig(state.CorePath, state.GameCRC)
+	if p, ok := shaders.Selected(); ok {
+		if err := vid.SetPreset(&p); err != nil {
+			logs.Warnf("Core", "Failed to load the shader preset: %v", err)
+		}
+	}
+
+	bezels.Load(state.GamePath, state.GameCRC, state.CorePath)
+	discord.Start()
 
-	state.Core.SetControllerPortDevice(0, libretro.DeviceJoypad)
-	state.Core.SetControllerPortDevice(1, libretro.DeviceJoypad)
-	state.Core.SetControllerPortDevice(2, libretro.DeviceJoypad)
-	state.Core.SetControllerPortDevice(3, libretro.DeviceJoypad)
-	state.Core.SetControllerPortDevice(4, libretro.DeviceJoypad)
+	input.LoadDevices()
+	for port, device := range input.Devices {
+		state.Core.SetControllerPortDevice(uint(port), device)
+	}
 
-	log.Println("[Core]: Game loaded: " + gamePath)
+	logs.Infof("Core", "Game loaded: %s", gamePath)
 	savefiles.LoadSRAM()
 
 	return nil
 }
 
+// checksum computes the CRC32 of a game file, used to identify the content
+// when saving per game settings
+func checksum(path string) (uint32, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	h := crc32.NewIEEE()
+	if _, err := io.Copy(h, f); err != nil {
+		return 0, err
+	}
+	return h.Sum32(), nil
+}
+
 // Unload unloads a libretro core
 func Unload() {
 	if state.Core != nil {
 		UnloadGame()
 		state.Core.Deinit()
+		if err := envaudit.Stop(); err != nil {
+			logs.Errorf("Core", "Failed to save the environment report: %v", err)
+		}
 		state.CorePath = ""
 		state.Core = nil
 		Options = nil
@@ -219,12 +316,41 @@ func Unload() {
 // UnloadGame unloads a game.
 func UnloadGame() {
 	if state.CoreRunning {
+		input.StopRumble()
+		if err := record.Stop(); err != nil {
+			logs.Errorf("Core", "Failed to finish the recording: %v", err)
+		}
+		if movie.Recording() {
+			if err := movie.Save(movie.Path(state.GamePath), movie.StopRecording()); err != nil {
+				logs.Errorf("Core", "Failed to save the movie: %v", err)
+			}
+		}
+		movie.Stop()
 		savefiles.SaveSRAM()
 		state.Core.UnloadGame()
 		state.GamePath = ""
+		state.GameCRC = 0
 		state.CoreRunning = false
+		remap.Current = remap.Identity()
+		bezels.Unload()
+		cheats.Current = nil
+		if g := achievements.Current(); g != nil {
+			unlocked, _, _, _ := g.Progress()
+			stats.Earned(unlocked)
+		}
+		if err := stats.Stop(); err != nil {
+			logs.Errorf("Core", "Failed to save the play statistics: %v", err)
+		}
+		achievements.Unload()
+		discord.Stop()
+		if shaders.Current.Preset != "" {
+			shaders.Select("")
+			vid.SetPreset(nil)
+		}
 		vid.ResetPitch()
 		vid.ResetRot()
+		settings.ClearOverrides()
+		crash.ClearGame()
 	}
 }
 
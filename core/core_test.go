@@ -10,6 +10,7 @@ import (
 
 	"github.com/go-gl/glfw/v3.3/glfw"
 	"github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/logs"
 	"github.com/libretro/ludo/state"
 	"github.com/libretro/ludo/utils"
 	"github.com/libretro/ludo/video"
@@ -29,7 +30,7 @@ func (m WindowMock) SetTitle(string)                             {}
 func (m WindowMock) SwapBuffers()                                {}
 
 func Test_coreLoad(t *testing.T) {
-	state.Verbose = true
+	logs.SetLevel(logs.Debug)
 
 	ext := utils.CoreExt()
 
@@ -45,11 +46,11 @@ func Test_coreLoad(t *testing.T) {
 
 	t.Run("Logs information about the loaded core", func(t *testing.T) {
 		got := out
-		want := `[Core]: Name: VecX
-[Core]: Version: 1.2 42366f8
-[Core]: Valid extensions: bin|vec
-[Core]: Need fullpath: false
-[Core]: Block extract: false
+		want := `DEBUG [Core]: Name: VecX
+DEBUG [Core]: Version: 1.2 42366f8
+DEBUG [Core]: Valid extensions: bin|vec
+DEBUG [Core]: Need fullpath: false
+DEBUG [Core]: Block extract: false
 `
 		if !strings.Contains(got, want) {
 			t.Errorf("got = %v, want %v", got, want)
@@ -58,7 +59,7 @@ func Test_coreLoad(t *testing.T) {
 
 	Unload()
 	state.GamePath = ""
-	state.Verbose = false
+	logs.SetLevel(logs.Info)
 }
 
 func Test_getGameInfo(t *testing.T) {
@@ -189,7 +190,6 @@ func Test_unarchiveGame(t *testing.T) {
 }
 
 func Test_coreLoadGame(t *testing.T) {
-	state.Verbose = true
 
 	ext := utils.CoreExt()
 
@@ -204,7 +204,7 @@ func Test_coreLoadGame(t *testing.T) {
 	got := utils.CaptureOutput(func() { LoadGame("testdata/Polar Rescue (USA).vec") })
 
 	t.Run("Logs information about the loaded game", func(t *testing.T) {
-		want := `[Core]: Game loaded: testdata/Polar Rescue (USA).vec`
+		want := `INFO  [Core]: Game loaded: testdata/Polar Rescue (USA).vec`
 		if !strings.Contains(got, want) {
 			t.Errorf("got = %v, want %v", got, want)
 		}
@@ -1,34 +1,48 @@
 package core
 
 import (
-	"log"
 	"os"
 	"os/user"
 	"time"
 	"unsafe"
 
+	"github.com/libretro/ludo/corethread"
+	"github.com/libretro/ludo/envaudit"
+	"github.com/libretro/ludo/input"
 	"github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/logs"
 	"github.com/libretro/ludo/options"
 	"github.com/libretro/ludo/settings"
 	"github.com/libretro/ludo/state"
 )
 
-var logLevels = map[uint32]string{
-	libretro.LogLevelDebug: "DEBUG",
-	libretro.LogLevelInfo:  "INFO",
-	libretro.LogLevelWarn:  "WARN",
-	libretro.LogLevelError: "ERROR",
-	libretro.LogLevelDummy: "DUMMY",
+var logLevels = map[uint32]logs.Level{
+	libretro.LogLevelDebug: logs.Debug,
+	libretro.LogLevelInfo:  logs.Info,
+	libretro.LogLevelWarn:  logs.Warn,
+	libretro.LogLevelError: logs.Error,
+	libretro.LogLevelDummy: logs.Debug,
 }
 
+// coreName tags the logs of the core, it is the library name of the core
+var coreName = "Core"
+
 func logCallback(level uint32, str string) {
-	log.Printf("[%s]: %s", logLevels[level], str)
+	logs.Logf(logLevels[level], coreName, "%s", str)
 }
 
 func getTimeUsec() int64 {
 	return time.Now().UnixNano() / 1000
 }
 
+// perfLogCallback logs the performance counters of the core when it asks for
+// it, usually when it is unloaded
+func perfLogCallback(counters []libretro.PerfCounter) {
+	for _, c := range counters {
+		logs.Infof(coreName, "Perf counter %s: %d calls, %v", c.Ident, c.Calls, c.Total)
+	}
+}
+
 func environmentGetVariable(data unsafe.Pointer) bool {
 	variable := libretro.GetVariable(data)
 	for _, v := range Options.Vars {
@@ -42,9 +56,24 @@ func environmentGetVariable(data unsafe.Pointer) bool {
 
 func environmentSetPixelFormat(data unsafe.Pointer) bool {
 	format := libretro.GetPixelFormat(data)
+	switch format {
+	case libretro.PixelFormat0RGB1555, libretro.PixelFormatXRGB8888, libretro.PixelFormatRGB565:
+		corethread.Defer(func() { vid.SetPixelFormat(format) })
+		return true
+	}
 	return vid.SetPixelFormat(format)
 }
 
+// environmentSetHWRender refuses the cores rendering with OpenGL when they
+// would run on the core thread, the context of the window belongs to the
+// main thread
+func environmentSetHWRender() bool {
+	if settings.Current.CoreThread {
+		logs.Warnf(coreName, "Hardware rendering isn't supported on the core thread")
+	}
+	return false
+}
+
 func environmentGetUsername(data unsafe.Pointer) bool {
 	currentUser, err := user.Current()
 	if err != nil {
@@ -58,7 +87,7 @@ func environmentGetUsername(data unsafe.Pointer) bool {
 func environmentGetSystemDirectory(data unsafe.Pointer) bool {
 	err := os.MkdirAll(settings.Current.SystemDirectory, os.ModePerm)
 	if err != nil {
-		log.Println(err)
+		logs.Errorf("Core", "%v", err)
 		return false
 	}
 	libretro.SetString(data, settings.Current.SystemDirectory)
@@ -68,7 +97,7 @@ func environmentGetSystemDirectory(data unsafe.Pointer) bool {
 func environmentGetSaveDirectory(data unsafe.Pointer) bool {
 	err := os.MkdirAll(settings.Current.SavefilesDirectory, os.ModePerm)
 	if err != nil {
-		log.Println(err)
+		logs.Errorf("Core", "%v", err)
 		return false
 	}
 	libretro.SetString(data, settings.Current.SavefilesDirectory)
@@ -87,7 +116,7 @@ func environmentSetVariables(data unsafe.Pointer) bool {
 	var err error
 	Options, err = options.New(pass)
 	if err != nil {
-		log.Println(err)
+		logs.Errorf("Core", "%v", err)
 		return false
 	}
 	return true
@@ -105,7 +134,7 @@ func environmentSetCoreOptions(data unsafe.Pointer) bool {
 	var err error
 	Options, err = options.New(pass)
 	if err != nil {
-		log.Println(err)
+		logs.Errorf("Core", "%v", err)
 		return false
 	}
 	return true
@@ -123,22 +152,33 @@ func environmentSetCoreOptionsIntl(data unsafe.Pointer) bool {
 	var err error
 	Options, err = options.New(pass)
 	if err != nil {
-		log.Println(err)
+		logs.Errorf("Core", "%v", err)
 		return false
 	}
 	return true
 }
 
 func environment(cmd uint32, data unsafe.Pointer) bool {
+	ok, implemented := environmentCall(cmd, data)
+	if settings.Current.EnvironmentAudit {
+		envaudit.Record(cmd, libretro.EnvironmentName(cmd), implemented, ok)
+	}
+	return ok
+}
+
+// environmentCall answers an environment command, and tells if Ludo
+// implements it
+func environmentCall(cmd uint32, data unsafe.Pointer) (bool, bool) {
 	switch cmd {
 	case libretro.EnvironmentSetRotation:
-		return vid.SetRotation(*(*uint)(data))
+		rot := *(*uint)(data)
+		corethread.Defer(func() { vid.SetRotation(rot) })
 	case libretro.EnvironmentGetUsername:
-		return environmentGetUsername(data)
+		return environmentGetUsername(data), true
 	case libretro.EnvironmentGetLogInterface:
 		state.Core.BindLogCallback(data, logCallback)
 	case libretro.EnvironmentGetPerfInterface:
-		state.Core.BindPerfCallback(data, getTimeUsec)
+		state.Core.BindPerfCallback(data, getTimeUsec, perfLogCallback)
 	case libretro.EnvironmentSetFrameTimeCallback:
 		state.Core.SetFrameTimeCallback(data)
 	case libretro.EnvironmentSetAudioCallback:
@@ -146,33 +186,39 @@ func environment(cmd uint32, data unsafe.Pointer) bool {
 	case libretro.EnvironmentGetCanDupe:
 		libretro.SetBool(data, true)
 	case libretro.EnvironmentSetPixelFormat:
-		return environmentSetPixelFormat(data)
+		return environmentSetPixelFormat(data), true
 	case libretro.EnvironmentGetSystemDirectory:
-		return environmentGetSystemDirectory(data)
+		return environmentGetSystemDirectory(data), true
 	case libretro.EnvironmentGetSaveDirectory:
-		return environmentGetSaveDirectory(data)
+		return environmentGetSaveDirectory(data), true
 	case libretro.EnvironmentShutdown:
-		vid.SetShouldClose(true)
+		corethread.Defer(func() { vid.SetShouldClose(true) })
 	case libretro.EnvironmentGetCoreOptionsVersion:
 		libretro.SetUint(data, 1)
 	case libretro.EnvironmentSetCoreOptions:
-		return environmentSetCoreOptions(data)
+		return environmentSetCoreOptions(data), true
 	case libretro.EnvironmentSetCoreOptionsIntl:
-		return environmentSetCoreOptionsIntl(data)
+		return environmentSetCoreOptionsIntl(data), true
 	case libretro.EnvironmentGetVariable:
-		return environmentGetVariable(data)
+		return environmentGetVariable(data), true
 	case libretro.EnvironmentSetVariables:
-		return environmentSetVariables(data)
+		return environmentSetVariables(data), true
 	case libretro.EnvironmentGetVariableUpdate:
 		libretro.SetBool(data, Options.Updated)
 		Options.Updated = false
 	case libretro.EnvironmentSetMemoryMaps:
 		state.Core.MemoryMap = libretro.GetMemoryMap(data)
 	case libretro.EnvironmentSetGeometry:
-		vid.Geom = libretro.GetGeometry(data)
+		geom := libretro.GetGeometry(data)
+		corethread.Defer(func() { vid.Geom = geom })
 	case libretro.EnvironmentSetSystemAVInfo:
 		avi := libretro.GetSystemAVInfo(data)
-		vid.Geom = avi.Geometry
+		corethread.Defer(func() {
+			vid.Geom = avi.Geometry
+			vid.Timing = avi.Timing
+		})
+	case libretro.EnvironmentSetHWRender:
+		return environmentSetHWRender(), true
 	case libretro.EnvironmentGetFastforwarding:
 		libretro.SetBool(data, state.FastForward)
 	case libretro.EnvironmentGetLanguage:
@@ -181,9 +227,12 @@ func environment(cmd uint32, data unsafe.Pointer) bool {
 		libretro.SetUint(data, 0)
 	case libretro.EnvironmentSetDiskControlInterface:
 		state.Core.SetDiskControlCallback(data)
+	case libretro.EnvironmentGetRumbleInterface:
+		state.Core.BindRumbleCallback(data, input.SetRumble)
+	case libretro.EnvironmentSetKeyboardCallback:
+		state.Core.SetKeyboardCallback(data)
 	default:
-		//log.Println("[Env]: Not implemented:", cmd)
-		return false
+		return false, false
 	}
-	return true
+	return true, true
 }
@@ -0,0 +1,421 @@
+// Package coreinfo knows which installed cores can run a content. The
+// extensions and the systems supported by the cores are read from their .info
+// files, like in RetroArch, and completed with the extensions reported by the
+// cores loaded by Ludo. It also keeps when the cores were last used, and
+// uninstalls them.
+package coreinfo
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/libretro/ludo/dat"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/utils"
+	"github.com/pelletier/go-toml"
+)
+
+// maxHashSize limits the size of the files hashed to find their system,
+// bigger files are usually disc images that the databases don't identify
+const maxHashSize = 64 << 20
+
+// Info describes a core. Extensions are lower case, without the dot.
+type Info struct {
+	Name       string   `toml:"name"`
+	Version    string   `toml:"version"`
+	Extensions []string `toml:"extensions"`
+	Systems    []string `toml:"systems"`
+}
+
+var (
+	// registry maps the core file names, without extension, to their info
+	registry = map[string]Info{}
+	// learned are the extensions reported by the loaded cores, they are
+	// cached for the next launches
+	learned = map[string]Info{}
+	// usage maps the core file names to when they were last loaded
+	usage = map[string]Usage{}
+	mu    sync.Mutex
+)
+
+// Usage is the last use of a core, and the version it reported then
+type Usage struct {
+	LastUsed time.Time `toml:"last_used"`
+	Version  string    `toml:"version"`
+}
+
+// cachePath is where the extensions reported by the loaded cores are saved
+func cachePath() string {
+	return filepath.Join(xdg.CacheHome, "ludo", "coreinfo.toml")
+}
+
+// split parses a list of values separated by |
+func split(s string, lower bool) []string {
+	var list []string
+	for _, v := range strings.Split(s, "|") {
+		v = strings.TrimSpace(v)
+		if lower {
+			v = strings.ToLower(v)
+		}
+		if v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// Parse reads a .info file
+func Parse(b []byte) Info {
+	var info Info
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		i := strings.Index(line, "=")
+		if i < 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		value := strings.TrimSpace(line[i+1:])
+		if u, err := strconv.Unquote(value); err == nil {
+			value = u
+		}
+		switch strings.TrimSpace(line[:i]) {
+		case "display_name":
+			info.Name = value
+		case "display_version":
+			info.Version = value
+		case "supported_extensions":
+			info.Extensions = split(value, true)
+		case "database":
+			info.Systems = split(value, false)
+		}
+	}
+	return info
+}
+
+// Load reads the .info files found in the cores directory, or in its info
+// folder, and the extensions cached from the previous launches
+func Load() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	registry = map[string]Info{}
+	learned = map[string]Info{}
+	if b, err := ioutil.ReadFile(cachePath()); err == nil {
+		toml.Unmarshal(b, &learned)
+	}
+	for core, info := range learned {
+		registry[core] = info
+	}
+	usage = map[string]Usage{}
+	if b, err := ioutil.ReadFile(usagePath()); err == nil {
+		toml.Unmarshal(b, &usage)
+	}
+
+	dir := settings.Current.CoresDirectory
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.info"))
+	more, _ := filepath.Glob(filepath.Join(dir, "info", "*.info"))
+	for _, p := range append(paths, more...) {
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		info := Parse(b)
+		if l, ok := registry[utils.FileName(p)]; ok && len(info.Extensions) == 0 {
+			info.Extensions = l.Extensions
+		}
+		registry[utils.FileName(p)] = info
+	}
+}
+
+// Remember records the extensions reported by a loaded core, for the cores
+// without a .info file
+func Remember(corePath, name, extensions string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	core := utils.FileName(corePath)
+	info := registry[core]
+	exts := split(extensions, true)
+	if len(exts) == 0 || strings.Join(exts, "|") == strings.Join(info.Extensions, "|") {
+		return nil
+	}
+	if info.Name == "" {
+		info.Name = name
+	}
+	info.Extensions = exts
+	registry[core] = info
+	learned[core] = info
+
+	b, err := toml.Marshal(learned)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(cachePath()), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(cachePath(), b, 0644)
+}
+
+// Lookup returns what is known about a core
+func Lookup(corePath string) Info {
+	mu.Lock()
+	defer mu.Unlock()
+	return registry[utils.FileName(corePath)]
+}
+
+// contentExtensions returns the extensions of a content and, for zip
+// archives, of the first file they hold
+func contentExtensions(path string) []string {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	exts := []string{ext}
+	if ext != "zip" {
+		return exts
+	}
+	z, err := zip.OpenReader(path)
+	if err != nil {
+		return exts
+	}
+	defer z.Close()
+	for _, f := range z.File {
+		if !f.FileInfo().IsDir() {
+			return append(exts, strings.ToLower(strings.TrimPrefix(filepath.Ext(f.Name), ".")))
+		}
+	}
+	return exts
+}
+
+// Supports tells if a content has one of the extensions of a core, given as
+// a list separated by |. Cores that don't tell their extensions support
+// everything.
+func Supports(extensions, path string) bool {
+	exts := split(extensions, true)
+	if len(exts) == 0 {
+		return true
+	}
+	for _, e := range contentExtensions(path) {
+		if utils.StringInSlice(e, exts) {
+			return true
+		}
+	}
+	return false
+}
+
+// crc computes the checksum a database would list for a content
+func crc(path string) (uint32, bool) {
+	if strings.EqualFold(filepath.Ext(path), ".zip") {
+		z, err := zip.OpenReader(path)
+		if err != nil {
+			return 0, false
+		}
+		defer z.Close()
+		for _, f := range z.File {
+			if !f.FileInfo().IsDir() {
+				return f.CRC32, true
+			}
+		}
+		return 0, false
+	}
+	fi, err := os.Stat(path)
+	if err != nil || fi.Size() > maxHashSize {
+		return 0, false
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	return crc32.ChecksumIEEE(b), true
+}
+
+// Identify finds the system of a content in the game databases, it returns
+// an empty string for unknown games. The systems are looked up by name, for a
+// game listed by several of them. It hashes the content, it is meant to be
+// run in a goroutine.
+func Identify(db dat.DB, path string) string {
+	sum, ok := crc(path)
+	if !ok {
+		return ""
+	}
+	var systems []string
+	for system := range db {
+		systems = append(systems, system)
+	}
+	sort.Strings(systems)
+	for _, system := range systems {
+		for _, g := range db[system].Games {
+			if len(g.ROMs) > 0 && uint32(g.ROMs[0].CRC) == sum {
+				return system
+			}
+		}
+	}
+	return ""
+}
+
+// installed lists the file names of the cores of the cores directory
+func installed() []string {
+	paths, _ := filepath.Glob(filepath.Join(settings.Current.CoresDirectory, "*"+utils.CoreExt()))
+	var cores []string
+	for _, p := range paths {
+		cores = append(cores, utils.FileName(p))
+	}
+	sort.Strings(cores)
+	return cores
+}
+
+// Installed lists the paths of the cores of the cores directory
+func Installed() []string {
+	var paths []string
+	for _, core := range installed() {
+		paths = append(paths, filepath.Join(settings.Current.CoresDirectory, core+utils.CoreExt()))
+	}
+	return paths
+}
+
+// usagePath is where the last uses of the cores are saved
+func usagePath() string {
+	return filepath.Join(xdg.DataHome, "ludo", "cores_usage.toml")
+}
+
+// saveUsage writes the last uses of the cores
+func saveUsage() error {
+	b, err := toml.Marshal(usage)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(usagePath()), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(usagePath(), b, 0644)
+}
+
+// Touch records that a core was loaded, and the version it reported
+func Touch(corePath, version string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	usage[utils.FileName(corePath)] = Usage{LastUsed: time.Now(), Version: version}
+	return saveUsage()
+}
+
+// LastUsage returns the last use of a core, LastUsed is zero for the cores
+// never loaded
+func LastUsage(corePath string) Usage {
+	mu.Lock()
+	defer mu.Unlock()
+	return usage[utils.FileName(corePath)]
+}
+
+// Version returns the version reported by a core the last time it was
+// loaded, or the version of its .info file
+func Version(corePath string) string {
+	mu.Lock()
+	defer mu.Unlock()
+	core := utils.FileName(corePath)
+	if v := usage[core].Version; v != "" {
+		return v
+	}
+	return registry[core].Version
+}
+
+// configDirs are the folders of the per core configs, in the config folder
+var configDirs = []string{"cheats", "macros", "overrides", "remaps", "shaders", "viewports", "volumes"}
+
+// Configs lists the config files and folders of a core that exist: its
+// options file and its remaps, shaders, overrides and other per game configs
+func Configs(corePath string) []string {
+	name := utils.FileName(corePath)
+	paths := []string{filepath.Join(xdg.ConfigHome, "ludo", name+".toml")}
+	for _, dir := range configDirs {
+		paths = append(paths, filepath.Join(xdg.ConfigHome, "ludo", dir, name))
+	}
+	var found []string
+	for _, p := range paths {
+		if _, err := os.Stat(p); err == nil {
+			found = append(found, p)
+		}
+	}
+	return found
+}
+
+// Uninstall removes a core from the cores directory with its .info file, and
+// its configs if asked
+func Uninstall(corePath string, configs bool) error {
+	if err := os.Remove(corePath); err != nil {
+		return err
+	}
+	dir, name := filepath.Dir(corePath), utils.FileName(corePath)
+	for _, p := range []string{filepath.Join(dir, name+".info"), filepath.Join(dir, "info", name+".info")} {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if configs {
+		for _, p := range Configs(corePath) {
+			if err := os.RemoveAll(p); err != nil {
+				return err
+			}
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	core := utils.FileName(corePath)
+	delete(registry, core)
+	if _, ok := usage[core]; !ok {
+		return nil
+	}
+	delete(usage, core)
+	return saveUsage()
+}
+
+// Candidates lists the paths of the installed cores able to run a content.
+// When the system of the content is known, the default core of the system is
+// picked, or else the cores declaring the system.
+func Candidates(path, system string) []string {
+	mu.Lock()
+	defer mu.Unlock()
+
+	exts := contentExtensions(path)
+	var matches []string
+	for _, core := range installed() {
+		for _, e := range exts {
+			if utils.StringInSlice(e, registry[core].Extensions) {
+				matches = append(matches, core)
+				break
+			}
+		}
+	}
+
+	if system != "" {
+		def := settings.Current.CoreForPlaylist[system]
+		if utils.StringInSlice(def, installed()) {
+			if utils.StringInSlice(def, matches) || len(registry[def].Extensions) == 0 {
+				matches = []string{def}
+			}
+		}
+		var bySystem []string
+		for _, core := range matches {
+			if core == def || utils.StringInSlice(system, registry[core].Systems) {
+				bySystem = append(bySystem, core)
+			}
+		}
+		if len(bySystem) > 0 {
+			matches = bySystem
+		}
+	}
+
+	var paths []string
+	for _, core := range matches {
+		paths = append(paths, filepath.Join(settings.Current.CoresDirectory, core+utils.CoreExt()))
+	}
+	return paths
+}
@@ -0,0 +1,181 @@
+package coreinfo
+
+import (
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/adrg/xdg"
+	"github.com/libretro/ludo/dat"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/utils"
+)
+
+func TestParse(t *testing.T) {
+	got := Parse([]byte(`# Software Information
+display_name = "Nintendo - SNES / SFC (Snes9x - Current)"
+display_version = "1.62.3"
+supported_extensions = "smc|SFC|swc|fig"
+database = "Nintendo - Super Nintendo Entertainment System|Nintendo - Satellaview"
+`))
+	want := Info{
+		Name:       "Nintendo - SNES / SFC (Snes9x - Current)",
+		Version:    "1.62.3",
+		Extensions: []string{"smc", "sfc", "swc", "fig"},
+		Systems:    []string{"Nintendo - Super Nintendo Entertainment System", "Nintendo - Satellaview"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got = %+v, want %+v", got, want)
+	}
+}
+
+func TestIdentify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ludo-identify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "Game.bin")
+	content := []byte("game")
+	ioutil.WriteFile(path, content, 0644)
+	game := []dat.Game{{ROMs: []dat.ROM{{CRC: dat.CRC(crc32.ChecksumIEEE(content))}}}}
+	db := dat.DB{
+		"Sega - Mega-CD - Sega CD": {Games: game},
+		"Sega - 32X":               {Games: game},
+		"Sony - PlayStation":       {},
+	}
+
+	for i := 0; i < 10; i++ {
+		if got := Identify(db, path); got != "Sega - 32X" {
+			t.Fatalf("got %q, want the first system by name", got)
+		}
+	}
+	if got := Identify(db, filepath.Join(dir, "Missing.bin")); got != "" {
+		t.Errorf("got %q, want none", got)
+	}
+}
+
+func TestSupports(t *testing.T) {
+	tests := []struct {
+		exts string
+		path string
+		want bool
+	}{
+		{"sfc|smc", "/roms/Game.SFC", true},
+		{"sfc|smc", "/roms/Game.nes", false},
+		{"", "/roms/Game.nes", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := Supports(tt.exts, tt.path); got != tt.want {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ludo-coreinfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	prev := settings.Current
+	defer func() { settings.Current = prev }()
+	settings.Current.CoresDirectory = dir
+	settings.Current.CoreForPlaylist = map[string]string{
+		"Sega - Mega Drive - Genesis": "genesis_plus_gx_libretro",
+	}
+
+	for _, core := range []string{"genesis_plus_gx_libretro", "picodrive_libretro", "swanstation_libretro"} {
+		ioutil.WriteFile(filepath.Join(dir, core+utils.CoreExt()), nil, 0644)
+	}
+	prevRegistry := registry
+	defer func() { registry = prevRegistry }()
+	registry = map[string]Info{
+		"genesis_plus_gx_libretro": {Extensions: []string{"md", "bin", "cue"}},
+		"picodrive_libretro":       {Extensions: []string{"md", "bin", "32x"}, Systems: []string{"Sega - 32X"}},
+		"swanstation_libretro":     {Extensions: []string{"bin", "cue"}, Systems: []string{"Sony - PlayStation"}},
+		"snes9x_libretro":          {Extensions: []string{"sfc"}},
+	}
+	path := func(core string) string {
+		return filepath.Join(dir, core+utils.CoreExt())
+	}
+
+	tests := []struct {
+		name   string
+		game   string
+		system string
+		want   []string
+	}{
+		{"Lists the cores supporting the extension", "Sonic.md", "", []string{path("genesis_plus_gx_libretro"), path("picodrive_libretro")}},
+		{"Ignores the cores that aren't installed", "Mario.sfc", "", nil},
+		{"Picks the default core of the system", "Sonic.bin", "Sega - Mega Drive - Genesis", []string{path("genesis_plus_gx_libretro")}},
+		{"Picks the cores declaring the system", "Crash.bin", "Sony - PlayStation", []string{path("swanstation_libretro")}},
+		{"Keeps every match for unknown systems", "Game.bin", "Unknown", []string{path("genesis_plus_gx_libretro"), path("picodrive_libretro"), path("swanstation_libretro")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Candidates(tt.game, tt.system)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUninstall(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ludo-coreinfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	prevConfig, prevData := xdg.ConfigHome, xdg.DataHome
+	defer func() { xdg.ConfigHome, xdg.DataHome = prevConfig, prevData }()
+	xdg.ConfigHome = filepath.Join(dir, "config")
+	xdg.DataHome = filepath.Join(dir, "data")
+	usage = map[string]Usage{}
+
+	core := filepath.Join(dir, "snes9x_libretro"+utils.CoreExt())
+	ioutil.WriteFile(core, nil, 0644)
+	info := filepath.Join(dir, "snes9x_libretro.info")
+	ioutil.WriteFile(info, nil, 0644)
+	options := filepath.Join(xdg.ConfigHome, "ludo", "snes9x_libretro.toml")
+	remaps := filepath.Join(xdg.ConfigHome, "ludo", "remaps", "snes9x_libretro")
+	other := filepath.Join(xdg.ConfigHome, "ludo", "remaps", "mgba_libretro")
+	os.MkdirAll(remaps, os.ModePerm)
+	os.MkdirAll(other, os.ModePerm)
+	ioutil.WriteFile(options, nil, 0644)
+
+	if got := Configs(core); !reflect.DeepEqual(got, []string{options, remaps}) {
+		t.Errorf("Configs() = %v", got)
+	}
+
+	if err := Touch(core, "1.62.3"); err != nil {
+		t.Fatal(err)
+	}
+	if Version(core) != "1.62.3" || LastUsage(core).LastUsed.IsZero() {
+		t.Errorf("LastUsage() = %+v", LastUsage(core))
+	}
+
+	if err := Uninstall(core, true); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{core, info, options, remaps} {
+		if _, err := os.Stat(p); err == nil {
+			t.Errorf("%s should be removed", p)
+		}
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("the configs of the other cores should be kept")
+	}
+	if !LastUsage(core).LastUsed.IsZero() {
+		t.Errorf("the usage of the core should be forgotten")
+	}
+}
@@ -0,0 +1,248 @@
+// Package corethread runs the frames of a core on a dedicated OS thread,
+// decoupled from the window events and the menu. The core is paced at its
+// own framerate, and its frames are queued to be drawn by the main thread, so
+// that a slow menu or a slow buffer swap doesn't starve the audio.
+//
+// The main thread must hold the lock while it touches the core, to load a
+// savestate for example. The core thread only runs frames while active says
+// so, the main thread can then access the core freely.
+package corethread
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"github.com/libretro/ludo/crash"
+)
+
+// Frame is a copy of a frame of the core, in the pixel format of the core
+type Frame struct {
+	Data   []byte
+	Width  int32
+	Height int32
+	Pitch  int32
+	// Dupes is the number of frames since the previous one that the core
+	// didn't redraw or that were dropped, the previous frame stands for them
+	Dupes int
+	// Changes are the video changes the core asked for before this frame,
+	// like a new geometry or pixel format, see Defer
+	Changes []func()
+}
+
+// Apply makes the video changes of the frame, on the main thread
+func (f *Frame) Apply() {
+	for _, c := range f.Changes {
+		c()
+	}
+}
+
+// Pointer returns the address of the pixels of the frame, for the renderer
+func (f *Frame) Pointer() unsafe.Pointer {
+	if len(f.Data) == 0 {
+		return nil
+	}
+	return unsafe.Pointer(&f.Data[0])
+}
+
+// queueSize is the number of frames waiting for the renderer. Older frames
+// are dropped when the renderer lags, showing them late would only add
+// latency.
+const queueSize = 2
+
+var (
+	// mu is held by the core thread while it runs a frame
+	mu sync.Mutex
+
+	qmu   sync.Mutex
+	queue []Frame
+	dupes int // duplicated frames since the last queued one
+	pool  sync.Pool
+	// changes are the changes waiting for the next frame
+	changes []func()
+
+	// inFrame is set while the core thread runs a frame
+	inFrame int32
+
+	stop chan struct{}
+	done chan struct{}
+)
+
+// Lock prevents the core thread from running frames
+func Lock() {
+	mu.Lock()
+}
+
+// Unlock lets the core thread run frames again
+func Unlock() {
+	mu.Unlock()
+}
+
+// Running tells if the core thread is started
+func Running() bool {
+	return stop != nil
+}
+
+// Start launches the core thread. It calls frame at fps frames per second,
+// or as fast as possible when fps returns 0, as long as active returns true.
+func Start(frame func(dt float32), fps func() float64, active func() bool) {
+	if Running() {
+		return
+	}
+	stop = make(chan struct{})
+	done = make(chan struct{})
+	go loop(frame, fps, active, stop, done)
+}
+
+// Stop waits for the current frame and ends the core thread. The frames
+// left in the queue are dropped.
+func Stop() {
+	if !Running() {
+		return
+	}
+	close(stop)
+	<-done
+	stop, done = nil, nil
+	qmu.Lock()
+	var pending []func()
+	for _, f := range queue {
+		pending = append(pending, f.Changes...)
+	}
+	pending = append(pending, changes...)
+	queue = nil
+	dupes = 0
+	changes = nil
+	qmu.Unlock()
+	// The changes of the dropped frames still apply to the next ones
+	for _, c := range pending {
+		c()
+	}
+}
+
+func loop(frame func(dt float32), fps func() float64, active func() bool, stop, done chan struct{}) {
+	defer crash.Recover()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	defer close(done)
+
+	var next time.Time
+	prev := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		mu.Lock()
+		ran := active()
+		rate := fps()
+		now := time.Now()
+		if ran {
+			atomic.StoreInt32(&inFrame, 1)
+			frame(float32(now.Sub(prev).Seconds()))
+			atomic.StoreInt32(&inFrame, 0)
+		}
+		mu.Unlock()
+		prev = now
+
+		if !ran {
+			// Idle while the menu is open or the game is paused
+			next = time.Time{}
+			time.Sleep(5 * time.Millisecond)
+			continue
+		}
+		next = pace(next, rate, time.Now())
+	}
+}
+
+// pace sleeps until the next frame is due and returns its due time. It
+// starts over after a hiccup instead of running fast to catch up.
+func pace(next time.Time, fps float64, now time.Time) time.Time {
+	if fps <= 0 {
+		return time.Time{}
+	}
+	period := time.Duration(float64(time.Second) / fps)
+	if next.IsZero() {
+		next = now
+	}
+	next = next.Add(period)
+	if next.Before(now.Add(-period)) {
+		return now
+	}
+	time.Sleep(next.Sub(now))
+	return next
+}
+
+// Defer delays a change of the video until the main thread draws the next
+// frame when it is called by the core thread, the video can't be touched from
+// there. Otherwise the change is made right away.
+func Defer(change func()) {
+	if atomic.LoadInt32(&inFrame) == 0 {
+		change()
+		return
+	}
+	qmu.Lock()
+	changes = append(changes, change)
+	qmu.Unlock()
+}
+
+// Refresh is the video callback of the core when it runs on the core thread.
+// The frame is copied, the core reuses its buffer. Duplicated frames, with
+// nil data, aren't queued but counted in the next frame.
+func Refresh(data unsafe.Pointer, width, height, pitch int32) {
+	if data == nil || height <= 0 || pitch <= 0 {
+		qmu.Lock()
+		dupes++
+		qmu.Unlock()
+		return
+	}
+	size := int(pitch * height)
+	src := (*[1 << 30]byte)(data)[:size:size]
+
+	f := Frame{Width: width, Height: height, Pitch: pitch}
+	if b, ok := pool.Get().([]byte); ok && cap(b) >= size {
+		f.Data = b[:size]
+	} else {
+		f.Data = make([]byte, size)
+	}
+	copy(f.Data, src)
+
+	qmu.Lock()
+	defer qmu.Unlock()
+	f.Dupes, dupes = dupes, 0
+	f.Changes, changes = changes, nil
+	if len(queue) == queueSize {
+		// The next frame stands for the dropped one, and makes its changes
+		next := &f
+		if len(queue) > 1 {
+			next = &queue[1]
+		}
+		next.Dupes += queue[0].Dupes + 1
+		next.Changes = append(queue[0].Changes, next.Changes...)
+		Release(queue[0])
+		queue = queue[1:]
+	}
+	queue = append(queue, f)
+}
+
+// Next pops the oldest frame of the queue
+func Next() (Frame, bool) {
+	qmu.Lock()
+	defer qmu.Unlock()
+	if len(queue) == 0 {
+		return Frame{}, false
+	}
+	f := queue[0]
+	queue = queue[1:]
+	return f, true
+}
+
+// Release gives the buffer of a frame back once it is drawn
+func Release(f Frame) {
+	if f.Data != nil {
+		pool.Put(f.Data[:0])
+	}
+}
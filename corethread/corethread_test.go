@@ -0,0 +1,159 @@
+package corethread
+
+import (
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func TestRefreshQueue(t *testing.T) {
+	defer func() { queue = nil }()
+
+	pixels := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	Refresh(unsafe.Pointer(&pixels[0]), 2, 2, 4)
+	// The core reuses its buffer
+	pixels[0] = 9
+
+	f, ok := Next()
+	if !ok {
+		t.Fatal("expected a frame")
+	}
+	if f.Width != 2 || f.Height != 2 || f.Pitch != 4 || len(f.Data) != 8 {
+		t.Errorf("unexpected frame %+v", f)
+	}
+	if f.Data[0] != 1 {
+		t.Errorf("the frame wasn't copied, got %d", f.Data[0])
+	}
+	Release(f)
+
+	if _, ok := Next(); ok {
+		t.Error("expected an empty queue")
+	}
+}
+
+func TestRefreshSkipsDupes(t *testing.T) {
+	defer func() { queue, dupes = nil, 0 }()
+
+	Refresh(nil, 2, 2, 4)
+	if _, ok := Next(); ok {
+		t.Error("duplicated frames shouldn't be queued")
+	}
+	Refresh(nil, 2, 2, 4)
+	pixels := []byte{1, 2}
+	Refresh(unsafe.Pointer(&pixels[0]), 1, 1, 2)
+	if f, ok := Next(); !ok || f.Dupes != 2 {
+		t.Errorf("expected a frame standing for 2 dupes, got %+v", f)
+	}
+}
+
+func TestRefreshDropsOldFrames(t *testing.T) {
+	defer func() { queue = nil }()
+
+	for i := 0; i < queueSize+2; i++ {
+		pixels := []byte{byte(i), 0}
+		Refresh(unsafe.Pointer(&pixels[0]), 1, 1, 2)
+	}
+	var got []byte
+	var dropped int
+	for {
+		f, ok := Next()
+		if !ok {
+			break
+		}
+		got = append(got, f.Data[0])
+		dropped += f.Dupes
+	}
+	if len(got) != queueSize || got[0] != 2 || got[len(got)-1] != queueSize+1 {
+		t.Errorf("expected the last %d frames, got %v", queueSize, got)
+	}
+	if dropped != 2 {
+		t.Errorf("expected the 2 dropped frames to be counted, got %d", dropped)
+	}
+}
+
+func TestDefer(t *testing.T) {
+	defer func() { queue, changes, inFrame = nil, nil, 0 }()
+
+	var got []int
+	Defer(func() { got = append(got, 0) })
+	if len(got) != 1 {
+		t.Fatal("changes outside of a frame should be made right away")
+	}
+
+	inFrame = 1
+	Defer(func() { got = append(got, 1) })
+	for i := 0; i < queueSize+1; i++ {
+		pixels := []byte{byte(i), 0}
+		Refresh(unsafe.Pointer(&pixels[0]), 1, 1, 2)
+		Defer(func() { got = append(got, 2) })
+	}
+	if len(got) != 1 {
+		t.Fatal("changes during a frame should wait for the main thread")
+	}
+
+	// The first frame was dropped, the next one makes its change
+	f, _ := Next()
+	f.Apply()
+	if want := []int{0, 1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestPace(t *testing.T) {
+	now := time.Now()
+
+	if next := pace(time.Time{}, 0, now); !next.IsZero() {
+		t.Errorf("fast-forward shouldn't be paced, got %v", next)
+	}
+
+	start := time.Now()
+	next := pace(time.Time{}, 100, start)
+	if want := start.Add(10 * time.Millisecond); !next.Equal(want) {
+		t.Errorf("expected %v, got %v", want, next)
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("expected to wait a frame, waited %v", elapsed)
+	}
+
+	// Late by more than a frame, start over
+	late := now.Add(-time.Second)
+	if next := pace(late, 100, now); !next.Equal(now) {
+		t.Errorf("expected to start over at %v, got %v", now, next)
+	}
+}
+
+func TestStartStop(t *testing.T) {
+	var frames int32
+	var active int32 = 1
+	Start(func(dt float32) {
+		atomic.AddInt32(&frames, 1)
+	}, func() float64 {
+		return 1000
+	}, func() bool {
+		return atomic.LoadInt32(&active) == 1
+	})
+	if !Running() {
+		t.Fatal("expected the core thread to run")
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	// Once inactive, the core thread doesn't run frames past the lock
+	atomic.StoreInt32(&active, 0)
+	Lock()
+	n := atomic.LoadInt32(&frames)
+	Unlock()
+	time.Sleep(20 * time.Millisecond)
+	Stop()
+
+	if n == 0 {
+		t.Error("expected frames to run")
+	}
+	if got := atomic.LoadInt32(&frames); got != n {
+		t.Errorf("expected %d frames once inactive, got %d", n, got)
+	}
+	if Running() {
+		t.Error("expected the core thread to be stopped")
+	}
+}
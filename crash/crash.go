@@ -0,0 +1,310 @@
+// Package crash writes crash reports, when Ludo panics or when a core crashes
+// the whole process. Reports hold the stack trace, the recent logs, the core
+// and the checksum of the content, but not its file name. They are only sent
+// if the user accepts to.
+package crash
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/libretro/ludo/logs"
+	"github.com/libretro/ludo/settings"
+)
+
+// Report is a crash report
+type Report struct {
+	Time        time.Time
+	Reason      string
+	Stack       string `json:",omitempty"`
+	OS          string
+	Arch        string
+	Core        string `json:",omitempty"`
+	CoreVersion string `json:",omitempty"`
+	ContentCRC  string `json:",omitempty"`
+	Logs        []string
+}
+
+// session is the game running, saved to disk so that a crash of the core
+// can be detected on the next launch
+type session struct {
+	Core        string
+	CoreVersion string
+	ContentCRC  string
+	// secrets are the paths to redact from the logs
+	Secrets []string
+	// Dirs are the directories whose files are redacted from the logs
+	Dirs []string
+}
+
+// maxLogs is the number of log lines in a report
+const maxLogs = 200
+
+var client = &http.Client{Timeout: 30 * time.Second}
+
+// current is the game running, if any
+var current *session
+
+// reported is set once a panic was reported. A panic caught by a goroutine
+// goes on and reaches the deferred Recover of the callers.
+var reported int32
+
+func dir() string {
+	return filepath.Join(xdg.StateHome, "ludo", "crashes")
+}
+
+// pendingDir holds the reports the user wasn't asked about yet
+func pendingDir() string {
+	return filepath.Join(dir(), "pending")
+}
+
+func sessionPath() string {
+	return filepath.Join(dir(), "session.json")
+}
+
+// redactUnder hides the paths under a directory in a line of the logs. A path
+// goes until the end of the line, a quote, or a colon followed by a space.
+func redactUnder(s, dir string) string {
+	dir = strings.TrimRight(dir, `/\`)
+	if dir == "" {
+		return s
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(s, dir)
+		if i < 0 {
+			break
+		}
+		end := i + len(dir)
+		// Only the paths inside dir, not /roms2 for /roms
+		if end < len(s) && s[end] != '/' && s[end] != '\\' {
+			b.WriteString(s[:end])
+			s = s[end:]
+			continue
+		}
+		rest := s[end:]
+		n := strings.IndexAny(rest, "\"'\n")
+		if c := strings.Index(rest, ": "); c >= 0 && (n < 0 || c < n) {
+			n = c
+		}
+		if n < 0 {
+			n = len(rest)
+		}
+		b.WriteString(s[:i])
+		b.WriteString("<redacted>")
+		s = rest[n:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// redact hides the paths of the content in a line of the logs: the files of
+// the private directories, then the secrets, the longest first
+func redact(s string, dirs, secrets []string) string {
+	for _, d := range dirs {
+		s = redactUnder(s, d)
+	}
+	sorted := append([]string{}, secrets...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	for _, p := range sorted {
+		if p != "" {
+			s = strings.Replace(s, p, "<redacted>", -1)
+		}
+	}
+	return s
+}
+
+// secrets are the paths identifying the user or the content
+func secrets(gamePath string) []string {
+	home, _ := os.UserHomeDir()
+	l := []string{home}
+	if gamePath != "" {
+		base := filepath.Base(gamePath)
+		l = append(l, gamePath, base, strings.TrimSuffix(base, filepath.Ext(base)))
+	}
+	return l
+}
+
+// privateDirs are the directories holding the games, the saves and the
+// playlists, along with the directory of the game running
+func privateDirs(gamePath string) []string {
+	s := settings.Current
+	l := []string{s.FileDirectory, s.SavefilesDirectory, s.SavestatesDirectory, s.PlaylistsDirectory}
+	if gamePath != "" {
+		l = append(l, filepath.Dir(gamePath))
+	}
+	return l
+}
+
+// recentLogs formats the recent logs, oldest first
+func recentLogs(dirs, secrets []string) []string {
+	entries := logs.Recent(logs.Debug, "")
+	if len(entries) > maxLogs {
+		entries = entries[:maxLogs]
+	}
+	var l []string
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		line := fmt.Sprintf("%s %s [%s]: %s", e.Time.Format("15:04:05"), logs.Levels[e.Level], e.Tag, e.Message)
+		l = append(l, redact(line, dirs, secrets))
+	}
+	return l
+}
+
+// tail returns the last lines of the log file of the previous session
+func tail(path string, n int, dirs, secrets []string) []string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var l []string
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		l = append(l, redact(scanner.Text(), dirs, secrets))
+	}
+	if len(l) > n {
+		l = l[len(l)-n:]
+	}
+	return l
+}
+
+// write saves a report in the pending reports
+func write(r Report) (string, error) {
+	if err := os.MkdirAll(pendingDir(), os.ModePerm); err != nil {
+		return "", err
+	}
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(pendingDir(), "crash-"+r.Time.Format("2006-01-02-15-04-05")+".json")
+	return path, ioutil.WriteFile(path, b, 0644)
+}
+
+// newReport fills the details of a report common to all crashes
+func newReport(reason string, s *session) Report {
+	r := Report{Time: time.Now(), Reason: reason, OS: runtime.GOOS, Arch: runtime.GOARCH}
+	if s != nil {
+		r.Core, r.CoreVersion, r.ContentCRC = s.Core, s.CoreVersion, s.ContentCRC
+	}
+	return r
+}
+
+// SetGame remembers the game running, until ClearGame is called. If Ludo
+// doesn't exit cleanly in between, the core is considered crashed on the next
+// launch.
+func SetGame(core, coreVersion, gamePath string, crc uint32) {
+	current = &session{
+		Core:        core,
+		CoreVersion: coreVersion,
+		ContentCRC:  fmt.Sprintf("%08X", crc),
+		Secrets:     secrets(gamePath),
+		Dirs:        privateDirs(gamePath),
+	}
+	b, err := json.Marshal(current)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(dir(), os.ModePerm); err != nil {
+		return
+	}
+	ioutil.WriteFile(sessionPath(), b, 0644)
+}
+
+// ClearGame forgets the game running, once it is unloaded normally
+func ClearGame() {
+	current = nil
+	os.Remove(sessionPath())
+}
+
+// Check looks for a game that was running when Ludo last exited, the sign
+// that the core crashed. It writes a report with the end of the previous log
+// file, so it has to be called before the log file is opened again.
+func Check() {
+	b, err := ioutil.ReadFile(sessionPath())
+	if err != nil {
+		return
+	}
+	os.Remove(sessionPath())
+	var s session
+	if err := json.Unmarshal(b, &s); err != nil {
+		return
+	}
+	r := newReport("The core crashed or Ludo was killed while a game was running", &s)
+	r.Logs = tail(logs.Path(), maxLogs, s.Dirs, s.Secrets)
+	if _, err := write(r); err != nil {
+		logs.Errorf("Crash", "Can't write the crash report: %v", err)
+	}
+}
+
+// Recover writes a report when Ludo panics, then lets the panic go on. It has
+// to be deferred by the main function and by the goroutines, a panic only
+// being caught in its own goroutine.
+func Recover() {
+	v := recover()
+	if v == nil {
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&reported, 0, 1) {
+		panic(v)
+	}
+	r := newReport(fmt.Sprint(v), current)
+	sec, dirs := secrets(""), privateDirs("")
+	if current != nil {
+		sec, dirs = current.Secrets, current.Dirs
+	}
+	r.Stack = redact(string(debug.Stack()), dirs, sec)
+	r.Logs = recentLogs(dirs, sec)
+	if path, err := write(r); err == nil {
+		logs.Errorf("Crash", "Crash report written to %s", path)
+	}
+	os.Remove(sessionPath())
+	panic(v)
+}
+
+// Pending returns the reports the user wasn't asked about yet
+func Pending() []string {
+	paths, _ := filepath.Glob(filepath.Join(pendingDir(), "*.json"))
+	return paths
+}
+
+// Keep moves a pending report with the older ones, once the user was asked
+// about it, and returns its new location
+func Keep(path string) (string, error) {
+	dest := filepath.Join(dir(), filepath.Base(path))
+	return dest, os.Rename(path, dest)
+}
+
+// Upload sends a report, it blocks
+func Upload(path, url string) error {
+	if url == "" {
+		return errors.New("no crash report server")
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("the crash report server returned %v", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package crash
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/adrg/xdg"
+)
+
+func Test_redact(t *testing.T) {
+	sec := []string{"/home/user", "/home/user/roms/Sonic (USA).md", "Sonic (USA).md", "Sonic (USA)"}
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Game loaded: /home/user/roms/Sonic (USA).md", "Game loaded: <redacted>"},
+		{"Loading Sonic (USA).srm", "Loading <redacted>.srm"},
+		{"/home/user/.config/ludo", "<redacted>/.config/ludo"},
+		{"nothing to hide", "nothing to hide"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := redact(tt.in, nil, sec); got != tt.want {
+				t.Errorf("redact() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_redactUnder(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Loading /saves/Sonic (USA).srm", "Loading <redacted>"},
+		{"open /saves/Sonic.srm: no such file", "open <redacted>: no such file"},
+		{`Scanning "/saves/a.md" and "/saves/b.md"`, `Scanning "<redacted>" and "<redacted>"`},
+		{"Loading /saves2/Sonic.srm", "Loading /saves2/Sonic.srm"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := redactUnder(tt.in, "/saves/"); got != tt.want {
+				t.Errorf("redactUnder() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_Check(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ludo-crash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	state := xdg.StateHome
+	xdg.StateHome = tmp
+	defer func() { xdg.StateHome = state }()
+
+	log := filepath.Join(tmp, "ludo", "ludo.log")
+	os.MkdirAll(filepath.Dir(log), os.ModePerm)
+	ioutil.WriteFile(log, []byte("INFO  [Core]: Game loaded: /roms/Sonic.md\nERROR [Genesis Plus GX]: boom\n"), 0644)
+
+	SetGame("Genesis Plus GX", "1.7.4", "/roms/Sonic.md", 0xDEADBEEF)
+	Check()
+	if len(Pending()) != 1 {
+		t.Fatalf("Pending() = %v, want a report", Pending())
+	}
+
+	b, err := ioutil.ReadFile(Pending()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r Report
+	if err := json.Unmarshal(b, &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Core != "Genesis Plus GX" || r.CoreVersion != "1.7.4" || r.ContentCRC != "DEADBEEF" {
+		t.Errorf("report = %+v", r)
+	}
+	want := []string{"INFO  [Core]: Game loaded: <redacted>", "ERROR [Genesis Plus GX]: boom"}
+	if !reflect.DeepEqual(r.Logs, want) {
+		t.Errorf("Logs = %v, want %v", r.Logs, want)
+	}
+
+	// The session is gone, the next launch is clean
+	Check()
+	if len(Pending()) != 1 {
+		t.Errorf("Pending() = %v, want a single report", Pending())
+	}
+	if _, err := Keep(Pending()[0]); err != nil {
+		t.Fatal(err)
+	}
+	if len(Pending()) != 0 {
+		t.Errorf("Pending() = %v, want none", Pending())
+	}
+}
@@ -0,0 +1,72 @@
+package dat
+
+import "encoding/xml"
+
+// archiveDat is the newer export of No-Intro. The games describe their
+// archive, and list the files of each source they were dumped from.
+type archiveDat struct {
+	XMLName xml.Name      `xml:"datafile"`
+	Games   []archiveGame `xml:"game"`
+}
+
+type archiveGame struct {
+	Name    string `xml:"name,attr"`
+	Archive struct {
+		Name   string `xml:"name,attr"`
+		Region string `xml:"region,attr"`
+	} `xml:"archive"`
+	Sources []struct {
+		Files []archiveFile `xml:"file"`
+	} `xml:"source"`
+}
+
+type archiveFile struct {
+	ForceName string `xml:"forcename,attr"`
+	Extension string `xml:"extension,attr"`
+	CRC       CRC    `xml:"crc32,attr"`
+	Size      Size   `xml:"size,attr"`
+}
+
+// name returns the name of a game, built from its archive when the game
+// doesn't tell it
+func (g archiveGame) name() string {
+	if g.Name != "" {
+		return g.Name
+	}
+	if g.Archive.Region == "" {
+		return g.Archive.Name
+	}
+	return g.Archive.Name + " (" + g.Archive.Region + ")"
+}
+
+// parseArchiveDat reads the newer export of No-Intro as a Dat. The files of
+// the sources become the ROMs of the games, once per checksum.
+func parseArchiveDat(b []byte) Dat {
+	var d archiveDat
+	warnings := unmarshal(b, &d)
+
+	output := Dat{Warnings: warnings}
+	for _, g := range d.Games {
+		name := g.name()
+		game := Game{Name: name, Description: name}
+		seen := map[CRC]bool{}
+		for _, s := range g.Sources {
+			for _, f := range s.Files {
+				if seen[f.CRC] {
+					continue
+				}
+				seen[f.CRC] = true
+				romName := f.ForceName
+				if romName == "" {
+					romName = name
+					if f.Extension != "" {
+						romName += "." + f.Extension
+					}
+				}
+				game.ROMs = append(game.ROMs, ROM{Name: romName, CRC: f.CRC, Size: f.Size})
+			}
+		}
+		output.Games = append(output.Games, game)
+	}
+	return output
+}
@@ -0,0 +1,49 @@
+package dat
+
+import (
+	"reflect"
+	"testing"
+)
+
+const archiveSchema = `<?xml version="1.0" encoding="UTF-8"?>
+<datafile>
+	<header>
+		<name>Nintendo - Game Boy</name>
+	</header>
+	<game name="Tetris (World) (Rev 1)">
+		<archive number="0417" name="Tetris" region="World" languages="En" />
+		<source>
+			<details section="Trusted" />
+			<file forcename="" extension="gb" size="32768" crc32="46df91ad" />
+		</source>
+		<source>
+			<details section="Trusted" />
+			<file forcename="" extension="gb" size="32768" crc32="46df91ad" />
+		</source>
+	</game>
+	<game>
+		<archive number="0001" name="Alleyway" region="USA, Europe" />
+		<source>
+			<file forcename="Alleyway (World).gb" extension="gb" crc32="6c8f1385" />
+		</source>
+	</game>
+</datafile>`
+
+func TestParseArchiveDat(t *testing.T) {
+	got := Parse([]byte(archiveSchema))
+	want := []Game{
+		{
+			Name:        "Tetris (World) (Rev 1)",
+			Description: "Tetris (World) (Rev 1)",
+			ROMs:        []ROM{{Name: "Tetris (World) (Rev 1).gb", CRC: 0x46df91ad, Size: 32768}},
+		},
+		{
+			Name:        "Alleyway (USA, Europe)",
+			Description: "Alleyway (USA, Europe)",
+			ROMs:        []ROM{{Name: "Alleyway (World).gb", CRC: 0x6c8f1385}},
+		},
+	}
+	if !reflect.DeepEqual(got.Games, want) {
+		t.Errorf("got = %+v, want %+v", got.Games, want)
+	}
+}
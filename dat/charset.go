@@ -0,0 +1,169 @@
+package dat
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// cp1252 maps the bytes 0x80 to 0x9f of Windows-1252 to their runes, the
+// other bytes are the same as in ISO-8859-1
+var cp1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8d, 'Ž', 0x8f,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9d, 'ž', 'Ÿ',
+}
+
+// singleByteReader decodes a single byte charset to UTF-8
+type singleByteReader struct {
+	r    *bufio.Reader
+	high *[32]rune
+	buf  []byte
+}
+
+func (s *singleByteReader) Read(p []byte) (int, error) {
+	for len(s.buf) < len(p) {
+		c, err := s.r.ReadByte()
+		if err != nil {
+			if len(s.buf) == 0 {
+				return 0, err
+			}
+			break
+		}
+		r := rune(c)
+		if s.high != nil && c >= 0x80 && c < 0xa0 {
+			r = s.high[c-0x80]
+		}
+		s.buf = append(s.buf, string(r)...)
+	}
+	n := copy(p, s.buf)
+	s.buf = s.buf[n:]
+	return n, nil
+}
+
+// charsets are the single byte charsets declared by dats, with the runes of
+// their bytes 0x80 to 0x9f
+var charsets = map[string]*[32]rune{
+	"iso-8859-1":   nil,
+	"iso8859-1":    nil,
+	"latin1":       nil,
+	"windows-1252": &cp1252,
+	"cp1252":       &cp1252,
+}
+
+// charsetReader decodes the charsets declared by some dats. Unknown charsets
+// are read as UTF-8, with a warning.
+func charsetReader(warn func(string)) func(string, io.Reader) (io.Reader, error) {
+	return func(charset string, input io.Reader) (io.Reader, error) {
+		if high, ok := charsets[strings.ToLower(charset)]; ok {
+			return &singleByteReader{r: bufio.NewReader(input), high: high}, nil
+		}
+		switch strings.ToLower(charset) {
+		case "us-ascii", "ascii", "utf8":
+			return input, nil
+		}
+		warn(fmt.Sprintf("unknown charset %s, read as UTF-8", charset))
+		return input, nil
+	}
+}
+
+// skipDoctype returns the length of the DOCTYPE declaration at the start of
+// b, its internal subset included, and tells if it had one
+func skipDoctype(b []byte) (int, bool) {
+	depth := 0
+	var quote byte
+	subset := false
+	for i := len("<!DOCTYPE"); i < len(b); i++ {
+		c := b[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+			subset = true
+		case c == ']':
+			depth--
+		case c == '>' && depth <= 0:
+			return i + 1, subset
+		}
+	}
+	return len(b), subset
+}
+
+// prepare cleans a dat before parsing it. The byte order mark and the
+// DOCTYPE are removed, the DTDs aren't used and their internal subsets
+// confuse the XML parser, and so are the control characters that XML
+// forbids. What was changed is returned as warnings.
+func prepare(b []byte) ([]byte, []string) {
+	var warnings []string
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+
+	if i := bytes.Index(b, []byte("<!DOCTYPE")); i >= 0 {
+		n, subset := skipDoctype(b[i:])
+		if subset {
+			warnings = append(warnings, "DOCTYPE with an internal subset ignored")
+		}
+		b = append(append([]byte{}, b[:i]...), b[i+n:]...)
+	}
+
+	clean := make([]byte, 0, len(b))
+	for _, c := range b {
+		if c >= 0x20 || c == '\t' || c == '\n' || c == '\r' {
+			clean = append(clean, c)
+		}
+	}
+	if n := len(b) - len(clean); n > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d control characters removed", n))
+	}
+
+	// Dats saved by old tools can be in Windows-1252 without declaring it
+	if !utf8.Valid(clean) {
+		if _, ok := charsets[strings.ToLower(declaredCharset(clean))]; !ok {
+			var buf bytes.Buffer
+			io.Copy(&buf, &singleByteReader{r: bufio.NewReader(bytes.NewReader(clean)), high: &cp1252})
+			clean = buf.Bytes()
+			warnings = append(warnings, "invalid UTF-8, read as Windows-1252")
+		}
+	}
+	return clean, warnings
+}
+
+var declaration = regexp.MustCompile(`^\s*<\?xml[^>]*encoding=["']([^"']+)["']`)
+
+// declaredCharset returns the charset of the XML declaration of a dat
+func declaredCharset(b []byte) string {
+	if m := declaration.FindSubmatch(b); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+// newDecoder creates a lenient XML decoder: the declared charsets are
+// decoded, the HTML entities are known and the unknown ones are kept as is
+func newDecoder(b []byte, warn func(string)) *xml.Decoder {
+	d := xml.NewDecoder(bytes.NewReader(b))
+	d.CharsetReader = charsetReader(warn)
+	d.Strict = false
+	d.Entity = xml.HTMLEntity
+	return d
+}
+
+// unmarshal decodes a dat and returns the warnings of the decoding
+func unmarshal(b []byte, v interface{}) []string {
+	var warnings []string
+	warn := func(w string) {
+		warnings = append(warnings, w)
+	}
+	if err := newDecoder(b, warn).Decode(v); err != nil {
+		warn(err.Error())
+	}
+	return warnings
+}
@@ -0,0 +1,53 @@
+package dat
+
+import "testing"
+
+func TestParseTolerance(t *testing.T) {
+	tests := []struct {
+		name     string
+		dat      string
+		want     string
+		warnings int
+	}{
+		{
+			name: "ISO-8859-1",
+			dat: "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n" +
+				"<datafile><game name=\"Pok\xe9mon\"><description>Pok\xe9mon</description><rom name=\"a.gb\" crc=\"1\"/></game></datafile>",
+			want: "Pokémon",
+		},
+		{
+			name:     "Undeclared Windows-1252",
+			dat:      "<datafile><game name=\"a\"><description>\x93Quoted\x94</description><rom name=\"a.gb\" crc=\"1\"/></game></datafile>",
+			want:     "“Quoted”",
+			warnings: 1,
+		},
+		{
+			name: "DOCTYPE with an internal subset",
+			dat: "<?xml version=\"1.0\"?>\n" +
+				"<!DOCTYPE datafile [\n<!ELEMENT datafile (header?, game*)>\n<!ENTITY pub \"Publisher\">\n]>\n" +
+				"<datafile><game name=\"a\"><description>Caf&eacute; &amp; more</description><rom name=\"a.gb\" crc=\"1\"/></game></datafile>",
+			want:     "Café & more",
+			warnings: 1,
+		},
+		{
+			name:     "Control characters",
+			dat:      "<datafile><game name=\"a\"><description>Game\x01</description><rom name=\"a.gb\" crc=\"1\"/></game></datafile>\x1a",
+			want:     "Game",
+			warnings: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse([]byte(tt.dat))
+			if len(got.Games) != 1 {
+				t.Fatalf("got %d games, want 1, warnings %v", len(got.Games), got.Warnings)
+			}
+			if got.Games[0].Description != tt.want {
+				t.Errorf("got = %v, want %v", got.Games[0].Description, tt.want)
+			}
+			if len(got.Warnings) != tt.warnings {
+				t.Errorf("got warnings %v, want %d", got.Warnings, tt.warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package dat
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kinds of the problems found in dats
+const (
+	DuplicateCRC   = "duplicate CRC"
+	NoROMs         = "no ROMs"
+	MalformedHash  = "malformed hash"
+	NameCollision  = "name collision"
+	ParsingWarning = "parsing"
+)
+
+// Problem is something wrong in a dat, Game is the game it was found in, if
+// any
+type Problem struct {
+	Kind   string
+	Game   string
+	Detail string
+}
+
+// Report is the result of the validation of a dat
+type Report struct {
+	Games    int
+	ROMs     int
+	Problems []Problem
+}
+
+// String formats a report for the terminal
+func (r Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d games, %d ROMs, %d problems\n", r.Games, r.ROMs, len(r.Problems))
+	for _, p := range r.Problems {
+		if p.Game != "" {
+			fmt.Fprintf(&b, "%s: %s: %s\n", p.Kind, p.Game, p.Detail)
+		} else {
+			fmt.Fprintf(&b, "%s: %s\n", p.Kind, p.Detail)
+		}
+	}
+	return b.String()
+}
+
+var hashes = map[string]*regexp.Regexp{
+	"crc":    regexp.MustCompile(`^[0-9a-fA-F]{8}$`),
+	"crc32":  regexp.MustCompile(`^[0-9a-fA-F]{8}$`),
+	"md5":    regexp.MustCompile(`^[0-9a-fA-F]{32}$`),
+	"sha1":   regexp.MustCompile(`^[0-9a-fA-F]{40}$`),
+	"sha256": regexp.MustCompile(`^[0-9a-fA-F]{64}$`),
+}
+
+// checkHashes looks for the hashes that aren't hexadecimal numbers of the
+// right length, in any schema
+func checkHashes(b []byte) []Problem {
+	var problems []Problem
+	var game string
+	d := newDecoder(b, func(string) {})
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return problems
+		}
+		se, ok := t.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		for _, a := range se.Attr {
+			switch {
+			case (se.Name.Local == "game" || se.Name.Local == "software") && a.Name.Local == "name":
+				game = a.Value
+			case hashes[a.Name.Local] != nil && a.Value != "" && a.Value != "-":
+				if !hashes[a.Name.Local].MatchString(a.Value) {
+					problems = append(problems, Problem{MalformedHash, game,
+						fmt.Sprintf("%s %q of %s", a.Name.Local, a.Value, se.Name.Local)})
+				}
+			}
+		}
+	}
+}
+
+// Check validates a dat. It reports the games without ROMs, the games
+// sharing the checksum of their first ROM, which the scanner can't tell
+// apart, the games sharing a name and the malformed hashes.
+func Check(b []byte) Report {
+	d := Parse(b)
+	r := Report{Games: len(d.Games)}
+	for _, w := range d.Warnings {
+		r.Problems = append(r.Problems, Problem{Kind: ParsingWarning, Detail: w})
+	}
+
+	byCRC := map[CRC][]string{}
+	byName := map[string][]string{}
+	for _, g := range d.Games {
+		r.ROMs += len(g.ROMs)
+		if len(g.ROMs) == 0 {
+			r.Problems = append(r.Problems, Problem{Kind: NoROMs, Game: g.Name})
+		} else if g.ROMs[0].CRC != 0 {
+			byCRC[g.ROMs[0].CRC] = append(byCRC[g.ROMs[0].CRC], g.Name)
+		}
+		key := strings.ToLower(g.Name)
+		byName[key] = append(byName[key], g.Name)
+	}
+
+	var dups []Problem
+	for crc, names := range byCRC {
+		if len(names) > 1 {
+			dups = append(dups, Problem{DuplicateCRC, names[0],
+				fmt.Sprintf("%08x is also the CRC of %s", uint32(crc), strings.Join(names[1:], ", "))})
+		}
+	}
+	for _, names := range byName {
+		if len(names) > 1 {
+			dups = append(dups, Problem{NameCollision, names[0],
+				fmt.Sprintf("%d games have this name", len(names))})
+		}
+	}
+	sort.Slice(dups, func(i, j int) bool {
+		if dups[i].Kind != dups[j].Kind {
+			return dups[i].Kind < dups[j].Kind
+		}
+		return dups[i].Game < dups[j].Game
+	})
+	r.Problems = append(r.Problems, dups...)
+
+	prepared, _ := prepare(b)
+	r.Problems = append(r.Problems, checkHashes(prepared)...)
+	return r
+}
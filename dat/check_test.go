@@ -0,0 +1,31 @@
+package dat
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	b := []byte(`<datafile>
+	<game name="Tetris"><rom name="tetris.gb" crc="46df91ad" md5="982ed5d2b12a0377eb14bcdc4123744e"/></game>
+	<game name="Tetris (Alt)"><rom name="tetris2.gb" crc="46df91ad"/></game>
+	<game name="tetris"><rom name="tetris3.gb" crc="12345678"/></game>
+	<game name="Empty"></game>
+	<game name="Broken"><rom name="broken.gb" crc="xyz" sha1="1234"/></game>
+</datafile>`)
+
+	got := Check(b)
+	if got.Games != 5 || got.ROMs != 4 {
+		t.Errorf("got %d games and %d ROMs, want 5 and 4", got.Games, got.ROMs)
+	}
+	want := []Problem{
+		{NoROMs, "Empty", ""},
+		{DuplicateCRC, "Tetris", "46df91ad is also the CRC of Tetris (Alt)"},
+		{NameCollision, "Tetris", "2 games have this name"},
+		{MalformedHash, "Broken", `crc "xyz" of rom`},
+		{MalformedHash, "Broken", `sha1 "1234" of rom`},
+	}
+	if !reflect.DeepEqual(got.Problems, want) {
+		t.Errorf("got = %v, want %v", got.Problems, want)
+	}
+}
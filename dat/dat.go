@@ -3,26 +3,35 @@
 package dat
 
 import (
+	"bytes"
 	"encoding/xml"
-	"log"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+
+	"github.com/libretro/ludo/logs"
 )
 
 // DB is a database that contains many Dats, mapped to their system name
 type DB map[string]Dat
 
-// Dat is a list of the games of a system
+// Dat is a list of the games of a system. Warnings are the problems met
+// while parsing the dat, that didn't prevent reading it.
 type Dat struct {
-	XMLName xml.Name `xml:"datafile"`
-	Games   []Game   `xml:"game"`
+	XMLName  xml.Name `xml:"datafile"`
+	Games    []Game   `xml:"game"`
+	Warnings []string `xml:"-"`
+	System   string   `xml:"-"` // declared by software lists, the dats are named after their system
 }
 
 // Game represents a game and can contain a list of ROMs
 type Game struct {
 	XMLName     xml.Name `xml:"game"`
 	Name        string   `xml:"name,attr"`
-	Description string   `xml:"description"` // The human readable name of the game
+	CloneOf     string   `xml:"cloneof,attr"` // The name of the parent game, for hacks and translations
+	Description string   `xml:"description"`  // The human readable name of the game
+	Year        string   `xml:"year"`
 	ROMs        []ROM    `xml:"rom"`
 
 	Path   string
@@ -37,55 +46,138 @@ type ROM struct {
 	XMLName xml.Name `xml:"rom"`
 	Name    string   `xml:"name,attr"`
 	CRC     CRC      `xml:"crc,attr"`
+	Size    Size     `xml:"size,attr"`
+}
+
+// Size is the size of a ROM in bytes
+type Size int64
+
+// UnmarshalXMLAttr parses a size, decimal or hexadecimal like in software
+// lists
+func (s *Size) UnmarshalXMLAttr(attr xml.Attr) error {
+	v, base := attr.Value, 10
+	if strings.HasPrefix(v, "0x") {
+		v, base = v[2:], 16
+	}
+	if n, err := strconv.ParseInt(v, base, 64); err == nil {
+		*s = Size(n)
+	}
+	return nil
 }
 
 // UnmarshalXMLAttr is used to parse a hex number in string form to uint
 func (s *CRC) UnmarshalXMLAttr(attr xml.Attr) error {
 	u64, err := strconv.ParseUint(attr.Value, 16, 64)
 	if err != nil {
-		log.Println(err)
+		logs.Warnf("DAT", "%v", err)
 	} else {
 		*s = CRC(uint32(u64))
 	}
 	return nil
 }
 
-// Parse parses a .dat file content and returns an array of Entries
+// rootName returns the name of the root element of an XML document
+func rootName(b []byte) string {
+	d := newDecoder(b, func(string) {})
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return ""
+		}
+		if se, ok := t.(xml.StartElement); ok {
+			return se.Name.Local
+		}
+	}
+}
+
+// Parse parses a .dat file content and returns an array of Entries. MAME
+// software lists and the archive schema of No-Intro are supported too.
+// The dats declaring a single byte charset, or with a DOCTYPE, are supported.
 func Parse(dat []byte) Dat {
-	var output Dat
+	b, warnings := prepare(dat)
 
-	err := xml.Unmarshal(dat, &output)
-	if err != nil {
-		log.Println(err)
+	var output Dat
+	switch {
+	case rootName(b) == "softwarelist":
+		output = parseSoftwareList(b)
+	case bytes.Contains(b, []byte("<archive ")):
+		output = parseArchiveDat(b)
+	default:
+		output.Warnings = unmarshal(b, &output)
 	}
 
+	output.Warnings = append(warnings, output.Warnings...)
+	for _, w := range output.Warnings {
+		logs.Warnf("DAT", "%s", w)
+	}
 	return output
 }
 
+// matchCRC returns the games of a Dat whose first ROM has the given checksum,
+// keeping the best dumps when several match
+func (dat Dat) matchCRC(crc uint32) []Game {
+	var matches []Game
+	// For each game in the Dat
+	for _, game := range dat.Games {
+		if len(game.ROMs) == 0 {
+			continue
+		}
+		// If the checksums match
+		if crc == uint32(game.ROMs[0].CRC) {
+			matches = append(matches, game)
+		}
+	}
+	return bestDumps(matches)
+}
+
 // FindByCRC loops over the Dats in the DB and concurrently matches CRC checksums.
-func (db *DB) FindByCRC(romPath string, romName string, crc uint32, games chan (Game)) {
+// It tells if a game was found.
+func (db *DB) FindByCRC(romPath string, romName string, crc uint32, games chan (Game)) bool {
+	var found int32
 	var wg sync.WaitGroup
 	wg.Add(len(*db))
 	// For every Dat in the DB
 	for system, dat := range *db {
 		go func(dat Dat, crc uint32, system string) {
-			// For each game in the Dat
-			for _, game := range dat.Games {
-				if len(game.ROMs) == 0 {
-					continue
-				}
-				// If the checksums match
-				if crc == uint32(game.ROMs[0].CRC) {
-					game.Path = romPath
-					game.System = system
-					games <- game
-				}
+			for _, game := range dat.matchCRC(crc) {
+				game.Path = romPath
+				game.System = system
+				games <- game
+				atomic.StoreInt32(&found, 1)
 			}
 			wg.Done()
 		}(dat, crc, system)
 	}
 	// Synchronize all the goroutines
 	wg.Wait()
+	return found == 1
+}
+
+// NameByCRC returns the human readable name of the game of a system whose
+// first ROM has the given checksum, or an empty string
+func (db DB) NameByCRC(system string, crc uint32) string {
+	if crc == 0 {
+		return ""
+	}
+	for _, game := range db[system].matchCRC(crc) {
+		if game.Description != "" {
+			return game.Description
+		}
+		return game.Name
+	}
+	return ""
+}
+
+// FindByCRCInSystem matches a CRC checksum against the Dat of a single system,
+// it tells if a game was found
+func (db *DB) FindByCRCInSystem(system string, romPath string, romName string, crc uint32, games chan (Game)) bool {
+	matches := (*db)[system].matchCRC(crc)
+	for _, game := range matches {
+		game.Path = romPath
+		game.System = system
+		games <- game
+	}
+	return len(matches) > 0
 }
 
 // FindByROMName loops over the Dats in the DB and concurrently matches ROM names.
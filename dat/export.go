@@ -0,0 +1,100 @@
+package dat
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Record is a ROM of the DB, flattened for the exports. The games without
+// ROMs have a single record with an empty CRC.
+type Record struct {
+	System string `json:"system"`
+	Name   string `json:"name"`
+	CRC    string `json:"crc"`
+	Size   int64  `json:"size"`
+	Region string `json:"region"`
+	Year   string `json:"year"`
+}
+
+// region guesses the region of a game from its name, in the TOSEC, GoodTools
+// or No-Intro conventions
+func region(name string) string {
+	if t, ok := ParseTOSEC(name); ok {
+		return strings.Join(t.Regions, ", ")
+	}
+	if g, ok := ParseGoodTools(name); ok {
+		return strings.Join(g.Regions, ", ")
+	}
+	i := strings.Index(name, " (")
+	if i < 0 {
+		return ""
+	}
+	j := strings.Index(name[i:], ")")
+	if j < 0 {
+		return ""
+	}
+	return name[i+2 : i+j]
+}
+
+// year returns the year of a game, from the dat or from its TOSEC date
+func year(g Game) string {
+	if g.Year != "" {
+		return g.Year
+	}
+	if t, ok := ParseTOSEC(g.Name); ok && len(t.Date) >= 4 {
+		return t.Date[:4]
+	}
+	return ""
+}
+
+// Records flattens the DB, sorted by system and in the order of the dats
+func (db *DB) Records() []Record {
+	var systems []string
+	for system := range *db {
+		systems = append(systems, system)
+	}
+	sort.Strings(systems)
+
+	var records []Record
+	for _, system := range systems {
+		for _, g := range (*db)[system].Games {
+			r := Record{System: system, Name: g.Name, Region: region(g.Name), Year: year(g)}
+			if len(g.ROMs) == 0 {
+				records = append(records, r)
+			}
+			for _, rom := range g.ROMs {
+				r.CRC = fmt.Sprintf("%08x", uint32(rom.CRC))
+				r.Size = int64(rom.Size)
+				records = append(records, r)
+			}
+		}
+	}
+	return records
+}
+
+// WriteCSV exports the DB as CSV, with a header line
+func (db *DB) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"system", "name", "crc", "size", "region", "year"})
+	for _, r := range db.Records() {
+		cw.Write([]string{r.System, r.Name, r.CRC, strconv.FormatInt(r.Size, 10), r.Region, r.Year})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON exports the DB as a JSON array of records
+func (db *DB) WriteJSON(w io.Writer) error {
+	records := db.Records()
+	if records == nil {
+		records = []Record{}
+	}
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(records)
+}
@@ -0,0 +1,65 @@
+package dat
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+var exportDB = DB{
+	"Nintendo - Game Boy": Dat{Games: []Game{
+		{Name: "Tetris (World) (Rev 1)", ROMs: []ROM{{Name: "tetris.gb", CRC: 0x46df91ad, Size: 32768}}},
+	}},
+	"Commodore - Amiga": Dat{Games: []Game{
+		{Name: "Lemmings (1991)(Psygnosis)(GB)", ROMs: []ROM{{Name: "a.adf", CRC: 0x1, Size: 901120}}},
+		{Name: "smb", Year: "1985"},
+	}},
+}
+
+func TestRecords(t *testing.T) {
+	want := []Record{
+		{System: "Commodore - Amiga", Name: "Lemmings (1991)(Psygnosis)(GB)", CRC: "00000001", Size: 901120, Region: "UK", Year: "1991"},
+		{System: "Commodore - Amiga", Name: "smb", Year: "1985"},
+		{System: "Nintendo - Game Boy", Name: "Tetris (World) (Rev 1)", CRC: "46df91ad", Size: 32768, Region: "World"},
+	}
+	if got := exportDB.Records(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got = %v, want %v", got, want)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var b bytes.Buffer
+	if err := exportDB.WriteCSV(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `system,name,crc,size,region,year
+Commodore - Amiga,Lemmings (1991)(Psygnosis)(GB),00000001,901120,UK,1991
+Commodore - Amiga,smb,,0,,1985
+Nintendo - Game Boy,Tetris (World) (Rev 1),46df91ad,32768,World,
+`
+	if b.String() != want {
+		t.Errorf("got = %q, want %q", b.String(), want)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var b bytes.Buffer
+	if err := exportDB.WriteJSON(&b); err != nil {
+		t.Fatal(err)
+	}
+	var got []Record
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, exportDB.Records()) {
+		t.Errorf("got = %v, want %v", got, exportDB.Records())
+	}
+
+	b.Reset()
+	empty := DB{}
+	empty.WriteJSON(&b)
+	if b.String() != "[]\n" {
+		t.Errorf("got = %q, want an empty array", b.String())
+	}
+}
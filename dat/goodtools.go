@@ -0,0 +1,203 @@
+package dat
+
+import (
+	"sort"
+	"strings"
+)
+
+// GoodTools is the metadata encoded in a name of a GoodSet, like
+// "Sonic the Hedgehog (JUE) [h1C]". The country codes are mapped to the
+// regions of No-Intro, like for TOSEC names.
+type GoodTools struct {
+	Title   string
+	Regions []string
+	Flags   []DumpFlag
+	More    []string
+}
+
+// goodCountries maps the GoodTools country codes to the regions of No-Intro
+var goodCountries = map[string]string{
+	"1":   "Japan, Korea",
+	"4":   "USA, Brazil",
+	"A":   "Australia",
+	"B":   "Brazil",
+	"C":   "China",
+	"E":   "Europe",
+	"F":   "France",
+	"FC":  "Canada",
+	"FN":  "Finland",
+	"G":   "Germany",
+	"GR":  "Greece",
+	"HK":  "Hong Kong",
+	"I":   "Italy",
+	"J":   "Japan",
+	"K":   "Korea",
+	"NL":  "Netherlands",
+	"S":   "Spain",
+	"SW":  "Sweden",
+	"U":   "USA",
+	"UK":  "UK",
+	"Unk": "Unknown",
+	"W":   "World",
+}
+
+// goodKinds are the GoodTools bracket codes, longest first
+var goodKinds = []string{"!p", "T+", "T-", "!", "a", "b", "c", "f", "h", "o", "p", "t", "x"}
+
+// goodRegions maps a group like JUE to the regions of its countries
+func goodRegions(s string) ([]string, bool) {
+	if r, ok := goodCountries[s]; ok {
+		return strings.Split(r, ", "), true
+	}
+	// USA would read as USA, Spain and Australia
+	if s == "" || s == "USA" {
+		return nil, false
+	}
+	var regions []string
+	for _, c := range s {
+		r, ok := goodCountries[string(c)]
+		if !ok {
+			return nil, false
+		}
+		regions = append(regions, strings.Split(r, ", ")...)
+	}
+	return regions, true
+}
+
+// parseGoodFlag reads the content of a bracket group, like h1C or T+Eng
+func parseGoodFlag(s string) (DumpFlag, bool) {
+	for _, k := range goodKinds {
+		if !strings.HasPrefix(s, k) {
+			continue
+		}
+		rest := s[len(k):]
+		if rest == "" || k[0] == 'T' || rest[0] >= '0' && rest[0] <= '9' {
+			return DumpFlag{Kind: k, Note: rest}, true
+		}
+	}
+	return DumpFlag{}, false
+}
+
+// ParseGoodTools parses a name using the GoodTools codes. It tells if the
+// name has at least a country code or a bracket code of GoodTools.
+func ParseGoodTools(name string) (GoodTools, bool) {
+	var g GoodTools
+	i := strings.IndexAny(name, "([")
+	if i < 0 {
+		return g, false
+	}
+	g.Title = strings.TrimSpace(name[:i])
+	groups, ok := tosecGroups(name[i:])
+	if !ok {
+		return GoodTools{}, false
+	}
+
+	known := false
+	for _, group := range groups {
+		content := group[1 : len(group)-1]
+		if group[0] == '(' {
+			if r, ok := goodRegions(content); ok && g.Regions == nil {
+				g.Regions, known = r, true
+			} else {
+				g.More = append(g.More, content)
+			}
+			continue
+		}
+		if f, ok := parseGoodFlag(content); ok {
+			g.Flags, known = append(g.Flags, f), true
+		} else {
+			g.More = append(g.More, content)
+		}
+	}
+	return g, known
+}
+
+// Has tells if a name has a bracket code of the given kind
+func (g GoodTools) Has(kind string) bool {
+	for _, f := range g.Flags {
+		if f.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Tags lists the metadata worth displaying next to the title
+func (g GoodTools) Tags() []string {
+	tags := append([]string{}, g.Regions...)
+	tags = append(tags, g.More...)
+	for _, f := range g.Flags {
+		tags = append(tags, f.Kind+f.Note)
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	return tags
+}
+
+// nameKey identifies a game by its title and regions, ignoring the case and
+// the order of the regions
+func nameKey(title string, regions []string) string {
+	r := append([]string{}, regions...)
+	sort.Strings(r)
+	return strings.ToLower(title) + "|" + strings.Join(r, ",")
+}
+
+// noIntroKey computes the key of a No-Intro name, like
+// "Sonic The Hedgehog (USA, Europe) (Rev 1)"
+func noIntroKey(name string) string {
+	i := strings.Index(name, " (")
+	if i < 0 {
+		return nameKey(name, nil)
+	}
+	j := strings.Index(name[i:], ")")
+	if j < 0 {
+		return nameKey(name, nil)
+	}
+	return nameKey(name[:i], strings.Split(name[i+2:i+j], ", "))
+}
+
+// FindByGoodName matches the name of a GoodSet file against the names of the
+// games of a system, for the files whose checksum is unknown, like hacks and
+// translations. The other systems aren't searched, they often have a game
+// with the same title. Bad dumps and overdumps are left out. The game found
+// keeps the checksum of the file. It tells if a game was found.
+func (db *DB) FindByGoodName(system string, romPath string, romName string, crc uint32, games chan (Game)) bool {
+	g, ok := ParseGoodTools(romName)
+	if !ok || g.Has("b") || g.Has("o") || g.Regions == nil {
+		return false
+	}
+	keys := map[string]bool{nameKey(g.Title, g.Regions): true}
+	// No-Intro names the games released in these three regions World
+	if nameKey("", g.Regions) == nameKey("", []string{"Europe", "Japan", "USA"}) {
+		keys[nameKey(g.Title, []string{"World"})] = true
+	}
+
+	var flags string
+	for _, f := range g.Flags {
+		flags += "[" + f.Kind + f.Note + "]"
+	}
+
+	// The first release is picked among the revisions of the game
+	dat := (*db)[system]
+	var match *Game
+	for i, game := range dat.Games {
+		if len(game.ROMs) > 0 && keys[noIntroKey(game.Name)] &&
+			(match == nil || len(game.Name) < len(match.Name)) {
+			match = &dat.Games[i]
+		}
+	}
+	if match == nil {
+		return false
+	}
+	game := *match
+	game.Path = romPath
+	game.System = system
+	// The checksum of the file isn't the one of the dat
+	game.ROMs = []ROM{{Name: romName, CRC: CRC(crc)}}
+	if game.Description != "" && flags != "" {
+		game.Description += " " + flags
+	}
+	games <- game
+	return true
+}
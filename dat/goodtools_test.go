@@ -0,0 +1,102 @@
+package dat
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGoodTools(t *testing.T) {
+	tests := []struct {
+		name string
+		want GoodTools
+		ok   bool
+	}{
+		{
+			name: "Sonic the Hedgehog (JUE) [!]",
+			want: GoodTools{
+				Title:   "Sonic the Hedgehog",
+				Regions: []string{"Japan", "USA", "Europe"},
+				Flags:   []DumpFlag{{Kind: "!"}},
+			},
+			ok: true,
+		},
+		{
+			name: "Phantasy Star (J) (V1.2) [T+Eng1.02_SMSTP][h1C]",
+			want: GoodTools{
+				Title:   "Phantasy Star",
+				Regions: []string{"Japan"},
+				Flags:   []DumpFlag{{Kind: "T+", Note: "Eng1.02_SMSTP"}, {Kind: "h", Note: "1C"}},
+				More:    []string{"V1.2"},
+			},
+			ok: true,
+		},
+		{
+			name: "Street Fighter II (4) [b1]",
+			want: GoodTools{
+				Title:   "Street Fighter II",
+				Regions: []string{"USA", "Brazil"},
+				Flags:   []DumpFlag{{Kind: "b", Note: "1"}},
+			},
+			ok: true,
+		},
+		{name: "Sonic The Hedgehog (USA, Europe)", ok: false},
+		{name: "Tetris (World) (Rev A)", ok: false},
+		{name: "Super Mario Land (USA)", ok: false},
+		{name: "Tetris", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseGoodTools(tt.name)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindByGoodName(t *testing.T) {
+	rom := []ROM{{CRC: 0x1234}}
+	db := DB{"Sega - Mega Drive - Genesis": Dat{Games: []Game{
+		{Name: "Sonic The Hedgehog (USA, Europe) (Rev 1)", Description: "Sonic The Hedgehog (USA, Europe) (Rev 1)", ROMs: rom},
+		{Name: "Sonic The Hedgehog (USA, Europe)", Description: "Sonic The Hedgehog (USA, Europe)", ROMs: rom},
+		{Name: "Sonic The Hedgehog (Japan)", Description: "Sonic The Hedgehog (Japan)", ROMs: rom},
+		{Name: "Columns (World)", Description: "Columns (World)", ROMs: rom},
+	}}}
+
+	db["Sega - Game Gear"] = Dat{Games: []Game{
+		{Name: "Sonic The Hedgehog (USA, Europe)", Description: "Sonic The Hedgehog (USA, Europe)", ROMs: rom},
+	}}
+
+	tests := []struct {
+		system string
+		file   string
+		want   []string
+	}{
+		{"Sega - Mega Drive - Genesis", "Sonic the Hedgehog (UE) [h1C]", []string{"Sonic The Hedgehog (USA, Europe) [h1C]"}},
+		{"Sega - Mega Drive - Genesis", "Columns (JUE) [T+Fre]", []string{"Columns (World) [T+Fre]"}},
+		{"Sega - Mega Drive - Genesis", "Sonic the Hedgehog (UE) [b1]", nil},
+		{"Sega - Mega Drive - Genesis", "Sonic the Hedgehog (K)", nil},
+		{"Sega - Game Gear", "Columns (JUE) [T+Fre]", nil},
+		{"", "Sonic the Hedgehog (UE) [h1C]", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.system+"/"+tt.file, func(t *testing.T) {
+			games := make(chan Game, 10)
+			found := db.FindByGoodName(tt.system, "/roms/sonic.md", tt.file, 0xABCD, games)
+			close(games)
+			var got []string
+			for g := range games {
+				got = append(got, g.Description)
+				if g.System != tt.system || g.ROMs[0].CRC != 0xABCD {
+					t.Errorf("System = %v, CRC = %x, want %v, abcd", g.System, g.ROMs[0].CRC, tt.system)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) || found != (tt.want != nil) {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package dat
+
+// ParentName returns the human readable name of the parent of a game, for
+// the hacks and translations of the dats that link them to their original
+// game. The game is looked up by its human readable name, like in playlists.
+// It returns an empty string if the game has no parent.
+func (db DB) ParentName(system, name string) string {
+	games := db[system].Games
+	var parent string
+	for _, g := range games {
+		if g.CloneOf != "" && (g.Description == name || g.Name == name) {
+			parent = g.CloneOf
+			break
+		}
+	}
+	if parent == "" {
+		return ""
+	}
+	for _, g := range games {
+		if g.Name == parent {
+			if g.Description != "" {
+				return g.Description
+			}
+			return g.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,29 @@
+package dat
+
+import "testing"
+
+func TestParentName(t *testing.T) {
+	b := []byte(`<datafile>
+	<game name="Super Mario World (USA)"><description>Super Mario World (USA)</description><rom name="smw.sfc" crc="b19ed489"/></game>
+	<game name="smw-kaizo" cloneof="Super Mario World (USA)"><description>Kaizo Mario World (Hack)</description><rom name="kaizo.sfc" crc="12345678"/></game>
+	<game name="smw-lost" cloneof="Missing Game"><description>Lost Hack</description><rom name="lost.sfc" crc="87654321"/></game>
+</datafile>`)
+	db := DB{"Nintendo - Super Nintendo Entertainment System": Parse(b)}
+	system := "Nintendo - Super Nintendo Entertainment System"
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Kaizo Mario World (Hack)", "Super Mario World (USA)"},
+		{"smw-kaizo", "Super Mario World (USA)"},
+		{"Super Mario World (USA)", ""},
+		{"Lost Hack", ""},
+		{"Unknown", ""},
+	}
+	for _, tt := range tests {
+		if got := db.ParentName(system, tt.name); got != tt.want {
+			t.Errorf("ParentName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
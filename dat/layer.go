@@ -0,0 +1,28 @@
+package dat
+
+// Layer puts the dats of user on top of the ones of the DB. The games of a
+// user dat replace the games of the DB sharing the checksum of their first
+// ROM, so that a hack or a translation isn't matched with its original game.
+// The systems only known by user are added.
+func (db DB) Layer(user DB) {
+	for system, u := range user {
+		base, ok := db[system]
+		if !ok {
+			db[system] = u
+			continue
+		}
+		crcs := map[CRC]bool{}
+		for _, g := range u.Games {
+			if len(g.ROMs) > 0 && g.ROMs[0].CRC != 0 {
+				crcs[g.ROMs[0].CRC] = true
+			}
+		}
+		games := append([]Game{}, u.Games...)
+		for _, g := range base.Games {
+			if len(g.ROMs) == 0 || !crcs[g.ROMs[0].CRC] {
+				games = append(games, g)
+			}
+		}
+		db[system] = Dat{Games: games, Warnings: append(base.Warnings, u.Warnings...)}
+	}
+}
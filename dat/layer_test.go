@@ -0,0 +1,34 @@
+package dat
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLayer(t *testing.T) {
+	tetris := Game{Name: "Tetris (World)", ROMs: []ROM{{CRC: 0x46df91ad}}}
+	alleyway := Game{Name: "Alleyway (World)", ROMs: []ROM{{CRC: 0xb0c1e4c2}}}
+	hack := Game{Name: "Tetris DX Colors (Hack)", ROMs: []ROM{{CRC: 0x46df91ad}}}
+	lynx := Game{Name: "Gates of Zendocon (USA, Europe)", ROMs: []ROM{{CRC: 0x1}}}
+
+	db := DB{"Nintendo - Game Boy": Dat{Games: []Game{tetris, alleyway}}}
+	db.Layer(DB{
+		"Nintendo - Game Boy": Dat{Games: []Game{hack}},
+		"Atari - Lynx":        Dat{Games: []Game{lynx}},
+	})
+
+	want := DB{
+		"Nintendo - Game Boy": Dat{Games: []Game{hack, alleyway}},
+		"Atari - Lynx":        Dat{Games: []Game{lynx}},
+	}
+	if !reflect.DeepEqual(db, want) {
+		t.Errorf("got = %v, want %v", db, want)
+	}
+	games := make(chan Game, 1)
+	if !db.FindByCRC("tetris.gb", "tetris.gb", 0x46df91ad, games) {
+		t.Fatalf("the user game wasn't found")
+	}
+	if g := <-games; g.Name != hack.Name {
+		t.Errorf("got %s, want %s", g.Name, hack.Name)
+	}
+}
@@ -0,0 +1,191 @@
+package dat
+
+import "encoding/xml"
+
+// softwareList is a MAME software list, like nes.xml. The ROMs of a software
+// are split in the data areas of its parts, like the PRG and CHR of a NES
+// cartridge.
+type softwareList struct {
+	XMLName     xml.Name   `xml:"softwarelist"`
+	Name        string     `xml:"name,attr"`
+	Description string     `xml:"description,attr"`
+	Software    []software `xml:"software"`
+}
+
+type software struct {
+	Name        string `xml:"name,attr"`
+	Description string `xml:"description"`
+	Year        string `xml:"year"`
+	Parts       []part `xml:"part"`
+}
+
+type part struct {
+	Areas []dataArea `xml:"dataarea"`
+}
+
+type dataArea struct {
+	Name string        `xml:"name,attr"`
+	ROMs []softwareROM `xml:"rom"`
+}
+
+// softwareROM is a ROM of a data area. The ROMs continuing the previous one
+// have no name, their size is part of the previous file.
+type softwareROM struct {
+	Name     string `xml:"name,attr"`
+	CRC      CRC    `xml:"crc,attr"`
+	Size     Size   `xml:"size,attr"`
+	LoadFlag string `xml:"loadflag,attr"`
+}
+
+// softwareListSystems are the systems of the software lists of MAME, by
+// list name
+var softwareListSystems = map[string]string{
+	"a2600":    "Atari - 2600",
+	"a7800":    "Atari - 7800",
+	"coleco":   "Coleco - ColecoVision",
+	"gamegear": "Sega - Game Gear",
+	"gameboy":  "Nintendo - Game Boy",
+	"gba":      "Nintendo - Game Boy Advance",
+	"gbcolor":  "Nintendo - Game Boy Color",
+	"lynx":     "Atari - Lynx",
+	"megadriv": "Sega - Mega Drive - Genesis",
+	"n64":      "Nintendo - Nintendo 64",
+	"nes":      "Nintendo - Nintendo Entertainment System",
+	"ngp":      "SNK - Neo Geo Pocket",
+	"ngpc":     "SNK - Neo Geo Pocket Color",
+	"pce":      "NEC - PC Engine - TurboGrafx 16",
+	"sms":      "Sega - Master System - Mark III",
+	"snes":     "Nintendo - Super Nintendo Entertainment System",
+	"vboy":     "Nintendo - Virtual Boy",
+	"wswan":    "Bandai - WonderSwan",
+	"wscolor":  "Bandai - WonderSwan Color",
+}
+
+// system returns the system of a software list, from its name, or its
+// description for the lists that aren't known
+func (l softwareList) system() string {
+	if s, ok := softwareListSystems[l.Name]; ok {
+		return s
+	}
+	if l.Description != "" {
+		return l.Description
+	}
+	return l.Name
+}
+
+// roms lists the files of a data area, the sizes of the ROMs continuing a
+// file being added to it
+func (a dataArea) roms() []ROM {
+	var roms []ROM
+	for _, r := range a.ROMs {
+		switch {
+		case r.Name != "":
+			roms = append(roms, ROM{Name: r.Name, CRC: r.CRC, Size: r.Size})
+		case r.LoadFlag == "continue" && len(roms) > 0:
+			roms[len(roms)-1].Size += r.Size
+		}
+	}
+	return roms
+}
+
+// cartridge builds the ROM of a cartridge dumped as a single file, like the
+// iNES files that hold the PRG then the CHR without their header. Its
+// checksum combines the ones of the data areas. It tells if the software has
+// these areas.
+func cartridge(name string, areas map[string][]ROM) (ROM, bool) {
+	files := append(append([]ROM{}, areas["prg"]...), areas["chr"]...)
+	if len(areas["prg"]) == 0 || len(files) < 2 {
+		return ROM{}, false
+	}
+	rom := ROM{Name: name, CRC: files[0].CRC, Size: files[0].Size}
+	for _, f := range files[1:] {
+		rom.CRC = CRC(crc32Combine(uint32(rom.CRC), uint32(f.CRC), int64(f.Size)))
+		rom.Size += f.Size
+	}
+	return rom, true
+}
+
+// parseSoftwareList reads a software list as a Dat, each software being a
+// game. The system is the one of the list.
+func parseSoftwareList(b []byte) Dat {
+	var list softwareList
+	warnings := unmarshal(b, &list)
+
+	output := Dat{Warnings: warnings, System: list.system()}
+	for _, s := range list.Software {
+		game := Game{Name: s.Name, Description: s.Description, Year: s.Year}
+		areas := map[string][]ROM{}
+		for _, p := range s.Parts {
+			for _, a := range p.Areas {
+				roms := a.roms()
+				areas[a.Name] = append(areas[a.Name], roms...)
+				game.ROMs = append(game.ROMs, roms...)
+			}
+		}
+		// The games are matched by their first ROM
+		if rom, ok := cartridge(s.Name, areas); ok {
+			game.ROMs = append([]ROM{rom}, game.ROMs...)
+		}
+		output.Games = append(output.Games, game)
+	}
+	return output
+}
+
+// crc32Combine computes the IEEE checksum of two blocks of data put end to
+// end, from their checksums and the length of the second one, like zlib's
+// crc32_combine
+func crc32Combine(crc1, crc2 uint32, len2 int64) uint32 {
+	if len2 <= 0 {
+		return crc1
+	}
+	even := make([]uint32, 32) // operator for even powers of two zeros
+	odd := make([]uint32, 32)  // operator for odd powers of two zeros
+
+	// The operator for one zero bit
+	odd[0] = 0xedb88320
+	row := uint32(1)
+	for n := 1; n < 32; n++ {
+		odd[n] = row
+		row <<= 1
+	}
+	gf2MatrixSquare(even, odd) // two zero bits
+	gf2MatrixSquare(odd, even) // four zero bits
+
+	// Apply len2 zeros to crc1, the first square puts the operator for one
+	// zero byte in even
+	for {
+		gf2MatrixSquare(even, odd)
+		if len2&1 != 0 {
+			crc1 = gf2MatrixTimes(even, crc1)
+		}
+		len2 >>= 1
+		if len2 == 0 {
+			break
+		}
+		gf2MatrixSquare(odd, even)
+		if len2&1 != 0 {
+			crc1 = gf2MatrixTimes(odd, crc1)
+		}
+		len2 >>= 1
+		if len2 == 0 {
+			break
+		}
+	}
+	return crc1 ^ crc2
+}
+
+func gf2MatrixTimes(mat []uint32, vec uint32) uint32 {
+	var sum uint32
+	for i := 0; vec != 0; i, vec = i+1, vec>>1 {
+		if vec&1 != 0 {
+			sum ^= mat[i]
+		}
+	}
+	return sum
+}
+
+func gf2MatrixSquare(square, mat []uint32) {
+	for n := 0; n < 32; n++ {
+		square[n] = gf2MatrixTimes(mat, mat[n])
+	}
+}
@@ -0,0 +1,77 @@
+package dat
+
+import (
+	"hash/crc32"
+	"reflect"
+	"testing"
+)
+
+const softlist = `<?xml version="1.0"?>
+<!DOCTYPE softwarelist SYSTEM "softwarelist.dtd">
+<softwarelist name="nes" description="Nintendo Entertainment System cartridges">
+	<software name="smb">
+		<description>Super Mario Bros. (World)</description>
+		<year>1985</year>
+		<publisher>Nintendo</publisher>
+		<part name="cart" interface="nes_cart">
+			<dataarea name="prg" size="32768">
+				<rom name="smb-prg.bin" size="32768" crc="5cf548d3" sha1="-" offset="00000" />
+			</dataarea>
+			<dataarea name="chr" size="8192">
+				<rom name="smb-chr.bin" size="8192" crc="867b51ad" sha1="-" offset="00000" />
+				<rom size="8192" offset="0x2000" loadflag="continue" />
+			</dataarea>
+		</part>
+	</software>
+	<software name="tetris">
+		<description>Tetris (USA)</description>
+		<part name="cart" interface="nes_cart">
+			<dataarea name="prg" size="32768">
+				<rom name="tetris.prg" size="32768" crc="1394f57e" sha1="-" offset="00000" />
+			</dataarea>
+		</part>
+	</software>
+</softwarelist>`
+
+func TestParseSoftwareList(t *testing.T) {
+	got := Parse([]byte(softlist))
+	if len(got.Games) != 2 {
+		t.Fatalf("got %d games, want 2", len(got.Games))
+	}
+
+	smb := got.Games[0]
+	if smb.Name != "smb" || smb.Description != "Super Mario Bros. (World)" {
+		t.Errorf("got = %v, %v", smb.Name, smb.Description)
+	}
+	if got.System != "Nintendo - Nintendo Entertainment System" {
+		t.Errorf("System = %q", got.System)
+	}
+	// The PRG and the CHR make a single file, the CHR continues past 8 KiB
+	cart := ROM{Name: "smb", CRC: CRC(crc32Combine(0x5cf548d3, 0x867b51ad, 16384)), Size: 49152}
+	want := []ROM{cart, {Name: "smb-prg.bin", CRC: 0x5cf548d3, Size: 32768}, {Name: "smb-chr.bin", CRC: 0x867b51ad, Size: 16384}}
+	if !reflect.DeepEqual(smb.ROMs, want) {
+		t.Errorf("got = %+v, want %+v", smb.ROMs, want)
+	}
+
+	db := DB{got.System: got}
+	games := make(chan Game, 10)
+	if !db.FindByCRC("/roms/tetris.zip", "tetris.prg", 0x1394f57e, games) {
+		t.Error("tetris not found")
+	}
+	if !db.FindByCRC("/roms/smb.nes", "smb.nes", uint32(cart.CRC), games) {
+		t.Error("smb not found")
+	}
+}
+
+func Test_crc32Combine(t *testing.T) {
+	prg := []byte("the program of the cartridge")
+	chr := []byte("and its graphics")
+	want := crc32.ChecksumIEEE(append(append([]byte{}, prg...), chr...))
+	got := crc32Combine(crc32.ChecksumIEEE(prg), crc32.ChecksumIEEE(chr), int64(len(chr)))
+	if got != want {
+		t.Errorf("got = %x, want %x", got, want)
+	}
+	if got := crc32Combine(0x1234, 0, 0); got != 0x1234 {
+		t.Errorf("got = %x, want 1234", got)
+	}
+}
@@ -0,0 +1,49 @@
+package dat
+
+import (
+	"sort"
+	"unsafe"
+)
+
+// SystemStats sums up the Dat of a system. Size is the size of all its ROMs,
+// the size of a full set.
+type SystemStats struct {
+	System string
+	Games  int
+	ROMs   int
+	Size   int64
+}
+
+// Stats sums up a DB. Memory is an estimation of the memory used by the
+// games.
+type Stats struct {
+	Systems []SystemStats
+	Games   int
+	ROMs    int
+	Size    int64
+	Memory  int64
+}
+
+// Stats computes the statistics of a DB, the systems are sorted by name
+func (db *DB) Stats() Stats {
+	var s Stats
+	for system, dat := range *db {
+		ss := SystemStats{System: system, Games: len(dat.Games)}
+		for _, g := range dat.Games {
+			ss.ROMs += len(g.ROMs)
+			s.Memory += int64(unsafe.Sizeof(g)) + int64(len(g.Name)+len(g.Description))
+			for _, r := range g.ROMs {
+				ss.Size += int64(r.Size)
+				s.Memory += int64(unsafe.Sizeof(r)) + int64(len(r.Name))
+			}
+		}
+		s.Systems = append(s.Systems, ss)
+		s.Games += ss.Games
+		s.ROMs += ss.ROMs
+		s.Size += ss.Size
+	}
+	sort.Slice(s.Systems, func(i, j int) bool {
+		return s.Systems[i].System < s.Systems[j].System
+	})
+	return s
+}
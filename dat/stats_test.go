@@ -0,0 +1,33 @@
+package dat
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	db := DB{
+		"Nintendo - Game Boy": Dat{Games: []Game{
+			{Name: "Tetris", ROMs: []ROM{{Name: "tetris.gb", Size: 32768}}},
+			{Name: "Alleyway", ROMs: []ROM{{Name: "alleyway.gb", Size: 32768}}},
+		}},
+		"Atari - Lynx": Dat{Games: []Game{
+			{Name: "Gates of Zendocon", ROMs: []ROM{{Name: "a.lnx", Size: 131072}, {Name: "b.lnx", Size: 64}}},
+		}},
+	}
+
+	got := db.Stats()
+	want := []SystemStats{
+		{System: "Atari - Lynx", Games: 1, ROMs: 2, Size: 131136},
+		{System: "Nintendo - Game Boy", Games: 2, ROMs: 2, Size: 65536},
+	}
+	if !reflect.DeepEqual(got.Systems, want) {
+		t.Errorf("got = %v, want %v", got.Systems, want)
+	}
+	if got.Games != 3 || got.ROMs != 4 || got.Size != 196672 {
+		t.Errorf("got %d games, %d ROMs, %d bytes", got.Games, got.ROMs, got.Size)
+	}
+	if got.Memory <= 0 {
+		t.Errorf("got memory %d, want a positive estimation", got.Memory)
+	}
+}
@@ -0,0 +1,273 @@
+package dat
+
+import (
+	"regexp"
+	"strings"
+)
+
+// TOSEC is the metadata encoded in a TOSEC name, like
+// "Legend of TOSEC, The (1986)(Devstudio)(US)[a2]". The fields are kept in
+// their TOSEC form, except the countries that are mapped to the regions of
+// No-Intro, like USA.
+type TOSEC struct {
+	Title      string
+	Demo       string
+	Date       string
+	Publisher  string
+	System     string
+	Video      string
+	Regions    []string
+	Languages  []string
+	Copyright  string
+	DevStatus  string
+	MediaType  string
+	MediaLabel string
+	Flags      []DumpFlag
+	More       []string
+}
+
+// DumpFlag is a flag of a dump, like [a2] or [cr Fairlight]. Kind is one of
+// the TOSEC dump flags, Note is what follows it.
+type DumpFlag struct {
+	Kind string
+	Note string
+}
+
+// String formats a dump flag like in TOSEC names, without the brackets
+func (f DumpFlag) String() string {
+	if f.Note == "" || f.Note[0] >= '0' && f.Note[0] <= '9' {
+		return f.Kind + f.Note
+	}
+	return f.Kind + " " + f.Note
+}
+
+// dumpKinds are the TOSEC dump flags, longest first so that tr is found
+// before t
+var dumpKinds = []string{"cr", "tr", "!", "a", "b", "f", "h", "m", "o", "p", "t", "u", "v"}
+
+var tosecDate = regexp.MustCompile(`^(19|20)[0-9x]{2}(-[0-9x]{2}(-[0-9x]{2})?)?$`)
+
+var tosecVideo = map[string]bool{
+	"CGA": true, "EGA": true, "HGC": true, "MCGA": true, "MDA": true,
+	"NTSC": true, "NTSC-PAL": true, "PAL": true, "PAL-60": true,
+	"PAL-NTSC": true, "SVGA": true, "VGA": true, "XGA": true,
+}
+
+var tosecCopyright = map[string]bool{
+	"CW": true, "CW-R": true, "FW": true, "GW": true, "GW-R": true,
+	"LW": true, "PD": true, "SW": true, "SW-R": true,
+}
+
+var tosecDevStatus = map[string]bool{
+	"alpha": true, "beta": true, "preview": true, "pre-release": true, "proto": true,
+}
+
+var tosecMedia = regexp.MustCompile(`^(Disc|Disk|File|Part|Side|Tape) [0-9A-Z]+( of [0-9A-Z]+)?$`)
+
+var tosecLanguage = regexp.MustCompile(`^([a-z]{2}(-[a-z]{2})*|M[0-9])$`)
+
+// tosecCountries maps the TOSEC country codes to the regions of No-Intro
+var tosecCountries = map[string]string{
+	"AE": "United Arab Emirates", "AR": "Argentina", "AS": "Asia",
+	"AT": "Austria", "AU": "Australia", "BE": "Belgium", "BG": "Bulgaria",
+	"BR": "Brazil", "CA": "Canada", "CH": "Switzerland", "CL": "Chile",
+	"CN": "China", "CS": "Serbia", "CY": "Cyprus", "CZ": "Czech",
+	"DE": "Germany", "DK": "Denmark", "EE": "Estonia", "EG": "Egypt",
+	"ES": "Spain", "EU": "Europe", "FI": "Finland", "FR": "France",
+	"GB": "UK", "GR": "Greece", "HK": "Hong Kong", "HR": "Croatia",
+	"HU": "Hungary", "ID": "Indonesia", "IE": "Ireland", "IL": "Israel",
+	"IN": "India", "IR": "Iran", "IS": "Iceland", "IT": "Italy",
+	"JO": "Jordan", "JP": "Japan", "KR": "Korea", "LT": "Lithuania",
+	"LU": "Luxembourg", "LV": "Latvia", "MN": "Mongolia", "MX": "Mexico",
+	"MY": "Malaysia", "NL": "Netherlands", "NO": "Norway", "NP": "Nepal",
+	"NZ": "New Zealand", "OM": "Oman", "PE": "Peru", "PH": "Philippines",
+	"PL": "Poland", "PT": "Portugal", "QA": "Qatar", "RO": "Romania",
+	"RU": "Russia", "SE": "Sweden", "SG": "Singapore", "SI": "Slovenia",
+	"SK": "Slovakia", "TH": "Thailand", "TR": "Turkey", "TW": "Taiwan",
+	"US": "USA", "VN": "Vietnam", "YU": "Yugoslavia", "ZA": "South Africa",
+}
+
+// tosecRegions maps a group like US-EU to the regions of its countries
+func tosecRegions(s string) ([]string, bool) {
+	var regions []string
+	for _, c := range strings.Split(s, "-") {
+		r, ok := tosecCountries[c]
+		if !ok {
+			return nil, false
+		}
+		regions = append(regions, r)
+	}
+	return regions, true
+}
+
+// parseDumpFlag reads the content of a bracket group
+func parseDumpFlag(s string) (DumpFlag, bool) {
+	for _, k := range dumpKinds {
+		if !strings.HasPrefix(s, k) {
+			continue
+		}
+		rest := s[len(k):]
+		if rest == "" || rest[0] == ' ' || rest[0] >= '0' && rest[0] <= '9' {
+			return DumpFlag{Kind: k, Note: strings.TrimSpace(rest)}, true
+		}
+	}
+	return DumpFlag{}, false
+}
+
+// tosecGroups splits the end of a TOSEC name in its groups, the parentheses
+// and brackets are kept
+func tosecGroups(s string) ([]string, bool) {
+	var groups []string
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		end := map[byte]byte{'(': ')', '[': ']'}[s[0]]
+		i := strings.IndexByte(s, end)
+		if end == 0 || i < 0 {
+			return nil, false
+		}
+		groups = append(groups, s[:i+1])
+		s = s[i+1:]
+	}
+	return groups, true
+}
+
+// ParseTOSEC parses a name following the TOSEC naming convention. It tells
+// if the name is a TOSEC one, with at least a title, a date and a publisher.
+func ParseTOSEC(name string) (TOSEC, bool) {
+	var t TOSEC
+	i := strings.Index(name, " (")
+	if i < 0 {
+		return t, false
+	}
+	t.Title = strings.TrimSpace(name[:i])
+	groups, ok := tosecGroups(name[i:])
+	if !ok || len(groups) < 2 {
+		return t, false
+	}
+
+	next := func() string {
+		g := groups[0]
+		groups = groups[1:]
+		return g[1 : len(g)-1]
+	}
+	if strings.HasPrefix(groups[0], "(demo") {
+		t.Demo = next()
+	}
+	if len(groups) < 2 || groups[0][0] != '(' || groups[1][0] != '(' || !tosecDate.MatchString(groups[0][1:len(groups[0])-1]) {
+		return TOSEC{}, false
+	}
+	t.Date = next()
+	t.Publisher = next()
+
+	// The optional fields are told apart by their content, in their order
+	step := 0
+	for len(groups) > 0 && groups[0][0] == '(' {
+		g := next()
+		switch {
+		case step < 1 && tosecVideo[g]:
+			t.Video, step = g, 1
+		case step < 2 && regionsOK(g):
+			t.Regions, _ = tosecRegions(g)
+			step = 2
+		case step < 3 && tosecLanguage.MatchString(g):
+			t.Languages, step = strings.Split(g, "-"), 3
+		case step < 4 && tosecCopyright[g]:
+			t.Copyright, step = g, 4
+		case step < 5 && tosecDevStatus[g]:
+			t.DevStatus, step = g, 5
+		case step < 6 && tosecMedia.MatchString(g):
+			t.MediaType, step = g, 6
+		case step == 0 && t.System == "":
+			t.System = g
+		case step == 6 && t.MediaLabel == "":
+			t.MediaLabel = g
+		default:
+			t.More = append(t.More, g)
+		}
+	}
+
+	for len(groups) > 0 {
+		g := next()
+		if f, ok := parseDumpFlag(g); ok {
+			t.Flags = append(t.Flags, f)
+		} else {
+			t.More = append(t.More, g)
+		}
+	}
+	return t, true
+}
+
+func regionsOK(s string) bool {
+	_, ok := tosecRegions(s)
+	return ok
+}
+
+// Has tells if a name has a dump flag of the given kind
+func (t TOSEC) Has(kind string) bool {
+	for _, f := range t.Flags {
+		if f.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Tags lists the metadata worth displaying next to the title
+func (t TOSEC) Tags() []string {
+	var tags []string
+	for _, s := range []string{t.Demo, t.Date, t.Publisher, t.System, t.Video} {
+		if s != "" && s != "-" {
+			tags = append(tags, s)
+		}
+	}
+	tags = append(tags, t.Regions...)
+	for _, l := range t.Languages {
+		tags = append(tags, strings.Title(l))
+	}
+	for _, s := range []string{t.Copyright, t.DevStatus, t.MediaType, t.MediaLabel} {
+		if s != "" {
+			tags = append(tags, s)
+		}
+	}
+	for _, f := range t.Flags {
+		tags = append(tags, f.String())
+	}
+	return tags
+}
+
+// dumpRank orders the dumps of a game, from the verified ones to the bad
+// ones. Names that aren't TOSEC names rank like clean dumps.
+func dumpRank(name string) int {
+	t, ok := ParseTOSEC(name)
+	if !ok {
+		return 1
+	}
+	switch {
+	case t.Has("b"), t.Has("o"), t.Has("u"):
+		return 4
+	case t.Has("cr"), t.Has("f"), t.Has("h"), t.Has("m"), t.Has("p"), t.Has("t"), t.Has("tr"):
+		return 3
+	case t.Has("a"):
+		return 2
+	case t.Has("!"):
+		return 0
+	}
+	return 1
+}
+
+// bestDumps keeps the games of a Dat that matched a file with the best dump
+// flags. TOSEC dats can list the same file as a clean dump and as a modified
+// one, the clean one is picked.
+func bestDumps(games []Game) []Game {
+	best := -1
+	var kept []Game
+	for _, g := range games {
+		r := dumpRank(g.Name)
+		switch {
+		case best < 0 || r < best:
+			best, kept = r, []Game{g}
+		case r == best:
+			kept = append(kept, g)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,97 @@
+package dat
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTOSEC(t *testing.T) {
+	tests := []struct {
+		name string
+		want TOSEC
+		ok   bool
+	}{
+		{
+			name: "Legend of TOSEC, The (1986)(Devstudio)(US)[a2]",
+			want: TOSEC{
+				Title:     "Legend of TOSEC, The",
+				Date:      "1986",
+				Publisher: "Devstudio",
+				Regions:   []string{"USA"},
+				Flags:     []DumpFlag{{Kind: "a", Note: "2"}},
+			},
+			ok: true,
+		},
+		{
+			name: "Mega Demo v1.1 (demo) (1991-03)(Fairlight)(A500)(PAL)(DE-GB)(de-en)(PD)(Disk 1 of 2)(Side A)[cr Fairlight][tr fr][!]",
+			want: TOSEC{
+				Title:      "Mega Demo v1.1",
+				Demo:       "demo",
+				Date:       "1991-03",
+				Publisher:  "Fairlight",
+				System:     "A500",
+				Video:      "PAL",
+				Regions:    []string{"Germany", "UK"},
+				Languages:  []string{"de", "en"},
+				Copyright:  "PD",
+				MediaType:  "Disk 1 of 2",
+				MediaLabel: "Side A",
+				Flags: []DumpFlag{
+					{Kind: "cr", Note: "Fairlight"},
+					{Kind: "tr", Note: "fr"},
+					{Kind: "!"},
+				},
+			},
+			ok: true,
+		},
+		{
+			name: "Game (19xx)(-)(beta)[b][more info]",
+			want: TOSEC{
+				Title:     "Game",
+				Date:      "19xx",
+				Publisher: "-",
+				DevStatus: "beta",
+				Flags:     []DumpFlag{{Kind: "b"}},
+				More:      []string{"more info"},
+			},
+			ok: true,
+		},
+		{name: "Super Mario Bros. (World)", ok: false},
+		{name: "Sonic The Hedgehog (USA, Europe) (Rev 1)", ok: false},
+		{name: "Tetris", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseTOSEC(tt.name)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindByCRC(t *testing.T) {
+	rom := []ROM{{CRC: 0x1234}}
+	db := DB{"Commodore - Amiga": Dat{Games: []Game{
+		{Name: "Demo (1990)(Group)[cr Other]", ROMs: rom},
+		{Name: "Demo (1990)(Group)[!]", ROMs: rom},
+		{Name: "Demo (1990)(Group)[b]", ROMs: rom},
+		{Name: "Other (1990)(Group)", ROMs: []ROM{{CRC: 0x5678}}},
+	}}}
+
+	games := make(chan Game, 10)
+	db.FindByCRC("/roms/demo.adf", "demo.adf", 0x1234, games)
+	close(games)
+
+	var names []string
+	for g := range games {
+		names = append(names, g.Name)
+	}
+	want := []string{"Demo (1990)(Group)[!]"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("got = %v, want %v", names, want)
+	}
+}
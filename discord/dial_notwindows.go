@@ -0,0 +1,30 @@
+//go:build !windows
+// +build !windows
+
+package discord
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+// dial opens the first IPC socket found in the temporary directories
+func dial() (io.ReadWriteCloser, error) {
+	dirs := []string{os.Getenv("XDG_RUNTIME_DIR"), os.Getenv("TMPDIR"), os.Getenv("TMP"), os.Getenv("TEMP"), "/tmp"}
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		for i := 0; i < 10; i++ {
+			c, err := net.Dial("unix", filepath.Join(dir, fmt.Sprintf("discord-ipc-%d", i)))
+			if err == nil {
+				return c, nil
+			}
+		}
+	}
+	return nil, errors.New("discord is not running")
+}
@@ -0,0 +1,19 @@
+package discord
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// dial opens the first IPC named pipe of the Discord client
+func dial() (io.ReadWriteCloser, error) {
+	for i := 0; i < 10; i++ {
+		c, err := os.OpenFile(fmt.Sprintf(`\\.\pipe\discord-ipc-%d`, i), os.O_RDWR, 0)
+		if err == nil {
+			return c, nil
+		}
+	}
+	return nil, errors.New("discord is not running")
+}
@@ -0,0 +1,211 @@
+// Package discord publishes the game being played to Discord Rich Presence,
+// through the IPC socket of the Discord client running on the same machine.
+package discord
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/libretro/ludo/crash"
+	"github.com/libretro/ludo/logs"
+	"github.com/libretro/ludo/playlists"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+)
+
+// Opcodes of the Discord IPC frames
+const (
+	opHandshake = 0
+	opFrame     = 1
+)
+
+// Activity is what the user is doing, as displayed on their Discord profile
+type Activity struct {
+	Details    string      `json:"details,omitempty"`
+	State      string      `json:"state,omitempty"`
+	Timestamps *Timestamps `json:"timestamps,omitempty"`
+	Assets     *Assets     `json:"assets,omitempty"`
+}
+
+// Timestamps is the elapsed time of an activity
+type Timestamps struct {
+	Start int64 `json:"start"`
+}
+
+// Assets are the images of an activity
+type Assets struct {
+	LargeImage string `json:"large_image,omitempty"`
+	LargeText  string `json:"large_text,omitempty"`
+}
+
+var (
+	mu      sync.Mutex
+	conn    io.ReadWriteCloser
+	pending = make(chan *Activity, 1)
+	once    sync.Once
+)
+
+// frame encodes a message of the Discord IPC protocol
+func frame(op uint32, payload interface{}) ([]byte, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	f := make([]byte, 8, 8+len(b))
+	binary.LittleEndian.PutUint32(f[0:], op)
+	binary.LittleEndian.PutUint32(f[4:], uint32(len(b)))
+	return append(f, b...), nil
+}
+
+// send writes a message and reads the answer of the Discord client
+func send(c io.ReadWriter, op uint32, payload interface{}) error {
+	f, err := frame(op, payload)
+	if err != nil {
+		return err
+	}
+	if _, err := c.Write(f); err != nil {
+		return err
+	}
+
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(c, header); err != nil {
+		return err
+	}
+	body := make([]byte, binary.LittleEndian.Uint32(header[4:]))
+	if _, err := io.ReadFull(c, body); err != nil {
+		return err
+	}
+
+	var resp struct {
+		Evt  string `json:"evt"`
+		Data struct {
+			Message string `json:"message"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return err
+	}
+	if resp.Evt == "ERROR" {
+		return errors.New(resp.Data.Message)
+	}
+	return nil
+}
+
+// connect opens the IPC socket and identifies Ludo to the Discord client
+func connect() (io.ReadWriteCloser, error) {
+	if settings.Current.DiscordApplicationID == "" {
+		return nil, errors.New("no application id")
+	}
+	c, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	err = send(c, opHandshake, map[string]interface{}{
+		"v":         1,
+		"client_id": settings.Current.DiscordApplicationID,
+	})
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// setActivity publishes an activity, or clears it if a is nil
+func setActivity(a *Activity) error {
+	if a == nil && conn == nil {
+		return nil // nothing was published
+	}
+	if conn == nil {
+		c, err := connect()
+		if err != nil {
+			return err
+		}
+		conn = c
+	}
+
+	err := send(conn, opFrame, map[string]interface{}{
+		"cmd":   "SET_ACTIVITY",
+		"nonce": fmt.Sprint(time.Now().UnixNano()),
+		"args": map[string]interface{}{
+			"pid":      os.Getpid(),
+			"activity": a,
+		},
+	})
+	if err != nil {
+		// Discord may have been closed, reconnect on the next update
+		conn.Close()
+		conn = nil
+	}
+	return err
+}
+
+// worker publishes the activities one at a time, so that a slow Discord
+// client doesn't block the emulation
+func worker() {
+	defer crash.Recover()
+	for a := range pending {
+		mu.Lock()
+		if err := setActivity(a); err != nil {
+			logs.Debugf("Discord", "%v", err)
+		}
+		mu.Unlock()
+	}
+}
+
+// publish queues an activity, replacing the one not yet sent
+func publish(a *Activity) {
+	once.Do(func() { go worker() })
+	select {
+	case <-pending:
+	default:
+	}
+	pending <- a
+}
+
+// boxart is the location of the boxart of a game on the libretro thumbnails
+// server
+func boxart(system, name string) string {
+	return "https://thumbnails.libretro.com/" + url.PathEscape(system) +
+		"/Named_Boxarts/" + url.PathEscape(utils.ScrubIllegalChars(name)) + ".png"
+}
+
+// activity describes a game. The name and the system come from the
+// playlists, falling back to the file name.
+func activity(gamePath string, crc uint32, start time.Time) *Activity {
+	a := Activity{
+		Details:    utils.FileName(gamePath),
+		Timestamps: &Timestamps{Start: start.Unix()},
+	}
+	if csv, game, ok := playlists.Find(gamePath, crc); ok {
+		system := utils.FileName(csv)
+		a.Details = game.Name
+		a.State = playlists.ShortName(system)
+		a.Assets = &Assets{
+			LargeImage: boxart(system, game.Name),
+			LargeText:  game.Name,
+		}
+	}
+	return &a
+}
+
+// Start publishes the running game, if Rich Presence is enabled
+func Start() {
+	if !settings.Current.DiscordEnable || !state.CoreRunning {
+		return
+	}
+	publish(activity(state.GamePath, state.GameCRC, time.Now()))
+}
+
+// Stop clears the activity of the user
+func Stop() {
+	publish(nil)
+}
@@ -0,0 +1,100 @@
+package discord
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/libretro/ludo/playlists"
+)
+
+func Test_frame(t *testing.T) {
+	got, err := frame(opFrame, map[string]string{"cmd": "SET_ACTIVITY"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := `{"cmd":"SET_ACTIVITY"}`
+	want := []byte{1, 0, 0, 0, byte(len(body)), 0, 0, 0}
+	want = append(want, body...)
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// pipe is a fake Discord client answering with a prepared message
+type pipe struct {
+	bytes.Buffer
+	written bytes.Buffer
+}
+
+func (p *pipe) Write(b []byte) (int, error) {
+	return p.written.Write(b)
+}
+
+func answer(body string) *pipe {
+	var p pipe
+	header := make([]byte, 8)
+	binary.LittleEndian.PutUint32(header[0:], opFrame)
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(body)))
+	p.Buffer.Write(header)
+	p.Buffer.WriteString(body)
+	return &p
+}
+
+func Test_send(t *testing.T) {
+	t.Run("Reads the answer", func(t *testing.T) {
+		p := answer(`{"cmd":"SET_ACTIVITY","evt":null}`)
+		if err := send(p, opFrame, nil); err != nil {
+			t.Fatal(err)
+		}
+		if p.written.Len() != 8+len("null") {
+			t.Errorf("got %v bytes written", p.written.Len())
+		}
+	})
+
+	t.Run("Returns the errors of Discord", func(t *testing.T) {
+		p := answer(`{"evt":"ERROR","data":{"code":4000,"message":"bad activity"}}`)
+		if err := send(p, opFrame, nil); err == nil || err.Error() != "bad activity" {
+			t.Errorf("got %v, want bad activity", err)
+		}
+	})
+}
+
+func Test_activity(t *testing.T) {
+	start := time.Unix(1500000000, 0)
+
+	t.Run("Uses the name from the playlists", func(t *testing.T) {
+		playlists.Playlists = map[string]playlists.Playlist{
+			"/playlists/Sega - Master System - Mark III.csv": {
+				{Path: "/roms/alex.sms", Name: "Alex Kidd in Miracle World (USA, Europe)", CRC32: 42},
+			},
+		}
+		got := activity("/roms/alex.sms", 42, start)
+		want := &Activity{
+			Details:    "Alex Kidd in Miracle World (USA, Europe)",
+			State:      "Master System",
+			Timestamps: &Timestamps{Start: 1500000000},
+			Assets: &Assets{
+				LargeImage: "https://thumbnails.libretro.com/Sega%20-%20Master%20System%20-%20Mark%20III/Named_Boxarts/Alex%20Kidd%20in%20Miracle%20World%20%28USA%2C%20Europe%29.png",
+				LargeText:  "Alex Kidd in Miracle World (USA, Europe)",
+			},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("Falls back to the file name", func(t *testing.T) {
+		playlists.Playlists = map[string]playlists.Playlist{}
+		got := activity("/roms/unknown.sms", 1, start)
+		want := &Activity{
+			Details:    "unknown",
+			Timestamps: &Timestamps{Start: 1500000000},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+}
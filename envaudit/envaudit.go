@@ -0,0 +1,202 @@
+// Package envaudit records the environment commands a core sends to Ludo, to
+// find the features of the libretro API a core needs and Ludo lacks. The
+// calls of a core are accumulated across sessions in a report per core.
+package envaudit
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/adrg/xdg"
+	"github.com/libretro/ludo/logs"
+	"github.com/libretro/ludo/utils"
+)
+
+// Call is the use of an environment command by a core. Failed counts the
+// calls Ludo answered negatively, Implemented tells if Ludo knows the
+// command at all.
+type Call struct {
+	Cmd         uint32
+	Name        string
+	Count       int
+	Failed      int
+	Implemented bool
+}
+
+var (
+	mu      sync.Mutex
+	core    string
+	current map[uint32]*Call
+	// logged are the commands already logged this session
+	logged map[uint32]bool
+)
+
+// reportPath is the location of the report of a core
+func reportPath(corePath string) string {
+	return filepath.Join(xdg.CacheHome, "ludo", "environment", utils.FileName(corePath)+".csv")
+}
+
+// load reads a report, a CSV file with a line per command
+func load(path string) (map[uint32]*Call, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r := csv.NewReader(bufio.NewReader(file))
+	r.FieldsPerRecord = 5
+	m := map[uint32]*Call{}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		cmd, err := strconv.ParseUint(record[0], 10, 32)
+		if err != nil {
+			continue
+		}
+		c := &Call{Cmd: uint32(cmd), Name: record[1], Implemented: record[4] == "true"}
+		c.Count, _ = strconv.Atoi(record[2])
+		c.Failed, _ = strconv.Atoi(record[3])
+		m[c.Cmd] = c
+	}
+	return m, nil
+}
+
+// save writes a report
+func save(path string, m map[uint32]*Call) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(bufio.NewWriter(file))
+	for _, c := range sorted(m) {
+		w.Write([]string{
+			strconv.FormatUint(uint64(c.Cmd), 10),
+			c.Name,
+			strconv.Itoa(c.Count),
+			strconv.Itoa(c.Failed),
+			strconv.FormatBool(c.Implemented),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// sorted lists the calls of a report, the commands Ludo doesn't implement
+// first
+func sorted(m map[uint32]*Call) []Call {
+	var calls []Call
+	for _, c := range m {
+		calls = append(calls, *c)
+	}
+	sort.Slice(calls, func(i, j int) bool {
+		if calls[i].Implemented != calls[j].Implemented {
+			return !calls[i].Implemented
+		}
+		return calls[i].Cmd < calls[j].Cmd
+	})
+	return calls
+}
+
+// Start begins the audit of a core, adding to its previous report
+func Start(corePath string) {
+	mu.Lock()
+	defer mu.Unlock()
+	core = corePath
+	logged = map[uint32]bool{}
+	current, _ = load(reportPath(corePath))
+	if current == nil {
+		current = map[uint32]*Call{}
+	}
+}
+
+// Record counts a call of the audited core. The first call of each command
+// in a session is logged, as a warning for the unimplemented commands. Cores
+// send some commands on every frame.
+func Record(cmd uint32, name string, implemented, ok bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		return
+	}
+	c, seen := current[cmd]
+	if !seen {
+		c = &Call{Cmd: cmd, Name: name}
+		current[cmd] = c
+	}
+	c.Count++
+	if !ok {
+		c.Failed++
+	}
+	c.Implemented = implemented
+	if logged[cmd] {
+		return
+	}
+	logged[cmd] = true
+	if !implemented {
+		logs.Warnf("Environment", "Not implemented: %s", name)
+		return
+	}
+	logs.Debugf("Environment", "%s ok=%t", name, ok)
+}
+
+// Stop ends the audit of the core and saves its report
+func Stop() error {
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		return nil
+	}
+	err := save(reportPath(core), current)
+	current = nil
+	core = ""
+	return err
+}
+
+// Cancel ends the audit of a core that failed to load, without saving its
+// report
+func Cancel() {
+	mu.Lock()
+	defer mu.Unlock()
+	current = nil
+	core = ""
+}
+
+// Report returns the calls recorded for a core, including the running
+// session. It is empty for the cores never audited.
+func Report(corePath string) []Call {
+	mu.Lock()
+	defer mu.Unlock()
+	if current != nil && utils.FileName(corePath) == utils.FileName(core) {
+		return sorted(current)
+	}
+	m, _ := load(reportPath(corePath))
+	return sorted(m)
+}
+
+// Missing counts the commands of a report Ludo doesn't implement
+func Missing(calls []Call) int {
+	var n int
+	for _, c := range calls {
+		if !c.Implemented {
+			n++
+		}
+	}
+	return n
+}
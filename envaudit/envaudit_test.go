@@ -0,0 +1,82 @@
+package envaudit
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/adrg/xdg"
+)
+
+func TestReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "envaudit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	prev := xdg.CacheHome
+	defer func() { xdg.CacheHome = prev }()
+	xdg.CacheHome = dir
+
+	core := "/cores/snes9x_libretro.so"
+	Start(core)
+	Record(1, "SET_ROTATION", true, true)
+	Record(15, "GET_VARIABLE", true, false)
+	Record(15, "GET_VARIABLE", true, true)
+	Record(62, "SET_AUDIO_BUFFER_STATUS_CALLBACK", false, false)
+	if err := Stop(); err != nil {
+		t.Fatal(err)
+	}
+	Record(1, "SET_ROTATION", true, true)
+
+	Start(core)
+	Record(1, "SET_ROTATION", true, true)
+	if err := Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := Report(core)
+	want := []Call{
+		{Cmd: 62, Name: "SET_AUDIO_BUFFER_STATUS_CALLBACK", Count: 1, Failed: 1},
+		{Cmd: 1, Name: "SET_ROTATION", Count: 2, Implemented: true},
+		{Cmd: 15, Name: "GET_VARIABLE", Count: 2, Failed: 1, Implemented: true},
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("Report() = %+v, want %+v", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("Report()[%d] = %+v, want %+v", i, calls[i], want[i])
+		}
+	}
+	if Missing(calls) != 1 {
+		t.Errorf("Missing() = %d, want 1", Missing(calls))
+	}
+	if len(Report("/cores/mgba_libretro.so")) != 0 {
+		t.Errorf("a core never audited has no report")
+	}
+}
+
+func TestCancel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "envaudit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	prev := xdg.CacheHome
+	defer func() { xdg.CacheHome = prev }()
+	xdg.CacheHome = dir
+
+	core := "/cores/broken_libretro.so"
+	Start(core)
+	Cancel()
+	Record(1, "SET_ROTATION", true, true)
+	if err := Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(reportPath(core)); !os.IsNotExist(err) {
+		t.Error("a canceled audit should not leave a report")
+	}
+}
@@ -5,11 +5,11 @@ import (
 	"bufio"
 	"encoding/csv"
 	"io"
-	"log"
 	"os"
 	"path/filepath"
 
 	"github.com/adrg/xdg"
+	"github.com/libretro/ludo/logs"
 )
 
 // Game represents a game in the history file
@@ -44,7 +44,7 @@ func Push(g Game) {
 
 	err := Save()
 	if err != nil {
-		log.Println(err)
+		logs.Errorf("History", "%v", err)
 	}
 }
 
@@ -0,0 +1,114 @@
+// Package i18n translates the strings of the menu and of the notifications.
+// The catalogs are TOML files named after the language code, like fr.toml,
+// found in the locales directory. They map the English strings to their
+// translation, the English string being used when it has no translation.
+package i18n
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pelletier/go-toml"
+)
+
+// English is the language of the strings of the source code, it needs no
+// catalog
+const English = "en"
+
+// Catalog is the translation of the strings in a language
+type Catalog struct {
+	Name     string            `toml:"name"`
+	Messages map[string]string `toml:"messages"`
+}
+
+var (
+	catalogs = map[string]Catalog{}
+	current  Catalog
+	mu       sync.RWMutex
+)
+
+// parse reads a catalog
+func parse(b []byte) (Catalog, error) {
+	var c Catalog
+	err := toml.Unmarshal(b, &c)
+	return c, err
+}
+
+// Load reads the catalogs of a directory. Catalogs that can't be parsed are
+// skipped.
+func Load(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.toml"))
+	if err != nil {
+		return err
+	}
+	list := map[string]Catalog{}
+	for _, p := range paths {
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		c, err := parse(b)
+		if err != nil {
+			continue
+		}
+		code := strings.TrimSuffix(filepath.Base(p), ".toml")
+		if c.Name == "" {
+			c.Name = code
+		}
+		list[code] = c
+	}
+
+	mu.Lock()
+	catalogs = list
+	mu.Unlock()
+	return nil
+}
+
+// Languages returns the codes of the languages having a catalog, English
+// first
+func Languages() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	var codes []string
+	for code := range catalogs {
+		if code != English {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	return append([]string{English}, codes...)
+}
+
+// Name returns the name of a language in this language
+func Name(code string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	if c, ok := catalogs[code]; ok {
+		return c.Name
+	}
+	if code == English {
+		return "English"
+	}
+	return code
+}
+
+// Set switches the language of the strings. Unknown languages fall back to
+// English.
+func Set(code string) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = catalogs[code]
+}
+
+// T translates a string in the current language
+func T(s string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	if t, ok := current.Messages[s]; ok && t != "" {
+		return t
+	}
+	return s
+}
@@ -0,0 +1,97 @@
+package i18n
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestT(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ludo-locales")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(filepath.Join(dir, "fr.toml"), []byte(`name = "Français"
+
+[messages]
+"Settings" = "Réglages"
+"%d Games" = "%d jeux"
+"Quit" = ""
+`), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "de.toml"), []byte(`[messages]
+"Settings" = "Einstellungen"
+`), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "broken.toml"), []byte(`name = `), 0644)
+
+	if err := Load(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer Set(English)
+
+	if got, want := Languages(), []string{"en", "de", "fr"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := Name("fr"); got != "Français" {
+		t.Errorf("got %v", got)
+	}
+	if got := Name("de"); got != "de" {
+		t.Errorf("got %v", got)
+	}
+
+	tests := []struct {
+		lang, s, want string
+	}{
+		{"fr", "Settings", "Réglages"},
+		{"fr", "%d Games", "%d jeux"},
+		{"fr", "Quit", "Quit"},
+		{"fr", "History", "History"},
+		{"de", "Settings", "Einstellungen"},
+		{"en", "Settings", "Settings"},
+		{"xx", "Settings", "Settings"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lang+"/"+tt.s, func(t *testing.T) {
+			Set(tt.lang)
+			if got := T(tt.s); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCatalogs checks the catalogs shipped with Ludo. Translations must keep
+// the formatting verbs of the English strings, and only use the characters of
+// the font atlas.
+func TestCatalogs(t *testing.T) {
+	paths, _ := filepath.Glob(filepath.Join("..", "locales", "*.toml"))
+	for _, p := range paths {
+		t.Run(filepath.Base(p), func(t *testing.T) {
+			b, err := ioutil.ReadFile(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := parse(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Name == "" {
+				t.Error("the catalog has no name")
+			}
+			for s, tr := range c.Messages {
+				if strings.Count(s, "%") != strings.Count(tr, "%") {
+					t.Errorf("%q and %q don't have the same verbs", s, tr)
+				}
+				for _, r := range tr {
+					if r >= 256 {
+						t.Errorf("%q uses %q, which the font can't display", tr, r)
+					}
+				}
+			}
+		})
+	}
+}
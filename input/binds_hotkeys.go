@@ -0,0 +1,135 @@
+package input
+
+import (
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/libretro/ludo/remap"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/utils"
+)
+
+// KeyNames lists the keyboard keys that can be bound to a hot key, in the
+// order they are cycled through in the settings
+var KeyNames = []string{
+	"None",
+	"A", "B", "C", "D", "E", "F", "G", "H", "I", "J", "K", "L", "M",
+	"N", "O", "P", "Q", "R", "S", "T", "U", "V", "W", "X", "Y", "Z",
+	"0", "1", "2", "3", "4", "5", "6", "7", "8", "9",
+	"F1", "F2", "F3", "F4", "F5", "F6", "F7", "F8", "F9", "F10", "F11", "F12",
+	"Space", "Enter", "Escape", "Tab", "Backspace", "Insert", "Delete",
+	"Home", "End", "Page Up", "Page Down",
+	"Left Shift", "Right Shift", "Left Control", "Right Control",
+	"Left Alt", "Right Alt", "Scroll Lock", "Pause",
+}
+
+var keys = map[string]glfw.Key{
+	"A": glfw.KeyA, "B": glfw.KeyB, "C": glfw.KeyC, "D": glfw.KeyD,
+	"E": glfw.KeyE, "F": glfw.KeyF, "G": glfw.KeyG, "H": glfw.KeyH,
+	"I": glfw.KeyI, "J": glfw.KeyJ, "K": glfw.KeyK, "L": glfw.KeyL,
+	"M": glfw.KeyM, "N": glfw.KeyN, "O": glfw.KeyO, "P": glfw.KeyP,
+	"Q": glfw.KeyQ, "R": glfw.KeyR, "S": glfw.KeyS, "T": glfw.KeyT,
+	"U": glfw.KeyU, "V": glfw.KeyV, "W": glfw.KeyW, "X": glfw.KeyX,
+	"Y": glfw.KeyY, "Z": glfw.KeyZ,
+	"0": glfw.Key0, "1": glfw.Key1, "2": glfw.Key2, "3": glfw.Key3,
+	"4": glfw.Key4, "5": glfw.Key5, "6": glfw.Key6, "7": glfw.Key7,
+	"8": glfw.Key8, "9": glfw.Key9,
+	"F1": glfw.KeyF1, "F2": glfw.KeyF2, "F3": glfw.KeyF3, "F4": glfw.KeyF4,
+	"F5": glfw.KeyF5, "F6": glfw.KeyF6, "F7": glfw.KeyF7, "F8": glfw.KeyF8,
+	"F9": glfw.KeyF9, "F10": glfw.KeyF10, "F11": glfw.KeyF11, "F12": glfw.KeyF12,
+	"Space":         glfw.KeySpace,
+	"Enter":         glfw.KeyEnter,
+	"Escape":        glfw.KeyEscape,
+	"Tab":           glfw.KeyTab,
+	"Backspace":     glfw.KeyBackspace,
+	"Insert":        glfw.KeyInsert,
+	"Delete":        glfw.KeyDelete,
+	"Home":          glfw.KeyHome,
+	"End":           glfw.KeyEnd,
+	"Page Up":       glfw.KeyPageUp,
+	"Page Down":     glfw.KeyPageDown,
+	"Left Shift":    glfw.KeyLeftShift,
+	"Right Shift":   glfw.KeyRightShift,
+	"Left Control":  glfw.KeyLeftControl,
+	"Right Control": glfw.KeyRightControl,
+	"Left Alt":      glfw.KeyLeftAlt,
+	"Right Alt":     glfw.KeyRightAlt,
+	"Scroll Lock":   glfw.KeyScrollLock,
+	"Pause":         glfw.KeyPause,
+}
+
+// ButtonNames lists the RetroPad buttons that can be bound to a hot key or
+// used as the hot key enable button
+var ButtonNames = append([]string{"None"}, remap.Buttons...)
+
+// hotkeyBind is the keyboard key and joypad button bound to a hot key
+type hotkeyBind struct {
+	key    string
+	button string
+}
+
+// hotkeyBinds returns the hot key bindings from the settings
+func hotkeyBinds() map[uint32]hotkeyBind {
+	s := settings.Current
+	return map[uint32]hotkeyBind{
+		ActionMenuToggle:        {s.HotkeyMenuToggleKey, s.HotkeyMenuToggleButton},
+		ActionFullscreenToggle:  {s.HotkeyFullscreenKey, s.HotkeyFullscreenButton},
+		ActionShouldClose:       {s.HotkeyQuitKey, s.HotkeyQuitButton},
+		ActionFastForwardToggle: {s.HotkeyFastForwardKey, s.HotkeyFastForwardButton},
+		ActionSaveState:         {s.HotkeySaveStateKey, s.HotkeySaveStateButton},
+		ActionLoadState:         {s.HotkeyLoadStateKey, s.HotkeyLoadStateButton},
+		ActionScreenshot:        {s.HotkeyScreenshotKey, s.HotkeyScreenshotButton},
+		ActionGameFocusToggle:   {s.HotkeyGameFocusKey, "None"},
+		ActionMacroRecord:       {s.HotkeyMacroRecordKey, s.HotkeyMacroRecordButton},
+		ActionMacroPlay:         {s.HotkeyMacroPlayKey, s.HotkeyMacroPlayButton},
+		ActionRecordToggle:      {s.HotkeyRecordKey, s.HotkeyRecordButton},
+		ActionMuteToggle:        {s.HotkeyMuteKey, s.HotkeyMuteButton},
+		ActionPauseToggle:       {s.HotkeyPauseKey, s.HotkeyPauseButton},
+		ActionFrameAdvance:      {s.HotkeyFrameAdvanceKey, s.HotkeyFrameAdvanceButton},
+		ActionPerfOverlayToggle: {s.HotkeyPerfOverlayKey, s.HotkeyPerfOverlayButton},
+	}
+}
+
+// buttonID returns the RetroPad id of a button name, or -1 for "None"
+func buttonID(name string) int {
+	if !utils.StringInSlice(name, remap.Buttons) {
+		return -1
+	}
+	return utils.IndexOfString(name, remap.Buttons)
+}
+
+// pollHotkeys sets the hot key states of the first player. Keyboard hot keys
+// are active unless the keyboard is in game focus. Joypad hot keys are only
+// active while the hot key enable button is held, if one is set, and the
+// buttons used are not sent to the core.
+func pollHotkeys(st States, binds map[uint32]hotkeyBind, enable string, keyDown func(glfw.Key) bool) States {
+	enableID := buttonID(enable)
+	enabled := enableID < 0 || st[0][enableID] == 1
+
+	for action, bind := range binds {
+		// Only the game focus toggle can be used while in game focus
+		if passthrough() && action != ActionGameFocusToggle {
+			continue
+		}
+		if k, ok := keys[bind.key]; ok && keyDown(k) {
+			st[0][action] = 1
+		}
+	}
+
+	if !enabled {
+		return st
+	}
+
+	held := false
+	for action, bind := range binds {
+		id := buttonID(bind.button)
+		if id >= 0 && st[0][id] == 1 {
+			st[0][action] = 1
+			st[0][id] = 0
+			held = true
+		}
+	}
+	if held && enableID >= 0 {
+		st[0][enableID] = 0
+	}
+
+	return st
+}
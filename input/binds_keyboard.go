@@ -18,8 +18,4 @@ var keyBinds = map[glfw.Key]uint32{
 	glfw.KeyRight:      libretro.DeviceIDJoypadRight,
 	glfw.KeyEnter:      libretro.DeviceIDJoypadStart,
 	glfw.KeyRightShift: libretro.DeviceIDJoypadSelect,
-	glfw.KeySpace:      ActionFastForwardToggle,
-	glfw.KeyP:          ActionMenuToggle,
-	glfw.KeyF:          ActionFullscreenToggle,
-	glfw.KeyEscape:     ActionShouldClose,
 }
@@ -0,0 +1,59 @@
+package input
+
+import (
+	lr "github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/remap"
+)
+
+// deviceTypes are the device types that can be plugged in a port, in the
+// order they are cycled through in the menu
+var deviceTypes = []uint32{lr.DeviceJoypad, lr.DeviceMouse, lr.DeviceLightgun, lr.DeviceNone}
+
+// DeviceNames are the human readable names of the device types
+var DeviceNames = map[uint32]string{
+	lr.DeviceJoypad:   "RetroPad",
+	lr.DeviceMouse:    "Mouse",
+	lr.DeviceLightgun: "Lightgun",
+	lr.DeviceNone:     "None",
+}
+
+// Devices holds the device type plugged in each port
+var Devices = [MaxPlayers]uint32{
+	lr.DeviceJoypad, lr.DeviceJoypad, lr.DeviceJoypad, lr.DeviceJoypad, lr.DeviceJoypad,
+}
+
+// LoadDevices plugs the device types saved in the remap of the game, the
+// RetroPad being plugged in the other ports
+func LoadDevices() {
+	for port := range Devices {
+		Devices[port] = lr.DeviceJoypad
+		if port >= remap.Ports {
+			continue
+		}
+		for _, d := range deviceTypes {
+			if DeviceNames[d] == remap.Current.Devices[port] {
+				Devices[port] = d
+			}
+		}
+	}
+}
+
+// CycleDevice changes the device type of a port to the next or previous one,
+// and returns it. The remap remembers it, to be saved.
+func CycleDevice(port int, direction int) uint32 {
+	i := 0
+	for k, d := range deviceTypes {
+		if d == Devices[port] {
+			i = k
+		}
+	}
+	i = (i + direction + len(deviceTypes)) % len(deviceTypes)
+	Devices[port] = deviceTypes[i]
+	if port < remap.Ports {
+		remap.Current.Devices[port] = DeviceNames[Devices[port]]
+		if Devices[port] == lr.DeviceJoypad {
+			remap.Current.Devices[port] = ""
+		}
+	}
+	return Devices[port]
+}
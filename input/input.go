@@ -4,12 +4,16 @@
 package input
 
 import (
-	"log"
-
 	"github.com/go-gl/glfw/v3.3/glfw"
 	lr "github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/logs"
+	"github.com/libretro/ludo/macro"
+	"github.com/libretro/ludo/netpad"
 	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/overlay"
+	"github.com/libretro/ludo/remap"
 	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
 	"github.com/libretro/ludo/video"
 )
 
@@ -32,9 +36,6 @@ var (
 	NewAnalogState AnalogStates // analog input state for the current frame
 )
 
-var oldMouseX float64
-var oldMouseY float64
-
 // Hot keys
 const (
 	// ActionMenuToggle toggles the menu UI
@@ -45,21 +46,50 @@ const (
 	ActionShouldClose uint32 = lr.DeviceIDJoypadR3 + 3
 	// ActionFastForwardToggle will run the core as fast as possible
 	ActionFastForwardToggle uint32 = lr.DeviceIDJoypadR3 + 4
+	// ActionSaveState saves the state of the game
+	ActionSaveState uint32 = lr.DeviceIDJoypadR3 + 5
+	// ActionLoadState loads the most recent savestate of the game
+	ActionLoadState uint32 = lr.DeviceIDJoypadR3 + 6
+	// ActionScreenshot takes a screenshot of the game
+	ActionScreenshot uint32 = lr.DeviceIDJoypadR3 + 7
+	// ActionGameFocusToggle routes the whole keyboard to the core
+	ActionGameFocusToggle uint32 = lr.DeviceIDJoypadR3 + 8
+	// ActionMacroRecord starts and stops recording an input macro
+	ActionMacroRecord uint32 = lr.DeviceIDJoypadR3 + 9
+	// ActionMacroPlay replays the input macro of the game
+	ActionMacroPlay uint32 = lr.DeviceIDJoypadR3 + 10
+	// ActionRecordToggle starts and stops recording the game video
+	ActionRecordToggle uint32 = lr.DeviceIDJoypadR3 + 11
+	// ActionMuteToggle mutes and unmutes the game audio
+	ActionMuteToggle uint32 = lr.DeviceIDJoypadR3 + 12
+	// ActionPauseToggle pauses and resumes the game
+	ActionPauseToggle uint32 = lr.DeviceIDJoypadR3 + 13
+	// ActionFrameAdvance pauses the game and runs it one frame at a time
+	ActionFrameAdvance uint32 = lr.DeviceIDJoypadR3 + 14
+	// ActionPerfOverlayToggle shows and hides the performance overlay
+	ActionPerfOverlayToggle uint32 = lr.DeviceIDJoypadR3 + 15
 	// ActionLast is used for iterating
-	ActionLast uint32 = lr.DeviceIDJoypadR3 + 5
+	ActionLast uint32 = lr.DeviceIDJoypadR3 + 16
 )
 
 // joystickCallback is triggered when a joypad is plugged.
 func joystickCallback(joy glfw.Joystick, event glfw.PeripheralEvent) {
 	switch event {
 	case glfw.Connected:
-		if joy.IsGamepad() {
-			ntf.DisplayAndLog(ntf.Info, "Input", "Joystick #%d plugged: %s.", joy, glfw.Joystick.GetName(joy))
-		} else {
+		if !joy.IsGamepad() {
 			ntf.DisplayAndLog(ntf.Warning, "Input", "Joystick #%d plugged: %s but not configured.", joy, glfw.Joystick.GetName(joy))
+		} else if p := bindJoystick(joy); p >= 0 {
+			ntf.DisplayAndLog(ntf.Info, "Input", "Joystick #%d plugged: %s, bound to port %d.", joy, glfw.Joystick.GetName(joy), p+1)
+		} else {
+			ntf.DisplayAndLog(ntf.Info, "Input", "Joystick #%d plugged: %s, no free port.", joy, glfw.Joystick.GetName(joy))
 		}
 	case glfw.Disconnected:
-		ntf.DisplayAndLog(ntf.Info, "Input", "Joystick #%d unplugged.", joy)
+		if p := portOf(joy); p >= 0 {
+			unbindJoystick(joy)
+			ntf.DisplayAndLog(ntf.Info, "Input", "Joystick #%d unplugged, port %d is free.", joy, p+1)
+		} else {
+			ntf.DisplayAndLog(ntf.Info, "Input", "Joystick #%d unplugged.", joy)
+		}
 	default:
 		ntf.DisplayAndLog(ntf.Warning, "Input", "Joystick #%d unhandled event: %d.", joy, event)
 	}
@@ -71,9 +101,17 @@ var vid *video.Video
 func Init(v *video.Video) {
 	vid = v
 	if !glfw.UpdateGamepadMappings(mappings) {
-		log.Println("Failed to update mappings")
+		logs.Warnf("Input", "Failed to update mappings")
 	}
 	glfw.SetJoystickCallback(joystickCallback)
+	if vid.Window != nil {
+		vid.Window.SetScrollCallback(scrollCallback)
+		vid.Window.SetKeyCallback(keyCallback)
+		vid.Window.SetCharModsCallback(charCallback)
+	}
+	for _, joy := range connectedGamepads() {
+		bindJoystick(joy)
+	}
 }
 
 func floatToAnalog(v float32) int16 {
@@ -82,9 +120,8 @@ func floatToAnalog(v float32) int16 {
 
 // pollJoypads process joypads of all players
 func pollJoypads(state States, analogState AnalogStates) (States, AnalogStates) {
-	p := 0
-	for joy := glfw.Joystick(0); joy < glfw.JoystickLast; joy++ {
-		if !joy.IsGamepad() {
+	for p, joy := range Ports {
+		if joy == noJoystick || !joy.IsGamepad() {
 			continue
 		}
 		pad := joy.GetGamepadState()
@@ -114,7 +151,7 @@ func pollJoypads(state States, analogState AnalogStates) (States, AnalogStates)
 		analogState[p][lr.DeviceIndexAnalogRight][lr.DeviceIDAnalogY] = floatToAnalog(pad.Axes[glfw.AxisRightY])
 
 		// optionally mapping analog sticks to dpad
-		if settings.Current.MapAxisToDPad {
+		if settings.Current.MapAxisToDPad || remap.Current.AnalogToDPad {
 			if pad.Axes[glfw.AxisLeftX] < -0.5 {
 				state[p][lr.DeviceIDJoypadLeft] = 1
 			} else if pad.Axes[glfw.AxisLeftX] > 0.5 {
@@ -126,14 +163,60 @@ func pollJoypads(state States, analogState AnalogStates) (States, AnalogStates)
 				state[p][lr.DeviceIDJoypadUp] = 1
 			}
 		}
-		p++
 	}
 
 	return state, analogState
 }
 
+// buttonsMask packs the RetroPad buttons of a player in a bit mask
+func buttonsMask(st [ActionLast]int16) uint16 {
+	var mask uint16
+	for id := range remap.Buttons {
+		if st[id] == 1 {
+			mask |= 1 << uint(id)
+		}
+	}
+	return mask
+}
+
+// applyMask replaces the RetroPad buttons of a player with a bit mask, leaving
+// the hot keys untouched
+func applyMask(st [ActionLast]int16, mask uint16) [ActionLast]int16 {
+	for id := range remap.Buttons {
+		st[id] = int16(mask >> uint(id) & 1)
+	}
+	return st
+}
+
+// dpadToAnalog drives the left analog stick with the dpad, for the ports where
+// the stick is not already in use
+func dpadToAnalog(state States, analogState AnalogStates) AnalogStates {
+	for p := range state {
+		x := &analogState[p][lr.DeviceIndexAnalogLeft][lr.DeviceIDAnalogX]
+		y := &analogState[p][lr.DeviceIndexAnalogLeft][lr.DeviceIDAnalogY]
+		if *x > 0x2000 || *x < -0x2000 || *y > 0x2000 || *y < -0x2000 {
+			continue
+		}
+		*x, *y = 0, 0
+		if state[p][lr.DeviceIDJoypadLeft] == 1 {
+			*x = -0x7fff
+		} else if state[p][lr.DeviceIDJoypadRight] == 1 {
+			*x = 0x7fff
+		}
+		if state[p][lr.DeviceIDJoypadUp] == 1 {
+			*y = -0x7fff
+		} else if state[p][lr.DeviceIDJoypadDown] == 1 {
+			*y = 0x7fff
+		}
+	}
+	return analogState
+}
+
 // pollKeyboard processes keyboard keys
 func pollKeyboard(state States) States {
+	if passthrough() {
+		return state
+	}
 	for k, v := range keyBinds {
 		if vid.Window.GetKey(k) == glfw.Press {
 			state[0][v] = 1
@@ -161,28 +244,91 @@ func getPressedReleased(new States, old States) (States, States) {
 	return Pressed, Released
 }
 
+// frame counts the polls, to pace the turbo buttons
+var frame uint
+
 // Poll calculates the input state. It is meant to be called for each frame.
 func Poll() {
+	frame++
 	NewState = States{}
-	NewState, NewAnalogState = pollJoypads(NewState, NewAnalogState)
+	NewAnalogState = AnalogStates{}
+	// GLFW reads the gamepads even when another window has the focus. They are
+	// only ignored in the background when the game pauses there too.
+	if !settings.Current.PauseOnFocusLoss || vid.Window.GetAttrib(glfw.Focused) == glfw.True {
+		NewState, NewAnalogState = pollJoypads(NewState, NewAnalogState)
+	}
 	NewState = pollKeyboard(NewState)
+	pollKeys()
+	pollMouse()
+	refreshRumble()
+	NewState = pollHotkeys(NewState, hotkeyBinds(), settings.Current.HotkeyEnable, func(k glfw.Key) bool {
+		return vid.Window.GetKey(k) == glfw.Press
+	})
+	if state.CoreRunning && !state.MenuActive {
+		for _, id := range overlay.Pressed(vid) {
+			NewState[0][id] = 1
+		}
+		for port := 0; port < MaxPlayers && port < netpad.MaxPorts; port++ {
+			for _, id := range netpad.Pressed(port) {
+				NewState[port][id] = 1
+			}
+		}
+	}
+	if state.CoreRunning && !state.MenuActive {
+		if macro.Record(buttonsMask(NewState[0])) {
+			if err := macro.Save(state.CorePath, state.GameCRC); err != nil {
+				ntf.DisplayAndLog(ntf.Error, "Input", "Error saving macro: %v", err.Error())
+			} else {
+				ntf.DisplayAndLog(ntf.Success, "Input", "Macro length limit reached, macro saved.")
+			}
+		}
+		if mask, ok := macro.Next(); ok {
+			NewState[0] = applyMask(NewState[0], mask)
+		}
+	}
+	if remap.Current.DPadToAnalog {
+		NewAnalogState = dpadToAnalog(NewState, NewAnalogState)
+	}
 	Pressed, Released = getPressedReleased(NewState, OldState)
+	pollWheel()
 
 	// Store the old input state for comparisions
 	OldState = NewState
 }
 
+// remapped returns the state of the button id as seen by the core, taking the
+// current remap into account
+func remapped(st States, port uint, id uint) int16 {
+	var v int16
+	for button, target := range remap.Current.Targets {
+		if uint(target) != id || st[port][button] == 0 {
+			continue
+		}
+		if remap.Current.Turbo[button] && !remap.Current.TurboOn(frame) {
+			continue
+		}
+		v = 1
+	}
+	return v
+}
+
 // State is a callback passed to core.SetInputState
 // It returns 1 if the button corresponding to the parameters is pressed
 func State(port uint, device uint32, index uint, id uint) int16 {
-	if port >= MaxPlayers {
+	if port >= MaxPlayers || Devices[port] == lr.DeviceNone {
 		return 0
 	}
+	if played != nil {
+		return playedState(port, device, index, id)
+	}
 
 	if device == lr.DeviceJoypad {
 		if id >= uint(ActionLast) || index > 0 {
 			return 0
 		}
+		if id < uint(len(remap.Current.Targets)) {
+			return remapped(NewState, port, id)
+		}
 		return NewState[port][id]
 	}
 	if device == lr.DeviceAnalog {
@@ -194,23 +340,19 @@ func State(port uint, device uint32, index uint, id uint) int16 {
 	}
 
 	if device == lr.DeviceMouse {
-		x, y := vid.Window.GetCursorPos()
-		if id == uint(lr.DeviceIDMouseX) {
-			d := x - oldMouseX
-			oldMouseX = x
-			return int16(d)
-		}
-		if id == uint(lr.DeviceIDMouseY) {
-			d := y - oldMouseY
-			oldMouseY = y
-			return int16(d)
-		}
-		if id == uint(lr.DeviceIDMouseLeft) && vid.Window.GetMouseButton(glfw.MouseButton1) == glfw.Press {
-			return 1
-		}
-		if id == uint(lr.DeviceIDMouseRight) && vid.Window.GetMouseButton(glfw.MouseButton2) == glfw.Press {
-			return 1
-		}
+		return mouseState(id)
+	}
+
+	if device == lr.DeviceLightgun {
+		return lightgunState(id)
+	}
+
+	if device == lr.DevicePointer {
+		return pointerState(index, id)
+	}
+
+	if device == lr.DeviceKeyboard {
+		return keyboardState(id)
 	}
 
 	return 0
@@ -1,7 +1,14 @@
 package input
 
 import (
+	"reflect"
 	"testing"
+	"time"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+	lr "github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/remap"
+	"github.com/libretro/ludo/state"
 )
 
 func Test_getPressedReleased(t *testing.T) {
@@ -23,3 +30,270 @@ func Test_getPressedReleased(t *testing.T) {
 		}
 	})
 }
+
+func Test_remapped(t *testing.T) {
+	defer func() { remap.Current = remap.Identity() }()
+
+	var st States
+	st[0][lr.DeviceIDJoypadA] = 1
+
+	t.Run("Sends buttons as is without remap", func(t *testing.T) {
+		remap.Current = remap.Identity()
+		if got := remapped(st, 0, uint(lr.DeviceIDJoypadA)); got != 1 {
+			t.Errorf("got = %v, want %v", got, 1)
+		}
+		if got := remapped(st, 0, uint(lr.DeviceIDJoypadB)); got != 0 {
+			t.Errorf("got = %v, want %v", got, 0)
+		}
+	})
+
+	t.Run("Sends the target button when remapped", func(t *testing.T) {
+		remap.Current = remap.Identity()
+		remap.Current.Targets[lr.DeviceIDJoypadA] = lr.DeviceIDJoypadB
+		if got := remapped(st, 0, uint(lr.DeviceIDJoypadA)); got != 0 {
+			t.Errorf("got = %v, want %v", got, 0)
+		}
+		if got := remapped(st, 0, uint(lr.DeviceIDJoypadB)); got != 1 {
+			t.Errorf("got = %v, want %v", got, 1)
+		}
+	})
+
+	t.Run("Turbo buttons are pressed during a part of the cycle", func(t *testing.T) {
+		remap.Current = remap.Identity()
+		remap.Current.Turbo[lr.DeviceIDJoypadA] = true
+		remap.Current.TurboPeriod = 4
+		remap.Current.TurboDuty = 1
+		var got []int16
+		for frame = 0; frame < 4; frame++ {
+			got = append(got, remapped(st, 0, uint(lr.DeviceIDJoypadA)))
+		}
+		want := []int16{1, 0, 0, 0}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got = %v, want %v", got, want)
+		}
+	})
+}
+
+func Test_pollHotkeys(t *testing.T) {
+	binds := map[uint32]hotkeyBind{
+		ActionMenuToggle: {"P", "Start"},
+	}
+	noKey := func(glfw.Key) bool { return false }
+
+	t.Run("Keyboard hot keys are always active", func(t *testing.T) {
+		got := pollHotkeys(States{}, binds, "Select", func(k glfw.Key) bool { return k == glfw.KeyP })
+		if got[0][ActionMenuToggle] != 1 {
+			t.Errorf("got = %v, want %v", got[0][ActionMenuToggle], 1)
+		}
+	})
+
+	t.Run("Joypad hot keys need the enable button", func(t *testing.T) {
+		var st States
+		st[0][lr.DeviceIDJoypadStart] = 1
+		got := pollHotkeys(st, binds, "Select", noKey)
+		if got[0][ActionMenuToggle] != 0 {
+			t.Errorf("got = %v, want %v", got[0][ActionMenuToggle], 0)
+		}
+		if got[0][lr.DeviceIDJoypadStart] != 1 {
+			t.Errorf("got = %v, want %v", got[0][lr.DeviceIDJoypadStart], 1)
+		}
+	})
+
+	t.Run("Joypad hot keys are not sent to the core", func(t *testing.T) {
+		var st States
+		st[0][lr.DeviceIDJoypadStart] = 1
+		st[0][lr.DeviceIDJoypadSelect] = 1
+		got := pollHotkeys(st, binds, "Select", noKey)
+		if got[0][ActionMenuToggle] != 1 {
+			t.Errorf("got = %v, want %v", got[0][ActionMenuToggle], 1)
+		}
+		if got[0][lr.DeviceIDJoypadStart] != 0 || got[0][lr.DeviceIDJoypadSelect] != 0 {
+			t.Errorf("got = %v, want %v", got[0][:4], []int16{0, 0, 0, 0})
+		}
+	})
+}
+
+func Test_ports(t *testing.T) {
+	defer func() { Ports = freePorts() }()
+	Ports = freePorts()
+
+	t.Run("Binds joysticks to the first free ports", func(t *testing.T) {
+		if got := bindJoystick(3); got != 0 {
+			t.Errorf("got = %v, want %v", got, 0)
+		}
+		if got := bindJoystick(1); got != 1 {
+			t.Errorf("got = %v, want %v", got, 1)
+		}
+		if got := bindJoystick(3); got != 0 {
+			t.Errorf("got = %v, want %v", got, 0)
+		}
+	})
+
+	t.Run("Frees the port of unplugged joysticks", func(t *testing.T) {
+		unbindJoystick(3)
+		if Ports[0] != noJoystick {
+			t.Errorf("got = %v, want %v", Ports[0], noJoystick)
+		}
+		if got := bindJoystick(5); got != 0 {
+			t.Errorf("got = %v, want %v", got, 0)
+		}
+	})
+
+	t.Run("Swaps ports when binding a joystick already in use", func(t *testing.T) {
+		SetPort(0, 1)
+		if Ports[0] != 1 || Ports[1] != 5 {
+			t.Errorf("got = %v, want %v", Ports[:2], []glfw.Joystick{1, 5})
+		}
+	})
+}
+
+func TestCycleDevice(t *testing.T) {
+	defer func() {
+		Devices[0] = lr.DeviceJoypad
+		remap.Current = remap.Identity()
+	}()
+
+	t.Run("Cycles forward", func(t *testing.T) {
+		if got := CycleDevice(0, 1); got != lr.DeviceMouse {
+			t.Errorf("got = %v, want %v", got, lr.DeviceMouse)
+		}
+	})
+
+	t.Run("Wraps around", func(t *testing.T) {
+		CycleDevice(0, -1)
+		if got := CycleDevice(0, -1); got != lr.DeviceNone {
+			t.Errorf("got = %v, want %v", got, lr.DeviceNone)
+		}
+	})
+}
+
+func Test_toScreen(t *testing.T) {
+	t.Run("Maps the game area to the libretro range", func(t *testing.T) {
+		x, y, off := toScreen(100, 50, 100, 0, 200, 100)
+		if x != -0x7fff || y != 0 || off {
+			t.Errorf("got = %v %v %v, want %v %v %v", x, y, off, -0x7fff, 0, false)
+		}
+		x, y, off = toScreen(300, 100, 100, 0, 200, 100)
+		if x != 0x7fff || y != 0x7fff || off {
+			t.Errorf("got = %v %v %v, want %v %v %v", x, y, off, 0x7fff, 0x7fff, false)
+		}
+	})
+
+	t.Run("Reports the black bars as off screen", func(t *testing.T) {
+		_, _, off := toScreen(50, 50, 100, 0, 200, 100)
+		if !off {
+			t.Errorf("got = %v, want %v", off, true)
+		}
+	})
+}
+
+func Test_dpadToAnalog(t *testing.T) {
+	t.Run("Pushes the left stick in the dpad direction", func(t *testing.T) {
+		var st States
+		st[0][lr.DeviceIDJoypadLeft] = 1
+		st[0][lr.DeviceIDJoypadDown] = 1
+		got := dpadToAnalog(st, AnalogStates{})
+		if got[0][lr.DeviceIndexAnalogLeft] != [2]int16{-0x7fff, 0x7fff} {
+			t.Errorf("got = %v, want %v", got[0][lr.DeviceIndexAnalogLeft], [2]int16{-0x7fff, 0x7fff})
+		}
+	})
+
+	t.Run("Doesn't override a tilted stick", func(t *testing.T) {
+		var st States
+		st[0][lr.DeviceIDJoypadLeft] = 1
+		var ast AnalogStates
+		ast[0][lr.DeviceIndexAnalogLeft][lr.DeviceIDAnalogX] = 0x6000
+		got := dpadToAnalog(st, ast)
+		if got[0][lr.DeviceIndexAnalogLeft] != [2]int16{0x6000, 0} {
+			t.Errorf("got = %v, want %v", got[0][lr.DeviceIndexAnalogLeft], [2]int16{0x6000, 0})
+		}
+	})
+}
+
+func Test_applyMask(t *testing.T) {
+	t.Run("Round trips the RetroPad buttons and keeps hot keys", func(t *testing.T) {
+		var st [ActionLast]int16
+		st[lr.DeviceIDJoypadA] = 1
+		st[lr.DeviceIDJoypadUp] = 1
+		mask := buttonsMask(st)
+
+		var live [ActionLast]int16
+		live[lr.DeviceIDJoypadB] = 1
+		live[ActionMenuToggle] = 1
+		got := applyMask(live, mask)
+
+		want := st
+		want[ActionMenuToggle] = 1
+		if got != want {
+			t.Errorf("got = %v, want %v", got, want)
+		}
+	})
+}
+
+func Test_sendKeyEvents(t *testing.T) {
+	defer func() {
+		state.Core, state.GameFocus, state.CoreRunning = nil, false, false
+		keyEvents = nil
+	}()
+	var got []uint32
+	state.Core = &lr.Core{KeyboardCallback: &lr.KeyboardCallback{
+		Callback: func(down bool, code, char uint32, mods uint16) {
+			got = append(got, code)
+		},
+	}}
+	state.GameFocus, state.CoreRunning, state.MenuActive = true, true, false
+
+	keyCallback(nil, glfw.KeyA, 0, glfw.Press, 0)
+	keyCallback(nil, glfw.KeyA, 0, glfw.Release, 0)
+	if len(got) != 0 {
+		t.Fatal("the events should wait for the poll")
+	}
+	sendKeyEvents()
+	if want := []uint32{lr.KeyA, lr.KeyA}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if len(keyEvents) != 0 {
+		t.Errorf("the queue wasn't emptied")
+	}
+}
+
+func Test_rumbleDue(t *testing.T) {
+	at := time.Now()
+	tests := []struct {
+		name      string
+		strengths [2]uint16
+		elapsed   time.Duration
+		want      bool
+	}{
+		{"Stopped", [2]uint16{0, 0}, 2 * rumbleRefresh, false},
+		{"Playing", [2]uint16{0xffff, 0}, time.Second, false},
+		{"About to end", [2]uint16{0, 0x8000}, rumbleRefresh, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rumbleDue(tt.strengths, at, at.Add(tt.elapsed)); got != tt.want {
+				t.Errorf("rumbleDue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadDevices(t *testing.T) {
+	defer func() {
+		remap.Current = remap.Identity()
+		LoadDevices()
+	}()
+
+	CycleDevice(1, 2)
+	if remap.Current.Devices[1] != "Lightgun" {
+		t.Fatalf("the remap should remember the device, got %q", remap.Current.Devices[1])
+	}
+
+	remap.Current = remap.Decode(remap.Encode(remap.Current))
+	Devices[1] = lr.DeviceJoypad
+	LoadDevices()
+	want := [MaxPlayers]uint32{lr.DeviceJoypad, lr.DeviceLightgun, lr.DeviceJoypad, lr.DeviceJoypad, lr.DeviceJoypad}
+	if Devices != want {
+		t.Errorf("got %v, want %v", Devices, want)
+	}
+}
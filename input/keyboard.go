@@ -0,0 +1,233 @@
+package input
+
+import (
+	"github.com/go-gl/glfw/v3.3/glfw"
+	lr "github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/state"
+)
+
+// retroKeys maps the host keyboard keys to the libretro keysyms
+var retroKeys = map[glfw.Key]uint32{
+	glfw.KeyBackspace:    lr.KeyBackspace,
+	glfw.KeyTab:          lr.KeyTab,
+	glfw.KeyEnter:        lr.KeyReturn,
+	glfw.KeyPause:        lr.KeyPause,
+	glfw.KeyEscape:       lr.KeyEscape,
+	glfw.KeySpace:        lr.KeySpace,
+	glfw.KeyApostrophe:   lr.KeyQuote,
+	glfw.KeyComma:        lr.KeyComma,
+	glfw.KeyMinus:        lr.KeyMinus,
+	glfw.KeyPeriod:       lr.KeyPeriod,
+	glfw.KeySlash:        lr.KeySlash,
+	glfw.Key0:            lr.Key0,
+	glfw.Key1:            lr.Key1,
+	glfw.Key2:            lr.Key2,
+	glfw.Key3:            lr.Key3,
+	glfw.Key4:            lr.Key4,
+	glfw.Key5:            lr.Key5,
+	glfw.Key6:            lr.Key6,
+	glfw.Key7:            lr.Key7,
+	glfw.Key8:            lr.Key8,
+	glfw.Key9:            lr.Key9,
+	glfw.KeySemicolon:    lr.KeySemicolon,
+	glfw.KeyEqual:        lr.KeyEquals,
+	glfw.KeyLeftBracket:  lr.KeyLeftBracket,
+	glfw.KeyBackslash:    lr.KeyBackslash,
+	glfw.KeyRightBracket: lr.KeyRightBracket,
+	glfw.KeyGraveAccent:  lr.KeyBackQuote,
+	glfw.KeyA:            lr.KeyA,
+	glfw.KeyB:            lr.KeyB,
+	glfw.KeyC:            lr.KeyC,
+	glfw.KeyD:            lr.KeyD,
+	glfw.KeyE:            lr.KeyE,
+	glfw.KeyF:            lr.KeyF,
+	glfw.KeyG:            lr.KeyG,
+	glfw.KeyH:            lr.KeyH,
+	glfw.KeyI:            lr.KeyI,
+	glfw.KeyJ:            lr.KeyJ,
+	glfw.KeyK:            lr.KeyK,
+	glfw.KeyL:            lr.KeyL,
+	glfw.KeyM:            lr.KeyM,
+	glfw.KeyN:            lr.KeyN,
+	glfw.KeyO:            lr.KeyO,
+	glfw.KeyP:            lr.KeyP,
+	glfw.KeyQ:            lr.KeyQ,
+	glfw.KeyR:            lr.KeyR,
+	glfw.KeyS:            lr.KeyS,
+	glfw.KeyT:            lr.KeyT,
+	glfw.KeyU:            lr.KeyU,
+	glfw.KeyV:            lr.KeyV,
+	glfw.KeyW:            lr.KeyW,
+	glfw.KeyX:            lr.KeyX,
+	glfw.KeyY:            lr.KeyY,
+	glfw.KeyZ:            lr.KeyZ,
+	glfw.KeyDelete:       lr.KeyDelete,
+	glfw.KeyKP0:          lr.KeyKP0,
+	glfw.KeyKP1:          lr.KeyKP1,
+	glfw.KeyKP2:          lr.KeyKP2,
+	glfw.KeyKP3:          lr.KeyKP3,
+	glfw.KeyKP4:          lr.KeyKP4,
+	glfw.KeyKP5:          lr.KeyKP5,
+	glfw.KeyKP6:          lr.KeyKP6,
+	glfw.KeyKP7:          lr.KeyKP7,
+	glfw.KeyKP8:          lr.KeyKP8,
+	glfw.KeyKP9:          lr.KeyKP9,
+	glfw.KeyKPDecimal:    lr.KeyKPPeriod,
+	glfw.KeyKPDivide:     lr.KeyKPDivide,
+	glfw.KeyKPMultiply:   lr.KeyKPMultiply,
+	glfw.KeyKPSubtract:   lr.KeyKPMinus,
+	glfw.KeyKPAdd:        lr.KeyKPPlus,
+	glfw.KeyKPEnter:      lr.KeyKPEnter,
+	glfw.KeyKPEqual:      lr.KeyKPEquals,
+	glfw.KeyUp:           lr.KeyUp,
+	glfw.KeyDown:         lr.KeyDown,
+	glfw.KeyRight:        lr.KeyRight,
+	glfw.KeyLeft:         lr.KeyLeft,
+	glfw.KeyInsert:       lr.KeyInsert,
+	glfw.KeyHome:         lr.KeyHome,
+	glfw.KeyEnd:          lr.KeyEnd,
+	glfw.KeyPageUp:       lr.KeyPageUp,
+	glfw.KeyPageDown:     lr.KeyPageDown,
+	glfw.KeyF1:           lr.KeyF1,
+	glfw.KeyF2:           lr.KeyF2,
+	glfw.KeyF3:           lr.KeyF3,
+	glfw.KeyF4:           lr.KeyF4,
+	glfw.KeyF5:           lr.KeyF5,
+	glfw.KeyF6:           lr.KeyF6,
+	glfw.KeyF7:           lr.KeyF7,
+	glfw.KeyF8:           lr.KeyF8,
+	glfw.KeyF9:           lr.KeyF9,
+	glfw.KeyF10:          lr.KeyF10,
+	glfw.KeyF11:          lr.KeyF11,
+	glfw.KeyF12:          lr.KeyF12,
+	glfw.KeyF13:          lr.KeyF13,
+	glfw.KeyF14:          lr.KeyF14,
+	glfw.KeyF15:          lr.KeyF15,
+	glfw.KeyNumLock:      lr.KeyNumLock,
+	glfw.KeyCapsLock:     lr.KeyCapsLock,
+	glfw.KeyScrollLock:   lr.KeyScrolLock,
+	glfw.KeyRightShift:   lr.KeyRShift,
+	glfw.KeyLeftShift:    lr.KeyLShift,
+	glfw.KeyRightControl: lr.KeyRCtrl,
+	glfw.KeyLeftControl:  lr.KeyLCtrl,
+	glfw.KeyRightAlt:     lr.KeyRAlt,
+	glfw.KeyLeftAlt:      lr.KeyLAlt,
+	glfw.KeyLeftSuper:    lr.KeyLSuper,
+	glfw.KeyRightSuper:   lr.KeyRSuper,
+	glfw.KeyMenu:         lr.KeyMenu,
+	glfw.KeyPrintScreen:  lr.KeyPrint,
+}
+
+// hostKeys is the reverse of retroKeys
+var hostKeys = func() map[uint32]glfw.Key {
+	m := make(map[uint32]glfw.Key)
+	for k, v := range retroKeys {
+		m[v] = k
+	}
+	return m
+}()
+
+// passthrough is true when the keyboard is routed to the core
+func passthrough() bool {
+	return state.GameFocus && state.CoreRunning && !state.MenuActive
+}
+
+// retroModifiers converts the host key modifiers to libretro key modifiers
+func retroModifiers(mods glfw.ModifierKey) uint16 {
+	m := lr.KeyModNone
+	if mods&glfw.ModShift != 0 {
+		m |= lr.KeyModShift
+	}
+	if mods&glfw.ModControl != 0 {
+		m |= lr.KeyModCtrl
+	}
+	if mods&glfw.ModAlt != 0 {
+		m |= lr.KeyModAlt
+	}
+	if mods&glfw.ModSuper != 0 {
+		m |= lr.KeyModMeta
+	}
+	if mods&glfw.ModCapsLock != 0 {
+		m |= lr.KeyModCapsLock
+	}
+	if mods&glfw.ModNumLock != 0 {
+		m |= lr.KeyModNumLock
+	}
+	return m
+}
+
+// keyEvent is a key press, release or typed character for the core
+type keyEvent struct {
+	down      bool
+	code      uint32
+	character uint32
+	mods      uint16
+}
+
+// keyEvents are the keyboard events received since the last poll. GLFW
+// delivers them while the core thread may be running a frame, they are sent
+// to the core by Poll, once the core thread waits.
+var keyEvents []keyEvent
+
+// heldKeys is the state of the host keyboard, read by pollKeys on the main
+// thread
+var heldKeys [glfw.KeyLast + 1]bool
+
+// keyCallback queues key presses and releases for the core
+func keyCallback(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+	if !passthrough() || state.Core.KeyboardCallback == nil || action == glfw.Repeat {
+		return
+	}
+	code, ok := retroKeys[key]
+	if !ok {
+		code = lr.KeyUnknown
+	}
+	keyEvents = append(keyEvents, keyEvent{down: action == glfw.Press, code: code, mods: retroModifiers(mods)})
+}
+
+// charCallback queues typed characters for the core
+func charCallback(w *glfw.Window, char rune, mods glfw.ModifierKey) {
+	if !passthrough() || state.Core.KeyboardCallback == nil {
+		return
+	}
+	keyEvents = append(keyEvents, keyEvent{down: true, code: lr.KeyUnknown, character: uint32(char), mods: retroModifiers(mods)})
+}
+
+// sendKeyEvents empties the queue of keyboard events, sending them to the
+// core. It tells if the keyboard goes to the core.
+func sendKeyEvents() bool {
+	events := keyEvents
+	keyEvents = nil
+	if !passthrough() {
+		return false
+	}
+	if cb := state.Core.KeyboardCallback; cb != nil {
+		for _, e := range events {
+			cb.Callback(e.down, e.code, e.character, e.mods)
+		}
+	}
+	return true
+}
+
+// pollKeys sends the queued keyboard events to the core, and reads the state
+// of the keys the core polls
+func pollKeys() {
+	if !sendKeyEvents() {
+		return
+	}
+	for k := range retroKeys {
+		heldKeys[k] = vid.Window.GetKey(k) == glfw.Press
+	}
+}
+
+// keyboardState returns the state of a key polled with DeviceKeyboard
+func keyboardState(id uint) int16 {
+	if !passthrough() {
+		return 0
+	}
+	k, ok := hostKeys[uint32(id)]
+	if !ok {
+		return 0
+	}
+	return boolToState(heldKeys[k])
+}
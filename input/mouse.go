@@ -0,0 +1,162 @@
+package input
+
+import (
+	"github.com/go-gl/glfw/v3.3/glfw"
+	lr "github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/overlay"
+)
+
+// mouse is the state of the host mouse, read by pollMouse on the main
+// thread. The cores poll it from the core thread, where GLFW can't be used.
+var mouse struct {
+	buttons [glfw.MouseButtonLast + 1]bool
+	x, y    float64 // cursor in the window
+	dx, dy  float64 // motion since the previous poll
+	cx, cy  float64 // cursor in the unrotated window, see CursorPos
+	rx, ry  float32 // area where the game is drawn, see ContentRect
+	rw, rh  float32
+	overlay bool // the cursor presses an overlay button
+}
+
+// pollMouse reads the state of the host mouse
+func pollMouse() {
+	for b := range mouse.buttons {
+		mouse.buttons[b] = vid.Window.GetMouseButton(glfw.MouseButton(b)) == glfw.Press
+	}
+	x, y := vid.Window.GetCursorPos()
+	mouse.dx, mouse.dy = x-mouse.x, y-mouse.y
+	mouse.x, mouse.y = x, y
+	mouse.cx, mouse.cy = vid.CursorPos()
+	mouse.rx, mouse.ry, mouse.rw, mouse.rh = vid.ContentRect()
+	mouse.overlay = len(overlay.Pressed(vid)) > 0
+}
+
+// Mouse wheel accumulated between two polls, and its state for this frame
+var (
+	scrollX, scrollY      float64
+	wheelUp, wheelDown    bool
+	wheelLeft, wheelRight bool
+)
+
+// scrollCallback is triggered when the mouse wheel or the trackpad scrolls
+func scrollCallback(w *glfw.Window, xoff float64, yoff float64) {
+	scrollX += xoff
+	scrollY += yoff
+}
+
+// pollWheel consumes the scroll events received since the last frame
+func pollWheel() {
+	wheelUp, wheelDown = scrollY > 0, scrollY < 0
+	wheelRight, wheelLeft = scrollX > 0, scrollX < 0
+	scrollX, scrollY = 0, 0
+}
+
+func boolToState(b bool) int16 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func mouseButton(b glfw.MouseButton) bool {
+	return mouse.buttons[b]
+}
+
+// mouseState returns the state of the host mouse as a libretro mouse
+func mouseState(id uint) int16 {
+	switch uint32(id) {
+	case lr.DeviceIDMouseX:
+		return int16(mouse.dx)
+	case lr.DeviceIDMouseY:
+		return int16(mouse.dy)
+	case lr.DeviceIDMouseLeft:
+		return boolToState(mouseButton(glfw.MouseButtonLeft))
+	case lr.DeviceIDMouseRight:
+		return boolToState(mouseButton(glfw.MouseButtonRight))
+	case lr.DeviceIDMouseMiddle:
+		return boolToState(mouseButton(glfw.MouseButtonMiddle))
+	case lr.DeviceIDMouseButton4:
+		return boolToState(mouseButton(glfw.MouseButton4))
+	case lr.DeviceIDMouseButton5:
+		return boolToState(mouseButton(glfw.MouseButton5))
+	case lr.DeviceIDMouseWheelUp:
+		return boolToState(wheelUp)
+	case lr.DeviceIDMouseWheelDown:
+		return boolToState(wheelDown)
+	case lr.DeviceIDMouseHorizWheelUp:
+		return boolToState(wheelRight)
+	case lr.DeviceIDMouseHorizWheelDown:
+		return boolToState(wheelLeft)
+	}
+	return 0
+}
+
+// toScreen converts a cursor position to the libretro absolute coordinates,
+// which range from -0x7fff to 0x7fff over the area where the game is drawn.
+// offscreen is true if the cursor is outside of the game area.
+func toScreen(cx, cy float64, x, y, w, h float32) (sx, sy int16, offscreen bool) {
+	if w <= 0 || h <= 0 {
+		return -0x8000, -0x8000, true
+	}
+	rx := (cx-float64(x))/float64(w)*2 - 1
+	ry := (cy-float64(y))/float64(h)*2 - 1
+	if rx < -1 || rx > 1 || ry < -1 || ry > 1 {
+		return -0x8000, -0x8000, true
+	}
+	return int16(rx * 0x7fff), int16(ry * 0x7fff), false
+}
+
+// lightgunState returns the state of the host mouse as a libretro lightgun.
+// The right button shoots off screen to reload.
+func lightgunState(id uint) int16 {
+	sx, sy, offscreen := toScreen(mouse.cx, mouse.cy, mouse.rx, mouse.ry, mouse.rw, mouse.rh)
+	reload := mouseButton(glfw.MouseButtonRight)
+
+	switch uint32(id) {
+	case lr.DeviceIDLightgunScreenX:
+		return sx
+	case lr.DeviceIDLightgunScreenY:
+		return sy
+	case lr.DeviceIDLightgunIsOffscreen:
+		return boolToState(offscreen || reload)
+	case lr.DeviceIDLightgunTrigger:
+		return boolToState(mouseButton(glfw.MouseButtonLeft) || reload)
+	case lr.DeviceIDLightgunReload:
+		return boolToState(reload)
+	case lr.DeviceIDLightgunAuxA:
+		return boolToState(mouseButton(glfw.MouseButtonMiddle))
+	case lr.DeviceIDLightgunAuxB:
+		return boolToState(mouseButton(glfw.MouseButton4))
+	case lr.DeviceIDLightgunStart:
+		return NewState[0][lr.DeviceIDJoypadStart]
+	case lr.DeviceIDLightgunSelect:
+		return NewState[0][lr.DeviceIDJoypadSelect]
+	case lr.DeviceIDLightgunX:
+		return mouseState(uint(lr.DeviceIDMouseX))
+	case lr.DeviceIDLightgunY:
+		return mouseState(uint(lr.DeviceIDMouseY))
+	}
+	return 0
+}
+
+// pointerState returns the state of the host mouse as a libretro pointer.
+// Touches on the overlay buttons are not sent as pointer presses.
+func pointerState(index uint, id uint) int16 {
+	if index > 0 {
+		return 0
+	}
+	sx, sy, offscreen := toScreen(mouse.cx, mouse.cy, mouse.rx, mouse.ry, mouse.rw, mouse.rh)
+	pressed := mouseButton(glfw.MouseButtonLeft) && !offscreen && !mouse.overlay
+
+	switch uint32(id) {
+	case lr.DeviceIDPointerX:
+		return sx
+	case lr.DeviceIDPointerY:
+		return sy
+	case lr.DeviceIDPointerPressed:
+		return boolToState(pressed)
+	case lr.DeviceIDPointerCount:
+		return boolToState(pressed)
+	}
+	return 0
+}
@@ -0,0 +1,82 @@
+package input
+
+import (
+	lr "github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/movie"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/remap"
+)
+
+var (
+	// played is the frame of the movie being replayed, it replaces the
+	// input of the players
+	played *movie.Frame
+	// replaying tells if the last frame came from a movie, to notice the end
+	// of the playback
+	replaying bool
+)
+
+// sticks are the analog axes recorded in movies, in the order of movie.Pad
+var sticks = [4][2]uint32{
+	{lr.DeviceIndexAnalogLeft, lr.DeviceIDAnalogX},
+	{lr.DeviceIndexAnalogLeft, lr.DeviceIDAnalogY},
+	{lr.DeviceIndexAnalogRight, lr.DeviceIDAnalogX},
+	{lr.DeviceIndexAnalogRight, lr.DeviceIDAnalogY},
+}
+
+// coreFrame captures the input of all the players as the core sees it, with
+// the remaps and the turbo buttons applied
+func coreFrame() movie.Frame {
+	var f movie.Frame
+	for p := 0; p < movie.Ports && p < MaxPlayers; p++ {
+		for id := range remap.Buttons {
+			if State(uint(p), lr.DeviceJoypad, 0, uint(id)) != 0 {
+				f[p].Buttons |= 1 << uint(id)
+			}
+		}
+		for i, s := range sticks {
+			f[p].Analog[i] = State(uint(p), lr.DeviceAnalog, uint(s[0]), uint(s[1]))
+		}
+	}
+	return f
+}
+
+// playedState returns the input of the movie being replayed
+func playedState(port uint, device uint32, index uint, id uint) int16 {
+	if port >= movie.Ports {
+		return 0
+	}
+	pad := played[port]
+	switch device {
+	case lr.DeviceJoypad:
+		if index == 0 && id < 16 {
+			return int16(pad.Buttons >> id & 1)
+		}
+	case lr.DeviceAnalog:
+		for i, s := range sticks {
+			if uint(s[0]) == index && uint(s[1]) == id {
+				return pad.Analog[i]
+			}
+		}
+	}
+	return 0
+}
+
+// StepMovie records or replays the input of the movie, if any. It is called
+// before each frame run by the core.
+func StepMovie() {
+	played = nil
+	if movie.Recording() {
+		movie.Record(coreFrame())
+		return
+	}
+	if f, ok := movie.Next(); ok {
+		played = &f
+		replaying = true
+		return
+	}
+	if replaying {
+		replaying = false
+		ntf.DisplayAndLog(ntf.Info, "Input", "Movie finished.")
+	}
+}
@@ -0,0 +1,96 @@
+package input
+
+import (
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// noJoystick is used for ports that are not driven by any joystick
+const noJoystick glfw.Joystick = -1
+
+// Ports maps each player port to the joystick driving it
+var Ports = freePorts()
+
+func freePorts() [MaxPlayers]glfw.Joystick {
+	var ports [MaxPlayers]glfw.Joystick
+	for i := range ports {
+		ports[i] = noJoystick
+	}
+	return ports
+}
+
+// portOf returns the port driven by a joystick, or -1 if the joystick is not
+// bound to any port
+func portOf(joy glfw.Joystick) int {
+	for p, j := range Ports {
+		if j == joy {
+			return p
+		}
+	}
+	return -1
+}
+
+// bindJoystick binds a newly connected joystick to the first free port. It
+// returns the port, or -1 if all the ports are taken.
+func bindJoystick(joy glfw.Joystick) int {
+	if p := portOf(joy); p >= 0 {
+		return p
+	}
+	for p, j := range Ports {
+		if j == noJoystick {
+			Ports[p] = joy
+			return p
+		}
+	}
+	return -1
+}
+
+// unbindJoystick frees the port of a disconnected joystick
+func unbindJoystick(joy glfw.Joystick) {
+	if p := portOf(joy); p >= 0 {
+		Ports[p] = noJoystick
+	}
+}
+
+// SetPort binds a joystick to a port. If the joystick was driving another
+// port, the two ports are swapped.
+func SetPort(port int, joy glfw.Joystick) {
+	if joy != noJoystick {
+		if p := portOf(joy); p >= 0 {
+			Ports[p] = Ports[port]
+		}
+	}
+	Ports[port] = joy
+}
+
+// connectedGamepads lists the joysticks that can be bound to a port
+func connectedGamepads() []glfw.Joystick {
+	var joys []glfw.Joystick
+	for joy := glfw.Joystick1; joy <= glfw.JoystickLast; joy++ {
+		if joy.IsGamepad() {
+			joys = append(joys, joy)
+		}
+	}
+	return joys
+}
+
+// CyclePort binds the next or previous connected gamepad to a port
+func CyclePort(port int, direction int) {
+	choices := append([]glfw.Joystick{noJoystick}, connectedGamepads()...)
+	i := 0
+	for k, joy := range choices {
+		if joy == Ports[port] {
+			i = k
+		}
+	}
+	i = (i + direction + len(choices)) % len(choices)
+	SetPort(port, choices[i])
+}
+
+// PortName returns the name of the joystick driving a port
+func PortName(port int) string {
+	joy := Ports[port]
+	if joy == noJoystick || !joy.IsGamepad() {
+		return "None"
+	}
+	return joy.GetGamepadName()
+}
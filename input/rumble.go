@@ -0,0 +1,87 @@
+package input
+
+import (
+	"time"
+
+	lr "github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/remap"
+	"github.com/libretro/ludo/settings"
+)
+
+// rumbles holds the strong and weak rumble strengths requested by the core
+// for each port
+var rumbles [MaxPlayers][2]uint16
+
+// rumbledAt is when the rumble effect of each port was last played
+var rumbledAt [MaxPlayers]time.Time
+
+// rumbleRefresh is how often a lasting rumble effect is played again. The
+// duration of an effect is capped at 0xffff ms, a core may keep the same
+// strength for longer.
+const rumbleRefresh = 60 * time.Second
+
+// rumbleDue tells if the rumble effect of a port has to be played again
+func rumbleDue(strengths [2]uint16, at, now time.Time) bool {
+	return (strengths[0] > 0 || strengths[1] > 0) && now.Sub(at) >= rumbleRefresh
+}
+
+// scaleRumble applies the rumble strength setting to a strength requested
+// by the core
+func scaleRumble(strength uint16, factor float32) uint16 {
+	if factor <= 0 {
+		return 0
+	}
+	if factor >= 1 {
+		return strength
+	}
+	return uint16(float32(strength) * factor)
+}
+
+// SetRumble is the callback of the libretro rumble interface. It forwards the
+// rumble state to the joystick driving the port, if it supports force
+// feedback.
+func SetRumble(port uint, effect uint32, strength uint16) bool {
+	if port >= MaxPlayers || effect > lr.RumbleWeak {
+		return false
+	}
+	if remap.Current.DisableRumble {
+		strength = 0
+	}
+	strength = scaleRumble(strength, settings.Current.RumbleStrength)
+	if rumbles[port][effect] == strength {
+		return true
+	}
+	rumbles[port][effect] = strength
+
+	return playRumble(port, time.Now())
+}
+
+// playRumble sends the rumble state of a port to its joystick
+func playRumble(port uint, now time.Time) bool {
+	joy := Ports[port]
+	if joy == noJoystick {
+		return false
+	}
+	rumbledAt[port] = now
+	return rumble(joy, rumbles[port][lr.RumbleStrong], rumbles[port][lr.RumbleWeak])
+}
+
+// refreshRumble plays the lasting rumble effects again before they end. It
+// is called for each frame.
+func refreshRumble() {
+	now := time.Now()
+	for port := range rumbles {
+		if rumbleDue(rumbles[port], rumbledAt[port], now) {
+			playRumble(uint(port), now)
+		}
+	}
+}
+
+// StopRumble stops the rumble of all the joysticks, to be called when the
+// game is paused or unloaded
+func StopRumble() {
+	for port := range rumbles {
+		SetRumble(uint(port), lr.RumbleStrong, 0)
+		SetRumble(uint(port), lr.RumbleWeak, 0)
+	}
+}
@@ -0,0 +1,110 @@
+package input
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/libretro/ludo/logs"
+)
+
+// Linux force feedback, see linux/input.h
+const (
+	evFF     = 0x15
+	ffRumble = 0x50
+)
+
+// ffEffect mirrors struct ff_effect with a rumble payload. The union is padded
+// to the size of its largest member, struct ff_periodic_effect.
+type ffEffect struct {
+	Type      uint16
+	ID        int16
+	Direction uint16
+	Trigger   [2]uint16
+	Replay    [2]uint16
+	_         [2]byte
+	Strong    uint16
+	Weak      uint16
+	_         [20 + unsafe.Sizeof(uintptr(0))]byte
+}
+
+// inputEvent mirrors struct input_event
+type inputEvent struct {
+	Time  syscall.Timeval
+	Type  uint16
+	Code  uint16
+	Value int32
+}
+
+// evdev is an event device opened for force feedback
+type evdev struct {
+	file   *os.File
+	effect ffEffect
+}
+
+var evdevs = map[string]*evdev{}
+
+// eviocsff is the EVIOCSFF ioctl request, it depends on the size of ff_effect
+func eviocsff() uintptr {
+	return 1<<30 | unsafe.Sizeof(ffEffect{})<<16 | 'E'<<8 | 0x80
+}
+
+// findEvdev finds the event device of a joystick by its name
+func findEvdev(name string) string {
+	paths, _ := filepath.Glob("/sys/class/input/event*/device/name")
+	for _, path := range paths {
+		b, err := ioutil.ReadFile(path)
+		if err != nil || strings.TrimSpace(string(b)) != name {
+			continue
+		}
+		return filepath.Join("/dev/input", filepath.Base(filepath.Dir(filepath.Dir(path))))
+	}
+	return ""
+}
+
+func openEvdev(name string) *evdev {
+	if dev, ok := evdevs[name]; ok {
+		return dev
+	}
+	evdevs[name] = nil
+
+	path := findEvdev(name)
+	if path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		logs.Warnf("Input", "Can't open %s for rumble: %v", path, err)
+		return nil
+	}
+	dev := &evdev{file: f, effect: ffEffect{Type: ffRumble, ID: -1}}
+	evdevs[name] = dev
+	return dev
+}
+
+// rumble uploads a rumble effect to the joystick and plays it
+func rumble(joy glfw.Joystick, strong, weak uint16) bool {
+	dev := openEvdev(joy.GetName())
+	if dev == nil {
+		return false
+	}
+
+	dev.effect.Strong = strong
+	dev.effect.Weak = weak
+	dev.effect.Replay[0] = 0xffff
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, dev.file.Fd(), eviocsff(), uintptr(unsafe.Pointer(&dev.effect)))
+	if errno != 0 {
+		return false
+	}
+
+	play := inputEvent{Type: evFF, Code: uint16(dev.effect.ID), Value: 0}
+	if strong > 0 || weak > 0 {
+		play.Value = 1
+	}
+	_, err := dev.file.Write((*[unsafe.Sizeof(inputEvent{})]byte)(unsafe.Pointer(&play))[:])
+	return err == nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package input
+
+import (
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// rumble is not supported on this platform
+func rumble(joy glfw.Joystick, strong, weak uint16) bool {
+	return false
+}
@@ -0,0 +1,183 @@
+// Package instance keeps Ludo to a single running instance. The first
+// instance listens on a local socket, the next ones forward the content they
+// were launched with to it and quit, instead of fighting over the audio
+// device. The forwarded content is opened on the main thread between two
+// frames.
+package instance
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/libretro/ludo/crash"
+	"github.com/libretro/ludo/logs"
+)
+
+// Request is what a forwarding instance asks the running one. A request
+// without content only brings the window of the running instance to the
+// front.
+type Request struct {
+	Core    string `json:"core,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+var (
+	requests = make(chan Request, 4)
+	listener net.Listener
+)
+
+// timeout is how long a forwarding instance waits for the running one
+const timeout = 2 * time.Second
+
+// socket is the path of the socket of the running instance. The runtime
+// directory is shared by the portable installs, the socket is named after
+// the configuration directory so that each install runs its own instance.
+func socket() string {
+	name := fmt.Sprintf("ludo-%08x.sock", crc32.ChecksumIEEE([]byte(xdg.ConfigHome)))
+	return filepath.Join(xdg.RuntimeDir, name)
+}
+
+// Forward sends the game to open, and the core to open it with, to the
+// running instance. Without a game, the window of the running instance is
+// brought to the front. It fails if no instance runs.
+func Forward(core, content string) error {
+	r := Request{Core: abs(core), Content: abs(content)}
+	return forward(socket(), &r)
+}
+
+// abs resolves a path given on the command line, the running instance has
+// its own working directory
+func abs(path string) string {
+	if path == "" {
+		return ""
+	}
+	if p, err := filepath.Abs(path); err == nil {
+		return p
+	}
+	return path
+}
+
+// forward sends a request to the instance listening on path. A nil request
+// only checks that the instance answers.
+func forward(path string, r *Request) error {
+	conn, err := net.DialTimeout("unix", path, timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+	var line []byte
+	if r != nil {
+		if line, err = json.Marshal(r); err != nil {
+			return err
+		}
+	}
+	if _, err := conn.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	ack, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if ack != "ok\n" {
+		return errors.New("unexpected answer of the running instance")
+	}
+	return nil
+}
+
+// Listen makes this instance the running one. A socket left by an instance
+// that crashed is replaced.
+func Listen() error {
+	return listen(socket())
+}
+
+func listen(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		// Nobody answers on the socket, it is stale
+		if forward(path, nil) == nil {
+			return errors.New("an instance is already running")
+		}
+		os.Remove(path)
+		if l, err = net.Listen("unix", path); err != nil {
+			return err
+		}
+	}
+	listener = l
+	go accept(l)
+	return nil
+}
+
+func accept(l net.Listener) {
+	defer crash.Recover()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go serve(conn)
+	}
+}
+
+func serve(conn net.Conn) {
+	defer crash.Recover()
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		logs.Warnf("Instance", "%v", err)
+		return
+	}
+	// An empty line only checks that this instance answers
+	if line = strings.TrimSuffix(line, "\n"); line != "" {
+		var r Request
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			logs.Warnf("Instance", "Invalid request: %v", err)
+			return
+		}
+		select {
+		case requests <- r:
+		default:
+			logs.Warnf("Instance", "Too many requests, %s ignored", r.Content)
+		}
+	}
+	conn.Write([]byte("ok\n"))
+}
+
+// Process handles the forwarded requests, it is called once per frame. The
+// games are opened with open, the core being empty when the request doesn't
+// name one, then the window is raised.
+func Process(open func(core, content string), raise func()) {
+	for {
+		select {
+		case r := <-requests:
+			if r.Content != "" {
+				open(r.Core, r.Content)
+			}
+			raise()
+		default:
+			return
+		}
+	}
+}
+
+// Close stops listening, the next instance becomes the running one
+func Close() {
+	if listener == nil {
+		return
+	}
+	listener.Close()
+	listener = nil
+}
@@ -0,0 +1,67 @@
+package instance
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestForward(t *testing.T) {
+	dir, err := ioutil.TempDir("", "instance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ludo.sock")
+
+	if err := forward(path, &Request{Content: "/roms/tetris.gb"}); err == nil {
+		t.Fatal("forwarded without a running instance")
+	}
+
+	if err := listen(path); err != nil {
+		t.Fatal(err)
+	}
+	defer Close()
+	if err := listen(path); err == nil {
+		t.Error("a second instance listened")
+	}
+
+	if err := forward(path, &Request{Core: "/cores/gambatte_libretro.so", Content: "/roms/tetris.gb"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := forward(path, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := forward(path, &Request{}); err != nil {
+		t.Fatal(err)
+	}
+	var opened []string
+	raised := 0
+	Process(func(core, p string) { opened = append(opened, core, p) }, func() { raised++ })
+	if len(opened) != 2 || opened[0] != "/cores/gambatte_libretro.so" || opened[1] != "/roms/tetris.gb" {
+		t.Errorf("got = %v, want the forwarded core and game", opened)
+	}
+	if raised != 2 {
+		t.Errorf("raised = %d, want 2", raised)
+	}
+}
+
+func TestListenStale(t *testing.T) {
+	dir, err := ioutil.TempDir("", "instance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ludo.sock")
+
+	// A crashed instance leaves its socket behind
+	ioutil.WriteFile(path, nil, 0600)
+	if err := listen(path); err != nil {
+		t.Fatal(err)
+	}
+	defer Close()
+	if err := forward(path, nil); err != nil {
+		t.Error(err)
+	}
+}
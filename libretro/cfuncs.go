@@ -6,6 +6,7 @@ package libretro
 #include <stdarg.h>
 #include <stdio.h>
 #include <pthread.h>
+#include <time.h>
 
 #ifdef __APPLE__
 #include <mach/semaphore.h>
@@ -237,6 +238,14 @@ void* bridge_retro_get_memory_data(void *f, unsigned id) {
 	return ((void* (*)(unsigned))f)(id);
 }
 
+void bridge_retro_cheat_reset(void *f) {
+	((void (*)(void))f)();
+}
+
+void bridge_retro_cheat_set(void *f, unsigned index, bool enabled, const char *code) {
+	((void (*)(unsigned, bool, const char*))f)(index, enabled, code);
+}
+
 void bridge_retro_set_eject_state(retro_set_eject_state_t f, bool state) {
 	f(state);
 }
@@ -257,6 +266,10 @@ unsigned bridge_retro_get_num_images(retro_get_num_images_t f) {
 	return ((unsigned (*)())f)();
 }
 
+void bridge_retro_keyboard_callback(retro_keyboard_event_t f, bool down, unsigned keycode, uint32_t character, uint16_t key_modifiers) {
+	f(down, keycode, character, key_modifiers);
+}
+
 bool coreEnvironment_cgo(unsigned cmd, void *data) {
 	bool coreEnvironment(unsigned, void*);
 	return coreEnvironment(cmd, data);
@@ -303,5 +316,80 @@ int64_t coreGetTimeUsec_cgo() {
 	return coreGetTimeUsec();
 }
 
+bool coreSetRumbleState_cgo(unsigned port, enum retro_rumble_effect effect, uint16_t strength) {
+	bool coreSetRumbleState(unsigned, enum retro_rumble_effect, uint16_t);
+	return coreSetRumbleState(port, effect, strength);
+}
+
+
+#define MAX_PERF_COUNTERS 64
+
+static struct retro_perf_counter *perf_counters[MAX_PERF_COUNTERS];
+static unsigned perf_count = 0;
+
+retro_perf_tick_t corePerfGetCounter_cgo() {
+	struct timespec ts;
+	clock_gettime(CLOCK_MONOTONIC, &ts);
+	return (retro_perf_tick_t)ts.tv_sec * 1000000000 + ts.tv_nsec;
+}
+
+uint64_t coreGetCPUFeatures_cgo() {
+	uint64_t cpu = 0;
+#if (defined(__x86_64__) || defined(__i386__)) && defined(__GNUC__)
+	__builtin_cpu_init();
+	if (__builtin_cpu_supports("mmx")) cpu |= RETRO_SIMD_MMX;
+	if (__builtin_cpu_supports("sse")) cpu |= RETRO_SIMD_SSE;
+	if (__builtin_cpu_supports("sse2")) cpu |= RETRO_SIMD_SSE2;
+	if (__builtin_cpu_supports("sse3")) cpu |= RETRO_SIMD_SSE3;
+	if (__builtin_cpu_supports("ssse3")) cpu |= RETRO_SIMD_SSSE3;
+	if (__builtin_cpu_supports("sse4.1")) cpu |= RETRO_SIMD_SSE4;
+	if (__builtin_cpu_supports("sse4.2")) cpu |= RETRO_SIMD_SSE42;
+	if (__builtin_cpu_supports("avx")) cpu |= RETRO_SIMD_AVX;
+	if (__builtin_cpu_supports("avx2")) cpu |= RETRO_SIMD_AVX2;
+	if (__builtin_cpu_supports("popcnt")) cpu |= RETRO_SIMD_POPCNT;
+	if (__builtin_cpu_supports("cmov")) cpu |= RETRO_SIMD_CMOV;
+#endif
+#if defined(__ARM_NEON) || defined(__ARM_NEON__)
+	cpu |= RETRO_SIMD_NEON;
+#endif
+	return cpu;
+}
+
+void corePerfRegister_cgo(struct retro_perf_counter *counter) {
+	if (counter->registered || perf_count >= MAX_PERF_COUNTERS)
+		return;
+	perf_counters[perf_count++] = counter;
+	counter->registered = true;
+}
+
+void corePerfStart_cgo(struct retro_perf_counter *counter) {
+	if (counter->registered)
+		counter->start = corePerfGetCounter_cgo();
+}
+
+void corePerfStop_cgo(struct retro_perf_counter *counter) {
+	if (!counter->registered)
+		return;
+	counter->total += corePerfGetCounter_cgo() - counter->start;
+	counter->call_cnt++;
+}
+
+void corePerfLog_cgo() {
+	void corePerfLog();
+	corePerfLog();
+}
+
+unsigned corePerfCount() {
+	return perf_count;
+}
+
+struct retro_perf_counter *corePerfAt(unsigned i) {
+	return perf_counters[i];
+}
+
+void corePerfClear() {
+	perf_count = 0;
+}
+
 */
 import "C"
@@ -40,11 +40,14 @@ void bridge_retro_audio_callback(retro_audio_callback_t f);
 void bridge_retro_audio_set_state(retro_audio_set_state_callback_t f, bool state);
 size_t bridge_retro_get_memory_size(void *f, unsigned id);
 void* bridge_retro_get_memory_data(void *f, unsigned id);
+void bridge_retro_cheat_reset(void *f);
+void bridge_retro_cheat_set(void *f, unsigned index, bool enabled, const char *code);
 void bridge_retro_set_eject_state(retro_set_eject_state_t f, bool state);
 bool bridge_retro_get_eject_state(retro_get_eject_state_t f);
 unsigned bridge_retro_get_image_index(retro_get_image_index_t f);
 void bridge_retro_set_image_index(retro_set_image_index_t f, unsigned index);
 unsigned bridge_retro_get_num_images(retro_get_num_images_t f);
+void bridge_retro_keyboard_callback(retro_keyboard_event_t f, bool down, unsigned keycode, uint32_t character, uint16_t key_modifiers);
 
 bool coreEnvironment_cgo(unsigned cmd, void *data);
 void coreVideoRefresh_cgo(void *data, unsigned width, unsigned height, size_t pitch);
@@ -54,11 +57,23 @@ size_t coreAudioSampleBatch_cgo(const int16_t *data, size_t frames);
 int16_t coreInputState_cgo(unsigned port, unsigned device, unsigned index, unsigned id);
 void coreLog_cgo(enum retro_log_level level, const char *msg);
 int64_t coreGetTimeUsec_cgo();
+retro_perf_tick_t corePerfGetCounter_cgo();
+uint64_t coreGetCPUFeatures_cgo();
+void corePerfRegister_cgo(struct retro_perf_counter *counter);
+void corePerfStart_cgo(struct retro_perf_counter *counter);
+void corePerfStop_cgo(struct retro_perf_counter *counter);
+void corePerfLog_cgo();
+unsigned corePerfCount();
+struct retro_perf_counter *corePerfAt(unsigned i);
+void corePerfClear();
+bool coreSetRumbleState_cgo(unsigned port, enum retro_rumble_effect effect, uint16_t strength);
 */
 import "C"
 import (
 	"errors"
+	"fmt"
 	"strings"
+	"time"
 	"unsafe"
 )
 
@@ -219,6 +234,11 @@ type FrameTimeCallback struct {
 	Reference int64
 }
 
+// KeyboardCallback stores the keyboard event callback of the core
+type KeyboardCallback struct {
+	Callback func(down bool, keycode uint32, character uint32, modifiers uint16)
+}
+
 // AudioCallback stores the audio callback itself and the SetState callback
 type AudioCallback struct {
 	Callback func()
@@ -280,6 +300,10 @@ const (
 	// Positive Y axis is down.
 	// Only use ANALOG type when polling for analog values of the axes.
 	DeviceAnalog = uint32(C.RETRO_DEVICE_ANALOG)
+
+	// DevicePointer is an abstraction around touch screens. X/Y coordinates are
+	// absolute, from -0x7fff to 0x7fff over the game area.
+	DevicePointer = uint32(C.RETRO_DEVICE_POINTER)
 )
 
 // Buttons for the RetroPad (JOYPAD).
@@ -334,6 +358,192 @@ const (
 	DeviceIDMouseButton5        = uint32(C.RETRO_DEVICE_ID_MOUSE_BUTTON_5)
 )
 
+// ID values for the lightgun device
+const (
+	DeviceIDLightgunScreenX     = uint32(C.RETRO_DEVICE_ID_LIGHTGUN_SCREEN_X)
+	DeviceIDLightgunScreenY     = uint32(C.RETRO_DEVICE_ID_LIGHTGUN_SCREEN_Y)
+	DeviceIDLightgunIsOffscreen = uint32(C.RETRO_DEVICE_ID_LIGHTGUN_IS_OFFSCREEN)
+	DeviceIDLightgunTrigger     = uint32(C.RETRO_DEVICE_ID_LIGHTGUN_TRIGGER)
+	DeviceIDLightgunReload      = uint32(C.RETRO_DEVICE_ID_LIGHTGUN_RELOAD)
+	DeviceIDLightgunAuxA        = uint32(C.RETRO_DEVICE_ID_LIGHTGUN_AUX_A)
+	DeviceIDLightgunAuxB        = uint32(C.RETRO_DEVICE_ID_LIGHTGUN_AUX_B)
+	DeviceIDLightgunStart       = uint32(C.RETRO_DEVICE_ID_LIGHTGUN_START)
+	DeviceIDLightgunSelect      = uint32(C.RETRO_DEVICE_ID_LIGHTGUN_SELECT)
+	DeviceIDLightgunX           = uint32(C.RETRO_DEVICE_ID_LIGHTGUN_X)
+	DeviceIDLightgunY           = uint32(C.RETRO_DEVICE_ID_LIGHTGUN_Y)
+)
+
+// Keysyms used for ID in input state callback when polling DeviceKeyboard
+const (
+	KeyUnknown      = uint32(C.RETROK_UNKNOWN)
+	KeyBackspace    = uint32(C.RETROK_BACKSPACE)
+	KeyTab          = uint32(C.RETROK_TAB)
+	KeyClear        = uint32(C.RETROK_CLEAR)
+	KeyReturn       = uint32(C.RETROK_RETURN)
+	KeyPause        = uint32(C.RETROK_PAUSE)
+	KeyEscape       = uint32(C.RETROK_ESCAPE)
+	KeySpace        = uint32(C.RETROK_SPACE)
+	KeyExclaim      = uint32(C.RETROK_EXCLAIM)
+	KeyQuoteDbl     = uint32(C.RETROK_QUOTEDBL)
+	KeyHash         = uint32(C.RETROK_HASH)
+	KeyDollar       = uint32(C.RETROK_DOLLAR)
+	KeyAmpersand    = uint32(C.RETROK_AMPERSAND)
+	KeyQuote        = uint32(C.RETROK_QUOTE)
+	KeyLeftParen    = uint32(C.RETROK_LEFTPAREN)
+	KeyRightParen   = uint32(C.RETROK_RIGHTPAREN)
+	KeyAsterisk     = uint32(C.RETROK_ASTERISK)
+	KeyPlus         = uint32(C.RETROK_PLUS)
+	KeyComma        = uint32(C.RETROK_COMMA)
+	KeyMinus        = uint32(C.RETROK_MINUS)
+	KeyPeriod       = uint32(C.RETROK_PERIOD)
+	KeySlash        = uint32(C.RETROK_SLASH)
+	Key0            = uint32(C.RETROK_0)
+	Key1            = uint32(C.RETROK_1)
+	Key2            = uint32(C.RETROK_2)
+	Key3            = uint32(C.RETROK_3)
+	Key4            = uint32(C.RETROK_4)
+	Key5            = uint32(C.RETROK_5)
+	Key6            = uint32(C.RETROK_6)
+	Key7            = uint32(C.RETROK_7)
+	Key8            = uint32(C.RETROK_8)
+	Key9            = uint32(C.RETROK_9)
+	KeyColon        = uint32(C.RETROK_COLON)
+	KeySemicolon    = uint32(C.RETROK_SEMICOLON)
+	KeyLess         = uint32(C.RETROK_LESS)
+	KeyEquals       = uint32(C.RETROK_EQUALS)
+	KeyGreater      = uint32(C.RETROK_GREATER)
+	KeyQuestion     = uint32(C.RETROK_QUESTION)
+	KeyAt           = uint32(C.RETROK_AT)
+	KeyLeftBracket  = uint32(C.RETROK_LEFTBRACKET)
+	KeyBackslash    = uint32(C.RETROK_BACKSLASH)
+	KeyRightBracket = uint32(C.RETROK_RIGHTBRACKET)
+	KeyCaret        = uint32(C.RETROK_CARET)
+	KeyUnderscore   = uint32(C.RETROK_UNDERSCORE)
+	KeyBackQuote    = uint32(C.RETROK_BACKQUOTE)
+	KeyA            = uint32(C.RETROK_a)
+	KeyB            = uint32(C.RETROK_b)
+	KeyC            = uint32(C.RETROK_c)
+	KeyD            = uint32(C.RETROK_d)
+	KeyE            = uint32(C.RETROK_e)
+	KeyF            = uint32(C.RETROK_f)
+	KeyG            = uint32(C.RETROK_g)
+	KeyH            = uint32(C.RETROK_h)
+	KeyI            = uint32(C.RETROK_i)
+	KeyJ            = uint32(C.RETROK_j)
+	KeyK            = uint32(C.RETROK_k)
+	KeyL            = uint32(C.RETROK_l)
+	KeyM            = uint32(C.RETROK_m)
+	KeyN            = uint32(C.RETROK_n)
+	KeyO            = uint32(C.RETROK_o)
+	KeyP            = uint32(C.RETROK_p)
+	KeyQ            = uint32(C.RETROK_q)
+	KeyR            = uint32(C.RETROK_r)
+	KeyS            = uint32(C.RETROK_s)
+	KeyT            = uint32(C.RETROK_t)
+	KeyU            = uint32(C.RETROK_u)
+	KeyV            = uint32(C.RETROK_v)
+	KeyW            = uint32(C.RETROK_w)
+	KeyX            = uint32(C.RETROK_x)
+	KeyY            = uint32(C.RETROK_y)
+	KeyZ            = uint32(C.RETROK_z)
+	KeyLeftBrace    = uint32(C.RETROK_LEFTBRACE)
+	KeyBar          = uint32(C.RETROK_BAR)
+	KeyRightBrace   = uint32(C.RETROK_RIGHTBRACE)
+	KeyTilde        = uint32(C.RETROK_TILDE)
+	KeyDelete       = uint32(C.RETROK_DELETE)
+	KeyKP0          = uint32(C.RETROK_KP0)
+	KeyKP1          = uint32(C.RETROK_KP1)
+	KeyKP2          = uint32(C.RETROK_KP2)
+	KeyKP3          = uint32(C.RETROK_KP3)
+	KeyKP4          = uint32(C.RETROK_KP4)
+	KeyKP5          = uint32(C.RETROK_KP5)
+	KeyKP6          = uint32(C.RETROK_KP6)
+	KeyKP7          = uint32(C.RETROK_KP7)
+	KeyKP8          = uint32(C.RETROK_KP8)
+	KeyKP9          = uint32(C.RETROK_KP9)
+	KeyKPPeriod     = uint32(C.RETROK_KP_PERIOD)
+	KeyKPDivide     = uint32(C.RETROK_KP_DIVIDE)
+	KeyKPMultiply   = uint32(C.RETROK_KP_MULTIPLY)
+	KeyKPMinus      = uint32(C.RETROK_KP_MINUS)
+	KeyKPPlus       = uint32(C.RETROK_KP_PLUS)
+	KeyKPEnter      = uint32(C.RETROK_KP_ENTER)
+	KeyKPEquals     = uint32(C.RETROK_KP_EQUALS)
+	KeyUp           = uint32(C.RETROK_UP)
+	KeyDown         = uint32(C.RETROK_DOWN)
+	KeyRight        = uint32(C.RETROK_RIGHT)
+	KeyLeft         = uint32(C.RETROK_LEFT)
+	KeyInsert       = uint32(C.RETROK_INSERT)
+	KeyHome         = uint32(C.RETROK_HOME)
+	KeyEnd          = uint32(C.RETROK_END)
+	KeyPageUp       = uint32(C.RETROK_PAGEUP)
+	KeyPageDown     = uint32(C.RETROK_PAGEDOWN)
+	KeyF1           = uint32(C.RETROK_F1)
+	KeyF2           = uint32(C.RETROK_F2)
+	KeyF3           = uint32(C.RETROK_F3)
+	KeyF4           = uint32(C.RETROK_F4)
+	KeyF5           = uint32(C.RETROK_F5)
+	KeyF6           = uint32(C.RETROK_F6)
+	KeyF7           = uint32(C.RETROK_F7)
+	KeyF8           = uint32(C.RETROK_F8)
+	KeyF9           = uint32(C.RETROK_F9)
+	KeyF10          = uint32(C.RETROK_F10)
+	KeyF11          = uint32(C.RETROK_F11)
+	KeyF12          = uint32(C.RETROK_F12)
+	KeyF13          = uint32(C.RETROK_F13)
+	KeyF14          = uint32(C.RETROK_F14)
+	KeyF15          = uint32(C.RETROK_F15)
+	KeyNumLock      = uint32(C.RETROK_NUMLOCK)
+	KeyCapsLock     = uint32(C.RETROK_CAPSLOCK)
+	KeyScrolLock    = uint32(C.RETROK_SCROLLOCK)
+	KeyRShift       = uint32(C.RETROK_RSHIFT)
+	KeyLShift       = uint32(C.RETROK_LSHIFT)
+	KeyRCtrl        = uint32(C.RETROK_RCTRL)
+	KeyLCtrl        = uint32(C.RETROK_LCTRL)
+	KeyRAlt         = uint32(C.RETROK_RALT)
+	KeyLAlt         = uint32(C.RETROK_LALT)
+	KeyRMeta        = uint32(C.RETROK_RMETA)
+	KeyLMeta        = uint32(C.RETROK_LMETA)
+	KeyLSuper       = uint32(C.RETROK_LSUPER)
+	KeyRSuper       = uint32(C.RETROK_RSUPER)
+	KeyMode         = uint32(C.RETROK_MODE)
+	KeyCompose      = uint32(C.RETROK_COMPOSE)
+	KeyHelp         = uint32(C.RETROK_HELP)
+	KeyPrint        = uint32(C.RETROK_PRINT)
+	KeySysReq       = uint32(C.RETROK_SYSREQ)
+	KeyBreak        = uint32(C.RETROK_BREAK)
+	KeyMenu         = uint32(C.RETROK_MENU)
+	KeyPower        = uint32(C.RETROK_POWER)
+	KeyEuro         = uint32(C.RETROK_EURO)
+	KeyUndo         = uint32(C.RETROK_UNDO)
+	KeyOem102       = uint32(C.RETROK_OEM_102)
+)
+
+// ID values for the pointer device
+const (
+	DeviceIDPointerX       = uint32(C.RETRO_DEVICE_ID_POINTER_X)
+	DeviceIDPointerY       = uint32(C.RETRO_DEVICE_ID_POINTER_Y)
+	DeviceIDPointerPressed = uint32(C.RETRO_DEVICE_ID_POINTER_PRESSED)
+	DeviceIDPointerCount   = uint32(C.RETRO_DEVICE_ID_POINTER_COUNT)
+)
+
+// Rumble effects, controlled independently
+const (
+	RumbleStrong = uint32(C.RETRO_RUMBLE_STRONG)
+	RumbleWeak   = uint32(C.RETRO_RUMBLE_WEAK)
+)
+
+// Key modifiers sent with keyboard events
+const (
+	KeyModNone      = uint16(C.RETROKMOD_NONE)
+	KeyModShift     = uint16(C.RETROKMOD_SHIFT)
+	KeyModCtrl      = uint16(C.RETROKMOD_CTRL)
+	KeyModAlt       = uint16(C.RETROKMOD_ALT)
+	KeyModMeta      = uint16(C.RETROKMOD_META)
+	KeyModNumLock   = uint16(C.RETROKMOD_NUMLOCK)
+	KeyModCapsLock  = uint16(C.RETROKMOD_CAPSLOCK)
+	KeyModScrolLock = uint16(C.RETROKMOD_SCROLLOCK)
+)
+
 // Environment callback API. See libretro.h for details
 const (
 	EnvironmentSetRotation                      = uint32(C.RETRO_ENVIRONMENT_SET_ROTATION)
@@ -394,6 +604,78 @@ const (
 	EnvironmentGetDiskControlExtInterface       = uint32(C.RETRO_ENVIRONMENT_SET_DISK_CONTROL_EXT_INTERFACE)
 )
 
+// environmentNames are the names of the environment commands of libretro.h
+var environmentNames = map[uint32]string{
+	uint32(C.RETRO_ENVIRONMENT_SET_ROTATION):                                "SET_ROTATION",
+	uint32(C.RETRO_ENVIRONMENT_GET_OVERSCAN):                                "GET_OVERSCAN",
+	uint32(C.RETRO_ENVIRONMENT_GET_CAN_DUPE):                                "GET_CAN_DUPE",
+	uint32(C.RETRO_ENVIRONMENT_SET_MESSAGE):                                 "SET_MESSAGE",
+	uint32(C.RETRO_ENVIRONMENT_SHUTDOWN):                                    "SHUTDOWN",
+	uint32(C.RETRO_ENVIRONMENT_SET_PERFORMANCE_LEVEL):                       "SET_PERFORMANCE_LEVEL",
+	uint32(C.RETRO_ENVIRONMENT_GET_SYSTEM_DIRECTORY):                        "GET_SYSTEM_DIRECTORY",
+	uint32(C.RETRO_ENVIRONMENT_SET_PIXEL_FORMAT):                            "SET_PIXEL_FORMAT",
+	uint32(C.RETRO_ENVIRONMENT_SET_INPUT_DESCRIPTORS):                       "SET_INPUT_DESCRIPTORS",
+	uint32(C.RETRO_ENVIRONMENT_SET_KEYBOARD_CALLBACK):                       "SET_KEYBOARD_CALLBACK",
+	uint32(C.RETRO_ENVIRONMENT_SET_DISK_CONTROL_INTERFACE):                  "SET_DISK_CONTROL_INTERFACE",
+	uint32(C.RETRO_ENVIRONMENT_SET_HW_RENDER):                               "SET_HW_RENDER",
+	uint32(C.RETRO_ENVIRONMENT_GET_VARIABLE):                                "GET_VARIABLE",
+	uint32(C.RETRO_ENVIRONMENT_SET_VARIABLES):                               "SET_VARIABLES",
+	uint32(C.RETRO_ENVIRONMENT_GET_VARIABLE_UPDATE):                         "GET_VARIABLE_UPDATE",
+	uint32(C.RETRO_ENVIRONMENT_SET_SUPPORT_NO_GAME):                         "SET_SUPPORT_NO_GAME",
+	uint32(C.RETRO_ENVIRONMENT_GET_LIBRETRO_PATH):                           "GET_LIBRETRO_PATH",
+	uint32(C.RETRO_ENVIRONMENT_SET_FRAME_TIME_CALLBACK):                     "SET_FRAME_TIME_CALLBACK",
+	uint32(C.RETRO_ENVIRONMENT_SET_AUDIO_CALLBACK):                          "SET_AUDIO_CALLBACK",
+	uint32(C.RETRO_ENVIRONMENT_GET_RUMBLE_INTERFACE):                        "GET_RUMBLE_INTERFACE",
+	uint32(C.RETRO_ENVIRONMENT_GET_INPUT_DEVICE_CAPABILITIES):               "GET_INPUT_DEVICE_CAPABILITIES",
+	uint32(C.RETRO_ENVIRONMENT_GET_SENSOR_INTERFACE):                        "GET_SENSOR_INTERFACE",
+	uint32(C.RETRO_ENVIRONMENT_GET_CAMERA_INTERFACE):                        "GET_CAMERA_INTERFACE",
+	uint32(C.RETRO_ENVIRONMENT_GET_LOG_INTERFACE):                           "GET_LOG_INTERFACE",
+	uint32(C.RETRO_ENVIRONMENT_GET_PERF_INTERFACE):                          "GET_PERF_INTERFACE",
+	uint32(C.RETRO_ENVIRONMENT_GET_LOCATION_INTERFACE):                      "GET_LOCATION_INTERFACE",
+	uint32(C.RETRO_ENVIRONMENT_GET_CORE_ASSETS_DIRECTORY):                   "GET_CORE_ASSETS_DIRECTORY",
+	uint32(C.RETRO_ENVIRONMENT_GET_SAVE_DIRECTORY):                          "GET_SAVE_DIRECTORY",
+	uint32(C.RETRO_ENVIRONMENT_SET_SYSTEM_AV_INFO):                          "SET_SYSTEM_AV_INFO",
+	uint32(C.RETRO_ENVIRONMENT_SET_PROC_ADDRESS_CALLBACK):                   "SET_PROC_ADDRESS_CALLBACK",
+	uint32(C.RETRO_ENVIRONMENT_SET_SUBSYSTEM_INFO):                          "SET_SUBSYSTEM_INFO",
+	uint32(C.RETRO_ENVIRONMENT_SET_CONTROLLER_INFO):                         "SET_CONTROLLER_INFO",
+	uint32(C.RETRO_ENVIRONMENT_SET_MEMORY_MAPS):                             "SET_MEMORY_MAPS",
+	uint32(C.RETRO_ENVIRONMENT_SET_GEOMETRY):                                "SET_GEOMETRY",
+	uint32(C.RETRO_ENVIRONMENT_GET_USERNAME):                                "GET_USERNAME",
+	uint32(C.RETRO_ENVIRONMENT_GET_LANGUAGE):                                "GET_LANGUAGE",
+	uint32(C.RETRO_ENVIRONMENT_GET_CURRENT_SOFTWARE_FRAMEBUFFER):            "GET_CURRENT_SOFTWARE_FRAMEBUFFER",
+	uint32(C.RETRO_ENVIRONMENT_GET_HW_RENDER_INTERFACE):                     "GET_HW_RENDER_INTERFACE",
+	uint32(C.RETRO_ENVIRONMENT_SET_SUPPORT_ACHIEVEMENTS):                    "SET_SUPPORT_ACHIEVEMENTS",
+	uint32(C.RETRO_ENVIRONMENT_SET_HW_RENDER_CONTEXT_NEGOTIATION_INTERFACE): "SET_HW_RENDER_CONTEXT_NEGOTIATION_INTERFACE",
+	uint32(C.RETRO_ENVIRONMENT_SET_SERIALIZATION_QUIRKS):                    "SET_SERIALIZATION_QUIRKS",
+	uint32(C.RETRO_ENVIRONMENT_SET_HW_SHARED_CONTEXT):                       "SET_HW_SHARED_CONTEXT",
+	uint32(C.RETRO_ENVIRONMENT_GET_VFS_INTERFACE):                           "GET_VFS_INTERFACE",
+	uint32(C.RETRO_ENVIRONMENT_GET_LED_INTERFACE):                           "GET_LED_INTERFACE",
+	uint32(C.RETRO_ENVIRONMENT_GET_AUDIO_VIDEO_ENABLE):                      "GET_AUDIO_VIDEO_ENABLE",
+	uint32(C.RETRO_ENVIRONMENT_GET_MIDI_INTERFACE):                          "GET_MIDI_INTERFACE",
+	uint32(C.RETRO_ENVIRONMENT_GET_FASTFORWARDING):                          "GET_FASTFORWARDING",
+	uint32(C.RETRO_ENVIRONMENT_GET_TARGET_REFRESH_RATE):                     "GET_TARGET_REFRESH_RATE",
+	uint32(C.RETRO_ENVIRONMENT_GET_INPUT_BITMASKS):                          "GET_INPUT_BITMASKS",
+	uint32(C.RETRO_ENVIRONMENT_GET_CORE_OPTIONS_VERSION):                    "GET_CORE_OPTIONS_VERSION",
+	uint32(C.RETRO_ENVIRONMENT_SET_CORE_OPTIONS):                            "SET_CORE_OPTIONS",
+	uint32(C.RETRO_ENVIRONMENT_SET_CORE_OPTIONS_INTL):                       "SET_CORE_OPTIONS_INTL",
+	uint32(C.RETRO_ENVIRONMENT_SET_CORE_OPTIONS_DISPLAY):                    "SET_CORE_OPTIONS_DISPLAY",
+	uint32(C.RETRO_ENVIRONMENT_GET_PREFERRED_HW_RENDER):                     "GET_PREFERRED_HW_RENDER",
+	uint32(C.RETRO_ENVIRONMENT_GET_DISK_CONTROL_INTERFACE_VERSION):          "GET_DISK_CONTROL_INTERFACE_VERSION",
+	uint32(C.RETRO_ENVIRONMENT_SET_DISK_CONTROL_EXT_INTERFACE):              "SET_DISK_CONTROL_EXT_INTERFACE",
+}
+
+// EnvironmentName returns the name of an environment command, like
+// SET_ROTATION, or its number for the commands unknown to libretro.h
+func EnvironmentName(cmd uint32) string {
+	if name, ok := environmentNames[cmd]; ok {
+		return name
+	}
+	if cmd&uint32(C.RETRO_ENVIRONMENT_EXPERIMENTAL) != 0 {
+		return fmt.Sprintf("%d (experimental)", cmd&^uint32(C.RETRO_ENVIRONMENT_EXPERIMENTAL))
+	}
+	return fmt.Sprintf("%d", cmd)
+}
+
 // Debug levels
 const (
 	LogLevelDebug = uint32(C.RETRO_LOG_DEBUG)
@@ -421,6 +703,8 @@ type (
 	inputStateFunc       func(uint, uint32, uint, uint) int16
 	logFunc              func(uint32, string)
 	getTimeUsecFunc      func() int64
+	perfLogFunc          func([]PerfCounter)
+	setRumbleStateFunc   func(uint, uint32, uint16) bool
 )
 
 var (
@@ -432,6 +716,8 @@ var (
 	inputState       inputStateFunc
 	log              logFunc
 	getTimeUsec      getTimeUsecFunc
+	perfLog          perfLogFunc
+	setRumbleState   setRumbleStateFunc
 )
 
 // Load dynamically loads a libretro core at the given path and returns a Core instance
@@ -467,6 +753,8 @@ func Load(sofile string) (*Core, error) {
 	core.symRetroUnserialize = DlSym(core.handle, "retro_unserialize")
 	core.symRetroGetMemorySize = DlSym(core.handle, "retro_get_memory_size")
 	core.symRetroGetMemoryData = DlSym(core.handle, "retro_get_memory_data")
+	core.symRetroCheatReset = DlSym(core.handle, "retro_cheat_reset")
+	core.symRetroCheatSet = DlSym(core.handle, "retro_cheat_set")
 
 	return &core, nil
 }
@@ -495,6 +783,8 @@ func (core *Core) Deinit() {
 	inputState = nil
 	log = nil
 	getTimeUsec = nil
+	perfLog = nil
+	C.corePerfClear()
 }
 
 // Run runs the game for one video frame.
@@ -650,11 +940,49 @@ func (core *Core) BindLogCallback(data unsafe.Pointer, f logFunc) {
 	cb.log = (C.retro_log_printf_t)(C.coreLog_cgo)
 }
 
-// BindPerfCallback binds f to the perf callback get_time_usec
-func (core *Core) BindPerfCallback(data unsafe.Pointer, f getTimeUsecFunc) {
+// BindPerfCallback binds f to the perf callback get_time_usec and l to
+// perf_log. The counters registered by the core are kept by the frontend
+// until Deinit.
+func (core *Core) BindPerfCallback(data unsafe.Pointer, f getTimeUsecFunc, l perfLogFunc) {
 	getTimeUsec = f
+	perfLog = l
 	cb := (*C.struct_retro_perf_callback)(data)
 	cb.get_time_usec = (C.retro_perf_get_time_usec_t)(C.coreGetTimeUsec_cgo)
+	cb.get_cpu_features = (C.retro_get_cpu_features_t)(C.coreGetCPUFeatures_cgo)
+	cb.get_perf_counter = (C.retro_perf_get_counter_t)(C.corePerfGetCounter_cgo)
+	cb.perf_register = (C.retro_perf_register_t)(C.corePerfRegister_cgo)
+	cb.perf_start = (C.retro_perf_start_t)(C.corePerfStart_cgo)
+	cb.perf_stop = (C.retro_perf_stop_t)(C.corePerfStop_cgo)
+	cb.perf_log = (C.retro_perf_log_t)(C.corePerfLog_cgo)
+}
+
+// PerfCounter is a performance counter registered by the core. Total is the
+// time spent between the perf_start and perf_stop calls.
+type PerfCounter struct {
+	Ident string
+	Total time.Duration
+	Calls uint64
+}
+
+// PerfCounters returns the performance counters registered by the core
+func PerfCounters() []PerfCounter {
+	var list []PerfCounter
+	for i := C.unsigned(0); i < C.corePerfCount(); i++ {
+		c := C.corePerfAt(i)
+		list = append(list, PerfCounter{
+			Ident: C.GoString(c.ident),
+			Total: time.Duration(c.total),
+			Calls: uint64(c.call_cnt),
+		})
+	}
+	return list
+}
+
+// BindRumbleCallback binds f to the rumble interface set_rumble_state
+func (core *Core) BindRumbleCallback(data unsafe.Pointer, f setRumbleStateFunc) {
+	setRumbleState = f
+	cb := (*C.struct_retro_rumble_interface)(data)
+	cb.set_rumble_state = (C.retro_set_rumble_state_t)(C.coreSetRumbleState_cgo)
 }
 
 // SetControllerPortDevice sets the device type attached to a controller port
@@ -726,6 +1054,22 @@ func coreGetTimeUsec() C.uint64_t {
 	return C.uint64_t(getTimeUsec())
 }
 
+//export corePerfLog
+func corePerfLog() {
+	if perfLog == nil {
+		return
+	}
+	perfLog(PerfCounters())
+}
+
+//export coreSetRumbleState
+func coreSetRumbleState(port C.unsigned, effect C.enum_retro_rumble_effect, strength C.uint16_t) C.bool {
+	if setRumbleState == nil {
+		return false
+	}
+	return C.bool(setRumbleState(uint(port), uint32(effect), uint16(strength)))
+}
+
 // SetData is a setter for the data of a GameInfo type
 func (gi *GameInfo) SetData(bytes []byte) {
 	cstr := C.CString(string(bytes))
@@ -888,6 +1232,16 @@ func (core *Core) SetFrameTimeCallback(data unsafe.Pointer) {
 	core.FrameTimeCallback = ftc
 }
 
+// SetKeyboardCallback is an environment callback helper to set the KeyboardCallback
+func (core *Core) SetKeyboardCallback(data unsafe.Pointer) {
+	c := *(*C.struct_retro_keyboard_callback)(data)
+	kbc := &KeyboardCallback{}
+	kbc.Callback = func(down bool, keycode uint32, character uint32, modifiers uint16) {
+		C.bridge_retro_keyboard_callback(c.callback, C.bool(down), C.unsigned(keycode), C.uint32_t(character), C.uint16_t(modifiers))
+	}
+	core.KeyboardCallback = kbc
+}
+
 // SetAudioCallback is an environment callback helper to set the AudioCallback
 func (core *Core) SetAudioCallback(data unsafe.Pointer) {
 	c := *(*C.struct_retro_audio_callback)(data)
@@ -913,6 +1267,19 @@ func (core *Core) GetMemoryData(id uint32) unsafe.Pointer {
 	return C.bridge_retro_get_memory_data(core.symRetroGetMemoryData, C.unsigned(id))
 }
 
+// CheatReset disables all the cheats
+func (core *Core) CheatReset() {
+	C.bridge_retro_cheat_reset(core.symRetroCheatReset)
+}
+
+// CheatSet enables or disables the cheat at index, code being in the format
+// expected by the core
+func (core *Core) CheatSet(index uint, enabled bool, code string) {
+	ccode := C.CString(code)
+	defer C.free(unsafe.Pointer(ccode))
+	C.bridge_retro_cheat_set(core.symRetroCheatSet, C.unsigned(index), C.bool(enabled), ccode)
+}
+
 // DiskControlCallback is an interface which frontend can use to eject and insert disk images
 type DiskControlCallback struct {
 	SetEjectState func(bool)
@@ -27,10 +27,13 @@ type Core struct {
 	symRetroUnserialize             unsafe.Pointer
 	symRetroGetMemorySize           unsafe.Pointer
 	symRetroGetMemoryData           unsafe.Pointer
+	symRetroCheatReset              unsafe.Pointer
+	symRetroCheatSet                unsafe.Pointer
 
 	AudioCallback       *AudioCallback
 	FrameTimeCallback   *FrameTimeCallback
 	DiskControlCallback *DiskControlCallback
+	KeyboardCallback    *KeyboardCallback
 
 	MemoryMap []MemoryDescriptor
 }
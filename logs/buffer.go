@@ -0,0 +1,71 @@
+package logs
+
+import (
+	"sort"
+	"time"
+)
+
+// MaxRecent is the number of entries kept in memory for the log viewer
+const MaxRecent = 500
+
+// Entry is a message that was logged
+type Entry struct {
+	Level   Level
+	Tag     string
+	Message string
+	Time    time.Time
+}
+
+var (
+	recent []Entry
+	// count is the number of entries logged since the start
+	count int
+)
+
+// keep adds an entry to the recent entries. It must be called with mu held.
+func keep(l Level, tag, msg string) {
+	recent = append(recent, Entry{l, tag, msg, time.Now()})
+	if len(recent) > MaxRecent {
+		recent = recent[len(recent)-MaxRecent:]
+	}
+	count++
+}
+
+// Count is the number of entries logged since the start, it changes when
+// something new is logged
+func Count() int {
+	mu.Lock()
+	defer mu.Unlock()
+	return count
+}
+
+// Recent returns the recent entries of at least a level, most recent first.
+// If tag isn't empty, only the entries of this module are returned.
+func Recent(min Level, tag string) []Entry {
+	mu.Lock()
+	defer mu.Unlock()
+	var list []Entry
+	for i := len(recent) - 1; i >= 0; i-- {
+		e := recent[i]
+		if e.Level >= min && (tag == "" || e.Tag == tag) {
+			list = append(list, e)
+		}
+	}
+	return list
+}
+
+// Tags returns the sorted modules of the recent entries
+func Tags() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	seen := map[string]bool{}
+	var tags []string
+	for _, e := range recent {
+		if !seen[e.Tag] {
+			seen[e.Tag] = true
+			tags = append(tags, e.Tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
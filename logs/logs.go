@@ -0,0 +1,172 @@
+// Package logs is the leveled logger of Ludo. Messages are tagged with the
+// module they come from, like [Core] or [Video], and the ones below the
+// minimum level are dropped. Logs go to the standard error, and to a file
+// rotated when it grows too big if enabled.
+package logs
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/adrg/xdg"
+)
+
+// Level is the importance of a message
+type Level int
+
+const (
+	// Debug is for the details only useful when tracking a problem
+	Debug Level = iota
+	// Info is for the normal operation of Ludo
+	Info
+	// Warn is for what isn't right but doesn't prevent Ludo from working
+	Warn
+	// Error is for failed operations
+	Error
+)
+
+// Levels are the names of the levels, as set in the settings and shown in the
+// log viewer
+var Levels = []string{"Debug", "Info", "Warn", "Error"}
+
+// labels prefix the messages of each level
+var labels = []string{"DEBUG", "INFO ", "WARN ", "ERROR"}
+
+var (
+	mu   sync.Mutex
+	min  = Info
+	file *rotator
+)
+
+// ParseLevel finds a level by name, Info being the default
+func ParseLevel(name string) Level {
+	for i, l := range Levels {
+		if strings.EqualFold(l, name) {
+			return Level(i)
+		}
+	}
+	return Info
+}
+
+// SetLevel sets the minimum level of the messages logged
+func SetLevel(l Level) {
+	mu.Lock()
+	defer mu.Unlock()
+	min = l
+}
+
+// Enabled tells if the messages of a level are logged
+func Enabled(l Level) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return l >= min
+}
+
+// format builds a log line, like "WARN  [Video]: Failed to create the texture"
+func format(l Level, tag, msg string) string {
+	return labels[l] + " [" + tag + "]: " + msg
+}
+
+// Logf logs a message of a level, tagged with its module
+func Logf(l Level, tag, f string, args ...interface{}) {
+	if !Enabled(l) {
+		return
+	}
+	msg := strings.TrimRight(fmt.Sprintf(f, args...), "\n")
+	mu.Lock()
+	keep(l, tag, msg)
+	mu.Unlock()
+	log.Println(format(l, tag, msg))
+}
+
+// Debugf logs a debug message
+func Debugf(tag, f string, args ...interface{}) {
+	Logf(Debug, tag, f, args...)
+}
+
+// Infof logs an informative message
+func Infof(tag, f string, args ...interface{}) {
+	Logf(Info, tag, f, args...)
+}
+
+// Warnf logs a warning
+func Warnf(tag, f string, args ...interface{}) {
+	Logf(Warn, tag, f, args...)
+}
+
+// Errorf logs an error
+func Errorf(tag, f string, args ...interface{}) {
+	Logf(Error, tag, f, args...)
+}
+
+// Fatalf logs an error and exits
+func Fatalf(tag, f string, args ...interface{}) {
+	Errorf(tag, f, args...)
+	Close()
+	os.Exit(1)
+}
+
+// OpenFile copies the logs to a file. The file is rotated once it exceeds
+// maxSize bytes, keeping the keep previous files as path.1, path.2 and so
+// on.
+func OpenFile(path string, maxSize int64, keep int) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	r := &rotator{path: path, maxSize: maxSize, keep: keep}
+	if err := r.open(); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if file != nil {
+		file.fd.Close()
+	}
+	file = r
+	log.SetOutput(io.MultiWriter(os.Stderr, r))
+	return nil
+}
+
+// Close stops copying the logs to a file
+func Close() {
+	mu.Lock()
+	defer mu.Unlock()
+	if file == nil {
+		return
+	}
+	log.SetOutput(os.Stderr)
+	file.fd.Close()
+	file = nil
+}
+
+// Path is the location of the log file
+func Path() string {
+	return filepath.Join(xdg.StateHome, "ludo", "ludo.log")
+}
+
+// Setup applies the log settings. Verbose logs everything, whatever the
+// level.
+func Setup(level string, toFile, verbose bool) error {
+	l := ParseLevel(level)
+	if verbose {
+		l = Debug
+	}
+	SetLevel(l)
+	if !toFile {
+		Close()
+		return nil
+	}
+	mu.Lock()
+	open := file != nil
+	mu.Unlock()
+	if open {
+		return nil
+	}
+	return OpenFile(Path(), 1<<20, 3)
+}
@@ -0,0 +1,105 @@
+package logs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/libretro/ludo/utils"
+)
+
+func Test_levels(t *testing.T) {
+	defer SetLevel(Info)
+
+	tests := []struct {
+		name  string
+		level Level
+		want  string
+	}{
+		{"Debug", Debug, "DEBUG [Test]: one\nINFO  [Test]: two\nWARN  [Test]: three\nERROR [Test]: four\n"},
+		{"Info", Info, "INFO  [Test]: two\nWARN  [Test]: three\nERROR [Test]: four\n"},
+		{"Error", Error, "ERROR [Test]: four\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetLevel(ParseLevel(tt.name))
+			got := utils.CaptureOutput(func() {
+				Debugf("Test", "one")
+				Infof("Test", "%s", "two")
+				Warnf("Test", "three\n")
+				Errorf("Test", "four")
+			})
+			if got != tt.want {
+				t.Errorf("got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_rotator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ludo-logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "ludo.log")
+	r := rotator{path: path, maxSize: 10, keep: 2}
+	if err := r.open(); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"aaaaaa\n", "bbbbbb\n", "cccccc\n", "dddddd\n"} {
+		if _, err := r.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r.fd.Close()
+
+	for name, want := range map[string]string{
+		"ludo.log":   "dddddd\n",
+		"ludo.log.1": "cccccc\n",
+		"ludo.log.2": "bbbbbb\n",
+	} {
+		b, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("%s = %q, want %q", name, b, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("only 2 rotated files should be kept")
+	}
+}
+
+func Test_Recent(t *testing.T) {
+	defer SetLevel(Info)
+	SetLevel(Debug)
+	recent = nil
+
+	utils.CaptureOutput(func() {
+		Debugf("Core", "one")
+		Warnf("Video", "two")
+		Errorf("Core", "three")
+	})
+
+	messages := func(entries []Entry) []string {
+		var l []string
+		for _, e := range entries {
+			l = append(l, e.Message)
+		}
+		return l
+	}
+	if got, want := messages(Recent(Debug, "")), []string{"three", "two", "one"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Recent() = %v, want %v", got, want)
+	}
+	if got, want := messages(Recent(Warn, "Core")), []string{"three"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Recent() = %v, want %v", got, want)
+	}
+	if got, want := Tags(), []string{"Core", "Video"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Tags() = %v, want %v", got, want)
+	}
+}
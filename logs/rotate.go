@@ -0,0 +1,61 @@
+package logs
+
+import (
+	"fmt"
+	"os"
+)
+
+// rotator is a log file that is renamed to path.1 when it exceeds maxSize,
+// the previous ones being shifted up to path.<keep>
+type rotator struct {
+	path    string
+	maxSize int64
+	keep    int
+	fd      *os.File
+	size    int64
+}
+
+// open opens the log file for appending, rotating it first if it is already
+// too big
+func (r *rotator) open() error {
+	if fi, err := os.Stat(r.path); err == nil && fi.Size() >= r.maxSize {
+		r.shift()
+	}
+	fd, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	fi, err := fd.Stat()
+	if err != nil {
+		fd.Close()
+		return err
+	}
+	r.fd, r.size = fd, fi.Size()
+	return nil
+}
+
+// shift renames the log files, dropping the oldest one
+func (r *rotator) shift() {
+	os.Remove(fmt.Sprintf("%s.%d", r.path, r.keep))
+	for i := r.keep - 1; i > 0; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	if r.keep > 0 {
+		os.Rename(r.path, r.path+".1")
+	} else {
+		os.Remove(r.path)
+	}
+}
+
+func (r *rotator) Write(b []byte) (int, error) {
+	if r.size+int64(len(b)) > r.maxSize && r.size > 0 {
+		r.fd.Close()
+		r.shift()
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.fd.Write(b)
+	r.size += int64(n)
+	return n, err
+}
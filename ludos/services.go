@@ -9,35 +9,23 @@ import (
 
 	"github.com/fatih/structs"
 	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/utils"
 )
 
-// exists naively checks if a file exists. We're not handling cases like, the
-// file is a directory.
-func exists(path string) bool {
-	_, err := os.Stat(path)
-	if err == nil {
-		return true
-	}
-	if os.IsNotExist(err) {
-		return false
-	}
-	return true
-}
-
 // SystemdServiceToggle can enable and start, or disable and stop a systemd
 // service in LudOS.
 func SystemdServiceToggle(path string, serviceName string, enable bool) error {
 	action := "stop"
 	if enable {
 		action = "start"
-		if !exists(path) {
+		if !utils.Exists(path) {
 			var file, err = os.Create(path)
 			if err != nil {
 				return err
 			}
 			file.Close()
 		}
-	} else if exists(path) {
+	} else if utils.Exists(path) {
 		err := os.Remove(path)
 		if err != nil {
 			return err
@@ -74,11 +62,11 @@ func InitializeServiceSettingsValues(fields []*structs.Field) {
 	for _, f := range fields {
 		switch f.Name() {
 		case "SSHService":
-			f.Set(exists(f.Tag("path")))
+			f.Set(utils.Exists(f.Tag("path")))
 		case "SambaService":
-			f.Set(exists(f.Tag("path")))
+			f.Set(utils.Exists(f.Tag("path")))
 		case "BluetoothService":
-			f.Set(exists(f.Tag("path")))
+			f.Set(utils.Exists(f.Tag("path")))
 		}
 	}
 }
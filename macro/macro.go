@@ -0,0 +1,151 @@
+// Package macro records the RetroPad input of the first player frame by frame
+// and replays it. Each game has its own macro, identified by the CRC32 of the
+// content.
+package macro
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/adrg/xdg"
+	"github.com/libretro/ludo/utils"
+	"github.com/pelletier/go-toml"
+)
+
+// MaxFrames limits the length of a macro, about one minute at 60 fps
+const MaxFrames = 3600
+
+// Macro is a sequence of RetroPad button masks, one per frame
+type Macro struct {
+	Frames []uint16 `toml:"frames"`
+}
+
+// Current is the macro of the running game
+var Current Macro
+
+var (
+	recording bool
+	playhead  = -1
+)
+
+// Recording tells if a macro is being recorded
+func Recording() bool {
+	return recording
+}
+
+// Playing tells if a macro is being replayed
+func Playing() bool {
+	return playhead >= 0
+}
+
+// StartRecording clears the current macro and starts recording a new one
+func StartRecording() {
+	playhead = -1
+	recording = true
+	Current = Macro{}
+}
+
+// StopRecording stops recording
+func StopRecording() {
+	recording = false
+}
+
+// Record appends the input of a frame to the macro being recorded. It tells if
+// the macro just reached MaxFrames, the recording is then stopped and the
+// macro has to be saved.
+func Record(mask uint16) bool {
+	if !recording {
+		return false
+	}
+	Current.Frames = append(Current.Frames, mask)
+	if len(Current.Frames) >= MaxFrames {
+		recording = false
+		return true
+	}
+	return false
+}
+
+// Play starts replaying the current macro from the beginning
+func Play() {
+	if recording || len(Current.Frames) == 0 {
+		return
+	}
+	playhead = 0
+}
+
+// Stop interrupts the playback
+func Stop() {
+	playhead = -1
+}
+
+// Next returns the input of the next frame of the macro being replayed. ok
+// is false when no macro is playing.
+func Next() (mask uint16, ok bool) {
+	if playhead < 0 {
+		return 0, false
+	}
+	mask = Current.Frames[playhead]
+	playhead++
+	if playhead >= len(Current.Frames) {
+		playhead = -1
+	}
+	return mask, true
+}
+
+// path returns the location of the macro file of a game
+func path(corePath string, crc uint32) string {
+	name := utils.FileName(corePath)
+	return filepath.Join(xdg.ConfigHome, "ludo", "macros", name, fmt.Sprintf("%08X.toml", crc))
+}
+
+// Load loads the macro of a game, or clears the current macro if the game has
+// none
+func Load(core string, crc uint32) {
+	Current = Macro{}
+	recording = false
+	playhead = -1
+
+	b, err := ioutil.ReadFile(path(core, crc))
+	if err != nil {
+		return
+	}
+	var m Macro
+	if err := toml.Unmarshal(b, &m); err != nil {
+		return
+	}
+	if len(m.Frames) > MaxFrames {
+		m.Frames = m.Frames[:MaxFrames]
+	}
+	Current = m
+}
+
+// Save saves the current macro for a game
+func Save(core string, crc uint32) error {
+	b, err := toml.Marshal(Current)
+	if err != nil {
+		return err
+	}
+
+	p := path(core, crc)
+	err = os.MkdirAll(filepath.Dir(p), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	fd, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	_, err = io.Copy(fd, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	return fd.Sync()
+}
@@ -0,0 +1,62 @@
+package macro
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRecordAndPlay(t *testing.T) {
+	StartRecording()
+	Record(1)
+	Record(0)
+	Record(3)
+	StopRecording()
+	Record(7)
+
+	t.Run("Records frames until stopped", func(t *testing.T) {
+		want := []uint16{1, 0, 3}
+		if !reflect.DeepEqual(Current.Frames, want) {
+			t.Errorf("got = %v, want %v", Current.Frames, want)
+		}
+	})
+
+	t.Run("Replays the frames in order", func(t *testing.T) {
+		Play()
+		var got []uint16
+		for {
+			mask, ok := Next()
+			if !ok {
+				break
+			}
+			got = append(got, mask)
+		}
+		want := []uint16{1, 0, 3}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got = %v, want %v", got, want)
+		}
+		if Playing() {
+			t.Errorf("got = %v, want %v", Playing(), false)
+		}
+	})
+}
+
+func TestRecordLimit(t *testing.T) {
+	StartRecording()
+	for i := 0; i < MaxFrames-1; i++ {
+		if Record(1) {
+			t.Fatalf("stopped early at frame %d", i)
+		}
+	}
+	if !Record(1) {
+		t.Errorf("got = %v, want %v", false, true)
+	}
+	if Recording() {
+		t.Errorf("got = %v, want %v", Recording(), false)
+	}
+	if len(Current.Frames) != MaxFrames {
+		t.Errorf("got = %v, want %v", len(Current.Frames), MaxFrames)
+	}
+	if Record(1) {
+		t.Errorf("got = %v, want %v", true, false)
+	}
+}
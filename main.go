@@ -1,25 +1,46 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"io"
+	"io/ioutil"
 	"os"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/libretro/ludo/achievements"
 	"github.com/libretro/ludo/audio"
+	"github.com/libretro/ludo/bench"
+	"github.com/libretro/ludo/buildbot"
 	"github.com/libretro/ludo/core"
+	"github.com/libretro/ludo/coreinfo"
+	"github.com/libretro/ludo/corethread"
+	"github.com/libretro/ludo/crash"
+	"github.com/libretro/ludo/dat"
 	"github.com/libretro/ludo/history"
+	"github.com/libretro/ludo/i18n"
 	"github.com/libretro/ludo/input"
+	"github.com/libretro/ludo/instance"
+	"github.com/libretro/ludo/logs"
+	"github.com/libretro/ludo/mainthread"
 	"github.com/libretro/ludo/menu"
 	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/overlay"
+	"github.com/libretro/ludo/parental"
 	"github.com/libretro/ludo/playlists"
+	"github.com/libretro/ludo/record"
+	"github.com/libretro/ludo/remote"
 	"github.com/libretro/ludo/savefiles"
 	"github.com/libretro/ludo/scanner"
 	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/shaders"
 	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/stats"
+	"github.com/libretro/ludo/themes"
 	"github.com/libretro/ludo/video"
 )
 
@@ -30,55 +51,290 @@ func init() {
 
 var frame = 0
 
+// sessionFlags collects the --set key=value flags
+type sessionFlags map[string]string
+
+func (s sessionFlags) String() string {
+	return fmt.Sprint(map[string]string(s))
+}
+
+func (s sessionFlags) Set(kv string) error {
+	i := strings.Index(kv, "=")
+	if i < 0 {
+		return fmt.Errorf("expected key=value, got %q", kv)
+	}
+	s[kv[:i]] = kv[i+1:]
+	return nil
+}
+
+// inBackground tells if the game is stopped because the window lost the
+// focus
+func inBackground(vid *video.Video) bool {
+	return settings.Current.PauseOnFocusLoss && state.CoreRunning &&
+		vid.Window.GetAttrib(glfw.Focused) == glfw.False
+}
+
+// runFrame runs a frame of the core and the features following the game
+// frame by frame
+func runFrame(dt float32) {
+	state.FrameAdvance = false
+	input.StepMovie()
+	start := time.Now()
+	state.Core.Run()
+	menu.RecordRun(time.Since(start))
+	achievements.Frame()
+	parental.Tick(dt)
+	stats.Tick(dt)
+	if state.Core.FrameTimeCallback != nil {
+		state.Core.FrameTimeCallback.Callback(state.Core.FrameTimeCallback.Reference)
+	}
+	if state.Core.AudioCallback != nil {
+		state.Core.AudioCallback.Callback()
+	}
+}
+
+// background is set while the window is in the background, the core thread
+// must not run frames then
+var background bool
+
+// coreActive tells if the game is running frames
+func coreActive() bool {
+	return state.CoreRunning && !state.MenuActive && !background && (!state.Paused || state.FrameAdvance)
+}
+
+// syncCoreThread starts or stops the core thread to follow the settings and
+// the running game. It tells if the core runs on the core thread.
+func syncCoreThread(vid *video.Video) bool {
+	if settings.Current.CoreThread && state.CoreRunning {
+		corethread.Start(runFrame, func() float64 {
+			if state.FastForward {
+				return 0
+			}
+			return vid.Timing.FPS
+		}, coreActive)
+	} else {
+		corethread.Stop()
+	}
+	return corethread.Running()
+}
+
+// shown is the frame of the core thread being displayed
+var shown corethread.Frame
+
+// nextFrame pops the next frame of the core thread and makes the video changes
+// that came with it. The lock must be held, the core thread paces itself with
+// the timing of the video.
+func nextFrame() (corethread.Frame, bool) {
+	f, ok := corethread.Next()
+	if ok {
+		f.Apply()
+	}
+	return f, ok
+}
+
+// showFrame hands a frame of the core thread to the renderer
+func showFrame(vid *video.Video, f corethread.Frame) {
+	corethread.Release(shown)
+	shown = f
+	vid.Refresh(f.Pointer(), f.Width, f.Height, f.Pitch)
+	if record.Video() {
+		record.Repeat(f.Dupes)
+		if img, err := vid.Frame(); err == nil {
+			record.Frame(img)
+		}
+	}
+}
+
 func runLoop(vid *video.Video, m *menu.Menu) {
 	var currTime time.Time
 	prevTime := time.Now()
 	for !vid.Window.ShouldClose() {
 		currTime = time.Now()
 		dt := float32(currTime.Sub(prevTime)) / 1000000000
+		threaded := syncCoreThread(vid)
 		glfw.PollEvents()
+		// Past this point the core thread waits until the main thread is
+		// done with the core
+		if threaded {
+			corethread.Lock()
+		}
+		remote.Process()
+		mainthread.Process()
+		instance.Process(m.OpenContentWith, vid.Raise)
+		scanner.Throttle(state.CoreRunning && !state.MenuActive)
+		background = inBackground(vid)
+		if background {
+			if threaded {
+				corethread.Unlock()
+			}
+			// Sleep until an event, like the window being focused again
+			audio.Pause()
+			glfw.WaitEventsTimeout(0.25)
+			prevTime = time.Now()
+			continue
+		}
 		m.ProcessHotkeys()
 		ntf.Process(dt)
 		vid.ResizeViewport()
 		m.UpdatePalette()
 		input.Poll()
+		// On the core thread, the game follows its own framerate
+		skew := 1.0
+		if settings.Current.AudioSyncRefreshRate && !threaded {
+			skew = vid.RateSkew()
+		}
+		audio.SetRateSkew(skew)
 		if !state.MenuActive {
-			if state.CoreRunning {
-				state.Core.Run()
-				if state.Core.FrameTimeCallback != nil {
-					state.Core.FrameTimeCallback.Callback(state.Core.FrameTimeCallback.Reference)
+			if threaded {
+				f, ok := nextFrame()
+				corethread.Unlock()
+				if ok {
+					showFrame(vid, f)
 				}
-				if state.Core.AudioCallback != nil {
-					state.Core.AudioCallback.Callback()
+			} else if state.CoreRunning && (!state.Paused || state.FrameAdvance) {
+				runFrame(dt)
+				if record.Video() {
+					if img, err := vid.Frame(); err == nil {
+						record.Frame(img)
+					}
 				}
 			}
 			vid.Render()
+			if state.CoreRunning {
+				overlay.Render(vid)
+				m.RenderTrackers()
+				m.RenderPerf(dt)
+			}
 			frame++
-			if frame%600 == 0 { // save sram about every 10 sec
+			if frame%600 == 0 { // save sram and stats about every 10 sec
+				if threaded {
+					corethread.Lock()
+				}
 				savefiles.SaveSRAM()
+				if threaded {
+					corethread.Unlock()
+				}
+				if err := stats.Save(); err != nil {
+					logs.Warnf("Stats", "Can't save the statistics: %v", err)
+				}
 			}
 		} else {
 			m.Update(dt)
+			if threaded {
+				corethread.Unlock()
+			}
 			vid.Render()
 			m.Render(dt)
 		}
+		audio.UpdateMusic(dt)
 		m.RenderNotifications()
-		if state.FastForward {
-			glfw.SwapInterval(0)
-		} else {
-			glfw.SwapInterval(1)
+		vid.MatchRefreshRate()
+		vid.UpdateSwapInterval()
+		vid.Present()
+		vid.SwapBuffers()
+		vid.InsertBlackFrames()
+		vid.SyncGPU()
+		vid.EstimateRefreshRate()
+		// The core thread paces itself
+		if !threaded {
+			vid.Pace()
+			if state.CoreRunning && !state.MenuActive && !state.FastForward && !state.Paused {
+				vid.FrameDelay()
+			}
+		} else if vid.Threaded() {
+			vid.Pace()
 		}
-		vid.Window.SwapBuffers()
 		prevTime = currTime
 	}
 }
 
-func main() {
-	err := settings.Load()
+// runBench runs a core as fast as possible in a hidden window and prints the
+// statistics of its frame times
+func runBench(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	frames := fs.Int("frames", 5000, "Number of frames to run")
+	moviePath := fs.String("movie", "", "Movie to replay during the run")
+
+	// The flags can come after the core and the content
+	var paths []string
+	for {
+		fs.Parse(args)
+		if fs.NArg() == 0 {
+			break
+		}
+		paths = append(paths, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if len(paths) != 2 {
+		return errors.New("expected a core and a content to run")
+	}
+
+	if err := glfw.Init(); err != nil {
+		return err
+	}
+	defer glfw.Terminate()
+	glfw.WindowHint(glfw.Visible, glfw.False)
+
+	vid := video.Init(false)
+	audio.Init()
+	input.Init(vid)
+
+	r, err := bench.Run(vid, paths[0], paths[1], *moviePath, *frames)
 	if err != nil {
-		log.Println("[Settings]: Loading failed:", err)
-		log.Println("[Settings]: Using default settings")
+		return err
 	}
+	fmt.Print(r.Stats)
+	for _, c := range r.Counters {
+		fmt.Printf("%s: %d calls, %v\n", c.Ident, c.Calls, c.Total)
+	}
+	return nil
+}
+
+// runDatCheck validates a dat and prints the problems found
+func runDatCheck(args []string) error {
+	if len(args) != 1 {
+		return errors.New("expected a dat to check")
+	}
+	b, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	r := dat.Check(b)
+	fmt.Print(r)
+	if len(r.Problems) > 0 {
+		return fmt.Errorf("%d problems found", len(r.Problems))
+	}
+	return nil
+}
+
+// runDatExport writes the game database as CSV or JSON, to a file or to the
+// standard output
+func runDatExport(args []string) error {
+	if len(args) < 1 || len(args) > 2 || args[0] != "csv" && args[0] != "json" {
+		return errors.New("expected csv or json, and optionally a file")
+	}
+	db, err := scanner.LoadLayeredDB(settings.Current.DatabaseDirectory, settings.Current.UserDatsDirectory)
+	if err != nil {
+		return err
+	}
+
+	w := io.Writer(os.Stdout)
+	if len(args) == 2 {
+		f, err := os.Create(args[1])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if args[0] == "json" {
+		return db.WriteJSON(w)
+	}
+	return db.WriteCSV(w)
+}
+
+func main() {
+	defer crash.Recover()
 
 	// ExitOnError causes flags to quit after displaying help.
 	// (--help counts as an error)
@@ -87,6 +343,9 @@ func main() {
 	// customize help message
 	flag.CommandLine.Usage = func() {
 		fmt.Printf("Usage: %s [OPTIONS] [content]\n", os.Args[0])
+		fmt.Printf("       %s [OPTIONS] bench <core> <content> [-frames N] [-movie file]\n", os.Args[0])
+		fmt.Printf("       %s [OPTIONS] dat-check <dat>\n", os.Args[0])
+		fmt.Printf("       %s [OPTIONS] dat-export <csv|json> [file]\n", os.Args[0])
 		fmt.Printf("Options:\n")
 		flag.PrintDefaults()
 	}
@@ -94,28 +353,117 @@ func main() {
 	// set arguments
 	flag.StringVar(&state.CorePath, "L", "", "Path to the libretro core")
 	flag.BoolVar(&state.Verbose, "v", false, "Verbose logs")
+	flag.BoolVar(&state.Verbose, "verbose", false, "Verbose logs")
 	flag.BoolVar(&state.LudOS, "ludos", false, "Expose the features related to LudOS")
+	set := sessionFlags(settings.EnvSession(os.Environ()))
+	flag.Var(set, "set", "Set a setting for this session only, like video_fullscreen=true")
+	portable := flag.Bool("portable", false, "Keep the configuration and the data next to the executable")
+	fullscreen := flag.Bool("fullscreen", false, "Start in fullscreen, for this session only")
 	flag.Parse()
 	args := flag.Args()
+	if *fullscreen {
+		set["video_fullscreen"] = "true"
+	}
+
+	// Portable mode has to be set before loading the settings, it moves the
+	// settings file
+	if state.Verbose {
+		logs.SetLevel(logs.Debug)
+	}
+	if *portable || settings.HasPortableMarker() {
+		settings.SetPortable(settings.ExecutableDir())
+	}
+	if err := settings.SetSession(set); err != nil {
+		logs.Fatalf("Settings", "Invalid session setting: %v", err)
+	}
+
+	err := settings.Load()
+	if err != nil {
+		logs.Warnf("Settings", "Loading failed: %v", err)
+		logs.Infof("Settings", "Using default settings")
+	}
+
+	// The report of a core crash includes the end of the previous log file
+	crash.Check()
+
+	if err := logs.Setup(settings.Current.LogLevel, settings.Current.LogToFile, state.Verbose); err != nil {
+		logs.Errorf("Logs", "Can't open the log file: %v", err)
+	}
+	defer logs.Close()
+
+	i18n.Load(settings.Current.LocalesDirectory)
+	i18n.Set(settings.Current.Language)
+
+	if settings.Current.NotificationsDuration > 0 {
+		ntf.Duration = settings.Current.NotificationsDuration
+	}
+
+	if len(args) > 0 && args[0] == "dat-check" {
+		if err := runDatCheck(args[1:]); err != nil {
+			logs.Errorf("DAT", "%v", err)
+			logs.Close()
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(args) > 0 && args[0] == "dat-export" {
+		if err := runDatExport(args[1:]); err != nil {
+			logs.Errorf("DAT", "%v", err)
+			logs.Close()
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(args) > 0 && args[0] == "bench" {
+		if err := runBench(args[1:]); err != nil {
+			logs.Errorf("Bench", "%v", err)
+			logs.Close()
+			os.Exit(1)
+		}
+		return
+	}
 
 	var gamePath string
 	if len(args) > 0 {
 		gamePath = args[0]
 	}
 
+	// Launching a game while Ludo runs opens it in the running instance
+	if settings.Current.SingleInstance {
+		if err := instance.Forward(state.CorePath, gamePath); err == nil {
+			logs.Infof("Instance", "Ludo is already running, the request was forwarded to it")
+			return
+		}
+		if err := instance.Listen(); err != nil {
+			logs.Warnf("Instance", "%v", err)
+		}
+		defer instance.Close()
+	}
+
 	if err := glfw.Init(); err != nil {
-		log.Fatalln("Failed to initialize glfw", err)
+		logs.Fatalf("Video", "Failed to initialize glfw: %v", err)
 	}
 	defer glfw.Terminate()
 
-	state.DB, err = scanner.LoadDB(settings.Current.DatabaseDirectory)
+	state.DB, err = scanner.LoadLayeredDB(settings.Current.DatabaseDirectory, settings.Current.UserDatsDirectory)
 	if err != nil {
-		log.Println("Can't load game database:", err)
+		logs.Warnf("Scanner", "Can't load game database: %v", err)
 	}
 
 	playlists.Load()
+	coreinfo.Load()
+	parental.LoadRatings()
 
 	history.Load()
+	if err := stats.Load(); err != nil {
+		logs.Warnf("Stats", "Can't load the statistics: %v", err)
+	}
+
+	overlay.Load()
+	shaders.Load()
+	themes.Load(settings.Current.ThemesDirectory)
 
 	vid := video.Init(settings.Current.VideoFullscreen)
 
@@ -141,12 +489,24 @@ func main() {
 		} else {
 			ntf.DisplayAndLog(ntf.Error, "Menu", err.Error())
 		}
+	} else if len(gamePath) > 0 {
+		m.OpenContent(gamePath)
 	}
 
 	// No game running? display the menu
 	state.MenuActive = !state.CoreRunning
 
+	remote.Start(m)
+
+	if !state.LudOS {
+		go buildbot.WatchUpdates()
+	}
+
 	runLoop(vid, m)
+	corethread.Stop()
+	vid.Close()
+
+	vid.SaveWindowGeometry()
 
 	// Unload and deinit in the core.
 	core.Unload()
@@ -0,0 +1,26 @@
+// Package mainthread runs the results of the background tasks on the main
+// thread. Downloads, scans and logins run in goroutines, they post a function
+// applying their result to the settings, the database or the menu, which are
+// only changed by the main thread.
+package mainthread
+
+// queue holds the posted functions until the next frame
+var queue = make(chan func(), 64)
+
+// Post queues f to be run by the main thread. It is meant to be called from
+// goroutines, it waits when the queue is full.
+func Post(f func()) {
+	queue <- f
+}
+
+// Process runs the posted functions, it is called once per frame
+func Process() {
+	for {
+		select {
+		case f := <-queue:
+			f()
+		default:
+			return
+		}
+	}
+}
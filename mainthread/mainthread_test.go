@@ -0,0 +1,26 @@
+package mainthread
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProcess(t *testing.T) {
+	var got []int
+	done := make(chan struct{})
+	go func() {
+		Post(func() { got = append(got, 1) })
+		Post(func() { got = append(got, 2) })
+		close(done)
+	}()
+	<-done
+	Process()
+
+	if want := []int{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	Process()
+	if len(got) != 2 {
+		t.Errorf("expected the queue to be empty, got %v", got)
+	}
+}
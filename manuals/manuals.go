@@ -0,0 +1,353 @@
+// Package manuals finds the manuals of the games and reads their pages.
+// Manuals are PDF or CBZ files stored in the manuals directory, or in a
+// folder per system, named after the game or after the CRC of its ROM. A
+// manual stored elsewhere can be associated to a game. The pages of the PDFs
+// are rendered by pdftoppm, from poppler, when it is installed.
+package manuals
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg" // decodes the pages of the CBZ files
+	_ "image/png"  // decodes the pages of the CBZ files and the PDFs
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/adrg/xdg"
+	"github.com/disintegration/imaging"
+	"github.com/libretro/ludo/thumbnails"
+	"github.com/libretro/ludo/utils"
+	"github.com/pelletier/go-toml"
+)
+
+// ErrNoRenderer is returned when opening a PDF without pdftoppm installed
+var ErrNoRenderer = errors.New("pdftoppm is not installed")
+
+// pdfResolution is the resolution of the rendered PDF pages, in DPI
+const pdfResolution = 110
+
+// Extensions are the kinds of manuals, in order of preference
+var Extensions = []string{".cbz", ".pdf"}
+
+// associationsPath is the file of the manuals associated to the games
+func associationsPath() string {
+	return filepath.Join(xdg.ConfigHome, "ludo", "manuals.toml")
+}
+
+// key identifies a game in the associations, by the CRC of its ROM or by its
+// name when the CRC isn't known
+func key(crc uint32, name string) string {
+	if crc != 0 {
+		return fmt.Sprintf("%08X", crc)
+	}
+	return name
+}
+
+// associations reads the manuals associated to the games
+func associations() map[string]string {
+	list := map[string]string{}
+	b, err := ioutil.ReadFile(associationsPath())
+	if err != nil {
+		return list
+	}
+	toml.Unmarshal(b, &list)
+	return list
+}
+
+// Associate sets the manual of a game, identified by the CRC of its ROM or by
+// its name. An empty path removes the association.
+func Associate(crc uint32, name, path string) error {
+	list := associations()
+	if path == "" {
+		delete(list, key(crc, name))
+	} else {
+		list[key(crc, name)] = path
+	}
+
+	b, err := toml.Marshal(list)
+	if err != nil {
+		return err
+	}
+	p := associationsPath()
+	if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(p, b, 0644)
+}
+
+// associated returns the manual associated to a game, or an empty string
+func associated(names []string, crc uint32) string {
+	list := associations()
+	keys := names
+	if crc != 0 {
+		keys = append([]string{key(crc, "")}, names...)
+	}
+	for _, k := range keys {
+		if path := list[k]; k != "" && path != "" && utils.Exists(path) {
+			return path
+		}
+	}
+	return ""
+}
+
+// Find returns the manual of a game, or an empty string. The manual
+// associated to the game comes first. Then the manuals named after the CRC of
+// the ROM, like 46df91ad.pdf, are tried, then the ones named after the game,
+// then the ones whose normalized name is the one of the game, to match
+// renamed files. Manuals are looked for in dir and in the folder of the
+// system.
+func Find(dir, system string, names []string, crc uint32) string {
+	if path := associated(names, crc); path != "" {
+		return path
+	}
+	if dir == "" {
+		return ""
+	}
+	dirs := []string{dir}
+	if system != "" {
+		dirs = []string{filepath.Join(dir, system), dir}
+	}
+
+	var bases []string
+	if crc != 0 {
+		bases = append(bases, fmt.Sprintf("%08x", crc))
+	}
+	bases = append(bases, names...)
+	for _, base := range bases {
+		for _, d := range dirs {
+			for _, ext := range Extensions {
+				if path := filepath.Join(d, base+ext); base != "" && utils.Exists(path) {
+					return path
+				}
+			}
+		}
+	}
+
+	for _, name := range names {
+		key := thumbnails.Normalize(name)
+		if key == "" {
+			continue
+		}
+		for _, d := range dirs {
+			files, _ := ioutil.ReadDir(d)
+			for _, f := range files {
+				ext := strings.ToLower(filepath.Ext(f.Name()))
+				if f.IsDir() || (ext != ".cbz" && ext != ".pdf") {
+					continue
+				}
+				if thumbnails.Normalize(strings.TrimSuffix(f.Name(), filepath.Ext(f.Name()))) == key {
+					return filepath.Join(d, f.Name())
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// IsPDF tells if a manual is a PDF, rendered by pdftoppm
+func IsPDF(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".pdf")
+}
+
+// isPage tells if a file of a CBZ is an image
+func isPage(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".png", ".jpg", ".jpeg":
+		return true
+	}
+	return false
+}
+
+// digits splits the names of the pages in numbers and text
+var digits = regexp.MustCompile(`[0-9]+|[^0-9]+`)
+
+// naturalLess sorts the names of the pages like a person would, page2
+// before page10
+func naturalLess(a, b string) bool {
+	pa := digits.FindAllString(strings.ToLower(a), -1)
+	pb := digits.FindAllString(strings.ToLower(b), -1)
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		if pa[i] == pb[i] {
+			continue
+		}
+		na, errA := strconv.Atoi(pa[i])
+		nb, errB := strconv.Atoi(pb[i])
+		if errA == nil && errB == nil && na != nb {
+			return na < nb
+		}
+		return pa[i] < pb[i]
+	}
+	return len(pa) < len(pb)
+}
+
+// Book is an opened manual, its pages are decoded on demand
+type Book struct {
+	len   int
+	page  func(i int) (image.Image, error)
+	close func() error
+}
+
+// Open opens a manual. The pages of a CBZ are the images of the archive, in
+// the natural order of their names. The pages of a PDF are rendered by
+// pdftoppm, ErrNoRenderer is returned when it isn't installed.
+func Open(path string) (*Book, error) {
+	if IsPDF(path) {
+		return openPDF(path)
+	}
+	return openCBZ(path)
+}
+
+func openCBZ(path string) (*Book, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	var pages []*zip.File
+	for _, f := range r.File {
+		if !f.FileInfo().IsDir() && isPage(f.Name) {
+			pages = append(pages, f)
+		}
+	}
+	if len(pages) == 0 {
+		r.Close()
+		return nil, errors.New("no pages in " + filepath.Base(path))
+	}
+	sort.Slice(pages, func(i, j int) bool {
+		return naturalLess(pages[i].Name, pages[j].Name)
+	})
+	return &Book{
+		len: len(pages),
+		page: func(i int) (image.Image, error) {
+			rc, err := pages[i].Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+			img, _, err := image.Decode(rc)
+			return img, err
+		},
+		close: r.Close,
+	}, nil
+}
+
+// pdfPages reads the page count in the output of pdfinfo
+func pdfPages(info string) int {
+	for _, line := range strings.Split(info, "\n") {
+		if !strings.HasPrefix(line, "Pages:") {
+			continue
+		}
+		n, _ := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "Pages:")))
+		return n
+	}
+	return 0
+}
+
+// cachedPage decodes a rendered page, render is only called when the page
+// hasn't been rendered yet. The renders are kept until the book is closed.
+func cachedPage(file string, render func() error) (image.Image, error) {
+	if !utils.Exists(file) {
+		if err := render(); err != nil {
+			os.Remove(file)
+			return nil, err
+		}
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+func openPDF(path string) (*Book, error) {
+	if _, err := exec.LookPath("pdftoppm"); err != nil {
+		return nil, ErrNoRenderer
+	}
+	info, err := exec.Command("pdfinfo", path).Output()
+	if err != nil {
+		return nil, err
+	}
+	n := pdfPages(string(info))
+	if n == 0 {
+		return nil, errors.New("no pages in " + filepath.Base(path))
+	}
+	dir, err := ioutil.TempDir("", "ludo-manual")
+	if err != nil {
+		return nil, err
+	}
+	return &Book{
+		len: n,
+		page: func(i int) (image.Image, error) {
+			page := strconv.Itoa(i + 1)
+			prefix := filepath.Join(dir, "page-"+page)
+			return cachedPage(prefix+".png", func() error {
+				return exec.Command("pdftoppm", "-f", page, "-l", page, "-r", strconv.Itoa(pdfResolution), "-png", "-singlefile", path, prefix).Run()
+			})
+		},
+		close: func() error {
+			return os.RemoveAll(dir)
+		},
+	}, nil
+}
+
+// Len is the number of pages of the manual
+func (b *Book) Len() int {
+	return b.len
+}
+
+// Page decodes a page of the manual
+func (b *Book) Page(i int) (image.Image, error) {
+	if i < 0 || i >= b.len {
+		return nil, fmt.Errorf("no page %d", i+1)
+	}
+	return b.page(i)
+}
+
+// Close closes the manual
+func (b *Book) Close() error {
+	return b.close()
+}
+
+// Fit downscales a page that is larger than max pixels, the largest texture
+// the GPU takes
+func Fit(img image.Image, max int) image.Image {
+	size := img.Bounds().Size()
+	if max <= 0 || (size.X <= max && size.Y <= max) {
+		return img
+	}
+	return imaging.Fit(img, max, max, imaging.Lanczos)
+}
+
+// viewer returns the command opening a file with the default application
+func viewer(goos, path string) (string, []string) {
+	switch goos {
+	case "darwin":
+		return "open", []string{path}
+	case "windows":
+		// cmd would interpret the metacharacters of the path, like &
+		return "rundll32", []string{"url.dll,FileProtocolHandler", path}
+	}
+	return "xdg-open", []string{path}
+}
+
+// OpenExternal opens a manual with the default application of the system,
+// for the PDFs when pdftoppm isn't installed
+func OpenExternal(path string) error {
+	name, args := viewer(runtime.GOOS, path)
+	cmd := exec.Command(name, args...)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,212 @@
+package manuals
+
+import (
+	"archive/zip"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/adrg/xdg"
+)
+
+func TestFind(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manuals")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	system := "Nintendo - Game Boy"
+	os.MkdirAll(filepath.Join(dir, system), os.ModePerm)
+	for _, name := range []string{
+		filepath.Join(system, "46df91ad.pdf"),
+		filepath.Join(system, "Tetris (World).cbz"),
+		"the_legend_of_zelda.pdf",
+		"Notes.txt",
+	} {
+		ioutil.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+
+	tests := []struct {
+		name  string
+		names []string
+		crc   uint32
+		want  string
+	}{
+		{"by CRC", []string{"Tetris (World)"}, 0x46df91ad, filepath.Join(dir, system, "46df91ad.pdf")},
+		{"by name", []string{"Tetris (World)"}, 0x12345678, filepath.Join(dir, system, "Tetris (World).cbz")},
+		{"by normalized name", []string{"Legend of Zelda, The (USA)"}, 0, filepath.Join(dir, "the_legend_of_zelda.pdf")},
+		{"not found", []string{"Notes"}, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Find(dir, system, tt.names, tt.crc); got != tt.want {
+				t.Errorf("Find() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := Find("", system, []string{"Tetris (World)"}, 0); got != "" {
+		t.Errorf("Find() without a directory = %v, want empty", got)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manuals")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "Tetris.cbz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for i, name := range []string{"page10.png", "page2.png", "ComicInfo.xml"} {
+		w, _ := zw.Create(name)
+		if name == "ComicInfo.xml" {
+			w.Write([]byte("<ComicInfo/>"))
+			continue
+		}
+		png.Encode(w, image.NewRGBA(image.Rect(0, 0, i+1, 1)))
+	}
+	zw.Close()
+	f.Close()
+
+	b, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	if b.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", b.Len())
+	}
+	var widths []int
+	for i := 0; i < b.Len(); i++ {
+		img, err := b.Page(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		widths = append(widths, img.Bounds().Dx())
+	}
+	// page2.png, the second file of the archive, is the first page
+	if want := []int{2, 1}; !reflect.DeepEqual(widths, want) {
+		t.Errorf("page widths = %v, want %v", widths, want)
+	}
+	if _, err := b.Page(2); err == nil {
+		t.Error("Page(2) should fail")
+	}
+}
+
+func Test_viewer(t *testing.T) {
+	name, args := viewer("linux", "a.pdf")
+	if name != "xdg-open" || !reflect.DeepEqual(args, []string{"a.pdf"}) {
+		t.Errorf("viewer() = %v %v", name, args)
+	}
+	name, args = viewer("windows", "a.pdf")
+	if name != "rundll32" || !reflect.DeepEqual(args, []string{"url.dll,FileProtocolHandler", "a.pdf"}) {
+		t.Errorf("viewer() = %v %v", name, args)
+	}
+}
+
+func Test_cachedPage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ludo-manual")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "page-1.png")
+	renders := 0
+	render := func() error {
+		renders++
+		f, err := os.Create(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return png.Encode(f, image.NewGray(image.Rect(0, 0, 2, 3)))
+	}
+	for i := 0; i < 3; i++ {
+		img, err := cachedPage(file, render)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if img.Bounds().Dy() != 3 {
+			t.Errorf("got %v", img.Bounds())
+		}
+	}
+	if renders != 1 {
+		t.Errorf("the page was rendered %d times", renders)
+	}
+}
+
+func TestAssociate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manuals")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(home string) { xdg.ConfigHome = home }(xdg.ConfigHome)
+	xdg.ConfigHome = dir
+
+	manual := filepath.Join(dir, "elsewhere", "tetris manual.pdf")
+	os.MkdirAll(filepath.Dir(manual), os.ModePerm)
+	ioutil.WriteFile(manual, nil, 0644)
+
+	if err := Associate(0x46df91ad, "Tetris (World)", manual); err != nil {
+		t.Fatal(err)
+	}
+	if err := Associate(0, "Alleyway", manual); err != nil {
+		t.Fatal(err)
+	}
+	if got := Find("", "", []string{"Tetris"}, 0x46df91ad); got != manual {
+		t.Errorf("Find() by CRC = %v, want the associated manual", got)
+	}
+	if got := Find("", "", []string{"Alleyway"}, 0); got != manual {
+		t.Errorf("Find() by name = %v, want the associated manual", got)
+	}
+
+	if err := Associate(0x46df91ad, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := Find("", "", []string{"Tetris"}, 0x46df91ad); got != "" {
+		t.Errorf("Find() = %v, want no manual once dissociated", got)
+	}
+}
+
+func Test_naturalLess(t *testing.T) {
+	names := []string{"page10.png", "Page1.png", "page2.png", "cover.png", "page2b.png"}
+	sort.Slice(names, func(i, j int) bool { return naturalLess(names[i], names[j]) })
+	want := []string{"cover.png", "Page1.png", "page2.png", "page2b.png", "page10.png"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("got = %v, want %v", names, want)
+	}
+}
+
+func Test_pdfPages(t *testing.T) {
+	info := "Title:          Tetris\nPages:          12\nEncrypted:      no\n"
+	if got := pdfPages(info); got != 12 {
+		t.Errorf("pdfPages() = %d, want 12", got)
+	}
+	if got := pdfPages("Title: Tetris\n"); got != 0 {
+		t.Errorf("pdfPages() = %d, want 0", got)
+	}
+}
+
+func TestFit(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 400, 100))
+	if got := Fit(img, 200).Bounds().Size(); got != image.Pt(200, 50) {
+		t.Errorf("Fit() = %v, want 200x50", got)
+	}
+	if got := Fit(img, 4096); got != image.Image(img) {
+		t.Error("Fit() changed a page that fits")
+	}
+}
@@ -0,0 +1,161 @@
+package menu
+
+import (
+	"math/rand"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/gl/v2.1/gl"
+	"github.com/libretro/ludo/contentid"
+	"github.com/libretro/ludo/input"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+	"github.com/libretro/ludo/video"
+	"github.com/tanema/gween"
+	"github.com/tanema/gween/ease"
+)
+
+// attractSlide is how long an image of the attract mode is shown, in seconds
+const attractSlide = 6
+
+// attract is the screensaver shown after some idle time in the menu. It
+// cycles through the boxarts, snaps and screenshots of the library.
+var attract struct {
+	idle   float32 // seconds without input
+	active bool
+	waking bool // the input that woke the menu is still held
+	images []string
+	t      float32 // time spent on the current image
+	image  uint32
+	name   string
+	alpha  float32
+}
+
+// anyInput tells if a button, a key or a stick of any port is used
+func anyInput() bool {
+	for p := range input.NewState {
+		for _, v := range input.NewState[p] {
+			if v != 0 {
+				return true
+			}
+		}
+		for _, stick := range input.NewAnalogState[p] {
+			for _, v := range stick {
+				if v > 0x4000 || v < -0x4000 {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// attractImages lists the images of the library
+func attractImages() []string {
+	var paths []string
+	for _, pattern := range []string{
+		filepath.Join(settings.Current.ThumbnailsDirectory, "*", "Named_Boxarts", "*.png"),
+		filepath.Join(settings.Current.ThumbnailsDirectory, "*", "Named_Snaps", "*.png"),
+		filepath.Join(settings.Current.ScreenshotsDirectory, "*", "*.png"),
+	} {
+		found, _ := filepath.Glob(pattern)
+		paths = append(paths, found...)
+	}
+	return paths
+}
+
+// attractName is the name of the game of an image of the library. The names
+// of screenshots end with their date, and start with the CRC of the game.
+func attractName(path string) string {
+	name := utils.FileName(path)
+	if i := strings.LastIndex(name, "@"); i > 0 {
+		name = contentid.Name(name[:i])
+	}
+	return name
+}
+
+// nextAttractImage replaces the image shown by a random one
+func nextAttractImage() {
+	freeAttractImage()
+	attract.t = 0
+	if len(attract.images) == 0 {
+		return
+	}
+	path := attract.images[rand.Intn(len(attract.images))]
+	attract.image = video.NewImage(path)
+	attract.name = attractName(path)
+	attract.alpha = 0
+	menu.tweens[&attract.alpha] = gween.New(0, 1, 1, ease.OutSine)
+}
+
+func freeAttractImage() {
+	if attract.image != 0 {
+		gl.DeleteTextures(1, &attract.image)
+		attract.image = 0
+	}
+}
+
+// updateAttract counts the idle time and starts or stops the attract mode. It
+// reports whether the attract mode is shown, or the input that stopped it is
+// still held, in which case the menu ignores the input.
+func updateAttract(dt float32) bool {
+	pressed := anyInput()
+
+	if attract.waking {
+		attract.waking = pressed
+		return pressed
+	}
+
+	if attract.active {
+		if pressed {
+			attract.active = false
+			attract.waking = true
+			attract.idle = 0
+			freeAttractImage()
+			return true
+		}
+		attract.t += dt
+		if attract.t > attractSlide {
+			nextAttractImage()
+		}
+		return true
+	}
+
+	delay := float32(settings.Current.MenuAttractDelay * 60)
+	if pressed || delay == 0 || state.CoreRunning {
+		attract.idle = 0
+		return false
+	}
+	attract.idle += dt
+	if attract.idle > delay {
+		attract.active = true
+		attract.images = attractImages()
+		nextAttractImage()
+	}
+	return attract.active
+}
+
+// renderAttract draws the current image of the attract mode and the name of
+// its game
+func renderAttract() {
+	w, h := menu.GetFramebufferSize()
+	fw, fh := float32(w), float32(h)
+	menu.DrawRect(0, 0, fw, fh, 0, black)
+
+	if attract.image == 0 {
+		return
+	}
+
+	// Fit a 4:3 image in the screen
+	iw, ih := fh*4/3, fh
+	if iw > fw {
+		iw, ih = fw, fw*3/4
+	}
+	menu.DrawImage(attract.image, fw/2-iw/2, fh/2-ih/2, iw, ih, 1, white.Alpha(attract.alpha))
+
+	size := 0.6 * menu.ratio
+	lw := menu.Font.Width(size, "%s", attract.name)
+	menu.Font.SetColor(white.Alpha(attract.alpha))
+	menu.Font.Printf(fw/2-lw/2, fh-80*menu.ratio, size, "%s", attract.name)
+}
@@ -0,0 +1,39 @@
+package menu
+
+import (
+	"github.com/libretro/ludo/crash"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/settings"
+)
+
+// Offers to send the report of the last crash. The reports that were pending
+// are kept on disk either way, and the user is only asked once.
+func askCrashReport() {
+	var path string
+	for _, p := range crash.Pending() {
+		if dest, err := crash.Keep(p); err == nil {
+			path = dest
+		}
+	}
+	if path == "" {
+		return
+	}
+	if settings.Current.CrashReportURL == "" {
+		ntf.DisplayAndLog(ntf.Warning, "Menu", "Ludo crashed, a report was saved in %s.", path)
+		return
+	}
+	menu.stack[len(menu.stack)-1].segueNext()
+	menu.Push(buildYesNoDialog(
+		"Ludo crashed",
+		"Send the crash report to help fixing it?",
+		"It doesn't include the names of your games.", func() {
+			go func() {
+				defer crash.Recover()
+				if err := crash.Upload(path, settings.Current.CrashReportURL); err != nil {
+					ntf.DisplayAndLog(ntf.Error, "Menu", "Error sending the crash report: %v", err)
+					return
+				}
+				ntf.DisplayAndLog(ntf.Success, "Menu", "Crash report sent, thank you.")
+			}()
+		}))
+}
@@ -2,10 +2,13 @@ package menu
 
 import (
 	"github.com/go-gl/glfw/v3.3/glfw"
+
+	"github.com/libretro/ludo/i18n"
 )
 
 // Used to easily compose different hint bars based on the context.
 func stackHint(stack *float32, icon uint32, label string, h int) {
+	label = i18n.T(label)
 	menu.Font.SetColor(darkGrey)
 	*stack += 30 * menu.ratio
 	menu.DrawImage(icon, *stack, float32(h)-70*menu.ratio, 70*menu.ratio, 70*menu.ratio, 1.0, darkGrey)
@@ -1,10 +1,14 @@
 package menu
 
 import (
+	"image"
+
 	"github.com/libretro/ludo/audio"
 	"github.com/libretro/ludo/input"
 	"github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/macro"
 	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/record"
 	"github.com/libretro/ludo/settings"
 	"github.com/libretro/ludo/state"
 )
@@ -20,8 +24,16 @@ var (
 // Update takes care of calling the update method of the current scene.
 // Each scene has it's own input logic to allow a variety of navigation systems.
 func (m *Menu) Update(dt float32) {
+	if tabsStale {
+		tabsStale = false
+		resetTabs()
+	}
+	if updateAttract(dt) {
+		return
+	}
 	currentScene := m.stack[len(m.stack)-1]
 	currentScene.update(dt)
+	speakFocus(currentScene.Entry())
 }
 
 // Used to increase scroll speed during long presses
@@ -187,6 +199,7 @@ func (m *Menu) ProcessHotkeys() {
 		state.MenuActive = !state.MenuActive
 		state.FastForward = false
 		if state.MenuActive {
+			input.StopRumble()
 			audio.PlayEffect(audio.Effects["notice"])
 		} else {
 			audio.PlayEffect(audio.Effects["notice_back"])
@@ -213,11 +226,149 @@ func (m *Menu) ProcessHotkeys() {
 		}
 	}
 
+	if input.Pressed[0][input.ActionSaveState] == 1 && state.CoreRunning && !state.MenuActive {
+		if err := m.SaveState(); err != nil {
+			ntf.DisplayAndLog(ntf.Error, "Menu", err.Error())
+		} else {
+			ntf.DisplayAndLog(ntf.Success, "Menu", "State saved.")
+		}
+	}
+
+	if input.Pressed[0][input.ActionLoadState] == 1 && state.CoreRunning && !state.MenuActive {
+		if err := m.LoadState(); err == errNoSavestate {
+			ntf.DisplayAndLog(ntf.Warning, "Menu", "No savestate to load.")
+		} else if err != nil {
+			ntf.DisplayAndLog(ntf.Error, "Menu", err.Error())
+		} else {
+			ntf.DisplayAndLog(ntf.Success, "Menu", "State loaded.")
+		}
+	}
+
+	if input.Pressed[0][input.ActionScreenshot] == 1 && state.CoreRunning && !state.MenuActive {
+		takeScreenshot()
+	}
+
+	if input.Pressed[0][input.ActionGameFocusToggle] == 1 && state.CoreRunning && !state.MenuActive {
+		state.GameFocus = !state.GameFocus
+		if state.GameFocus {
+			ntf.DisplayAndLog(ntf.Info, "Menu", "Game focus ON")
+		} else {
+			ntf.DisplayAndLog(ntf.Info, "Menu", "Game focus OFF")
+		}
+	}
+
+	if input.Pressed[0][input.ActionMacroRecord] == 1 && state.CoreRunning && !state.MenuActive {
+		if macro.Recording() {
+			macro.StopRecording()
+			if err := macro.Save(state.CorePath, state.GameCRC); err != nil {
+				ntf.DisplayAndLog(ntf.Error, "Menu", "Error saving macro: %v", err.Error())
+			} else {
+				ntf.DisplayAndLog(ntf.Success, "Menu", "Macro saved.")
+			}
+		} else {
+			macro.StartRecording()
+			ntf.DisplayAndLog(ntf.Info, "Menu", "Recording macro.")
+		}
+	}
+
+	if input.Pressed[0][input.ActionMacroPlay] == 1 && state.CoreRunning && !state.MenuActive {
+		if macro.Playing() {
+			macro.Stop()
+		} else if len(macro.Current.Frames) == 0 {
+			ntf.DisplayAndLog(ntf.Warning, "Menu", "No macro recorded for this game.")
+		} else {
+			macro.Play()
+		}
+	}
+
+	if input.Pressed[0][input.ActionRecordToggle] == 1 && state.CoreRunning && !state.MenuActive {
+		toggleRecording()
+	}
+
+	if input.Pressed[0][input.ActionPauseToggle] == 1 && state.CoreRunning && !state.MenuActive {
+		state.Paused = !state.Paused
+		if state.Paused {
+			audio.Pause()
+			ntf.DisplayAndLog(ntf.Info, "Menu", "Paused")
+		} else {
+			ntf.DisplayAndLog(ntf.Info, "Menu", "Resumed")
+		}
+	}
+
+	if input.Pressed[0][input.ActionFrameAdvance] == 1 && state.CoreRunning && !state.MenuActive {
+		if settings.Current.AchievementsHardcore {
+			ntf.DisplayAndLog(ntf.Warning, "Menu", "Frame advance is disabled in hardcore mode.")
+		} else {
+			if !state.Paused {
+				state.Paused = true
+				audio.Pause()
+				ntf.DisplayAndLog(ntf.Info, "Menu", "Paused, advancing frame by frame")
+			}
+			state.FrameAdvance = true
+		}
+	}
+
+	if input.Pressed[0][input.ActionPerfOverlayToggle] == 1 && state.CoreRunning && !state.MenuActive {
+		perf.visible = !perf.visible
+	}
+
+	if input.Pressed[0][input.ActionMuteToggle] == 1 && state.CoreRunning {
+		if audio.ToggleMute() {
+			ntf.DisplayAndLog(ntf.Info, "Menu", "Audio muted")
+		} else {
+			ntf.DisplayAndLog(ntf.Info, "Menu", "Audio unmuted")
+		}
+	}
+
+	checkSession()
+
 	// Close if ActionShouldClose is pressed, but display a confirmation dialog
 	// in case a game is running
 	if input.Pressed[0][input.ActionShouldClose] == 1 {
-		askQuitConfirmation(func() {
-			m.SetShouldClose(true)
-		})
+		quit := func() {
+			askQuitConfirmation(func() {
+				m.SetShouldClose(true)
+			})
+		}
+		if kiosk() {
+			askPIN(quit)
+		} else {
+			quit()
+		}
+	}
+}
+
+// toggleRecording starts or stops recording the running game
+func toggleRecording() {
+	if record.Active() {
+		if err := record.Stop(); err != nil {
+			ntf.DisplayAndLog(ntf.Error, "Menu", "Error finishing the recording: %v", err.Error())
+			return
+		}
+		ntf.DisplayAndLog(ntf.Success, "Menu", "Recording saved.")
+		return
+	}
+
+	var b image.Rectangle
+	if !record.AudioOnly() {
+		img, err := menu.Frame()
+		if err != nil {
+			ntf.DisplayAndLog(ntf.Error, "Menu", err.Error())
+			return
+		}
+		b = img.Bounds()
+	}
+	avi := state.Core.GetSystemAVInfo()
+	path := record.Path(state.GamePath)
+	if err := record.Start(path, b.Dx(), b.Dy(), avi.Timing.FPS, avi.Timing.SampleRate); err != nil {
+		ntf.DisplayAndLog(ntf.Error, "Menu", "Error starting the recording: %v", err.Error())
+		return
+	}
+	if record.AudioOnly() {
+		ntf.DisplayAndLog(ntf.Info, "Menu", "Audio recording started.")
+	} else if settings.Current.RecordStream {
+		ntf.DisplayAndLog(ntf.Info, "Menu", "Streaming started.")
+	} else {
+		ntf.DisplayAndLog(ntf.Info, "Menu", "Recording started.")
 	}
 }
@@ -0,0 +1,89 @@
+package menu
+
+import (
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+)
+
+// kiosk tells if the menu is restricted to the playlists, for arcade
+// cabinets or children. Leaving the kiosk mode or quitting needs the PIN.
+func kiosk() bool {
+	return settings.Current.KioskMode
+}
+
+// askPIN shows the keyboard to type the PIN of the kiosk mode and of the
+// parental controls, and calls cb if it is right
+func askPIN(cb func()) {
+	state.MenuActive = true
+	k := newKeyboard("Enter the PIN", "", true, func(pin string) {
+		if pin != settings.Current.KioskPIN {
+			ntf.DisplayAndLog(ntf.Error, "Menu", "Wrong PIN.")
+			return
+		}
+		cb()
+	})
+	// cb can open another scene
+	k.afterClose = true
+	menu.Push(k)
+}
+
+// pinned asks for the PIN before calling cb in kiosk mode. It locks the
+// entries changing the configuration.
+func pinned(cb func()) func() {
+	return func() {
+		if !kiosk() {
+			cb()
+			return
+		}
+		askPIN(cb)
+	}
+}
+
+// tabsStale is set when the kiosk mode changed, the tabs are rebuilt on the
+// next update. Rebuilding them from the settings callbacks would be an
+// initialization cycle.
+var tabsStale bool
+
+// resetTabs rebuilds the menu from the tabs
+func resetTabs() {
+	menu.scroll = 0
+	menu.stack = []Scene{}
+	menu.Push(buildTabs())
+	menu.tweens.FastForward()
+}
+
+// choosePIN shows the keyboard to set a new PIN, and calls cb once it is set
+func choosePIN(cb func()) {
+	k := newKeyboard("Choose a PIN", "", true, func(pin string) {
+		if pin == "" {
+			ntf.DisplayAndLog(ntf.Warning, "Menu", "The PIN can't be empty.")
+			return
+		}
+		settings.Current.KioskPIN = pin
+		cb()
+	})
+	k.afterClose = true
+	menu.Push(k)
+}
+
+// enterKiosk asks for the PIN that will unlock the menu and enables the kiosk
+// mode
+func enterKiosk() {
+	choosePIN(func() {
+		settings.Current.KioskMode = true
+		settings.Save()
+		tabsStale = true
+		ntf.DisplayAndLog(ntf.Success, "Menu", "Kiosk mode enabled.")
+	})
+}
+
+// exitKiosk asks for the PIN and disables the kiosk mode
+func exitKiosk() {
+	askPIN(func() {
+		settings.Current.KioskMode = false
+		settings.Save()
+		tabsStale = true
+		ntf.DisplayAndLog(ntf.Success, "Menu", "Kiosk mode disabled.")
+	})
+}
@@ -6,8 +6,10 @@ package menu
 import (
 	"path/filepath"
 
+	"github.com/libretro/ludo/scanner"
 	"github.com/libretro/ludo/settings"
 	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/themes"
 	"github.com/libretro/ludo/utils"
 	"github.com/libretro/ludo/video"
 )
@@ -23,6 +25,8 @@ type Menu struct {
 	ratio  float32
 	t      float64
 
+	background uint32 // background image of the theme, if it has one
+
 	*video.Video // we embbed video here to have direct access to drawing functions
 }
 
@@ -36,16 +40,50 @@ func Init(v *video.Video) *Menu {
 	menu.Video = v
 	menu.stack = []Scene{}
 	menu.tweens = make(Tweens)
-	menu.ratio = float32(w) / 1920
+	menu.ratio = scaledRatio(w, v.DisplayScale())
 	menu.icons = map[string]uint32{}
 
 	menu.Push(buildTabs())
 
-	menu.ContextReset()
+	if settings.FirstRun {
+		menu.stack[0].segueNext()
+		menu.Push(buildWizard())
+	} else {
+		askCrashReport()
+	}
+
+	menu.applyTheme()
+	menu.SetFontSize(settings.Current.MenuFontSize)
+	settings.OnOverrides = reapply
+
+	if settings.Current.ScanOnStartup {
+		scanner.ScanChanged(refreshTabs)
+	}
 
 	return menu
 }
 
+// scaledRatio is the size of the menu elements for a framebuffer width, the
+// menu being designed for a width of 1920 pixels. The scale of the display
+// enlarges them on top.
+func scaledRatio(w int, display float32) float32 {
+	scale := settings.Current.MenuScale
+	if scale <= 0 {
+		scale = 1
+	}
+	if display > 0 {
+		scale *= display
+	}
+	return float32(w) / 1920 * scale
+}
+
+// updateScale follows the size of the framebuffer and the scale of the
+// display. The font is rasterized at the size it is displayed.
+func (m *Menu) updateScale(w int) {
+	m.ratio = scaledRatio(w, m.DisplayScale())
+	m.SetFontDensity(m.ratio)
+}
+
 // Push will navigate to a new scene. It usually happen when the user presses
 // OK on a menu entry.
 func (m *Menu) Push(s Scene) {
@@ -62,12 +100,21 @@ func (m *Menu) Render(dt float32) {
 
 	m.t += float64(dt * 8)
 	w, h := m.GetFramebufferSize()
-	m.ratio = float32(w) / 1920
+	m.updateScale(w)
+
+	if attract.active {
+		m.tweens.Update(dt)
+		renderAttract()
+		return
+	}
 
 	if state.CoreRunning {
 		m.DrawRect(0, 0, float32(w), float32(h), 0, bgColor.Alpha(0.85))
 	} else {
 		m.DrawRect(0, 0, float32(w), float32(h), 0, bgColor)
+		if m.background != 0 {
+			m.DrawImage(m.background, 0, 0, float32(w), float32(h), 1, white)
+		}
 	}
 
 	m.tweens.Update(dt)
@@ -102,6 +149,16 @@ func (m *Menu) ContextReset() {
 		m.icons[filename] = video.NewImage(path)
 	}
 
+	// The icons of the theme replace the ones of the assets
+	theme := themes.Current()
+	for _, path := range theme.Icons() {
+		m.icons[utils.FileName(path)] = video.NewImage(path)
+	}
+	m.background = 0
+	if theme.Image != "" {
+		m.background = video.NewImage(theme.Image)
+	}
+
 	currentScreenIndex := len(m.stack) - 1
 	curList := m.stack[currentScreenIndex].Entry()
 	for i := range curList.children {
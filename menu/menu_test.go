@@ -1,11 +1,23 @@
 package menu
 
 import (
+	"archive/zip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/libretro/ludo/dat"
+	"github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/playlists"
+	"github.com/libretro/ludo/settings"
 	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
 	"github.com/libretro/ludo/video"
 
 	"github.com/tanema/gween"
@@ -188,6 +200,29 @@ func Test_buildExplorer(t *testing.T) {
 	})
 }
 
+func Test_buildExplorerDirActions(t *testing.T) {
+	Init(&video.Video{})
+	var got string
+	dirAction := &entry{
+		label: "<Scan this directory>",
+		children: []entry{{
+			label:       "<Preview scan>",
+			callbackDir: func(path string) { got = path },
+		}},
+	}
+	dir := os.TempDir()
+	scene := buildExplorer(dir, nil, func(string) {}, dirAction, nil)
+	children := scene.Entry().children
+
+	if children[1].label != "<Preview scan>" {
+		t.Fatalf("got %v, want the other action second", children[1].label)
+	}
+	children[1].callbackOK()
+	if got != dir {
+		t.Errorf("got %v, want %v", got, dir)
+	}
+}
+
 func TestExtractTags(t *testing.T) {
 	var empty []string
 	tests := []struct {
@@ -220,6 +255,18 @@ func TestExtractTags(t *testing.T) {
 			want1: "My Awesome Game",
 			want2: []string{"Europe", "Fr", "De", "En"},
 		},
+		{
+			name:  "TOSEC name",
+			args:  "Legend of TOSEC, The (1986)(Devstudio)(US)(en)[a2]",
+			want1: "Legend of TOSEC, The",
+			want2: []string{"1986", "Devstudio", "USA", "En", "a2"},
+		},
+		{
+			name:  "GoodTools name",
+			args:  "Sonic the Hedgehog (UE) [h1C]",
+			want1: "Sonic the Hedgehog",
+			want2: []string{"USA", "Europe", "h1C"},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -233,3 +280,369 @@ func TestExtractTags(t *testing.T) {
 		})
 	}
 }
+
+func Test_buildTabs(t *testing.T) {
+	Init(&video.Video{})
+	settings.Current.KioskMode = true
+	defer func() { settings.Current.KioskMode = false }()
+
+	t.Run("Only shows the playlists and the kiosk exit in kiosk mode", func(t *testing.T) {
+		tabs := buildTabs().Entry()
+		got := tabs.children[len(tabs.children)-1].label
+		want := "Exit Kiosk Mode"
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got = %v, want %v", got, want)
+		}
+		for _, e := range tabs.children {
+			if e.label == "Settings" || e.label == "Main Menu" || e.label == "Add games" {
+				t.Errorf("got a %v tab in kiosk mode", e.label)
+			}
+		}
+	})
+}
+
+func Test_focusText(t *testing.T) {
+	on := true
+	list := entry{children: []entry{
+		{label: "Settings"},
+		{label: "Port 1 Device", stringValue: func() string { return "RetroPad" }},
+		{label: "Rumble", value: func() interface{} { return on }, widget: func(*entry) {}},
+	}}
+
+	tests := []struct {
+		ptr  int
+		want string
+	}{
+		{0, "Settings"},
+		{1, "Port 1 Device, RetroPad"},
+		{2, "Rumble, on"},
+		{3, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			list.ptr = tt.ptr
+			if got := focusText(&list); got != tt.want {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_keys(t *testing.T) {
+	tests := []struct {
+		layout  string
+		symbols bool
+		shift   bool
+		want    string
+	}{
+		{"QWERTY", false, false, "1234567890qwertyuiopasdfghjkl@zxcvbnm,-."},
+		{"QWERTY", false, true, "1234567890QWERTYUIOPASDFGHJKL@ZXCVBNM,-."},
+		{"AZERTY", false, false, "1234567890azertyuiopqsdfghjklmwxcvbn,.-@"},
+		{"QWERTZ", false, false, "1234567890qwertzuiopasdfghjkl@yxcvbnm,-."},
+		{"Dvorak", false, false, "1234567890qwertyuiopasdfghjkl@zxcvbnm,-."},
+		{"QWERTY", true, true, strings.Join(symbols, "")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.layout, func(t *testing.T) {
+			got := keys(tt.layout, tt.symbols, tt.shift)
+			if len(got) != actionRow || strings.Join(got, "") != tt.want {
+				t.Errorf("got = %v, want %v", strings.Join(got, ""), tt.want)
+			}
+		})
+	}
+}
+
+func Test_moveKey(t *testing.T) {
+	tests := []struct {
+		name          string
+		index, dx, dy int
+		want          int
+	}{
+		{"right", 0, 1, 0, 1},
+		{"wrap right", 9, 1, 0, 0},
+		{"wrap left", 10, -1, 0, 19},
+		{"down to a wide key", 33, 0, 1, 42},
+		{"right on wide keys", 42, 1, 0, 44},
+		{"wrap right on wide keys", 48, 1, 0, 40},
+		{"wrap left on wide keys", 40, -1, 0, 48},
+		{"wrap down", 44, 0, 1, 4},
+		{"wrap up", 3, 0, -1, 42},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := moveKey(tt.index, tt.dx, tt.dy); got != tt.want {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_fuzzyScore(t *testing.T) {
+	tests := []struct {
+		query, s string
+		score    int
+		ok       bool
+	}{
+		{"volume", "Audio Volume", 6, true},
+		{"aud vol", "Audio Volume", 3, true},
+		{"fs", "Video Fullscreen", 9, true},
+		{"VIDEO", "Video Filter", 0, true},
+		{"zz", "Video Filter", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			score, ok := fuzzyScore(tt.query, tt.s)
+			if ok != tt.ok || ok && score != tt.score {
+				t.Errorf("got = %v %v, want %v %v", score, ok, tt.score, tt.ok)
+			}
+		})
+	}
+}
+
+func Test_searchSettings(t *testing.T) {
+	children := []entry{
+		{label: "Video Fullscreen"},
+		{label: "Audio Volume"},
+		{label: "Menu Audio Volume"},
+		{label: "Video Filter"},
+	}
+	got := searchSettings("volume", children)
+	if want := []int{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got = %v, want %v", got, want)
+	}
+}
+
+func Test_attractName(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/thumbnails/Sega - Mega Drive - Genesis/Named_Snaps/Sonic The Hedgehog (USA, Europe).png", "Sonic The Hedgehog (USA, Europe)"},
+		{"/screenshots/Sonic The Hedgehog (USA, Europe)/Sonic The Hedgehog (USA, Europe)@2019-10-19-10-15-44.png", "Sonic The Hedgehog (USA, Europe)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := attractName(tt.path); got != tt.want {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_perfLines(t *testing.T) {
+	counters := []libretro.PerfCounter{
+		{Ident: "blit", Total: 2 * time.Millisecond, Calls: 4},
+		{Ident: "cpu", Total: 30 * time.Millisecond, Calls: 10},
+		{Ident: "idle", Total: 0, Calls: 0},
+	}
+	got := perfLines(20*time.Millisecond, 5*time.Millisecond, 64*time.Millisecond, counters)
+	want := []string{
+		"FPS: 50.0",
+		"Frame time: 20.00 ms",
+		"Core run: 5.00 ms",
+		"Audio buffer: 64 ms",
+		"cpu: 3.000 ms x 10",
+		"blit: 0.500 ms x 4",
+		"idle: 0.000 ms x 0",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got = %v, want %v", got, want)
+	}
+}
+
+func Test_matchesName(t *testing.T) {
+	tests := []struct {
+		name string
+		exts []string
+		want bool
+	}{
+		{"Game.sfc", []string{".sfc", ".smc"}, true},
+		{"GAME.SMC", []string{".sfc", ".smc"}, true},
+		{"Game.zip", []string{".sfc", ".smc"}, false},
+		{"Game", []string{".sfc"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesName(tt.name, tt.exts); got != tt.want {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_buildArchiveExplorer(t *testing.T) {
+	tmp := filepath.Join(os.TempDir(), "Test_buildArchiveExplorer")
+	os.RemoveAll(tmp)
+	os.Mkdir(tmp, 0777)
+	defer os.RemoveAll(tmp)
+	defer os.RemoveAll(filepath.Join(archivesDir(), "Games"))
+
+	path := filepath.Join(tmp, "Games.zip")
+	f, _ := os.Create(path)
+	w := zip.NewWriter(f)
+	for _, name := range []string{"Game 2.img", "readme.txt", "Game 1.IMG"} {
+		fw, _ := w.Create(name)
+		fw.Write([]byte(name))
+	}
+	w.Close()
+	f.Close()
+
+	var got string
+	scene := buildArchiveExplorer(path, []string{".img"}, func(p string) { got = p })
+	children := scene.Entry().children
+
+	t.Run("Lists the matching files sorted", func(t *testing.T) {
+		if len(children) != 2 || children[0].label != "Game 1.IMG" || children[1].label != "Game 2.img" {
+			t.Errorf("got %v entries, want Game 1.IMG and Game 2.img", len(children))
+		}
+	})
+
+	t.Run("Shows the size of the files", func(t *testing.T) {
+		if v := children[0].stringValue(); v != "10 B" {
+			t.Errorf("got = %v, want %v", v, "10 B")
+		}
+	})
+
+	t.Run("Extracts the archive to open a file", func(t *testing.T) {
+		children[1].callbackOK()
+		want := filepath.Join(archivesDir(), "Games", "Game 2.img")
+		if got != want {
+			t.Errorf("got = %v, want %v", got, want)
+		}
+		if _, err := os.Stat(want); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func Test_fetchThumbnail(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/parent.png" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("png"))
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "thumbnails")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "snaps", "hack.png")
+
+	t.Run("Falls back to the next url", func(t *testing.T) {
+		n, err := fetchThumbnail([]string{srv.URL + "/hack.png", srv.URL + "/parent.png"}, filepath.Dir(path), path)
+		if err != nil || n != 3 {
+			t.Fatalf("got %d bytes, %v", n, err)
+		}
+		if b, _ := ioutil.ReadFile(path); string(b) != "png" {
+			t.Errorf("got = %q, want the thumbnail", b)
+		}
+		if utils.Exists(path + ".part") {
+			t.Error("the partial download was left behind")
+		}
+	})
+
+	t.Run("Fails when no url is found", func(t *testing.T) {
+		if _, err := fetchThumbnail([]string{srv.URL + "/missing.png"}, dir, filepath.Join(dir, "missing.png")); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func Test_thumbnailSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "thumbnails")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	settings.Current.ThumbnailsDirectory = dir
+	settings.Current.ArtworkDirectory = ""
+	system := "Nintendo - Game Boy"
+	snaps := filepath.Join(dir, system, "Named_Snaps")
+	os.MkdirAll(snaps, os.ModePerm)
+	ioutil.WriteFile(filepath.Join(snaps, "Legend of Zelda, The - Link's Awakening (USA, Europe).png"), []byte("png"), 0644)
+
+	defer func(db dat.DB) { state.DB = db }(state.DB)
+	state.DB = dat.DB{system: dat.Dat{Games: []dat.Game{
+		{Name: "Tetris (World)", Description: "Tetris (World)", ROMs: []dat.ROM{{CRC: 0x46df91ad}}},
+	}}}
+
+	t.Run("Uses the name of the dat", func(t *testing.T) {
+		_, path, urls := thumbnailSource(system, "tetris_renamed", "/roms/tetris_renamed.gb", 0x46df91ad)
+		if path != filepath.Join(snaps, "Tetris (World).png") {
+			t.Errorf("got = %v, want the name of the dat", path)
+		}
+		if len(urls) != 2 || !strings.HasSuffix(urls[0], "/Tetris (World).png") {
+			t.Errorf("got = %v, want the name of the dat first", urls)
+		}
+	})
+
+	t.Run("Matches the normalized name", func(t *testing.T) {
+		_, path, _ := thumbnailSource(system, "The Legend of Zelda - Link's Awakening [h1]", "", 0)
+		if path != filepath.Join(snaps, "Legend of Zelda, The - Link's Awakening (USA, Europe).png") {
+			t.Errorf("got = %v, want the cached thumbnail", path)
+		}
+	})
+}
+
+func Test_playlistGaps(t *testing.T) {
+	dir, err := ioutil.TempDir("", "thumbnails")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	settings.Current.ThumbnailsDirectory = dir
+	settings.Current.ArtworkDirectory = ""
+	system := "Sega - Game Gear"
+	snaps := filepath.Join(dir, system, "Named_Snaps")
+	os.MkdirAll(snaps, os.ModePerm)
+	ioutil.WriteFile(filepath.Join(snaps, "Columns (USA, Europe).png"), []byte("png"), 0644)
+
+	defer func(db dat.DB) { state.DB = db }(state.DB)
+	state.DB = dat.DB{system: dat.Dat{Games: []dat.Game{
+		{Name: "Columns (USA, Europe)", ROMs: []dat.ROM{{CRC: 0x83fa26d9}}},
+		{Name: "Shinobi (USA, Europe)", ROMs: []dat.ROM{{CRC: 0x30f1c984}}},
+	}}}
+
+	games := []playlists.Game{
+		{Path: "/roms/Columns.gg", Name: "Columns (USA, Europe)", CRC32: 0x83fa26d9},
+		{Path: "/roms/Shinobi.gg", Name: "Shinobi (USA, Europe)", CRC32: 0x30f1c984},
+		{Path: "/roms/Homebrew.gg", Name: "Homebrew", CRC32: 0x12345678},
+	}
+	got := playlistGaps(state.DB, system, games)
+	want := []gap{
+		{game: games[1], thumbnail: true},
+		{game: games[2], thumbnail: true, metadata: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("playlistGaps() = %+v, want %+v", got, want)
+	}
+	if thumbnails, metadata := countGaps(got); thumbnails != 2 || metadata != 1 {
+		t.Errorf("countGaps() = %d, %d, want 2, 1", thumbnails, metadata)
+	}
+	if d := got[1].describe(); d != "No thumbnail, No metadata" {
+		t.Errorf("describe() = %q", d)
+	}
+}
+
+func Test_scaledRatio(t *testing.T) {
+	old := settings.Current.MenuScale
+	defer func() { settings.Current.MenuScale = old }()
+	settings.Current.MenuScale = 1
+
+	if got := scaledRatio(1920, 1); got != 1 {
+		t.Errorf("got %v, want 1", got)
+	}
+	// A desktop scaled to 150% enlarges the menu in a framebuffer of the
+	// same size
+	if got := scaledRatio(1920, 1.5); got != 1.5 {
+		t.Errorf("got %v, want 1.5", got)
+	}
+	settings.Current.MenuScale = 2
+	if got := scaledRatio(960, 1.5); got != 1.5 {
+		t.Errorf("got %v, want 1.5", got)
+	}
+}
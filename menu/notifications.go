@@ -1,10 +1,19 @@
 package menu
 
 import (
+	"strings"
+
 	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
 	"github.com/libretro/ludo/video"
 )
 
+// notificationPositions are the corners of the screen notifications can be
+// stacked in
+var notificationPositions = []string{"Top Left", "Top Right", "Bottom Left", "Bottom Right"}
+
 var severityFgColor = map[ntf.Severity]video.Color{
 	ntf.Error:   lightDanger,
 	ntf.Warning: lightWarning,
@@ -22,33 +31,47 @@ var severityBgColor = map[ntf.Severity]video.Color{
 // RenderNotifications draws the list of notification messages on the viewport
 func (m *Menu) RenderNotifications() {
 	fbw, fbh := m.GetFramebufferSize()
+	m.updateScale(fbw)
 	m.Font.UpdateResolution(fbw, fbh)
-	var h float32 = 75
+
+	s := settings.Current
+	scale := s.NotificationsFontSize
+	size := scale / 0.5 // relative to the default font size
+	right := strings.HasSuffix(s.NotificationsPosition, "Right")
+	bottom := strings.HasPrefix(s.NotificationsPosition, "Bottom")
+	margin := float32(0)
+	if state.MenuActive {
+		margin = 70 * m.ratio // keep the hint bar visible
+	}
+
+	var h = 75 * size
 	stack := h
 	for _, n := range ntf.List() {
+		if utils.StringInSlice(n.Category, s.NotificationsHidden) {
+			continue
+		}
 		fading := n.Duration * 4
 		if fading > 1 {
 			fading = 1
 		}
 		offset := fading*h - h
-		lw := m.Font.Width(0.5*m.ratio, n.Message)
+		lw := m.Font.Width(scale*m.ratio, n.Message)
 		fg := severityFgColor[n.Severity]
 		bg := severityBgColor[n.Severity]
-		m.DrawRect(
-			25*m.ratio,
-			(stack+offset-46)*m.ratio,
-			lw+40*m.ratio,
-			70*m.ratio,
-			0.25,
-			bg.Alpha(fading),
-		)
+
+		w := lw + 40*size*m.ratio
+		x := 25 * m.ratio
+		if right {
+			x = float32(fbw) - w - 25*m.ratio
+		}
+		y := (stack + offset - 46*size) * m.ratio
+		if bottom {
+			y = float32(fbh) - margin - y - 70*size*m.ratio
+		}
+
+		m.DrawRect(x, y, w, 70*size*m.ratio, 0.25, bg.Alpha(fading))
 		m.Font.SetColor(fg.Alpha(fading))
-		m.Font.Printf(
-			45*m.ratio,
-			(stack+offset)*m.ratio,
-			0.5*m.ratio,
-			n.Message,
-		)
+		m.Font.Printf(x+20*size*m.ratio, y+46*size*m.ratio, scale*m.ratio, n.Message)
 		stack += h + offset
 	}
 }
@@ -1,8 +1,10 @@
 package menu
 
 import (
-	"github.com/libretro/ludo/settings"
+	"github.com/go-gl/gl/v2.1/gl"
+	ntf "github.com/libretro/ludo/notifications"
 	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/themes"
 	"github.com/libretro/ludo/video"
 )
 
@@ -10,13 +12,10 @@ var white = video.Color{R: 1, G: 1, B: 1, A: 1}
 var black = video.Color{R: 0, G: 0, B: 0, A: 1}
 
 var orange = video.Color{R: 0.8, G: 0.4, B: 0.1, A: 1}
-var cyan = video.Color{R: 0.8784, G: 1, B: 1, A: 1}
-var darkBlue = video.Color{R: 0.1, G: 0.1, B: 0.4, A: 1}
 
 var lightGrey = video.Color{R: 0.75, G: 0.75, B: 0.75, A: 1}
 var mediumGrey = video.Color{R: 0.5, G: 0.5, B: 0.5, A: 1}
 var darkGrey = video.Color{R: 0.25, G: 0.25, B: 0.25, A: 1}
-var darkerGrey = video.Color{R: 0.10, G: 0.10, B: 0.10, A: 1}
 
 var darkInfo = video.Color{R: 0.04, G: 0.36, B: 0.46, A: 1}
 var lightInfo = video.Color{R: 0.53, G: 0.89, B: 1.00, A: 1}
@@ -30,19 +29,36 @@ var lightDanger = video.Color{R: 1.00, G: 0.53, B: 0.53, A: 1}
 var darkWarning = video.Color{R: 0.47, G: 0.40, B: 0.04, A: 1}
 var lightWarning = video.Color{R: 1.00, G: 0.92, B: 0.53, A: 1}
 
-var bgColor = white
-var cursorBg = cyan
-var textColor = black
+// The colors of the menu are the ones of the themes
+var bgColor = themes.Light.Background
+var cursorBg = themes.Light.Cursor
+var textColor = themes.Light.Text
 
-// UpdatePalette updates the color palette to honor the dark theme
+// UpdatePalette updates the color palette to honor the menu theme. The light
+// theme turns dark while a game is running.
 func (m *Menu) UpdatePalette() {
-	bgColor = white
-	cursorBg = cyan
-	textColor = black
-
-	if state.CoreRunning || settings.Current.VideoDarkMode {
-		bgColor = darkerGrey
-		cursorBg = darkBlue
-		textColor = white
+	t := themes.Current()
+	if state.CoreRunning && t.Name == themes.Light.Name {
+		t = themes.Dark
 	}
+	bgColor = t.Background
+	cursorBg = t.Cursor
+	textColor = t.Text
+}
+
+// applyTheme loads the font, the background and the icons of the menu theme.
+// The textures of the previous theme are freed.
+func (m *Menu) applyTheme() {
+	t := themes.Current()
+	if err := m.SetFont(t.Font); err != nil {
+		ntf.DisplayAndLog(ntf.Error, "Menu", "Error loading the font of the theme: %v", err)
+	}
+	for name, tex := range m.icons {
+		gl.DeleteTextures(1, &tex)
+		delete(m.icons, name)
+	}
+	if m.background != 0 {
+		gl.DeleteTextures(1, &m.background)
+	}
+	m.ContextReset()
 }
@@ -0,0 +1,71 @@
+package menu
+
+import (
+	"errors"
+
+	"github.com/libretro/ludo/audio"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/parental"
+	"github.com/libretro/ludo/playlists"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+)
+
+var errRestricted = errors.New("this game is restricted by the parental controls")
+
+// restrictedGame tells if a game of the history or of the remote control
+// needs the PIN. Its rating is looked up from its checksum in the playlists.
+func restrictedGame(path, system string) bool {
+	var crc uint32
+	if csv, game, ok := playlists.Find(path, 0); ok {
+		crc = game.CRC32
+		if system == "" {
+			system = utils.FileName(csv)
+		}
+	}
+	return parental.Restricted(system, crc)
+}
+
+// unlockParental asks for the PIN before calling cb if the content is
+// restricted
+func unlockParental(restricted bool, cb func()) {
+	if restricted {
+		askPIN(cb)
+		return
+	}
+	cb()
+}
+
+// openParental opens the parental controls, once the PIN is set or typed
+func openParental(list Scene) {
+	list.segueNext()
+	open := func() {
+		list.segueNext()
+		menu.Push(buildParental())
+	}
+	if settings.Current.KioskPIN == "" {
+		choosePIN(func() {
+			settings.Save()
+			open()
+		})
+		return
+	}
+	askPIN(open)
+}
+
+// checkSession pauses the game when the play time of the session is over.
+// The PIN starts a new session.
+func checkSession() {
+	if !parental.Expired() || !state.CoreRunning || state.MenuActive {
+		return
+	}
+	state.Paused = true
+	audio.Pause()
+	ntf.DisplayAndLog(ntf.Warning, "Parental", "Play time is over.")
+	askPIN(func() {
+		parental.ResetSession()
+		state.Paused = false
+		state.MenuActive = false
+	})
+}
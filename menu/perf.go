@@ -0,0 +1,98 @@
+package menu
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/libretro/ludo/audio"
+	"github.com/libretro/ludo/i18n"
+	"github.com/libretro/ludo/libretro"
+)
+
+// maxPerfCounters is the number of core counters shown by the performance
+// overlay, the most expensive ones first
+const maxPerfCounters = 8
+
+// perf holds the measures of the performance overlay. The frame and run
+// times are smoothed so that the numbers stay readable.
+var perf struct {
+	visible bool
+	frame   time.Duration
+	run     time.Duration
+}
+
+// smooth moves an average a tenth of the way to a new sample
+func smooth(avg, sample time.Duration) time.Duration {
+	if avg == 0 {
+		return sample
+	}
+	return avg + (sample-avg)/10
+}
+
+// RecordRun measures the time spent by the core to run a frame
+func RecordRun(d time.Duration) {
+	perf.run = smooth(perf.run, d)
+}
+
+// perfLines formats the measures of the performance overlay
+func perfLines(frame, run, buffered time.Duration, counters []libretro.PerfCounter) []string {
+	ms := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Millisecond)
+	}
+	var fps float64
+	if frame > 0 {
+		fps = float64(time.Second) / float64(frame)
+	}
+	lines := []string{
+		fmt.Sprintf(i18n.T("FPS: %.1f"), fps),
+		fmt.Sprintf(i18n.T("Frame time: %.2f ms"), ms(frame)),
+		fmt.Sprintf(i18n.T("Core run: %.2f ms"), ms(run)),
+		fmt.Sprintf(i18n.T("Audio buffer: %.0f ms"), ms(buffered)),
+	}
+
+	sort.SliceStable(counters, func(i, j int) bool {
+		return counters[i].Total > counters[j].Total
+	})
+	if len(counters) > maxPerfCounters {
+		counters = counters[:maxPerfCounters]
+	}
+	for _, c := range counters {
+		var avg time.Duration
+		if c.Calls > 0 {
+			avg = c.Total / time.Duration(c.Calls)
+		}
+		lines = append(lines, fmt.Sprintf(i18n.T("%s: %.3f ms x %d"), c.Ident, ms(avg), c.Calls))
+	}
+	return lines
+}
+
+// RenderPerf draws the performance overlay on the top left corner of the
+// game, when it is toggled by its hotkey
+func (m *Menu) RenderPerf(dt float32) {
+	if !perf.visible {
+		return
+	}
+	perf.frame = smooth(perf.frame, time.Duration(dt*float32(time.Second)))
+
+	lines := perfLines(perf.frame, perf.run, audio.Buffered(), libretro.PerfCounters())
+
+	fbw, fbh := m.GetFramebufferSize()
+	m.updateScale(fbw)
+	m.Font.UpdateResolution(fbw, fbh)
+
+	scale := float32(0.4)
+	lh := 30 * m.ratio
+	var w float32
+	for _, l := range lines {
+		if lw := m.Font.Width(scale*m.ratio, l); lw > w {
+			w = lw
+		}
+	}
+	x, y := 25*m.ratio, 25*m.ratio
+	m.DrawRect(x, y, w+30*m.ratio, float32(len(lines))*lh+20*m.ratio, 0.1, darkInfo.Alpha(0.85))
+	m.Font.SetColor(lightInfo)
+	for i, l := range lines {
+		m.Font.Printf(x+15*m.ratio, y+10*m.ratio+float32(i+1)*lh-8*m.ratio, scale*m.ratio, l)
+	}
+}
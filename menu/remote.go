@@ -0,0 +1,118 @@
+package menu
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/libretro/ludo/core"
+	"github.com/libretro/ludo/history"
+	"github.com/libretro/ludo/i18n"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/playlists"
+	"github.com/libretro/ludo/remote"
+	"github.com/libretro/ludo/savestates"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+)
+
+var errNoSavestate = errors.New("no savestate to load")
+
+// showRemoteURL tells where to open the web UI and the pairing code. The code
+// is only shown, not logged, the logs end up in the crash reports.
+func showRemoteURL() {
+	msg := fmt.Sprintf(i18n.T("Open %s, pairing code %s"), remote.URL(), settings.Current.RemoteCode)
+	ntf.Display(ntf.Info, msg, ntf.Medium*3)
+}
+
+// Launch loads a game with the core of its system and closes the menu. The
+// system is found in the playlists if it is empty. The running core is used
+// for games that aren't in any playlist.
+func (m *Menu) Launch(path, system string) error {
+	if _, err := os.Stat(path); err != nil {
+		return errors.New("game not found")
+	}
+	if restrictedGame(path, system) {
+		return errRestricted
+	}
+	name := utils.FileName(path)
+	if csv, game, ok := playlists.Find(path, 0); ok {
+		name = game.Name
+		if system == "" {
+			system = utils.FileName(csv)
+		}
+	}
+
+	corePath := state.CorePath
+	if system != "" {
+		c, err := settings.CoreForPlaylist(system)
+		if err != nil {
+			return err
+		}
+		corePath = c
+	}
+	if corePath == "" {
+		return errors.New("no core for this game")
+	}
+	if _, err := os.Stat(corePath); err != nil {
+		return errors.New("core not found: " + filepath.Base(corePath))
+	}
+
+	if state.CorePath != corePath {
+		if err := core.Load(corePath); err != nil {
+			return err
+		}
+	}
+	if state.GamePath != path {
+		if err := core.LoadGame(path); err != nil {
+			return err
+		}
+		history.Push(history.Game{
+			Path:     path,
+			Name:     name,
+			System:   system,
+			CorePath: corePath,
+		})
+	}
+	m.WarpToQuickMenu()
+	state.MenuActive = false
+	return nil
+}
+
+// SaveState saves a state of the running game and its screenshot
+func (m *Menu) SaveState() error {
+	if !state.CoreRunning {
+		return errors.New("no game running")
+	}
+	name := savestates.Name(state.GamePath, state.GameCRC)
+	if err := savestates.Save(name); err != nil {
+		return err
+	}
+	if err := m.TakeScreenshot(name); err != nil {
+		ntf.DisplayAndLog(ntf.Error, "Menu", err.Error())
+	}
+	return nil
+}
+
+// LoadState loads the most recent state of the running game
+func (m *Menu) LoadState() error {
+	if !state.CoreRunning {
+		return errors.New("no game running")
+	}
+	paths := savestatePaths()
+	if len(paths) == 0 {
+		return errNoSavestate
+	}
+	return savestates.Load(paths[0])
+}
+
+// Screenshot saves a screenshot of the running game and returns its path
+func (m *Menu) Screenshot() (string, error) {
+	if !state.CoreRunning {
+		return "", errors.New("no game running")
+	}
+	path := filepath.Join(screenshotsDirectory(), utils.Dated(gameKey())+".png")
+	return path, m.SaveScreenshot(path, settings.Current.ScreenshotPostShader)
+}
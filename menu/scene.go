@@ -1,6 +1,7 @@
 package menu
 
 import (
+	"github.com/libretro/ludo/i18n"
 	"github.com/libretro/ludo/state"
 	"github.com/tanema/gween"
 	"github.com/tanema/gween/ease"
@@ -19,8 +20,9 @@ type entry struct {
 	iconAlpha       float32
 	tagAlpha        float32
 	subLabelAlpha   float32
-	callbackOK      func() // callback executed when user presses OK
-	callbackX       func() // callback executed when user presses X
+	callbackOK      func()       // callback executed when user presses OK
+	callbackX       func()       // callback executed when user presses X
+	callbackDir     func(string) // callback of an action on the directory browsed, see buildExplorer
 	value           func() interface{}
 	stringValue     func() string
 	widget          func(*entry) // widget draw callback used in settings
@@ -28,6 +30,7 @@ type entry struct {
 	tags            []string     // flags extracted from game title
 	thumbnail       uint32       // thumbnail texture id
 	gameName        string       // title of the game in db, used for thumbnails
+	crc             uint32       // checksum of the rom, used for thumbnails
 	cursor          struct {
 		alpha float32
 		yp    float32
@@ -198,16 +201,17 @@ func genericRender(list *entry) {
 			menu.Font.Printf(
 				670*menu.ratio,
 				float32(h)*e.yp+fontOffset,
-				0.5*menu.ratio, e.label)
+				0.5*menu.ratio, i18n.T(e.label))
 
 			if e.widget != nil {
 				e.widget(&e)
 			} else if e.stringValue != nil {
-				lw := menu.Font.Width(0.5*menu.ratio, e.stringValue())
+				v := i18n.T(e.stringValue())
+				lw := menu.Font.Width(0.5*menu.ratio, v)
 				menu.Font.Printf(
 					float32(w)-lw-128*menu.ratio,
 					float32(h)*e.yp+fontOffset,
-					0.5*menu.ratio, e.stringValue())
+					0.5*menu.ratio, v)
 			}
 		}
 	}
@@ -262,6 +266,16 @@ func askDeleteSavestateConfirmation(cb func()) {
 		}))
 }
 
+// Displays a confirmation dialog before uninstalling a core
+func askUninstallCoreConfirmation(detail string, cb func()) {
+	menu.Push(buildYesNoDialog(
+		"Confirm before uninstalling",
+		"You are about to uninstall a core.",
+		detail, func() {
+			cb()
+		}))
+}
+
 func genericDrawHintBar() {
 	w, h := menu.GetFramebufferSize()
 	menu.DrawRect(0, float32(h)-70*menu.ratio, float32(w), 70*menu.ratio, 0, lightGrey)
@@ -0,0 +1,193 @@
+package menu
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/libretro/ludo/achievements"
+	"github.com/libretro/ludo/crash"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/video"
+)
+
+type sceneAchievements struct {
+	entry
+	badges map[int]achievements.Achievement
+}
+
+func buildAchievements() Scene {
+	var list sceneAchievements
+	list.label = "Achievements"
+	list.badges = map[int]achievements.Achievement{}
+
+	if !achievements.Enabled() {
+		list.children = append(list.children, entry{
+			label: "Set cheevos_username and cheevos_api_key in the settings",
+			icon:  "close",
+		})
+		list.segueMount()
+		return &list
+	}
+
+	list.children = append(list.children, entry{
+		label: "Profile",
+		icon:  "subsetting",
+		stringValue: func() string {
+			p := achievements.CurrentProfile()
+			if p == nil {
+				return "Loading..."
+			}
+			return fmt.Sprintf("%d points, rank %d", p.TotalPoints, p.Rank)
+		},
+	})
+	go func() {
+		defer crash.Recover()
+		if err := achievements.LoadProfile(); err != nil {
+			ntf.DisplayAndLog(ntf.Error, "Achievements", err.Error())
+		}
+	}()
+
+	if g := achievements.Current(); state.CoreRunning && g != nil {
+		unlocked, total, points, totalPoints := g.Progress()
+		list.children = append(list.children, entry{
+			label: g.Title,
+			icon:  "subsetting",
+			stringValue: func() string {
+				return fmt.Sprintf("%d/%d, %d/%d points (%.0f%%)",
+					unlocked, total, points, totalPoints, g.Completion())
+			},
+		})
+
+		for _, a := range g.List() {
+			a := a
+			list.badges[len(list.children)] = a
+			list.children = append(list.children, entry{
+				label: fmt.Sprintf("%s (%d)", a.Title, a.Points),
+				stringValue: func() string {
+					if a.Unlocked() {
+						return "Unlocked"
+					}
+					return "Locked"
+				},
+				callbackOK: func() {
+					ntf.DisplayAndLog(ntf.Info, "Achievements", a.Description)
+				},
+			})
+		}
+	} else if state.CoreRunning {
+		list.children = append(list.children, entry{
+			label: "No achievement for this game",
+			icon:  "close",
+		})
+	}
+
+	list.segueMount()
+
+	return &list
+}
+
+// drawBadge draws the badge of an achievement, downloading it to the cache
+// the first time
+func drawBadge(list *entry, i int, a achievements.Achievement, x, y, w, h, scale float32, color video.Color) {
+	path := a.BadgePath()
+	if list.children[i].thumbnail == 0 || list.children[i].thumbnail == menu.icons["img-dl"] {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			list.children[i].thumbnail = video.NewImage(path)
+		} else if list.children[i].thumbnail != menu.icons["img-dl"] {
+			list.children[i].thumbnail = menu.icons["img-dl"]
+			go downloadThumbnail(list, i, []string{a.BadgeURL()}, filepath.Dir(path), path)
+		}
+	}
+
+	menu.DrawImage(list.children[i].thumbnail, x, y, w, h, scale, color)
+}
+
+func (s *sceneAchievements) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneAchievements) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneAchievements) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneAchievements) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneAchievements) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+// Override rendering to draw the badges in place of the icons
+func (s *sceneAchievements) render() {
+	list := &s.entry
+	w, h := menu.GetFramebufferSize()
+
+	genericDrawCursor(list)
+
+	menu.ScissorStart(int32(530*menu.ratio), 0, int32(1310*menu.ratio), int32(h))
+
+	for i, e := range list.children {
+		if e.yp < -0.1 || e.yp > 1.1 {
+			continue
+		}
+
+		fontOffset := 64 * 0.7 * menu.ratio * 0.3
+
+		if a, ok := s.badges[i]; ok {
+			drawBadge(list, i, a,
+				610*menu.ratio-64*0.5*menu.ratio,
+				float32(h)*e.yp-14*menu.ratio-64*0.5*menu.ratio+fontOffset,
+				64*menu.ratio, 64*menu.ratio,
+				1, white.Alpha(e.iconAlpha))
+		} else {
+			menu.DrawImage(menu.icons[e.icon],
+				610*menu.ratio-64*0.5*menu.ratio,
+				float32(h)*e.yp-14*menu.ratio-64*0.5*menu.ratio+fontOffset,
+				128*menu.ratio, 128*menu.ratio,
+				0.5, textColor.Alpha(e.iconAlpha))
+		}
+
+		if e.labelAlpha > 0 {
+			menu.Font.SetColor(textColor.Alpha(e.labelAlpha))
+			menu.Font.Printf(
+				670*menu.ratio,
+				float32(h)*e.yp+fontOffset,
+				0.5*menu.ratio, e.label)
+
+			if e.stringValue != nil {
+				lw := menu.Font.Width(0.5*menu.ratio, e.stringValue())
+				menu.Font.Printf(
+					float32(w)-lw-128*menu.ratio,
+					float32(h)*e.yp+fontOffset,
+					0.5*menu.ratio, e.stringValue())
+			}
+		}
+	}
+
+	menu.ScissorEnd()
+}
+
+func (s *sceneAchievements) drawHintBar() {
+	w, h := menu.GetFramebufferSize()
+	menu.DrawRect(0, float32(h)-70*menu.ratio, float32(w), 70*menu.ratio, 0, lightGrey)
+
+	_, upDown, _, a, b, _, _, _, _, guide := hintIcons()
+
+	var stack float32
+	list := menu.stack[len(menu.stack)-1].Entry()
+	if state.CoreRunning {
+		stackHint(&stack, guide, "RESUME", h)
+	}
+	stackHint(&stack, upDown, "NAVIGATE", h)
+	stackHint(&stack, b, "BACK", h)
+	if list.children[list.ptr].callbackOK != nil {
+		stackHint(&stack, a, "DESCRIPTION", h)
+	}
+}
@@ -0,0 +1,120 @@
+package menu
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/libretro/ludo/core"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/utils"
+	"github.com/mholt/archiver/v3"
+)
+
+// archiveExts are the archives that the explorer can browse
+var archiveExts = []string{".zip", ".rar", ".tar"}
+
+func isArchive(name string) bool {
+	return matchesName(name, archiveExts)
+}
+
+// archivesDir is where the archives browsed by the explorer are extracted
+func archivesDir() string {
+	return filepath.Join(os.TempDir(), "ludo", "archives")
+}
+
+// lastArchive is the archive a file was last extracted from
+var lastArchive string
+
+// archiveFile is a file of an archive, Name is its path in the archive
+type archiveFile struct {
+	Name string
+	Size int64
+}
+
+// listArchive lists the files of an archive
+func listArchive(path string) ([]archiveFile, error) {
+	var files []archiveFile
+	err := archiver.Walk(path, func(f archiver.File) error {
+		if f.IsDir() {
+			return nil
+		}
+		name := f.Name()
+		switch h := f.Header.(type) {
+		case zip.FileHeader:
+			name = h.Name
+		case *tar.Header:
+			name = h.Name
+		}
+		files = append(files, archiveFile{Name: name, Size: f.Size()})
+		return nil
+	})
+	sort.SliceStable(files, func(i, j int) bool {
+		return strings.ToLower(files[i].Name) < strings.ToLower(files[j].Name)
+	})
+	return files, err
+}
+
+// openArchiveFile passes a file of an archive to the callback of the
+// explorer. Archives holding a single file are passed as is, the cores and
+// Ludo know how to open them. Otherwise the archive is extracted, so that the
+// files next to the selected one, like the tracks of a cue sheet, are found.
+func openArchiveFile(path string, files []archiveFile, name string, cb func(string)) {
+	if len(files) == 1 {
+		cb(path)
+		return
+	}
+	dst := filepath.Join(archivesDir(), utils.FileName(path))
+	if err := core.Unarchive(path, dst); err != nil {
+		ntf.DisplayAndLog(ntf.Error, "Menu", err.Error())
+		return
+	}
+	lastArchive = path
+	cb(filepath.Join(dst, filepath.FromSlash(name)))
+}
+
+// buildArchiveExplorer lists the files of an archive matching the extensions
+func buildArchiveExplorer(path string, exts []string, cb func(string)) Scene {
+	var list sceneExplorer
+	list.label = filepath.Base(path)
+
+	files, err := listArchive(path)
+	if err != nil {
+		ntf.DisplayAndLog(ntf.Error, "Menu", err.Error())
+	}
+
+	for _, f := range files {
+		f := f
+		if exts != nil && !matchesName(f.Name, exts) {
+			continue
+		}
+		list.children = append(list.children, entry{
+			label: f.Name,
+			icon:  "file",
+			stringValue: func() string {
+				return utils.HumanSize(f.Size)
+			},
+			callbackOK: func() {
+				if cb != nil {
+					openArchiveFile(path, files, f.Name, cb)
+				}
+			},
+		})
+	}
+
+	buildIndexes(&list.entry)
+
+	if len(list.children) == 0 {
+		list.children = append(list.children, entry{
+			label: "Empty",
+			icon:  "subsetting",
+		})
+	}
+
+	list.segueMount()
+
+	return &list
+}
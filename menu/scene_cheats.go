@@ -0,0 +1,101 @@
+package menu
+
+import (
+	"github.com/libretro/ludo/cheats"
+	ntf "github.com/libretro/ludo/notifications"
+)
+
+type sceneCheats struct {
+	entry
+}
+
+func buildCheats() Scene {
+	var list sceneCheats
+	list.label = "Cheats"
+	list.fill()
+	list.segueMount()
+	return &list
+}
+
+// fill lists the cheats of the running game
+func (s *sceneCheats) fill() {
+	s.children = []entry{{
+		label: "Add Cheat Code",
+		icon:  "subsetting",
+		callbackOK: func() {
+			s.segueNext()
+			menu.Push(buildKeyboardThen("Cheat code", func(code string) {
+				s.segueNext()
+				menu.Push(buildKeyboard("Cheat name", func(name string) {
+					if err := cheats.Add(name, code); err != nil {
+						ntf.DisplayAndLog(ntf.Error, "Menu", "Error adding the cheat: %v", err.Error())
+						return
+					}
+					s.fill()
+					s.ptr = len(s.children) - 1
+					genericAnimate(&s.entry)
+					ntf.DisplayAndLog(ntf.Success, "Menu", "Cheat added.")
+				}))
+			}))
+		},
+	}}
+
+	for i, c := range cheats.Current {
+		i := i
+		s.children = append(s.children, entry{
+			label: c.Desc,
+			icon:  "subsetting",
+			value: func() interface{} {
+				return cheats.Current[i].Enabled
+			},
+			incr: func(direction int) {
+				if err := cheats.Toggle(i); err != nil {
+					ntf.DisplayAndLog(ntf.Error, "Menu", "Error saving cheats: %v", err.Error())
+				}
+			},
+			widget: widgets["switch"],
+		})
+	}
+}
+
+func (s *sceneCheats) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneCheats) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneCheats) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneCheats) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneCheats) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneCheats) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneCheats) drawHintBar() {
+	w, h := menu.GetFramebufferSize()
+	menu.DrawRect(0, float32(h)-70*menu.ratio, float32(w), 70*menu.ratio, 0, lightGrey)
+
+	_, upDown, leftRight, a, b, _, _, _, _, guide := hintIcons()
+
+	var stack float32
+	list := menu.stack[len(menu.stack)-1].Entry()
+	stackHint(&stack, guide, "RESUME", h)
+	stackHint(&stack, upDown, "NAVIGATE", h)
+	stackHint(&stack, b, "BACK", h)
+	if list.children[list.ptr].callbackOK != nil {
+		stackHint(&stack, a, "OK", h)
+	} else {
+		stackHint(&stack, leftRight, "TOGGLE", h)
+	}
+}
@@ -0,0 +1,125 @@
+package menu
+
+import (
+	"sort"
+
+	"github.com/libretro/ludo/collection"
+	"github.com/libretro/ludo/crash"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/playlists"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/stats"
+	"github.com/libretro/ludo/utils"
+)
+
+// collectionSystem converts a playlist to a section of the collection page
+func collectionSystem(path string, games []playlists.Game) collection.System {
+	system := utils.FileName(path)
+	s := collection.System{Name: playlists.ShortName(system)}
+	for _, game := range games {
+		name, tags := extractTags(game.Name)
+		g := collection.Game{Name: name, Tags: tags}
+		if _, thumb, _ := thumbnailSource(system, game.Name, game.Path, game.CRC32); thumb != "" && utils.Exists(thumb) {
+			g.Image = thumb
+		}
+		if st := stats.Get(game.Path); st.Sessions > 0 {
+			g.Details = append(g.Details, "Played "+stats.Playtime(st.Seconds))
+		}
+		s.Games = append(s.Games, g)
+	}
+	return s
+}
+
+// exportCollection writes the collection page of some playlists in the
+// collection directory. The thumbnails are looked up and copied in the
+// background.
+func exportCollection(file, title string, paths []string) {
+	lists := map[string][]playlists.Game{}
+	for _, path := range paths {
+		lists[path] = playlists.Playlists[path]
+	}
+	dir := settings.Current.CollectionDirectory
+	n := ntf.DisplayAndLog(ntf.Info, "Menu", "Exporting the collection")
+	go func() {
+		defer crash.Recover()
+		var systems []collection.System
+		for _, path := range paths {
+			systems = append(systems, collectionSystem(path, lists[path]))
+		}
+		page, err := collection.Export(dir, file, title, systems)
+		if err != nil {
+			n.Update(ntf.Error, "Could not export the collection: %s", err.Error())
+			return
+		}
+		n.Update(ntf.Success, "Collection exported to %s", page)
+	}()
+}
+
+type sceneCollection struct {
+	entry
+}
+
+// buildCollection lets the user export the whole library, or a playlist, as
+// a static HTML page
+func buildCollection() Scene {
+	var list sceneCollection
+	list.label = "Export Collection Page"
+
+	var keys []string
+	for k := range playlists.Playlists {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	list.children = append(list.children, entry{
+		label: "Whole Library",
+		icon:  "subsetting",
+		callbackOK: func() {
+			exportCollection("index.html", "Ludo", keys)
+		},
+	})
+
+	for _, path := range keys {
+		path := path
+		system := utils.FileName(path)
+		list.children = append(list.children, entry{
+			label: playlists.ShortName(system),
+			icon:  system + "-content",
+			callbackOK: func() {
+				exportCollection(utils.ScrubIllegalChars(system)+".html", playlists.ShortName(system), []string{path})
+			},
+		})
+	}
+
+	list.segueMount()
+
+	return &list
+}
+
+func (s *sceneCollection) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneCollection) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneCollection) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneCollection) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneCollection) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneCollection) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneCollection) drawHintBar() {
+	genericDrawHintBar()
+}
@@ -0,0 +1,80 @@
+package menu
+
+import (
+	"fmt"
+
+	"github.com/libretro/ludo/input"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/remap"
+	"github.com/libretro/ludo/state"
+)
+
+type sceneControllers struct {
+	entry
+}
+
+func buildControllers() Scene {
+	var list sceneControllers
+	list.label = "Controllers"
+
+	for port := 0; port < input.MaxPlayers; port++ {
+		port := port
+		list.children = append(list.children, entry{
+			label: fmt.Sprintf("Port %d Device", port+1),
+			icon:  "subsetting",
+			stringValue: func() string {
+				return input.DeviceNames[input.Devices[port]]
+			},
+			incr: func(direction int) {
+				device := input.CycleDevice(port, direction)
+				state.Core.SetControllerPortDevice(uint(port), device)
+				if err := remap.Save(state.CorePath, state.GameCRC); err != nil {
+					ntf.DisplayAndLog(ntf.Error, "Menu", "Error saving remap: %v", err.Error())
+				}
+			},
+		})
+	}
+
+	list.segueMount()
+
+	return &list
+}
+
+func (s *sceneControllers) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneControllers) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneControllers) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneControllers) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneControllers) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneControllers) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneControllers) drawHintBar() {
+	w, h := menu.GetFramebufferSize()
+	menu.DrawRect(0, float32(h)-70*menu.ratio, float32(w), 70*menu.ratio, 0, lightGrey)
+
+	_, upDown, leftRight, _, b, _, _, _, _, guide := hintIcons()
+
+	var stack float32
+	if state.CoreRunning {
+		stackHint(&stack, guide, "RESUME", h)
+	}
+	stackHint(&stack, upDown, "NAVIGATE", h)
+	stackHint(&stack, b, "BACK", h)
+	stackHint(&stack, leftRight, "SET", h)
+}
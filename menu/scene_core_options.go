@@ -42,6 +42,9 @@ func buildCoreOptions() Scene {
 					v.Choice = 0
 				}
 				core.Options.Updated = true
+				core.Options.Lock()
+				core.Options.Release(v.Key)
+				core.Options.Unlock()
 				err := core.Options.Save()
 				if err != nil {
 					ntf.DisplayAndLog(ntf.Error, "Core", "Error saving core options: %v", err.Error())
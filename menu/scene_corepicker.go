@@ -0,0 +1,136 @@
+package menu
+
+import (
+	"path/filepath"
+
+	"github.com/libretro/ludo/core"
+	"github.com/libretro/ludo/coreinfo"
+	"github.com/libretro/ludo/crash"
+	"github.com/libretro/ludo/history"
+	"github.com/libretro/ludo/mainthread"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+)
+
+// OpenContent loads a game opened outside of the playlists, from the file
+// explorer or the command line. The running core is kept if it supports the
+// game, otherwise the core is picked from the extension and the system of the
+// game, asking the user when several cores fit.
+func (m *Menu) OpenContent(path string) {
+	if state.Core != nil && coreinfo.Supports(state.Core.GetSystemInfo().ValidExtensions, path) {
+		launchContent(state.CorePath, path)
+		return
+	}
+	// Hashing the game takes a while, the menu keeps running meanwhile
+	db := state.DB
+	go func() {
+		defer crash.Recover()
+		system := coreinfo.Identify(db, path)
+		mainthread.Post(func() {
+			m.openWith(path, coreinfo.Candidates(path, system))
+		})
+	}()
+}
+
+// OpenContentWith loads a game with the given core, or picks the core like
+// OpenContent when none is given
+func (m *Menu) OpenContentWith(corePath, path string) {
+	if corePath == "" {
+		m.OpenContent(path)
+		return
+	}
+	launchContent(corePath, path)
+}
+
+// openWith loads a game with the cores able to run it, asking the user when
+// there are several
+func (m *Menu) openWith(path string, cores []string) {
+	switch len(cores) {
+	case 0:
+		ntf.DisplayAndLog(ntf.Warning, "Menu", "No core found for this game, please load a core first.")
+	case 1:
+		launchContent(cores[0], path)
+	default:
+		if len(m.stack) > 0 {
+			m.stack[len(m.stack)-1].segueNext()
+		}
+		m.Push(buildCorePicker(path, cores))
+	}
+}
+
+// launchContent loads a core, if needed, and a game
+func launchContent(corePath, gamePath string) {
+	if state.CorePath != corePath {
+		if err := core.Load(corePath); err != nil {
+			ntf.DisplayAndLog(ntf.Error, "Core", err.Error())
+			return
+		}
+	}
+	if err := core.LoadGame(gamePath); err != nil {
+		ntf.DisplayAndLog(ntf.Error, "Core", err.Error())
+		return
+	}
+	history.Push(history.Game{
+		Path:     gamePath,
+		Name:     utils.FileName(gamePath),
+		CorePath: state.CorePath,
+	})
+	menu.WarpToQuickMenu()
+	state.MenuActive = false
+}
+
+type sceneCorePicker struct {
+	entry
+}
+
+// buildCorePicker lists the cores able to run a game
+func buildCorePicker(gamePath string, cores []string) Scene {
+	var list sceneCorePicker
+	list.label = "Select Core"
+
+	for _, c := range cores {
+		c := c
+		list.children = append(list.children, entry{
+			label: prettifyCoreName(utils.FileName(c)),
+			icon:  "subsetting",
+			stringValue: func() string {
+				return filepath.Base(c)
+			},
+			callbackOK: func() {
+				launchContent(c, gamePath)
+			},
+		})
+	}
+
+	list.segueMount()
+	return &list
+}
+
+func (s *sceneCorePicker) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneCorePicker) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneCorePicker) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneCorePicker) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneCorePicker) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneCorePicker) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneCorePicker) drawHintBar() {
+	genericDrawHintBar()
+}
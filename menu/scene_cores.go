@@ -0,0 +1,309 @@
+package menu
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/libretro/ludo/buildbot"
+	"github.com/libretro/ludo/coreinfo"
+	"github.com/libretro/ludo/envaudit"
+	"github.com/libretro/ludo/logs"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+)
+
+type sceneCores struct {
+	entry
+}
+
+// buildCores lists the installed cores with their size
+func buildCores() Scene {
+	var list sceneCores
+	list.label = "Installed Cores"
+	list.fill()
+	list.segueMount()
+	return &list
+}
+
+// coreLabel is the display name of a core, or its file name
+func coreLabel(path string) string {
+	if name := coreinfo.Lookup(path).Name; name != "" {
+		return name
+	}
+	return utils.FileName(path)
+}
+
+// coreSize is the size of a core file
+func coreSize(path string) string {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return utils.HumanSize(fi.Size())
+}
+
+// fill lists the cores of the cores directory
+func (s *sceneCores) fill() {
+	s.children = []entry{}
+	for _, path := range coreinfo.Installed() {
+		path := path
+		s.children = append(s.children, entry{
+			label: coreLabel(path),
+			icon:  "subsetting",
+			stringValue: func() string {
+				return coreSize(path)
+			},
+			callbackOK: func() {
+				s.segueNext()
+				menu.Push(buildCore(s, path))
+			},
+		})
+	}
+	if len(s.children) == 0 {
+		s.children = append(s.children, entry{
+			label: "No cores installed",
+			icon:  "subsetting",
+		})
+	}
+}
+
+func (s *sceneCores) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneCores) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneCores) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneCores) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneCores) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneCores) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneCores) drawHintBar() {
+	genericDrawHintBar()
+}
+
+// uninstallCore removes a core, and its configs if asked, then goes back to
+// the list of cores
+func uninstallCore(parent *sceneCores, path string, configs bool) {
+	if path == state.CorePath {
+		ntf.DisplayAndLog(ntf.Error, "Menu", "Close the running game before uninstalling its core.")
+		return
+	}
+	if err := coreinfo.Uninstall(path, configs); err != nil {
+		ntf.DisplayAndLog(ntf.Error, "Menu", "Could not uninstall the core: %v", err.Error())
+		return
+	}
+	if err := buildbot.Forget(utils.FileName(path)); err != nil {
+		logs.Warnf("Menu", "Could not forget the build of the core: %v", err)
+	}
+	ntf.DisplayAndLog(ntf.Success, "Menu", "%s uninstalled.", coreLabel(path))
+	menu.stack = menu.stack[:len(menu.stack)-1]
+	parent.fill()
+	if parent.ptr >= len(parent.children) {
+		parent.ptr = len(parent.children) - 1
+	}
+	parent.segueBack()
+}
+
+type sceneCore struct {
+	entry
+}
+
+// buildCore shows the details of an installed core, the systems it supports
+// and offers to uninstall it
+func buildCore(parent *sceneCores, path string) Scene {
+	var list sceneCore
+	list.label = coreLabel(path)
+
+	list.children = append(list.children, entry{
+		label:       "File",
+		icon:        "subsetting",
+		stringValue: func() string { return utils.FileName(path) },
+	})
+
+	list.children = append(list.children, entry{
+		label: "Version",
+		icon:  "subsetting",
+		stringValue: func() string {
+			if v := coreinfo.Version(path); v != "" {
+				return v
+			}
+			return "Unknown"
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label:       "Size",
+		icon:        "subsetting",
+		stringValue: func() string { return coreSize(path) },
+	})
+
+	list.children = append(list.children, entry{
+		label: "Last Used",
+		icon:  "subsetting",
+		stringValue: func() string {
+			u := coreinfo.LastUsage(path)
+			if u.LastUsed.IsZero() {
+				return "Never"
+			}
+			return u.LastUsed.Format("2006-01-02 15:04")
+		},
+	})
+
+	for _, system := range coreinfo.Lookup(path).Systems {
+		list.children = append(list.children, entry{
+			label: system,
+			icon:  system + "-content",
+		})
+	}
+
+	if calls := envaudit.Report(path); len(calls) > 0 {
+		list.children = append(list.children, entry{
+			label: "Environment Report",
+			icon:  "subsetting",
+			stringValue: func() string {
+				return fmt.Sprintf("%d not implemented", envaudit.Missing(calls))
+			},
+			callbackOK: func() {
+				list.segueNext()
+				menu.Push(buildEnvironmentReport(coreLabel(path), calls))
+			},
+		})
+	}
+
+	list.children = append(list.children, entry{
+		label: "Uninstall",
+		icon:  "subsetting",
+		callbackOK: func() {
+			askUninstallCoreConfirmation("The configs of the core will be kept.", func() {
+				uninstallCore(parent, path, false)
+			})
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Uninstall With Configs",
+		icon:  "subsetting",
+		callbackOK: func() {
+			askUninstallCoreConfirmation("Its options, cheats, macros, overrides, remaps, shaders, viewports and volumes will be removed.", func() {
+				uninstallCore(parent, path, true)
+			})
+		},
+	})
+
+	list.segueMount()
+
+	return &list
+}
+
+func (s *sceneCore) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneCore) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneCore) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneCore) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneCore) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneCore) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneCore) drawHintBar() {
+	genericDrawHintBar()
+}
+
+type sceneEnvironmentReport struct {
+	entry
+}
+
+// buildEnvironmentReport lists the environment commands called by a core,
+// the ones Ludo doesn't implement first
+func buildEnvironmentReport(label string, calls []envaudit.Call) Scene {
+	var list sceneEnvironmentReport
+	list.label = label
+
+	for _, c := range calls {
+		c := c
+		icon := "subsetting"
+		if !c.Implemented {
+			icon = "menu_exit"
+		}
+		list.children = append(list.children, entry{
+			label: c.Name,
+			icon:  icon,
+			stringValue: func() string {
+				if !c.Implemented {
+					return fmt.Sprintf("Not implemented, %d calls", c.Count)
+				}
+				if c.Failed > 0 {
+					return fmt.Sprintf("%d calls, %d failed", c.Count, c.Failed)
+				}
+				return fmt.Sprintf("%d calls", c.Count)
+			},
+		})
+	}
+	if len(list.children) == 0 {
+		list.children = append(list.children, entry{
+			label: "No environment calls recorded",
+			icon:  "subsetting",
+		})
+	}
+
+	list.segueMount()
+
+	return &list
+}
+
+func (s *sceneEnvironmentReport) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneEnvironmentReport) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneEnvironmentReport) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneEnvironmentReport) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneEnvironmentReport) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneEnvironmentReport) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneEnvironmentReport) drawHintBar() {
+	genericDrawHintBar()
+}
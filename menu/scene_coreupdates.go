@@ -0,0 +1,211 @@
+package menu
+
+import (
+	"fmt"
+
+	"github.com/libretro/ludo/buildbot"
+	"github.com/libretro/ludo/crash"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+)
+
+// sceneCoreUpdates is refilled by update when a background check or update
+// is over, the tweens aren't safe to use from other goroutines
+type sceneCoreUpdates struct {
+	entry
+	refresh bool
+}
+
+// buildCoreUpdates lists the installed cores with a newer build on the
+// buildbot, found by the last check
+func buildCoreUpdates() Scene {
+	var list sceneCoreUpdates
+	list.label = "Core Updates"
+	list.fill()
+	list.segueMount()
+	return &list
+}
+
+// fill lists the updates found by the last check
+func (s *sceneCoreUpdates) fill() {
+	s.children = []entry{{
+		label: "Check For Updates",
+		icon:  "reload",
+		callbackOK: func() {
+			ntf.DisplayAndLog(ntf.Info, "Menu", "Checking core updates.")
+			go func() {
+				defer crash.Recover()
+				updates, err := buildbot.CheckUpdates()
+				if err != nil {
+					ntf.DisplayAndLog(ntf.Error, "Menu", "Could not check the core updates: %v", err.Error())
+					return
+				}
+				ntf.DisplayAndLog(ntf.Success, "Menu", "%d core updates available.", len(updates))
+				s.refresh = true
+			}()
+		},
+	}}
+
+	updates := buildbot.Updates()
+	if len(updates) == 0 {
+		return
+	}
+
+	s.children = append(s.children, entry{
+		label: "Update All",
+		icon:  "menu_saving",
+		stringValue: func() string {
+			return fmt.Sprintf("%d cores", len(updates))
+		},
+		callbackOK: func() {
+			running := utils.FileName(state.CorePath)
+			go func() {
+				defer crash.Recover()
+				buildbot.UpdateCores(updates, running)
+				s.refresh = true
+			}()
+		},
+	})
+
+	for _, u := range updates {
+		u := u
+		s.children = append(s.children, entry{
+			label: u.Core,
+			icon:  "subsetting",
+			stringValue: func() string {
+				return u.Latest.Date
+			},
+			callbackOK: func() {
+				s.segueNext()
+				menu.Push(buildCoreChangelog(s, u))
+			},
+		})
+	}
+}
+
+func (s *sceneCoreUpdates) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneCoreUpdates) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneCoreUpdates) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneCoreUpdates) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneCoreUpdates) update(dt float32) {
+	if s.refresh {
+		s.refresh = false
+		s.fill()
+		if s.ptr >= len(s.children) {
+			s.ptr = 0
+		}
+		genericAnimate(&s.entry)
+	}
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneCoreUpdates) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneCoreUpdates) drawHintBar() {
+	genericDrawHintBar()
+}
+
+type sceneCoreChangelog struct {
+	entry
+	changes chan []string
+}
+
+// buildCoreChangelog shows the changes of a core since its installed build,
+// fetched in the background, and offers to update it
+func buildCoreChangelog(parent *sceneCoreUpdates, u buildbot.Update) Scene {
+	var list sceneCoreChangelog
+	list.label = u.Core
+
+	list.children = append(list.children, entry{
+		label: "Update",
+		icon:  "menu_saving",
+		stringValue: func() string {
+			return u.Latest.Date
+		},
+		callbackOK: func() {
+			running := utils.FileName(state.CorePath)
+			go func() {
+				defer crash.Recover()
+				buildbot.UpdateCores([]buildbot.Update{u}, running)
+				parent.refresh = true
+			}()
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Loading changelog",
+		icon:  "reload",
+	})
+
+	list.segueMount()
+
+	list.changes = make(chan []string, 1)
+	go func() {
+		defer crash.Recover()
+		changes, _ := buildbot.Changelog(u)
+		list.changes <- changes
+	}()
+
+	return &list
+}
+
+func (s *sceneCoreChangelog) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneCoreChangelog) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneCoreChangelog) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneCoreChangelog) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneCoreChangelog) update(dt float32) {
+	select {
+	case changes := <-s.changes:
+		s.children = s.children[:1]
+		if len(changes) == 0 {
+			s.children = append(s.children, entry{
+				label: "No changelog available",
+				icon:  "subsetting",
+			})
+		}
+		for _, c := range changes {
+			s.children = append(s.children, entry{
+				label: c,
+				icon:  "subsetting",
+			})
+		}
+		genericAnimate(&s.entry)
+	default:
+	}
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneCoreChangelog) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneCoreChangelog) drawHintBar() {
+	genericDrawHintBar()
+}
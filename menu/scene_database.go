@@ -0,0 +1,84 @@
+package menu
+
+import (
+	"fmt"
+
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+)
+
+type sceneDatabase struct {
+	entry
+}
+
+// buildDatabase shows the statistics of the game database
+func buildDatabase() Scene {
+	var list sceneDatabase
+	list.label = "Database"
+
+	s := state.DB.Stats()
+	list.children = append(list.children, entry{
+		label: "Total",
+		icon:  "subsetting",
+		stringValue: func() string {
+			return fmt.Sprintf("%d games, %d ROMs, %s", s.Games, s.ROMs, utils.HumanSize(s.Size))
+		},
+	})
+	list.children = append(list.children, entry{
+		label: "Memory",
+		icon:  "subsetting",
+		stringValue: func() string {
+			return utils.HumanSize(s.Memory)
+		},
+	})
+
+	for _, ss := range s.Systems {
+		ss := ss
+		list.children = append(list.children, entry{
+			label: ss.System,
+			icon:  "subsetting",
+			stringValue: func() string {
+				return fmt.Sprintf("%d games, %s", ss.Games, utils.HumanSize(ss.Size))
+			},
+		})
+	}
+
+	if len(s.Systems) == 0 {
+		list.children = append(list.children, entry{
+			label: "No database loaded",
+			icon:  "close",
+		})
+	}
+
+	list.segueMount()
+
+	return &list
+}
+
+func (s *sceneDatabase) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneDatabase) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneDatabase) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneDatabase) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneDatabase) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneDatabase) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneDatabase) drawHintBar() {
+	genericDrawHintBar()
+}
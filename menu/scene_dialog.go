@@ -2,6 +2,7 @@ package menu
 
 import (
 	"github.com/libretro/ludo/audio"
+	"github.com/libretro/ludo/i18n"
 	"github.com/libretro/ludo/input"
 	"github.com/libretro/ludo/libretro"
 )
@@ -70,14 +71,15 @@ func (s *sceneDialog) render() {
 		white,
 	)
 
+	title, line1, line2 := i18n.T(s.title), i18n.T(s.line1), i18n.T(s.line2)
 	menu.Font.SetColor(orange)
-	lw1 := menu.Font.Width(0.7*menu.ratio, s.title)
-	menu.Font.Printf(fw/2-lw1/2, fh/2-120*menu.ratio+20*menu.ratio, 0.7*menu.ratio, s.title)
+	lw1 := menu.Font.Width(0.7*menu.ratio, title)
+	menu.Font.Printf(fw/2-lw1/2, fh/2-120*menu.ratio+20*menu.ratio, 0.7*menu.ratio, title)
 	menu.Font.SetColor(black)
-	lw2 := menu.Font.Width(0.5*menu.ratio, s.line1)
-	menu.Font.Printf(fw/2-lw2/2, fh/2-30*menu.ratio+20*menu.ratio, 0.5*menu.ratio, s.line1)
-	lw3 := menu.Font.Width(0.5*menu.ratio, s.line2)
-	menu.Font.Printf(fw/2-lw3/2, fh/2+30*menu.ratio+20*menu.ratio, 0.5*menu.ratio, s.line2)
+	lw2 := menu.Font.Width(0.5*menu.ratio, line1)
+	menu.Font.Printf(fw/2-lw2/2, fh/2-30*menu.ratio+20*menu.ratio, 0.5*menu.ratio, line1)
+	lw3 := menu.Font.Width(0.5*menu.ratio, line2)
+	menu.Font.Printf(fw/2-lw3/2, fh/2+30*menu.ratio+20*menu.ratio, 0.5*menu.ratio, line2)
 
 	menu.Font.SetColor(darkGrey)
 
@@ -94,7 +96,7 @@ func (s *sceneDialog) render() {
 		fw/2-width/2*menu.ratio+margin*menu.ratio+70*menu.ratio,
 		fh/2+height/2*menu.ratio-23*menu.ratio-margin*menu.ratio,
 		0.4*menu.ratio,
-		"NO")
+		i18n.T("NO"))
 
 	menu.DrawImage(
 		a,
@@ -105,7 +107,7 @@ func (s *sceneDialog) render() {
 		fw/2+width/2*menu.ratio-150*menu.ratio-margin*menu.ratio+70*menu.ratio,
 		fh/2+height/2*menu.ratio-23*menu.ratio-margin*menu.ratio,
 		0.4*menu.ratio,
-		"YES")
+		i18n.T("YES"))
 }
 
 func (s *sceneDialog) drawHintBar() {
@@ -2,7 +2,6 @@ package menu
 
 import (
 	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -10,6 +9,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/libretro/ludo/logs"
 	ntf "github.com/libretro/ludo/notifications"
 	"github.com/libretro/ludo/settings"
 	"github.com/libretro/ludo/utils"
@@ -23,12 +23,15 @@ type sceneExplorer struct {
 type Prettifier func(string) string
 
 func matchesExtensions(f os.FileInfo, exts []string) bool {
-	if len(exts) > 0 {
-		var fileExtension = filepath.Ext(f.Name())
-		for _, ext := range exts {
-			if ext == fileExtension {
-				return true
-			}
+	return matchesName(f.Name(), exts)
+}
+
+// matchesName tells if a file name has one of the extensions, ignoring case
+func matchesName(name string, exts []string) bool {
+	var fileExtension = filepath.Ext(name)
+	for _, ext := range exts {
+		if strings.EqualFold(ext, fileExtension) {
+			return true
 		}
 	}
 	return false
@@ -80,8 +83,11 @@ func appendNode(list *sceneExplorer, fullPath string, name string, f os.FileInfo
 		return
 	}
 
+	// Archives are browsed like folders, unless a directory is being picked
+	archive := dirAction == nil && !f.IsDir() && isArchive(name)
+
 	// Filter files by extension.
-	if exts != nil && !f.IsDir() && !matchesExtensions(f, exts) {
+	if exts != nil && !f.IsDir() && !archive && !matchesExtensions(f, exts) {
 		return
 	}
 
@@ -91,32 +97,50 @@ func appendNode(list *sceneExplorer, fullPath string, name string, f os.FileInfo
 		displayName = prettifier(utils.FileName(name))
 	}
 
-	list.children = append(list.children, entry{
+	e := entry{
 		label: displayName,
 		icon:  explorerIcon(f),
 		callbackOK: func() {
-			if f.IsDir() {
+			if archive {
+				list.segueNext()
+				menu.Push(buildArchiveExplorer(filepath.Clean(fullPath), exts, cb))
+			} else if f.IsDir() {
 				list.segueNext()
 				newPath := filepath.Clean(fullPath)
 				if dirAction != nil {
 					dirAction.callbackOK = func() { cb(newPath) }
 				}
 				menu.Push(buildExplorer(newPath, exts, cb, dirAction, prettifier))
-			} else if cb != nil && (exts == nil || utils.StringInSlice(filepath.Ext(name), exts)) {
+			} else if cb != nil && (exts == nil || matchesExtensions(f, exts)) {
 				cb(filepath.Clean(fullPath))
 			}
 		},
-	})
+	}
+	if archive {
+		e.icon = "folder"
+	}
+	if !f.IsDir() {
+		e.stringValue = func() string {
+			return utils.HumanSize(f.Size())
+		}
+	}
+	list.children = append(list.children, e)
 }
 
 func buildExplorer(path string, exts []string, cb func(string), dirAction *entry, prettifier Prettifier) Scene {
 	var list sceneExplorer
 	list.label = "Explorer"
 
-	// Display the special directory action entry.
+	// Display the special directory action entry. Its children are other
+	// actions on the directory, calling their callbackDir.
 	if dirAction != nil && dirAction.label != "" {
 		dirAction.callbackOK = func() { cb(path) }
 		list.children = append(list.children, *dirAction)
+		for _, action := range dirAction.children {
+			action := action
+			action.callbackOK = func() { action.callbackDir(path) }
+			list.children = append(list.children, action)
+		}
 	}
 
 	if path == "/" {
@@ -156,12 +180,12 @@ func buildExplorer(path string, exts []string, cb func(string), dirAction *entry
 		f := f
 		fullPath, err := filepath.EvalSymlinks(filepath.Join(path, f.Name()))
 		if err != nil {
-			log.Println(err)
+			logs.Warnf("Menu", "%v", err)
 			continue
 		}
 		fi, err := os.Stat(fullPath)
 		if err != nil {
-			log.Println(err)
+			logs.Warnf("Menu", "%v", err)
 			continue
 		}
 		appendNode(&list, fullPath, f.Name(), fi, exts, cb, dirAction, prettifier)
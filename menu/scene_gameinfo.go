@@ -0,0 +1,99 @@
+package menu
+
+import (
+	"fmt"
+
+	"github.com/libretro/ludo/stats"
+)
+
+type sceneGameInfo struct {
+	entry
+}
+
+// buildGameInfo shows the play statistics of a game of a playlist, and the
+// ones of the whole library
+func buildGameInfo(label, path string) Scene {
+	var list sceneGameInfo
+	list.label = label
+
+	g := stats.Get(path)
+	list.children = append(list.children, entry{
+		label: "Sessions",
+		icon:  "subsetting",
+		stringValue: func() string {
+			return fmt.Sprintf("%d", g.Sessions)
+		},
+	})
+	list.children = append(list.children, entry{
+		label: "Play Time",
+		icon:  "subsetting",
+		stringValue: func() string {
+			return stats.Playtime(g.Seconds)
+		},
+	})
+	list.children = append(list.children, entry{
+		label: "Last Played",
+		icon:  "subsetting",
+		stringValue: func() string {
+			if g.LastPlayed.IsZero() {
+				return "Never"
+			}
+			return g.LastPlayed.Format("2006-01-02 15:04")
+		},
+	})
+	list.children = append(list.children, entry{
+		label: "Achievements Earned",
+		icon:  "subsetting",
+		stringValue: func() string {
+			return fmt.Sprintf("%d", g.Achievements)
+		},
+	})
+
+	t := stats.Totals()
+	list.children = append(list.children, entry{
+		label: "All Games",
+		icon:  "subsetting",
+		stringValue: func() string {
+			return fmt.Sprintf("%d games, %d sessions, %s", t.Games, t.Sessions, stats.Playtime(t.Seconds))
+		},
+	})
+	list.children = append(list.children, entry{
+		label: "All Achievements Earned",
+		icon:  "subsetting",
+		stringValue: func() string {
+			return fmt.Sprintf("%d", t.Achievements)
+		},
+	})
+
+	list.segueMount()
+
+	return &list
+}
+
+func (s *sceneGameInfo) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneGameInfo) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneGameInfo) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneGameInfo) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneGameInfo) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneGameInfo) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneGameInfo) drawHintBar() {
+	genericDrawHintBar()
+}
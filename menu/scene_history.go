@@ -7,6 +7,7 @@ import (
 	"github.com/libretro/ludo/core"
 	"github.com/libretro/ludo/history"
 	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/savestates"
 	"github.com/libretro/ludo/state"
 )
 
@@ -46,27 +47,53 @@ func buildHistory() Scene {
 }
 
 func loadHistoryEntry(list Scene, game history.Game) {
+	if restrictedGame(game.Path, game.System) {
+		list.segueNext()
+		askPIN(func() { launchHistoryEntry(list, game) })
+		return
+	}
+	launchHistoryEntry(list, game)
+}
+
+// launchHistoryEntry loads a game of the history with the core it was
+// played with
+func launchHistoryEntry(list Scene, game history.Game) {
+	running := state.GamePath == game.Path
+	if !openHistoryGame(game) {
+		return
+	}
+	list.segueNext()
+	menu.Push(buildQuickMenu())
+	if !running {
+		menu.tweens.FastForward() // position the elements without animating
+		state.MenuActive = false
+	}
+}
+
+// openHistoryGame loads a game of the history and its core, unless it is
+// already running. It reports whether the game is running.
+func openHistoryGame(game history.Game) bool {
 	if _, err := os.Stat(game.Path); os.IsNotExist(err) {
 		ntf.DisplayAndLog(ntf.Error, "Menu", "Game not found.")
-		return
+		return false
 	}
 	corePath := game.CorePath
 	if _, err := os.Stat(corePath); os.IsNotExist(err) {
 		ntf.DisplayAndLog(ntf.Error, "Menu", "Core not found: %s", filepath.Base(corePath))
-		return
+		return false
 	}
 	if state.CorePath != corePath {
 		err := core.Load(corePath)
 		if err != nil {
 			ntf.DisplayAndLog(ntf.Error, "Menu", err.Error())
-			return
+			return false
 		}
 	}
 	if state.GamePath != game.Path {
 		err := core.LoadGame(game.Path)
 		if err != nil {
 			ntf.DisplayAndLog(ntf.Error, "Menu", err.Error())
-			return
+			return false
 		}
 		history.Push(history.Game{
 			Path:     game.Path,
@@ -74,13 +101,41 @@ func loadHistoryEntry(list Scene, game history.Game) {
 			System:   game.System,
 			CorePath: corePath,
 		})
-		list.segueNext()
-		menu.Push(buildQuickMenu())
-		menu.tweens.FastForward() // position the elements without animating
-		state.MenuActive = false
-	} else {
-		list.segueNext()
-		menu.Push(buildQuickMenu())
+	}
+	return true
+}
+
+// continueEntry is the tab resuming the last played game
+func continueEntry() entry {
+	name, _ := extractTags(history.List[0].Name)
+	return entry{
+		label:    "Continue",
+		subLabel: name,
+		icon:     "history",
+		callbackOK: func() {
+			game := history.List[0]
+			unlockParental(restrictedGame(game.Path, game.System), func() { continueGame(game) })
+		},
+	}
+}
+
+// continueGame resumes a game from its most recent savestate and closes the
+// menu. A game that is already running is just resumed.
+func continueGame(game history.Game) {
+	running := state.GamePath == game.Path
+	if !openHistoryGame(game) {
+		menu.stack[0].segueBack()
+		return
+	}
+	menu.WarpToQuickMenu()
+	state.MenuActive = false
+	if running {
+		return
+	}
+	if paths := savestatePaths(); len(paths) > 0 {
+		if err := savestates.Load(paths[0]); err != nil {
+			ntf.DisplayAndLog(ntf.Error, "Menu", err.Error())
+		}
 	}
 }
 
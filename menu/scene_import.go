@@ -0,0 +1,108 @@
+package menu
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/retroarch"
+	"github.com/libretro/ludo/state"
+)
+
+type sceneImport struct {
+	entry
+}
+
+// importRetroArch opens an explorer to pick the retroarch.cfg to import,
+// starting in the RetroArch directory if it exists
+func importRetroArch(list Scene) {
+	dir := filepath.Dir(retroarch.DefaultConfig())
+	if _, err := os.Stat(dir); err != nil {
+		usr, _ := user.Current()
+		dir = usr.HomeDir
+	}
+	list.segueNext()
+	menu.Push(buildExplorer(dir, []string{".cfg"}, func(path string) {
+		r, err := retroarch.Import(path)
+		if err != nil {
+			ntf.DisplayAndLog(ntf.Error, "Menu", "Error importing RetroArch settings: %v", err)
+			return
+		}
+		ntf.DisplayAndLog(ntf.Success, "Menu", "%d RetroArch settings imported.", len(r.Migrated))
+		menu.stack[len(menu.stack)-1].segueNext()
+		menu.Push(buildImport(r))
+	}, nil, nil))
+}
+
+// buildImport lists what was migrated from RetroArch, then what wasn't
+func buildImport(r retroarch.Report) Scene {
+	var list sceneImport
+	list.label = "RetroArch Import"
+
+	for _, item := range r.Migrated {
+		item := item
+		list.children = append(list.children, entry{
+			label:       item.Key,
+			icon:        "subsetting",
+			stringValue: func() string { return item.Note },
+		})
+	}
+	for _, item := range r.Skipped {
+		item := item
+		list.children = append(list.children, entry{
+			label:       item.Key,
+			icon:        "close",
+			stringValue: func() string { return item.Note },
+		})
+	}
+
+	if len(list.children) == 0 {
+		list.children = append(list.children, entry{
+			label: "Nothing to import",
+			icon:  "subsetting",
+		})
+	}
+
+	list.segueMount()
+
+	return &list
+}
+
+func (s *sceneImport) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneImport) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneImport) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneImport) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneImport) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneImport) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneImport) drawHintBar() {
+	w, h := menu.GetFramebufferSize()
+	menu.DrawRect(0, float32(h)-70*menu.ratio, float32(w), 70*menu.ratio, 0, lightGrey)
+
+	_, upDown, _, _, b, _, _, _, _, guide := hintIcons()
+
+	var stack float32
+	if state.CoreRunning {
+		stackHint(&stack, guide, "RESUME", h)
+	}
+	stackHint(&stack, upDown, "NAVIGATE", h)
+	stackHint(&stack, b, "BACK", h)
+}
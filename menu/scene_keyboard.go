@@ -1,9 +1,15 @@
 package menu
 
 import (
+	"strings"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+
 	"github.com/libretro/ludo/audio"
+	"github.com/libretro/ludo/i18n"
 	"github.com/libretro/ludo/input"
 	"github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/settings"
 	"github.com/libretro/ludo/video"
 	"github.com/tanema/gween"
 	"github.com/tanema/gween/ease"
@@ -12,37 +18,109 @@ import (
 type sceneKeyboard struct {
 	entry
 	index        int
-	layout       int
+	symbols      bool // the symbols are shown instead of the letters
+	shift        bool
+	secret       bool // the value is a password, it is masked
 	value        string
 	y            float32
 	alpha        float32
 	callbackDone func(string)
+	afterClose   bool // callbackDone runs once the keyboard is closed
+}
+
+// keyboardLayouts are the rows of letters of the keyboard layouts, below the
+// row of digits
+var keyboardLayouts = map[string][]string{
+	"QWERTY": {"qwertyuiop", "asdfghjkl@", "zxcvbnm,-."},
+	"AZERTY": {"azertyuiop", "qsdfghjklm", "wxcvbn,.-@"},
+	"QWERTZ": {"qwertzuiop", "asdfghjkl@", "yxcvbnm,-."},
 }
 
-var layouts = [][]string{
-	{
-		"1", "2", "3", "4", "5", "6", "7", "8", "9", "0",
-		"q", "w", "e", "r", "t", "y", "u", "i", "o", "p",
-		"a", "s", "d", "f", "g", "h", "j", "k", "l", "@",
-		"z", "x", "c", "v", "b", "n", "m", " ", "-", ".",
-	},
-	{
-		"1", "2", "3", "4", "5", "6", "7", "8", "9", "0",
-		"Q", "W", "E", "R", "T", "Y", "U", "I", "O", "P",
-		"A", "S", "D", "F", "G", "H", "J", "K", "L", "+",
-		"Z", "X", "C", "V", "B", "N", "M", " ", "_", "/",
-	},
-	{
-		"1", "2", "3", "4", "5", "6", "7", "8", "9", "0",
-		"!", "\"", "#", "$", "%%", "&", "'", "*", "(", ")",
-		"+", ",", "-", "~", "/", ":", ";", "=", "<", ">",
-		"?", "@", "[", "\\", "]", "^", "_", "|", "{", "}",
-	},
+// keyboardLayoutNames are the keyboard layouts in the order of the settings
+var keyboardLayoutNames = []string{"QWERTY", "AZERTY", "QWERTZ"}
+
+var symbols = []string{
+	"1", "2", "3", "4", "5", "6", "7", "8", "9", "0",
+	"!", "\"", "#", "$", "%", "&", "'", "*", "(", ")",
+	"+", ",", "-", "~", "/", ":", ";", "=", "<", ">",
+	"?", "@", "[", "\\", "]", "^", "_", "|", "{", "}",
+}
+
+// The last row of the keyboard has wide keys, spanning two columns each
+const (
+	keyShift = iota
+	keySymbols
+	keySpace
+	keyPaste
+	keyDone
+)
+
+const keysPerRow = 10
+const actionRow = 4 * keysPerRow
+
+// keys returns the characters of the keyboard, row by row
+func keys(layout string, symbolsPage, shift bool) []string {
+	if symbolsPage {
+		return symbols
+	}
+	rows, ok := keyboardLayouts[layout]
+	if !ok {
+		rows = keyboardLayouts["QWERTY"]
+	}
+	list := strings.Split("1234567890", "")
+	for _, row := range rows {
+		if shift {
+			row = strings.ToUpper(row)
+		}
+		list = append(list, strings.Split(row, "")...)
+	}
+	return list
+}
+
+// moveKey moves the cursor on the keyboard grid, wrapping around. The wide
+// keys of the last row are addressed by their left column.
+func moveKey(index, dx, dy int) int {
+	row, col := index/keysPerRow, index%keysPerRow
+	rows := actionRow/keysPerRow + 1
+	if row == rows-1 && dx != 0 {
+		col = (col + 2*dx + keysPerRow) % keysPerRow
+	} else {
+		col = (col + dx + keysPerRow) % keysPerRow
+	}
+	row = (row + dy + rows) % rows
+	if row == rows-1 {
+		col -= col % 2
+	}
+	return row*keysPerRow + col
+}
+
+// actionLabels are the labels of the wide keys
+func (s *sceneKeyboard) actionLabels() []string {
+	letters := "Symbols"
+	if s.symbols {
+		letters = "Letters"
+	}
+	return []string{"Shift", letters, "Space", "Paste", "Done"}
 }
 
 func buildKeyboard(label string, callbackDone func(string)) Scene {
+	return newKeyboard(label, "", false, callbackDone)
+}
+
+// buildKeyboardThen builds a keyboard whose callback runs once it is closed,
+// so that the callback can push another scene
+func buildKeyboardThen(label string, callbackDone func(string)) Scene {
+	k := newKeyboard(label, "", false, callbackDone)
+	k.afterClose = true
+	return k
+}
+
+// newKeyboard builds a keyboard to edit a value. A secret value is masked.
+func newKeyboard(label, value string, secret bool, callbackDone func(string)) *sceneKeyboard {
 	var list sceneKeyboard
 	list.label = label
+	list.value = value
+	list.secret = secret
 	list.callbackDone = callbackDone
 
 	list.segueMount()
@@ -68,67 +146,91 @@ func (s *sceneKeyboard) segueNext() {
 func (s *sceneKeyboard) segueBack() {
 }
 
+// press types the key under the cursor, or runs the action of a wide key
+func (s *sceneKeyboard) press() {
+	if s.index < actionRow {
+		s.value += keys(settings.Current.KeyboardLayout, s.symbols, s.shift)[s.index]
+		return
+	}
+	switch (s.index - actionRow) / 2 {
+	case keyShift:
+		s.shift = !s.shift
+	case keySymbols:
+		s.symbols = !s.symbols
+	case keySpace:
+		s.value += " "
+	case keyPaste:
+		s.value += strings.Join(strings.Fields(glfw.GetClipboardString()), " ")
+	case keyDone:
+		s.done()
+	}
+}
+
+// done closes the keyboard and passes the value to the callback
+func (s *sceneKeyboard) done() {
+	if s.value == "" {
+		return
+	}
+	audio.PlayEffect(audio.Effects["notice"])
+	if !s.afterClose {
+		s.callbackDone(s.value)
+	}
+	menu.stack[len(menu.stack)-2].segueBack()
+	menu.stack = menu.stack[:len(menu.stack)-1]
+	if s.afterClose {
+		s.callbackDone(s.value)
+	}
+}
+
 func (s *sceneKeyboard) update(dt float32) {
 	// Right
 	repeatRight(dt, input.NewState[0][libretro.DeviceIDJoypadRight] == 1, func() {
 		audio.PlayEffect(audio.Effects["up"])
-		if (s.index+1)%10 == 0 {
-			s.index -= 9
-		} else {
-			s.index++
-		}
+		s.index = moveKey(s.index, 1, 0)
 	})
 
 	// Left
 	repeatLeft(dt, input.NewState[0][libretro.DeviceIDJoypadLeft] == 1, func() {
 		audio.PlayEffect(audio.Effects["down"])
-		if s.index%10 == 0 {
-			s.index += 9
-		} else {
-			s.index--
-		}
+		s.index = moveKey(s.index, -1, 0)
 	})
 
 	// Up
 	repeatUp(dt, input.NewState[0][libretro.DeviceIDJoypadUp] == 1, func() {
 		audio.PlayEffect(audio.Effects["up"])
-		if s.index < 10 {
-			s.index += len(layouts[s.layout]) - 10
-		} else {
-			s.index -= 10
-		}
+		s.index = moveKey(s.index, 0, -1)
 	})
 
 	// Down
 	repeatDown(dt, input.NewState[0][libretro.DeviceIDJoypadDown] == 1, func() {
 		audio.PlayEffect(audio.Effects["down"])
-		if s.index >= len(layouts[s.layout])-10 {
-			s.index -= len(layouts[s.layout]) - 10
-		} else {
-			s.index += 10
-		}
+		s.index = moveKey(s.index, 0, 1)
 	})
 
 	// OK
 	if input.Released[0][libretro.DeviceIDJoypadA] == 1 {
 		audio.PlayEffect(audio.Effects["ok"])
-		s.value += layouts[s.layout][s.index]
+		s.press()
 	}
 
-	// Switch layout
+	// Shift
 	if input.Released[0][libretro.DeviceIDJoypadX] == 1 {
 		audio.PlayEffect(audio.Effects["ok"])
-		s.layout++
-		if s.layout >= len(layouts) {
-			s.layout = 0
-		}
+		s.shift = !s.shift
+	}
+
+	// Switch between letters and symbols
+	if input.Released[0][libretro.DeviceIDJoypadSelect] == 1 {
+		audio.PlayEffect(audio.Effects["ok"])
+		s.symbols = !s.symbols
 	}
 
 	// Delete character
 	repeatY(dt, input.NewState[0][libretro.DeviceIDJoypadY] == 1, func() {
 		if len(s.value) > 0 {
 			audio.PlayEffect(audio.Effects["cancel"])
-			s.value = s.value[:len(s.value)-1]
+			r := []rune(s.value)
+			s.value = string(r[:len(r)-1])
 		}
 	})
 
@@ -140,17 +242,33 @@ func (s *sceneKeyboard) update(dt float32) {
 	}
 
 	// Done
-	if input.Released[0][libretro.DeviceIDJoypadStart] == 1 && s.value != "" {
-		audio.PlayEffect(audio.Effects["notice"])
-		s.callbackDone(s.value)
-		menu.stack[len(menu.stack)-2].segueBack()
-		menu.stack = menu.stack[:len(menu.stack)-1]
+	if input.Released[0][libretro.DeviceIDJoypadStart] == 1 {
+		s.done()
+	}
+}
+
+// drawKey draws a key of the keyboard, focused or not
+func drawKey(x, y, width, ksz float32, label string, focused bool) {
+	c1 := video.Color{R: 0.15, G: 0.15, B: 0.15, A: 1}
+	c2 := video.Color{R: 0.25, G: 0.25, B: 0.25, A: 1}
+	if focused {
+		c1 = video.Color{R: 0.35, G: 0.35, B: 0.35, A: 1}
+		c2 = video.Color{R: 0.45, G: 0.45, B: 0.45, A: 1}
 	}
+
+	menu.DrawRect(x, y, width, ksz, 0.2, c1)
+	menu.DrawRect(x, y, width, ksz*0.95, 0.2, c2)
+
+	gw := menu.Font.Width(ksz/200, "%s", label)
+	menu.Font.Printf(
+		x+width/2-gw/2,
+		y+ksz*0.6,
+		ksz/200, "%s", label)
 }
 
 func (s *sceneKeyboard) render() {
 	w, h := menu.GetFramebufferSize()
-	lines := float32(4)
+	lines := float32(5)
 	kbh := float32(h) * 0.6
 	ksp := (kbh - (50 * menu.ratio)) / (lines + 1)
 	ksz := ksp * 0.9
@@ -165,15 +283,19 @@ func (s *sceneKeyboard) render() {
 	menu.Font.Printf(
 		float32(w)/2-ttw/2,
 		s.y+float32(h)*0.15-ksz/2+ksz*0.6,
-		ksz/260, s.label)
+		ksz/260, i18n.T(s.label))
 
 	// Value
+	value := s.value
+	if s.secret {
+		value = strings.Repeat("*", len([]rune(value)))
+	}
 	menu.DrawRect(float32(w)/2-ttw/2, s.y+float32(h)*0.25-ksz/2, ttw, ksz, 0,
 		video.Color{R: 0.95, G: 0.95, B: 0.95, A: 1})
 	menu.Font.Printf(
 		float32(w)/2-ttw/2+ksz/4,
 		s.y+float32(h)*0.25-ksz/2+ksz*0.62,
-		ksz/200, s.value+"|")
+		ksz/200, "%s|", value)
 
 	// Keyboard
 
@@ -181,25 +303,19 @@ func (s *sceneKeyboard) render() {
 
 	menu.Font.SetColor(white)
 
-	for i, key := range layouts[s.layout] {
+	for i, key := range keys(settings.Current.KeyboardLayout, s.symbols, s.shift) {
 		x := float32(i%10)*ksp - ttw/2 + float32(w)/2
 		y := s.y + float32(i/10)*ksp + ksp/2 + float32(h) - kbh
-		gw := menu.Font.Width(ksz/200, key)
+		drawKey(x, y, ksz, ksz, key, i == s.index)
+	}
 
-		c1 := video.Color{R: 0.15, G: 0.15, B: 0.15, A: 1}
-		c2 := video.Color{R: 0.25, G: 0.25, B: 0.25, A: 1}
-		if i == s.index {
-			c1 = video.Color{R: 0.35, G: 0.35, B: 0.35, A: 1}
-			c2 = video.Color{R: 0.45, G: 0.45, B: 0.45, A: 1}
+	for i, label := range s.actionLabels() {
+		x := float32(i*2)*ksp - ttw/2 + float32(w)/2
+		y := s.y + 4*ksp + ksp/2 + float32(h) - kbh
+		if i == keyShift && s.shift {
+			label = "SHIFT"
 		}
-
-		menu.DrawRect(x, y, ksz, ksz, 0.2, c1)
-		menu.DrawRect(x, y, ksz, ksz*0.95, 0.2, c2)
-
-		menu.Font.Printf(
-			x+ksz/2-gw/2,
-			y+ksz*0.6,
-			ksz/200, key)
+		drawKey(x, y, ksp+ksz, ksz, i18n.T(label), actionRow+i*2 == s.index)
 	}
 }
 
@@ -207,12 +323,13 @@ func (s *sceneKeyboard) drawHintBar() {
 	w, h := menu.GetFramebufferSize()
 	menu.DrawRect(0, float32(h)-70*menu.ratio, float32(w), 70*menu.ratio, 0, lightGrey)
 
-	arrows, _, _, a, b, x, y, start, _, _ := hintIcons()
+	arrows, _, _, a, b, x, y, start, slct, _ := hintIcons()
 
 	var stack float32
 	stackHint(&stack, arrows, "SELECT", h)
 	stackHint(&stack, b, "BACK", h)
 	stackHint(&stack, x, "SHIFT", h)
+	stackHint(&stack, slct, "SYMBOLS", h)
 	stackHint(&stack, y, "DELETE", h)
 	stackHint(&stack, a, "INSERT", h)
 	stackHint(&stack, start, "DONE", h)
@@ -0,0 +1,130 @@
+package menu
+
+import (
+	"github.com/libretro/ludo/logs"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+)
+
+type sceneLogs struct {
+	entry
+	level logs.Level
+	tag   string
+	seen  int
+}
+
+// buildLogs lists the recent logs, so that problems can be diagnosed from
+// the couch
+func buildLogs() Scene {
+	var list sceneLogs
+	list.label = "Logs"
+	list.fill()
+	list.segueMount()
+	return &list
+}
+
+// fill lists the filters and the entries matching them
+func (s *sceneLogs) fill() {
+	s.seen = logs.Count()
+	s.children = []entry{{
+		label: "Minimum Level",
+		icon:  "subsetting",
+		stringValue: func() string {
+			return "<" + logs.Levels[s.level] + ">"
+		},
+		incr: func(direction int) {
+			s.level = logs.Level((int(s.level) + direction + len(logs.Levels)) % len(logs.Levels))
+			s.refill()
+		},
+	}, {
+		label: "Module",
+		icon:  "subsetting",
+		stringValue: func() string {
+			if s.tag == "" {
+				return "<All>"
+			}
+			return "<" + s.tag + ">"
+		},
+		incr: func(direction int) {
+			tags := append([]string{""}, logs.Tags()...)
+			i := (utils.IndexOfString(s.tag, tags) + direction + len(tags)) % len(tags)
+			s.tag = tags[i]
+			s.refill()
+		},
+	}}
+
+	for _, e := range logs.Recent(s.level, s.tag) {
+		e := e
+		s.children = append(s.children, entry{
+			label: "[" + e.Tag + "] " + e.Message,
+			icon:  "subsetting",
+			stringValue: func() string {
+				return logs.Levels[e.Level] + " " + e.Time.Format("15:04:05")
+			},
+		})
+	}
+
+	if len(s.children) == 2 {
+		s.children = append(s.children, entry{
+			label: "No log",
+			icon:  "close",
+		})
+	}
+}
+
+// refill lists the entries again, keeping the cursor in place
+func (s *sceneLogs) refill() {
+	ptr := s.ptr
+	s.fill()
+	if ptr >= len(s.children) {
+		ptr = len(s.children) - 1
+	}
+	s.ptr = ptr
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneLogs) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneLogs) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneLogs) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneLogs) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneLogs) update(dt float32) {
+	// Tail the logs while the screen is open
+	if logs.Count() != s.seen {
+		s.refill()
+	}
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneLogs) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneLogs) drawHintBar() {
+	w, h := menu.GetFramebufferSize()
+	menu.DrawRect(0, float32(h)-70*menu.ratio, float32(w), 70*menu.ratio, 0, lightGrey)
+
+	_, upDown, leftRight, _, b, _, _, _, _, guide := hintIcons()
+
+	var stack float32
+	list := menu.stack[len(menu.stack)-1].Entry()
+	if state.CoreRunning {
+		stackHint(&stack, guide, "RESUME", h)
+	}
+	stackHint(&stack, upDown, "NAVIGATE", h)
+	stackHint(&stack, b, "BACK", h)
+	if list.children[list.ptr].incr != nil {
+		stackHint(&stack, leftRight, "FILTER", h)
+	}
+}
@@ -1,12 +1,17 @@
 package menu
 
 import (
+	"fmt"
+	"os"
 	"os/exec"
 	"os/user"
 	"path/filepath"
+	"strings"
 
+	"github.com/libretro/ludo/bezels"
+	"github.com/libretro/ludo/buildbot"
 	"github.com/libretro/ludo/core"
-	"github.com/libretro/ludo/history"
+	"github.com/libretro/ludo/coreinfo"
 	ntf "github.com/libretro/ludo/notifications"
 	"github.com/libretro/ludo/settings"
 	"github.com/libretro/ludo/state"
@@ -96,18 +101,145 @@ func buildMainMenu() Scene {
 		label: "Load Game",
 		icon:  "subsetting",
 		callbackOK: func() {
-			if state.Core != nil {
+			list.segueNext()
+			menu.Push(buildExplorer(
+				gameDirectory(usr.HomeDir),
+				gameExtensions(),
+				gameExplorerCb,
+				nil,
+				nil,
+			))
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Achievements",
+		icon:  "subsetting",
+		callbackOK: func() {
+			list.segueNext()
+			menu.Push(buildAchievements())
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Parental Controls",
+		icon:  "subsetting",
+		callbackOK: func() {
+			openParental(&list)
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Setup Wizard",
+		icon:  "subsetting",
+		callbackOK: func() {
+			list.segueNext()
+			menu.Push(buildWizard())
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Notifications",
+		icon:  "subsetting",
+		callbackOK: func() {
+			list.segueNext()
+			menu.Push(buildNotifications())
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Database",
+		icon:  "subsetting",
+		callbackOK: func() {
+			list.segueNext()
+			menu.Push(buildDatabase())
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Thumbnails Cache",
+		icon:  "subsetting",
+		callbackOK: func() {
+			list.segueNext()
+			menu.Push(buildThumbnails())
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Library Report",
+		icon:  "subsetting",
+		callbackOK: func() {
+			openReport(&list)
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Import RetroArch Settings",
+		icon:  "subsetting",
+		callbackOK: func() {
+			importRetroArch(&list)
+		},
+	})
+
+	if !state.LudOS {
+		list.children = append(list.children, entry{
+			label: "Export to Steam",
+			icon:  "subsetting",
+			callbackOK: func() {
+				list.segueNext()
+				menu.Push(buildSteam())
+			},
+		})
+	}
+
+	list.children = append(list.children, entry{
+		label: "Export Collection Page",
+		icon:  "subsetting",
+		callbackOK: func() {
+			list.segueNext()
+			menu.Push(buildCollection())
+		},
+	})
+
+	if !state.LudOS {
+		list.children = append(list.children, entry{
+			label: "Core Updates",
+			icon:  "subsetting",
+			stringValue: func() string {
+				if n := len(buildbot.Updates()); n > 0 {
+					return fmt.Sprintf("%d available", n)
+				}
+				return ""
+			},
+			callbackOK: func() {
+				list.segueNext()
+				menu.Push(buildCoreUpdates())
+			},
+		})
+
+		list.children = append(list.children, entry{
+			label: "Installed Cores",
+			icon:  "subsetting",
+			callbackOK: func() {
 				list.segueNext()
-				menu.Push(buildExplorer(
-					usr.HomeDir,
-					nil,
-					gameExplorerCb,
-					nil,
-					nil,
-				))
-			} else {
-				ntf.DisplayAndLog(ntf.Warning, "Menu", "Please load a core first.")
-			}
+				menu.Push(buildCores())
+			},
+		})
+	}
+
+	list.children = append(list.children, entry{
+		label: "Download Bezels",
+		icon:  "subsetting",
+		callbackOK: func() {
+			go bezels.Download(settings.Current.BezelPackURL)
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Download Hacks Database",
+		icon:  "subsetting",
+		callbackOK: func() {
+			go buildbot.DownloadHacks()
 		},
 	})
 
@@ -164,17 +296,56 @@ func coreExplorerCb(path string) {
 
 // triggered when a game is selected in the file explorer of Load Game
 func gameExplorerCb(path string) {
-	if err := core.LoadGame(path); err != nil {
-		ntf.DisplayAndLog(ntf.Error, "Core", err.Error())
-		return
+	dir := filepath.Dir(path)
+	if strings.HasPrefix(path, archivesDir()) {
+		dir = filepath.Dir(lastArchive)
 	}
-	history.Push(history.Game{
-		Path:     path,
-		Name:     utils.FileName(path),
-		CorePath: state.CorePath,
-	})
-	menu.WarpToQuickMenu()
-	state.MenuActive = false
+	if settings.Current.GameDirectories == nil {
+		settings.Current.GameDirectories = map[string]string{}
+	}
+	settings.Current.GameDirectories[gameSystem()] = dir
+	settings.Save()
+	menu.OpenContent(path)
+}
+
+// gameSystem identifies the system of the running core, to remember where
+// its games are
+func gameSystem() string {
+	if state.Core == nil {
+		return ""
+	}
+	if info := coreinfo.Lookup(state.CorePath); len(info.Systems) > 0 {
+		return info.Systems[0]
+	}
+	return utils.FileName(state.CorePath)
+}
+
+// gameDirectory is the folder games of the system of the running core were
+// last loaded from
+func gameDirectory(fallback string) string {
+	dir, ok := settings.Current.GameDirectories[gameSystem()]
+	if !ok {
+		return fallback
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return fallback
+	}
+	return dir
+}
+
+// gameExtensions are the extensions of the games supported by the running
+// core, nil to show every file
+func gameExtensions() []string {
+	if state.Core == nil {
+		return nil
+	}
+	var exts []string
+	for _, e := range strings.Split(state.Core.GetSystemInfo().ValidExtensions, "|") {
+		if e != "" {
+			exts = append(exts, "."+e)
+		}
+	}
+	return exts
 }
 
 // Shutdown the operating system
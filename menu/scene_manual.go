@@ -0,0 +1,172 @@
+package menu
+
+import (
+	"fmt"
+	"os/user"
+
+	"github.com/go-gl/gl/v2.1/gl"
+	"github.com/libretro/ludo/input"
+	"github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/manuals"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/playlists"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+	"github.com/libretro/ludo/video"
+)
+
+// runningManual returns the manual of the running game, or an empty string
+func runningManual() string {
+	if state.GamePath == "" {
+		return ""
+	}
+	var system string
+	if list, _, ok := playlists.Find(state.GamePath, state.GameCRC); ok {
+		system = utils.FileName(list)
+	}
+	names := namesOf(system, utils.FileName(state.GamePath), state.GamePath, state.GameCRC)
+	return manuals.Find(settings.Current.ManualsDirectory, system, names, state.GameCRC)
+}
+
+// chooseManual opens an explorer to pick the manual of the running game,
+// then displays it
+func chooseManual(list Scene) {
+	dir := settings.Current.ManualsDirectory
+	if dir == "" {
+		usr, _ := user.Current()
+		dir = usr.HomeDir
+	}
+	list.segueNext()
+	menu.Push(buildExplorer(dir, manuals.Extensions, func(path string) {
+		if err := manuals.Associate(state.GameCRC, utils.FileName(state.GamePath), path); err != nil {
+			ntf.DisplayAndLog(ntf.Error, "Menu", "Could not associate the manual: %s", err.Error())
+			return
+		}
+		ntf.DisplayAndLog(ntf.Success, "Menu", "Manual associated to this game.")
+		openManual(menu.stack[len(menu.stack)-1], path)
+	}, nil, nil))
+}
+
+// openManual displays a manual. The PDFs are opened with the viewer of the
+// system when Ludo can't render them.
+func openManual(list Scene, path string) {
+	book, err := manuals.Open(path)
+	if err == manuals.ErrNoRenderer {
+		if err := manuals.OpenExternal(path); err != nil {
+			ntf.DisplayAndLog(ntf.Error, "Menu", "Could not open the manual: %s", err.Error())
+			return
+		}
+		ntf.DisplayAndLog(ntf.Info, "Menu", "Manual opened in the PDF viewer.")
+		return
+	}
+	if err != nil {
+		ntf.DisplayAndLog(ntf.Error, "Menu", "Could not open the manual: %s", err.Error())
+		return
+	}
+	list.segueNext()
+	menu.Push(buildManualViewer(book))
+}
+
+// sceneManualViewer displays the pages of a manual over the whole screen
+type sceneManualViewer struct {
+	entry
+	book  *manuals.Book
+	page  int
+	image uint32
+}
+
+func buildManualViewer(book *manuals.Book) Scene {
+	var s sceneManualViewer
+	s.label = "Manual"
+	s.book = book
+	s.load(0)
+	return &s
+}
+
+// load uploads a page of the manual, in place of the current one
+func (s *sceneManualViewer) load(page int) {
+	img, err := s.book.Page(page)
+	if err != nil {
+		ntf.DisplayAndLog(ntf.Error, "Menu", "Could not read the page: %s", err.Error())
+		return
+	}
+	if s.image != 0 {
+		gl.DeleteTextures(1, &s.image)
+	}
+	// The scans of the pages can be larger than the textures of the GPU
+	var max int32
+	gl.GetIntegerv(gl.MAX_TEXTURE_SIZE, &max)
+	s.page = page
+	s.image = video.NewImageFrom(manuals.Fit(img, int(max)))
+}
+
+func (s *sceneManualViewer) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneManualViewer) segueMount() {
+}
+
+func (s *sceneManualViewer) segueNext() {
+}
+
+func (s *sceneManualViewer) segueBack() {
+}
+
+func (s *sceneManualViewer) update(dt float32) {
+	repeatRight(dt, input.NewState[0][libretro.DeviceIDJoypadRight] == 1, func() {
+		if s.page < s.book.Len()-1 {
+			s.load(s.page + 1)
+		}
+	})
+	repeatLeft(dt, input.NewState[0][libretro.DeviceIDJoypadLeft] == 1, func() {
+		if s.page > 0 {
+			s.load(s.page - 1)
+		}
+	})
+
+	if input.Released[0][libretro.DeviceIDJoypadB] == 1 {
+		if s.image != 0 {
+			gl.DeleteTextures(1, &s.image)
+		}
+		s.book.Close()
+		menu.stack[len(menu.stack)-2].segueBack()
+		menu.stack = menu.stack[:len(menu.stack)-1]
+	}
+}
+
+func (s *sceneManualViewer) render() {
+	w, h := menu.GetFramebufferSize()
+	menu.DrawRect(0, 0, float32(w), float32(h), 0, black)
+	if s.image == 0 {
+		return
+	}
+
+	var iw, ih int32
+	gl.BindTexture(gl.TEXTURE_2D, s.image)
+	gl.GetTexLevelParameteriv(gl.TEXTURE_2D, 0, gl.TEXTURE_WIDTH, &iw)
+	gl.GetTexLevelParameteriv(gl.TEXTURE_2D, 0, gl.TEXTURE_HEIGHT, &ih)
+	if iw == 0 || ih == 0 {
+		return
+	}
+
+	// Fit the page in the screen, above the hint bar
+	fw, fh := float32(w), float32(h)-70*menu.ratio
+	dw, dh := fw, fw*float32(ih)/float32(iw)
+	if dh > fh {
+		dw, dh = fh*float32(iw)/float32(ih), fh
+	}
+	menu.DrawImage(s.image, (fw-dw)/2, (fh-dh)/2, dw, dh, 1, white)
+}
+
+func (s *sceneManualViewer) drawHintBar() {
+	w, h := menu.GetFramebufferSize()
+	menu.DrawRect(0, float32(h)-70*menu.ratio, float32(w), 70*menu.ratio, 0, lightGrey)
+
+	_, _, leftRight, _, b, _, _, _, _, _ := hintIcons()
+
+	var stack float32
+	stackHint(&stack, b, "BACK", h)
+	stackHint(&stack, leftRight, fmt.Sprintf("PAGE %d/%d", s.page+1, s.book.Len()), h)
+}
@@ -0,0 +1,167 @@
+package menu
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/libretro/ludo/movie"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+)
+
+type sceneMovies struct {
+	entry
+}
+
+func buildMovies() Scene {
+	var list sceneMovies
+	list.label = "Movies"
+	list.fill()
+	list.segueMount()
+	return &list
+}
+
+// moviePaths lists the movies of the running game, most recent first
+func moviePaths() []string {
+	paths, _ := filepath.Glob(filepath.Join(movie.Dir(state.GamePath), "*.bsv"))
+	sort.Sort(sort.Reverse(sort.StringSlice(paths)))
+	return paths
+}
+
+// toggleMovie starts recording a movie, or saves the movie being recorded
+func toggleMovie() {
+	if movie.Recording() {
+		if err := movie.Save(movie.Path(state.GamePath), movie.StopRecording()); err != nil {
+			ntf.DisplayAndLog(ntf.Error, "Menu", "Error saving the movie: %v", err.Error())
+			return
+		}
+		ntf.DisplayAndLog(ntf.Success, "Menu", "Movie saved.")
+		return
+	}
+	if err := movie.Begin(); err != nil {
+		ntf.DisplayAndLog(ntf.Error, "Menu", "Error recording the movie: %v", err.Error())
+		return
+	}
+	ntf.DisplayAndLog(ntf.Info, "Menu", "Recording movie.")
+	state.MenuActive = false
+}
+
+// playMovie loads the savestate of a movie and replays it
+func playMovie(path string) {
+	m, err := movie.Open(path)
+	if err == nil {
+		err = movie.Replay(m)
+	}
+	if err != nil {
+		ntf.DisplayAndLog(ntf.Error, "Menu", "Error playing the movie: %v", err.Error())
+		return
+	}
+	ntf.DisplayAndLog(ntf.Info, "Menu", "Playing movie.")
+	state.MenuActive = false
+}
+
+// fill lists the movies of the running game after the recording controls
+func (s *sceneMovies) fill() {
+	record := entry{
+		label: "Record Movie",
+		icon:  "subsetting",
+		callbackOK: func() {
+			toggleMovie()
+			s.fill()
+			genericAnimate(&s.entry)
+		},
+	}
+	if movie.Recording() {
+		record.label = "Stop Recording Movie"
+	}
+	s.children = []entry{record}
+
+	if movie.Playing() {
+		s.children = append(s.children, entry{
+			label: "Stop Movie Playback",
+			icon:  "close",
+			callbackOK: func() {
+				movie.Stop()
+				s.fill()
+				genericAnimate(&s.entry)
+			},
+		})
+	}
+
+	gameName := utils.FileName(state.GamePath)
+	for _, path := range moviePaths() {
+		path := path
+		s.children = append(s.children, entry{
+			label: strings.Replace(utils.FileName(path), gameName+"@", "", 1),
+			icon:  "resume",
+			path:  path,
+			callbackOK: func() {
+				playMovie(path)
+			},
+			callbackX: func() {
+				menu.Push(buildYesNoDialog(
+					"Confirm before deleting",
+					"You are about to delete a movie.",
+					"This action is irreversible.", func() {
+						if err := os.Remove(path); err != nil {
+							ntf.DisplayAndLog(ntf.Error, "Menu", "Could not delete movie: %s", err.Error())
+							return
+						}
+						s.fill()
+						if s.ptr >= len(s.children) {
+							s.ptr = len(s.children) - 1
+						}
+						genericAnimate(&s.entry)
+					}))
+			},
+		})
+	}
+}
+
+func (s *sceneMovies) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneMovies) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneMovies) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneMovies) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneMovies) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneMovies) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneMovies) drawHintBar() {
+	w, h := menu.GetFramebufferSize()
+	menu.DrawRect(0, float32(h)-70*menu.ratio, float32(w), 70*menu.ratio, 0, lightGrey)
+
+	_, upDown, _, a, b, x, _, _, _, guide := hintIcons()
+
+	var stack float32
+	list := menu.stack[len(menu.stack)-1].Entry()
+	if state.CoreRunning {
+		stackHint(&stack, guide, "RESUME", h)
+	}
+	stackHint(&stack, upDown, "NAVIGATE", h)
+	stackHint(&stack, b, "BACK", h)
+	if list.children[list.ptr].callbackOK != nil {
+		stackHint(&stack, a, "OK", h)
+	}
+	if list.children[list.ptr].callbackX != nil {
+		stackHint(&stack, x, "DELETE", h)
+	}
+}
@@ -0,0 +1,121 @@
+package menu
+
+import (
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+)
+
+type sceneNotifications struct {
+	entry
+}
+
+// toggleCategory shows or hides the notifications of a category
+func toggleCategory(category string) {
+	hidden := settings.Current.NotificationsHidden
+	if utils.StringInSlice(category, hidden) {
+		var l []string
+		for _, c := range hidden {
+			if c != category {
+				l = append(l, c)
+			}
+		}
+		settings.Current.NotificationsHidden = l
+	} else {
+		settings.Current.NotificationsHidden = append(hidden, category)
+	}
+	settings.Save()
+}
+
+func buildNotifications() Scene {
+	var list sceneNotifications
+	list.label = "Notifications"
+
+	// Hidden categories are listed even if nothing was notified yet
+	categories := ntf.Categories()
+	for _, c := range settings.Current.NotificationsHidden {
+		if !utils.StringInSlice(c, categories) {
+			categories = append(categories, c)
+		}
+	}
+
+	for _, category := range categories {
+		category := category
+		list.children = append(list.children, entry{
+			label: "Show " + category + " Notifications",
+			icon:  "subsetting",
+			value: func() interface{} {
+				return !utils.StringInSlice(category, settings.Current.NotificationsHidden)
+			},
+			incr: func(direction int) {
+				toggleCategory(category)
+			},
+			widget: widgets["switch"],
+		})
+	}
+
+	for _, r := range ntf.History() {
+		r := r
+		list.children = append(list.children, entry{
+			label: r.Message,
+			icon:  "subsetting",
+			stringValue: func() string {
+				return r.Time.Format("15:04:05")
+			},
+		})
+	}
+
+	if len(list.children) == 0 {
+		list.children = append(list.children, entry{
+			label: "No notification",
+			icon:  "close",
+		})
+	}
+
+	list.segueMount()
+
+	return &list
+}
+
+func (s *sceneNotifications) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneNotifications) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneNotifications) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneNotifications) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneNotifications) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneNotifications) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneNotifications) drawHintBar() {
+	w, h := menu.GetFramebufferSize()
+	menu.DrawRect(0, float32(h)-70*menu.ratio, float32(w), 70*menu.ratio, 0, lightGrey)
+
+	_, upDown, leftRight, _, b, _, _, _, _, guide := hintIcons()
+
+	var stack float32
+	list := menu.stack[len(menu.stack)-1].Entry()
+	if state.CoreRunning {
+		stackHint(&stack, guide, "RESUME", h)
+	}
+	stackHint(&stack, upDown, "NAVIGATE", h)
+	stackHint(&stack, b, "BACK", h)
+	if list.children[list.ptr].incr != nil {
+		stackHint(&stack, leftRight, "SET", h)
+	}
+}
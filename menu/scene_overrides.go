@@ -0,0 +1,124 @@
+package menu
+
+import (
+	"github.com/libretro/ludo/audio"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+)
+
+// reapply does what the settings menu does when the settings it changes are
+// set by applying or clearing the overrides. The swap interval is read every
+// frame, it doesn't need to.
+func reapply(prev settings.Settings) {
+	cur := settings.Current
+	if cur.VideoFullscreen != prev.VideoFullscreen ||
+		cur.VideoMonitorIndex != prev.VideoMonitorIndex ||
+		cur.VideoFullscreenMode != prev.VideoFullscreenMode ||
+		cur.VideoThreaded != prev.VideoThreaded {
+		menu.Reconfigure(cur.VideoFullscreen)
+		menu.ContextReset()
+	}
+	if cur.VideoFilter != prev.VideoFilter {
+		menu.UpdateFilter(cur.VideoFilter)
+	}
+	if cur.MenuTheme != prev.MenuTheme {
+		menu.applyTheme()
+	}
+	if cur.MenuFontSize != prev.MenuFontSize {
+		menu.SetFontSize(cur.MenuFontSize)
+	}
+	if cur.AudioDevice != prev.AudioDevice || cur.AudioBufferSize != prev.AudioBufferSize {
+		if err := audio.SetDevice(cur.AudioDevice); err != nil {
+			ntf.DisplayAndLog(ntf.Error, "Settings", err.Error())
+		}
+	}
+	if cur.AudioVolume != prev.AudioVolume {
+		audio.SetVolume(cur.AudioVolume)
+	}
+}
+
+type sceneOverrides struct {
+	entry
+}
+
+func buildOverrides() Scene {
+	var list sceneOverrides
+	list.label = "Overrides"
+
+	list.children = append(list.children, entry{
+		label: "Settings",
+		icon:  "subsetting",
+		callbackOK: func() {
+			list.segueNext()
+			menu.Push(buildSettings())
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Save Core Overrides",
+		icon:  "subsetting",
+		callbackOK: func() {
+			if err := settings.SaveCoreOverrides(state.CorePath); err != nil {
+				ntf.DisplayAndLog(ntf.Error, "Menu", "Error saving overrides: %v", err.Error())
+				return
+			}
+			ntf.DisplayAndLog(ntf.Success, "Menu", "Overrides saved for this core.")
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Save Game Overrides",
+		icon:  "subsetting",
+		callbackOK: func() {
+			if err := settings.SaveGameOverrides(state.CorePath, state.GameCRC); err != nil {
+				ntf.DisplayAndLog(ntf.Error, "Menu", "Error saving overrides: %v", err.Error())
+				return
+			}
+			ntf.DisplayAndLog(ntf.Success, "Menu", "Overrides saved for this game.")
+		},
+	})
+
+	list.segueMount()
+
+	return &list
+}
+
+func (s *sceneOverrides) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneOverrides) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneOverrides) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneOverrides) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneOverrides) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneOverrides) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneOverrides) drawHintBar() {
+	w, h := menu.GetFramebufferSize()
+	menu.DrawRect(0, float32(h)-70*menu.ratio, float32(w), 70*menu.ratio, 0, lightGrey)
+
+	_, upDown, _, a, b, _, _, _, _, guide := hintIcons()
+
+	var stack float32
+	if state.CoreRunning {
+		stackHint(&stack, guide, "RESUME", h)
+	}
+	stackHint(&stack, upDown, "NAVIGATE", h)
+	stackHint(&stack, b, "BACK", h)
+	stackHint(&stack, a, "OK", h)
+}
@@ -0,0 +1,141 @@
+package menu
+
+import (
+	"fmt"
+	"sort"
+
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/parental"
+	"github.com/libretro/ludo/playlists"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+)
+
+type sceneParental struct {
+	entry
+}
+
+func buildParental() Scene {
+	var list sceneParental
+	list.label = "Parental Controls"
+
+	list.children = append(list.children, entry{
+		label: "Maximum Age Rating",
+		icon:  "subsetting",
+		stringValue: func() string {
+			if settings.Current.ParentalMaxAge == 0 {
+				return "Off"
+			}
+			return fmt.Sprintf("%d+", settings.Current.ParentalMaxAge)
+		},
+		incr: func(direction int) {
+			i := 0
+			for j, a := range parental.Ages {
+				if a == settings.Current.ParentalMaxAge {
+					i = j
+				}
+			}
+			i = (i + direction + len(parental.Ages)) % len(parental.Ages)
+			settings.Current.ParentalMaxAge = parental.Ages[i]
+			settings.Save()
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Session Time Limit",
+		icon:  "subsetting",
+		stringValue: func() string {
+			if settings.Current.ParentalSessionLimit == 0 {
+				return "Off"
+			}
+			return fmt.Sprintf("%d min", settings.Current.ParentalSessionLimit)
+		},
+		incr: func(direction int) {
+			v := settings.Current.ParentalSessionLimit + direction*15
+			if v < 0 || v > 240 {
+				return
+			}
+			settings.Current.ParentalSessionLimit = v
+			settings.Save()
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Reset Session Timer",
+		icon:  "subsetting",
+		callbackOK: func() {
+			parental.ResetSession()
+			ntf.DisplayAndLog(ntf.Success, "Menu", "Session timer reset.")
+		},
+	})
+
+	var keys []string
+	for k := range playlists.Playlists {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, path := range keys {
+		name := utils.FileName(path)
+		list.children = append(list.children, entry{
+			label: "Restrict " + playlists.ShortName(name),
+			icon:  "subsetting",
+			value: func() interface{} {
+				return parental.RestrictedPlaylist(name)
+			},
+			incr: func(direction int) {
+				parental.ToggleRestriction(name)
+				settings.Save()
+			},
+			widget: widgets["switch"],
+		})
+	}
+
+	list.segueMount()
+
+	return &list
+}
+
+func (s *sceneParental) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneParental) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneParental) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneParental) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneParental) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneParental) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneParental) drawHintBar() {
+	w, h := menu.GetFramebufferSize()
+	menu.DrawRect(0, float32(h)-70*menu.ratio, float32(w), 70*menu.ratio, 0, lightGrey)
+
+	_, upDown, leftRight, a, b, _, _, _, _, guide := hintIcons()
+
+	var stack float32
+	if state.CoreRunning {
+		stackHint(&stack, guide, "RESUME", h)
+	}
+	stackHint(&stack, upDown, "NAVIGATE", h)
+	stackHint(&stack, b, "BACK", h)
+	list := menu.stack[len(menu.stack)-1].Entry()
+	if list.children[list.ptr].callbackOK != nil {
+		stackHint(&stack, a, "OK", h)
+	} else {
+		stackHint(&stack, leftRight, "SET", h)
+	}
+}
@@ -7,11 +7,16 @@ import (
 	"strings"
 
 	"github.com/libretro/ludo/core"
+	"github.com/libretro/ludo/dat"
 	"github.com/libretro/ludo/history"
+	"github.com/libretro/ludo/input"
+	"github.com/libretro/ludo/libretro"
 	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/parental"
 	"github.com/libretro/ludo/playlists"
 	"github.com/libretro/ludo/settings"
 	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/stats"
 	"github.com/libretro/ludo/utils"
 )
 
@@ -23,23 +28,28 @@ func buildPlaylist(path string) Scene {
 	var list scenePlaylist
 	list.label = utils.FileName(path)
 
-	for _, game := range playlists.Playlists[path] {
+	for _, game := range sortedGames(path) {
 		game := game // needed for callbackOK
 		strippedName, tags := extractTags(game.Name)
 		if strings.Contains(game.Name, "Disc") {
 			re := regexp.MustCompile(`\((Disc [1-9]?)\)`)
-			match := re.FindStringSubmatch(game.Name)
-			strippedName = strippedName + " (" + match[1] + ")"
+			if match := re.FindStringSubmatch(game.Name); match != nil {
+				strippedName = strippedName + " (" + match[1] + ")"
+			}
 		}
-		list.children = append(list.children, entry{
+		e := entry{
 			label:      strippedName,
 			gameName:   game.Name,
 			path:       game.Path,
+			crc:        game.CRC32,
 			tags:       tags,
 			icon:       utils.FileName(path) + "-content",
 			callbackOK: func() { loadPlaylistEntry(&list, list.label, game) },
-			callbackX:  func() { askDeleteGameConfirmation(func() { deletePlaylistEntry(&list, path, game) }) },
-		})
+		}
+		if !kiosk() {
+			e.callbackX = func() { askDeleteGameConfirmation(func() { deletePlaylistEntry(&list, path, game) }) }
+		}
+		list.children = append(list.children, e)
 	}
 
 	if len(playlists.Playlists[path]) == 0 {
@@ -49,12 +59,42 @@ func buildPlaylist(path string) Scene {
 		})
 	}
 
-	buildIndexes(&list.entry)
+	if settings.Current.PlaylistSort == "Name" {
+		buildIndexes(&list.entry)
+	}
 
 	list.segueMount()
 	return &list
 }
 
+// playlistSorts are the orders of the games of the playlists
+var playlistSorts = []string{"Name", "Most Played", "Last Played"}
+
+// sortedGames returns the games of a playlist in the order of the sort
+// setting. Playlists are saved sorted by name.
+func sortedGames(path string) []playlists.Game {
+	games := playlists.Playlists[path]
+	if settings.Current.PlaylistSort == "Name" {
+		return games
+	}
+	byPath := map[string]playlists.Game{}
+	var paths []string
+	for _, g := range games {
+		byPath[g.Path] = g
+		paths = append(paths, g.Path)
+	}
+	if settings.Current.PlaylistSort == "Last Played" {
+		stats.LastPlayed(paths)
+	} else {
+		stats.MostPlayed(paths)
+	}
+	sorted := make([]playlists.Game, 0, len(paths))
+	for _, p := range paths {
+		sorted = append(sorted, byPath[p])
+	}
+	return sorted
+}
+
 // Index first letters of entries to allow quick jump to the next or previous
 // letter
 func buildIndexes(list *entry) {
@@ -72,6 +112,12 @@ func buildIndexes(list *entry) {
 }
 
 func extractTags(name string) (string, []string) {
+	if t, ok := dat.ParseTOSEC(name); ok {
+		return t.Title, t.Tags()
+	}
+	if g, ok := dat.ParseGoodTools(name); ok {
+		return g.Title, g.Tags()
+	}
 	re := regexp.MustCompile(`\(.*?\)`)
 	pars := re.FindAllString(name, -1)
 	var tags []string
@@ -89,6 +135,16 @@ func extractTags(name string) (string, []string) {
 }
 
 func loadPlaylistEntry(list *scenePlaylist, playlist string, game playlists.Game) {
+	if parental.Restricted(playlist, game.CRC32) {
+		list.segueNext()
+		askPIN(func() { launchPlaylistEntry(list, playlist, game) })
+		return
+	}
+	launchPlaylistEntry(list, playlist, game)
+}
+
+// launchPlaylistEntry loads a game of a playlist with the core of its system
+func launchPlaylistEntry(list *scenePlaylist, playlist string, game playlists.Game) {
 	if _, err := os.Stat(game.Path); os.IsNotExist(err) {
 		ntf.DisplayAndLog(ntf.Error, "Menu", "Game not found.")
 		return
@@ -190,6 +246,27 @@ func (s *scenePlaylist) segueBack() {
 
 func (s *scenePlaylist) update(dt float32) {
 	genericInput(&s.entry, dt)
+
+	// Y downloads the missing thumbnails of the whole playlist
+	if input.Released[0][libretro.DeviceIDJoypadY] == 1 {
+		var games []entry
+		for _, e := range s.children {
+			if e.gameName != "" {
+				games = append(games, entry{gameName: e.gameName, path: e.path, crc: e.crc})
+			}
+		}
+		if len(games) > 0 {
+			go downloadAllThumbnails(s.label, games)
+		}
+	}
+
+	// Select shows the statistics of the game, Start and Select toggle the menu
+	if input.Released[0][libretro.DeviceIDJoypadSelect] == 1 && input.NewState[0][libretro.DeviceIDJoypadStart] == 0 {
+		if e := s.children[s.ptr]; e.gameName != "" {
+			s.segueNext()
+			menu.Push(buildGameInfo(e.label, e.path))
+		}
+	}
 }
 
 // Override rendering
@@ -268,7 +345,7 @@ func (s *scenePlaylist) drawHintBar() {
 	w, h := menu.GetFramebufferSize()
 	menu.DrawRect(0, float32(h)-70*menu.ratio, float32(w), 70*menu.ratio, 0, lightGrey)
 
-	_, upDown, _, a, b, x, _, _, _, guide := hintIcons()
+	_, upDown, _, a, b, x, y, _, slct, guide := hintIcons()
 
 	var stack float32
 	if state.CoreRunning {
@@ -282,4 +359,8 @@ func (s *scenePlaylist) drawHintBar() {
 	if list.children[list.ptr].callbackX != nil {
 		stackHint(&stack, x, "DELETE", h)
 	}
+	if list.children[list.ptr].gameName != "" {
+		stackHint(&stack, y, "THUMBNAILS", h)
+		stackHint(&stack, slct, "INFO", h)
+	}
 }
@@ -0,0 +1,74 @@
+package menu
+
+import (
+	"fmt"
+
+	"github.com/libretro/ludo/input"
+	"github.com/libretro/ludo/state"
+)
+
+type scenePortBinds struct {
+	entry
+}
+
+func buildPortBinds() Scene {
+	var list scenePortBinds
+	list.label = "Port Binds"
+
+	for port := 0; port < input.MaxPlayers; port++ {
+		port := port
+		list.children = append(list.children, entry{
+			label: fmt.Sprintf("Port %d", port+1),
+			icon:  "subsetting",
+			stringValue: func() string {
+				return input.PortName(port)
+			},
+			incr: func(direction int) {
+				input.CyclePort(port, direction)
+			},
+		})
+	}
+
+	list.segueMount()
+
+	return &list
+}
+
+func (s *scenePortBinds) Entry() *entry {
+	return &s.entry
+}
+
+func (s *scenePortBinds) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *scenePortBinds) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *scenePortBinds) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *scenePortBinds) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *scenePortBinds) render() {
+	genericRender(&s.entry)
+}
+
+func (s *scenePortBinds) drawHintBar() {
+	w, h := menu.GetFramebufferSize()
+	menu.DrawRect(0, float32(h)-70*menu.ratio, float32(w), 70*menu.ratio, 0, lightGrey)
+
+	_, upDown, leftRight, _, b, _, _, _, _, guide := hintIcons()
+
+	var stack float32
+	if state.CoreRunning {
+		stackHint(&stack, guide, "RESUME", h)
+	}
+	stackHint(&stack, upDown, "NAVIGATE", h)
+	stackHint(&stack, b, "BACK", h)
+	stackHint(&stack, leftRight, "SET", h)
+}
@@ -0,0 +1,243 @@
+package menu
+
+import (
+	"os"
+	"os/user"
+
+	"github.com/libretro/ludo/core"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/presets"
+	"github.com/libretro/ludo/remap"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/shaders"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+)
+
+type scenePresets struct {
+	entry
+}
+
+// buildPresets lets the user export the setup of the running core as a
+// preset, import the presets shared by other users, and apply them
+func buildPresets() Scene {
+	var list scenePresets
+	list.label = "Presets"
+	list.fill()
+	list.segueMount()
+	return &list
+}
+
+// fill lists the presets of the presets directory
+func (s *scenePresets) fill() {
+	dir := settings.Current.PresetsDirectory
+
+	s.children = []entry{{
+		label: "Export Current Setup",
+		icon:  "subsetting",
+		callbackOK: func() {
+			s.segueNext()
+			menu.Push(buildKeyboard("Preset name", func(name string) {
+				p := presets.Capture(name, state.CorePath, core.Options, shaders.Current, remap.Current)
+				path, err := presets.Save(dir, p)
+				if err != nil {
+					ntf.DisplayAndLog(ntf.Error, "Menu", "Error exporting the preset: %v", err.Error())
+					return
+				}
+				s.fill()
+				genericAnimate(&s.entry)
+				ntf.DisplayAndLog(ntf.Success, "Menu", "Preset exported to %s", path)
+			}))
+		},
+	}, {
+		label: "Import Preset File",
+		icon:  "subsetting",
+		callbackOK: func() {
+			usr, _ := user.Current()
+			s.segueNext()
+			menu.Push(buildExplorer(usr.HomeDir, []string{".toml"}, func(path string) {
+				s.importPreset(dir, path, false)
+			}, nil, nil))
+		},
+	}}
+
+	// The option keys of a preset only make sense for its core
+	running := utils.FileName(state.CorePath)
+	for _, path := range presets.List(dir) {
+		path := path
+		p, err := presets.Load(path)
+		if err != nil || (running != "" && p.Core != running) {
+			continue
+		}
+		s.children = append(s.children, entry{
+			label:       p.Name,
+			icon:        "subsetting",
+			stringValue: func() string { return p.Core },
+			callbackOK: func() {
+				s.segueNext()
+				menu.Push(buildPreset(s, p, path))
+			},
+		})
+	}
+}
+
+// importPreset copies a preset file to the presets directory, asking before
+// replacing a preset of the same name
+func (s *scenePresets) importPreset(dir, path string, replace bool) {
+	p, err := presets.Import(dir, path, replace)
+	if err == presets.ErrExists {
+		menu.Push(buildYesNoDialog(
+			"Confirm before replacing",
+			"A preset with this name exists already.",
+			"Do you want to replace it?", func() {
+				s.importPreset(dir, path, true)
+			}))
+		return
+	}
+	if err != nil {
+		ntf.DisplayAndLog(ntf.Error, "Menu", "Error importing the preset: %v", err.Error())
+		return
+	}
+	s.fill()
+	ntf.DisplayAndLog(ntf.Success, "Menu", "Preset imported to %s", p)
+}
+
+func (s *scenePresets) Entry() *entry {
+	return &s.entry
+}
+
+func (s *scenePresets) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *scenePresets) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *scenePresets) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *scenePresets) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *scenePresets) render() {
+	genericRender(&s.entry)
+}
+
+func (s *scenePresets) drawHintBar() {
+	genericDrawHintBar()
+}
+
+// usePreset makes a preset the current setup of the running game. The option
+// values set for the game only aren't saved with the options of the core.
+func usePreset(p presets.Preset, gameOnly bool) {
+	presets.SetOptions(p, core.Options, gameOnly)
+	shaders.Current = presets.ShaderConfig(p)
+	applyPreset()
+	// The devices plugged in the ports stay, they depend on the game
+	devices := remap.Current.Devices
+	remap.Current = remap.Decode(p.Remap)
+	remap.Current.Devices = devices
+}
+
+type scenePreset struct {
+	entry
+}
+
+// buildPreset offers to apply a preset to all the games of the running core,
+// or to the running game only. Deleting it goes back to the list of presets.
+func buildPreset(parent *scenePresets, p presets.Preset, path string) Scene {
+	var list scenePreset
+	list.label = p.Name
+
+	list.children = append(list.children, entry{
+		label: "Apply To This Core",
+		icon:  "subsetting",
+		callbackOK: func() {
+			usePreset(p, false)
+			if core.Options != nil {
+				if err := core.Options.Save(); err != nil {
+					ntf.DisplayAndLog(ntf.Error, "Menu", "Error saving core options: %v", err.Error())
+					return
+				}
+			}
+			if err := shaders.SaveCore(state.CorePath); err != nil {
+				ntf.DisplayAndLog(ntf.Error, "Menu", "Error saving shader preset: %v", err.Error())
+				return
+			}
+			if err := remap.SaveCore(state.CorePath); err != nil {
+				ntf.DisplayAndLog(ntf.Error, "Menu", "Error saving remap: %v", err.Error())
+				return
+			}
+			ntf.DisplayAndLog(ntf.Success, "Menu", "Preset applied to this core.")
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Apply To This Game",
+		icon:  "subsetting",
+		callbackOK: func() {
+			// Core options are saved per core, they only last for this session
+			usePreset(p, true)
+			if err := shaders.SaveGame(state.CorePath, state.GameCRC); err != nil {
+				ntf.DisplayAndLog(ntf.Error, "Menu", "Error saving shader preset: %v", err.Error())
+				return
+			}
+			if err := remap.SaveGame(state.CorePath, state.GameCRC); err != nil {
+				ntf.DisplayAndLog(ntf.Error, "Menu", "Error saving remap: %v", err.Error())
+				return
+			}
+			ntf.DisplayAndLog(ntf.Success, "Menu", "Preset applied to this game. Core options last until the game is closed.")
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Delete",
+		icon:  "subsetting",
+		callbackOK: func() {
+			if err := os.Remove(path); err != nil {
+				ntf.DisplayAndLog(ntf.Error, "Menu", "Error deleting the preset: %v", err.Error())
+				return
+			}
+			ntf.DisplayAndLog(ntf.Success, "Menu", "Preset deleted.")
+			menu.stack = menu.stack[:len(menu.stack)-1]
+			parent.fill()
+			parent.ptr = 0
+			parent.segueBack()
+		},
+	})
+
+	list.segueMount()
+
+	return &list
+}
+
+func (s *scenePreset) Entry() *entry {
+	return &s.entry
+}
+
+func (s *scenePreset) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *scenePreset) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *scenePreset) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *scenePreset) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *scenePreset) render() {
+	genericRender(&s.entry)
+}
+
+func (s *scenePreset) drawHintBar() {
+	genericDrawHintBar()
+}
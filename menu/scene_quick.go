@@ -1,9 +1,14 @@
 package menu
 
 import (
+	"fmt"
+
+	"github.com/libretro/ludo/audio"
+	"github.com/libretro/ludo/envaudit"
 	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/shaders"
 	"github.com/libretro/ludo/state"
-	"github.com/libretro/ludo/utils"
 )
 
 type sceneQuick struct {
@@ -14,6 +19,10 @@ func buildQuickMenu() Scene {
 	var list sceneQuick
 	list.label = "Quick Menu"
 
+	if settings.Current.AchievementsHardcore {
+		list.label = "Quick Menu - Hardcore Mode"
+	}
+
 	list.children = append(list.children, entry{
 		label: "Resume",
 		icon:  "resume",
@@ -33,38 +42,178 @@ func buildQuickMenu() Scene {
 		},
 	})
 
+	if !settings.Current.AchievementsHardcore {
+		list.children = append(list.children, entry{
+			label: "Savestates",
+			icon:  "states",
+			callbackOK: func() {
+				list.segueNext()
+				menu.Push(buildSavestates())
+			},
+		})
+
+		list.children = append(list.children, entry{
+			label: "Movies",
+			icon:  "subsetting",
+			callbackOK: func() {
+				list.segueNext()
+				menu.Push(buildMovies())
+			},
+		})
+	}
+
 	list.children = append(list.children, entry{
-		label: "Savestates",
-		icon:  "states",
+		label: "Take Screenshot",
+		icon:  "screenshot",
 		callbackOK: func() {
-			list.segueNext()
-			menu.Push(buildSavestates())
+			takeScreenshot()
 		},
 	})
 
 	list.children = append(list.children, entry{
-		label: "Take Screenshot",
+		label: "Screenshots",
 		icon:  "screenshot",
 		callbackOK: func() {
-			name := utils.DatedName(state.GamePath)
-			err := menu.TakeScreenshot(name)
-			if err != nil {
-				ntf.DisplayAndLog(ntf.Error, "Menu", err.Error())
-			} else {
-				ntf.DisplayAndLog(ntf.Success, "Menu", "Took a screenshot.")
-			}
+			list.segueNext()
+			menu.Push(buildScreenshots())
 		},
 	})
 
+	if manual := runningManual(); manual != "" {
+		list.children = append(list.children, entry{
+			label: "Manual",
+			icon:  "subsetting",
+			callbackOK: func() {
+				openManual(&list, manual)
+			},
+		})
+	}
+
+	list.children = append(list.children, entry{
+		label: "Choose Manual",
+		icon:  "subsetting",
+		callbackOK: pinned(func() {
+			chooseManual(&list)
+		}),
+	})
+
 	list.children = append(list.children, entry{
 		label: "Options",
 		icon:  "subsetting",
-		callbackOK: func() {
+		callbackOK: pinned(func() {
 			list.segueNext()
 			menu.Push(buildCoreOptions())
-		},
+		}),
+	})
+
+	if len(shaders.Names()) > 0 {
+		list.children = append(list.children, entry{
+			label: "Shaders",
+			icon:  "subsetting",
+			callbackOK: pinned(func() {
+				list.segueNext()
+				menu.Push(buildShaders())
+			}),
+		})
+	}
+
+	list.children = append(list.children, entry{
+		label: "Viewport",
+		icon:  "subsetting",
+		callbackOK: pinned(func() {
+			list.segueNext()
+			menu.Push(buildViewport())
+		}),
 	})
 
+	if !settings.Current.AchievementsHardcore {
+		list.children = append(list.children, entry{
+			label: "Cheats",
+			icon:  "subsetting",
+			callbackOK: pinned(func() {
+				list.segueNext()
+				menu.Push(buildCheats())
+			}),
+		})
+	}
+
+	list.children = append(list.children, entry{
+		label: "Controls",
+		icon:  "subsetting",
+		callbackOK: pinned(func() {
+			list.segueNext()
+			menu.Push(buildRemap())
+		}),
+	})
+
+	list.children = append(list.children, entry{
+		label: "Controllers",
+		icon:  "subsetting",
+		callbackOK: pinned(func() {
+			list.segueNext()
+			menu.Push(buildControllers())
+		}),
+	})
+
+	list.children = append(list.children, entry{
+		label: "Overrides",
+		icon:  "subsetting",
+		callbackOK: pinned(func() {
+			list.segueNext()
+			menu.Push(buildOverrides())
+		}),
+	})
+
+	list.children = append(list.children, entry{
+		label: "Presets",
+		icon:  "subsetting",
+		callbackOK: pinned(func() {
+			list.segueNext()
+			menu.Push(buildPresets())
+		}),
+	})
+
+	if settings.Current.EnvironmentAudit {
+		list.children = append(list.children, entry{
+			label: "Environment Report",
+			icon:  "subsetting",
+			callbackOK: func() {
+				list.segueNext()
+				menu.Push(buildEnvironmentReport(coreLabel(state.CorePath), envaudit.Report(state.CorePath)))
+			},
+		})
+	}
+
+	// The audio can't be tuned in kiosk mode, only the entries opening a
+	// page can ask for the PIN
+	if !kiosk() {
+		list.children = append(list.children, entry{
+			label: "Game Volume Offset",
+			icon:  "subsetting",
+			stringValue: func() string {
+				return fmt.Sprintf("%+.1f", audio.VolumeOffset())
+			},
+			incr: func(direction int) {
+				audio.SetVolumeOffset(audio.VolumeOffset() + 0.1*float32(direction))
+				if err := audio.SaveVolume(state.CorePath, state.GameCRC); err != nil {
+					ntf.DisplayAndLog(ntf.Error, "Menu", "Error saving volume: %v", err.Error())
+				}
+			},
+		})
+
+		list.children = append(list.children, entry{
+			label: "Audio Latency",
+			icon:  "subsetting",
+			stringValue: func() string {
+				l, measured := audio.Latency()
+				if !measured {
+					return fmt.Sprintf("%d ms + device", l.Milliseconds())
+				}
+				return fmt.Sprintf("%d ms", l.Milliseconds())
+			},
+		})
+	}
+
 	if state.Core != nil && state.Core.DiskControlCallback != nil {
 		list.children = append(list.children, entry{
 			label: "Disk Control",
@@ -106,5 +255,21 @@ func (s *sceneQuick) render() {
 }
 
 func (s *sceneQuick) drawHintBar() {
-	genericDrawHintBar()
+	w, h := menu.GetFramebufferSize()
+	menu.DrawRect(0, float32(h)-70*menu.ratio, float32(w), 70*menu.ratio, 0, lightGrey)
+
+	_, upDown, leftRight, a, b, _, _, _, _, guide := hintIcons()
+
+	var stack float32
+	list := menu.stack[len(menu.stack)-1].Entry()
+	if state.CoreRunning {
+		stackHint(&stack, guide, "RESUME", h)
+	}
+	stackHint(&stack, upDown, "NAVIGATE", h)
+	stackHint(&stack, b, "BACK", h)
+	if list.children[list.ptr].callbackOK != nil {
+		stackHint(&stack, a, "OK", h)
+	} else if list.children[list.ptr].incr != nil {
+		stackHint(&stack, leftRight, "SET", h)
+	}
 }
@@ -0,0 +1,177 @@
+package menu
+
+import (
+	"fmt"
+
+	"github.com/libretro/ludo/input"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/remap"
+	"github.com/libretro/ludo/state"
+)
+
+type sceneRemap struct {
+	entry
+}
+
+func buildRemap() Scene {
+	var list sceneRemap
+	list.label = "Controls"
+
+	for i, name := range remap.Buttons {
+		i := i
+		list.children = append(list.children, entry{
+			label: name,
+			icon:  "subsetting",
+			stringValue: func() string {
+				if remap.Current.Turbo[i] {
+					return remap.Buttons[remap.Current.Targets[i]] + " (Turbo)"
+				}
+				return remap.Buttons[remap.Current.Targets[i]]
+			},
+			incr: func(direction int) {
+				remap.Current.Incr(i, direction)
+			},
+			callbackX: func() {
+				remap.Current.Turbo[i] = !remap.Current.Turbo[i]
+			},
+		})
+	}
+
+	list.children = append(list.children, entry{
+		label: "Turbo Period",
+		icon:  "subsetting",
+		stringValue: func() string {
+			return fmt.Sprintf("%d frames", remap.Current.TurboPeriod)
+		},
+		incr: func(direction int) {
+			remap.Current.IncrTurboPeriod(direction)
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Turbo Duty Cycle",
+		icon:  "subsetting",
+		stringValue: func() string {
+			return fmt.Sprintf("%d frames", remap.Current.TurboDuty)
+		},
+		incr: func(direction int) {
+			remap.Current.IncrTurboDuty(direction)
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Analog To D-Pad",
+		icon:  "subsetting",
+		value: func() interface{} { return remap.Current.AnalogToDPad },
+		incr: func(direction int) {
+			remap.Current.AnalogToDPad = !remap.Current.AnalogToDPad
+		},
+		widget: widgets["switch"],
+	})
+
+	list.children = append(list.children, entry{
+		label: "D-Pad To Analog",
+		icon:  "subsetting",
+		value: func() interface{} { return remap.Current.DPadToAnalog },
+		incr: func(direction int) {
+			remap.Current.DPadToAnalog = !remap.Current.DPadToAnalog
+		},
+		widget: widgets["switch"],
+	})
+
+	list.children = append(list.children, entry{
+		label: "Rumble",
+		icon:  "subsetting",
+		value: func() interface{} { return !remap.Current.DisableRumble },
+		incr: func(direction int) {
+			remap.Current.DisableRumble = !remap.Current.DisableRumble
+			if remap.Current.DisableRumble {
+				input.StopRumble()
+			}
+		},
+		widget: widgets["switch"],
+	})
+
+	list.children = append(list.children, entry{
+		label: "Save Core Remap",
+		icon:  "subsetting",
+		callbackOK: func() {
+			if err := remap.SaveCore(state.CorePath); err != nil {
+				ntf.DisplayAndLog(ntf.Error, "Menu", "Error saving remap: %v", err.Error())
+				return
+			}
+			ntf.DisplayAndLog(ntf.Success, "Menu", "Remap saved for this core.")
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Save Game Remap",
+		icon:  "subsetting",
+		callbackOK: func() {
+			if err := remap.SaveGame(state.CorePath, state.GameCRC); err != nil {
+				ntf.DisplayAndLog(ntf.Error, "Menu", "Error saving remap: %v", err.Error())
+				return
+			}
+			ntf.DisplayAndLog(ntf.Success, "Menu", "Remap saved for this game.")
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Reset Remap",
+		icon:  "reset",
+		callbackOK: func() {
+			remap.Current = remap.Identity()
+		},
+	})
+
+	list.segueMount()
+
+	return &list
+}
+
+func (s *sceneRemap) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneRemap) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneRemap) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneRemap) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneRemap) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneRemap) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneRemap) drawHintBar() {
+	w, h := menu.GetFramebufferSize()
+	menu.DrawRect(0, float32(h)-70*menu.ratio, float32(w), 70*menu.ratio, 0, lightGrey)
+
+	_, upDown, leftRight, a, b, x, _, _, _, guide := hintIcons()
+
+	var stack float32
+	list := menu.stack[len(menu.stack)-1].Entry()
+	if state.CoreRunning {
+		stackHint(&stack, guide, "RESUME", h)
+	}
+	stackHint(&stack, upDown, "NAVIGATE", h)
+	stackHint(&stack, b, "BACK", h)
+	if list.children[list.ptr].callbackOK != nil {
+		stackHint(&stack, a, "OK", h)
+	} else {
+		stackHint(&stack, leftRight, "SET", h)
+	}
+	if list.children[list.ptr].callbackX != nil {
+		stackHint(&stack, x, "TURBO", h)
+	}
+}
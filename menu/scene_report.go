@@ -0,0 +1,287 @@
+package menu
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/libretro/ludo/buildbot"
+	"github.com/libretro/ludo/crash"
+	"github.com/libretro/ludo/dat"
+	"github.com/libretro/ludo/mainthread"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/playlists"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+)
+
+// gap is a game of a playlist lacking a thumbnail or a database entry
+type gap struct {
+	game      playlists.Game
+	thumbnail bool
+	metadata  bool
+}
+
+// describe formats what a game lacks
+func (g gap) describe() string {
+	var missing []string
+	if g.thumbnail {
+		missing = append(missing, "No thumbnail")
+	}
+	if g.metadata {
+		missing = append(missing, "No metadata")
+	}
+	return strings.Join(missing, ", ")
+}
+
+// playlistGaps lists the games of a playlist without a thumbnail, cached or
+// local, or whose checksum isn't in the database of their system, like the
+// games recognized by their name only
+func playlistGaps(db dat.DB, system string, games []playlists.Game) []gap {
+	var gaps []gap
+	for _, game := range games {
+		_, path, _ := thumbnailSource(system, game.Name, game.Path, game.CRC32)
+		g := gap{
+			game:      game,
+			thumbnail: path == "" || !utils.Exists(path),
+			metadata:  db.NameByCRC(system, game.CRC32) == "",
+		}
+		if g.thumbnail || g.metadata {
+			gaps = append(gaps, g)
+		}
+	}
+	return gaps
+}
+
+// countGaps counts the games lacking a thumbnail and the ones lacking a
+// database entry
+func countGaps(gaps []gap) (thumbnails, metadata int) {
+	for _, g := range gaps {
+		if g.thumbnail {
+			thumbnails++
+		}
+		if g.metadata {
+			metadata++
+		}
+	}
+	return
+}
+
+// playlistEntries converts the games of a playlist to the entries expected by
+// downloadAllThumbnails
+func playlistEntries(games []playlists.Game) []entry {
+	var entries []entry
+	for _, g := range games {
+		entries = append(entries, entry{gameName: g.Name, path: g.Path, crc: g.CRC32})
+	}
+	return entries
+}
+
+// report is what the playlists lack, by playlist path
+type report struct {
+	keys      []string
+	playlists map[string][]playlists.Game
+	gaps      map[string][]gap
+}
+
+// libraryReport looks for the gaps of the playlists. It reads the files of the
+// thumbnails cache, it is meant to be run in a goroutine.
+func libraryReport(db dat.DB, lists map[string][]playlists.Game) report {
+	r := report{playlists: lists, gaps: map[string][]gap{}}
+	for k := range lists {
+		r.keys = append(r.keys, k)
+	}
+	sort.Strings(r.keys)
+	for _, path := range r.keys {
+		r.gaps[path] = playlistGaps(db, utils.FileName(path), lists[path])
+	}
+	return r
+}
+
+// openReport builds the library report in the background, then displays it
+func openReport(list Scene) {
+	lists := map[string][]playlists.Game{}
+	for k, v := range playlists.Playlists {
+		lists[k] = v
+	}
+	db := state.DB
+	n := ntf.DisplayAndLog(ntf.Info, "Menu", "Building the library report")
+	go func() {
+		defer crash.Recover()
+		r := libraryReport(db, lists)
+		mainthread.Post(func() {
+			n.Update(ntf.Success, "Library report built.")
+			// The user left the scene meanwhile
+			if menu.stack[len(menu.stack)-1] != list {
+				return
+			}
+			list.segueNext()
+			menu.Push(buildReport(r))
+		})
+	}()
+}
+
+type sceneReport struct {
+	entry
+}
+
+// buildReport shows how complete the presentation of the library is: the
+// games of each playlist lacking a thumbnail or metadata, with actions to
+// fetch what is missing from each provider
+func buildReport(r report) Scene {
+	var list sceneReport
+	list.label = "Library Report"
+
+	keys, gaps := r.keys, r.gaps
+	var thumbnails, metadata int
+	var systems []string
+	for _, path := range keys {
+		t, m := countGaps(gaps[path])
+		thumbnails += t
+		metadata += m
+		if m > 0 {
+			systems = append(systems, utils.FileName(path))
+		}
+	}
+
+	list.children = append(list.children, entry{
+		label: "Fetch Missing Thumbnails",
+		icon:  "subsetting",
+		stringValue: func() string {
+			return fmt.Sprintf("%d missing", thumbnails)
+		},
+		callbackOK: func() {
+			go func() {
+				defer crash.Recover()
+				for _, path := range keys {
+					if t, _ := countGaps(gaps[path]); t > 0 {
+						downloadAllThumbnails(utils.FileName(path), playlistEntries(r.playlists[path]))
+					}
+				}
+			}()
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Fetch Missing Metadata",
+		icon:  "subsetting",
+		stringValue: func() string {
+			return fmt.Sprintf("%d missing", metadata)
+		},
+		callbackOK: func() {
+			// Only the dats of the systems of the games lacking metadata
+			if len(systems) == 0 {
+				ntf.DisplayAndLog(ntf.Info, "Menu", "Nothing missing")
+				return
+			}
+			go buildbot.DownloadDats(systems)
+		},
+	})
+
+	if settings.Current.HackPackURL != "" {
+		list.children = append(list.children, entry{
+			label: "Fetch Missing Hacks Metadata",
+			icon:  "subsetting",
+			callbackOK: func() {
+				go buildbot.DownloadHacks()
+			},
+		})
+	}
+
+	for _, path := range keys {
+		path := path
+		t, m := countGaps(gaps[path])
+		total := len(r.playlists[path])
+		list.children = append(list.children, entry{
+			label: playlists.ShortName(utils.FileName(path)),
+			icon:  utils.FileName(path) + "-content",
+			stringValue: func() string {
+				return fmt.Sprintf("%d/%d thumbnails, %d/%d metadata", total-t, total, total-m, total)
+			},
+			callbackOK: func() {
+				list.segueNext()
+				menu.Push(buildPlaylistReport(path, gaps[path]))
+			},
+		})
+	}
+
+	if len(keys) == 0 {
+		list.children = append(list.children, entry{
+			label: "No playlists",
+			icon:  "close",
+		})
+	}
+
+	list.segueMount()
+
+	return &list
+}
+
+// buildPlaylistReport lists the games of a playlist lacking a thumbnail or
+// metadata
+func buildPlaylistReport(path string, gaps []gap) Scene {
+	var list sceneReport
+	list.label = playlists.ShortName(utils.FileName(path))
+
+	if t, _ := countGaps(gaps); t > 0 {
+		list.children = append(list.children, entry{
+			label: "Fetch Missing Thumbnails",
+			icon:  "subsetting",
+			callbackOK: func() {
+				go downloadAllThumbnails(utils.FileName(path), playlistEntries(playlists.Playlists[path]))
+			},
+		})
+	}
+
+	for _, g := range gaps {
+		g := g
+		name, _ := extractTags(g.game.Name)
+		list.children = append(list.children, entry{
+			label: name,
+			icon:  "subsetting",
+			stringValue: func() string {
+				return g.describe()
+			},
+		})
+	}
+
+	if len(gaps) == 0 {
+		list.children = append(list.children, entry{
+			label: "Nothing missing",
+			icon:  "subsetting",
+		})
+	}
+
+	list.segueMount()
+
+	return &list
+}
+
+func (s *sceneReport) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneReport) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneReport) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneReport) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneReport) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneReport) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneReport) drawHintBar() {
+	genericDrawHintBar()
+}
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	ntf "github.com/libretro/ludo/notifications"
 	"github.com/libretro/ludo/savestates"
@@ -25,15 +26,14 @@ func buildSavestates() Scene {
 		label: "Save State",
 		icon:  "savestate",
 		callbackOK: func() {
-			name := utils.DatedName(state.GamePath)
-			err := menu.TakeScreenshot(name)
-			if err != nil {
-				ntf.DisplayAndLog(ntf.Error, "Menu", err.Error())
-			}
-			err = savestates.Save(name)
+			name := savestates.Name(state.GamePath, state.GameCRC)
+			err := savestates.Save(name)
 			if err != nil {
 				ntf.DisplayAndLog(ntf.Error, "Menu", err.Error())
 			} else {
+				if err := menu.TakeScreenshot(name); err != nil {
+					ntf.DisplayAndLog(ntf.Error, "Menu", err.Error())
+				}
 				menu.stack[len(menu.stack)-1] = buildSavestates()
 				menu.tweens.FastForward()
 				ntf.DisplayAndLog(ntf.Success, "Menu", "State saved.")
@@ -41,12 +41,8 @@ func buildSavestates() Scene {
 		},
 	})
 
-	gameName := utils.FileName(state.GamePath)
-	gameName = strings.Replace(gameName, "[", "\\[", -1)
-	gameName = strings.Replace(gameName, "]", "\\]", -1)
-	paths, _ := filepath.Glob(settings.Current.SavestatesDirectory + "/" + gameName + "@*.state")
-	sort.Sort(sort.Reverse(sort.StringSlice(paths)))
-	for _, path := range paths {
+	gameName := gameKey()
+	for _, path := range savestatePaths() {
 		path := path
 		date := strings.Replace(utils.FileName(path), gameName+"@", "", 1)
 		list.children = append(list.children, entry{
@@ -72,6 +68,26 @@ func buildSavestates() Scene {
 	return &list
 }
 
+// savestatePaths lists the savestates of the current game, most recent first
+func savestatePaths() []string {
+	gameName := gameKey()
+	gameName = strings.Replace(gameName, "[", "\\[", -1)
+	gameName = strings.Replace(gameName, "]", "\\]", -1)
+	paths, _ := filepath.Glob(settings.Current.SavestatesDirectory + "/" + gameName + "@*.state")
+	sort.Sort(sort.Reverse(sort.StringSlice(paths)))
+	// Slots are named after their number, not after the date of the state
+	modTimes := map[string]time.Time{}
+	for _, p := range paths {
+		if fi, err := os.Stat(p); err == nil {
+			modTimes[p] = fi.ModTime()
+		}
+	}
+	sort.SliceStable(paths, func(i, j int) bool {
+		return modTimes[paths[i]].After(modTimes[paths[j]])
+	})
+	return paths
+}
+
 func (s *sceneSavestates) Entry() *entry {
 	return &s.entry
 }
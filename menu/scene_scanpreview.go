@@ -0,0 +1,83 @@
+package menu
+
+import (
+	"github.com/libretro/ludo/scanner"
+)
+
+type sceneScanPreview struct {
+	entry
+}
+
+// previewEntry is the entry of a playlist change found by a scan preview
+func previewEntry(label, kind string, c scanner.Change) entry {
+	return entry{
+		label: label,
+		icon:  "subsetting",
+		stringValue: func() string {
+			return kind + " " + c.Playlist
+		},
+	}
+}
+
+// buildScanPreview lists the playlist changes a scan of dir would make, and
+// offers to do the scan
+func buildScanPreview(dir string, p scanner.Preview) Scene {
+	var list sceneScanPreview
+	list.label = "Scan Preview"
+
+	list.children = append(list.children, entry{
+		label: "<Scan this directory>",
+		icon:  "scan",
+		callbackOK: func() {
+			scanner.ScanDir(dir, refreshTabs)
+		},
+	})
+
+	for _, c := range p.Added {
+		list.children = append(list.children, previewEntry(c.Game.Name, "+", c))
+	}
+	for _, c := range p.Changed {
+		list.children = append(list.children, previewEntry(c.Old.Name+" -> "+c.Game.Name, "~", c))
+	}
+	for _, c := range p.Removed {
+		list.children = append(list.children, previewEntry(c.Old.Name, "-", c))
+	}
+
+	if len(list.children) == 1 {
+		list.children = append(list.children, entry{
+			label: "Nothing to change",
+			icon:  "subsetting",
+		})
+	}
+
+	list.segueMount()
+	return &list
+}
+
+func (s *sceneScanPreview) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneScanPreview) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneScanPreview) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneScanPreview) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneScanPreview) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneScanPreview) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneScanPreview) drawHintBar() {
+	genericDrawHintBar()
+}
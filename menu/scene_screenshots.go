@@ -0,0 +1,265 @@
+package menu
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-gl/gl/v2.1/gl"
+	"github.com/libretro/ludo/contentid"
+	"github.com/libretro/ludo/input"
+	"github.com/libretro/ludo/libretro"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+	"github.com/libretro/ludo/video"
+)
+
+// gameKey is the name of the files of the running game, the CRC of its
+// content
+func gameKey() string {
+	return contentid.Key(state.GamePath, state.GameCRC)
+}
+
+// screenshotsDirectory is the folder holding the screenshots of the running
+// game
+func screenshotsDirectory() string {
+	return filepath.Join(settings.Current.ScreenshotsDirectory, gameKey())
+}
+
+// takeScreenshot saves a screenshot of the running game in its folder
+func takeScreenshot() {
+	if _, err := menu.Screenshot(); err != nil {
+		ntf.DisplayAndLog(ntf.Error, "Menu", err.Error())
+	} else {
+		ntf.DisplayAndLog(ntf.Success, "Menu", "Took a screenshot.")
+	}
+}
+
+// screenshotPaths lists the screenshots of the running game, most recent
+// first
+func screenshotPaths() []string {
+	paths, _ := filepath.Glob(filepath.Join(screenshotsDirectory(), "*.png"))
+	sort.Sort(sort.Reverse(sort.StringSlice(paths)))
+	return paths
+}
+
+type sceneScreenshots struct {
+	entry
+}
+
+func buildScreenshots() Scene {
+	var list sceneScreenshots
+	list.label = "Screenshots"
+
+	gameName := gameKey()
+	for _, path := range screenshotPaths() {
+		path := path
+		date := strings.Replace(utils.FileName(path), gameName+"@", "", 1)
+		list.children = append(list.children, entry{
+			label: date,
+			icon:  "screenshot",
+			path:  path,
+			callbackOK: func() {
+				list.segueNext()
+				menu.Push(buildScreenshotViewer(path))
+			},
+			callbackX: func() {
+				menu.Push(buildYesNoDialog(
+					"Confirm before deleting",
+					"You are about to delete a screenshot.",
+					"This action is irreversible.", func() {
+						deleteScreenshotEntry(&list, path)
+					}))
+			},
+		})
+	}
+
+	if len(list.children) == 0 {
+		list.children = append(list.children, entry{
+			label: "No screenshot",
+			icon:  "close",
+		})
+	}
+
+	list.segueMount()
+
+	return &list
+}
+
+func deleteScreenshotEntry(list *sceneScreenshots, path string) {
+	err := os.Remove(path)
+	if err != nil {
+		ntf.DisplayAndLog(ntf.Error, "Menu", "Could not delete screenshot: %s", err.Error())
+		return
+	}
+	for i := range list.children {
+		if list.children[i].path == path {
+			freeThumbnail(&list.entry, i)
+		}
+	}
+	list.children = removeSavestateEntry(list.children, path)
+	if len(list.children) == 0 {
+		list.children = append(list.children, entry{
+			label: "No screenshot",
+			icon:  "close",
+		})
+	}
+	if list.ptr >= len(list.children) {
+		list.ptr = len(list.children) - 1
+	}
+	genericAnimate(&list.entry)
+}
+
+func (s *sceneScreenshots) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneScreenshots) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneScreenshots) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneScreenshots) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneScreenshots) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+// Override rendering
+func (s *sceneScreenshots) render() {
+	list := &s.entry
+
+	_, h := menu.GetFramebufferSize()
+
+	thumbnailDrawCursor(list)
+
+	for i, e := range list.children {
+		if e.yp < -0.1 || e.yp > 1.1 {
+			continue
+		}
+
+		fontOffset := 64 * 0.7 * menu.ratio * 0.3
+
+		if e.labelAlpha > 0 && e.path != "" {
+			drawSavestateThumbnail(
+				list, i, e.path,
+				680*menu.ratio-85*e.scale*menu.ratio,
+				float32(h)*e.yp-14*menu.ratio-64*e.scale*menu.ratio+fontOffset,
+				170*menu.ratio, 128*menu.ratio,
+				e.scale, textColor.Alpha(e.iconAlpha),
+			)
+			menu.DrawBorder(
+				680*menu.ratio-85*e.scale*menu.ratio,
+				float32(h)*e.yp-14*menu.ratio-64*e.scale*menu.ratio+fontOffset,
+				170*menu.ratio*e.scale, 128*menu.ratio*e.scale, 0.02/e.scale,
+				textColor.Alpha(e.iconAlpha))
+		}
+
+		if e.labelAlpha > 0 {
+			menu.Font.SetColor(textColor.Alpha(e.labelAlpha))
+			menu.Font.Printf(
+				840*menu.ratio,
+				float32(h)*e.yp+fontOffset,
+				0.5*menu.ratio, e.label)
+		}
+	}
+}
+
+func (s *sceneScreenshots) drawHintBar() {
+	w, h := menu.GetFramebufferSize()
+	menu.DrawRect(0, float32(h)-70*menu.ratio, float32(w), 70*menu.ratio, 0, lightGrey)
+
+	_, upDown, _, a, b, x, _, _, _, guide := hintIcons()
+
+	var stack float32
+	list := menu.stack[len(menu.stack)-1].Entry()
+	if state.CoreRunning {
+		stackHint(&stack, guide, "RESUME", h)
+	}
+	stackHint(&stack, upDown, "NAVIGATE", h)
+	stackHint(&stack, b, "BACK", h)
+	if list.children[list.ptr].callbackOK != nil {
+		stackHint(&stack, a, "VIEW", h)
+	}
+	if list.children[list.ptr].callbackX != nil {
+		stackHint(&stack, x, "DELETE", h)
+	}
+}
+
+// sceneScreenshotViewer displays a screenshot over the whole screen
+type sceneScreenshotViewer struct {
+	entry
+	image uint32
+}
+
+func buildScreenshotViewer(path string) Scene {
+	var s sceneScreenshotViewer
+	s.label = "Screenshot"
+	s.image = video.NewImage(path)
+	return &s
+}
+
+func (s *sceneScreenshotViewer) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneScreenshotViewer) segueMount() {
+}
+
+func (s *sceneScreenshotViewer) segueNext() {
+}
+
+func (s *sceneScreenshotViewer) segueBack() {
+}
+
+func (s *sceneScreenshotViewer) update(dt float32) {
+	if input.Released[0][libretro.DeviceIDJoypadB] == 1 || input.Released[0][libretro.DeviceIDJoypadA] == 1 {
+		if s.image != 0 {
+			gl.DeleteTextures(1, &s.image)
+		}
+		menu.stack[len(menu.stack)-2].segueBack()
+		menu.stack = menu.stack[:len(menu.stack)-1]
+	}
+}
+
+func (s *sceneScreenshotViewer) render() {
+	w, h := menu.GetFramebufferSize()
+	menu.DrawRect(0, 0, float32(w), float32(h), 0, black)
+	if s.image == 0 {
+		return
+	}
+
+	var iw, ih int32
+	gl.BindTexture(gl.TEXTURE_2D, s.image)
+	gl.GetTexLevelParameteriv(gl.TEXTURE_2D, 0, gl.TEXTURE_WIDTH, &iw)
+	gl.GetTexLevelParameteriv(gl.TEXTURE_2D, 0, gl.TEXTURE_HEIGHT, &ih)
+	if iw == 0 || ih == 0 {
+		return
+	}
+
+	// Fit the image in the screen, above the hint bar
+	fw, fh := float32(w), float32(h)-70*menu.ratio
+	dw, dh := fw, fw*float32(ih)/float32(iw)
+	if dh > fh {
+		dw, dh = fh*float32(iw)/float32(ih), fh
+	}
+	menu.DrawImage(s.image, (fw-dw)/2, (fh-dh)/2, dw, dh, 1, white)
+}
+
+func (s *sceneScreenshotViewer) drawHintBar() {
+	w, h := menu.GetFramebufferSize()
+	menu.DrawRect(0, float32(h)-70*menu.ratio, float32(w), 70*menu.ratio, 0, lightGrey)
+
+	_, _, _, _, b, _, _, _, _, _ := hintIcons()
+
+	var stack float32
+	stackHint(&stack, b, "BACK", h)
+}
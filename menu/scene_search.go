@@ -0,0 +1,99 @@
+package menu
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/libretro/ludo/i18n"
+	ntf "github.com/libretro/ludo/notifications"
+)
+
+// fuzzyScore tells if the letters of the query appear in order in s, ignoring
+// case and spaces. The lower the score, the closer the letters are to each
+// other and to the start of s.
+func fuzzyScore(query, s string) (int, bool) {
+	q := []rune(strings.ToLower(strings.Join(strings.Fields(query), "")))
+	score, last := 0, -1
+	for i, r := range []rune(strings.ToLower(s)) {
+		if len(q) == 0 {
+			break
+		}
+		if r != q[0] {
+			continue
+		}
+		if last < 0 {
+			score += i
+		} else {
+			score += i - last - 1
+		}
+		last = i
+		q = q[1:]
+	}
+	return score, len(q) == 0
+}
+
+// searchSettings returns the indexes of the entries of the settings whose
+// label, in English or translated, matches a query, best matches first
+func searchSettings(query string, children []entry) []int {
+	var found []int
+	scores := map[int]int{}
+	for i, e := range children {
+		score, ok := fuzzyScore(query, e.label)
+		if s, tok := fuzzyScore(query, i18n.T(e.label)); tok && (!ok || s < score) {
+			score, ok = s, true
+		}
+		if ok {
+			found = append(found, i)
+			scores[i] = score
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		return scores[found[i]] < scores[found[j]]
+	})
+	return found
+}
+
+// buildSettingsSearch lists the settings matching a query. Selecting one jumps
+// to it in the settings page.
+func buildSettingsSearch(query string, page *sceneSettings) Scene {
+	var list sceneSettings
+	list.label = "Search: " + query
+
+	for _, i := range searchSettings(query, page.children) {
+		i := i
+		e := page.children[i]
+		if e.label == "Search" {
+			continue
+		}
+		e.callbackOK = func() {
+			menu.stack = menu.stack[:len(menu.stack)-1]
+			page.ptr = i
+			page.segueBack()
+		}
+		list.children = append(list.children, e)
+	}
+
+	list.segueMount()
+
+	return &list
+}
+
+// searchEntry is the entry opening the keyboard to search the settings
+func searchEntry(page *sceneSettings) entry {
+	return entry{
+		label: "Search",
+		icon:  "subsetting",
+		callbackOK: func() {
+			page.segueNext()
+			menu.Push(buildKeyboardThen("Search settings", func(query string) {
+				results := buildSettingsSearch(query, page)
+				if len(results.Entry().children) == 0 {
+					ntf.DisplayAndLog(ntf.Warning, "Menu", "No settings match %s.", query)
+					return
+				}
+				page.segueNext()
+				menu.Push(results)
+			}))
+		},
+	}
+}
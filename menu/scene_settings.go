@@ -4,16 +4,33 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/fatih/structs"
 	"github.com/go-gl/glfw/v3.3/glfw"
 
+	"github.com/libretro/ludo/achievements"
 	"github.com/libretro/ludo/audio"
+	"github.com/libretro/ludo/buildbot"
+	"github.com/libretro/ludo/cheats"
+	"github.com/libretro/ludo/crash"
+	"github.com/libretro/ludo/discord"
+	"github.com/libretro/ludo/i18n"
+	"github.com/libretro/ludo/input"
+	"github.com/libretro/ludo/logs"
 	"github.com/libretro/ludo/ludos"
+	"github.com/libretro/ludo/mainthread"
 	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/overlay"
+	"github.com/libretro/ludo/record"
+	"github.com/libretro/ludo/remote"
 	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/speech"
 	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/themes"
 	"github.com/libretro/ludo/utils"
+	"github.com/libretro/ludo/video"
+	"github.com/libretro/ludo/viewport"
 )
 
 type sceneSettings struct {
@@ -33,6 +50,8 @@ func buildSettings() Scene {
 	var list sceneSettings
 	list.label = "Settings"
 
+	list.children = append(list.children, searchEntry(&list))
+
 	if state.LudOS {
 		list.children = append(list.children, entry{
 			label:       "Wi-Fi",
@@ -45,6 +64,24 @@ func buildSettings() Scene {
 		})
 	}
 
+	list.children = append(list.children, entry{
+		label: "Port Binds",
+		icon:  "subsetting",
+		callbackOK: func() {
+			list.segueNext()
+			menu.Push(buildPortBinds())
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Logs",
+		icon:  "subsetting",
+		callbackOK: func() {
+			list.segueNext()
+			menu.Push(buildLogs())
+		},
+	})
+
 	fields := structs.Fields(&settings.Current)
 	for _, f := range fields {
 		f := f
@@ -53,31 +90,17 @@ func buildSettings() Scene {
 			continue
 		}
 
-		if f.Tag("widget") == "dir" {
+		switch f.Tag("widget") {
+		case "dir":
 			// Directory settings
-			list.children = append(list.children, entry{
-				label: f.Tag("label"),
-				icon:  "folder",
-				value: f.Value,
-				stringValue: func() string {
-					return "[" + utils.FileName(f.Value().(string)) + "]"
-				},
-				widget: widgets[f.Tag("widget")],
-				callbackOK: func() {
-					list.segueNext()
-					menu.Push(buildExplorer(
-						f.Value().(string),
-						nil,
-						func(path string) { dirExplorerCb(path, f) },
-						&entry{
-							label: "<Select this directory>",
-							icon:  "scan",
-						},
-						nil,
-					))
-				},
-			})
-		} else {
+			list.children = append(list.children, dirEntry(&list, f))
+		case "text", "password":
+			// Text settings, typed with the on-screen keyboard
+			list.children = append(list.children, textEntry(&list, f))
+		case "login":
+			// Accounts, only the token of the login is stored
+			list.children = append(list.children, loginEntry(&list, f))
+		default:
 			// Regular settings
 			list.children = append(list.children, entry{
 				label: f.Tag("label"),
@@ -87,6 +110,10 @@ func buildSettings() Scene {
 				},
 				value: f.Value,
 				stringValue: func() string {
+					// Some settings give a meaning to 0, like no limit
+					if zero := f.Tag("zero"); zero != "" && fmt.Sprint(f.Value()) == "0" {
+						return zero
+					}
 					return fmt.Sprintf(f.Tag("fmt"), f.Value())
 				},
 				widget: widgets[f.Tag("widget")],
@@ -99,6 +126,94 @@ func buildSettings() Scene {
 	return &list
 }
 
+// dirEntry is the entry of a directory setting, opening an explorer to select
+// the directory
+func dirEntry(list Scene, f *structs.Field) entry {
+	return entry{
+		label: f.Tag("label"),
+		icon:  "folder",
+		value: f.Value,
+		stringValue: func() string {
+			return "[" + utils.FileName(f.Value().(string)) + "]"
+		},
+		widget: widgets[f.Tag("widget")],
+		callbackOK: func() {
+			list.segueNext()
+			menu.Push(buildExplorer(
+				f.Value().(string),
+				nil,
+				func(path string) { dirExplorerCb(path, f) },
+				&entry{
+					label: "<Select this directory>",
+					icon:  "scan",
+				},
+				nil,
+			))
+		},
+	}
+}
+
+// textEntry is the entry of a text setting, opening the keyboard to type it.
+// Passwords are masked.
+func textEntry(list Scene, f *structs.Field) entry {
+	secret := f.Tag("widget") == "password"
+	return entry{
+		label: f.Tag("label"),
+		icon:  "subsetting",
+		value: f.Value,
+		stringValue: func() string {
+			v := f.Value().(string)
+			if secret {
+				v = strings.Repeat("*", len([]rune(v)))
+			}
+			return "[" + v + "]"
+		},
+		callbackOK: func() {
+			list.segueNext()
+			menu.Push(newKeyboard(f.Tag("label"), f.Value().(string), secret, func(v string) {
+				f.Set(v)
+				settings.Save()
+			}))
+		},
+	}
+}
+
+// loginEntry is the entry of the RetroAchievements account. The password is
+// typed with the on-screen keyboard and exchanged for a token in the
+// background, the field stores the token.
+func loginEntry(list Scene, f *structs.Field) entry {
+	return entry{
+		label: f.Tag("label"),
+		icon:  "subsetting",
+		value: f.Value,
+		stringValue: func() string {
+			if f.Value().(string) == "" {
+				return "[Not logged in]"
+			}
+			return "[Logged in]"
+		},
+		callbackOK: func() {
+			list.segueNext()
+			user := settings.Current.AchievementsUsername
+			menu.Push(newKeyboard(f.Tag("label"), "", true, func(password string) {
+				go func() {
+					defer crash.Recover()
+					tok, err := achievements.Login(user, password)
+					mainthread.Post(func() {
+						if err != nil {
+							ntf.DisplayAndLog(ntf.Error, "Menu", "Error logging in: %v", err.Error())
+							return
+						}
+						f.Set(tok)
+						settings.Save()
+						ntf.DisplayAndLog(ntf.Success, "Menu", "Logged in to RetroAchievements.")
+					})
+				}()
+			}))
+		},
+	}
+}
+
 // triggered when selecting a directory in the settings file explorer
 func dirExplorerCb(path string, f *structs.Field) {
 	var err error
@@ -178,10 +293,18 @@ var incrCallbacks = map[string]callbackIncrement{
 			v = len(glfw.GetMonitors()) - 1
 		}
 		f.Set(v)
+		ntf.DisplayAndLog(ntf.Info, "Menu", "Monitor: %s", video.MonitorName(v))
 		menu.Reconfigure(settings.Current.VideoFullscreen)
 		menu.ContextReset()
 		settings.Save()
 	},
+	"VideoFullscreenMode": func(f *structs.Field, direction int) {
+		cycleIncrCallback(video.FullscreenModes)(f, direction)
+		if settings.Current.VideoFullscreen {
+			menu.Reconfigure(true)
+			menu.ContextReset()
+		}
+	},
 	"VideoFilter": func(f *structs.Field, direction int) {
 		filters := []string{"Raw", "Smooth", "Pixel Perfect", "CRT", "LCD"}
 		v := f.Value().(string)
@@ -197,11 +320,103 @@ var incrCallbacks = map[string]callbackIncrement{
 		menu.UpdateFilter(filters[i])
 		settings.Save()
 	},
-	"VideoDarkMode": func(f *structs.Field, direction int) {
+	"MenuTheme": func(f *structs.Field, direction int) {
+		cycleIncrCallback(themes.Names())(f, direction)
+		menu.applyTheme()
+	},
+	"KeyboardLayout": func(f *structs.Field, direction int) {
+		cycleIncrCallback(keyboardLayoutNames)(f, direction)
+	},
+	"MenuAttractDelay": func(f *structs.Field, direction int) {
+		v := f.Value().(int)
+		v += direction
+		if v < 0 || v > 60 {
+			return
+		}
+		f.Set(v)
+		settings.Save()
+	},
+	"PlaylistSort":     cycleIncrCallback(playlistSorts),
+	"CoreUpdatesCheck": cycleIncrCallback(buildbot.CheckIntervals),
+	"MenuScale": func(f *structs.Field, direction int) {
+		v := f.Value().(float32)
+		v += 0.25 * float32(direction)
+		if v < 0.5 || v > 2 {
+			return
+		}
+		f.Set(v)
+		settings.Save()
+	},
+	"MenuFontSize": func(f *structs.Field, direction int) {
+		v := f.Value().(float32)
+		v += 0.25 * float32(direction)
+		if v < 0.75 || v > 2 {
+			return
+		}
+		f.Set(v)
+		menu.SetFontSize(v)
+		settings.Save()
+	},
+	"MenuSpeech": func(f *structs.Field, direction int) {
 		v := f.Value().(bool)
 		v = !v
 		f.Set(v)
 		settings.Save()
+		if !v {
+			speech.Stop()
+		}
+	},
+	"VideoIntegerScale": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		v = !v
+		f.Set(v)
+		settings.Save()
+	},
+	"VideoCropOverscan": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		v = !v
+		f.Set(v)
+		settings.Save()
+	},
+	"VideoCustomViewport": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		v = !v
+		f.Set(v)
+		settings.Save()
+	},
+	"VideoViewportWidth":  viewportSizeIncrCallback,
+	"VideoViewportHeight": viewportSizeIncrCallback,
+	"VideoViewportX":      viewportOffsetIncrCallback,
+	"VideoViewportY":      viewportOffsetIncrCallback,
+	"VideoAspectRatio":    cycleIncrCallback(viewport.AspectRatios),
+	"VideoCustomAspectRatio": func(f *structs.Field, direction int) {
+		v := f.Value().(float32)
+		v = incrAspectRatio(v, direction)
+		f.Set(v)
+		settings.Save()
+	},
+	"VideoRotation":       rotationIncrCallback,
+	"VideoScreenRotation": rotationIncrCallback,
+	"ScreenshotPostShader": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		v = !v
+		f.Set(v)
+		settings.Save()
+	},
+	"SavestateAutoIncrement": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		v = !v
+		f.Set(v)
+		settings.Save()
+	},
+	"SavestateSlots": func(f *structs.Field, direction int) {
+		v := f.Value().(int)
+		v += direction
+		if v < 1 || v > 99 {
+			return
+		}
+		f.Set(v)
+		settings.Save()
 	},
 	"MapAxisToDPad": func(f *structs.Field, direction int) {
 		v := f.Value().(bool)
@@ -209,6 +424,212 @@ var incrCallbacks = map[string]callbackIncrement{
 		f.Set(v)
 		settings.Save()
 	},
+	"VideoSwapInterval": func(f *structs.Field, direction int) {
+		v := f.Value().(int)
+		v += direction
+		if v < 0 {
+			v = 0
+		}
+		if v > video.MaxSwapInterval {
+			v = video.MaxSwapInterval
+		}
+		f.Set(v)
+		settings.Save()
+	},
+	"VideoVRR": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		v = !v
+		f.Set(v)
+		settings.Save()
+	},
+	"VideoSyncExactFPS": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		v = !v
+		f.Set(v)
+		settings.Save()
+	},
+	"VideoMatchRefreshRate": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		v = !v
+		f.Set(v)
+		settings.Save()
+	},
+	"VideoBlackFrames": func(f *structs.Field, direction int) {
+		v := f.Value().(int)
+		v += direction
+		if v < 0 {
+			v = 0
+		}
+		if v > video.MaxBlackFrames {
+			v = video.MaxBlackFrames
+		}
+		f.Set(v)
+		settings.Save()
+		if msg := menu.BlackFramesProblem(); v > 0 && msg != "" {
+			ntf.DisplayAndLog(ntf.Warning, "Menu", msg)
+		}
+	},
+	"VideoFrameDelay": func(f *structs.Field, direction int) {
+		v := f.Value().(int)
+		v += direction
+		if v < 0 {
+			v = 0
+		}
+		if v > video.MaxFrameDelay {
+			v = video.MaxFrameDelay
+		}
+		if v > 0 && f.Value().(int) == 0 {
+			ntf.DisplayAndLog(ntf.Warning, "Menu", "Frame delay lowers latency but can cause stutter on slow systems.")
+		}
+		f.Set(v)
+		settings.Save()
+	},
+	"VideoHardSync": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		v = !v
+		if v {
+			ntf.DisplayAndLog(ntf.Warning, "Menu", "Hard GPU sync lowers latency but costs performance.")
+		}
+		f.Set(v)
+		settings.Save()
+	},
+	"VideoThreaded": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		v = !v
+		f.Set(v)
+		menu.Reconfigure(settings.Current.VideoFullscreen)
+		menu.ContextReset()
+		settings.Save()
+	},
+	"CoreThread": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		v = !v
+		f.Set(v)
+		settings.Save()
+	},
+	"VideoHardSyncFrames": func(f *structs.Field, direction int) {
+		v := f.Value().(int)
+		v += direction
+		if v < 0 {
+			v = 0
+		}
+		if v > 3 {
+			v = 3
+		}
+		f.Set(v)
+		settings.Save()
+	},
+	"ThumbnailsCacheSize": func(f *structs.Field, direction int) {
+		v := f.Value().(int)
+		v += direction * 100
+		if v < 0 || v > 10000 {
+			return
+		}
+		f.Set(v)
+		settings.Save()
+	},
+	"ScanOnStartup": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		v = !v
+		f.Set(v)
+		settings.Save()
+	},
+	"BezelEnable": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		v = !v
+		f.Set(v)
+		settings.Save()
+	},
+	"Language": func(f *structs.Field, direction int) {
+		cycleIncrCallback(i18n.Languages())(f, direction)
+		i18n.Set(settings.Current.Language)
+	},
+	"RecordQuality": cycleIncrCallback(record.Qualities),
+	"RecordFormat":  cycleIncrCallback(record.Formats),
+	"RecordStream": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		v = !v
+		f.Set(v)
+		settings.Save()
+	},
+	"AchievementsHardcore": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		v = !v
+		f.Set(v)
+		settings.Save()
+		cheats.Apply()
+		if v && state.CoreRunning {
+			// Progress made with savestates or cheats doesn't count
+			state.Core.Reset()
+			state.Paused = false
+			ntf.DisplayAndLog(ntf.Info, "Settings", "Hardcore mode enabled, the game was reset.")
+		}
+	},
+	"LogLevel": func(f *structs.Field, direction int) {
+		cycleIncrCallback(logs.Levels)(f, direction)
+		applyLogs()
+	},
+	"LogToFile": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		v = !v
+		f.Set(v)
+		settings.Save()
+		applyLogs()
+	},
+	"EnvironmentAudit": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		v = !v
+		f.Set(v)
+		settings.Save()
+	},
+	"SingleInstance": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		v = !v
+		f.Set(v)
+		settings.Save()
+	},
+	"PauseOnFocusLoss": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		v = !v
+		f.Set(v)
+		settings.Save()
+	},
+	"KioskMode": func(f *structs.Field, direction int) {
+		enterKiosk()
+	},
+	"RemoteEnable": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		v = !v
+		f.Set(v)
+		settings.Save()
+		if v {
+			remote.Start(menu)
+			showRemoteURL()
+		} else {
+			remote.Stop()
+		}
+	},
+	"RemoteLAN": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		f.Set(!v)
+		settings.Save()
+		if settings.Current.RemoteEnable {
+			remote.Stop()
+			remote.Start(menu)
+			showRemoteURL()
+		}
+	},
+	"DiscordEnable": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		v = !v
+		f.Set(v)
+		settings.Save()
+		if v {
+			discord.Start()
+		} else {
+			discord.Stop()
+		}
+	},
 	"AudioVolume": func(f *structs.Field, direction int) {
 		v := f.Value().(float32)
 		v += 0.1 * float32(direction)
@@ -222,6 +643,24 @@ var incrCallbacks = map[string]callbackIncrement{
 		audio.SetVolume(v)
 		settings.Save()
 	},
+	"MenuSounds": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		v = !v
+		f.Set(v)
+		settings.Save()
+	},
+	"MenuMusicVolume": func(f *structs.Field, direction int) {
+		v := f.Value().(float32)
+		v += 0.1 * float32(direction)
+		if v < 0 {
+			v = 0
+		}
+		if v > 1 {
+			v = 1
+		}
+		f.Set(v)
+		settings.Save()
+	},
 	"MenuAudioVolume": func(f *structs.Field, direction int) {
 		v := f.Value().(float32)
 		v += 0.1 * float32(direction)
@@ -235,17 +674,232 @@ var incrCallbacks = map[string]callbackIncrement{
 		audio.SetEffectsVolume(v)
 		settings.Save()
 	},
+	"RumbleStrength": func(f *structs.Field, direction int) {
+		v := f.Value().(float32)
+		v += 0.1 * float32(direction)
+		if v < 0 {
+			v = 0
+		}
+		if v > 1 {
+			v = 1
+		}
+		f.Set(v)
+		settings.Save()
+	},
+	"OverlayEnable": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		v = !v
+		f.Set(v)
+		settings.Save()
+	},
+	"OverlayLayout": func(f *structs.Field, direction int) {
+		cycleIncrCallback(overlay.Names())(f, direction)
+	},
+	"OverlayOpacity": func(f *structs.Field, direction int) {
+		v := f.Value().(float32)
+		v += 0.1 * float32(direction)
+		if v < 0.1 {
+			v = 0.1
+		}
+		if v > 0.5 {
+			v = 0.5
+		}
+		f.Set(v)
+		settings.Save()
+	},
+	"AudioDevice": func(f *structs.Field, direction int) {
+		devices := append([]string{audio.DefaultDevice}, audio.Devices()...)
+		cycleIncrCallback(devices)(f, direction)
+		if err := audio.SetDevice(settings.Current.AudioDevice); err != nil {
+			ntf.DisplayAndLog(ntf.Error, "Settings", err.Error())
+		}
+	},
+	"AudioBufferSize": func(f *structs.Field, direction int) {
+		v := f.Value().(int)
+		i := 0
+		for j, s := range audio.BufferSizes {
+			if s == v {
+				i = j
+			}
+		}
+		i += direction
+		if i < 0 {
+			i = 0
+		}
+		if i > len(audio.BufferSizes)-1 {
+			i = len(audio.BufferSizes) - 1
+		}
+		f.Set(audio.BufferSizes[i])
+		settings.Save()
+		if state.CoreRunning {
+			audio.SetDevice(settings.Current.AudioDevice)
+		}
+	},
+	"AudioLatencyMode": func(f *structs.Field, direction int) {
+		cycleIncrCallback(audio.LatencyModes)(f, direction)
+		ntf.DisplayAndLog(ntf.Info, "Settings", "Restart Ludo to change the audio latency mode.")
+	},
+	"AudioResampler": cycleIncrCallback(audio.Resamplers),
+	"AudioRateControl": func(f *structs.Field, direction int) {
+		v := f.Value().(float32)
+		v += 0.001 * float32(direction)
+		if v < 0.0005 {
+			v = 0
+		}
+		if v > 0.02 {
+			v = 0.02
+		}
+		f.Set(v)
+		settings.Save()
+	},
+	"AudioSyncRefreshRate": func(f *structs.Field, direction int) {
+		v := f.Value().(bool)
+		v = !v
+		f.Set(v)
+		settings.Save()
+	},
+	"NotificationsPosition": cycleIncrCallback(notificationPositions),
+	"NotificationsDuration": func(f *structs.Field, direction int) {
+		v := f.Value().(float32)
+		v += float32(direction)
+		if v < 1 {
+			v = 1
+		}
+		if v > 10 {
+			v = 10
+		}
+		f.Set(v)
+		ntf.Duration = v
+		settings.Save()
+	},
+	"NotificationsFontSize": func(f *structs.Field, direction int) {
+		v := f.Value().(float32)
+		v += 0.1 * float32(direction)
+		if v < 0.3 {
+			v = 0.3
+		}
+		if v > 1 {
+			v = 1
+		}
+		f.Set(v)
+		settings.Save()
+	},
 	"ShowHiddenFiles": func(f *structs.Field, direction int) {
 		v := f.Value().(bool)
 		v = !v
 		f.Set(v)
 		settings.Save()
 	},
-	"SSHService":       ludos.ServiceSettingIncrCallback,
-	"SambaService":     ludos.ServiceSettingIncrCallback,
-	"BluetoothService": ludos.ServiceSettingIncrCallback,
+	"HotkeyEnable":            buttonIncrCallback,
+	"HotkeyMenuToggleKey":     keyIncrCallback,
+	"HotkeyMenuToggleButton":  buttonIncrCallback,
+	"HotkeyFastForwardKey":    keyIncrCallback,
+	"HotkeyFastForwardButton": buttonIncrCallback,
+	"HotkeySaveStateKey":      keyIncrCallback,
+	"HotkeySaveStateButton":   buttonIncrCallback,
+	"HotkeyLoadStateKey":      keyIncrCallback,
+	"HotkeyLoadStateButton":   buttonIncrCallback,
+	"HotkeyScreenshotKey":     keyIncrCallback,
+	"HotkeyScreenshotButton":  buttonIncrCallback,
+	"HotkeyFullscreenKey":     keyIncrCallback,
+	"HotkeyFullscreenButton":  buttonIncrCallback,
+	"HotkeyQuitKey":           keyIncrCallback,
+	"HotkeyQuitButton":        buttonIncrCallback,
+	"HotkeyGameFocusKey":      keyIncrCallback,
+	"HotkeyMacroRecordKey":    keyIncrCallback,
+	"HotkeyMacroRecordButton": buttonIncrCallback,
+	"HotkeyMacroPlayKey":      keyIncrCallback,
+	"HotkeyMacroPlayButton":   buttonIncrCallback,
+	"HotkeyRecordKey":         keyIncrCallback,
+	"HotkeyRecordButton":      buttonIncrCallback,
+	"HotkeyMuteKey":           keyIncrCallback,
+	"HotkeyMuteButton":        buttonIncrCallback,
+	"SSHService":              ludos.ServiceSettingIncrCallback,
+	"SambaService":            ludos.ServiceSettingIncrCallback,
+	"BluetoothService":        ludos.ServiceSettingIncrCallback,
+
+	"HotkeyPauseKey":           keyIncrCallback,
+	"HotkeyPauseButton":        buttonIncrCallback,
+	"HotkeyFrameAdvanceKey":    keyIncrCallback,
+	"HotkeyFrameAdvanceButton": buttonIncrCallback,
+	"HotkeyPerfOverlayKey":     keyIncrCallback,
+	"HotkeyPerfOverlayButton":  buttonIncrCallback,
+}
+
+// viewportStep is the number of pixels a custom viewport dimension changes by
+const viewportStep = 8
+
+// viewportSizeIncrCallback changes the size of the custom viewport
+func viewportSizeIncrCallback(f *structs.Field, direction int) {
+	v := f.Value().(int)
+	v += viewportStep * direction
+	if v < viewportStep {
+		v = viewportStep
+	}
+	f.Set(v)
+	settings.Save()
+}
+
+// viewportOffsetIncrCallback moves the custom viewport
+func viewportOffsetIncrCallback(f *structs.Field, direction int) {
+	v := f.Value().(int)
+	v += viewportStep * direction
+	f.Set(v)
+	settings.Save()
+}
+
+// rotationIncrCallback cycles a rotation setting by quarter turns
+func rotationIncrCallback(f *structs.Field, direction int) {
+	v := f.Value().(int)
+	v = ((v+90*direction)%360 + 360) % 360
+	f.Set(v)
+	settings.Save()
+}
+
+// incrAspectRatio changes a custom aspect ratio by a step of 0.01
+func incrAspectRatio(v float32, direction int) float32 {
+	v += 0.01 * float32(direction)
+	if v < 0.25 {
+		v = 0.25
+	}
+	if v > 4 {
+		v = 4
+	}
+	return v
+}
+
+// cycleIncrCallback returns a callback that cycles a string setting through
+// a list of allowed values
+// applyLogs applies the changes to the log settings
+func applyLogs() {
+	s := settings.Current
+	if err := logs.Setup(s.LogLevel, s.LogToFile, state.Verbose); err != nil {
+		ntf.DisplayAndLog(ntf.Error, "Settings", "Can't open the log file: %v", err)
+	}
+}
+
+func cycleIncrCallback(values []string) callbackIncrement {
+	return func(f *structs.Field, direction int) {
+		v := f.Value().(string)
+		i := utils.IndexOfString(v, values)
+		i += direction
+		if i < 0 {
+			i = len(values) - 1
+		}
+		if i > len(values)-1 {
+			i = 0
+		}
+		f.Set(values[i])
+		settings.Save()
+	}
 }
 
+// Used to bind hot keys to keyboard keys and joypad buttons
+var (
+	keyIncrCallback    = cycleIncrCallback(input.KeyNames)
+	buttonIncrCallback = cycleIncrCallback(input.ButtonNames)
+)
+
 // Generic stuff
 
 func (s *sceneSettings) Entry() *entry {
@@ -0,0 +1,152 @@
+package menu
+
+import (
+	"fmt"
+
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/shaders"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+)
+
+type sceneShaders struct {
+	entry
+}
+
+func buildShaders() Scene {
+	var list sceneShaders
+	list.label = "Shaders"
+
+	list.build()
+	list.segueMount()
+
+	return &list
+}
+
+// build creates the entries of the scene, the parameter entries depend on
+// the selected preset
+func (s *sceneShaders) build() {
+	s.children = []entry{}
+
+	s.children = append(s.children, entry{
+		label: "Preset",
+		icon:  "subsetting",
+		stringValue: func() string {
+			if shaders.Current.Preset == "" {
+				return "None"
+			}
+			return shaders.Current.Preset
+		},
+		incr: func(direction int) {
+			names := append([]string{""}, shaders.Names()...)
+			i := 0
+			if utils.StringInSlice(shaders.Current.Preset, names) {
+				i = utils.IndexOfString(shaders.Current.Preset, names)
+			}
+			i = (i + direction + len(names)) % len(names)
+			shaders.Select(names[i])
+			applyPreset()
+			s.build()
+			s.segueMount()
+			menu.tweens.FastForward()
+		},
+	})
+
+	if p, ok := shaders.Selected(); ok {
+		for _, param := range p.Parameters {
+			param := param
+			s.children = append(s.children, entry{
+				label: param.Label,
+				icon:  "subsetting",
+				stringValue: func() string {
+					return fmt.Sprintf("%.2f", shaders.Value(param))
+				},
+				incr: func(direction int) {
+					shaders.Incr(param, direction)
+				},
+			})
+		}
+	}
+
+	s.children = append(s.children, entry{
+		label: "Save Core Preset",
+		icon:  "subsetting",
+		callbackOK: func() {
+			if err := shaders.SaveCore(state.CorePath); err != nil {
+				ntf.DisplayAndLog(ntf.Error, "Menu", "Error saving shader preset: %v", err.Error())
+				return
+			}
+			ntf.DisplayAndLog(ntf.Success, "Menu", "Shader preset saved for this core.")
+		},
+	})
+
+	s.children = append(s.children, entry{
+		label: "Save Game Preset",
+		icon:  "subsetting",
+		callbackOK: func() {
+			if err := shaders.SaveGame(state.CorePath, state.GameCRC); err != nil {
+				ntf.DisplayAndLog(ntf.Error, "Menu", "Error saving shader preset: %v", err.Error())
+				return
+			}
+			ntf.DisplayAndLog(ntf.Success, "Menu", "Shader preset saved for this game.")
+		},
+	})
+}
+
+// applyPreset compiles the selected preset, or restores the video filter if
+// none is selected
+func applyPreset() {
+	p, ok := shaders.Selected()
+	if !ok {
+		menu.SetPreset(nil)
+		return
+	}
+	if err := menu.SetPreset(&p); err != nil {
+		ntf.DisplayAndLog(ntf.Error, "Menu", "Error loading shader preset: %v", err.Error())
+		shaders.Select("")
+	}
+}
+
+func (s *sceneShaders) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneShaders) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneShaders) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneShaders) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneShaders) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneShaders) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneShaders) drawHintBar() {
+	w, h := menu.GetFramebufferSize()
+	menu.DrawRect(0, float32(h)-70*menu.ratio, float32(w), 70*menu.ratio, 0, lightGrey)
+
+	_, upDown, leftRight, a, b, _, _, _, _, guide := hintIcons()
+
+	var stack float32
+	list := menu.stack[len(menu.stack)-1].Entry()
+	if state.CoreRunning {
+		stackHint(&stack, guide, "RESUME", h)
+	}
+	stackHint(&stack, upDown, "NAVIGATE", h)
+	stackHint(&stack, b, "BACK", h)
+	if list.children[list.ptr].callbackOK != nil {
+		stackHint(&stack, a, "OK", h)
+	} else {
+		stackHint(&stack, leftRight, "SET", h)
+	}
+}
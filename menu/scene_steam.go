@@ -0,0 +1,124 @@
+package menu
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/playlists"
+	"github.com/libretro/ludo/steam"
+	"github.com/libretro/ludo/utils"
+)
+
+type sceneSteam struct {
+	entry
+	selected map[string]bool
+}
+
+// buildSteam lets the user pick the playlists to add to Steam
+func buildSteam() Scene {
+	var list sceneSteam
+	list.label = "Export to Steam"
+	list.selected = map[string]bool{}
+
+	list.children = append(list.children, entry{
+		label: "Export Selected Playlists",
+		icon:  "subsetting",
+		callbackOK: func() {
+			exportToSteam(list.selected)
+		},
+	})
+
+	var keys []string
+	for k := range playlists.Playlists {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, path := range keys {
+		path := path
+		list.children = append(list.children, entry{
+			label: playlists.ShortName(utils.FileName(path)),
+			icon:  "subsetting",
+			value: func() interface{} {
+				return list.selected[path]
+			},
+			incr: func(direction int) {
+				list.selected[path] = !list.selected[path]
+			},
+			widget: widgets["switch"],
+		})
+	}
+
+	list.segueMount()
+
+	return &list
+}
+
+// exportToSteam adds the games of the selected playlists to the Steam
+// accounts of this computer
+func exportToSteam(selected map[string]bool) {
+	dir := steam.Dir()
+	if dir == "" {
+		ntf.DisplayAndLog(ntf.Error, "Menu", "Steam not found.")
+		return
+	}
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		ntf.DisplayAndLog(ntf.Error, "Menu", err.Error())
+		return
+	}
+
+	var shortcuts []steam.Shortcut
+	for path, ok := range selected {
+		if !ok {
+			continue
+		}
+		for _, game := range playlists.Playlists[path] {
+			shortcuts = append(shortcuts, steam.NewShortcut(exe, utils.FileName(path), game.Path, game.Name))
+		}
+	}
+	if len(shortcuts) == 0 {
+		ntf.DisplayAndLog(ntf.Warning, "Menu", "No games selected.")
+		return
+	}
+
+	for _, u := range steam.UserDirs(dir) {
+		if err := steam.Export(u, shortcuts); err != nil {
+			ntf.DisplayAndLog(ntf.Error, "Menu", "Error exporting to Steam: %v", err.Error())
+			return
+		}
+	}
+	ntf.DisplayAndLog(ntf.Success, "Menu", "%d games exported, restart Steam to see them.", len(shortcuts))
+}
+
+func (s *sceneSteam) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneSteam) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneSteam) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneSteam) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneSteam) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneSteam) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneSteam) drawHintBar() {
+	genericDrawHintBar()
+}
@@ -7,9 +7,12 @@ import (
 	"sort"
 
 	"github.com/libretro/ludo/audio"
+	"github.com/libretro/ludo/history"
+	"github.com/libretro/ludo/i18n"
 	"github.com/libretro/ludo/input"
 	"github.com/libretro/ludo/libretro"
 	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/parental"
 	"github.com/libretro/ludo/playlists"
 	"github.com/libretro/ludo/scanner"
 	"github.com/libretro/ludo/state"
@@ -26,10 +29,32 @@ type sceneTabs struct {
 	entry
 }
 
+// tabsHead is the number of tabs before the playlists
+var tabsHead int
+
 func buildTabs() Scene {
 	var list sceneTabs
 	list.label = "Ludo"
 
+	if kiosk() {
+		tabsHead = 0
+		list.children = append(list.children, getPlaylists()...)
+		list.children = append(list.children, entry{
+			label:      "Exit Kiosk Mode",
+			subLabel:   "Unlock the menus with the PIN",
+			icon:       "setting",
+			callbackOK: exitKiosk,
+		})
+		list.segueMount()
+		return &list
+	}
+	tabsHead = 3
+
+	if len(history.List) > 0 {
+		tabsHead++
+		list.children = append(list.children, continueEntry())
+	}
+
 	list.children = append(list.children, entry{
 		label:    "Main Menu",
 		subLabel: "Load cores and games manually",
@@ -72,6 +97,16 @@ func buildTabs() Scene {
 				&entry{
 					label: "<Scan this directory>",
 					icon:  "scan",
+					children: []entry{{
+						label: "<Preview scan>",
+						icon:  "scan",
+						callbackDir: func(path string) {
+							scanner.PreviewDir(path, func(p scanner.Preview) {
+								menu.stack[len(menu.stack)-1].segueNext()
+								menu.Push(buildScanPreview(path, p))
+							})
+						},
+					}},
 				},
 				nil,
 			))
@@ -91,13 +126,13 @@ func refreshTabs() {
 	l := len(e.children)
 	pls := getPlaylists()
 
-	// This assumes that the 3 first tabs are not playlists, and that the last
-	// tab is the scanner.
-	e.children = append(e.children[:3], append(pls, e.children[l-1:]...)...)
+	// This assumes that the tabsHead first tabs are not playlists, and that
+	// the last tab is the scanner, or the kiosk exit.
+	e.children = append(e.children[:tabsHead], append(pls, e.children[l-1:]...)...)
 
 	// Update which tab is the active tab after the refresh
-	if e.ptr >= 3 {
-		e.ptr += len(pls) - (l - 4)
+	if e.ptr >= tabsHead {
+		e.ptr += len(pls) - (l - tabsHead - 1)
 	}
 
 	// Ensure new icons are styled properly
@@ -145,15 +180,20 @@ func getPlaylists() []entry {
 		filename := utils.FileName(path)
 		count := playlists.Count(path)
 		label := playlists.ShortName(filename)
-		pls = append(pls, entry{
+		e := entry{
 			label:    label,
-			subLabel: fmt.Sprintf("%d Games", count),
+			subLabel: fmt.Sprintf(i18n.T("%d Games"), count),
 			icon:     filename,
 			callbackOK: func() {
-				menu.Push(buildPlaylist(path))
+				unlockParental(parental.RestrictedPlaylist(filename), func() {
+					menu.Push(buildPlaylist(path))
+				})
 			},
-			callbackX: func() { askDeletePlaylistConfirmation(func() { deletePlaylist(path) }) },
-		})
+		}
+		if !kiosk() {
+			e.callbackX = func() { askDeletePlaylistConfirmation(func() { deletePlaylist(path) }) }
+		}
+		pls = append(pls, e)
 	}
 	return pls
 }
@@ -243,6 +283,19 @@ func (tabs *sceneTabs) segueNext() {
 }
 
 func (tabs *sceneTabs) update(dt float32) {
+	// The last played game changes when launching games from the other tabs
+	if tabsHead > 3 && len(history.List) > 0 {
+		tabs.children[0].subLabel, _ = extractTags(history.List[0].Name)
+	}
+
+	// The scanner tab shows the progress of the background scans
+	if last := &tabs.children[len(tabs.children)-1]; last.label == "Add games" {
+		last.subLabel = "Scan your collection"
+		if status := scanner.Status(); status != "" {
+			last.subLabel = status
+		}
+	}
+
 	// Right
 	repeatRight(dt, input.NewState[0][libretro.DeviceIDJoypadRight] == 1, func() {
 		tabs.ptr++
@@ -295,10 +348,11 @@ func (tabs sceneTabs) render() {
 
 		if e.labelAlpha > 0 {
 			menu.Font.SetColor(c.Alpha(e.labelAlpha))
-			lw := menu.Font.Width(0.5*menu.ratio, e.label)
-			menu.Font.Printf(x-lw/2, float32(int(float32(h)/2+250*menu.ratio)), 0.5*menu.ratio, e.label)
-			lw = menu.Font.Width(0.4*menu.ratio, e.subLabel)
-			menu.Font.Printf(x-lw/2, float32(int(float32(h)/2+330*menu.ratio)), 0.4*menu.ratio, e.subLabel)
+			label, subLabel := i18n.T(e.label), i18n.T(e.subLabel)
+			lw := menu.Font.Width(0.5*menu.ratio, label)
+			menu.Font.Printf(x-lw/2, float32(int(float32(h)/2+250*menu.ratio)), 0.5*menu.ratio, label)
+			lw = menu.Font.Width(0.4*menu.ratio, subLabel)
+			menu.Font.Printf(x-lw/2, float32(int(float32(h)/2+330*menu.ratio)), 0.4*menu.ratio, subLabel)
 		}
 
 		menu.DrawImage(menu.icons["hexagon"],
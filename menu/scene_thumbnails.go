@@ -0,0 +1,133 @@
+package menu
+
+import (
+	"fmt"
+
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/thumbnails"
+	"github.com/libretro/ludo/utils"
+)
+
+type sceneThumbnails struct {
+	entry
+	sizes map[string]thumbnails.SystemSize
+}
+
+// buildThumbnails shows the size of the thumbnails cache of each system and
+// lets the user purge it
+func buildThumbnails() Scene {
+	var list sceneThumbnails
+	list.label = "Thumbnails Cache"
+	list.refresh()
+
+	list.children = append(list.children, entry{
+		label: "Purge All",
+		icon:  "subsetting",
+		stringValue: func() string {
+			return list.describe("")
+		},
+		callbackOK: func() {
+			list.askPurge("")
+		},
+	})
+
+	sizes, _ := thumbnails.Sizes(settings.Current.ThumbnailsDirectory)
+	for _, size := range sizes {
+		system := size.System
+		list.children = append(list.children, entry{
+			label: system,
+			icon:  "subsetting",
+			stringValue: func() string {
+				return list.describe(system)
+			},
+			callbackOK: func() {
+				list.askPurge(system)
+			},
+		})
+	}
+
+	list.segueMount()
+
+	return &list
+}
+
+// refresh reads the sizes of the cache again
+func (s *sceneThumbnails) refresh() {
+	s.sizes = map[string]thumbnails.SystemSize{}
+	sizes, _ := thumbnails.Sizes(settings.Current.ThumbnailsDirectory)
+	var total thumbnails.SystemSize
+	for _, size := range sizes {
+		s.sizes[size.System] = size
+		total.Files += size.Files
+		total.Size += size.Size
+	}
+	s.sizes[""] = total
+}
+
+// describe formats the size of the cache of a system, or of the whole cache
+func (s *sceneThumbnails) describe(system string) string {
+	size := s.sizes[system]
+	return fmt.Sprintf("%d files, %s", size.Files, utils.HumanSize(size.Size))
+}
+
+// askPurge removes the thumbnails of a system, or all of them, after a
+// confirmation
+func (s *sceneThumbnails) askPurge(system string) {
+	menu.Push(buildYesNoDialog(
+		"Confirm before deleting",
+		"You are about to delete cached thumbnails.",
+		"They will be downloaded again when needed.", func() {
+			if err := thumbnails.Purge(settings.Current.ThumbnailsDirectory, system); err != nil {
+				ntf.DisplayAndLog(ntf.Error, "Menu", err.Error())
+				return
+			}
+			s.refresh()
+			s.drop(system)
+			ntf.DisplayAndLog(ntf.Success, "Menu", "Thumbnails deleted.")
+		}))
+}
+
+// drop removes the entries of the purged systems, all of them when system is
+// empty, the first entry purging the whole cache
+func (s *sceneThumbnails) drop(system string) {
+	kept := s.children[:1]
+	for _, e := range s.children[1:] {
+		if system != "" && e.label != system {
+			kept = append(kept, e)
+		}
+	}
+	s.children = kept
+	if s.ptr >= len(s.children) {
+		s.ptr = len(s.children) - 1
+	}
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneThumbnails) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneThumbnails) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneThumbnails) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneThumbnails) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneThumbnails) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneThumbnails) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneThumbnails) drawHintBar() {
+	genericDrawHintBar()
+}
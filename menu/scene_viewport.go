@@ -0,0 +1,171 @@
+package menu
+
+import (
+	"fmt"
+
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+	"github.com/libretro/ludo/viewport"
+)
+
+type sceneViewport struct {
+	entry
+}
+
+// viewportSwitch builds an entry toggling a boolean of the viewport config
+func viewportSwitch(label string, field func(*viewport.Config) *bool) entry {
+	return entry{
+		label: label,
+		icon:  "subsetting",
+		value: func() interface{} {
+			c := viewport.Current()
+			return *field(&c)
+		},
+		incr: func(direction int) {
+			c := viewport.Current()
+			*field(&c) = !*field(&c)
+			viewport.Set(c)
+		},
+		widget: widgets["switch"],
+	}
+}
+
+// viewportInt builds an entry changing a dimension of the viewport config
+func viewportInt(label string, min int, field func(*viewport.Config) *int) entry {
+	return entry{
+		label: label,
+		icon:  "subsetting",
+		stringValue: func() string {
+			c := viewport.Current()
+			return fmt.Sprintf("%d", *field(&c))
+		},
+		incr: func(direction int) {
+			c := viewport.Current()
+			v := *field(&c) + viewportStep*direction
+			if v < min {
+				v = min
+			}
+			*field(&c) = v
+			viewport.Set(c)
+		},
+	}
+}
+
+func buildViewport() Scene {
+	var list sceneViewport
+	list.label = "Viewport"
+
+	list.children = append(list.children,
+		viewportSwitch("Integer Scaling", func(c *viewport.Config) *bool { return &c.IntegerScale }),
+		viewportSwitch("Crop Overscan", func(c *viewport.Config) *bool { return &c.CropOverscan }),
+		viewportSwitch("Custom Viewport", func(c *viewport.Config) *bool { return &c.Custom }),
+		viewportInt("Custom Viewport Width", viewportStep, func(c *viewport.Config) *int { return &c.Width }),
+		viewportInt("Custom Viewport Height", viewportStep, func(c *viewport.Config) *int { return &c.Height }),
+		viewportInt("Custom Viewport X Offset", -1<<15, func(c *viewport.Config) *int { return &c.X }),
+		viewportInt("Custom Viewport Y Offset", -1<<15, func(c *viewport.Config) *int { return &c.Y }),
+	)
+
+	list.children = append(list.children, entry{
+		label: "Aspect Ratio",
+		icon:  "subsetting",
+		stringValue: func() string {
+			return viewport.Current().Aspect
+		},
+		incr: func(direction int) {
+			c := viewport.Current()
+			n := len(viewport.AspectRatios)
+			i := 0
+			if utils.StringInSlice(c.Aspect, viewport.AspectRatios) {
+				i = utils.IndexOfString(c.Aspect, viewport.AspectRatios)
+			}
+			c.Aspect = viewport.AspectRatios[(i+direction+n)%n]
+			viewport.Set(c)
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Custom Aspect Ratio",
+		icon:  "subsetting",
+		stringValue: func() string {
+			return fmt.Sprintf("%.2f", viewport.Current().CustomAspect)
+		},
+		incr: func(direction int) {
+			c := viewport.Current()
+			c.CustomAspect = incrAspectRatio(c.CustomAspect, direction)
+			viewport.Set(c)
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Save Core Viewport",
+		icon:  "subsetting",
+		callbackOK: func() {
+			if err := viewport.Save(state.CorePath); err != nil {
+				ntf.DisplayAndLog(ntf.Error, "Menu", "Error saving viewport: %v", err.Error())
+				return
+			}
+			ntf.DisplayAndLog(ntf.Success, "Menu", "Viewport saved for this core.")
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Use Video Settings",
+		icon:  "reset",
+		callbackOK: func() {
+			if err := viewport.Reset(state.CorePath); err != nil {
+				ntf.DisplayAndLog(ntf.Error, "Menu", "Error removing viewport: %v", err.Error())
+				return
+			}
+			ntf.DisplayAndLog(ntf.Success, "Menu", "This core now uses the video settings.")
+		},
+	})
+
+	list.segueMount()
+
+	return &list
+}
+
+func (s *sceneViewport) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneViewport) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneViewport) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneViewport) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneViewport) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneViewport) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneViewport) drawHintBar() {
+	w, h := menu.GetFramebufferSize()
+	menu.DrawRect(0, float32(h)-70*menu.ratio, float32(w), 70*menu.ratio, 0, lightGrey)
+
+	_, upDown, leftRight, a, b, _, _, _, _, guide := hintIcons()
+
+	var stack float32
+	list := menu.stack[len(menu.stack)-1].Entry()
+	if state.CoreRunning {
+		stackHint(&stack, guide, "RESUME", h)
+	}
+	stackHint(&stack, upDown, "NAVIGATE", h)
+	stackHint(&stack, b, "BACK", h)
+	if list.children[list.ptr].callbackOK != nil {
+		stackHint(&stack, a, "OK", h)
+	} else {
+		stackHint(&stack, leftRight, "SET", h)
+	}
+}
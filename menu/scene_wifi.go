@@ -36,8 +36,8 @@ func buildWiFi() Scene {
 					stringValue: func() string { return ludos.NetworkStatus(network) },
 					callbackOK: func() {
 						list.segueNext()
-						menu.Push(buildKeyboard(
-							"Passphrase for "+network.SSID,
+						menu.Push(newKeyboard(
+							"Passphrase for "+network.SSID, "", true,
 							func(pass string) {
 								go func() {
 									if err := ludos.ConnectNetwork(network, pass); err != nil {
@@ -0,0 +1,136 @@
+package menu
+
+import (
+	"fmt"
+
+	"github.com/fatih/structs"
+
+	"github.com/libretro/ludo/buildbot"
+	"github.com/libretro/ludo/i18n"
+	"github.com/libretro/ludo/scanner"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+)
+
+type sceneWizard struct {
+	entry
+}
+
+// buildWizard guides the user through the setup of Ludo: the language, where
+// the games and the BIOS files are, the cores and databases to download, a
+// first scan of the games and the controllers. It is shown on the first
+// launch.
+func buildWizard() Scene {
+	var list sceneWizard
+	list.label = "Setup Wizard"
+
+	fields := structs.New(&settings.Current)
+
+	language := fields.Field("Language")
+	list.children = append(list.children, entry{
+		label: "Language",
+		icon:  "subsetting",
+		stringValue: func() string {
+			return i18n.Name(settings.Current.Language)
+		},
+		incr: func(direction int) {
+			incrCallbacks["Language"](language, direction)
+		},
+	})
+
+	games := dirEntry(&list, fields.Field("FileDirectory"))
+	games.label = "Games Directory"
+	list.children = append(list.children, games)
+
+	bios := dirEntry(&list, fields.Field("SystemDirectory"))
+	bios.label = "BIOS Directory"
+	list.children = append(list.children, bios)
+
+	list.children = append(list.children, entry{
+		label: "Download Recommended Cores",
+		icon:  "subsetting",
+		stringValue: func() string {
+			return fmt.Sprintf("%d cores", len(buildbot.Recommended()))
+		},
+		callbackOK: func() {
+			go buildbot.DownloadCores(buildbot.Recommended())
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Download Databases",
+		icon:  "subsetting",
+		callbackOK: func() {
+			go buildbot.DownloadDatabase()
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Scan Games Directory",
+		icon:  "scan",
+		callbackOK: func() {
+			scanner.ScanDir(settings.Current.FileDirectory, refreshTabs)
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Controller Ports",
+		icon:  "subsetting",
+		callbackOK: func() {
+			list.segueNext()
+			menu.Push(buildPortBinds())
+		},
+	})
+
+	list.children = append(list.children, entry{
+		label: "Finish",
+		icon:  "menu_exit",
+		callbackOK: func() {
+			menu.stack[len(menu.stack)-2].segueBack()
+			menu.stack = menu.stack[:len(menu.stack)-1]
+		},
+	})
+
+	list.segueMount()
+
+	return &list
+}
+
+func (s *sceneWizard) Entry() *entry {
+	return &s.entry
+}
+
+func (s *sceneWizard) segueMount() {
+	genericSegueMount(&s.entry)
+}
+
+func (s *sceneWizard) segueNext() {
+	genericSegueNext(&s.entry)
+}
+
+func (s *sceneWizard) segueBack() {
+	genericAnimate(&s.entry)
+}
+
+func (s *sceneWizard) update(dt float32) {
+	genericInput(&s.entry, dt)
+}
+
+func (s *sceneWizard) render() {
+	genericRender(&s.entry)
+}
+
+func (s *sceneWizard) drawHintBar() {
+	w, h := menu.GetFramebufferSize()
+	menu.DrawRect(0, float32(h)-70*menu.ratio, float32(w), 70*menu.ratio, 0, lightGrey)
+
+	_, upDown, _, a, b, _, _, _, _, guide := hintIcons()
+
+	var stack float32
+	if state.CoreRunning {
+		stackHint(&stack, guide, "RESUME", h)
+	}
+	stackHint(&stack, upDown, "NAVIGATE", h)
+	stackHint(&stack, b, "BACK", h)
+	stackHint(&stack, a, "OK", h)
+}
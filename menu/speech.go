@@ -0,0 +1,50 @@
+package menu
+
+import (
+	"fmt"
+
+	"github.com/libretro/ludo/i18n"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/speech"
+	"github.com/libretro/ludo/state"
+)
+
+// spoken is the text of the last entry read aloud
+var spoken string
+
+// focusText describes the focused entry of a scene: its label and value
+func focusText(list *entry) string {
+	if list.ptr < 0 || list.ptr >= len(list.children) {
+		return ""
+	}
+	e := list.children[list.ptr]
+	text := i18n.T(e.label)
+	switch {
+	case e.widget != nil && e.value != nil:
+		if v, ok := e.value().(bool); ok {
+			if v {
+				return text + ", " + i18n.T("on")
+			}
+			return text + ", " + i18n.T("off")
+		}
+		return text + ", " + fmt.Sprint(e.value())
+	case e.stringValue != nil:
+		return text + ", " + i18n.T(e.stringValue())
+	}
+	return text
+}
+
+// speakFocus reads the focused entry aloud when it changes, if enabled in
+// the settings
+func speakFocus(list *entry) {
+	if !settings.Current.MenuSpeech || !state.MenuActive {
+		spoken = ""
+		return
+	}
+	text := focusText(list)
+	if text == spoken || text == "" {
+		return
+	}
+	spoken = text
+	speech.Say(text)
+}
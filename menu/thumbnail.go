@@ -1,78 +1,235 @@
 package menu
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
+	"sync"
 
 	"github.com/go-gl/gl/v2.1/gl"
+	"github.com/libretro/ludo/crash"
+	"github.com/libretro/ludo/logs"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/playlists"
 	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/thumbnails"
+	"github.com/libretro/ludo/utils"
 	"github.com/libretro/ludo/video"
 )
 
-// Downloads a thumbnail from the web and cache it to the local filesystem.
-func downloadThumbnail(list *entry, i int, url, folderPath, path string) {
-	resp, err := http.Get(url)
-	if err != nil {
-		list.children[i].thumbnail = menu.icons["img-broken"]
-		return
+// fetching are the thumbnails being downloaded, by path
+var fetching = struct {
+	sync.Mutex
+	paths map[string]bool
+	all   bool // a playlist is being downloaded
+}{paths: map[string]bool{}}
+
+// fetchThumbnail downloads an image to the local filesystem and returns its
+// size. The urls are tried in order, the first one found is cached.
+func fetchThumbnail(urls []string, folderPath, path string) (int64, error) {
+	var resp *http.Response
+	for _, url := range urls {
+		r, err := http.Get(url)
+		if err != nil {
+			continue
+		}
+		if r.StatusCode == 200 {
+			resp = r
+			break
+		}
+		r.Body.Close()
+	}
+	if resp == nil {
+		return 0, errors.New("thumbnail not found")
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		list.children[i].thumbnail = menu.icons["img-broken"]
-		return
+	if err := os.MkdirAll(folderPath, os.ModePerm); err != nil {
+		return 0, err
 	}
 
-	err = os.MkdirAll(folderPath, os.ModePerm)
+	// The image is renamed once complete, an interrupted download doesn't
+	// leave a broken thumbnail behind
+	part := path + ".part"
+	imgFile, err := os.Create(part)
 	if err != nil {
-		list.children[i].thumbnail = menu.icons["img-broken"]
-		return
+		return 0, err
 	}
 
-	imgFile, err := os.Create(path)
+	n, err := io.Copy(imgFile, resp.Body)
+	if cerr := imgFile.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(part, path)
+	}
 	if err != nil {
+		os.Remove(part)
+	}
+	return n, err
+}
+
+// Downloads a thumbnail from the web and cache it to the local filesystem.
+// The urls are tried in order, the first one found is cached.
+func downloadThumbnail(list *entry, i int, urls []string, folderPath, path string) {
+	defer crash.Recover()
+	if _, err := fetchThumbnail(urls, folderPath, path); err != nil {
 		list.children[i].thumbnail = menu.icons["img-broken"]
 		return
 	}
-	defer imgFile.Close()
+	evictThumbnails()
+}
 
-	_, err = io.Copy(imgFile, resp.Body)
+// evictThumbnails keeps the cache under its maximum size
+func evictThumbnails() {
+	n, err := thumbnails.Evict(settings.Current.ThumbnailsDirectory, int64(settings.Current.ThumbnailsCacheSize)<<20)
 	if err != nil {
-		list.children[i].thumbnail = menu.icons["img-broken"]
+		logs.Warnf("Menu", "Can't evict thumbnails: %v", err)
+	} else if n > 0 {
+		logs.Debugf("Menu", "%d thumbnails evicted", n)
+	}
+}
+
+// artworkNames are the names a thumbnail of a game can have, by priority: the
+// name of the game matched by checksum in the dat, its name in the playlists
+// and the name it is displayed with
+var artworkNames = struct {
+	sync.Mutex
+	cache map[string][]string
+}{cache: map[string][]string{}}
+
+func namesOf(system, gameName, romPath string, crc uint32) []string {
+	key := fmt.Sprintf("%s|%s|%s|%08x", system, gameName, romPath, crc)
+	artworkNames.Lock()
+	defer artworkNames.Unlock()
+	if names, ok := artworkNames.cache[key]; ok {
+		return names
+	}
+	var names []string
+	add := func(name string) {
+		if name != "" && !utils.StringInSlice(name, names) {
+			names = append(names, name)
+		}
 	}
+	add(state.DB.NameByCRC(system, crc))
+	if romPath != "" {
+		if _, game, ok := playlists.Find(romPath, crc); ok {
+			add(game.Name)
+		}
+	}
+	add(gameName)
+	artworkNames.cache[key] = names
+	return names
 }
 
-// Scrub characters that are not cross-platform and/or violate the
-// No-Intro filename standard.
-func scrubIllegalChars(str string) string {
-	str = strings.Replace(str, "&", "_", -1)
-	str = strings.Replace(str, "*", "_", -1)
-	str = strings.Replace(str, "/", "_", -1)
-	str = strings.Replace(str, ":", "_", -1)
-	str = strings.Replace(str, "`", "_", -1)
-	str = strings.Replace(str, "<", "_", -1)
-	str = strings.Replace(str, ">", "_", -1)
-	str = strings.Replace(str, "?", "_", -1)
-	str = strings.Replace(str, "|", "_", -1)
-	return str
+// source is where the thumbnail of a game is found
+type source struct {
+	folderPath string
+	path       string
+	urls       []string
 }
 
-// Draws a thumbnail in the playlist scene.
-func drawThumbnail(list *entry, i int, system, gameName string, x, y, w, h, scale float32, color video.Color) {
+// sources caches the thumbnail sources, they are looked up on every frame
+// while a thumbnail is missing
+var sources = struct {
+	sync.Mutex
+	cache map[string]source
+}{cache: map[string]source{}}
+
+// thumbnailSource returns the folder and the path of the cached thumbnail of
+// a game, and the urls to download it from. The names of the game are tried
+// as is, then normalized to match renamed files. The hacks without a thumbnail
+// of their own get the one of their parent game. The images of the artwork
+// directory are used as is, without urls.
+func thumbnailSource(system, gameName, romPath string, crc uint32) (string, string, []string) {
+	key := fmt.Sprintf("%s|%s|%s|%s|%s|%08x", settings.Current.ThumbnailsDirectory,
+		settings.Current.ArtworkDirectory, system, gameName, romPath, crc)
+	sources.Lock()
+	src, ok := sources.cache[key]
+	sources.Unlock()
+	if !ok {
+		src.folderPath, src.path, src.urls = findThumbnail(system, gameName, romPath, crc)
+		sources.Lock()
+		sources.cache[key] = src
+		sources.Unlock()
+	}
+	return src.folderPath, src.path, src.urls
+}
+
+// findThumbnail looks up the thumbnail of a game, see thumbnailSource
+func findThumbnail(system, gameName, romPath string, crc uint32) (string, string, []string) {
 	folderPath := filepath.Join(settings.Current.ThumbnailsDirectory, system, "Named_Snaps")
-	legalName := scrubIllegalChars(gameName)
-	path := filepath.Join(folderPath, legalName+".png")
-	url := "http://thumbnails.libretro.com/" + system + "/Named_Snaps/" + legalName + ".png"
+	names := namesOf(system, gameName, romPath, crc)
+	if len(names) == 0 {
+		return folderPath, "", nil
+	}
+	var urls []string
+	for _, name := range names {
+		legalName := utils.ScrubIllegalChars(name)
+		urls = append(urls, "http://thumbnails.libretro.com/"+system+"/Named_Snaps/"+legalName+".png")
+		if parent := state.DB.ParentName(system, name); parent != "" {
+			urls = append(urls, "http://thumbnails.libretro.com/"+system+"/Named_Snaps/"+utils.ScrubIllegalChars(parent)+".png")
+		}
+	}
+
+	artwork := settings.Current.ArtworkDirectory
+	for _, name := range names {
+		legalName := utils.ScrubIllegalChars(name)
+		if local := thumbnails.Local(artwork, system, "Named_Snaps", legalName); local != "" {
+			return filepath.Dir(local), local, nil
+		}
+		if path := filepath.Join(folderPath, legalName+".png"); utils.Exists(path) {
+			return folderPath, path, urls
+		}
+	}
+	if artwork != "" {
+		for _, name := range names {
+			for _, dir := range []string{filepath.Join(artwork, system, "Named_Snaps"), filepath.Join(artwork, system)} {
+				if local := thumbnails.Match(dir, name); local != "" {
+					return dir, local, nil
+				}
+			}
+		}
+	}
+	for _, name := range names {
+		if path := thumbnails.Match(folderPath, name); path != "" {
+			return folderPath, path, urls
+		}
+	}
 
+	// Downloaded thumbnails are cached under the best name
+	return folderPath, filepath.Join(folderPath, utils.ScrubIllegalChars(names[0])+".png"), urls
+}
+
+// Draws a thumbnail in the playlist scene. The missing thumbnails are
+// downloaded when their entry is focused, a placeholder is drawn meanwhile.
+func drawThumbnail(list *entry, i int, system, gameName string, x, y, w, h, scale float32, color video.Color) {
 	if list.children[i].thumbnail == 0 || list.children[i].thumbnail == menu.icons["img-dl"] {
+		e := &list.children[i]
+		folderPath, path, urls := thumbnailSource(system, gameName, e.path, e.crc)
 		if _, err := os.Stat(path); !os.IsNotExist(err) {
 			list.children[i].thumbnail = video.NewImage(path)
-		} else if list.children[i].thumbnail != menu.icons["img-dl"] {
+			if urls != nil {
+				thumbnails.Touch(path)
+			}
+		} else {
 			list.children[i].thumbnail = menu.icons["img-dl"]
-			go downloadThumbnail(list, i, url, folderPath, path)
+			fetching.Lock()
+			if i == list.ptr && !fetching.paths[path] {
+				fetching.paths[path] = true
+				go func() {
+					defer crash.Recover()
+					downloadThumbnail(list, i, urls, folderPath, path)
+					fetching.Lock()
+					delete(fetching.paths, path)
+					fetching.Unlock()
+				}()
+			}
+			fetching.Unlock()
 		}
 	}
 
@@ -83,6 +240,61 @@ func drawThumbnail(list *entry, i int, system, gameName string, x, y, w, h, scal
 	)
 }
 
+// downloadAllThumbnails downloads the missing thumbnails of the games of a
+// playlist, with the progress and the downloaded size in a notification. It
+// blocks, it is meant to be run in a goroutine.
+func downloadAllThumbnails(system string, games []entry) {
+	defer crash.Recover()
+	fetching.Lock()
+	if fetching.all {
+		fetching.Unlock()
+		ntf.DisplayAndLog(ntf.Error, "Menu", "A download is already in progress")
+		return
+	}
+	fetching.all = true
+	fetching.Unlock()
+	defer func() {
+		fetching.Lock()
+		fetching.all = false
+		fetching.Unlock()
+	}()
+
+	n := ntf.DisplayAndLog(ntf.Info, "Menu", "Downloading thumbnails")
+	var done, failed int
+	var total int64
+	for i, game := range games {
+		folderPath, path, urls := thumbnailSource(system, game.gameName, game.path, game.crc)
+		if utils.Exists(path) || urls == nil {
+			continue
+		}
+		// The focused game may be downloading already
+		fetching.Lock()
+		busy := fetching.paths[path]
+		fetching.paths[path] = true
+		fetching.Unlock()
+		if busy {
+			continue
+		}
+		size, err := fetchThumbnail(urls, folderPath, path)
+		fetching.Lock()
+		delete(fetching.paths, path)
+		fetching.Unlock()
+		if err != nil {
+			failed++
+		} else {
+			done++
+			total += size
+		}
+		n.Update(ntf.Info, "Downloading thumbnails %d/%d, %s", i+1, len(games), utils.HumanSize(total))
+	}
+	evictThumbnails()
+	if failed > 0 {
+		n.Update(ntf.Warning, "%d thumbnails downloaded, %s, %d not found.", done, utils.HumanSize(total), failed)
+		return
+	}
+	n.Update(ntf.Success, "%d thumbnails downloaded, %s.", done, utils.HumanSize(total))
+}
+
 // Draws a thumbnail in the savestates scene.
 func drawSavestateThumbnail(list *entry, i int, path string, x, y, w, h, scale float32, color video.Color) {
 	if list.children[i].thumbnail == 0 {
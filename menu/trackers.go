@@ -0,0 +1,37 @@
+package menu
+
+import (
+	"strings"
+
+	"github.com/libretro/ludo/achievements"
+	"github.com/libretro/ludo/settings"
+)
+
+// RenderTrackers draws the scores of the leaderboard attempts in progress on
+// the right side of the game, away from the notifications
+func (m *Menu) RenderTrackers() {
+	trackers := achievements.Trackers()
+	if len(trackers) == 0 {
+		return
+	}
+	fbw, fbh := m.GetFramebufferSize()
+	m.updateScale(fbw)
+	m.Font.UpdateResolution(fbw, fbh)
+
+	scale := float32(0.4)
+	h := 50 * m.ratio
+	bottom := !strings.HasPrefix(settings.Current.NotificationsPosition, "Bottom")
+
+	for i, t := range trackers {
+		msg := t.Title + ": " + t.Value
+		w := m.Font.Width(scale*m.ratio, msg) + 30*m.ratio
+		x := float32(fbw) - w - 25*m.ratio
+		y := 25*m.ratio + float32(i)*(h+10*m.ratio)
+		if bottom {
+			y = float32(fbh) - y - h
+		}
+		m.DrawRect(x, y, w, h, 0.25, darkInfo.Alpha(0.85))
+		m.Font.SetColor(lightInfo)
+		m.Font.Printf(x+15*m.ratio, y+33*m.ratio, scale*m.ratio, msg)
+	}
+}
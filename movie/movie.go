@@ -0,0 +1,301 @@
+// Package movie records the input of all the players frame by frame, starting
+// from a savestate, and replays it. Replaying a movie with the same core and
+// content gives the same frames, which helps testing cores and writing tool
+// assisted runs. The file format is similar to the .bsv files of RetroArch.
+package movie
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/libretro/ludo/logs"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+)
+
+// magic starts the movie files, its last byte is the version of the format
+const magic = "LUDOMOV\x01"
+
+// Ports is the number of players whose input is recorded
+const Ports = 5
+
+// Pad is the input of a player on a frame, as seen by the core: the RetroPad
+// buttons mask and the left and right analog sticks
+type Pad struct {
+	Buttons uint16
+	Analog  [4]int16
+}
+
+// Frame is the input of all the players on a frame
+type Frame [Ports]Pad
+
+// Header identifies the core and the content a movie was recorded with, and
+// holds the savestate the movie starts from
+type Header struct {
+	Core        string
+	CoreVersion string
+	CRC         uint32
+	State       []byte
+}
+
+// Movie is a recorded game session
+type Movie struct {
+	Header
+	Frames []Frame
+}
+
+var (
+	current   *Movie
+	recording bool
+	playhead  = -1
+)
+
+var (
+	// ErrFormat is returned when reading a file that isn't a movie
+	ErrFormat = errors.New("not a movie file")
+	// errTruncated is returned when a length goes past the end of the file
+	errTruncated = errors.New("truncated movie")
+	// ErrHardcore is returned when using movies in hardcore mode, they rely
+	// on savestates
+	ErrHardcore = errors.New("movies are disabled in hardcore mode")
+)
+
+// Recording tells if a movie is being recorded
+func Recording() bool {
+	return recording
+}
+
+// Playing tells if a movie is being replayed
+func Playing() bool {
+	return playhead >= 0
+}
+
+// StartRecording starts recording a new movie
+func StartRecording(h Header) {
+	playhead = -1
+	recording = true
+	current = &Movie{Header: h}
+}
+
+// StopRecording stops recording and returns the recorded movie
+func StopRecording() *Movie {
+	recording = false
+	return current
+}
+
+// Record appends the input of a frame to the movie being recorded
+func Record(f Frame) {
+	if !recording {
+		return
+	}
+	current.Frames = append(current.Frames, f)
+}
+
+// Play starts replaying a movie from its first frame. The savestate of the
+// movie has to be loaded first.
+func Play(m *Movie) {
+	recording = false
+	current = m
+	playhead = 0
+	if len(m.Frames) == 0 {
+		playhead = -1
+	}
+}
+
+// Stop interrupts the recording or the playback
+func Stop() {
+	recording = false
+	playhead = -1
+}
+
+// Next returns the input of the next frame of the movie being replayed. ok
+// is false when no movie is playing.
+func Next() (f Frame, ok bool) {
+	if playhead < 0 {
+		return f, false
+	}
+	f = current.Frames[playhead]
+	playhead++
+	if playhead >= len(current.Frames) {
+		playhead = -1
+	}
+	return f, true
+}
+
+// Begin starts recording a movie of the running game from its current state
+func Begin() error {
+	if settings.Current.AchievementsHardcore {
+		return ErrHardcore
+	}
+	st, err := state.Core.Serialize(state.Core.SerializeSize())
+	if err != nil {
+		return err
+	}
+	si := state.Core.GetSystemInfo()
+	StartRecording(Header{
+		Core:        si.LibraryName,
+		CoreVersion: si.LibraryVersion,
+		CRC:         state.GameCRC,
+		State:       st,
+	})
+	return nil
+}
+
+// Replay loads the savestate of a movie and starts replaying it. The movie
+// has to be recorded with the running core and content.
+func Replay(m *Movie) error {
+	if settings.Current.AchievementsHardcore {
+		return ErrHardcore
+	}
+	si := state.Core.GetSystemInfo()
+	if m.Core != si.LibraryName {
+		return fmt.Errorf("the movie was recorded with %s", m.Core)
+	}
+	if m.CRC != state.GameCRC {
+		return errors.New("the movie was recorded with another content")
+	}
+	if m.CoreVersion != si.LibraryVersion {
+		logs.Warnf("Movie", "Recorded with %s %s, the playback may differ", m.Core, m.CoreVersion)
+	}
+	if err := state.Core.Unserialize(m.State, state.Core.SerializeSize()); err != nil {
+		return err
+	}
+	Play(m)
+	return nil
+}
+
+// Dir is the folder holding the movies of a game
+func Dir(game string) string {
+	return filepath.Join(settings.Current.MoviesDirectory, utils.FileName(game))
+}
+
+// Path returns the location of a new movie of a game
+func Path(game string) string {
+	return filepath.Join(Dir(game), utils.DatedName(game)+".bsv")
+}
+
+// writeString writes a string preceded by its length
+func writeString(w io.Writer, s string) error {
+	if err := binary.Write(w, binary.LittleEndian, uint16(len(s))); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, s)
+	return err
+}
+
+// readString reads a string preceded by its length
+func readString(r *io.LimitedReader) (string, error) {
+	var n uint16
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return "", err
+	}
+	if int64(n) > r.N {
+		return "", errTruncated
+	}
+	b := make([]byte, n)
+	_, err := io.ReadFull(r, b)
+	return string(b), err
+}
+
+// Encode writes a movie: the header, the savestate, then the frames
+func Encode(w io.Writer, m *Movie) error {
+	if _, err := io.WriteString(w, magic); err != nil {
+		return err
+	}
+	if err := writeString(w, m.Core); err != nil {
+		return err
+	}
+	if err := writeString(w, m.CoreVersion); err != nil {
+		return err
+	}
+	header := []uint32{m.CRC, uint32(len(m.State)), uint32(len(m.Frames))}
+	if err := binary.Write(w, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	if _, err := w.Write(m.State); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, m.Frames)
+}
+
+// Decode reads a movie written by Encode, size bytes long. The lengths it
+// holds are checked against the size before allocating, a broken file can't
+// exhaust the memory.
+func Decode(rd io.Reader, size int64) (*Movie, error) {
+	r := &io.LimitedReader{R: rd, N: size}
+	b := make([]byte, len(magic))
+	if _, err := io.ReadFull(r, b); err != nil || string(b) != magic {
+		return nil, ErrFormat
+	}
+
+	var m Movie
+	var err error
+	if m.Core, err = readString(r); err != nil {
+		return nil, err
+	}
+	if m.CoreVersion, err = readString(r); err != nil {
+		return nil, err
+	}
+	var header [3]uint32
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+	m.CRC = header[0]
+
+	if int64(header[1]) > r.N {
+		return nil, errTruncated
+	}
+	m.State = make([]byte, header[1])
+	if _, err := io.ReadFull(r, m.State); err != nil {
+		return nil, err
+	}
+	if int64(header[2])*int64(binary.Size(Frame{})) > r.N {
+		return nil, errTruncated
+	}
+	m.Frames = make([]Frame, header[2])
+	if err := binary.Read(r, binary.LittleEndian, m.Frames); err != nil {
+		return nil, fmt.Errorf("truncated movie: %v", err)
+	}
+	return &m, nil
+}
+
+// Save writes a movie file
+func Save(path string, m *Movie) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	fd, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	w := bufio.NewWriter(fd)
+	if err := Encode(w, m); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return fd.Sync()
+}
+
+// Open reads a movie file
+func Open(path string) (*Movie, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	fi, err := fd.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return Decode(bufio.NewReader(fd), fi.Size())
+}
@@ -0,0 +1,97 @@
+package movie
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func TestRecordAndPlay(t *testing.T) {
+	var a, b Frame
+	a[0].Buttons = 1
+	b[1].Analog[2] = -300
+
+	StartRecording(Header{Core: "Test", CRC: 42})
+	Record(a)
+	Record(b)
+	m := StopRecording()
+	Record(a)
+
+	t.Run("Records frames until stopped", func(t *testing.T) {
+		want := []Frame{a, b}
+		if !reflect.DeepEqual(m.Frames, want) {
+			t.Errorf("got = %v, want %v", m.Frames, want)
+		}
+	})
+
+	t.Run("Replays the frames in order", func(t *testing.T) {
+		Play(m)
+		var got []Frame
+		for {
+			f, ok := Next()
+			if !ok {
+				break
+			}
+			got = append(got, f)
+		}
+		want := []Frame{a, b}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got = %v, want %v", got, want)
+		}
+		if Playing() {
+			t.Errorf("got = %v, want %v", Playing(), false)
+		}
+	})
+}
+
+func TestEncodeDecode(t *testing.T) {
+	var f Frame
+	f[0].Buttons = 0x0101
+	f[4].Analog = [4]int16{1, -2, 3, -32768}
+	m := &Movie{
+		Header: Header{Core: "Snes9x", CoreVersion: "1.60", CRC: 0xDEADBEEF, State: []byte{1, 2, 3}},
+		Frames: []Frame{f, {}, f},
+	}
+
+	t.Run("Reads back what was written", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := Encode(&buf, m); err != nil {
+			t.Fatal(err)
+		}
+		got, err := Decode(&buf, int64(buf.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, m) {
+			t.Errorf("got = %+v, want %+v", got, m)
+		}
+	})
+
+	t.Run("Rejects other files", func(t *testing.T) {
+		if _, err := Decode(bytes.NewReader([]byte("BSV1 not ours")), 13); err != ErrFormat {
+			t.Errorf("got = %v, want %v", err, ErrFormat)
+		}
+	})
+
+	t.Run("Rejects truncated movies", func(t *testing.T) {
+		var buf bytes.Buffer
+		Encode(&buf, m)
+		b := buf.Bytes()
+		if _, err := Decode(bytes.NewReader(b[:len(b)-5]), int64(len(b)-5)); err == nil {
+			t.Errorf("got = %v, want an error", err)
+		}
+	})
+
+	t.Run("Rejects lengths past the end of the file", func(t *testing.T) {
+		m := &Movie{Header: Header{Core: "Snes9x", State: []byte{1}}}
+		var buf bytes.Buffer
+		Encode(&buf, m)
+		b := buf.Bytes()
+		// The number of frames, right before the savestate
+		binary.LittleEndian.PutUint32(b[len(b)-5:], 0xFFFFFFFF)
+		if _, err := Decode(bytes.NewReader(b), int64(len(b))); err != errTruncated {
+			t.Errorf("got = %v, want %v", err, errTruncated)
+		}
+	})
+}
@@ -0,0 +1,242 @@
+// Package netpad turns a phone into a RetroPad. A web page served by the
+// remote API shows a gamepad whose buttons are sent over a WebSocket, as JSON
+// messages like {"button":"A","pressed":true}, to the player port chosen on
+// the page. The first message, {"code":"..."}, carries the pairing code.
+package netpad
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/libretro/ludo/logs"
+	"github.com/libretro/ludo/remap"
+	"github.com/libretro/ludo/utils"
+)
+
+// MaxPorts is the number of player ports a phone can be plugged to
+const MaxPorts = 5
+
+// pairTimeout is how long a phone has to send the pairing code
+const pairTimeout = 10 * time.Second
+
+// message is a button press or release sent by a phone
+type message struct {
+	Button  string `json:"button"`
+	Pressed bool   `json:"pressed"`
+}
+
+var (
+	mu sync.Mutex
+	// held counts, per port and RetroPad id, the phones holding a button
+	held [MaxPorts][16]int
+)
+
+// pad is the buttons held by one phone
+type pad struct {
+	port    int
+	pressed [16]bool
+}
+
+// handle applies a message to the buttons of a phone
+func (p *pad) handle(m message) {
+	if !utils.StringInSlice(m.Button, remap.Buttons) {
+		return
+	}
+	id := utils.IndexOfString(m.Button, remap.Buttons)
+	if id >= len(p.pressed) || p.pressed[id] == m.Pressed {
+		return
+	}
+	p.pressed[id] = m.Pressed
+	mu.Lock()
+	if m.Pressed {
+		held[p.port][id]++
+	} else {
+		held[p.port][id]--
+	}
+	mu.Unlock()
+}
+
+// release lets go of all the buttons of a disconnected phone
+func (p *pad) release() {
+	for id, pressed := range p.pressed {
+		if pressed {
+			p.handle(message{Button: remap.Buttons[id], Pressed: false})
+		}
+	}
+}
+
+// Pressed returns the RetroPad ids of the buttons held on a port
+func Pressed(port int) []uint32 {
+	if port < 0 || port >= MaxPorts {
+		return nil
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	var ids []uint32
+	for id, n := range held[port] {
+		if n > 0 {
+			ids = append(ids, uint32(id))
+		}
+	}
+	return ids
+}
+
+// Socket receives the inputs of a phone. The port is the 1 based player
+// number in the query string. The phone sends the pairing code once
+// connected, instead of in the URL, the connection is closed when authorized
+// refuses it.
+func Socket(authorized func(code string) bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		port, err := strconv.Atoi(r.FormValue("port"))
+		if err != nil || port < 1 || port > MaxPorts {
+			http.Error(w, "invalid port", http.StatusBadRequest)
+			return
+		}
+		conn, rw, err := upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetReadDeadline(time.Now().Add(pairTimeout))
+		if !pair(rw, authorized) {
+			writeFrame(rw, opClose, nil)
+			return
+		}
+		conn.SetReadDeadline(time.Time{})
+		serve(rw, port-1)
+	}
+}
+
+// pair reads the pairing code sent by a phone, and tells it it is accepted
+func pair(rw *bufio.ReadWriter, authorized func(code string) bool) bool {
+	op, payload, err := readFrame(rw)
+	if err != nil || op != opText {
+		return false
+	}
+	var m struct {
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal(payload, &m); err != nil || !authorized(m.Code) {
+		return false
+	}
+	return writeFrame(rw, opText, []byte(`{"paired":true}`)) == nil
+}
+
+// serve applies the inputs of a paired phone to a port
+func serve(rw *bufio.ReadWriter, port int) {
+	p := pad{port: port}
+	defer p.release()
+	for {
+		op, payload, err := readFrame(rw)
+		if err != nil {
+			return
+		}
+		switch op {
+		case opText:
+			var m message
+			if err := json.Unmarshal(payload, &m); err != nil {
+				logs.Warnf("Netpad", "%v", err)
+				continue
+			}
+			p.handle(m)
+		case opPing:
+			writeFrame(rw, opPong, payload)
+		case opClose:
+			writeFrame(rw, opClose, nil)
+			return
+		}
+	}
+}
+
+// Page serves the gamepad web page
+func Page(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(page))
+}
+
+const page = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1, user-scalable=no">
+<title>Ludo Gamepad</title>
+<style>
+body { margin: 0; height: 100vh; font-family: sans-serif; background: #1a1a1a; color: #eee; user-select: none; -webkit-user-select: none; touch-action: none; overflow: hidden; }
+header { padding: 8px; display: flex; gap: 8px; align-items: center; font-size: 14px; }
+select { font-size: 16px; }
+.pad { position: absolute; left: 0; right: 0; top: 44px; bottom: 0; }
+.b { position: absolute; display: flex; align-items: center; justify-content: center; background: #444; border-radius: 50%; font-weight: bold; }
+.b.on { background: #888; }
+</style>
+</head>
+<body>
+<header>
+Player <select id="port"><option>1</option><option selected>2</option><option>3</option><option>4</option><option>5</option></select>
+<span id="status">Connecting</span>
+</header>
+<div class="pad" id="pad"></div>
+<script>
+// Positions relative to the pad, in percents: x, y, size
+var layout = {
+	L: [8, 8, 14], R: [92, 8, 14],
+	Up: [18, 38, 14], Down: [18, 72, 14], Left: [8, 55, 14], Right: [28, 55, 14],
+	X: [82, 38, 14], B: [82, 72, 14], Y: [72, 55, 14], A: [92, 55, 14],
+	Select: [42, 88, 10], Start: [58, 88, 10]
+};
+var ws;
+
+function connect() {
+	if (ws) ws.close();
+	var proto = location.protocol === 'https:' ? 'wss://' : 'ws://';
+	var code = localStorage.getItem('ludo-code');
+	if (!code) {
+		code = (prompt('Pairing code') || '').trim().toUpperCase();
+		localStorage.setItem('ludo-code', code);
+	}
+	var paired = false;
+	ws = new WebSocket(proto + location.host + '/gamepad/ws?' + new URLSearchParams({port: document.getElementById('port').value}));
+	ws.onopen = function () { ws.send(JSON.stringify({code: code})); };
+	ws.onmessage = function () {
+		paired = true;
+		document.getElementById('status').textContent = 'Connected';
+	};
+	ws.onclose = function () {
+		document.getElementById('status').textContent = 'Disconnected';
+		// A wrong pairing code is asked again on the next connection
+		if (!paired) localStorage.removeItem('ludo-code');
+	};
+}
+
+function send(button, pressed) {
+	if (ws && ws.readyState === 1) ws.send(JSON.stringify({button: button, pressed: pressed}));
+}
+
+var pad = document.getElementById('pad');
+Object.keys(layout).forEach(function (name) {
+	var l = layout[name];
+	var el = document.createElement('div');
+	el.className = 'b';
+	el.textContent = name;
+	el.style.left = 'calc(' + l[0] + '% - ' + l[2] / 2 + 'vmin)';
+	el.style.top = 'calc(' + l[1] + '% - ' + l[2] / 2 + 'vmin)';
+	el.style.width = el.style.height = l[2] + 'vmin';
+	function down(e) { e.preventDefault(); el.classList.add('on'); send(name, true); }
+	function up(e) { e.preventDefault(); el.classList.remove('on'); send(name, false); }
+	el.addEventListener('touchstart', down);
+	el.addEventListener('touchend', up);
+	el.addEventListener('touchcancel', up);
+	el.addEventListener('mousedown', down);
+	el.addEventListener('mouseup', up);
+	pad.appendChild(el);
+});
+
+document.getElementById('port').onchange = connect;
+connect();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,150 @@
+package netpad
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_acceptKey(t *testing.T) {
+	// Example of RFC 6455
+	got := acceptKey("dGhlIHNhbXBsZSBub25jZQ==")
+	if want := "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func Test_readFrame(t *testing.T) {
+	t.Run("Unmasks the payload", func(t *testing.T) {
+		// Example of RFC 6455
+		frame := []byte{0x81, 0x85, 0x37, 0xfa, 0x21, 0x3d, 0x7f, 0x9f, 0x4d, 0x51, 0x58}
+		op, payload, err := readFrame(bytes.NewReader(frame))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if op != opText || string(payload) != "Hello" {
+			t.Errorf("got %v %q", op, payload)
+		}
+	})
+
+	t.Run("Rejects unmasked frames", func(t *testing.T) {
+		frame := []byte{0x81, 0x05, 'H', 'e', 'l', 'l', 'o'}
+		if _, _, err := readFrame(bytes.NewReader(frame)); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func Test_writeFrame(t *testing.T) {
+	var b bytes.Buffer
+	if err := writeFrame(&b, opPong, []byte("Hello")); err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{0x8a, 0x05, 'H', 'e', 'l', 'l', 'o'}
+	if !bytes.Equal(b.Bytes(), want) {
+		t.Errorf("got %v, want %v", b.Bytes(), want)
+	}
+}
+
+func Test_pad(t *testing.T) {
+	p1 := pad{port: 1}
+	p2 := pad{port: 1}
+
+	p1.handle(message{"A", true})
+	p1.handle(message{"A", true})
+	p2.handle(message{"A", true})
+	p2.handle(message{"Start", true})
+	p1.handle(message{"Unknown", true})
+
+	if got, want := Pressed(1), []uint32{3, 8}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	p2.release()
+	if got, want := Pressed(1), []uint32{8}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	p1.handle(message{"A", false})
+	if got := Pressed(1); len(got) != 0 {
+		t.Errorf("got %v, want none", got)
+	}
+	if got := Pressed(0); len(got) != 0 {
+		t.Errorf("got %v on another port", got)
+	}
+}
+
+// clientFrame masks a text message like a browser does
+func clientFrame(msg string) []byte {
+	mask := []byte{1, 2, 3, 4}
+	frame := append([]byte{0x81, 0x80 | byte(len(msg))}, mask...)
+	for i, c := range []byte(msg) {
+		frame = append(frame, c^mask[i%4])
+	}
+	return frame
+}
+
+// dialSocket opens a WebSocket to the gamepad of player 3
+func dialSocket(t *testing.T, url string) (net.Conn, *bufio.Reader) {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(url, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Write([]byte("GET /?port=3 HTTP/1.1\r\nHost: ludo\r\nUpgrade: websocket\r\n" +
+		"Connection: Upgrade\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n" +
+		"Sec-WebSocket-Version: 13\r\n\r\n"))
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("got %v", resp.StatusCode)
+	}
+	return conn, br
+}
+
+func TestSocket(t *testing.T) {
+	srv := httptest.NewServer(Socket(func(code string) bool { return code == "CODE" }))
+	defer srv.Close()
+
+	t.Run("Closes the connection on a wrong pairing code", func(t *testing.T) {
+		conn, br := dialSocket(t, srv.URL)
+		defer conn.Close()
+		conn.Write(clientFrame(`{"code":"WRONG"}`))
+		if h, err := br.ReadByte(); err != nil || h&0xf != opClose {
+			t.Errorf("expected a close frame, got %x, %v", h, err)
+		}
+	})
+
+	conn, br := dialSocket(t, srv.URL)
+	defer conn.Close()
+	conn.Write(clientFrame(`{"code":"CODE"}`))
+	if h, err := br.ReadByte(); err != nil || h&0xf != opText {
+		t.Fatalf("expected the pairing answer, got %x, %v", h, err)
+	}
+	conn.Write(clientFrame(`{"button":"B","pressed":true}`))
+
+	deadline := time.Now().Add(time.Second)
+	for len(Pressed(2)) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got, want := Pressed(2), []uint32{0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	conn.Close()
+	deadline = time.Now().Add(time.Second)
+	for len(Pressed(2)) != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := Pressed(2); len(got) != 0 {
+		t.Errorf("got %v after disconnecting", got)
+	}
+}
@@ -4,9 +4,12 @@ package notifications
 
 import (
 	"fmt"
-	"log"
+	"sort"
+	"sync"
+	"time"
 
-	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/i18n"
+	"github.com/libretro/ludo/logs"
 )
 
 // Severity represents the severity of a notification message. It will affect
@@ -26,30 +29,75 @@ const (
 	Error
 )
 
+// levels are the log levels of the severities. Informative notifications are
+// already on screen, they are only logged in debug.
+var levels = map[Severity]logs.Level{
+	Info:    logs.Debug,
+	Success: logs.Debug,
+	Warning: logs.Warn,
+	Error:   logs.Error,
+}
+
 // Notification is a message that will be displayed on the screen during a
-// certain time.
+// certain time. Category is the prefix of the message in the logs.
 type Notification struct {
 	Severity Severity
 	Message  string
 	Duration float32
+	Category string
+}
+
+// Record is a notification kept in the history
+type Record struct {
+	Severity Severity
+	Message  string
+	Category string
+	Time     time.Time
 }
 
 // Medium is the standard duration for a notification
 const Medium float32 = 4
 
+// Duration is how long notifications created by DisplayAndLog stay on the
+// screen, in seconds
+var Duration = Medium
+
+// MaxHistory is the number of notifications kept in the history
+const MaxHistory = 100
+
+// mu guards the notifications and the history. Notifications are created
+// from the core thread and from background downloads, and drawn by the main
+// thread.
+var mu sync.Mutex
+
 var notifications []*Notification
+var history []Record
 
-// List lists the current notifications.
+// List lists the current notifications. They are copies, safe to read while
+// the notifications are updated.
 func List() []*Notification {
-	return notifications
+	mu.Lock()
+	defer mu.Unlock()
+	list := make([]*Notification, len(notifications))
+	for i, n := range notifications {
+		c := *n
+		list[i] = &c
+	}
+	return list
 }
 
 // Display creates a new notification.
 func Display(severity Severity, message string, duration float32) *Notification {
+	mu.Lock()
+	defer mu.Unlock()
+	return display(severity, message, duration)
+}
+
+func display(severity Severity, message string, duration float32) *Notification {
 	n := &Notification{
-		severity,
-		message,
-		duration,
+		Severity: severity,
+		Message:  message,
+		Duration: duration,
 	}
 
 	notifications = append(notifications, n)
@@ -58,16 +106,63 @@ func Display(severity Severity, message string, duration float32) *Notification
 }
 
 // DisplayAndLog creates a new notification and also logs the message to stdout.
+// The notification is kept in the history, prefix being its category.
 func DisplayAndLog(severity Severity, prefix, message string, vars ...interface{}) *Notification {
-	msg := fmt.Sprintf(message, vars...)
-	if state.Verbose {
-		log.Print("[" + prefix + "]: " + msg + "\n")
+	logs.Logf(levels[severity], prefix, message, vars...)
+	msg := fmt.Sprintf(i18n.T(message), vars...)
+	mu.Lock()
+	defer mu.Unlock()
+	n := display(severity, msg, Duration)
+	n.Category = prefix
+	remember(n)
+	return n
+}
+
+// remember adds a notification to the history, mu being held
+func remember(n *Notification) {
+	history = append(history, Record{
+		Severity: n.Severity,
+		Message:  n.Message,
+		Category: n.Category,
+		Time:     time.Now(),
+	})
+	if len(history) > MaxHistory {
+		history = history[len(history)-MaxHistory:]
+	}
+}
+
+// History returns the past notifications, most recent first
+func History() []Record {
+	mu.Lock()
+	defer mu.Unlock()
+	h := make([]Record, len(history))
+	for i, r := range history {
+		h[len(history)-1-i] = r
 	}
-	return Display(severity, msg, Medium)
+	return h
+}
+
+// Categories returns the sorted categories of the notifications of the
+// history
+func Categories() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	seen := map[string]bool{}
+	var cats []string
+	for _, r := range history {
+		if r.Category != "" && !seen[r.Category] {
+			seen[r.Category] = true
+			cats = append(cats, r.Category)
+		}
+	}
+	sort.Strings(cats)
+	return cats
 }
 
 // Process iterates over the notifications, update them, delete the old ones.
 func Process(dt float32) {
+	mu.Lock()
+	defer mu.Unlock()
 	deleted := 0
 	for i := range notifications {
 		j := i - deleted
@@ -81,15 +176,23 @@ func Process(dt float32) {
 
 // Clear empties the notification list
 func Clear() {
+	mu.Lock()
+	defer mu.Unlock()
 	notifications = []*Notification{}
 }
 
 // Update the message of a given notification. Also resets the delay before
 // disapearing.
 func (n *Notification) Update(severity Severity, message string, vars ...interface{}) {
-	msg := fmt.Sprintf(message, vars...)
+	msg := fmt.Sprintf(i18n.T(message), vars...)
 
-	n.Duration = Medium
+	mu.Lock()
+	defer mu.Unlock()
+	n.Duration = Duration
 	n.Message = msg
 	n.Severity = severity
+	// Progress updates are not worth keeping
+	if n.Category != "" && severity != Info {
+		remember(n)
+	}
 }
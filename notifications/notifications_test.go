@@ -4,7 +4,7 @@ import (
 	"reflect"
 	"testing"
 
-	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/logs"
 	"github.com/libretro/ludo/utils"
 )
 
@@ -67,9 +67,9 @@ func Test_DisplayAndLog(t *testing.T) {
 
 	Clear()
 	t.Run("Logs to stdout if verbose", func(t *testing.T) {
-		state.Verbose = true
+		logs.SetLevel(logs.Debug)
 		got := utils.CaptureOutput(func() { DisplayAndLog(Info, "Test", "Joypad #%d loaded with name %s.", 3, "Foo") })
-		want := "[Test]: Joypad #3 loaded with name Foo.\n"
+		want := "DEBUG [Test]: Joypad #3 loaded with name Foo.\n"
 		if !reflect.DeepEqual(got, want) {
 			t.Errorf("got = %v, want %v", got, want)
 		}
@@ -77,13 +77,21 @@ func Test_DisplayAndLog(t *testing.T) {
 
 	Clear()
 	t.Run("Logs nothing if not verbose", func(t *testing.T) {
-		state.Verbose = false
+		logs.SetLevel(logs.Info)
 		got := utils.CaptureOutput(func() { DisplayAndLog(Info, "Test", "Joypad #%d loaded with name %s.", 3, "Foo") })
 		want := ""
 		if !reflect.DeepEqual(got, want) {
 			t.Errorf("got = %v, want %v", got, want)
 		}
 	})
+	Clear()
+	t.Run("Logs errors if not verbose", func(t *testing.T) {
+		got := utils.CaptureOutput(func() { DisplayAndLog(Error, "Test", "Can't load %s.", "Foo") })
+		want := "ERROR [Test]: Can't load Foo.\n"
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got = %v, want %v", got, want)
+		}
+	})
 }
 
 func Test_processNotifications(t *testing.T) {
@@ -143,3 +151,47 @@ func Test_Update(t *testing.T) {
 		}
 	})
 }
+
+func Test_History(t *testing.T) {
+	history = nil
+	t.Run("Keeps the notifications, most recent first", func(t *testing.T) {
+		DisplayAndLog(Info, "Menu", "Test1")
+		n := DisplayAndLog(Info, "Core", "Test2")
+		n.Update(Info, "Test2 50%%")
+		n.Update(Success, "Test2 done")
+		Display(Info, "Test3", Medium)
+		got := []string{}
+		for _, r := range History() {
+			got = append(got, r.Category+": "+r.Message)
+		}
+		want := []string{"Core: Test2 done", "Core: Test2", "Menu: Test1"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got = %v, want %v", got, want)
+		}
+	})
+
+	history = nil
+	t.Run("Drops the oldest notifications", func(t *testing.T) {
+		for i := 0; i < MaxHistory+10; i++ {
+			DisplayAndLog(Info, "Menu", "Test%d", i)
+		}
+		got := History()
+		if len(got) != MaxHistory || got[MaxHistory-1].Message != "Test10" {
+			t.Errorf("got %d notifications, oldest %v", len(got), got[len(got)-1].Message)
+		}
+	})
+}
+
+func Test_Categories(t *testing.T) {
+	history = nil
+	t.Run("Lists the categories once", func(t *testing.T) {
+		DisplayAndLog(Info, "Menu", "Test1")
+		DisplayAndLog(Info, "Core", "Test2")
+		DisplayAndLog(Info, "Menu", "Test3")
+		got := Categories()
+		want := []string{"Core", "Menu"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got = %v, want %v", got, want)
+		}
+	})
+}
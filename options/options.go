@@ -34,6 +34,10 @@ type Options struct {
 	Vars    []*Variable // the variables exposed by the core
 	Updated bool        // notify the core that values have been updated
 
+	// held are the values saved for the options changed for the running
+	// game only, by key
+	held map[string]string
+
 	sync.Mutex
 }
 
@@ -65,14 +69,36 @@ func New(vars []VariableInterface) (*Options, error) {
 	return o, err
 }
 
-// Save core options to a file
+// Hold keeps saving the current value of an option while it is changed for
+// the running game only. It is called with the lock held, before the change.
+func (o *Options) Hold(v *Variable) {
+	if o.held == nil {
+		o.held = map[string]string{}
+	}
+	if _, ok := o.held[v.Key]; !ok {
+		o.held[v.Key] = v.Choices[v.Choice]
+	}
+}
+
+// Release saves an option held by Hold with its current value again, like
+// when the user changes it for the core. It is called with the lock held.
+func (o *Options) Release(key string) {
+	delete(o.held, key)
+}
+
+// Save core options to a file. The options held for the running game keep
+// their saved value.
 func (o *Options) Save() error {
 	o.Lock()
 	defer o.Unlock()
 
 	m := make(map[string]string)
 	for _, v := range o.Vars {
-		m[strings.Replace(v.Key, ".", "___", 1)] = v.Choices[v.Choice]
+		val := v.Choices[v.Choice]
+		if h, ok := o.held[v.Key]; ok {
+			val = h
+		}
+		m[strings.Replace(v.Key, ".", "___", 1)] = val
 	}
 	b, err := toml.Marshal(m)
 	if err != nil {
@@ -94,6 +120,32 @@ func (o *Options) Save() error {
 	return fd.Sync()
 }
 
+// Import adds option values to the options file of a core, like values
+// migrated from another frontend. They apply the next time the core is
+// loaded.
+func Import(core string, values map[string]string) error {
+	path := filepath.Join(xdg.ConfigHome, "ludo", core+".toml")
+
+	m := map[string]string{}
+	if b, err := ioutil.ReadFile(path); err == nil {
+		if err := toml.Unmarshal(b, &m); err != nil {
+			return err
+		}
+	}
+	for k, v := range values {
+		m[strings.Replace(k, ".", "___", 1)] = v
+	}
+
+	b, err := toml.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
+
 // Load core options from a file
 func (o *Options) load() error {
 	o.Lock()
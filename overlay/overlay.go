@@ -0,0 +1,163 @@
+// Package overlay draws an on-screen gamepad on top of the game for touch
+// screens, and turns touches into RetroPad input. Layouts are described in
+// TOML files, positions being relative to the window size.
+//
+// GLFW doesn't expose touch points, touch screens are seen through the
+// pointer they emulate, so only one button can be touched at a time.
+package overlay
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/libretro/ludo/remap"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/utils"
+	"github.com/libretro/ludo/video"
+	"github.com/pelletier/go-toml"
+)
+
+// Button is a round button of the overlay. X and Y are the position of its
+// center relative to the window width and height, R is its radius relative
+// to the window height.
+type Button struct {
+	Button string  `toml:"button"`
+	Label  string  `toml:"label"`
+	X      float32 `toml:"x"`
+	Y      float32 `toml:"y"`
+	R      float32 `toml:"r"`
+}
+
+// Layout is a set of buttons
+type Layout struct {
+	Name    string   `toml:"name"`
+	Buttons []Button `toml:"buttons"`
+}
+
+// Layouts are the available overlay layouts, by name
+var Layouts = map[string]Layout{
+	"Gamepad": {
+		Name: "Gamepad",
+		Buttons: []Button{
+			{"Up", "UP", 0.12, 0.55, 0.07},
+			{"Down", "DOWN", 0.12, 0.83, 0.07},
+			{"Left", "LEFT", 0.06, 0.69, 0.07},
+			{"Right", "RIGHT", 0.18, 0.69, 0.07},
+			{"A", "A", 0.94, 0.69, 0.07},
+			{"B", "B", 0.88, 0.83, 0.07},
+			{"X", "X", 0.88, 0.55, 0.07},
+			{"Y", "Y", 0.82, 0.69, 0.07},
+			{"L", "L", 0.06, 0.1, 0.06},
+			{"R", "R", 0.94, 0.1, 0.06},
+			{"Select", "SELECT", 0.42, 0.92, 0.05},
+			{"Start", "START", 0.58, 0.92, 0.05},
+		},
+	},
+	"Minimal": {
+		Name: "Minimal",
+		Buttons: []Button{
+			{"Left", "LEFT", 0.06, 0.8, 0.08},
+			{"Right", "RIGHT", 0.2, 0.8, 0.08},
+			{"A", "A", 0.94, 0.8, 0.08},
+			{"B", "B", 0.8, 0.8, 0.08},
+			{"Start", "START", 0.5, 0.92, 0.05},
+		},
+	},
+}
+
+// Names returns the sorted names of the layouts
+func Names() []string {
+	var names []string
+	for name := range Layouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load adds the layouts found in the overlays folder of the assets
+func Load() {
+	paths, _ := filepath.Glob(filepath.Join(settings.Current.AssetsDirectory, "overlays", "*.toml"))
+	for _, path := range paths {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var l Layout
+		if err := toml.Unmarshal(b, &l); err != nil {
+			continue
+		}
+		if l.Name == "" {
+			l.Name = utils.FileName(path)
+		}
+		Layouts[l.Name] = l
+	}
+}
+
+// current returns the layout selected in the settings
+func current() Layout {
+	if l, ok := Layouts[settings.Current.OverlayLayout]; ok {
+		return l
+	}
+	return Layouts["Gamepad"]
+}
+
+// Hit returns the RetroPad ids of the buttons of a layout under a pointer,
+// for a window of size w*h
+func Hit(l Layout, px, py, w, h float32) []uint32 {
+	var ids []uint32
+	for _, b := range l.Buttons {
+		if !utils.StringInSlice(b.Button, remap.Buttons) {
+			continue
+		}
+		dx := px - b.X*w
+		dy := py - b.Y*h
+		r := b.R * h
+		if dx*dx+dy*dy <= r*r {
+			ids = append(ids, uint32(utils.IndexOfString(b.Button, remap.Buttons)))
+		}
+	}
+	return ids
+}
+
+// Pressed returns the RetroPad ids of the buttons touched on the overlay.
+// Only the emulated pointer is seen, a second finger moves it instead of
+// adding a touch.
+func Pressed(vid *video.Video) []uint32 {
+	if !settings.Current.OverlayEnable || vid.Window == nil {
+		return nil
+	}
+	if vid.Window.GetMouseButton(glfw.MouseButtonLeft) != glfw.Press {
+		return nil
+	}
+	x, y := vid.CursorPos()
+	w, h := vid.GetSize()
+	return Hit(current(), float32(x), float32(y), float32(w), float32(h))
+}
+
+// Render draws the overlay on top of the game
+func Render(vid *video.Video) {
+	if !settings.Current.OverlayEnable {
+		return
+	}
+	fbw, fbh := vid.GetFramebufferSize()
+	w, h := float32(fbw), float32(fbh)
+	ratio := h / 1080
+	alpha := settings.Current.OverlayOpacity
+	pressed := Pressed(vid)
+
+	for _, b := range current().Buttons {
+		c := video.Color{R: 1, G: 1, B: 1, A: alpha}
+		for _, id := range pressed {
+			if utils.StringInSlice(b.Button, remap.Buttons) && int(id) == utils.IndexOfString(b.Button, remap.Buttons) {
+				c.A = alpha * 2
+			}
+		}
+		vid.DrawCircle(b.X*w, b.Y*h, b.R*h, c)
+		vid.Font.SetColor(video.Color{R: 0, G: 0, B: 0, A: alpha * 2})
+		lw := vid.Font.Width(0.5*ratio, b.Label)
+		vid.Font.Printf(b.X*w-lw/2, b.Y*h+15*ratio, 0.5*ratio, b.Label)
+	}
+}
@@ -0,0 +1,40 @@
+package overlay
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/libretro/ludo/libretro"
+)
+
+func TestHit(t *testing.T) {
+	l := Layout{
+		Buttons: []Button{
+			{Button: "A", X: 0.9, Y: 0.5, R: 0.1},
+			{Button: "B", X: 0.1, Y: 0.5, R: 0.1},
+			{Button: "Unknown", X: 0.5, Y: 0.5, R: 0.1},
+		},
+	}
+
+	t.Run("Returns the button under the pointer", func(t *testing.T) {
+		got := Hit(l, 180, 50, 200, 100)
+		want := []uint32{libretro.DeviceIDJoypadA}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Returns nothing between buttons", func(t *testing.T) {
+		got := Hit(l, 60, 50, 200, 100)
+		if got != nil {
+			t.Errorf("got = %v, want %v", got, nil)
+		}
+	})
+
+	t.Run("Ignores unknown buttons", func(t *testing.T) {
+		got := Hit(l, 100, 50, 200, 100)
+		if got != nil {
+			t.Errorf("got = %v, want %v", got, nil)
+		}
+	})
+}
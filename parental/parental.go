@@ -0,0 +1,196 @@
+// Package parental restricts games behind the menu PIN, and limits the play
+// time of a session. Games are restricted when their playlist is, or when
+// their age rating, found in the ESRB and PEGI metadat files of the
+// libretro database, is above the maximum age.
+package parental
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/utils"
+)
+
+// Ages are the maximum age ratings that can be chosen, 0 meaning no limit
+var Ages = []int{0, 3, 7, 10, 12, 13, 16, 17, 18}
+
+// esrbAges are the minimum ages of the ESRB ratings
+var esrbAges = map[string]int{
+	"EC": 3, "E": 6, "E10+": 10, "KA": 6, "T": 13, "M": 17, "AO": 18,
+}
+
+// ratings are the minimum ages of the games, by CRC
+var ratings = map[uint32]int{}
+
+// warning is how long before the end of a session a warning is displayed
+const warning = 5 * 60
+
+var (
+	played float32 // seconds played in this session
+	warned bool
+)
+
+// age converts an ESRB rating like "T - Teen" or a PEGI rating like "12" to
+// a minimum age
+func age(key, value string) int {
+	switch key {
+	case "esrb_rating":
+		code := strings.TrimSpace(strings.SplitN(value, " - ", 2)[0])
+		return esrbAges[code]
+	case "pegi_rating":
+		n, _ := strconv.Atoi(strings.TrimSpace(value))
+		return n
+	}
+	return 0
+}
+
+// tokens splits a clrmamepro dat file in words, quoted strings being a single
+// word
+func tokens(r io.Reader) ([]string, error) {
+	var list []string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := s.Text()
+		for {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				break
+			}
+			if line[0] == '"' {
+				end := strings.IndexByte(line[1:], '"')
+				if end < 0 {
+					list = append(list, line[1:])
+					break
+				}
+				list = append(list, line[1:end+1])
+				line = line[end+2:]
+				continue
+			}
+			end := strings.IndexAny(line, " \t")
+			if end < 0 {
+				end = len(line)
+			}
+			list = append(list, line[:end])
+			line = line[end:]
+		}
+	}
+	return list, s.Err()
+}
+
+// parseRatings reads the age ratings of a clrmamepro metadat file, like
+// game ( esrb_rating "T - Teen" rom ( crc 3337EC46 ) )
+func parseRatings(r io.Reader, into map[uint32]int) error {
+	words, err := tokens(r)
+	if err != nil {
+		return err
+	}
+	rating := 0
+	for i := 0; i+1 < len(words); i++ {
+		switch words[i] {
+		case "game":
+			rating = 0
+		case "esrb_rating", "pegi_rating":
+			if a := age(words[i], words[i+1]); a > rating {
+				rating = a
+			}
+		case "crc":
+			crc, err := strconv.ParseUint(words[i+1], 16, 32)
+			if err == nil && rating > 0 {
+				into[uint32(crc)] = rating
+			}
+		}
+	}
+	return nil
+}
+
+// LoadRatings reads the ESRB and PEGI metadat files found in the database
+// directory
+func LoadRatings() {
+	ratings = map[uint32]int{}
+	filepath.Walk(settings.Current.DatabaseDirectory, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != ".dat" {
+			return nil
+		}
+		dir := strings.ToLower(filepath.Base(filepath.Dir(path)))
+		if dir != "esrb" && dir != "pegi" {
+			return nil
+		}
+		fd, err := os.Open(path)
+		if err != nil {
+			return nil
+		}
+		defer fd.Close()
+		parseRatings(fd, ratings)
+		return nil
+	})
+}
+
+// Restricted tells if a game needs the PIN to be launched
+func Restricted(playlist string, crc uint32) bool {
+	if RestrictedPlaylist(playlist) {
+		return true
+	}
+	max := settings.Current.ParentalMaxAge
+	return max > 0 && ratings[crc] > max
+}
+
+// RestrictedPlaylist tells if a playlist needs the PIN to be opened
+func RestrictedPlaylist(playlist string) bool {
+	return utils.StringInSlice(playlist, settings.Current.ParentalPlaylists)
+}
+
+// ToggleRestriction restricts a playlist or lifts its restriction
+func ToggleRestriction(playlist string) {
+	var list []string
+	for _, p := range settings.Current.ParentalPlaylists {
+		if p != playlist {
+			list = append(list, p)
+		}
+	}
+	if !RestrictedPlaylist(playlist) {
+		list = append(list, playlist)
+	}
+	settings.Current.ParentalPlaylists = list
+}
+
+// limit is the length of a session in seconds, 0 for no limit
+func limit() float32 {
+	return float32(settings.Current.ParentalSessionLimit * 60)
+}
+
+// Tick counts the play time, it is called for each frame run by the core
+func Tick(dt float32) {
+	if limit() == 0 {
+		return
+	}
+	played += dt
+	if !warned && played >= limit()-warning && played < limit() {
+		warned = true
+		ntf.DisplayAndLog(ntf.Warning, "Parental", "%d minutes of play left.", warning/60)
+	}
+}
+
+// Expired tells if the play time of the session is over
+func Expired() bool {
+	return limit() > 0 && played >= limit()
+}
+
+// Remaining is the play time left in the session, in seconds
+func Remaining() float32 {
+	if limit() == 0 || played > limit() {
+		return 0
+	}
+	return limit() - played
+}
+
+// ResetSession starts a new session
+func ResetSession() {
+	played = 0
+	warned = false
+}
@@ -0,0 +1,111 @@
+package parental
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/libretro/ludo/settings"
+)
+
+func Test_age(t *testing.T) {
+	tests := []struct {
+		key, value string
+		want       int
+	}{
+		{"esrb_rating", "T - Teen", 13},
+		{"esrb_rating", "E10+ - Everyone 10+", 10},
+		{"esrb_rating", "M", 17},
+		{"pegi_rating", "16", 16},
+		{"esrb_rating", "RP - Rating Pending", 0},
+		{"users", "2", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := age(tt.key, tt.value); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseRatings(t *testing.T) {
+	dat := `clrmamepro (
+	name "Nintendo - Super Nintendo Entertainment System"
+)
+
+game (
+	comment "Mortal Kombat (USA)"
+	esrb_rating "M - Mature"
+	rom ( crc B2B9B2B9 )
+)
+
+game (
+	comment "Tetris"
+	esrb_rating "E - Everyone"
+	rom ( crc 0000ABCD )
+)
+
+game (
+	comment "Unrated"
+	rom ( crc 12345678 )
+)
+`
+	got := map[uint32]int{}
+	if err := parseRatings(strings.NewReader(dat), got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[uint32]int{0xB2B9B2B9: 17, 0xABCD: 6}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestRestricted(t *testing.T) {
+	ratings = map[uint32]int{1: 17, 2: 6}
+	settings.Current.ParentalMaxAge = 12
+	settings.Current.ParentalPlaylists = nil
+	defer func() {
+		ratings = map[uint32]int{}
+		settings.Current.ParentalMaxAge = 0
+		settings.Current.ParentalPlaylists = nil
+	}()
+
+	if !Restricted("Sega - Mega Drive - Genesis", 1) {
+		t.Error("a mature game is not restricted")
+	}
+	if Restricted("Sega - Mega Drive - Genesis", 2) || Restricted("Sega - Mega Drive - Genesis", 3) {
+		t.Error("an unrated or everyone game is restricted")
+	}
+
+	ToggleRestriction("Sega - Mega Drive - Genesis")
+	if !Restricted("Sega - Mega Drive - Genesis", 2) {
+		t.Error("a game of a restricted playlist is not restricted")
+	}
+	ToggleRestriction("Sega - Mega Drive - Genesis")
+	if RestrictedPlaylist("Sega - Mega Drive - Genesis") {
+		t.Error("the restriction is not lifted")
+	}
+}
+
+func TestTick(t *testing.T) {
+	settings.Current.ParentalSessionLimit = 10
+	defer func() { settings.Current.ParentalSessionLimit = 0 }()
+	ResetSession()
+
+	Tick(9 * 60)
+	if Expired() || !warned {
+		t.Errorf("expired %v, warned %v after 9 minutes", Expired(), warned)
+	}
+	if got := Remaining(); got != 60 {
+		t.Errorf("got %v remaining, want 60", got)
+	}
+	Tick(60)
+	if !Expired() {
+		t.Error("not expired after 10 minutes")
+	}
+	ResetSession()
+	if Expired() {
+		t.Error("expired after a reset")
+	}
+}
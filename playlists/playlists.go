@@ -7,12 +7,12 @@ import (
 	"bufio"
 	"encoding/csv"
 	"io"
-	"log"
 	"os"
 	"path/filepath"
 	"sort"
 	"strconv"
 
+	"github.com/libretro/ludo/logs"
 	"github.com/libretro/ludo/settings"
 )
 
@@ -33,7 +33,7 @@ var Playlists = map[string]Playlist{}
 func getPaths() (paths []string) {
 	paths, err := filepath.Glob(settings.Current.PlaylistsDirectory + "/*.csv")
 	if err != nil {
-		log.Println(err)
+		logs.Warnf("Playlists", "%v", err)
 	}
 	return
 }
@@ -46,7 +46,7 @@ func Load() {
 
 		file, err := os.Open(path)
 		if err != nil {
-			log.Println(err)
+			logs.Warnf("Playlists", "%v", err)
 			continue
 		}
 		defer file.Close()
@@ -59,7 +59,7 @@ func Load() {
 			if err == io.EOF {
 				break
 			} else if err != nil {
-				log.Println(err)
+				logs.Warnf("Playlists", "%v", err)
 				continue
 			}
 			var entry Game
@@ -68,7 +68,7 @@ func Load() {
 			if line[2] != "" {
 				u64, err := strconv.ParseUint(line[2], 16, 64)
 				if err != nil {
-					log.Println(err)
+					logs.Warnf("Playlists", "%v", err)
 				} else {
 					entry.CRC32 = uint32(u64)
 				}
@@ -94,6 +94,24 @@ func Contains(CSVPath, path string, CRC32 uint32) bool {
 	return false
 }
 
+// Find looks for a game in the playlists and returns the playlist containing
+// it, playlists being searched in alphabetical order
+func Find(path string, CRC32 uint32) (string, Game, bool) {
+	var csvs []string
+	for csv := range Playlists {
+		csvs = append(csvs, csv)
+	}
+	sort.Strings(csvs)
+	for _, csv := range csvs {
+		for _, entry := range Playlists[csv] {
+			if filepath.Clean(entry.Path) == filepath.Clean(path) || (CRC32 != 0 && entry.CRC32 == CRC32) {
+				return csv, entry, true
+			}
+		}
+	}
+	return "", Game{}, false
+}
+
 // Count is a quick way of knowing how many games are in a playlist
 func Count(path string) int {
 	return len(Playlists[filepath.Clean(path)])
@@ -73,6 +73,31 @@ func TestContains(t *testing.T) {
 	})
 }
 
+func TestFind(t *testing.T) {
+	settings.Current.PlaylistsDirectory = "./testdata"
+
+	Load()
+
+	t.Run("Should find the playlist of a game by CRC", func(t *testing.T) {
+		csv, game, ok := Find("", 2933500612)
+		if !ok {
+			t.Fatal("game not found")
+		}
+		if want := "testdata/Sega - Master System - Mark III.csv"; csv != want {
+			t.Errorf("got = %v, want %v", csv, want)
+		}
+		if game.CRC32 != 2933500612 {
+			t.Errorf("got = %v, want %v", game.CRC32, 2933500612)
+		}
+	})
+
+	t.Run("Should not find unknown games", func(t *testing.T) {
+		if _, _, ok := Find("/nowhere.zip", 1); ok {
+			t.Error("got = true, want false")
+		}
+	})
+}
+
 func TestCount(t *testing.T) {
 	settings.Current.PlaylistsDirectory = "./testdata"
 
@@ -0,0 +1,173 @@
+// Package presets bundles the setup of a core, its option values, its shader
+// config and its remap, into a named preset file. Presets can be shared
+// between users, and applied to all the games of a core or to a single game.
+package presets
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/libretro/ludo/options"
+	"github.com/libretro/ludo/remap"
+	"github.com/libretro/ludo/shaders"
+	"github.com/libretro/ludo/utils"
+	"github.com/pelletier/go-toml"
+)
+
+// Preset is the content of a preset file. Core is the name of the core the
+// preset was made with, the option keys only make sense for this core.
+type Preset struct {
+	Name    string            `toml:"name"`
+	Core    string            `toml:"core"`
+	Options map[string]string `toml:"options"`
+	Shader  shaders.Config    `toml:"shader"`
+	Remap   remap.File        `toml:"remap"`
+}
+
+// Capture makes a preset of a setup. The options can be nil for cores
+// without options.
+func Capture(name, core string, o *options.Options, c shaders.Config, r remap.Remap) Preset {
+	p := Preset{
+		Name:    name,
+		Core:    utils.FileName(core),
+		Options: map[string]string{},
+		Shader:  shaders.Config{Preset: c.Preset, Params: map[string]float32{}},
+		Remap:   remap.Encode(r),
+	}
+	for k, v := range c.Params {
+		p.Shader.Params[k] = v
+	}
+	if o != nil {
+		o.Lock()
+		for _, v := range o.Vars {
+			p.Options[v.Key] = v.Choices[v.Choice]
+		}
+		o.Unlock()
+	}
+	return p
+}
+
+// SetOptions changes the values of the options found in the preset, and
+// returns how many were changed. The values not offered by the core are
+// ignored. The values set for the running game only aren't saved in the
+// options of the core.
+func SetOptions(p Preset, o *options.Options, gameOnly bool) int {
+	if o == nil {
+		return 0
+	}
+	o.Lock()
+	defer o.Unlock()
+	var n int
+	for _, v := range o.Vars {
+		val, ok := p.Options[v.Key]
+		if !ok || !utils.StringInSlice(val, v.Choices) {
+			continue
+		}
+		if gameOnly {
+			o.Hold(v)
+		} else {
+			o.Release(v.Key)
+		}
+		v.Choice = utils.IndexOfString(val, v.Choices)
+		n++
+	}
+	if n > 0 {
+		o.Updated = true
+	}
+	return n
+}
+
+// ShaderConfig returns a copy of the shader config of a preset
+func ShaderConfig(p Preset) shaders.Config {
+	c := shaders.Config{Preset: p.Shader.Preset, Params: map[string]float32{}}
+	for k, v := range p.Shader.Params {
+		c.Params[k] = v
+	}
+	return c
+}
+
+// ErrExists is returned when importing a preset named like an existing one
+var ErrExists = errors.New("a preset with this name exists")
+
+// fileName is the file of a preset in the presets directory. The name can't
+// point outside of it.
+func fileName(name string) string {
+	name = utils.ScrubIllegalChars(name)
+	name = strings.Replace(name, "\\", "_", -1)
+	name = strings.Replace(name, "..", "_", -1)
+	if strings.TrimSpace(name) == "" {
+		name = "preset"
+	}
+	return name + ".toml"
+}
+
+// Save writes a preset in dir, in a file named after the preset, and returns
+// its path
+func Save(dir string, p Preset) (string, error) {
+	// Dots in keys would be read as nested tables
+	f := p
+	f.Options = map[string]string{}
+	for k, v := range p.Options {
+		f.Options[strings.Replace(k, ".", "___", -1)] = v
+	}
+	b, err := toml.Marshal(f)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fileName(p.Name))
+	return path, ioutil.WriteFile(path, b, 0644)
+}
+
+// Load reads a preset file. A preset without a name is named after its file.
+func Load(path string) (Preset, error) {
+	var p Preset
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return p, err
+	}
+	if err := toml.Unmarshal(b, &p); err != nil {
+		return p, err
+	}
+	if p.Name == "" {
+		p.Name = utils.FileName(path)
+	}
+	opts := map[string]string{}
+	for k, v := range p.Options {
+		opts[strings.Replace(k, "___", ".", -1)] = v
+	}
+	p.Options = opts
+	if p.Shader.Params == nil {
+		p.Shader.Params = map[string]float32{}
+	}
+	return p, nil
+}
+
+// Import copies a preset file shared by another user to dir, so that it is
+// listed with the other presets. It returns the path of the copy. A preset
+// of the same name is only replaced when replace is set, ErrExists is
+// returned otherwise.
+func Import(dir, path string, replace bool) (string, error) {
+	p, err := Load(path)
+	if err != nil {
+		return "", err
+	}
+	dest := filepath.Join(dir, fileName(p.Name))
+	if _, err := os.Stat(dest); err == nil && !replace {
+		return dest, ErrExists
+	}
+	return Save(dir, p)
+}
+
+// List returns the paths of the preset files of dir, sorted by name
+func List(dir string) []string {
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.toml"))
+	sort.Strings(paths)
+	return paths
+}
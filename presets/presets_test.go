@@ -0,0 +1,161 @@
+package presets
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/adrg/xdg"
+	"github.com/libretro/ludo/options"
+	"github.com/libretro/ludo/remap"
+	"github.com/libretro/ludo/shaders"
+	"github.com/libretro/ludo/state"
+)
+
+func TestSaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "presets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	o := &options.Options{Vars: []*options.Variable{
+		{Key: "snes9x.region", Choices: []string{"auto", "ntsc", "pal"}, Choice: 2},
+		{Key: "snes9x_overclock", Choices: []string{"disabled", "enabled"}, Choice: 1},
+	}}
+	r := remap.Identity()
+	r.Targets[0] = 8
+	r.Turbo[8] = true
+	c := shaders.Config{Preset: "CRT", Params: map[string]float32{"SCANLINES": 0.5}}
+
+	p := Capture("My CRT Setup", "/cores/snes9x_libretro.so", o, c, r)
+	c.Params["SCANLINES"] = 1
+	if p.Shader.Params["SCANLINES"] != 0.5 {
+		t.Errorf("Capture() shares the shader parameters")
+	}
+
+	path, err := Save(dir, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(dir, "My CRT Setup.toml") {
+		t.Errorf("Save() path = %v", path)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, p) {
+		t.Errorf("Load() = %+v, want %+v", got, p)
+	}
+	if got.Core != "snes9x_libretro" {
+		t.Errorf("Core = %v", got.Core)
+	}
+	if remap.Decode(got.Remap) != r {
+		t.Errorf("remap not restored")
+	}
+
+	o.Vars[0].Choice = 0
+	o.Vars[1].Choice = 0
+	o.Updated = false
+	got.Options["snes9x_overclock"] = "turbo"
+	if n := SetOptions(got, o, false); n != 1 || o.Vars[0].Choice != 2 || o.Vars[1].Choice != 0 || !o.Updated {
+		t.Errorf("SetOptions() = %d, vars %d %d", n, o.Vars[0].Choice, o.Vars[1].Choice)
+	}
+	if SetOptions(got, nil, false) != 0 {
+		t.Errorf("SetOptions() without options should change nothing")
+	}
+}
+
+func TestImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "presets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	shared := filepath.Join(dir, "download.toml")
+	ioutil.WriteFile(shared, []byte(`core = "mgba_libretro"
+
+[options]
+mgba_solar_sensor_level = "3"
+
+[shader]
+preset = "LCD"
+`), 0644)
+
+	presetsDir := filepath.Join(dir, "presets")
+	path, err := Import(presetsDir, shared, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Import(presetsDir, shared, false); err != ErrExists {
+		t.Errorf("Import() of an existing preset = %v, want ErrExists", err)
+	}
+	if _, err := Import(presetsDir, shared, true); err != nil {
+		t.Errorf("Import() replacing a preset = %v", err)
+	}
+	if !reflect.DeepEqual(List(presetsDir), []string{path}) {
+		t.Errorf("List() = %v, want %v", List(presetsDir), path)
+	}
+	p, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "download" || p.Options["mgba_solar_sensor_level"] != "3" || ShaderConfig(p).Preset != "LCD" {
+		t.Errorf("Import() = %+v", p)
+	}
+	if remap.Decode(p.Remap) != remap.Identity() {
+		t.Errorf("a preset without remap should decode to the identity")
+	}
+}
+
+func TestSetOptionsForGame(t *testing.T) {
+	dir, err := ioutil.TempDir("", "presets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(home, core string) { xdg.ConfigHome, state.CorePath = home, core }(xdg.ConfigHome, state.CorePath)
+	xdg.ConfigHome = dir
+	state.CorePath = "/cores/snes9x_libretro.so"
+	os.MkdirAll(filepath.Join(dir, "ludo"), os.ModePerm)
+
+	o := &options.Options{Vars: []*options.Variable{
+		{Key: "snes9x_region", Choices: []string{"auto", "ntsc", "pal"}},
+	}}
+	p := Preset{Options: map[string]string{"snes9x_region": "pal"}}
+	if n := SetOptions(p, o, true); n != 1 || o.Vars[0].Choice != 2 {
+		t.Fatalf("SetOptions() = %d, choice %d", n, o.Vars[0].Choice)
+	}
+	if err := o.Save(); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ioutil.ReadFile(filepath.Join(dir, "ludo", "snes9x_libretro.toml"))
+	if !strings.Contains(string(b), `"auto"`) {
+		t.Errorf("saved = %s, want the value of the core", b)
+	}
+
+	SetOptions(p, o, false)
+	o.Save()
+	b, _ = ioutil.ReadFile(filepath.Join(dir, "ludo", "snes9x_libretro.toml"))
+	if !strings.Contains(string(b), `"pal"`) {
+		t.Errorf("saved = %s, want the value of the preset", b)
+	}
+}
+
+func Test_fileName(t *testing.T) {
+	for name, want := range map[string]string{
+		"My CRT Setup": "My CRT Setup.toml",
+		"..\\..\\evil": "____evil.toml",
+		"../../evil":   "____evil.toml",
+		"  ":           "preset.toml",
+	} {
+		if got := fileName(name); got != want {
+			t.Errorf("fileName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,384 @@
+// Package record captures the game video and audio by piping them to an
+// ffmpeg subprocess. The result is written to a MP4 or MKV file, or streamed
+// to a RTMP server. Frames are sent to ffmpeg on its standard input, audio on
+// an extra file descriptor, which isn't supported on Windows. The audio can
+// also be captured alone, to a WAV file written directly or to a FLAC file
+// encoded by ffmpeg.
+package record
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/disintegration/imaging"
+	"github.com/libretro/ludo/crash"
+	"github.com/libretro/ludo/logs"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/utils"
+)
+
+// Qualities are the encoding quality presets
+var Qualities = []string{"Low", "Medium", "High", "Lossless"}
+
+// Formats are the containers recordings can be saved in. WAV and FLAC only
+// hold the audio.
+var Formats = []string{"MP4", "MKV", "WAV", "FLAC"}
+
+// extensions are the file extensions of the formats
+var extensions = map[string]string{
+	"MP4":  ".mp4",
+	"MKV":  ".mkv",
+	"WAV":  ".wav",
+	"FLAC": ".flac",
+}
+
+// AudioOnly tells if the selected format only records the audio
+func AudioOnly() bool {
+	f := settings.Current.RecordFormat
+	return f == "WAV" || f == "FLAC"
+}
+
+// queueSize is the number of frames or audio batches waiting to be sent to
+// ffmpeg. Data is dropped when the queue is full rather than slowing down
+// the game. Dropped frames are replaced by the previous one, ffmpeg expects
+// a constant framerate.
+const queueSize = 120
+
+// videoFrame is a frame queued for ffmpeg, written after repeats copies of
+// the previous frame
+type videoFrame struct {
+	pix     []byte
+	repeats int
+}
+
+type recorder struct {
+	cmd           *exec.Cmd
+	frames        chan videoFrame
+	samples       chan []byte
+	width, height int
+	repeats       int // frames to repeat before the next queued one
+	wg            sync.WaitGroup
+}
+
+var (
+	current *recorder
+	mu      sync.Mutex
+)
+
+// Active tells if a recording is in progress
+func Active() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return current != nil
+}
+
+// Video tells if a recording capturing the video is in progress
+func Video() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return current != nil && current.frames != nil
+}
+
+// Path returns the location of a new recording for a game
+func Path(game string) string {
+	ext, ok := extensions[settings.Current.RecordFormat]
+	if !ok {
+		ext = ".mp4"
+	}
+	return filepath.Join(settings.Current.RecordingsDirectory, utils.DatedName(game)+ext)
+}
+
+// args builds the ffmpeg command line
+func args(output string, width, height int, fps, sampleRate float64, quality string, stream bool) []string {
+	a := []string{
+		"-y", "-loglevel", "error",
+		"-f", "rawvideo", "-pix_fmt", "rgba",
+		"-s", fmt.Sprintf("%dx%d", width, height),
+		"-r", fmt.Sprintf("%.4f", fps),
+		"-thread_queue_size", "512", "-i", "pipe:0",
+		"-f", "s16le", "-ar", fmt.Sprintf("%d", int(sampleRate)), "-ac", "2",
+		"-thread_queue_size", "512", "-i", "pipe:3",
+		"-c:v", "libx264",
+	}
+
+	switch quality {
+	case "Low":
+		a = append(a, "-preset", "veryfast", "-crf", "28", "-pix_fmt", "yuv420p")
+	case "High":
+		a = append(a, "-preset", "medium", "-crf", "18", "-pix_fmt", "yuv420p")
+	case "Lossless":
+		a = append(a, "-preset", "veryfast", "-qp", "0", "-pix_fmt", "yuv444p")
+	default:
+		a = append(a, "-preset", "fast", "-crf", "23", "-pix_fmt", "yuv420p")
+	}
+
+	a = append(a, "-c:a", "aac", "-b:a", "128k")
+
+	if stream {
+		a = append(a, "-tune", "zerolatency", "-g", fmt.Sprintf("%d", int(fps*2)), "-f", "flv")
+	}
+
+	return append(a, output)
+}
+
+// audioArgs builds the ffmpeg command line encoding the audio alone to FLAC
+func audioArgs(output string, sampleRate float64) []string {
+	return []string{
+		"-y", "-loglevel", "error",
+		"-f", "s16le", "-ar", fmt.Sprintf("%d", int(sampleRate)), "-ac", "2",
+		"-i", "pipe:0",
+		"-c:a", "flac",
+		output,
+	}
+}
+
+// startAudio records the audio alone to path, in the selected format
+func startAudio(path string, sampleRate float64) error {
+	if sampleRate <= 0 {
+		return errors.New("no game to record")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	r := &recorder{samples: make(chan []byte, queueSize)}
+
+	var w io.WriteCloser
+	if settings.Current.RecordFormat == "WAV" {
+		wav, err := createWAV(path, uint32(sampleRate))
+		if err != nil {
+			return err
+		}
+		w = wav
+	} else {
+		r.cmd = exec.Command("ffmpeg", audioArgs(path, sampleRate)...)
+		r.cmd.Stderr = os.Stderr
+		pipe, err := r.cmd.StdinPipe()
+		if err != nil {
+			return err
+		}
+		if err := r.cmd.Start(); err != nil {
+			return fmt.Errorf("could not start ffmpeg: %v", err)
+		}
+		w = pipe
+	}
+
+	r.wg.Add(1)
+	go r.pump(r.samples, w)
+	current = r
+
+	return nil
+}
+
+// Start launches ffmpeg to record frames of size width*height. The output is
+// the stream URL from the settings if streaming is enabled, or path.
+func Start(path string, width, height int, fps, sampleRate float64) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if current != nil {
+		return errors.New("already recording")
+	}
+	if AudioOnly() {
+		return startAudio(path, sampleRate)
+	}
+	if width <= 0 || height <= 0 || fps <= 0 || sampleRate <= 0 {
+		return errors.New("no game to record")
+	}
+
+	// The encoder works on 2x2 pixel blocks
+	width += width % 2
+	height += height % 2
+
+	stream := settings.Current.RecordStream
+	output := path
+	if stream {
+		if !strings.HasPrefix(settings.Current.StreamURL, "rtmp") {
+			return errors.New("invalid stream URL, set stream_url in the settings file")
+		}
+		output = settings.Current.StreamURL
+	} else if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	q := settings.Current.RecordQuality
+	cmd := exec.Command("ffmpeg", args(output, width, height, fps, sampleRate, q, stream)...)
+	cmd.Stderr = os.Stderr
+
+	videoPipe, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	audioRead, audioPipe, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	cmd.ExtraFiles = []*os.File{audioRead}
+
+	if err := cmd.Start(); err != nil {
+		audioRead.Close()
+		audioPipe.Close()
+		return fmt.Errorf("could not start ffmpeg: %v", err)
+	}
+	audioRead.Close()
+
+	r := &recorder{
+		cmd:     cmd,
+		frames:  make(chan videoFrame, queueSize),
+		samples: make(chan []byte, queueSize),
+		width:   width,
+		height:  height,
+	}
+	r.wg.Add(2)
+	go r.pumpFrames(videoPipe)
+	go r.pump(r.samples, audioPipe)
+	current = r
+
+	return nil
+}
+
+// pump writes queued data to one of the ffmpeg inputs, or to the audio
+// file, until the queue is closed
+func (r *recorder) pump(queue chan []byte, w io.WriteCloser) {
+	defer crash.Recover()
+	defer r.wg.Done()
+	defer func() {
+		if err := w.Close(); err != nil {
+			logs.Errorf("Record", "%v", err)
+		}
+	}()
+	for b := range queue {
+		if _, err := w.Write(b); err != nil {
+			logs.Errorf("Record", "%v", err)
+			for range queue {
+			}
+			return
+		}
+	}
+}
+
+// pumpFrames writes the queued frames to the video input of ffmpeg, repeating
+// the previous frame in place of the duplicated and dropped ones
+func (r *recorder) pumpFrames(w io.WriteCloser) {
+	defer crash.Recover()
+	defer r.wg.Done()
+	defer func() {
+		if err := w.Close(); err != nil {
+			logs.Errorf("Record", "%v", err)
+		}
+	}()
+	var prev []byte
+	for f := range r.frames {
+		if err := writeFrame(w, prev, f); err != nil {
+			logs.Errorf("Record", "%v", err)
+			for range r.frames {
+			}
+			return
+		}
+		prev = f.pix
+	}
+}
+
+// writeFrame writes a frame after the repeats of the previous one
+func writeFrame(w io.Writer, prev []byte, f videoFrame) error {
+	if prev != nil {
+		for i := 0; i < f.repeats; i++ {
+			if _, err := w.Write(prev); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := w.Write(f.pix)
+	return err
+}
+
+// Stop closes the ffmpeg inputs and waits for the recording to be finalized
+func Stop() error {
+	mu.Lock()
+	r := current
+	current = nil
+	mu.Unlock()
+
+	if r == nil {
+		return nil
+	}
+
+	if r.frames != nil {
+		close(r.frames)
+	}
+	close(r.samples)
+	r.wg.Wait()
+	if r.cmd == nil {
+		return nil
+	}
+	return r.cmd.Wait()
+}
+
+// Frame queues a game frame. Frames are scaled to the size the recording
+// was started with.
+func Frame(img image.Image) {
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil || current.frames == nil {
+		return
+	}
+
+	b := img.Bounds()
+	if b.Dx() != current.width || b.Dy() != current.height {
+		img = imaging.Resize(img, current.width, current.height, imaging.NearestNeighbor)
+	}
+
+	var pix []byte
+	switch i := img.(type) {
+	case *image.RGBA:
+		pix = i.Pix
+	case *image.NRGBA:
+		pix = i.Pix
+	default:
+		pix = imaging.Clone(img).Pix
+	}
+
+	select {
+	case current.frames <- videoFrame{pix: pix, repeats: current.repeats}:
+		current.repeats = 0
+	default:
+		logs.Warnf("Record", "Dropped a frame")
+		current.repeats++
+	}
+}
+
+// Repeat records the previous frame again, for the frames the core didn't
+// redraw
+func Repeat(n int) {
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil || current.frames == nil {
+		return
+	}
+	current.repeats += n
+}
+
+// Audio queues a batch of interleaved stereo 16 bits samples
+func Audio(buf []byte) {
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		return
+	}
+
+	b := make([]byte, len(buf))
+	copy(b, buf)
+
+	select {
+	case current.samples <- b:
+	default:
+		logs.Warnf("Record", "Dropped audio samples")
+	}
+}
@@ -0,0 +1,90 @@
+package record
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// flag returns the value following the last occurrence of a flag
+func flag(args []string, name string) string {
+	v := ""
+	for i := 0; i < len(args)-1; i++ {
+		if args[i] == name {
+			v = args[i+1]
+		}
+	}
+	return v
+}
+
+func Test_args(t *testing.T) {
+	t.Run("Records a file", func(t *testing.T) {
+		got := args("out.mp4", 320, 240, 60, 44100, "High", false)
+		want := []string{
+			"-y", "-loglevel", "error",
+			"-f", "rawvideo", "-pix_fmt", "rgba",
+			"-s", "320x240",
+			"-r", "60.0000",
+			"-thread_queue_size", "512", "-i", "pipe:0",
+			"-f", "s16le", "-ar", "44100", "-ac", "2",
+			"-thread_queue_size", "512", "-i", "pipe:3",
+			"-c:v", "libx264",
+			"-preset", "medium", "-crf", "18", "-pix_fmt", "yuv420p",
+			"-c:a", "aac", "-b:a", "128k",
+			"out.mp4",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Streams to a server", func(t *testing.T) {
+		got := args("rtmp://localhost/live", 256, 224, 60.0988, 32040.5, "Medium", true)
+		tail := []string{"-tune", "zerolatency", "-g", "120", "-f", "flv", "rtmp://localhost/live"}
+		if !reflect.DeepEqual(got[len(got)-len(tail):], tail) {
+			t.Errorf("got %v, want suffix %v", got, tail)
+		}
+		if flag(got, "-r") != "60.0988" || flag(got, "-ar") != "32040" {
+			t.Errorf("got %v", got)
+		}
+	})
+
+	t.Run("Defaults to the medium quality", func(t *testing.T) {
+		got := args("out.mkv", 256, 224, 60, 48000, "", false)
+		if flag(got, "-preset") != "fast" || flag(got, "-crf") != "23" {
+			t.Errorf("got %v", got)
+		}
+	})
+}
+
+func Test_audioArgs(t *testing.T) {
+	got := audioArgs("out.flac", 32040.5)
+	want := []string{
+		"-y", "-loglevel", "error",
+		"-f", "s16le", "-ar", "32040", "-ac", "2",
+		"-i", "pipe:0",
+		"-c:a", "flac",
+		"out.flac",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func Test_writeFrame(t *testing.T) {
+	var b bytes.Buffer
+	if err := writeFrame(&b, []byte{1}, videoFrame{pix: []byte{2}, repeats: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.Bytes(), []byte{1, 1, 2}; !bytes.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	b.Reset()
+	if err := writeFrame(&b, nil, videoFrame{pix: []byte{2}, repeats: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.Bytes(), []byte{2}; !bytes.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
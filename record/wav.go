@@ -0,0 +1,66 @@
+package record
+
+import (
+	"encoding/binary"
+	"os"
+)
+
+// wavHeaderSize is the size of the RIFF header preceding the samples
+const wavHeaderSize = 44
+
+// wavWriter writes interleaved stereo 16 bits samples to a WAV file. The
+// sizes in the header are only known once the recording is over, they are
+// filled on Close.
+type wavWriter struct {
+	fd   *os.File
+	rate uint32
+	size uint32
+}
+
+// wavHeader builds the header of a WAV file holding size bytes of samples
+func wavHeader(sampleRate, size uint32) []byte {
+	const channels, bits = 2, 16
+	h := make([]byte, wavHeaderSize)
+	copy(h[0:], "RIFF")
+	binary.LittleEndian.PutUint32(h[4:], 36+size)
+	copy(h[8:], "WAVE")
+	copy(h[12:], "fmt ")
+	binary.LittleEndian.PutUint32(h[16:], 16)
+	binary.LittleEndian.PutUint16(h[20:], 1) // PCM
+	binary.LittleEndian.PutUint16(h[22:], channels)
+	binary.LittleEndian.PutUint32(h[24:], sampleRate)
+	binary.LittleEndian.PutUint32(h[28:], sampleRate*channels*bits/8)
+	binary.LittleEndian.PutUint16(h[32:], channels*bits/8)
+	binary.LittleEndian.PutUint16(h[34:], bits)
+	copy(h[36:], "data")
+	binary.LittleEndian.PutUint32(h[40:], size)
+	return h
+}
+
+// createWAV creates a WAV file, to be closed once all the samples are written
+func createWAV(path string, sampleRate uint32) (*wavWriter, error) {
+	fd, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fd.Write(wavHeader(sampleRate, 0)); err != nil {
+		fd.Close()
+		return nil, err
+	}
+	return &wavWriter{fd: fd, rate: sampleRate}, nil
+}
+
+func (w *wavWriter) Write(b []byte) (int, error) {
+	n, err := w.fd.Write(b)
+	w.size += uint32(n)
+	return n, err
+}
+
+// Close writes the final sizes in the header and closes the file
+func (w *wavWriter) Close() error {
+	defer w.fd.Close()
+	if _, err := w.fd.WriteAt(wavHeader(w.rate, w.size), 0); err != nil {
+		return err
+	}
+	return w.fd.Sync()
+}
@@ -0,0 +1,72 @@
+package record
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_wavHeader(t *testing.T) {
+	h := wavHeader(44100, 1000)
+
+	t.Run("Has the RIFF markers", func(t *testing.T) {
+		if string(h[0:4]) != "RIFF" || string(h[8:12]) != "WAVE" || string(h[36:40]) != "data" {
+			t.Errorf("got %q", h)
+		}
+	})
+
+	t.Run("Describes stereo 16 bits samples", func(t *testing.T) {
+		if got := binary.LittleEndian.Uint16(h[22:]); got != 2 {
+			t.Errorf("got %v channels, want 2", got)
+		}
+		if got := binary.LittleEndian.Uint32(h[24:]); got != 44100 {
+			t.Errorf("got a rate of %v, want 44100", got)
+		}
+		if got := binary.LittleEndian.Uint32(h[28:]); got != 44100*4 {
+			t.Errorf("got %v bytes per second, want %v", got, 44100*4)
+		}
+		if got := binary.LittleEndian.Uint16(h[34:]); got != 16 {
+			t.Errorf("got %v bits, want 16", got)
+		}
+	})
+
+	t.Run("Holds the sizes", func(t *testing.T) {
+		if got := binary.LittleEndian.Uint32(h[4:]); got != 1036 {
+			t.Errorf("got %v, want 1036", got)
+		}
+		if got := binary.LittleEndian.Uint32(h[40:]); got != 1000 {
+			t.Errorf("got %v, want 1000", got)
+		}
+	})
+}
+
+func Test_wavWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ludo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "out.wav")
+	w, err := createWAV(path, 32000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte{1, 2, 3, 4})
+	w.Write([]byte{5, 6, 7, 8})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append(wavHeader(32000, 8), 1, 2, 3, 4, 5, 6, 7, 8)
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
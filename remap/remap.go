@@ -0,0 +1,254 @@
+// Package remap deals with input remapping. A remap tells which RetroPad
+// button is sent to the core when a physical RetroPad button is pressed.
+// Remaps can be saved for a core, or for a specific game of a core, in which
+// case the game remap takes precedence.
+package remap
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/adrg/xdg"
+	"github.com/libretro/ludo/utils"
+	"github.com/pelletier/go-toml"
+)
+
+// Buttons are the names of the RetroPad buttons that can be remapped, in the
+// order of their libretro ids
+var Buttons = []string{
+	"B", "Y", "Select", "Start", "Up", "Down", "Left", "Right",
+	"A", "X", "L", "R", "L2", "R2", "L3", "R3",
+}
+
+// Remap maps each RetroPad button id to the button id received by the core.
+// Buttons flagged as turbo are pressed and released repeatedly while held,
+// staying pressed TurboDuty frames every TurboPeriod frames.
+// AnalogToDPad and DPadToAnalog make the left stick and the d-pad drive each
+// other, for cores that only read one of them. Devices are the names of the
+// device types plugged in the ports, empty for the default one.
+type Remap struct {
+	Targets       [16]uint32
+	Turbo         [16]bool
+	TurboPeriod   uint
+	TurboDuty     uint
+	AnalogToDPad  bool
+	DPadToAnalog  bool
+	DisableRumble bool
+	Devices       [Ports]string
+}
+
+// Ports is the number of ports whose device type is saved
+const Ports = 5
+
+// File is the serialized form of a Remap, as saved in the remap files
+type File struct {
+	Buttons     map[string]string `toml:"buttons"`
+	Turbo       []string          `toml:"turbo"`
+	TurboPeriod uint              `toml:"turbo_period"`
+	TurboDuty   uint              `toml:"turbo_duty"`
+
+	AnalogToDPad bool `toml:"analog_to_dpad"`
+	DPadToAnalog bool `toml:"dpad_to_analog"`
+
+	DisableRumble bool `toml:"disable_rumble"`
+
+	Devices []string `toml:"devices,omitempty"`
+}
+
+// Current is the remap in use for the running game
+var Current = Identity()
+
+// Identity returns a remap which sends every button as is
+func Identity() Remap {
+	r := Remap{TurboPeriod: 6, TurboDuty: 3}
+	for i := range r.Targets {
+		r.Targets[i] = uint32(i)
+	}
+	return r
+}
+
+// Incr cycles the target of a button in the given direction
+func (r *Remap) Incr(button int, direction int) {
+	n := len(Buttons)
+	r.Targets[button] = uint32((int(r.Targets[button]) + direction + n) % n)
+}
+
+// IncrTurboPeriod changes the length of a turbo cycle, in frames
+func (r *Remap) IncrTurboPeriod(direction int) {
+	p := int(r.TurboPeriod) + direction
+	if p < 2 {
+		p = 2
+	}
+	if p > 60 {
+		p = 60
+	}
+	r.TurboPeriod = uint(p)
+	if r.TurboDuty >= r.TurboPeriod {
+		r.TurboDuty = r.TurboPeriod - 1
+	}
+}
+
+// IncrTurboDuty changes the number of frames a turbo button stays pressed
+// during a turbo cycle
+func (r *Remap) IncrTurboDuty(direction int) {
+	d := int(r.TurboDuty) + direction
+	if d < 1 {
+		d = 1
+	}
+	if d > int(r.TurboPeriod)-1 {
+		d = int(r.TurboPeriod) - 1
+	}
+	r.TurboDuty = uint(d)
+}
+
+// TurboOn tells if turbo buttons are pressed during a given frame
+func (r *Remap) TurboOn(frame uint) bool {
+	if r.TurboPeriod == 0 {
+		return true
+	}
+	return frame%r.TurboPeriod < r.TurboDuty
+}
+
+// corePath returns the location of the remap file of a core
+func corePath(corePath string) string {
+	name := utils.FileName(corePath)
+	return filepath.Join(xdg.ConfigHome, "ludo", "remaps", name, name+".toml")
+}
+
+// gamePath returns the location of the remap file of a game, identified by
+// its CRC32 checksum
+func gamePath(corePath string, crc uint32) string {
+	name := utils.FileName(corePath)
+	return filepath.Join(xdg.ConfigHome, "ludo", "remaps", name, fmt.Sprintf("%08X.toml", crc))
+}
+
+// Load sets Current to the remap of the game if it exists, or to the remap of
+// the core, or to the identity remap if none of them exists
+func Load(core string, crc uint32) {
+	Current = Identity()
+	if r, err := read(gamePath(core, crc)); err == nil {
+		Current = r
+	} else if r, err := read(corePath(core)); err == nil {
+		Current = r
+	}
+}
+
+// SaveCore saves the current remap for all the games of a core
+func SaveCore(core string) error {
+	return write(corePath(core), Current)
+}
+
+// SaveGame saves the current remap for a specific game
+func SaveGame(core string, crc uint32) error {
+	return write(gamePath(core, crc), Current)
+}
+
+// Save saves the current remap where it was loaded from, for the game if it
+// has its own remap, for the core otherwise
+func Save(core string, crc uint32) error {
+	if _, err := os.Stat(gamePath(core, crc)); err == nil {
+		return SaveGame(core, crc)
+	}
+	return SaveCore(core)
+}
+
+// Import saves a remap for all the games of a core, like a remap migrated
+// from another frontend
+func Import(core string, r Remap) error {
+	return write(corePath(core), r)
+}
+
+func read(path string) (Remap, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Identity(), err
+	}
+
+	var f File
+	err = toml.Unmarshal(b, &f)
+	if err != nil {
+		return Identity(), err
+	}
+
+	return Decode(f), nil
+}
+
+// Decode converts a serialized remap to a Remap. Unknown buttons and invalid
+// turbo timings are ignored.
+func Decode(f File) Remap {
+	r := Identity()
+	for from, to := range f.Buttons {
+		if utils.StringInSlice(from, Buttons) && utils.StringInSlice(to, Buttons) {
+			r.Targets[utils.IndexOfString(from, Buttons)] = uint32(utils.IndexOfString(to, Buttons))
+		}
+	}
+	for _, name := range f.Turbo {
+		if utils.StringInSlice(name, Buttons) {
+			r.Turbo[utils.IndexOfString(name, Buttons)] = true
+		}
+	}
+	if f.TurboPeriod >= 2 {
+		r.TurboPeriod = f.TurboPeriod
+	}
+	if f.TurboDuty >= 1 && f.TurboDuty < r.TurboPeriod {
+		r.TurboDuty = f.TurboDuty
+	}
+	r.AnalogToDPad = f.AnalogToDPad
+	r.DPadToAnalog = f.DPadToAnalog
+	r.DisableRumble = f.DisableRumble
+	copy(r.Devices[:], f.Devices)
+	return r
+}
+
+// Encode converts a Remap to its serialized form
+func Encode(r Remap) File {
+	f := File{
+		Buttons:     make(map[string]string),
+		TurboPeriod: r.TurboPeriod,
+		TurboDuty:   r.TurboDuty,
+
+		AnalogToDPad: r.AnalogToDPad,
+		DPadToAnalog: r.DPadToAnalog,
+
+		DisableRumble: r.DisableRumble,
+	}
+	if r.Devices != [Ports]string{} {
+		f.Devices = r.Devices[:]
+	}
+	for i, to := range r.Targets {
+		f.Buttons[Buttons[i]] = Buttons[to]
+		if r.Turbo[i] {
+			f.Turbo = append(f.Turbo, Buttons[i])
+		}
+	}
+	return f
+}
+
+func write(path string, r Remap) error {
+	b, err := toml.Marshal(Encode(r))
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	fd, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	_, err = io.Copy(fd, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	return fd.Sync()
+}
@@ -0,0 +1,79 @@
+package remote
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+)
+
+// codeAlphabet are the characters of the pairing codes, without the ones
+// that look alike
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// codeLength is the number of characters of a pairing code, about 40 bits
+const codeLength = 8
+
+// NewPairingCode returns a random code the clients authenticate with
+func NewPairingCode() string {
+	b := make([]byte, codeLength)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	for i := range b {
+		b[i] = codeAlphabet[int(b[i])%len(codeAlphabet)]
+	}
+	return string(b)
+}
+
+// sameOrigin tells if a request doesn't come from a page of another site.
+// Browsers send the origin of cross-site requests and WebSocket upgrades,
+// the other clients usually send none.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+// codeCookie is the cookie of the pairing code, for the images the browser
+// loads itself. The code is never put in URLs, they end up in the history
+// and in the logs of proxies.
+const codeCookie = "ludo-code"
+
+// sameCode compares a pairing code to the expected one in constant time
+func sameCode(got, code string) bool {
+	return code != "" && subtle.ConstantTimeCompare([]byte(got), []byte(code)) == 1
+}
+
+// authorized tells if a request carries the pairing code, in the X-Ludo-Code
+// header or in the cookie
+func authorized(r *http.Request, code string) bool {
+	got := r.Header.Get("X-Ludo-Code")
+	if c, err := r.Cookie(codeCookie); got == "" && err == nil {
+		got = c.Value
+	}
+	return sameCode(got, code)
+}
+
+// public are the static pages, they ask for the pairing code. The gamepad
+// WebSocket checks the code once connected.
+var public = map[string]bool{"/": true, "/gamepad": true, "/gamepad/ws": true}
+
+// guard rejects the cross-site requests, and the requests without the
+// pairing code except for the static pages
+func guard(code string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !sameOrigin(r) {
+			http.Error(w, "cross-origin request", http.StatusForbidden)
+			return
+		}
+		if !public[r.URL.Path] && !authorized(r, code) {
+			http.Error(w, "wrong pairing code", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,284 @@
+// Package remote is an optional HTTP API to drive Ludo from another device,
+// like a phone or a home automation system, and a web UI to browse the
+// library and launch games from a phone. Requests are queued and run on the
+// main thread between two frames, as the cores and OpenGL aren't thread safe.
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/libretro/ludo/crash"
+	"github.com/libretro/ludo/logs"
+	"github.com/libretro/ludo/netpad"
+	"github.com/libretro/ludo/playlists"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
+)
+
+// Controller performs the actions that need the menu
+type Controller interface {
+	Launch(path, system string) error
+	SaveState() error
+	LoadState() error
+	Screenshot() (string, error)
+}
+
+// Status is the state of the emulator
+type Status struct {
+	Running bool   `json:"running"`
+	Paused  bool   `json:"paused"`
+	Menu    bool   `json:"menu"`
+	Core    string `json:"core,omitempty"`
+	Game    string `json:"game,omitempty"`
+}
+
+// Game is a game of a playlist
+type Game struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+var (
+	queue  = make(chan func(), 16)
+	server *http.Server
+)
+
+// timeout is how long a request waits for the main thread
+const timeout = 10 * time.Second
+
+// Process runs the queued requests, it is called once per frame
+func Process() {
+	for {
+		select {
+		case f := <-queue:
+			f()
+		default:
+			return
+		}
+	}
+}
+
+// do runs f on the main thread and waits for its result
+func do(f func() (interface{}, error)) (interface{}, error) {
+	type result struct {
+		v   interface{}
+		err error
+	}
+	done := make(chan result, 1)
+	select {
+	case queue <- func() {
+		v, err := f()
+		done <- result{v, err}
+	}:
+	case <-time.After(timeout):
+		return nil, errors.New("busy")
+	}
+	select {
+	case r := <-done:
+		return r.v, r.err
+	case <-time.After(timeout):
+		return nil, errors.New("timed out")
+	}
+}
+
+// reply writes a JSON answer, or the error
+func reply(w http.ResponseWriter, v interface{}, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		w.WriteHeader(http.StatusConflict)
+		v = map[string]string{"error": err.Error()}
+	}
+	if v == nil {
+		v = map[string]bool{"ok": true}
+	}
+	json.NewEncoder(w).Encode(v)
+}
+
+// action handles a request with the given method by running f on the main
+// thread
+func action(method string, f func(r *http.Request) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		v, err := do(func() (interface{}, error) { return f(r) })
+		reply(w, v, err)
+	}
+}
+
+func status() Status {
+	return Status{
+		Running: state.CoreRunning,
+		Paused:  state.Paused,
+		Menu:    state.MenuActive,
+		Core:    utils.FileName(state.CorePath),
+		Game:    utils.FileName(state.GamePath),
+	}
+}
+
+func listPlaylists() map[string][]Game {
+	list := map[string][]Game{}
+	for csv, playlist := range playlists.Playlists {
+		games := []Game{}
+		for _, g := range playlist {
+			games = append(games, Game{Name: g.Name, Path: g.Path})
+		}
+		sort.Slice(games, func(i, j int) bool { return games[i].Name < games[j].Name })
+		list[utils.FileName(csv)] = games
+	}
+	return list
+}
+
+// inPlaylist tells if a game is an entry of a playlist
+func inPlaylist(path string) bool {
+	for _, playlist := range playlists.Playlists {
+		for _, g := range playlist {
+			if g.Path == path {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Handler returns the routes of the API. The clients authenticate with the
+// pairing code.
+func Handler(c Controller, code string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", index)
+	mux.HandleFunc("/thumbnail", thumbnail)
+	mux.HandleFunc("/gamepad", netpad.Page)
+	mux.HandleFunc("/gamepad/ws", netpad.Socket(func(got string) bool {
+		return sameCode(got, code)
+	}))
+	mux.HandleFunc("/status", action(http.MethodGet, func(r *http.Request) (interface{}, error) {
+		return status(), nil
+	}))
+	mux.HandleFunc("/playlists", action(http.MethodGet, func(r *http.Request) (interface{}, error) {
+		return listPlaylists(), nil
+	}))
+	mux.HandleFunc("/launch", action(http.MethodPost, func(r *http.Request) (interface{}, error) {
+		path := r.FormValue("path")
+		if path == "" {
+			return nil, errors.New("missing path")
+		}
+		if !inPlaylist(path) {
+			return nil, errors.New("not in a playlist")
+		}
+		if err := c.Launch(path, r.FormValue("system")); err != nil {
+			return nil, err
+		}
+		return status(), nil
+	}))
+	mux.HandleFunc("/savestate", action(http.MethodPost, func(r *http.Request) (interface{}, error) {
+		return nil, c.SaveState()
+	}))
+	mux.HandleFunc("/loadstate", action(http.MethodPost, func(r *http.Request) (interface{}, error) {
+		return nil, c.LoadState()
+	}))
+	mux.HandleFunc("/pause", action(http.MethodPost, func(r *http.Request) (interface{}, error) {
+		if !state.CoreRunning {
+			return nil, errors.New("no game running")
+		}
+		switch r.FormValue("paused") {
+		case "true":
+			state.Paused = true
+		case "false":
+			state.Paused = false
+		default:
+			state.Paused = !state.Paused
+		}
+		return status(), nil
+	}))
+	mux.HandleFunc("/screenshot", action(http.MethodPost, func(r *http.Request) (interface{}, error) {
+		path, err := c.Screenshot()
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"path": path}, nil
+	}))
+	return guard(code, mux)
+}
+
+// listenAddress returns the address the server listens on. The host of the
+// settings is replaced by all the interfaces when the LAN can access it.
+func listenAddress(addr string, lan bool) string {
+	if !lan {
+		return addr
+	}
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return net.JoinHostPort("0.0.0.0", port)
+}
+
+// lanIP returns the first address of the machine on the local network
+func lanIP() string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return ""
+	}
+	for _, a := range addrs {
+		if n, ok := a.(*net.IPNet); ok && !n.IP.IsLoopback() && n.IP.To4() != nil {
+			return n.IP.String()
+		}
+	}
+	return ""
+}
+
+// URL returns the address of the web UI to open in a browser
+func URL() string {
+	addr := settings.Current.RemoteAddress
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "http://" + addr
+	}
+	if settings.Current.RemoteLAN {
+		if ip := lanIP(); ip != "" {
+			host = ip
+		}
+	}
+	return "http://" + net.JoinHostPort(host, port)
+}
+
+// Start serves the API on the address of the settings, if it is enabled. A
+// pairing code is created the first time.
+func Start(c Controller) {
+	if !settings.Current.RemoteEnable || server != nil {
+		return
+	}
+	if settings.Current.RemoteCode == "" {
+		settings.Current.RemoteCode = NewPairingCode()
+		settings.Save()
+	}
+	addr := listenAddress(settings.Current.RemoteAddress, settings.Current.RemoteLAN)
+	logs.Infof("Remote", "Listening on %s", addr)
+	server = &http.Server{Addr: addr, Handler: Handler(c, settings.Current.RemoteCode)}
+	go func(s *http.Server) {
+		defer crash.Recover()
+		if err := s.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logs.Errorf("Remote", "%v", err)
+		}
+	}(server)
+}
+
+// Stop closes the API server
+func Stop() {
+	if server == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	server.Shutdown(ctx)
+	server = nil
+}
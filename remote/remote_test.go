@@ -0,0 +1,281 @@
+package remote
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libretro/ludo/playlists"
+	"github.com/libretro/ludo/state"
+)
+
+type fakeController struct {
+	launched string
+	saved    bool
+}
+
+func (c *fakeController) Launch(path, system string) error {
+	c.launched = path
+	state.CoreRunning = true
+	state.GamePath = path
+	return nil
+}
+
+func (c *fakeController) SaveState() error {
+	c.saved = true
+	return nil
+}
+
+func (c *fakeController) LoadState() error {
+	return errors.New("no savestate to load")
+}
+
+func (c *fakeController) Screenshot() (string, error) {
+	return "/screenshots/game.png", nil
+}
+
+// mainLoop processes the queued requests until stopped
+func mainLoop(stop chan bool) {
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+			Process()
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
+
+// paired adds the pairing code to the requests
+type paired string
+
+func (code paired) RoundTrip(r *http.Request) (*http.Response, error) {
+	r.Header.Set("X-Ludo-Code", string(code))
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestNewPairingCode(t *testing.T) {
+	code := NewPairingCode()
+	if len(code) != codeLength || strings.Trim(code, codeAlphabet) != "" {
+		t.Errorf("got %q", code)
+	}
+	if NewPairingCode() == code {
+		t.Errorf("got the same code twice")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	stop := make(chan bool)
+	defer close(stop)
+	go mainLoop(stop)
+
+	c := &fakeController{}
+	srv := httptest.NewServer(Handler(c, "CODE2345"))
+	defer srv.Close()
+	client := &http.Client{Transport: paired("CODE2345")}
+
+	state.CoreRunning = false
+	state.Paused = false
+	playlists.Playlists = map[string]playlists.Playlist{
+		"/playlists/Nintendo - Game Boy.csv": {{Path: "/roms/tetris.gb", Name: "Tetris"}},
+	}
+
+	t.Run("Lists the playlists", func(t *testing.T) {
+		resp, err := client.Get(srv.URL + "/playlists")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var got map[string][]Game
+		json.NewDecoder(resp.Body).Decode(&got)
+		if len(got["Nintendo - Game Boy"]) != 1 || got["Nintendo - Game Boy"][0].Name != "Tetris" {
+			t.Errorf("got %v", got)
+		}
+	})
+
+	t.Run("Refuses to pause without game", func(t *testing.T) {
+		resp, err := client.Post(srv.URL+"/pause", "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusConflict {
+			t.Errorf("got %v, want %v", resp.StatusCode, http.StatusConflict)
+		}
+	})
+
+	t.Run("Launches a game", func(t *testing.T) {
+		resp, err := client.PostForm(srv.URL+"/launch", url.Values{"path": {"/roms/tetris.gb"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var got Status
+		json.NewDecoder(resp.Body).Decode(&got)
+		if c.launched != "/roms/tetris.gb" || !got.Running || got.Game != "tetris" {
+			t.Errorf("got %+v, launched %v", got, c.launched)
+		}
+	})
+
+	t.Run("Only launches the games of the playlists", func(t *testing.T) {
+		resp, err := client.PostForm(srv.URL+"/launch", url.Values{"path": {"/etc/passwd"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusConflict || c.launched != "/roms/tetris.gb" {
+			t.Errorf("got %v, launched %v", resp.StatusCode, c.launched)
+		}
+	})
+
+	t.Run("Pauses", func(t *testing.T) {
+		resp, err := client.PostForm(srv.URL+"/pause", url.Values{"paused": {"true"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if !state.Paused {
+			t.Error("the game is not paused")
+		}
+	})
+
+	t.Run("Returns the errors", func(t *testing.T) {
+		resp, err := client.Post(srv.URL+"/loadstate", "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var got map[string]string
+		json.NewDecoder(resp.Body).Decode(&got)
+		if got["error"] != "no savestate to load" {
+			t.Errorf("got %v", got)
+		}
+	})
+
+	t.Run("Rejects the wrong methods", func(t *testing.T) {
+		resp, err := client.Get(srv.URL + "/savestate")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusMethodNotAllowed || c.saved {
+			t.Errorf("got %v", resp.StatusCode)
+		}
+	})
+
+	t.Run("Requires the pairing code", func(t *testing.T) {
+		resp, err := http.Post(srv.URL+"/savestate", "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized || c.saved {
+			t.Errorf("got %v", resp.StatusCode)
+		}
+	})
+
+	t.Run("Rejects cross-origin requests", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/savestate", nil)
+		req.Header.Set("Origin", "http://example.com")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusForbidden || c.saved {
+			t.Errorf("got %v", resp.StatusCode)
+		}
+	})
+
+	t.Run("Serves the web UI without the pairing code", func(t *testing.T) {
+		resp, err := http.Get(srv.URL + "/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+			t.Errorf("got %v %v", resp.StatusCode, resp.Header.Get("Content-Type"))
+		}
+	})
+
+	t.Run("Redirects to missing thumbnails", func(t *testing.T) {
+		client := http.Client{Transport: paired("CODE2345"), CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}}
+		resp, err := client.Get(srv.URL + "/thumbnail?system=Nintendo+-+Game+Boy&name=Tetris")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		want := "http://thumbnails.libretro.com/Nintendo%20-%20Game%20Boy/Named_Snaps/Tetris.png"
+		if got := resp.Header.Get("Location"); got != want {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Takes the pairing code from the cookie, not the URL", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/status?code=CODE2345", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("got %v, want %v", resp.StatusCode, http.StatusUnauthorized)
+		}
+
+		req.URL.RawQuery = ""
+		req.AddCookie(&http.Cookie{Name: codeCookie, Value: "CODE2345"})
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("got %v, want %v", resp.StatusCode, http.StatusOK)
+		}
+	})
+
+	t.Run("Rejects paths in thumbnail requests", func(t *testing.T) {
+		resp, err := client.Get(srv.URL + "/thumbnail?system=..&name=Tetris")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("got %v, want %v", resp.StatusCode, http.StatusNotFound)
+		}
+	})
+
+	t.Run("Takes screenshots", func(t *testing.T) {
+		resp, err := client.Post(srv.URL+"/screenshot", "", strings.NewReader(""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var got map[string]string
+		json.NewDecoder(resp.Body).Decode(&got)
+		if got["path"] != "/screenshots/game.png" {
+			t.Errorf("got %v", got)
+		}
+	})
+
+	state.CoreRunning = false
+	state.GamePath = ""
+	state.Paused = false
+}
+
+func Test_listenAddress(t *testing.T) {
+	if got := listenAddress("127.0.0.1:8055", false); got != "127.0.0.1:8055" {
+		t.Errorf("got %v", got)
+	}
+	if got := listenAddress("127.0.0.1:8055", true); got != "0.0.0.0:8055" {
+		t.Errorf("got %v", got)
+	}
+}
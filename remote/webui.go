@@ -0,0 +1,167 @@
+package remote
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/utils"
+)
+
+// safeName tells if a file name can't escape the thumbnails directory
+func safeName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// thumbnail serves the snapshot of a game from the thumbnails directory of
+// the menu, or redirects to the libretro thumbnails server if it isn't
+// downloaded yet
+func thumbnail(w http.ResponseWriter, r *http.Request) {
+	system := r.FormValue("system")
+	name := utils.ScrubIllegalChars(r.FormValue("name"))
+	if !safeName(system) || !safeName(name) {
+		http.NotFound(w, r)
+		return
+	}
+
+	path := filepath.Join(settings.Current.ThumbnailsDirectory, system, "Named_Snaps", name+".png")
+	if _, err := os.Stat(path); err == nil {
+		http.ServeFile(w, r, path)
+		return
+	}
+	http.Redirect(w, r, "http://thumbnails.libretro.com/"+url.PathEscape(system)+
+		"/Named_Snaps/"+url.PathEscape(name)+".png", http.StatusFound)
+}
+
+// index serves the web UI, a page listing the games of the playlists that
+// launches them using the API
+func index(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(page))
+}
+
+const page = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Ludo</title>
+<style>
+body { margin: 0; font-family: sans-serif; background: #1a1a1a; color: #eee; }
+header { position: sticky; top: 0; padding: 10px; background: #333; display: flex; gap: 8px; flex-wrap: wrap; align-items: center; }
+header input { flex: 1; min-width: 150px; padding: 8px; font-size: 16px; border: 0; border-radius: 4px; }
+button { padding: 8px 12px; font-size: 14px; border: 0; border-radius: 4px; background: #555; color: #eee; }
+#status { width: 100%; font-size: 13px; color: #aaa; }
+h2 { margin: 16px 10px 6px; font-size: 16px; }
+.games { display: grid; grid-template-columns: repeat(auto-fill, minmax(140px, 1fr)); gap: 10px; padding: 0 10px; }
+.game { background: #2a2a2a; border-radius: 4px; overflow: hidden; cursor: pointer; }
+.game img { width: 100%; aspect-ratio: 4 / 3; object-fit: cover; background: #000; display: block; }
+.game div { padding: 6px; font-size: 13px; }
+</style>
+</head>
+<body>
+<header>
+<input id="search" type="search" placeholder="Search">
+<button onclick="post('/pause')">Pause</button>
+<button onclick="post('/savestate')">Save State</button>
+<button onclick="post('/loadstate')">Load State</button>
+<button onclick="post('/screenshot')">Screenshot</button>
+<a href="/gamepad"><button>Gamepad</button></a>
+<div id="status"></div>
+</header>
+<main id="library"></main>
+<script>
+var library = {};
+var code = localStorage.getItem('ludo-code') || '';
+
+// The images send the pairing code in a cookie
+function setCookie() {
+	document.cookie = 'ludo-code=' + encodeURIComponent(code) + '; path=/; SameSite=Strict';
+}
+
+// The pairing code is shown by Ludo when the remote control is enabled
+function askCode() {
+	code = (prompt('Pairing code') || '').trim().toUpperCase();
+	localStorage.setItem('ludo-code', code);
+	setCookie();
+}
+
+function api(path, options) {
+	options = options || {};
+	options.headers = {'X-Ludo-Code': code};
+	return fetch(path, options).then(function (r) {
+		if (r.status === 401) {
+			askCode();
+			throw new Error('wrong pairing code');
+		}
+		return r.json();
+	});
+}
+
+function post(path, params) {
+	return api(path, {method: 'POST', body: new URLSearchParams(params || {})})
+		.then(function (r) {
+			if (r.error) alert(r.error);
+			refresh();
+		});
+}
+
+function refresh() {
+	api('/status').then(function (s) {
+		document.getElementById('status').textContent = s.running
+			? 'Playing ' + s.game + ' on ' + s.core + (s.paused ? ' (paused)' : '')
+			: 'No game running';
+	});
+}
+
+function render() {
+	var query = document.getElementById('search').value.toLowerCase();
+	var main = document.getElementById('library');
+	main.innerHTML = '';
+	Object.keys(library).sort().forEach(function (system) {
+		var games = library[system].filter(function (g) {
+			return g.name.toLowerCase().indexOf(query) >= 0;
+		});
+		if (!games.length) return;
+		var h = document.createElement('h2');
+		h.textContent = system;
+		main.appendChild(h);
+		var grid = document.createElement('div');
+		grid.className = 'games';
+		games.forEach(function (g) {
+			var card = document.createElement('div');
+			card.className = 'game';
+			var img = document.createElement('img');
+			img.loading = 'lazy';
+			img.src = '/thumbnail?' + new URLSearchParams({system: system, name: g.name});
+			var label = document.createElement('div');
+			label.textContent = g.name;
+			card.appendChild(img);
+			card.appendChild(label);
+			card.onclick = function () { post('/launch', {path: g.path, system: system}); };
+			grid.appendChild(card);
+		});
+		main.appendChild(grid);
+	});
+}
+
+document.getElementById('search').oninput = render;
+if (!code) askCode();
+setCookie();
+api('/playlists').then(function (l) {
+	library = l;
+	render();
+});
+refresh();
+setInterval(refresh, 5000);
+</script>
+</body>
+</html>
+`
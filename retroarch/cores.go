@@ -0,0 +1,141 @@
+package retroarch
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/libretro/ludo/options"
+	"github.com/libretro/ludo/remap"
+	"github.com/libretro/ludo/utils"
+)
+
+// installedCores lists the names of the cores of the cores directory, like
+// snes9x_libretro
+func installedCores(dir string) []string {
+	paths, _ := filepath.Glob(filepath.Join(dir, "*_libretro"+utils.CoreExt()))
+	var cores []string
+	for _, p := range paths {
+		cores = append(cores, utils.FileName(p))
+	}
+	return cores
+}
+
+// simplify keeps the lower case letters and digits of a name
+func simplify(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, strings.ToLower(s))
+}
+
+// coreFor finds the core of a RetroArch config directory, named after the
+// library name of the core, like "Genesis Plus GX" for
+// genesis_plus_gx_libretro. Failing that, the core whose name prefixes the
+// keys of its options is used.
+func coreFor(library string, keys []string, cores []string) string {
+	for _, c := range cores {
+		if simplify(strings.TrimSuffix(c, "_libretro")) == simplify(library) {
+			return c
+		}
+	}
+	best, count := "", 0
+	for _, c := range cores {
+		prefix := strings.TrimSuffix(c, "libretro")
+		n := 0
+		for _, k := range keys {
+			if strings.HasPrefix(k, prefix) {
+				n++
+			}
+		}
+		if n > count {
+			best, count = c, n
+		}
+	}
+	return best
+}
+
+// importCoreOptions migrates the core options files, like
+// config/Snes9x/Snes9x.opt
+func importCoreOptions(configDir string, cores []string) Report {
+	var r Report
+	paths, _ := filepath.Glob(filepath.Join(configDir, "*", "*.opt"))
+	for _, p := range paths {
+		library := filepath.Base(filepath.Dir(p))
+		if utils.FileName(p) != library {
+			r.Skipped = append(r.Skipped, Item{p, "game options aren't supported"})
+			continue
+		}
+		values, err := readFile(p)
+		if err != nil {
+			r.Skipped = append(r.Skipped, Item{p, err.Error()})
+			continue
+		}
+		var keys []string
+		for k := range values {
+			keys = append(keys, k)
+		}
+		c := coreFor(library, keys, cores)
+		if c == "" {
+			r.Skipped = append(r.Skipped, Item{p, "no matching core installed"})
+			continue
+		}
+		if err := options.Import(c, values); err != nil {
+			r.Skipped = append(r.Skipped, Item{p, err.Error()})
+			continue
+		}
+		r.Migrated = append(r.Migrated, Item{p, c})
+	}
+	return r
+}
+
+// parseRemap reads the button mapping of the first player of a RetroArch
+// remap, like input_player1_btn_a = "8". Unmapped buttons are left alone.
+func parseRemap(values map[string]string) remap.Remap {
+	rm := remap.Identity()
+	for i, name := range remap.Buttons {
+		v, ok := values["input_player1_btn_"+strings.ToLower(name)]
+		if !ok {
+			continue
+		}
+		to, err := strconv.Atoi(v)
+		if err != nil || to < 0 || to >= len(remap.Buttons) {
+			continue
+		}
+		rm.Targets[i] = uint32(to)
+	}
+	return rm
+}
+
+// importRemaps migrates the core remaps, like remaps/Snes9x/Snes9x.rmp. Game
+// remaps are named after the content, Ludo identifies games by checksum so
+// they can't be migrated.
+func importRemaps(remapsDir string, cores []string) Report {
+	var r Report
+	paths, _ := filepath.Glob(filepath.Join(remapsDir, "*", "*.rmp"))
+	for _, p := range paths {
+		library := filepath.Base(filepath.Dir(p))
+		if utils.FileName(p) != library {
+			r.Skipped = append(r.Skipped, Item{p, "game remaps aren't supported"})
+			continue
+		}
+		values, err := readFile(p)
+		if err != nil {
+			r.Skipped = append(r.Skipped, Item{p, err.Error()})
+			continue
+		}
+		c := coreFor(library, nil, cores)
+		if c == "" {
+			r.Skipped = append(r.Skipped, Item{p, "no matching core installed"})
+			continue
+		}
+		if err := remap.Import(c, parseRemap(values)); err != nil {
+			r.Skipped = append(r.Skipped, Item{p, err.Error()})
+			continue
+		}
+		r.Migrated = append(r.Migrated, Item{p, c})
+	}
+	return r
+}
@@ -0,0 +1,259 @@
+// Package retroarch imports the configuration of RetroArch: the settings of
+// retroarch.cfg that have an equivalent in Ludo, the core options and the
+// core remaps. It reports what was migrated and what wasn't.
+package retroarch
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/adrg/xdg"
+	"github.com/libretro/ludo/settings"
+)
+
+// Report lists the migrated settings and the ones that couldn't be, each with
+// a short explanation
+type Report struct {
+	Migrated []Item
+	Skipped  []Item
+}
+
+// Item is a setting, a core options file or a remap file of RetroArch
+type Item struct {
+	Key  string
+	Note string
+}
+
+// mapping tells which Ludo setting a RetroArch setting maps to, and how to
+// convert its value
+type mapping struct {
+	key     string // TOML key of the Ludo setting
+	convert func(value, dir string) (string, error)
+}
+
+// mappings are the RetroArch settings with a Ludo equivalent
+var mappings = map[string]mapping{
+	"video_fullscreen":             {key: "video_fullscreen"},
+	"video_monitor_index":          {key: "video_monitor_index"},
+	"video_scale_integer":          {key: "video_integer_scale"},
+	"video_crop_overscan":          {key: "video_crop_overscan"},
+	"video_rotation":               {key: "video_rotation", convert: quarterTurns},
+	"video_swap_interval":          {key: "video_swap_interval"},
+	"video_black_frame_insertion":  {key: "video_black_frame_insertion"},
+	"video_frame_delay":            {key: "video_frame_delay"},
+	"video_hard_sync":              {key: "video_hard_sync"},
+	"video_hard_sync_frames":       {key: "video_hard_sync_frames"},
+	"audio_volume":                 {key: "audio_volume", convert: decibels},
+	"audio_device":                 {key: "audio_device"},
+	"audio_rate_control_delta":     {key: "audio_rate_control_delta"},
+	"pause_nonactive":              {key: "pause_nonactive"},
+	"discord_allow":                {key: "discord_allow"},
+	"cheevos_username":             {key: "cheevos_username"},
+	"cheevos_token":                {key: "cheevos_token"},
+	"cheevos_hardcore_mode_enable": {key: "cheevos_hardcore_mode_enable"},
+	"input_overlay_enable":         {key: "input_overlay_enable"},
+	"input_overlay_opacity":        {key: "input_overlay_opacity"},
+	"show_hidden_files":            {key: "menu_showhiddenfiles"},
+	"rgui_browser_directory":       {key: "files_dir", convert: directory},
+	"libretro_directory":           {key: "cores_dir", convert: directory},
+	"savestate_directory":          {key: "savestates_dir", convert: directory},
+	"savefile_directory":           {key: "savefiles_dir", convert: directory},
+	"screenshot_directory":         {key: "screenshots_dir", convert: directory},
+	"cheat_database_path":          {key: "cheats_dir", convert: directory},
+	"recording_output_directory":   {key: "recordings_dir", convert: directory},
+	"system_directory":             {key: "system_dir", convert: directory},
+	"playlist_directory":           {key: "playlists_dir", convert: directory},
+	"thumbnails_directory":         {key: "thumbnail_dir", convert: directory},
+	"input_menu_toggle":            {key: "input_menu_toggle_key", convert: hotkey},
+	"input_toggle_fast_forward":    {key: "input_fast_forward_key", convert: hotkey},
+	"input_save_state":             {key: "input_save_state_key", convert: hotkey},
+	"input_load_state":             {key: "input_load_state_key", convert: hotkey},
+	"input_screenshot":             {key: "input_screenshot_key", convert: hotkey},
+	"input_toggle_fullscreen":      {key: "input_fullscreen_key", convert: hotkey},
+	"input_exit_emulator":          {key: "input_quit_key", convert: hotkey},
+	"input_game_focus_toggle":      {key: "input_game_focus_key", convert: hotkey},
+	"input_recording_toggle":       {key: "input_record_key", convert: hotkey},
+	"input_audio_mute":             {key: "input_audio_mute_key", convert: hotkey},
+	"input_pause_toggle":           {key: "input_pause_toggle_key", convert: hotkey},
+	"input_frame_advance":          {key: "input_frame_advance_key", convert: hotkey},
+}
+
+// hotkeys are the names of the RetroArch keys in Ludo
+var hotkeys = map[string]string{
+	"space": "Space", "enter": "Enter", "escape": "Escape", "tab": "Tab",
+	"backspace": "Backspace", "insert": "Insert", "del": "Delete",
+	"home": "Home", "end": "End", "pageup": "Page Up", "pagedown": "Page Down",
+	"shift": "Left Shift", "rshift": "Right Shift",
+	"ctrl": "Left Control", "rctrl": "Right Control",
+	"alt": "Left Alt", "ralt": "Right Alt",
+	"scroll_lock": "Scroll Lock", "pause": "Pause", "nul": "None",
+}
+
+// hotkey converts the name of a RetroArch key
+func hotkey(value, dir string) (string, error) {
+	if k, ok := hotkeys[value]; ok {
+		return k, nil
+	}
+	switch {
+	case len(value) == 1 && value[0] >= 'a' && value[0] <= 'z':
+		return strings.ToUpper(value), nil
+	case len(value) == 4 && strings.HasPrefix(value, "num") && value[3] >= '0' && value[3] <= '9':
+		return value[3:], nil
+	case len(value) <= 3 && strings.HasPrefix(value, "f"):
+		if n, err := strconv.Atoi(value[1:]); err == nil && n >= 1 && n <= 12 {
+			return strings.ToUpper(value), nil
+		}
+	}
+	return "", fmt.Errorf("unsupported key %s", value)
+}
+
+// decibels converts a RetroArch volume, in dB, to a gain
+func decibels(value, dir string) (string, error) {
+	db, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprint(math.Min(1, math.Pow(10, db/20))), nil
+}
+
+// quarterTurns converts a RetroArch rotation, in quarter turns, to degrees
+func quarterTurns(value, dir string) (string, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprint(n % 4 * 90), nil
+}
+
+// directory resolves a RetroArch directory. A leading colon means the
+// directory of RetroArch, and "default" means no directory.
+func directory(value, dir string) (string, error) {
+	if value == "" || value == "default" {
+		return "", fmt.Errorf("no directory set")
+	}
+	if strings.HasPrefix(value, ":") {
+		value = filepath.Join(dir, value[1:])
+	}
+	if strings.HasPrefix(value, "~") {
+		home, _ := os.UserHomeDir()
+		value = filepath.Join(home, value[1:])
+	}
+	return filepath.Clean(value), nil
+}
+
+// parse reads the settings of a RetroArch config file, like
+// video_fullscreen = "true"
+func parse(r io.Reader) (map[string]string, error) {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.Index(line, "=")
+		if i < 0 {
+			continue
+		}
+		v := strings.TrimSpace(line[i+1:])
+		if len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"' {
+			v = v[1 : len(v)-1]
+		}
+		values[strings.TrimSpace(line[:i])] = v
+	}
+	return values, scanner.Err()
+}
+
+// readFile reads a RetroArch config file
+func readFile(path string) (map[string]string, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	return parse(fd)
+}
+
+// importSettings maps the RetroArch settings onto Ludo settings. dir is the
+// directory of RetroArch, the base of relative directories.
+func importSettings(s *settings.Settings, values map[string]string, dir string) Report {
+	var r Report
+	var keys []string
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		m, ok := mappings[k]
+		if !ok {
+			r.Skipped = append(r.Skipped, Item{k, "no equivalent in Ludo"})
+			continue
+		}
+		v := values[k]
+		var err error
+		if m.convert != nil {
+			v, err = m.convert(v, dir)
+		}
+		if err == nil {
+			err = settings.SetValue(s, m.key, v)
+		}
+		if err != nil {
+			r.Skipped = append(r.Skipped, Item{k, err.Error()})
+			continue
+		}
+		r.Migrated = append(r.Migrated, Item{k, v})
+	}
+	return r
+}
+
+// DefaultConfig is the location of retroarch.cfg on this platform
+func DefaultConfig() string {
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(os.Getenv("APPDATA"), "RetroArch", "retroarch.cfg")
+	case "darwin":
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, "Library", "Application Support", "RetroArch", "config", "retroarch.cfg")
+	}
+	return filepath.Join(xdg.ConfigHome, "retroarch", "retroarch.cfg")
+}
+
+// Import migrates a retroarch.cfg file, and the core options and core remaps
+// found in the RetroArch config directories
+func Import(path string) (Report, error) {
+	values, err := readFile(path)
+	if err != nil {
+		return Report{}, err
+	}
+	dir := filepath.Dir(path)
+
+	r := importSettings(&settings.Current, values, dir)
+	if err := settings.Save(); err != nil {
+		return r, err
+	}
+
+	configDir := filepath.Join(dir, "config")
+	if d, err := directory(values["rgui_config_directory"], dir); err == nil {
+		configDir = d
+	}
+	remapsDir := filepath.Join(configDir, "remaps")
+	if d, err := directory(values["input_remapping_directory"], dir); err == nil {
+		remapsDir = d
+	}
+
+	cores := installedCores(settings.Current.CoresDirectory)
+	o := importCoreOptions(configDir, cores)
+	m := importRemaps(remapsDir, cores)
+	r.Migrated = append(append(r.Migrated, o.Migrated...), m.Migrated...)
+	r.Skipped = append(append(r.Skipped, o.Skipped...), m.Skipped...)
+	return r, nil
+}
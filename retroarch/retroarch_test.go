@@ -0,0 +1,155 @@
+package retroarch
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/libretro/ludo/remap"
+	"github.com/libretro/ludo/settings"
+)
+
+func Test_parse(t *testing.T) {
+	got, err := parse(strings.NewReader(`# comment
+video_fullscreen = "true"
+audio_volume = "-6.000000"
+
+garbage
+input_player1_a = x
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"video_fullscreen": "true",
+		"audio_volume":     "-6.000000",
+		"input_player1_a":  "x",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parse() = %v, want %v", got, want)
+	}
+}
+
+func Test_hotkey(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"f1", "F1", false},
+		{"f12", "F12", false},
+		{"p", "P", false},
+		{"space", "Space", false},
+		{"rshift", "Right Shift", false},
+		{"num5", "5", false},
+		{"nul", "None", false},
+		{"f13", "", true},
+		{"kp_plus", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := hotkey(tt.value, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("hotkey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("hotkey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_decibels(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"0.000000", "1"},
+		{"-20.000000", "0.1"},
+		{"6.000000", "1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := decibels(tt.value, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("decibels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_directory(t *testing.T) {
+	dir := filepath.FromSlash("/opt/retroarch")
+	got, err := directory(":/states", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "states"); got != want {
+		t.Errorf("directory() = %v, want %v", got, want)
+	}
+	if _, err := directory("default", dir); err == nil {
+		t.Error("directory() of default should fail")
+	}
+}
+
+func Test_importSettings(t *testing.T) {
+	var s settings.Settings
+	r := importSettings(&s, map[string]string{
+		"video_fullscreen":    "true",
+		"video_rotation":      "5",
+		"input_menu_toggle":   "f1",
+		"input_save_state":    "kp_plus",
+		"menu_driver":         "ozone",
+		"savestate_directory": ":/states",
+	}, filepath.FromSlash("/ra"))
+
+	if !s.VideoFullscreen || s.VideoRotation != 90 || s.HotkeyMenuToggleKey != "F1" {
+		t.Errorf("importSettings() = %+v", s)
+	}
+	if want := filepath.FromSlash("/ra/states"); s.SavestatesDirectory != want {
+		t.Errorf("SavestatesDirectory = %v, want %v", s.SavestatesDirectory, want)
+	}
+	if len(r.Migrated) != 4 || len(r.Skipped) != 2 {
+		t.Errorf("importSettings() report = %+v", r)
+	}
+}
+
+func Test_coreFor(t *testing.T) {
+	cores := []string{"genesis_plus_gx_libretro", "snes9x_libretro", "mgba_libretro"}
+	tests := []struct {
+		library string
+		keys    []string
+		want    string
+	}{
+		{"Genesis Plus GX", nil, "genesis_plus_gx_libretro"},
+		{"Snes9x", nil, "snes9x_libretro"},
+		{"mGBA", nil, "mgba_libretro"},
+		{"Snes9x 2010", []string{"snes9x_overclock", "snes9x_region"}, "snes9x_libretro"},
+		{"Beetle PSX", []string{"beetle_psx_cd_access_method"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.library, func(t *testing.T) {
+			if got := coreFor(tt.library, tt.keys, cores); got != tt.want {
+				t.Errorf("coreFor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseRemap(t *testing.T) {
+	// A and B are swapped, the X target is out of range
+	got := parseRemap(map[string]string{
+		"input_player1_btn_a": "0",
+		"input_player1_btn_b": "8",
+		"input_player1_btn_x": "99",
+	})
+	want := remap.Identity()
+	want.Targets[0], want.Targets[8] = 8, 0
+	if got.Targets != want.Targets {
+		t.Errorf("parseRemap() = %v, want %v", got.Targets, want.Targets)
+	}
+}
@@ -10,19 +10,20 @@ import (
 	"sync"
 	"unsafe"
 
+	"github.com/libretro/ludo/contentid"
 	"github.com/libretro/ludo/libretro"
 	"github.com/libretro/ludo/settings"
 	"github.com/libretro/ludo/state"
-	"github.com/libretro/ludo/utils"
 )
 
 var mutex sync.Mutex
 
-// path returns the path of the SRAM file for the current core
+// path returns the path of the SRAM file of the running game, named after
+// the CRC of its content
 func path() string {
 	return filepath.Join(
 		settings.Current.SavefilesDirectory,
-		utils.FileName(state.GamePath)+".srm")
+		contentid.Key(state.GamePath, state.GameCRC)+".srm")
 }
 
 // SaveSRAM saves the game SRAM to the filesystem
@@ -3,17 +3,28 @@
 package savestates
 
 import (
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/libretro/ludo/contentid"
 	"github.com/libretro/ludo/settings"
 	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/utils"
 )
 
+// ErrHardcore is returned when using savestates in hardcore mode
+var ErrHardcore = errors.New("savestates are disabled in hardcore mode")
+
 // Save the current state to the filesystem. name is the name of the
 // savestate file to save to, without extension.
 func Save(name string) error {
+	if settings.Current.AchievementsHardcore {
+		return ErrHardcore
+	}
 	s := state.Core.SerializeSize()
 	bytes, err := state.Core.Serialize(s)
 	if err != nil {
@@ -29,6 +40,9 @@ func Save(name string) error {
 
 // Load the state from the filesystem
 func Load(path string) error {
+	if settings.Current.AchievementsHardcore {
+		return ErrHardcore
+	}
 	s := state.Core.SerializeSize()
 	bytes, err := ioutil.ReadFile(path)
 	if err != nil {
@@ -37,3 +51,37 @@ func Load(path string) error {
 	err = state.Core.Unserialize(bytes, s)
 	return err
 }
+
+// slotName is the name of a savestate slot of a game, without extension
+func slotName(game string, slot int) string {
+	return fmt.Sprintf("%s@slot-%02d", game, slot)
+}
+
+// nextSlot returns the slot following the one saved last, among the slots of
+// a game in dir. The slots are filled in order, then the first one is used
+// again once all of them were.
+func nextSlot(dir, game string, slots int) int {
+	var last int
+	var newest time.Time
+	for slot := 1; slot <= slots; slot++ {
+		fi, err := os.Stat(filepath.Join(dir, slotName(game, slot)+".state"))
+		if err != nil {
+			continue
+		}
+		if last == 0 || fi.ModTime().After(newest) {
+			last, newest = slot, fi.ModTime()
+		}
+	}
+	return last%slots + 1
+}
+
+// Name returns the name of the next savestate of a game, without extension.
+// It is a dated name, or the next slot in auto-increment mode, after the CRC
+// of the content of the game.
+func Name(gamePath string, crc uint32) string {
+	game := contentid.Key(gamePath, crc)
+	if !settings.Current.SavestateAutoIncrement || settings.Current.SavestateSlots < 1 {
+		return utils.Dated(game)
+	}
+	return slotName(game, nextSlot(settings.Current.SavestatesDirectory, game, settings.Current.SavestateSlots))
+}
@@ -0,0 +1,44 @@
+package savestates
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_nextSlot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "savestates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	save := func(slot int, age time.Duration) {
+		path := filepath.Join(dir, slotName("Tetris", slot)+".state")
+		ioutil.WriteFile(path, nil, 0644)
+		when := time.Now().Add(-age)
+		os.Chtimes(path, when, when)
+	}
+
+	if got := nextSlot(dir, "Tetris", 3); got != 1 {
+		t.Errorf("nextSlot() without slots = %d, want 1", got)
+	}
+	save(1, 2*time.Hour)
+	save(2, time.Hour)
+	if got := nextSlot(dir, "Tetris", 3); got != 3 {
+		t.Errorf("nextSlot() = %d, want the next free slot 3", got)
+	}
+	save(3, 0)
+	if got := nextSlot(dir, "Tetris", 3); got != 1 {
+		t.Errorf("nextSlot() = %d, want to wrap to 1", got)
+	}
+	save(1, -time.Hour)
+	if got := nextSlot(dir, "Tetris", 3); got != 2 {
+		t.Errorf("nextSlot() = %d, want 2 after the wrap", got)
+	}
+	if got := nextSlot(dir, "Tetris", 2); got != 2 {
+		t.Errorf("nextSlot() with fewer slots = %d, want 2", got)
+	}
+}
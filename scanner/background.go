@@ -0,0 +1,187 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/libretro/ludo/crash"
+	"github.com/libretro/ludo/logs"
+	"github.com/libretro/ludo/mainthread"
+	"github.com/libretro/ludo/settings"
+)
+
+// throttleDelay is the pause between two files while a game runs
+const throttleDelay = 50 * time.Millisecond
+
+// scanning is set while a scan runs
+var scanning int32
+
+// throttled is set while a game runs, see Throttle
+var throttled int32
+
+// job is a directory waiting to be scanned
+type job struct {
+	dir    string
+	doneCb func()
+}
+
+// queued are the directories waiting for the running scan to end
+var queued struct {
+	sync.Mutex
+	jobs []job
+}
+
+var progress struct {
+	sync.Mutex
+	done, total int
+}
+
+func setProgress(done, total int) {
+	progress.Lock()
+	defer progress.Unlock()
+	progress.done, progress.total = done, total
+}
+
+// Status returns the progress of the running scan, like "Scanning 12/300", or
+// an empty string if no scan runs
+func Status() string {
+	progress.Lock()
+	defer progress.Unlock()
+	if atomic.LoadInt32(&scanning) == 0 || progress.total == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Scanning %d/%d", progress.done, progress.total)
+}
+
+// Throttle slows the scans down while a game runs, leaving the disk to the
+// game. It is called by the main thread on every frame.
+func Throttle(on bool) {
+	var v int32
+	if on {
+		v = 1
+	}
+	atomic.StoreInt32(&throttled, v)
+}
+
+// throttle pauses a scan between two files while it is throttled
+func throttle() {
+	if atomic.LoadInt32(&throttled) == 1 {
+		time.Sleep(throttleDelay)
+	}
+}
+
+// Fingerprint sums up the content of a directory, the count of its files and
+// their last modification. It changes when files are added, removed or
+// modified. It returns an empty string if the directory can't be read.
+func Fingerprint(dir string) string {
+	count := 0
+	var last time.Time
+	err := filepath.Walk(dir, func(path string, f os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(f.Name(), ".") && path != dir {
+			if f.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !f.IsDir() {
+			count++
+		}
+		if f.ModTime().After(last) {
+			last = f.ModTime()
+		}
+		return nil
+	})
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d:%d", count, last.UnixNano())
+}
+
+// remember saves the fingerprint of a scanned directory. The settings are
+// changed on the main thread.
+func remember(dir string) {
+	fp := Fingerprint(dir)
+	mainthread.Post(func() {
+		if settings.Current.ScannedDirectories == nil {
+			settings.Current.ScannedDirectories = map[string]string{}
+		}
+		settings.Current.ScannedDirectories[filepath.Clean(dir)] = fp
+		if err := settings.Save(); err != nil {
+			logs.Warnf("Scanner", "Can't save the scanned directories: %v", err)
+		}
+	})
+}
+
+// ScanChanged scans again, in the background, the directories whose content
+// changed since their last scan, one after the other. The directories wait
+// for the running scan to end, if any. It has to be called by the main
+// thread.
+func ScanChanged(doneCb func()) {
+	known := map[string]string{}
+	for dir, fp := range settings.Current.ScannedDirectories {
+		known[dir] = fp
+	}
+	go func() {
+		defer crash.Recover()
+		var dirs []string
+		for dir, fp := range known {
+			if f := Fingerprint(dir); f != "" && f != fp {
+				dirs = append(dirs, dir)
+			}
+		}
+		sort.Strings(dirs)
+		queued.Lock()
+		for _, dir := range dirs {
+			queued.jobs = append(queued.jobs, job{dir, doneCb})
+		}
+		queued.Unlock()
+		drain()
+	}()
+}
+
+// pop takes the next directory of the queue
+func pop() (job, bool) {
+	queued.Lock()
+	defer queued.Unlock()
+	if len(queued.jobs) == 0 {
+		return job{}, false
+	}
+	j := queued.jobs[0]
+	queued.jobs = queued.jobs[1:]
+	return j, true
+}
+
+// pending tells if directories are waiting to be scanned
+func pending() bool {
+	queued.Lock()
+	defer queued.Unlock()
+	return len(queued.jobs) > 0
+}
+
+// drain scans the queued directories, unless a scan runs. The running scan
+// drains the queue once it ends. It blocks until the queue is empty.
+func drain() {
+	for {
+		if !atomic.CompareAndSwapInt32(&scanning, 0, 1) {
+			return
+		}
+		j, ok := pop()
+		if ok {
+			scanDir(j.dir, j.doneCb)
+		}
+		atomic.StoreInt32(&scanning, 0)
+		// A directory queued while the queue was found empty
+		if !ok && !pending() {
+			return
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/libretro/ludo/crash"
+	"github.com/libretro/ludo/dat"
+	"github.com/libretro/ludo/mainthread"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/playlists"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/utils"
+)
+
+// Change is a playlist entry in the preview of a scan. Old is the entry of
+// the playlist, for the changed and removed entries.
+type Change struct {
+	Playlist string
+	Game     playlists.Game
+	Old      playlists.Game
+}
+
+// Preview is the report of a scan preview. Added are the entries the scan
+// would write. Changed are the entries of scanned files that now match
+// another game, and Removed the entries of missing files of the scanned
+// folder, the scan leaves both in the playlists.
+type Preview struct {
+	Added   []Change
+	Changed []Change
+	Removed []Change
+}
+
+// inDir tells if a path is in a directory or one of its subdirectories
+func inDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// preview compares the games found in dir with the playlists, with the same
+// rules as the scan
+func preview(dir string, found []dat.Game, lists map[string]playlists.Playlist, exists func(string) bool) Preview {
+	var p Preview
+	scanned := map[string]bool{}
+	added := map[string]bool{}
+	for _, game := range found {
+		if len(game.Description) == 0 {
+			continue
+		}
+		CSVPath := filepath.Join(settings.Current.PlaylistsDirectory, game.System+".csv")
+		crc := uint32(game.ROMs[0].CRC)
+		entry := playlists.Game{Path: filepath.Clean(game.Path), Name: game.Description, CRC32: crc}
+		scanned[entry.Path] = true
+
+		known := false
+		for _, old := range lists[filepath.Clean(CSVPath)] {
+			if filepath.Clean(old.Path) == entry.Path {
+				if old.Name != entry.Name || crc != 0 && old.CRC32 != crc {
+					p.Changed = append(p.Changed, Change{game.System, entry, old})
+				}
+				known = true
+				break
+			}
+			if crc != 0 && old.CRC32 == crc {
+				known = true
+				break
+			}
+		}
+		if !known && !added[CSVPath+"|"+entry.Path] {
+			p.Added = append(p.Added, Change{Playlist: game.System, Game: entry})
+			added[CSVPath+"|"+entry.Path] = true
+		}
+	}
+
+	var csvs []string
+	for csv := range lists {
+		csvs = append(csvs, csv)
+	}
+	sort.Strings(csvs)
+	for _, csv := range csvs {
+		for _, old := range lists[csv] {
+			path := filepath.Clean(old.Path)
+			if inDir(path, dir) && !scanned[path] && !exists(path) {
+				p.Removed = append(p.Removed, Change{Playlist: utils.FileName(csv), Old: old})
+			}
+		}
+	}
+	return p
+}
+
+// previewDir scans a full directory like scanDir but writes nothing. It blocks
+// until the scan is done.
+func previewDir(dir string) (Preview, error) {
+	n := ntf.DisplayAndLog(ntf.Info, "Menu", "Previewing the scan of %s", dir)
+	roms, err := utils.AllFilesIn(dir)
+	if err != nil {
+		n.Update(ntf.Error, err.Error())
+		return Preview{}, err
+	}
+	games := make(chan (dat.Game))
+	go Scan(dir, roms, games, n)
+	var found []dat.Game
+	for game := range games {
+		found = append(found, game)
+	}
+	p := preview(filepath.Clean(dir), found, playlists.Playlists, func(path string) bool {
+		_, err := os.Stat(path)
+		return !os.IsNotExist(err)
+	})
+	n.Update(ntf.Success, "Scan preview done. %d games to add, %d changed, %d removed.",
+		len(p.Added), len(p.Changed), len(p.Removed))
+	return p, nil
+}
+
+// PreviewDir previews the scan of a directory in the background, the
+// playlists are left untouched. The preview is passed to cb on the main
+// thread. A single scan runs at a time.
+func PreviewDir(dir string, cb func(Preview)) {
+	if !atomic.CompareAndSwapInt32(&scanning, 0, 1) {
+		ntf.DisplayAndLog(ntf.Error, "Menu", "A scan is already in progress")
+		return
+	}
+	go func() {
+		defer crash.Recover()
+		p, err := previewDir(dir)
+		atomic.StoreInt32(&scanning, 0)
+		drain()
+		if err != nil {
+			return
+		}
+		mainthread.Post(func() { cb(p) })
+	}()
+}
@@ -5,13 +5,20 @@ package scanner
 
 import (
 	"archive/zip"
+	"compress/gzip"
 	"hash/crc32"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"github.com/libretro/ludo/crash"
 	"github.com/libretro/ludo/dat"
+	"github.com/libretro/ludo/logs"
+	"github.com/libretro/ludo/mainthread"
 	ntf "github.com/libretro/ludo/notifications"
 	"github.com/libretro/ludo/playlists"
 	"github.com/libretro/ludo/settings"
@@ -19,7 +26,85 @@ import (
 	"github.com/libretro/ludo/utils"
 )
 
-// LoadDB loops over the RDBs in a given directory and parses them
+// datDate matches the date that No-Intro appends to the names of its dats,
+// like "Nintendo - Game Boy (20240101-093000)"
+var datDate = regexp.MustCompile(`\s*\([0-9]{8}-[0-9]{6}\)$`)
+
+// systemName returns the system of a dat from its file name
+func systemName(name string) string {
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	return datDate.ReplaceAllString(name, "")
+}
+
+// addDat parses a dat and adds it to the DB, under the system declared by
+// the software lists or the system of its file name. A software list and a
+// dat of the same system are merged.
+func addDat(db dat.DB, name string, b []byte) {
+	d := dat.Parse(b)
+	system := d.System
+	if system == "" {
+		system = systemName(name)
+	}
+	prev := db[system]
+	d.Games = append(prev.Games, d.Games...)
+	d.Warnings = append(prev.Warnings, d.Warnings...)
+	db[system] = d
+}
+
+// isDat tells if a file is a dat or a software list
+func isDat(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".dat" || ext == ".xml"
+}
+
+// loadZippedDats parses the dats of a zip archive, like the packs of dats
+// distributed by No-Intro
+func loadZippedDats(path string, db dat.DB) error {
+	z, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer z.Close()
+	for _, f := range z.File {
+		if f.FileInfo().IsDir() || !isDat(f.Name) {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			return err
+		}
+		b, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+		addDat(db, filepath.Base(f.Name), b)
+	}
+	return nil
+}
+
+// loadGzippedDat parses a dat compressed with gzip, like system.dat.gz
+func loadGzippedDat(path string, db dat.DB) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	addDat(db, strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), b)
+	return nil
+}
+
+// LoadDB loops over the dats and the MAME software lists in a given directory
+// and parses them. The dats can be compressed in zip archives or with gzip.
 func LoadDB(dir string) (dat.DB, error) {
 	files, err := ioutil.ReadDir(dir)
 	if err != nil {
@@ -28,18 +113,78 @@ func LoadDB(dir string) (dat.DB, error) {
 	db := make(dat.DB)
 	for _, f := range files {
 		name := f.Name()
-		if !strings.Contains(name, ".dat") {
-			continue
+		path := filepath.Join(dir, name)
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".zip":
+			if err := loadZippedDats(path, db); err != nil {
+				logs.Warnf("Scanner", "Can't load %s: %v", name, err)
+			}
+		case ".gz":
+			if err := loadGzippedDat(path, db); err != nil {
+				logs.Warnf("Scanner", "Can't load %s: %v", name, err)
+			}
+		case ".dat", ".xml":
+			bytes, _ := ioutil.ReadFile(path)
+			addDat(db, name, bytes)
 		}
-		system := name[0 : len(name)-4]
-		bytes, _ := ioutil.ReadFile(filepath.Join(dir, name))
-		db[system] = dat.Parse(bytes)
 	}
 	return db, nil
 }
 
-// ScanDir scans a full directory, report progress and generate playlists
+// LoadLayeredDB loads the bundled databases and puts the dats of the user
+// directory on top of them, the user games win on checksum conflicts. The
+// user directory is optional.
+func LoadLayeredDB(dir, userDir string) (dat.DB, error) {
+	db, err := LoadDB(dir)
+	if err != nil {
+		return db, err
+	}
+	if userDir == "" {
+		return db, nil
+	}
+	user, err := LoadDB(userDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logs.Warnf("Scanner", "Can't load the user dats: %v", err)
+		}
+		return db, nil
+	}
+	db.Layer(user)
+	return db, nil
+}
+
+// SystemHint returns the system declared for the folder of a file in the scan
+// hints, or an empty string. The longest matching folder wins.
+func SystemHint(path string, hints map[string]string) string {
+	dir := "/" + strings.ToLower(filepath.ToSlash(filepath.Dir(path))) + "/"
+	system, best := "", 0
+	for folder, s := range hints {
+		folder = strings.Trim(strings.ToLower(filepath.ToSlash(folder)), "/")
+		if folder != "" && len(folder) > best && strings.Contains(dir, "/"+folder+"/") {
+			system, best = s, len(folder)
+		}
+	}
+	return system
+}
+
+// ScanDir scans a full directory in the background, report progress and
+// generate playlists. A single scan runs at a time.
 func ScanDir(dir string, doneCb func()) {
+	if !atomic.CompareAndSwapInt32(&scanning, 0, 1) {
+		ntf.DisplayAndLog(ntf.Error, "Menu", "A scan is already in progress")
+		return
+	}
+	go func() {
+		defer crash.Recover()
+		scanDir(dir, doneCb)
+		atomic.StoreInt32(&scanning, 0)
+		drain()
+	}()
+}
+
+// scanDir scans a directory and generate playlists, it blocks until the scan
+// is done. doneCb is called on the main thread.
+func scanDir(dir string, doneCb func()) {
 	n := ntf.DisplayAndLog(ntf.Info, "Menu", "Scanning %s", dir)
 	roms, err := utils.AllFilesIn(dir)
 	if err != nil {
@@ -48,30 +193,39 @@ func ScanDir(dir string, doneCb func()) {
 	}
 	games := make(chan (dat.Game))
 	go Scan(dir, roms, games, n)
-	go func() {
-		i := 0
-		for game := range games {
-			os.MkdirAll(settings.Current.PlaylistsDirectory, os.ModePerm)
-			CSVPath := filepath.Join(settings.Current.PlaylistsDirectory, game.System+".csv")
-			if playlists.Contains(CSVPath, game.Path, uint32(game.ROMs[0].CRC)) {
-				continue
-			}
-			f, _ := os.OpenFile(CSVPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-			if len(game.Description) == 0 {
-				continue
-			}
-			f.WriteString(game.Path + "\t")
-			f.WriteString(game.Description + "\t")
-			if game.ROMs[0].CRC > 0 {
-				f.WriteString(strconv.FormatUint(uint64(game.ROMs[0].CRC), 16))
-			}
-			f.WriteString("\n")
-			f.Close()
-			i++
+	i := 0
+	misfiled := 0
+	for game := range games {
+		if hint := SystemHint(game.Path, settings.Current.ScanHints); hint != "" && hint != game.System {
+			logs.Warnf("Scanner", "%s is in a folder of %s but matches %s", game.Path, hint, game.System)
+			misfiled++
 		}
-		doneCb()
-		n.Update(ntf.Success, "Done scanning. %d new games found.", i)
-	}()
+		os.MkdirAll(settings.Current.PlaylistsDirectory, os.ModePerm)
+		CSVPath := filepath.Join(settings.Current.PlaylistsDirectory, game.System+".csv")
+		if playlists.Contains(CSVPath, game.Path, uint32(game.ROMs[0].CRC)) {
+			continue
+		}
+		f, _ := os.OpenFile(CSVPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if len(game.Description) == 0 {
+			continue
+		}
+		f.WriteString(game.Path + "\t")
+		f.WriteString(game.Description + "\t")
+		if game.ROMs[0].CRC > 0 {
+			f.WriteString(strconv.FormatUint(uint64(game.ROMs[0].CRC), 16))
+		}
+		f.WriteString("\n")
+		f.Close()
+		i++
+	}
+	mainthread.Post(doneCb)
+	remember(dir)
+	known := state.DB.Stats().Games
+	if misfiled > 0 {
+		n.Update(ntf.Warning, "Done scanning. %d new games found among %d known games, %d misfiled, see the logs.", i, known, misfiled)
+		return
+	}
+	n.Update(ntf.Success, "Done scanning. %d new games found among %d known games.", i, known)
 }
 
 // Returns the checksum and headerless checksum of a ROM
@@ -99,9 +253,27 @@ var headerSizes = map[string]uint{
 	".lnx": 64,
 }
 
+// checksumFile computes the checksum of a file without loading it in memory,
+// for the disc images
+func checksumFile(path string) (uint32, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	h := crc32.NewIEEE()
+	if _, err := io.Copy(h, f); err != nil {
+		return 0, err
+	}
+	return h.Sum32(), nil
+}
+
 // Scan scans a list of roms against the database
 func Scan(dir string, roms []string, games chan (dat.Game), n *ntf.Notification) {
+	defer crash.Recover()
 	for i, f := range roms {
+		setProgress(i+1, len(roms))
+		throttle()
 		ext := filepath.Ext(f)
 		switch ext {
 		case ".zip":
@@ -111,6 +283,8 @@ func Scan(dir string, roms []string, games chan (dat.Game), n *ntf.Notification)
 				n.Update(ntf.Error, err.Error())
 				continue
 			}
+			// GoodSet names are only matched in the system of the folder
+			hint := SystemHint(f, settings.Current.ScanHints)
 			for _, rom := range z.File {
 				romExt := filepath.Ext(rom.Name)
 				// these 4 systems might have headered or headerless roms and need special logic
@@ -120,12 +294,18 @@ func Scan(dir string, roms []string, games chan (dat.Game), n *ntf.Notification)
 						n.Update(ntf.Error, err.Error())
 						continue
 					}
-					state.DB.FindByCRC(f, rom.Name, crc, games)
-					state.DB.FindByCRC(f, rom.Name, crcHeaderless, games)
+					found := state.DB.FindByCRC(f, rom.Name, crc, games)
+					found = state.DB.FindByCRC(f, rom.Name, crcHeaderless, games) || found
+					if !found {
+						state.DB.FindByGoodName(hint, f, utils.FileName(rom.Name), crc, games)
+					}
 					n.Update(ntf.Info, strconv.Itoa(i)+"/"+strconv.Itoa(len(roms))+" "+f)
 				} else if rom.CRC32 > 0 {
 					// Look for a matching game entry in the database
-					state.DB.FindByCRC(f, rom.Name, rom.CRC32, games)
+					if !state.DB.FindByCRC(f, rom.Name, rom.CRC32, games) {
+						// Hacks and translations of GoodSets are matched by name
+						state.DB.FindByGoodName(hint, f, utils.FileName(rom.Name), rom.CRC32, games)
+					}
 					n.Update(ntf.Info, strconv.Itoa(i)+"/"+strconv.Itoa(len(roms))+" "+f)
 				}
 			}
@@ -134,20 +314,37 @@ func Scan(dir string, roms []string, games chan (dat.Game), n *ntf.Notification)
 			// Look for a matching game entry in the database
 			state.DB.FindByROMName(f, filepath.Base(f), 0, games)
 			n.Update(ntf.Info, strconv.Itoa(i)+"/"+strconv.Itoa(len(roms))+" "+f)
-		case ".32x", ".a26", "a52", ".a78", ".col", ".crt", ".d64", ".pce", ".fds", ".gb", ".gba", ".gbc", ".gen", ".gg", ".ipf", ".j64", ".jag", ".lnx", ".md", ".n64", ".nes", ".ngc", ".nds", ".rom", ".sfc", ".sg", ".smc", ".smd", ".sms", ".ws", ".wsc":
+		case ".bin", ".iso":
+			// These extensions are used by many systems, the dat of the system
+			// of the folder is tried first
+			crc, err := checksumFile(f)
+			if err != nil {
+				n.Update(ntf.Error, err.Error())
+				continue
+			}
+			hint := SystemHint(f, settings.Current.ScanHints)
+			if hint == "" || !state.DB.FindByCRCInSystem(hint, f, utils.FileName(f), crc, games) {
+				state.DB.FindByCRC(f, utils.FileName(f), crc, games)
+			}
+			n.Update(ntf.Info, strconv.Itoa(i)+"/"+strconv.Itoa(len(roms))+" "+f)
+		case ".32x", ".a26", "a52", ".a78", ".adf", ".atr", ".col", ".crt", ".d64", ".dsk", ".prg", ".st", ".t64", ".tap", ".tzx", ".xex", ".pce", ".fds", ".gb", ".gba", ".gbc", ".gen", ".gg", ".ipf", ".j64", ".jag", ".lnx", ".md", ".n64", ".nes", ".ngc", ".nds", ".rom", ".sfc", ".sg", ".smc", ".smd", ".sms", ".ws", ".wsc":
 			bytes, err := ioutil.ReadFile(f)
 			if err != nil {
 				n.Update(ntf.Error, err.Error())
 				continue
 			}
 			crc := crc32.ChecksumIEEE(bytes)
-			state.DB.FindByCRC(f, utils.FileName(f), crc, games)
+			found := state.DB.FindByCRC(f, utils.FileName(f), crc, games)
 			if headerSize, ok := headerSizes[ext]; ok {
 				crcHeaderless := crc32.ChecksumIEEE(bytes[headerSize:])
-				state.DB.FindByCRC(f, utils.FileName(f), crcHeaderless, games)
+				found = state.DB.FindByCRC(f, utils.FileName(f), crcHeaderless, games) || found
+			}
+			if !found {
+				state.DB.FindByGoodName(SystemHint(f, settings.Current.ScanHints), f, utils.FileName(f), crc, games)
 			}
 			n.Update(ntf.Info, strconv.Itoa(i)+"/"+strconv.Itoa(len(roms))+" "+f)
 		}
 	}
 	close(games)
+	setProgress(0, 0)
 }
@@ -0,0 +1,152 @@
+package scanner
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/libretro/ludo/dat"
+	"github.com/libretro/ludo/playlists"
+	"github.com/libretro/ludo/settings"
+)
+
+func TestSystemHint(t *testing.T) {
+	hints := map[string]string{
+		"megadrive":      "Sega - Mega Drive - Genesis",
+		"roms/psx/":      "Sony - PlayStation",
+		"roms/psx/japan": "Sony - PlayStation (Japan)",
+	}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/home/user/roms/megadrive/Sonic.bin", "Sega - Mega Drive - Genesis"},
+		{"/home/user/roms/MegaDrive/hacks/Sonic.bin", "Sega - Mega Drive - Genesis"},
+		{"/home/user/roms/psx/Crash.bin", "Sony - PlayStation"},
+		{"/home/user/roms/psx/japan/Crash.bin", "Sony - PlayStation (Japan)"},
+		{"/home/user/roms/megadrive2/Sonic.bin", ""},
+		{"/home/user/megadrive.bin", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := SystemHint(tt.path, hints); got != tt.want {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadDB(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scanner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dat := func(name string) []byte {
+		return []byte(`<datafile><game name="` + name + `"><rom name="a" crc="1"/></game></datafile>`)
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "Nintendo - Game Boy.dat"), dat("Tetris"), 0644)
+
+	f, _ := os.Create(filepath.Join(dir, "Pack (2024-01-01).zip"))
+	z := zip.NewWriter(f)
+	w, _ := z.Create("Sega - Game Gear (20240101-093000).dat")
+	w.Write(dat("Columns"))
+	w, _ = z.Create("readme.txt")
+	w.Write([]byte("not a dat"))
+	z.Close()
+	f.Close()
+
+	f, _ = os.Create(filepath.Join(dir, "Atari - Lynx.dat.gz"))
+	g := gzip.NewWriter(f)
+	g.Write(dat("Chip's Challenge"))
+	g.Close()
+	f.Close()
+
+	db, err := LoadDB(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"Nintendo - Game Boy": "Tetris",
+		"Sega - Game Gear":    "Columns",
+		"Atari - Lynx":        "Chip's Challenge",
+	}
+	if len(db) != len(want) {
+		t.Errorf("got %d systems, want %d", len(db), len(want))
+	}
+	for system, name := range want {
+		if games := db[system].Games; len(games) != 1 || games[0].Name != name {
+			t.Errorf("%s: got = %v, want %v", system, games, name)
+		}
+	}
+}
+
+func TestPreview(t *testing.T) {
+	settings.Current.PlaylistsDirectory = "/playlists"
+	game := func(path, name string, crc dat.CRC) dat.Game {
+		return dat.Game{Path: path, Description: name, System: "Nintendo - Game Boy", ROMs: []dat.ROM{{CRC: crc}}}
+	}
+	lists := map[string]playlists.Playlist{
+		"/playlists/Nintendo - Game Boy.csv": {
+			{Path: "/roms/gb/tetris.zip", Name: "Tetris (World)", CRC32: 0x46df91ad},
+			{Path: "/roms/gb/hack.zip", Name: "Unknown", CRC32: 0x2},
+			{Path: "/roms/gb/gone.zip", Name: "Alleyway (World)", CRC32: 0x3},
+			{Path: "/other/gone.zip", Name: "Kirby (World)", CRC32: 0x4},
+		},
+	}
+	found := []dat.Game{
+		game("/roms/gb/tetris.zip", "Tetris (World)", 0x46df91ad),
+		game("/roms/gb/hack.zip", "Tetris DX (Hack)", 0x2),
+		game("/roms/gb/new.zip", "Wario Land (World)", 0x5),
+		game("/roms/gb/new.zip", "Wario Land (World)", 0x5),
+	}
+
+	got := preview("/roms", found, lists, func(string) bool { return false })
+	want := Preview{
+		Added: []Change{{Playlist: "Nintendo - Game Boy",
+			Game: playlists.Game{Path: "/roms/gb/new.zip", Name: "Wario Land (World)", CRC32: 0x5}}},
+		Changed: []Change{{Playlist: "Nintendo - Game Boy",
+			Game: playlists.Game{Path: "/roms/gb/hack.zip", Name: "Tetris DX (Hack)", CRC32: 0x2},
+			Old:  playlists.Game{Path: "/roms/gb/hack.zip", Name: "Unknown", CRC32: 0x2}}},
+		Removed: []Change{{Playlist: "Nintendo - Game Boy",
+			Old: playlists.Game{Path: "/roms/gb/gone.zip", Name: "Alleyway (World)", CRC32: 0x3}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got = %v, want %v", got, want)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scanner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(filepath.Join(dir, "tetris.gb"), []byte("tetris"), 0644)
+	before := Fingerprint(dir)
+	if before == "" {
+		t.Fatal("no fingerprint")
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, ".hidden"), []byte("hidden"), 0644)
+	os.Chtimes(dir, time.Unix(0, 0), time.Unix(0, 0))
+	os.Chtimes(filepath.Join(dir, "tetris.gb"), time.Unix(1, 0), time.Unix(1, 0))
+	before = Fingerprint(dir)
+
+	ioutil.WriteFile(filepath.Join(dir, "alleyway.gb"), []byte("alleyway"), 0644)
+	if after := Fingerprint(dir); after == before {
+		t.Errorf("the fingerprint didn't change after adding a file")
+	}
+
+	if got := Fingerprint(filepath.Join(dir, "missing")); got != "" {
+		t.Errorf("got = %v, want an empty fingerprint", got)
+	}
+}
@@ -9,13 +9,93 @@ import (
 func defaultSettings() Settings {
 	usr, _ := user.Current()
 	return Settings{
+		SchemaVersion:     SchemaVersion,
+		Language:          "en",
 		VideoFullscreen:   false,
 		VideoMonitorIndex: 0,
 		VideoFilter:       "Pixel Perfect",
+		MenuTheme:         "Light",
+		MenuScale:         1,
+		MenuFontSize:      1,
+		KeyboardLayout:    "QWERTY",
+		PlaylistSort:      "Name",
+		CoreUpdatesCheck:  "Off",
+		SavestateSlots:    10,
 		MapAxisToDPad:     false,
 		AudioVolume:       0.5,
+		MenuSounds:        true,
 		MenuAudioVolume:   0.25,
+		MenuMusicVolume:   0.5,
 		ShowHiddenFiles:   false,
+		RumbleStrength:    1,
+		OverlayLayout:     "Gamepad",
+		OverlayOpacity:    0.3,
+
+		RemoteAddress: "127.0.0.1:8055",
+
+		SingleInstance: true,
+
+		VideoViewportWidth:  640,
+		VideoViewportHeight: 480,
+
+		VideoAspectRatio:       "Core Provided",
+		VideoCustomAspectRatio: 1.33,
+
+		VideoFullscreenMode: "Exclusive",
+
+		AudioDevice:     "Default",
+		AudioBufferSize: 8192,
+
+		AudioLatencyMode: "Shared",
+
+		AudioResampler:   "Sinc",
+		AudioRateControl: 0.005,
+
+		AudioSyncRefreshRate: true,
+
+		NotificationsPosition: "Top Left",
+		NotificationsDuration: 4,
+		LogLevel:              "Info",
+		NotificationsFontSize: 0.5,
+
+		VideoSwapInterval: 1,
+
+		BezelPackURL: "https://github.com/libretro/overlay-borders/archive/refs/heads/master.zip",
+
+		RecordQuality: "Medium",
+		RecordFormat:  "MP4",
+
+		HotkeyEnable:            "None",
+		HotkeyMenuToggleKey:     "P",
+		HotkeyMenuToggleButton:  "None",
+		HotkeyFastForwardKey:    "Space",
+		HotkeyFastForwardButton: "None",
+		HotkeySaveStateKey:      "F2",
+		HotkeySaveStateButton:   "None",
+		HotkeyLoadStateKey:      "F4",
+		HotkeyLoadStateButton:   "None",
+		HotkeyScreenshotKey:     "F8",
+		HotkeyScreenshotButton:  "None",
+		HotkeyFullscreenKey:     "F",
+		HotkeyFullscreenButton:  "None",
+		HotkeyQuitKey:           "Escape",
+		HotkeyQuitButton:        "None",
+		HotkeyGameFocusKey:      "Scroll Lock",
+		HotkeyMacroRecordKey:    "F9",
+		HotkeyMacroRecordButton: "None",
+		HotkeyMacroPlayKey:      "F10",
+		HotkeyMacroPlayButton:   "None",
+		HotkeyRecordKey:         "F11",
+		HotkeyRecordButton:      "None",
+		HotkeyMuteKey:           "F12",
+		HotkeyMuteButton:        "None",
+
+		HotkeyPauseKey:           "Pause",
+		HotkeyPauseButton:        "None",
+		HotkeyFrameAdvanceKey:    "K",
+		HotkeyFrameAdvanceButton: "None",
+		HotkeyPerfOverlayKey:     "F3",
+		HotkeyPerfOverlayButton:  "None",
 		CoreForPlaylist: map[string]string{
 			"Atari - 2600":                                   "stella2014_libretro",
 			"Atari - 5200":                                   "atari800_libretro",
@@ -59,15 +139,39 @@ func defaultSettings() Settings {
 			"SNK - Neo Geo Pocket":                           "mednafen_ngp_libretro",
 			"Sony - PlayStation":                             playstationCore,
 		},
+		ScanHints: map[string]string{
+			"atari2600":    "Atari - 2600",
+			"atari7800":    "Atari - 7800",
+			"genesis":      "Sega - Mega Drive - Genesis",
+			"mastersystem": "Sega - Master System - Mark III",
+			"megadrive":    "Sega - Mega Drive - Genesis",
+			"pcenginecd":   "NEC - PC Engine CD - TurboGrafx-CD",
+			"psx":          "Sony - PlayStation",
+			"saturn":       "Sega - Saturn",
+			"sega32x":      "Sega - 32X",
+			"segacd":       "Sega - Mega-CD - Sega CD",
+		},
 		FileDirectory:        usr.HomeDir,
 		CoresDirectory:       "./cores",
 		AssetsDirectory:      "./assets",
 		DatabaseDirectory:    "./database",
+		UserDatsDirectory:    filepath.Join(xdg.DataHome, "ludo", "dats"),
+		LocalesDirectory:     "./locales",
 		SavestatesDirectory:  filepath.Join(xdg.DataHome, "ludo", "savestates"),
 		SavefilesDirectory:   filepath.Join(xdg.DataHome, "ludo", "savefiles"),
 		ScreenshotsDirectory: filepath.Join(xdg.DataHome, "ludo", "screenshots"),
+		BezelsDirectory:      filepath.Join(xdg.DataHome, "ludo", "bezels"),
+		CheatsDirectory:      filepath.Join(xdg.DataHome, "ludo", "cheats"),
+		RecordingsDirectory:  filepath.Join(xdg.DataHome, "ludo", "recordings"),
+		MoviesDirectory:      filepath.Join(xdg.DataHome, "ludo", "movies"),
 		SystemDirectory:      filepath.Join(xdg.DataHome, "ludo", "system"),
 		PlaylistsDirectory:   filepath.Join(xdg.DataHome, "ludo", "playlists"),
 		ThumbnailsDirectory:  filepath.Join(xdg.DataHome, "ludo", "thumbnails"),
+		ArtworkDirectory:     filepath.Join(xdg.DataHome, "ludo", "artwork"),
+		ManualsDirectory:     filepath.Join(xdg.DataHome, "ludo", "manuals"),
+		CollectionDirectory:  filepath.Join(xdg.DataHome, "ludo", "collection"),
+		PresetsDirectory:     filepath.Join(xdg.ConfigHome, "ludo", "presets"),
+		ThemesDirectory:      filepath.Join(xdg.DataHome, "ludo", "themes"),
+		MusicDirectory:       filepath.Join(xdg.DataHome, "ludo", "music"),
 	}
 }
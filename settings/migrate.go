@@ -0,0 +1,65 @@
+package settings
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/libretro/ludo/logs"
+	"github.com/pelletier/go-toml"
+)
+
+// SchemaVersion is the version of the format of settings.toml. It is bumped,
+// along with a new migration, each time a setting is renamed, removed or
+// changes meaning.
+const SchemaVersion = 1
+
+// migrations upgrade settings.toml, the migration at index i going from
+// version i to version i+1
+var migrations = []func(t *toml.Tree){
+	// The dark mode switch became the menu themes
+	func(t *toml.Tree) {
+		if dark, ok := t.Get("video_dark_mode").(bool); ok && dark && !t.Has("menu_theme") {
+			t.Set("menu_theme", "Dark")
+		}
+		t.Delete("video_dark_mode")
+	},
+}
+
+// migrate upgrades the content of a settings file to the current version of
+// the schema. Files without a version predate the versioning, they are at
+// version 0, and so are files with a negative version.
+func migrate(b []byte) ([]byte, int, error) {
+	t, err := toml.LoadBytes(b)
+	if err != nil {
+		return nil, 0, err
+	}
+	v, _ := t.Get("version").(int64)
+	// A broken version is taken for a file predating the versioning
+	if v < 0 {
+		v = 0
+	}
+	if v >= SchemaVersion {
+		return b, int(v), nil
+	}
+	for _, m := range migrations[v:] {
+		m(t)
+	}
+	t.Set("version", int64(SchemaVersion))
+	out, err := t.Marshal()
+	return out, int(v), err
+}
+
+// upgrade migrates the settings file at path if it has an older version,
+// keeping a backup of the file as it was
+func upgrade(path string, b []byte) ([]byte, error) {
+	out, v, err := migrate(b)
+	if err != nil || v >= SchemaVersion {
+		return out, err
+	}
+	backup := fmt.Sprintf("%s.v%d.bak", path, v)
+	if err := ioutil.WriteFile(backup, b, 0644); err != nil {
+		return nil, err
+	}
+	logs.Infof("Settings", "Migrated from version %d to %d, backup in %s", v, SchemaVersion, backup)
+	return out, nil
+}
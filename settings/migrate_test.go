@@ -0,0 +1,52 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/pelletier/go-toml"
+)
+
+func Test_migrate(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		wantVersion int
+		wantTheme   interface{}
+	}{
+		{"dark mode", "video_dark_mode = true\n", 0, "Dark"},
+		{"light mode", "video_dark_mode = false\n", 0, nil},
+		{"theme kept", "video_dark_mode = true\nmenu_theme = \"Ocean\"\n", 0, "Ocean"},
+		{"current", "version = 1\nvideo_dark_mode = true\n", 1, nil},
+		{"negative version", "version = -3\nvideo_dark_mode = true\n", 0, "Dark"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, v, err := migrate([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v != tt.wantVersion {
+				t.Errorf("migrate() version = %v, want %v", v, tt.wantVersion)
+			}
+			tree, err := toml.LoadBytes(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tree.Get("menu_theme"); got != tt.wantTheme {
+				t.Errorf("menu_theme = %v, want %v", got, tt.wantTheme)
+			}
+			if got := tree.Get("version"); got != int64(SchemaVersion) {
+				t.Errorf("version = %v, want %v", got, SchemaVersion)
+			}
+			if tt.wantVersion < SchemaVersion && tree.Has("video_dark_mode") {
+				t.Error("video_dark_mode should be removed")
+			}
+		})
+	}
+}
+
+func Test_migrations(t *testing.T) {
+	if len(migrations) != SchemaVersion {
+		t.Errorf("%d migrations for schema version %d", len(migrations), SchemaVersion)
+	}
+}
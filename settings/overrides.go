@@ -0,0 +1,221 @@
+package settings
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/adrg/xdg"
+	"github.com/fatih/structs"
+	"github.com/libretro/ludo/utils"
+	"github.com/pelletier/go-toml"
+)
+
+// Settings are layered: the global settings of settings.toml, then the
+// overrides of the running core, then the overrides of the running game.
+// Overrides only hold the settings that differ from the layer below.
+var (
+	// global is the content of settings.toml, without the overrides
+	global Settings
+	// applied is Current as it was after applying the overrides, or after the
+	// last save. The fields that changed since are saved by Save.
+	applied Settings
+	// below are the layers below the core and the game overrides, as they
+	// were when the overrides were applied
+	belowCore Settings
+	belowGame Settings
+	// coreKeys and gameKeys are the settings set by the overrides
+	coreKeys map[string]bool
+	gameKeys map[string]bool
+)
+
+// clone copies settings, without sharing their maps and slices
+func clone(s Settings) Settings {
+	var c Settings
+	b, err := toml.Marshal(s)
+	if err != nil {
+		return s
+	}
+	if err := toml.Unmarshal(b, &c); err != nil {
+		return s
+	}
+	return c
+}
+
+// overridable tells if a setting can be overridden per core or per game. The
+// hidden settings and the LudOS services can't.
+func overridable(f *structs.Field) bool {
+	return f.Tag("hide") != "always" && f.Tag("service") == "" && f.Tag("toml") != ""
+}
+
+// diff returns the overridable settings of cur that differ from base, by
+// TOML key. The keys of skip are left out.
+func diff(cur, base Settings, skip map[string]bool) map[string]interface{} {
+	d := map[string]interface{}{}
+	bf := structs.Fields(&base)
+	for i, f := range structs.Fields(&cur) {
+		key := f.Tag("toml")
+		if !overridable(f) || skip[key] {
+			continue
+		}
+		if !reflect.DeepEqual(f.Value(), bf[i].Value()) {
+			d[key] = f.Value()
+		}
+	}
+	return d
+}
+
+// layer applies an override file to settings and returns the keys it sets
+func layer(s *Settings, b []byte) (map[string]bool, error) {
+	tree, err := toml.LoadBytes(b)
+	if err != nil {
+		return nil, err
+	}
+	if err := tree.Unmarshal(s); err != nil {
+		return nil, err
+	}
+	keys := map[string]bool{}
+	for _, k := range tree.Keys() {
+		keys[k] = true
+	}
+	return keys, nil
+}
+
+// coreOverridePath returns the location of the overrides of a core
+func coreOverridePath(corePath string) string {
+	name := utils.FileName(corePath)
+	return filepath.Join(xdg.ConfigHome, "ludo", "overrides", name, name+".toml")
+}
+
+// gameOverridePath returns the location of the overrides of a game,
+// identified by its CRC32 checksum
+func gameOverridePath(corePath string, crc uint32) string {
+	name := utils.FileName(corePath)
+	return filepath.Join(xdg.ConfigHome, "ludo", "overrides", name, fmt.Sprintf("%08X.toml", crc))
+}
+
+// readLayer applies an override file if it exists
+func readLayer(s *Settings, path string) map[string]bool {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return map[string]bool{}
+	}
+	keys, err := layer(s, b)
+	if err != nil {
+		return map[string]bool{}
+	}
+	return keys
+}
+
+// OnOverrides is called after the overrides are applied or cleared, with the
+// settings from before. The menu reconfigures the video and the audio from it.
+var OnOverrides func(prev Settings)
+
+// changed calls OnOverrides if it is set
+func changed(prev Settings) {
+	if OnOverrides != nil {
+		OnOverrides(prev)
+	}
+}
+
+// ApplyOverrides sets Current to the global settings, overridden by the
+// settings of a core, then by the settings of a game
+func ApplyOverrides(corePath string, crc uint32) {
+	prev := Current
+	belowCore = clone(global)
+	s := clone(global)
+	coreKeys = readLayer(&s, coreOverridePath(corePath))
+	belowGame = clone(s)
+	gameKeys = readLayer(&s, gameOverridePath(corePath, crc))
+	applySession(&s)
+	Current = s
+	applied = clone(s)
+	changed(prev)
+}
+
+// ClearOverrides restores the global settings once the game is unloaded.
+// Changes to overridden settings that weren't saved in an override are lost.
+func ClearOverrides() {
+	prev := Current
+	coreKeys, gameKeys = nil, nil
+	Current = clone(global)
+	applySession(&Current)
+	applied = clone(Current)
+	changed(prev)
+}
+
+// Overridden tells if a setting, by TOML key, is set by the overrides of the
+// running core or game, or for the session
+func Overridden(key string) bool {
+	_, ok := session[key]
+	return coreKeys[key] || gameKeys[key] || ok
+}
+
+// writeLayer saves an override file, or removes it if it overrides nothing
+func writeLayer(path string, d map[string]interface{}) error {
+	if len(d) == 0 {
+		err := os.Remove(path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	b, err := toml.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+// keep the settings of an override out of settings.toml. They were saved in
+// settings.toml as they changed, they get back their value from before the
+// overrides were applied.
+func keep(keys map[string]bool) error {
+	gf := structs.Fields(&global)
+	bf := structs.Fields(&belowCore)
+	for i, f := range gf {
+		if keys[f.Tag("toml")] {
+			f.Set(bf[i].Value())
+		}
+	}
+	return Save()
+}
+
+// SaveCoreOverrides saves the settings that differ from the global settings
+// as the overrides of a core. The settings set by the game overrides are left
+// out, and so are the settings of the session.
+func SaveCoreOverrides(corePath string) error {
+	d := diff(Current, belowCore, sessionKeys(gameKeys))
+	if err := writeLayer(coreOverridePath(corePath), d); err != nil {
+		return err
+	}
+	coreKeys = map[string]bool{}
+	for k := range d {
+		coreKeys[k] = true
+	}
+	return keep(coreKeys)
+}
+
+// SaveGameOverrides saves the settings that differ from the global settings
+// and the core overrides as the overrides of a game, except the settings of
+// the session
+func SaveGameOverrides(corePath string, crc uint32) error {
+	d := diff(Current, belowGame, sessionKeys(nil))
+	if err := writeLayer(gameOverridePath(corePath, crc), d); err != nil {
+		return err
+	}
+	gameKeys = map[string]bool{}
+	for k := range d {
+		gameKeys[k] = true
+	}
+	return keep(gameKeys)
+}
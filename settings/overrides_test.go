@@ -0,0 +1,73 @@
+package settings
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_diff(t *testing.T) {
+	base := Settings{AudioVolume: 0.5, VideoFilter: "Pixel Perfect", KioskPIN: "1234"}
+	cur := base
+	cur.AudioVolume = 0.8
+	cur.VideoFullscreen = true
+	cur.KioskPIN = "0000"
+
+	tests := []struct {
+		name string
+		skip map[string]bool
+		want map[string]interface{}
+	}{
+		{
+			name: "Keeps the overridable settings that changed",
+			want: map[string]interface{}{"audio_volume": float32(0.8), "video_fullscreen": true},
+		},
+		{
+			name: "Leaves out the skipped settings",
+			skip: map[string]bool{"video_fullscreen": true},
+			want: map[string]interface{}{"audio_volume": float32(0.8)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diff(cur, base, tt.skip); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_layer(t *testing.T) {
+	s := Settings{AudioVolume: 0.5, VideoFilter: "Pixel Perfect"}
+	keys, err := layer(&s, []byte("audio_volume = 0.8\nvideo_fullscreen = true\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]bool{"audio_volume": true, "video_fullscreen": true}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("keys = %v, want %v", keys, want)
+	}
+	if s.AudioVolume != 0.8 || !s.VideoFullscreen || s.VideoFilter != "Pixel Perfect" {
+		t.Errorf("settings = %+v", s)
+	}
+}
+
+func Test_commit(t *testing.T) {
+	global = Settings{AudioVolume: 0.5, VideoFilter: "Pixel Perfect"}
+	applied = Settings{AudioVolume: 0.5, VideoFilter: "Smooth"}
+	gameKeys = map[string]bool{"video_filter": true}
+	defer func() { gameKeys = nil }()
+	Current = applied
+	Current.AudioVolume = 0.8
+	Current.VideoFilter = "Raw"
+
+	commit()
+
+	if global.AudioVolume != 0.8 {
+		t.Errorf("the change wasn't committed: %v", global.AudioVolume)
+	}
+	if global.VideoFilter != "Pixel Perfect" {
+		t.Errorf("the overridden setting was committed: %v", global.VideoFilter)
+	}
+	if !reflect.DeepEqual(applied, Current) {
+		t.Errorf("applied = %+v, want %+v", applied, Current)
+	}
+}
@@ -0,0 +1,80 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/adrg/xdg"
+	"github.com/fatih/structs"
+)
+
+// PortableDir is the directory of the executable when Ludo runs in portable
+// mode, empty otherwise. The configuration, the data and the cache then live
+// in this directory, so that Ludo can run from a USB stick.
+var PortableDir string
+
+// ExecutableDir returns the directory of the Ludo executable
+func ExecutableDir() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	if p, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = p
+	}
+	return filepath.Dir(exe)
+}
+
+// HasPortableMarker tells if a portable.txt file is next to the executable
+func HasPortableMarker() bool {
+	dir := ExecutableDir()
+	if dir == "" {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(dir, "portable.txt"))
+	return err == nil
+}
+
+// SetPortable moves the configuration, the data and the cache of Ludo to
+// dir. It has to be called before Load.
+func SetPortable(dir string) {
+	PortableDir = dir
+	xdg.ConfigHome = filepath.Join(dir, "config")
+	xdg.DataHome = filepath.Join(dir, "data")
+	xdg.CacheHome = filepath.Join(dir, "cache")
+	xdg.StateHome = filepath.Join(dir, "state")
+	Defaults = defaultSettings()
+	Defaults.FileDirectory = dir
+	absolute(&Defaults, dir)
+}
+
+// absolute resolves the relative directories of settings against dir
+func absolute(s *Settings, dir string) {
+	for _, f := range structs.Fields(s) {
+		if f.Tag("widget") != "dir" {
+			continue
+		}
+		p := f.Value().(string)
+		if p != "" && !filepath.IsAbs(p) {
+			f.Set(filepath.Join(dir, p))
+		}
+	}
+}
+
+// relative makes the directories of settings inside dir relative to it, so
+// that they still point to the right place once the drive is mounted
+// elsewhere
+func relative(s *Settings, dir string) {
+	for _, f := range structs.Fields(s) {
+		if f.Tag("widget") != "dir" {
+			continue
+		}
+		p := f.Value().(string)
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || !filepath.IsAbs(p) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		f.Set(rel)
+	}
+}
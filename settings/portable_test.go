@@ -0,0 +1,40 @@
+package settings
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func Test_portablePaths(t *testing.T) {
+	dir := filepath.FromSlash("/media/usb/ludo")
+	outside := filepath.FromSlash("/home/user/roms")
+
+	s := Settings{
+		CoresDirectory:      "./cores",
+		SavestatesDirectory: filepath.Join(dir, "data", "ludo", "savestates"),
+		FileDirectory:       outside,
+		Language:            "fr",
+	}
+
+	relative(&s, dir)
+	if s.CoresDirectory != "./cores" {
+		t.Errorf("CoresDirectory = %v, want ./cores", s.CoresDirectory)
+	}
+	if want := filepath.Join("data", "ludo", "savestates"); s.SavestatesDirectory != want {
+		t.Errorf("SavestatesDirectory = %v, want %v", s.SavestatesDirectory, want)
+	}
+	if s.FileDirectory != outside {
+		t.Errorf("FileDirectory = %v, want %v", s.FileDirectory, outside)
+	}
+
+	absolute(&s, dir)
+	if want := filepath.Join(dir, "cores"); s.CoresDirectory != want {
+		t.Errorf("CoresDirectory = %v, want %v", s.CoresDirectory, want)
+	}
+	if want := filepath.Join(dir, "data", "ludo", "savestates"); s.SavestatesDirectory != want {
+		t.Errorf("SavestatesDirectory = %v, want %v", s.SavestatesDirectory, want)
+	}
+	if s.FileDirectory != outside || s.Language != "fr" {
+		t.Errorf("absolute() changed %+v", s)
+	}
+}
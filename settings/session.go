@@ -0,0 +1,103 @@
+package settings
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/structs"
+)
+
+// session are the settings set for the current session only, by the --set
+// flags and the LUDO_ environment variables, by TOML key. They are applied
+// above the overrides and never saved.
+var session map[string]string
+
+// SetValue changes a setting, by TOML key, parsing the value according to
+// the type of the setting
+func SetValue(s *Settings, key, value string) error {
+	for _, f := range structs.Fields(s) {
+		if f.Tag("toml") != key {
+			continue
+		}
+		switch f.Value().(type) {
+		case bool:
+			v, err := strconv.ParseBool(value)
+			if err != nil {
+				return err
+			}
+			return f.Set(v)
+		case int:
+			v, err := strconv.Atoi(value)
+			if err != nil {
+				return err
+			}
+			return f.Set(v)
+		case float32:
+			v, err := strconv.ParseFloat(value, 32)
+			if err != nil {
+				return err
+			}
+			return f.Set(float32(v))
+		case string:
+			return f.Set(value)
+		}
+		return fmt.Errorf("unsupported setting type for %s", key)
+	}
+	return fmt.Errorf("unknown setting %s", key)
+}
+
+// EnvSession returns the settings set by environment variables, like
+// LUDO_VIDEO_FULLSCREEN=true for video_fullscreen. Variables that don't name
+// a setting are ignored.
+func EnvSession(environ []string) map[string]string {
+	keys := map[string]bool{}
+	for _, f := range structs.Fields(&Settings{}) {
+		keys[f.Tag("toml")] = true
+	}
+	values := map[string]string{}
+	for _, kv := range environ {
+		i := strings.Index(kv, "=")
+		if i < 0 || !strings.HasPrefix(kv[:i], "LUDO_") {
+			continue
+		}
+		key := strings.ToLower(strings.TrimPrefix(kv[:i], "LUDO_"))
+		if keys[key] {
+			values[key] = kv[i+1:]
+		}
+	}
+	return values
+}
+
+// SetSession sets settings for the current session only. It has to be called
+// before Load.
+func SetSession(values map[string]string) error {
+	var s Settings
+	for k, v := range values {
+		if err := SetValue(&s, k, v); err != nil {
+			return err
+		}
+	}
+	session = values
+	return nil
+}
+
+// applySession sets the settings of the session on top of s
+func applySession(s *Settings) {
+	for k, v := range session {
+		SetValue(s, k, v)
+	}
+}
+
+// sessionKeys returns the keys set by the session, along with the keys of
+// extra
+func sessionKeys(extra map[string]bool) map[string]bool {
+	keys := map[string]bool{}
+	for k := range extra {
+		keys[k] = true
+	}
+	for k := range session {
+		keys[k] = true
+	}
+	return keys
+}
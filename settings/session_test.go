@@ -0,0 +1,54 @@
+package settings
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_EnvSession(t *testing.T) {
+	got := EnvSession([]string{
+		"HOME=/home/user",
+		"LUDO_VIDEO_FULLSCREEN=true",
+		"LUDO_AUDIO_VOLUME=0.2",
+		"LUDO_UNKNOWN=1",
+		"LUDO_LANGUAGE=a=b",
+	})
+	want := map[string]string{
+		"video_fullscreen": "true",
+		"audio_volume":     "0.2",
+		"language":         "a=b",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("EnvSession() = %v, want %v", got, want)
+	}
+}
+
+func Test_SetSession(t *testing.T) {
+	defer func() { session = nil }()
+
+	if err := SetSession(map[string]string{"video_fullscreen": "yes"}); err == nil {
+		t.Error("SetSession() should fail on an invalid bool")
+	}
+	if err := SetSession(map[string]string{"no_such_setting": "1"}); err == nil {
+		t.Error("SetSession() should fail on an unknown setting")
+	}
+	if err := SetSession(map[string]string{"video_fullscreen": "true", "audio_volume": "0.2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	global = Settings{AudioVolume: 0.5}
+	ClearOverrides()
+	if !Current.VideoFullscreen || Current.AudioVolume != 0.2 {
+		t.Errorf("the session wasn't applied: %+v", Current)
+	}
+
+	Current.AudioVolume = 0.8
+	Current.MenuSounds = true
+	commit()
+	if global.AudioVolume != 0.5 || global.VideoFullscreen {
+		t.Errorf("the session was committed: %+v", global)
+	}
+	if !global.MenuSounds {
+		t.Error("the change outside of the session wasn't committed")
+	}
+}
@@ -7,12 +7,13 @@ import (
 	"errors"
 	"io"
 	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
+	"reflect"
 
 	"github.com/adrg/xdg"
 	"github.com/fatih/structs"
+	"github.com/libretro/ludo/logs"
 	"github.com/libretro/ludo/ludos"
 	"github.com/libretro/ludo/utils"
 	"github.com/pelletier/go-toml"
@@ -22,30 +23,220 @@ import (
 // Tags are used to set a human readable label and a format for the settings value.
 // Widget sets the graphical representation of the value.
 type Settings struct {
+	SchemaVersion int `hide:"always" toml:"version"`
+
+	Language string `toml:"language" label:"Language" fmt:"<%s>"`
+
 	VideoFullscreen   bool   `hide:"ludos" toml:"video_fullscreen" label:"Video Fullscreen" fmt:"%t" widget:"switch"`
 	VideoMonitorIndex int    `toml:"video_monitor_index" label:"Video Monitor Index" fmt:"%d"`
 	VideoFilter       string `toml:"video_filter" label:"Video Filter" fmt:"<%s>"`
-	VideoDarkMode     bool   `toml:"video_dark_mode" label:"Video Dark Mode" fmt:"%t" widget:"switch"`
+	MenuTheme         string `toml:"menu_theme" label:"Menu Theme" fmt:"<%s>"`
+
+	MenuScale    float32 `toml:"menu_scale" label:"Menu Scale" fmt:"%.2fx"`
+	MenuFontSize float32 `toml:"menu_font_size" label:"Menu Font Size" fmt:"%.2fx"`
+	MenuSpeech   bool    `toml:"menu_speech" label:"Read Menu Aloud" fmt:"%t" widget:"switch"`
+
+	KeyboardLayout   string `toml:"menu_keyboard_layout" label:"On-Screen Keyboard Layout" fmt:"<%s>"`
+	MenuAttractDelay int    `toml:"menu_attract_delay" label:"Screensaver Delay" fmt:"%d min"`
+	PlaylistSort     string `toml:"menu_playlist_sort" label:"Playlist Sort" fmt:"<%s>"`
+
+	VideoFullscreenMode string `hide:"ludos" toml:"video_fullscreen_mode" label:"Fullscreen Mode" fmt:"<%s>"`
+	VideoWindowX        int    `hide:"always" toml:"video_window_x"`
+	VideoWindowY        int    `hide:"always" toml:"video_window_y"`
+	VideoWindowWidth    int    `hide:"always" toml:"video_window_width"`
+	VideoWindowHeight   int    `hide:"always" toml:"video_window_height"`
+
+	VideoIntegerScale   bool `toml:"video_integer_scale" label:"Integer Scaling" fmt:"%t" widget:"switch"`
+	VideoCropOverscan   bool `toml:"video_crop_overscan" label:"Crop Overscan" fmt:"%t" widget:"switch"`
+	VideoCustomViewport bool `toml:"video_custom_viewport" label:"Custom Viewport" fmt:"%t" widget:"switch"`
+	VideoViewportWidth  int  `toml:"video_viewport_width" label:"Custom Viewport Width" fmt:"%d"`
+	VideoViewportHeight int  `toml:"video_viewport_height" label:"Custom Viewport Height" fmt:"%d"`
+	VideoViewportX      int  `toml:"video_viewport_x" label:"Custom Viewport X Offset" fmt:"%d"`
+	VideoViewportY      int  `toml:"video_viewport_y" label:"Custom Viewport Y Offset" fmt:"%d"`
+
+	VideoAspectRatio       string  `toml:"video_aspect_ratio" label:"Aspect Ratio" fmt:"<%s>"`
+	VideoCustomAspectRatio float32 `toml:"video_custom_aspect_ratio" label:"Custom Aspect Ratio" fmt:"%.2f"`
+
+	VideoRotation       int `toml:"video_rotation" label:"Game Rotation" fmt:"%d°"`
+	VideoScreenRotation int `toml:"video_screen_rotation" label:"Screen Rotation" fmt:"%d°"`
+
+	VideoSwapInterval int  `toml:"video_swap_interval" label:"Vertical Sync Interval" fmt:"%d"`
+	VideoVRR          bool `toml:"video_vrr" label:"Variable Refresh Rate" fmt:"%t" widget:"switch"`
+	VideoSyncExactFPS bool `toml:"video_sync_exact_fps" label:"Sync To Exact Content Framerate" fmt:"%t" widget:"switch"`
+
+	// VideoMatchRefreshRate switches the exclusive fullscreen mode to the
+	// refresh rate closest to the framerate of the game
+	VideoMatchRefreshRate bool `toml:"video_match_refresh_rate" label:"Match Content Refresh Rate" fmt:"%t" widget:"switch"`
+
+	VideoBlackFrames int `toml:"video_black_frame_insertion" label:"Black Frame Insertion" fmt:"%d"`
+
+	VideoFrameDelay     int  `toml:"video_frame_delay" label:"Frame Delay" fmt:"%d ms"`
+	VideoHardSync       bool `toml:"video_hard_sync" label:"Hard GPU Sync" fmt:"%t" widget:"switch"`
+	VideoHardSyncFrames int  `toml:"video_hard_sync_frames" label:"Hard GPU Sync Frames" fmt:"%d"`
+
+	// VideoThreaded displays the frames from another thread, with triple
+	// buffering. It adds a frame of latency and disables black frame
+	// insertion, but the main loop doesn't wait for slow GPUs.
+	VideoThreaded bool `toml:"video_threaded" label:"Threaded Video" fmt:"%t" widget:"switch"`
+
+	// CoreThread runs the core on its own OS thread, paced at the framerate
+	// of the game, so the menu and the buffer swaps can't delay the audio
+	CoreThread bool `toml:"core_thread" label:"Threaded Core" fmt:"%t" widget:"switch"`
+
+	BezelEnable  bool   `toml:"video_bezel_enable" label:"Bezels" fmt:"%t" widget:"switch"`
+	BezelPackURL string `hide:"always" toml:"video_bezel_pack_url"`
+
+	ScreenshotPostShader bool `toml:"video_screenshot_post_shader" label:"Screenshots With Shaders" fmt:"%t" widget:"switch"`
+
+	// SavestateAutoIncrement saves each state in the next slot, up to
+	// SavestateSlots, instead of a new dated file
+	SavestateAutoIncrement bool `toml:"savestate_auto_increment" label:"Savestate Auto-Increment" fmt:"%t" widget:"switch"`
+	SavestateSlots         int  `toml:"savestate_slots" label:"Savestate Slots" fmt:"%d"`
+
+	RecordQuality string `toml:"record_quality" label:"Recording Quality" fmt:"<%s>"`
+	RecordFormat  string `toml:"record_format" label:"Recording Format" fmt:"<%s>"`
+	RecordStream  bool   `toml:"record_stream" label:"Stream Instead Of Recording" fmt:"%t" widget:"switch"`
+	StreamURL     string `hide:"always" toml:"stream_url"`
 
 	AudioVolume float32 `toml:"audio_volume" label:"Audio Volume" fmt:"%.1f" widget:"range"`
 
+	AudioDevice     string `toml:"audio_device" label:"Audio Device" fmt:"<%s>"`
+	AudioBufferSize int    `toml:"audio_buffer_size" label:"Audio Buffer Size" fmt:"%d bytes"`
+
+	AudioLatencyMode string `toml:"audio_latency_mode" label:"Audio Latency Mode" fmt:"<%s>"`
+
+	AudioResampler   string  `toml:"audio_resampler" label:"Audio Resampler" fmt:"<%s>"`
+	AudioRateControl float32 `toml:"audio_rate_control_delta" label:"Dynamic Rate Control" fmt:"%.3f"`
+
+	// AudioSyncRefreshRate adjusts the audio to the measured refresh rate of
+	// the display, when the game follows it
+	AudioSyncRefreshRate bool `toml:"audio_sync_refresh_rate" label:"Sync Audio To Refresh Rate" fmt:"%t" widget:"switch"`
+
+	NotificationsPosition string   `toml:"menu_notifications_position" label:"Notifications Position" fmt:"<%s>"`
+	NotificationsDuration float32  `toml:"menu_notifications_duration" label:"Notifications Duration" fmt:"%.0f s"`
+	NotificationsFontSize float32  `toml:"menu_notifications_font_size" label:"Notifications Font Size" fmt:"%.1f"`
+	NotificationsHidden   []string `hide:"always" toml:"menu_notifications_hidden"`
+
+	LogLevel  string `toml:"log_level" label:"Log Level" fmt:"<%s>"`
+	LogToFile bool   `toml:"log_to_file" label:"Log To File" fmt:"%t" widget:"switch"`
+	// EnvironmentAudit records the environment calls of the cores, for the
+	// compatibility reports
+	EnvironmentAudit bool `toml:"log_environment_calls" label:"Audit Environment Calls" fmt:"%t" widget:"switch"`
+
+	AchievementsHardcore bool   `toml:"cheevos_hardcore_mode_enable" label:"Hardcore Mode" fmt:"%t" widget:"switch"`
+	AchievementsUsername string `toml:"cheevos_username" label:"RetroAchievements Username" widget:"text"`
+	AchievementsAPIKey   string `toml:"cheevos_api_key" label:"RetroAchievements API Key" widget:"password"`
+	// AchievementsToken is the connect token of the user, the password typed
+	// to log in isn't stored
+	AchievementsToken string `toml:"cheevos_token" label:"RetroAchievements Password" widget:"login"`
+
+	PauseOnFocusLoss bool `hide:"ludos" toml:"pause_nonactive" label:"Pause When In Background" fmt:"%t" widget:"switch"`
+	SingleInstance   bool `hide:"ludos" toml:"single_instance" label:"Single Instance" fmt:"%t" widget:"switch"`
+
+	DiscordEnable        bool   `hide:"ludos" toml:"discord_allow" label:"Discord Rich Presence" fmt:"%t" widget:"switch"`
+	DiscordApplicationID string `hide:"always" toml:"discord_app_id"`
+
+	CrashReportURL string `hide:"always" toml:"crash_report_url"`
+
+	RemoteEnable  bool   `toml:"remote_enable" label:"Remote Control API" fmt:"%t" widget:"switch"`
+	RemoteLAN     bool   `toml:"remote_lan" label:"Allow LAN Access" fmt:"%t" widget:"switch"`
+	RemoteAddress string `hide:"always" toml:"remote_address"`
+	RemoteCode    string `hide:"always" toml:"remote_pairing_code"`
+
+	KioskMode bool   `toml:"kiosk_mode_enable" label:"Kiosk Mode" fmt:"%t" widget:"switch"`
+	KioskPIN  string `hide:"always" toml:"kiosk_mode_password"`
+
+	ParentalMaxAge       int      `hide:"always" toml:"parental_max_age"`
+	ParentalSessionLimit int      `hide:"always" toml:"parental_session_limit"`
+	ParentalPlaylists    []string `hide:"always" toml:"parental_restricted_playlists"`
+
+	MenuSounds      bool    `toml:"menu_sounds_enable" label:"Menu Sounds" fmt:"%t" widget:"switch"`
 	MenuAudioVolume float32 `toml:"menu_audio_volume" label:"Menu Audio Volume" fmt:"%.1f" widget:"range"`
+	MenuMusicVolume float32 `toml:"menu_music_volume" label:"Menu Music Volume" fmt:"%.1f" widget:"range"`
 	ShowHiddenFiles bool    `toml:"menu_showhiddenfiles" label:"Show Hidden Files" fmt:"%t" widget:"switch"`
+	ScanOnStartup   bool    `toml:"scan_on_startup" label:"Scan Changed Folders On Startup" fmt:"%t" widget:"switch"`
+	// ThumbnailsCacheSize is the maximum size of the thumbnails cache in MB, 0
+	// for no limit
+	ThumbnailsCacheSize int `toml:"thumbnails_cache_size" label:"Thumbnails Cache Size" fmt:"%d MB" zero:"Unlimited"`
 
-	MapAxisToDPad bool `toml:"input_map_axis_to_dpad" label:"Map Sticks To DPad" fmt:"%t" widget:"switch"`
+	MapAxisToDPad  bool    `toml:"input_map_axis_to_dpad" label:"Map Sticks To DPad" fmt:"%t" widget:"switch"`
+	RumbleStrength float32 `toml:"input_rumble_strength" label:"Rumble Strength" fmt:"%.1f" widget:"range"`
+
+	OverlayEnable  bool    `toml:"input_overlay_enable" label:"Touch Overlay" fmt:"%t" widget:"switch"`
+	OverlayLayout  string  `toml:"input_overlay_layout" label:"Touch Overlay Layout" fmt:"<%s>"`
+	OverlayOpacity float32 `toml:"input_overlay_opacity" label:"Touch Overlay Opacity" fmt:"%.1f" widget:"range"`
+
+	HotkeyEnable            string `toml:"input_hotkey_enable_btn" label:"Hotkey Enable Button" fmt:"<%s>"`
+	HotkeyMenuToggleKey     string `toml:"input_menu_toggle_key" label:"Menu Toggle Key" fmt:"<%s>"`
+	HotkeyMenuToggleButton  string `toml:"input_menu_toggle_btn" label:"Menu Toggle Button" fmt:"<%s>"`
+	HotkeyFastForwardKey    string `toml:"input_fast_forward_key" label:"Fast Forward Key" fmt:"<%s>"`
+	HotkeyFastForwardButton string `toml:"input_fast_forward_btn" label:"Fast Forward Button" fmt:"<%s>"`
+	HotkeySaveStateKey      string `toml:"input_save_state_key" label:"Save State Key" fmt:"<%s>"`
+	HotkeySaveStateButton   string `toml:"input_save_state_btn" label:"Save State Button" fmt:"<%s>"`
+	HotkeyLoadStateKey      string `toml:"input_load_state_key" label:"Load State Key" fmt:"<%s>"`
+	HotkeyLoadStateButton   string `toml:"input_load_state_btn" label:"Load State Button" fmt:"<%s>"`
+	HotkeyScreenshotKey     string `toml:"input_screenshot_key" label:"Screenshot Key" fmt:"<%s>"`
+	HotkeyScreenshotButton  string `toml:"input_screenshot_btn" label:"Screenshot Button" fmt:"<%s>"`
+	HotkeyFullscreenKey     string `hide:"ludos" toml:"input_fullscreen_key" label:"Fullscreen Key" fmt:"<%s>"`
+	HotkeyFullscreenButton  string `hide:"ludos" toml:"input_fullscreen_btn" label:"Fullscreen Button" fmt:"<%s>"`
+	HotkeyQuitKey           string `toml:"input_quit_key" label:"Quit Key" fmt:"<%s>"`
+	HotkeyQuitButton        string `toml:"input_quit_btn" label:"Quit Button" fmt:"<%s>"`
+	HotkeyGameFocusKey      string `toml:"input_game_focus_key" label:"Game Focus Key" fmt:"<%s>"`
+	HotkeyMacroRecordKey    string `toml:"input_macro_record_key" label:"Record Macro Key" fmt:"<%s>"`
+	HotkeyMacroRecordButton string `toml:"input_macro_record_btn" label:"Record Macro Button" fmt:"<%s>"`
+	HotkeyMacroPlayKey      string `toml:"input_macro_play_key" label:"Play Macro Key" fmt:"<%s>"`
+	HotkeyMacroPlayButton   string `toml:"input_macro_play_btn" label:"Play Macro Button" fmt:"<%s>"`
+	HotkeyRecordKey         string `toml:"input_record_key" label:"Record Video Key" fmt:"<%s>"`
+	HotkeyRecordButton      string `toml:"input_record_btn" label:"Record Video Button" fmt:"<%s>"`
+	HotkeyMuteKey           string `toml:"input_audio_mute_key" label:"Mute Key" fmt:"<%s>"`
+	HotkeyMuteButton        string `toml:"input_audio_mute_btn" label:"Mute Button" fmt:"<%s>"`
+
+	HotkeyPauseKey           string `toml:"input_pause_toggle_key" label:"Pause Key" fmt:"<%s>"`
+	HotkeyPauseButton        string `toml:"input_pause_toggle_btn" label:"Pause Button" fmt:"<%s>"`
+	HotkeyFrameAdvanceKey    string `toml:"input_frame_advance_key" label:"Frame Advance Key" fmt:"<%s>"`
+	HotkeyFrameAdvanceButton string `toml:"input_frame_advance_btn" label:"Frame Advance Button" fmt:"<%s>"`
+	HotkeyPerfOverlayKey     string `toml:"input_perf_overlay_key" label:"Performance Overlay Key" fmt:"<%s>"`
+	HotkeyPerfOverlayButton  string `toml:"input_perf_overlay_btn" label:"Performance Overlay Button" fmt:"<%s>"`
 
 	CoreForPlaylist map[string]string `hide:"always" toml:"core_for_playlist"`
+	// GameDirectories are the last folders games were loaded from, by system
+	GameDirectories map[string]string `hide:"always" toml:"game_directories"`
+	// ScanHints map folders, like roms/megadrive, to the system of their games
+	ScanHints map[string]string `hide:"always" toml:"scan_hints"`
+	// ScannedDirectories map the scanned folders to the fingerprint of their
+	// content at the time of the scan
+	ScannedDirectories map[string]string `hide:"always" toml:"scanned_directories"`
+	// HackPackURL is the location of a zip of dats of ROM hacks and
+	// translations, installed in the user dats directory
+	HackPackURL string `toml:"hack_pack_url" label:"Hacks Database URL" fmt:"%s" widget:"text"`
+
+	CoreUpdatesCheck string `hide:"ludos" toml:"core_updates_check" label:"Core Updates Check" fmt:"<%s>"`
+	// CoreUpdatesLastCheck is the time of the last background check of the
+	// core updates
+	CoreUpdatesLastCheck string `hide:"always" toml:"core_updates_last_check"`
 
 	FileDirectory        string `hide:"ludos" toml:"files_dir" label:"Files Directory" fmt:"%s" widget:"dir"`
 	CoresDirectory       string `hide:"ludos" toml:"cores_dir" label:"Cores Directory" fmt:"%s" widget:"dir"`
 	AssetsDirectory      string `hide:"ludos" toml:"assets_dir" label:"Assets Directory" fmt:"%s" widget:"dir"`
 	DatabaseDirectory    string `hide:"ludos" toml:"database_dir" label:"Database Directory" fmt:"%s" widget:"dir"`
+	UserDatsDirectory    string `hide:"ludos" toml:"user_dats_dir" label:"User Dats Directory" fmt:"%s" widget:"dir"`
+	LocalesDirectory     string `hide:"ludos" toml:"locales_dir" label:"Locales Directory" fmt:"%s" widget:"dir"`
 	SavestatesDirectory  string `hide:"ludos" toml:"savestates_dir" label:"Savestates Directory" fmt:"%s" widget:"dir"`
 	SavefilesDirectory   string `hide:"ludos" toml:"savefiles_dir" label:"Savefiles Directory" fmt:"%s" widget:"dir"`
 	ScreenshotsDirectory string `hide:"ludos" toml:"screenshots_dir" label:"Screenshots Directory" fmt:"%s" widget:"dir"`
+	BezelsDirectory      string `hide:"ludos" toml:"bezels_dir" label:"Bezels Directory" fmt:"%s" widget:"dir"`
+	CheatsDirectory      string `hide:"ludos" toml:"cheats_dir" label:"Cheats Directory" fmt:"%s" widget:"dir"`
+	RecordingsDirectory  string `hide:"ludos" toml:"recordings_dir" label:"Recordings Directory" fmt:"%s" widget:"dir"`
+	MoviesDirectory      string `hide:"ludos" toml:"movies_dir" label:"Movies Directory" fmt:"%s" widget:"dir"`
 	SystemDirectory      string `hide:"ludos" toml:"system_dir" label:"System Directory" fmt:"%s" widget:"dir"`
 	PlaylistsDirectory   string `hide:"ludos" toml:"playlists_dir" label:"Playlists Directory" fmt:"%s" widget:"dir"`
 	ThumbnailsDirectory  string `hide:"ludos" toml:"thumbnail_dir" label:"Thumbnails Directory" fmt:"%s" widget:"dir"`
+	ArtworkDirectory     string `hide:"ludos" toml:"artwork_dir" label:"Artwork Directory" fmt:"%s" widget:"dir"`
+	ManualsDirectory     string `hide:"ludos" toml:"manuals_dir" label:"Manuals Directory" fmt:"%s" widget:"dir"`
+	CollectionDirectory  string `hide:"ludos" toml:"collection_dir" label:"Collection Page Directory" fmt:"%s" widget:"dir"`
+	PresetsDirectory     string `hide:"ludos" toml:"presets_dir" label:"Presets Directory" fmt:"%s" widget:"dir"`
+	ThemesDirectory      string `hide:"ludos" toml:"themes_dir" label:"Themes Directory" fmt:"%s" widget:"dir"`
+	MusicDirectory       string `hide:"ludos" toml:"music_dir" label:"Music Directory" fmt:"%s" widget:"dir"`
 
 	SSHService       bool `hide:"app" toml:"ssh_service" label:"SSH" widget:"switch" service:"sshd.service" path:"/storage/.cache/services/sshd.conf"`
 	SambaService     bool `hide:"app" toml:"samba_service" label:"Samba" widget:"switch" service:"smbd.service" path:"/storage/.cache/services/samba.conf"`
@@ -58,14 +249,23 @@ var Current Settings
 // Defaults stores default values for settings
 var Defaults = defaultSettings()
 
+// FirstRun is set when no settings file was found, Ludo is launched for the
+// first time
+var FirstRun bool
+
 // Load loads settings from the home directory.
 // If the settings file doesn't exists, it will return an error and
 // set all the settings to their default value.
 func Load() error {
 	defer func() {
+		// The settings of the session go on top of the loaded settings,
+		// which are the ones saved
+		global = clone(Current)
+		applySession(&Current)
+		applied = clone(Current)
 		err := Save()
 		if err != nil {
-			log.Println(err)
+			logs.Errorf("Settings", "%v", err)
 		}
 	}()
 
@@ -82,6 +282,13 @@ func Load() error {
 	}
 
 	b, err := ioutil.ReadFile(filepath.Join(xdg.ConfigHome, "ludo", "settings.toml"))
+	if os.IsNotExist(err) {
+		FirstRun = true
+	}
+	if err != nil {
+		return err
+	}
+	b, err = upgrade(filepath.Join(xdg.ConfigHome, "ludo", "settings.toml"), b)
 	if err != nil {
 		return err
 	}
@@ -89,6 +296,9 @@ func Load() error {
 	if err != nil {
 		return err
 	}
+	if PortableDir != "" {
+		absolute(&Current, PortableDir)
+	}
 
 	// Those are special fields, their value is not saved in settings.toml but
 	// depends on the presence of some files
@@ -97,14 +307,38 @@ func Load() error {
 	return nil
 }
 
-// Save saves the current configuration to the home directory
+// commit copies the settings changed since the overrides were applied to the
+// global settings, except the overridden ones which only last for the session
+func commit() {
+	gf := structs.Fields(&global)
+	af := structs.Fields(&applied)
+	for i, f := range structs.Fields(&Current) {
+		if reflect.DeepEqual(f.Value(), af[i].Value()) {
+			continue
+		}
+		af[i].Set(f.Value())
+		if !Overridden(f.Tag("toml")) {
+			gf[i].Set(f.Value())
+		}
+	}
+}
+
+// Save saves the global settings to the home directory, along with the
+// settings changed since the last save that aren't overridden
 func Save() error {
+	commit()
+
 	err := os.MkdirAll(filepath.Join(xdg.ConfigHome, "ludo"), os.ModePerm)
 	if err != nil {
 		return err
 	}
 
-	b, err := toml.Marshal(Current)
+	g := global
+	if PortableDir != "" {
+		relative(&g, PortableDir)
+	}
+
+	b, err := toml.Marshal(g)
 	if err != nil {
 		return err
 	}
@@ -116,7 +350,7 @@ func Save() error {
 	defer func() {
 		err := fd.Close()
 		if err != nil {
-			log.Println(err)
+			logs.Errorf("Settings", "%v", err)
 		}
 	}()
 
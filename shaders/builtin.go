@@ -0,0 +1,188 @@
+package shaders
+
+// header is shared by the built-in fragment shaders, it makes them work with
+// both GLSL 1.20 and 1.30+
+const header = `
+#if __VERSION__ >= 130
+#define COMPAT_VARYING in
+#define COMPAT_TEXTURE texture
+#define COMPAT_FRAGCOLOR FragColor
+out vec4 COMPAT_FRAGCOLOR;
+#else
+#define COMPAT_VARYING varying
+#define COMPAT_TEXTURE texture2D
+#define COMPAT_FRAGCOLOR gl_FragColor
+#endif
+
+uniform vec2 OutputSize;
+uniform vec2 TextureSize;
+uniform vec2 InputSize;
+uniform sampler2D Texture;
+COMPAT_VARYING vec2 fragTexCoord;
+`
+
+var crtShader = `
+#pragma parameter BLURSCALEX "Blur Amount X-Axis" 0.30 0.0 1.0 0.05
+#pragma parameter LOWLUMSCAN "Scanline Darkness - Low" 6.0 0.0 10.0 0.5
+#pragma parameter HILUMSCAN "Scanline Darkness - High" 8.0 0.0 50.0 1.0
+#pragma parameter BRIGHTBOOST "Dark Pixel Brightness Boost" 1.25 0.5 1.5 0.05
+#pragma parameter MASK_DARK "Mask Effect Amount" 0.25 0.0 1.0 0.05
+#pragma parameter MASK_FADE "Mask/Scanline Fade" 0.8 0.0 1.0 0.05
+` + header + `
+#ifdef PARAMETER_UNIFORM
+uniform float BLURSCALEX;
+uniform float LOWLUMSCAN;
+uniform float HILUMSCAN;
+uniform float BRIGHTBOOST;
+uniform float MASK_DARK;
+uniform float MASK_FADE;
+#else
+#define BLURSCALEX 0.30
+#define LOWLUMSCAN 6.0
+#define HILUMSCAN 8.0
+#define BRIGHTBOOST 1.25
+#define MASK_DARK 0.25
+#define MASK_FADE 0.8
+#endif
+
+void main() {
+  float maskFade = 0.3333*MASK_FADE;
+  vec2 invDims = 1.0/TextureSize.xy;
+
+  vec2 p = fragTexCoord * TextureSize;
+  vec2 i = floor(p) + 0.50;
+  vec2 f = p - i;
+
+  p = (i + 4.0*f*f*f)*invDims;
+  p.x = mix(p.x, fragTexCoord.x, BLURSCALEX);
+  float Y = f.y*f.y;
+  float YY = Y*Y;
+
+  float whichmask = fract(gl_FragCoord.x*-0.4999);
+  float mask = 1.0 + float(whichmask < 0.5) * -MASK_DARK;
+
+  vec3 colour = COMPAT_TEXTURE(Texture, p).rgb;
+
+  float scanLineWeight = (BRIGHTBOOST - LOWLUMSCAN*(Y - 2.05*YY));
+  float scanLineWeightB = 1.0 - HILUMSCAN*(YY-2.8*YY*Y);
+
+  COMPAT_FRAGCOLOR = vec4(colour.rgb*mix(scanLineWeight*mask, scanLineWeightB, dot(colour.rgb,vec3(maskFade))), 1.0);
+}
+`
+
+var scanlinesShader = `
+#pragma parameter SCANLINE_STRENGTH "Scanline Strength" 0.5 0.0 1.0 0.05
+#pragma parameter SCANLINE_BRIGHTNESS "Brightness Boost" 1.1 1.0 2.0 0.05
+` + header + `
+#ifdef PARAMETER_UNIFORM
+uniform float SCANLINE_STRENGTH;
+uniform float SCANLINE_BRIGHTNESS;
+#else
+#define SCANLINE_STRENGTH 0.5
+#define SCANLINE_BRIGHTNESS 1.1
+#endif
+
+void main() {
+  vec3 colour = COMPAT_TEXTURE(Texture, fragTexCoord).rgb;
+  float line = fract(fragTexCoord.y * TextureSize.y);
+  float weight = 1.0 - SCANLINE_STRENGTH * step(0.5, line);
+  COMPAT_FRAGCOLOR = vec4(colour * weight * SCANLINE_BRIGHTNESS, 1.0);
+}
+`
+
+var lcdShader = `
+#pragma parameter BORDERMULT "Border Multiplier" 14.0 -40.0 40.0 1.0
+#pragma parameter GBAGAMMA "GBA Gamma Hack" 1.0 0.0 1.0 1.0
+` + header + `
+#ifdef PARAMETER_UNIFORM
+uniform float BORDERMULT;
+uniform float GBAGAMMA;
+#else
+#define BORDERMULT 14.0
+#define GBAGAMMA 1.0
+#endif
+
+void main() {
+  vec2 texcoordInPixels = fragTexCoord.xy * TextureSize.xy;
+  vec2 centerCoord = floor(texcoordInPixels.xy)+vec2(0.5,0.5);
+  vec2 distFromCenter = abs(centerCoord - texcoordInPixels);
+  vec2 invSize = 1.0/TextureSize.xy;
+
+  float Y = max(distFromCenter.x,(distFromCenter.y));
+
+  Y=Y*Y;
+  float YY = Y*Y;
+  float YYY = YY*Y;
+
+  float LineWeight = YY - 2.7*YYY;
+  LineWeight = 1.0 - BORDERMULT*LineWeight;
+
+  vec3 colour = COMPAT_TEXTURE(Texture, invSize*centerCoord).rgb*LineWeight;
+
+  if (GBAGAMMA > 0.5)
+    colour.rgb*=0.6+0.4*(colour.rgb);
+
+  COMPAT_FRAGCOLOR = vec4(colour.rgb, 1.0);
+}
+`
+
+var glowShader = `
+#pragma parameter GLOW_STRENGTH "Glow Strength" 0.35 0.0 1.0 0.05
+` + header + `
+#ifdef PARAMETER_UNIFORM
+uniform float GLOW_STRENGTH;
+#else
+#define GLOW_STRENGTH 0.35
+#endif
+
+void main() {
+  vec2 dx = vec2(1.0/TextureSize.x, 0.0);
+  vec3 colour = COMPAT_TEXTURE(Texture, fragTexCoord).rgb;
+  vec3 glow = 0.25*COMPAT_TEXTURE(Texture, fragTexCoord - 2.0*dx).rgb
+            + 0.25*COMPAT_TEXTURE(Texture, fragTexCoord - dx).rgb
+            + 0.25*COMPAT_TEXTURE(Texture, fragTexCoord + dx).rgb
+            + 0.25*COMPAT_TEXTURE(Texture, fragTexCoord + 2.0*dx).rgb;
+  COMPAT_FRAGCOLOR = vec4(max(colour, mix(colour, glow, GLOW_STRENGTH)), 1.0);
+}
+`
+
+// builtinSources are the shaders the built-in preset files refer to
+var builtinSources = map[string]string{
+	"crt.glsl":  crtShader,
+	"glow.glsl": glowShader,
+}
+
+// builtinFiles are the multi-pass presets shipped with Ludo, in the format
+// of the .glslp files
+var builtinFiles = map[string]string{
+	"CRT Glow": `
+shaders = 2
+shader0 = glow.glsl
+filter_linear0 = false
+scale_type0 = source
+scale0 = 1.0
+shader1 = crt.glsl
+filter_linear1 = true
+scale_type1 = viewport
+scale1 = 1.0
+`,
+}
+
+// builtins are the single pass presets shipped with Ludo
+var builtins = []Preset{
+	{
+		Name:       "CRT",
+		Passes:     []Pass{{Source: crtShader, Linear: true}},
+		Parameters: parameters(crtShader),
+	},
+	{
+		Name:       "Scanlines",
+		Passes:     []Pass{{Source: scanlinesShader}},
+		Parameters: parameters(scanlinesShader),
+	},
+	{
+		Name:       "LCD Grid",
+		Passes:     []Pass{{Source: lcdShader, Linear: true}},
+		Parameters: parameters(lcdShader),
+	},
+}
@@ -0,0 +1,365 @@
+// Package shaders deals with post-processing shader presets. A preset is a
+// chain of GLSL passes applied to the game image, described by a RetroArch
+// style .glslp file. Shaders can declare parameters with #pragma parameter,
+// their values can be changed at runtime.
+//
+// The selected preset and its parameter values can be saved for a core, or
+// for a specific game of a core, in which case the game config takes
+// precedence. Slang presets need cross compilation to GLSL and are not
+// supported.
+package shaders
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/adrg/xdg"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/utils"
+	"github.com/pelletier/go-toml"
+)
+
+// Parameter is a shader value that can be tweaked by the user
+type Parameter struct {
+	Name    string
+	Label   string
+	Default float32
+	Min     float32
+	Max     float32
+	Step    float32
+}
+
+// Pass is a step of a preset. ScaleType is source, viewport or absolute and
+// tells what Scale is relative to.
+type Pass struct {
+	Source    string
+	Linear    bool
+	ScaleType string
+	Scale     float32
+}
+
+// Preset is a chain of passes
+type Preset struct {
+	Name       string
+	Passes     []Pass
+	Parameters []Parameter
+}
+
+// Presets are the available presets, by name
+var Presets = map[string]Preset{}
+
+func init() {
+	for _, p := range builtins {
+		Presets[p.Name] = p
+	}
+	read := func(file string) ([]byte, error) {
+		src, ok := builtinSources[file]
+		if !ok {
+			return nil, fmt.Errorf("no built-in shader %s", file)
+		}
+		return []byte(src), nil
+	}
+	for name, file := range builtinFiles {
+		if p, err := parse(name, []byte(file), read); err == nil {
+			Presets[name] = p
+		}
+	}
+}
+
+// Names returns the sorted names of the presets
+func Names() []string {
+	var names []string
+	for name := range Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load adds the presets found in the shaders folder of the assets
+func Load() {
+	paths, _ := filepath.Glob(filepath.Join(settings.Current.AssetsDirectory, "shaders", "*.glslp"))
+	for _, path := range paths {
+		p, err := Parse(path)
+		if err != nil {
+			continue
+		}
+		Presets[p.Name] = p
+	}
+}
+
+// Parse reads a .glslp preset file. Shader paths are relative to the preset.
+func Parse(path string) (Preset, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Preset{}, err
+	}
+	dir := filepath.Dir(path)
+	return parse(utils.FileName(path), b, func(name string) ([]byte, error) {
+		return ioutil.ReadFile(filepath.Join(dir, name))
+	})
+}
+
+// parse builds a preset from the content of a .glslp file, using readShader
+// to get the source of the passes
+func parse(name string, b []byte, readShader func(string) ([]byte, error)) (Preset, error) {
+	p := Preset{Name: name}
+	kv := map[string]string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		kv[strings.TrimSpace(parts[0])] = strings.Trim(strings.TrimSpace(parts[1]), `"`)
+	}
+
+	n, err := strconv.Atoi(kv["shaders"])
+	if err != nil || n < 1 {
+		return p, fmt.Errorf("invalid number of shaders in %s", name)
+	}
+
+	for i := 0; i < n; i++ {
+		file, ok := kv[fmt.Sprintf("shader%d", i)]
+		if !ok {
+			return p, fmt.Errorf("missing shader%d in %s", i, name)
+		}
+		src, err := readShader(file)
+		if err != nil {
+			return p, err
+		}
+		pass := Pass{
+			Source:    string(src),
+			Linear:    kv[fmt.Sprintf("filter_linear%d", i)] == "true",
+			ScaleType: kv[fmt.Sprintf("scale_type%d", i)],
+			Scale:     1,
+		}
+		if s, err := strconv.ParseFloat(kv[fmt.Sprintf("scale%d", i)], 32); err == nil {
+			pass.Scale = float32(s)
+		}
+		p.Passes = append(p.Passes, pass)
+		p.Parameters = append(p.Parameters, parameters(pass.Source)...)
+	}
+
+	// The preset can override the default values declared by the shaders
+	for i, param := range p.Parameters {
+		if v, err := strconv.ParseFloat(kv[param.Name], 32); err == nil {
+			p.Parameters[i].Default = float32(v)
+		}
+	}
+
+	return p, nil
+}
+
+// parameters extracts the #pragma parameter declarations of a shader
+func parameters(src string) []Parameter {
+	var params []Parameter
+	for _, line := range strings.Split(src, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "#pragma parameter") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "#pragma parameter"))
+
+		// NAME "Label" default min max step
+		start := strings.Index(line, `"`)
+		end := strings.LastIndex(line, `"`)
+		if start < 0 || end <= start {
+			continue
+		}
+		var p Parameter
+		p.Name = strings.TrimSpace(line[:start])
+		p.Label = line[start+1 : end]
+		var values []float32
+		for _, f := range strings.Fields(line[end+1:]) {
+			v, err := strconv.ParseFloat(f, 32)
+			if err != nil {
+				break
+			}
+			values = append(values, float32(v))
+		}
+		if p.Name == "" || len(values) < 3 {
+			continue
+		}
+		p.Default, p.Min, p.Max = values[0], values[1], values[2]
+		p.Step = (p.Max - p.Min) / 10
+		if len(values) > 3 {
+			p.Step = values[3]
+		}
+		params = append(params, p)
+	}
+	return params
+}
+
+// Stages returns the vertex and fragment sources of a pass. Shaders holding
+// both stages, guarded by VERTEX and FRAGMENT defines, are split. For
+// fragment only shaders, the vertex source is empty. Parameters are turned
+// into uniforms by defining PARAMETER_UNIFORM.
+func Stages(src string) (vertex, fragment string) {
+	defines := func(stage string) string {
+		d := "#define PARAMETER_UNIFORM\n"
+		if stage != "" {
+			d += "#define " + stage + "\n"
+		}
+		// #version has to stay the first directive
+		if strings.HasPrefix(strings.TrimSpace(src), "#version") {
+			s := strings.TrimSpace(src)
+			i := strings.Index(s, "\n")
+			if i < 0 {
+				return s + "\n" + d
+			}
+			return s[:i+1] + d + s[i+1:]
+		}
+		return d + src
+	}
+
+	if strings.Contains(src, "defined(VERTEX)") || strings.Contains(src, "#ifdef VERTEX") {
+		return defines("VERTEX"), defines("FRAGMENT")
+	}
+	return "", defines("")
+}
+
+// OutputSize computes the size of the image produced by a pass, from the
+// size of its input and the size of the viewport
+func (p Pass) OutputSize(inW, inH, vpW, vpH int32) (int32, int32) {
+	scale := p.Scale
+	if scale <= 0 {
+		scale = 1
+	}
+	switch p.ScaleType {
+	case "viewport":
+		return int32(float32(vpW) * scale), int32(float32(vpH) * scale)
+	case "absolute":
+		return int32(scale), int32(scale)
+	default:
+		return int32(float32(inW) * scale), int32(float32(inH) * scale)
+	}
+}
+
+// Config is the preset selected for a game and the values of its parameters
+type Config struct {
+	Preset string             `toml:"preset"`
+	Params map[string]float32 `toml:"parameters"`
+}
+
+// Current is the config in use for the running game. An empty preset means
+// the video filter from the settings is used.
+var Current = Config{Params: map[string]float32{}}
+
+// Selected returns the preset of the current config
+func Selected() (Preset, bool) {
+	p, ok := Presets[Current.Preset]
+	return p, ok
+}
+
+// Value returns the current value of a parameter
+func Value(p Parameter) float32 {
+	if v, ok := Current.Params[p.Name]; ok {
+		return v
+	}
+	return p.Default
+}
+
+// Incr changes the value of a parameter by one step in the given direction
+func Incr(p Parameter, direction int) {
+	v := Value(p) + float32(direction)*p.Step
+	if v < p.Min {
+		v = p.Min
+	}
+	if v > p.Max {
+		v = p.Max
+	}
+	Current.Params[p.Name] = v
+}
+
+// Select sets the preset of the current config and resets the parameters
+func Select(name string) {
+	Current = Config{Preset: name, Params: map[string]float32{}}
+}
+
+// corePath returns the location of the shader config of a core
+func corePath(corePath string) string {
+	name := utils.FileName(corePath)
+	return filepath.Join(xdg.ConfigHome, "ludo", "shaders", name, name+".toml")
+}
+
+// gamePath returns the location of the shader config of a game, identified
+// by its CRC32 checksum
+func gamePath(corePath string, crc uint32) string {
+	name := utils.FileName(corePath)
+	return filepath.Join(xdg.ConfigHome, "ludo", "shaders", name, fmt.Sprintf("%08X.toml", crc))
+}
+
+// LoadConfig sets Current to the config of the game if it exists, or to the
+// config of the core, or to an empty config if none of them exists
+func LoadConfig(core string, crc uint32) {
+	Select("")
+	if c, err := read(gamePath(core, crc)); err == nil {
+		Current = c
+	} else if c, err := read(corePath(core)); err == nil {
+		Current = c
+	}
+}
+
+// SaveCore saves the current config for all the games of a core
+func SaveCore(core string) error {
+	return write(corePath(core), Current)
+}
+
+// SaveGame saves the current config for a specific game
+func SaveGame(core string, crc uint32) error {
+	return write(gamePath(core, crc), Current)
+}
+
+func read(path string) (Config, error) {
+	c := Config{Params: map[string]float32{}}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+
+	err = toml.Unmarshal(b, &c)
+	if c.Params == nil {
+		c.Params = map[string]float32{}
+	}
+	return c, err
+}
+
+func write(path string, c Config) error {
+	b, err := toml.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	fd, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	_, err = io.Copy(fd, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	return fd.Sync()
+}
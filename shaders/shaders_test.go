@@ -0,0 +1,137 @@
+package shaders
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_parse(t *testing.T) {
+	sources := map[string]string{
+		"a.glsl": "#pragma parameter STRENGTH \"Strength\" 0.5 0.0 1.0 0.1\nvoid main() {}\n",
+		"b.glsl": "#pragma parameter GAMMA \"Gamma\" 2.2 1.0 3.0\nvoid main() {}\n",
+	}
+	read := func(name string) ([]byte, error) {
+		src, ok := sources[name]
+		if !ok {
+			return nil, fmt.Errorf("not found: %s", name)
+		}
+		return []byte(src), nil
+	}
+
+	t.Run("Reads the passes and the parameters", func(t *testing.T) {
+		preset := `
+# Two passes
+shaders = 2
+shader0 = a.glsl
+filter_linear0 = true
+scale_type0 = source
+scale0 = 2.0
+shader1 = "b.glsl"
+parameters = "STRENGTH;GAMMA"
+STRENGTH = 0.8
+`
+		got, err := parse("test", []byte(preset), read)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := Preset{
+			Name: "test",
+			Passes: []Pass{
+				{Source: sources["a.glsl"], Linear: true, ScaleType: "source", Scale: 2},
+				{Source: sources["b.glsl"], Scale: 1},
+			},
+			Parameters: []Parameter{
+				{Name: "STRENGTH", Label: "Strength", Default: 0.8, Min: 0, Max: 1, Step: 0.1},
+				{Name: "GAMMA", Label: "Gamma", Default: 2.2, Min: 1, Max: 3, Step: 0.2},
+			},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("parse() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("Fails on missing shaders", func(t *testing.T) {
+		if _, err := parse("test", []byte("shaders = 2\nshader0 = a.glsl\n"), read); err == nil {
+			t.Error("parse() should fail")
+		}
+		if _, err := parse("test", []byte("shader0 = a.glsl\n"), read); err == nil {
+			t.Error("parse() should fail")
+		}
+		if _, err := parse("test", []byte("shaders = 1\nshader0 = c.glsl\n"), read); err == nil {
+			t.Error("parse() should fail")
+		}
+	})
+}
+
+func TestBuiltins(t *testing.T) {
+	for _, name := range []string{"CRT", "CRT Glow", "LCD Grid", "Scanlines"} {
+		if _, ok := Presets[name]; !ok {
+			t.Errorf("missing built-in preset %s", name)
+		}
+	}
+	if p := Presets["CRT Glow"]; len(p.Passes) != 2 || p.Passes[1].ScaleType != "viewport" {
+		t.Errorf("CRT Glow should have two passes, got %+v", p.Passes)
+	}
+}
+
+func TestStages(t *testing.T) {
+	t.Run("Fragment only shaders", func(t *testing.T) {
+		vs, fs := Stages("void main() {}")
+		if vs != "" {
+			t.Errorf("Stages() vertex = %q, want empty", vs)
+		}
+		if fs != "#define PARAMETER_UNIFORM\nvoid main() {}" {
+			t.Errorf("Stages() fragment = %q", fs)
+		}
+	})
+
+	t.Run("Shaders holding both stages keep #version first", func(t *testing.T) {
+		src := "#version 120\n#if defined(VERTEX)\n#elif defined(FRAGMENT)\n#endif\n"
+		vs, fs := Stages(src)
+		if !strings.HasPrefix(vs, "#version 120\n#define PARAMETER_UNIFORM\n#define VERTEX\n") {
+			t.Errorf("Stages() vertex = %q", vs)
+		}
+		if !strings.HasPrefix(fs, "#version 120\n#define PARAMETER_UNIFORM\n#define FRAGMENT\n") {
+			t.Errorf("Stages() fragment = %q", fs)
+		}
+	})
+}
+
+func TestPass_OutputSize(t *testing.T) {
+	tests := []struct {
+		name  string
+		pass  Pass
+		wantW int32
+		wantH int32
+	}{
+		{"Default", Pass{}, 256, 224},
+		{"Source", Pass{ScaleType: "source", Scale: 2}, 512, 448},
+		{"Viewport", Pass{ScaleType: "viewport", Scale: 0.5}, 640, 480},
+		{"Absolute", Pass{ScaleType: "absolute", Scale: 300}, 300, 300},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, h := tt.pass.OutputSize(256, 224, 1280, 960)
+			if w != tt.wantW || h != tt.wantH {
+				t.Errorf("OutputSize() = %v, %v, want %v, %v", w, h, tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestIncr(t *testing.T) {
+	Select("CRT")
+	p := Parameter{Name: "X", Default: 0.5, Min: 0, Max: 1, Step: 0.25}
+	Incr(p, 1)
+	Incr(p, 1)
+	Incr(p, 1)
+	if got := Value(p); got != 1 {
+		t.Errorf("Value() = %v, want %v", got, 1)
+	}
+	Select("")
+	if got := Value(p); got != 0.5 {
+		t.Errorf("Value() = %v, want %v", got, 0.5)
+	}
+}
@@ -0,0 +1,88 @@
+// Package speech reads text aloud with the text to speech of the platform:
+// say on macOS, the speech synthesizer of PowerShell on Windows, and
+// speech-dispatcher or espeak on Linux.
+package speech
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/libretro/ludo/crash"
+)
+
+var (
+	current *exec.Cmd
+	mu      sync.Mutex
+)
+
+// linuxCommands are the text to speech programs tried on Linux, in order
+var linuxCommands = []string{"spd-say", "espeak-ng", "espeak"}
+
+// lookPath finds a program, it is replaced in the tests
+var lookPath = exec.LookPath
+
+// command returns the program reading a text aloud and its arguments
+func command(goos, text string) (string, []string, error) {
+	switch goos {
+	case "darwin":
+		return "say", []string{text}, nil
+	case "windows":
+		quoted := "'" + strings.Replace(text, "'", "''", -1) + "'"
+		return "powershell", []string{"-NoProfile", "-Command",
+			"Add-Type -AssemblyName System.Speech; " +
+				"(New-Object System.Speech.Synthesis.SpeechSynthesizer).Speak(" + quoted + ")"}, nil
+	case "linux":
+		for _, name := range linuxCommands {
+			if _, err := lookPath(name); err == nil {
+				if name == "spd-say" {
+					// Cancel the previous messages of Ludo
+					return name, []string{"-C", "--", text}, nil
+				}
+				return name, []string{"--", text}, nil
+			}
+		}
+		return "", nil, errors.New("no text to speech program found")
+	}
+	return "", nil, errors.New("text to speech is not supported on " + goos)
+}
+
+// Say reads a text aloud, interrupting the previous one
+func Say(text string) error {
+	Stop()
+	name, args, err := command(runtime.GOOS, text)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(name, args...)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	current = cmd
+	mu.Unlock()
+
+	go func() {
+		defer crash.Recover()
+		cmd.Wait()
+		mu.Lock()
+		if current == cmd {
+			current = nil
+		}
+		mu.Unlock()
+	}()
+	return nil
+}
+
+// Stop interrupts the text being read
+func Stop() {
+	mu.Lock()
+	defer mu.Unlock()
+	if current != nil && current.Process != nil {
+		current.Process.Kill()
+	}
+	current = nil
+}
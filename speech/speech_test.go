@@ -0,0 +1,58 @@
+package speech
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_command(t *testing.T) {
+	installed := map[string]bool{}
+	lookPath = func(name string) (string, error) {
+		if installed[name] {
+			return "/usr/bin/" + name, nil
+		}
+		return "", errors.New("not found")
+	}
+
+	tests := []struct {
+		name      string
+		goos      string
+		installed []string
+		wantName  string
+		wantArgs  []string
+		wantErr   bool
+	}{
+		{"macOS", "darwin", nil, "say", []string{"Settings"}, false},
+		{"speech-dispatcher", "linux", []string{"espeak", "spd-say"}, "spd-say", []string{"-C", "--", "Settings"}, false},
+		{"espeak", "linux", []string{"espeak"}, "espeak", []string{"--", "Settings"}, false},
+		{"nothing installed", "linux", nil, "", nil, true},
+		{"unsupported", "plan9", nil, "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installed = map[string]bool{}
+			for _, n := range tt.installed {
+				installed[n] = true
+			}
+			name, args, err := command(tt.goos, "Settings")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if name != tt.wantName || !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("got %v %v, want %v %v", name, args, tt.wantName, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func Test_command_windows(t *testing.T) {
+	name, args, err := command("windows", "Don't Starve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Add-Type -AssemblyName System.Speech; (New-Object System.Speech.Synthesis.SpeechSynthesizer).Speak('Don''t Starve')"
+	if name != "powershell" || args[len(args)-1] != want {
+		t.Errorf("got %v %v", name, args)
+	}
+}
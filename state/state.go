@@ -25,6 +25,9 @@ var CorePath string
 // GamePath is the path of the current game
 var GamePath string
 
+// GameCRC is the CRC32 checksum of the current game
+var GameCRC uint32
+
 // DB is the game database loaded on startup
 var DB dat.DB
 
@@ -33,3 +36,13 @@ var LudOS bool
 
 // FastForward will run the core as fast as possible
 var FastForward bool
+
+// Paused stops running the core while the game stays displayed
+var Paused bool
+
+// FrameAdvance runs a single frame of the core while it is paused
+var FrameAdvance bool
+
+// GameFocus sends the whole keyboard to the core and suspends the keyboard
+// hot keys
+var GameFocus bool
@@ -0,0 +1,233 @@
+// Package stats keeps the play statistics of the games: their sessions, their
+// play time, the last time they were played and the achievements earned. The
+// games are identified by their path, like in the playlists.
+package stats
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adrg/xdg"
+)
+
+// Game is the statistics of a game
+type Game struct {
+	Sessions     int
+	Seconds      float64
+	LastPlayed   time.Time
+	Achievements int
+}
+
+// Total is the statistics of the whole library
+type Total struct {
+	Games        int
+	Sessions     int
+	Seconds      float64
+	Achievements int
+}
+
+var (
+	mu      sync.Mutex
+	games   = map[string]Game{}
+	current string
+)
+
+// statsPath is the location of the statistics file
+func statsPath() string {
+	return filepath.Join(xdg.DataHome, "ludo", "stats.csv")
+}
+
+// load reads a statistics file, a CSV file with a line per game
+func load(path string) (map[string]Game, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r := csv.NewReader(bufio.NewReader(file))
+	r.FieldsPerRecord = 5
+	m := map[string]Game{}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		var g Game
+		g.Sessions, _ = strconv.Atoi(record[1])
+		g.Seconds, _ = strconv.ParseFloat(record[2], 64)
+		g.LastPlayed, _ = time.Parse(time.RFC3339, record[3])
+		g.Achievements, _ = strconv.Atoi(record[4])
+		m[record[0]] = g
+	}
+	return m, nil
+}
+
+// save writes a statistics file
+func save(path string, m map[string]Game) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var paths []string
+	for p := range m {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	w := csv.NewWriter(bufio.NewWriter(file))
+	for _, p := range paths {
+		g := m[p]
+		w.Write([]string{
+			p,
+			strconv.Itoa(g.Sessions),
+			strconv.FormatFloat(g.Seconds, 'f', 1, 64),
+			g.LastPlayed.Format(time.RFC3339),
+			strconv.Itoa(g.Achievements),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// Load reads the statistics of the games. There are none before the first
+// game is played.
+func Load() error {
+	m, err := load(statsPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	games = m
+	mu.Unlock()
+	return nil
+}
+
+// Start begins a session of a game
+func Start(path string) {
+	mu.Lock()
+	defer mu.Unlock()
+	g := games[path]
+	g.Sessions++
+	g.LastPlayed = time.Now()
+	games[path] = g
+	current = path
+}
+
+// Tick counts the play time of the running game, it is called for each
+// frame run by the core
+func Tick(dt float32) {
+	mu.Lock()
+	defer mu.Unlock()
+	if current == "" {
+		return
+	}
+	g := games[current]
+	g.Seconds += float64(dt)
+	games[current] = g
+}
+
+// Earned records the number of achievements unlocked in the running game
+func Earned(n int) {
+	mu.Lock()
+	defer mu.Unlock()
+	if current == "" {
+		return
+	}
+	g := games[current]
+	if n > g.Achievements {
+		g.Achievements = n
+		games[current] = g
+	}
+}
+
+// Stop ends the session of the running game and saves the statistics
+func Stop() error {
+	mu.Lock()
+	defer mu.Unlock()
+	if current == "" {
+		return nil
+	}
+	current = ""
+	return save(statsPath(), games)
+}
+
+// Save saves the statistics during a session, so that a crash doesn't lose
+// the play time
+func Save() error {
+	mu.Lock()
+	defer mu.Unlock()
+	if current == "" {
+		return nil
+	}
+	return save(statsPath(), games)
+}
+
+// Get returns the statistics of a game
+func Get(path string) Game {
+	mu.Lock()
+	defer mu.Unlock()
+	return games[path]
+}
+
+// Totals sums the statistics of all the games
+func Totals() Total {
+	mu.Lock()
+	defer mu.Unlock()
+	var t Total
+	for _, g := range games {
+		t.Games++
+		t.Sessions += g.Sessions
+		t.Seconds += g.Seconds
+		t.Achievements += g.Achievements
+	}
+	return t
+}
+
+// MostPlayed sorts paths of games by play time, the most played first. The
+// games never played keep their order.
+func MostPlayed(paths []string) {
+	mu.Lock()
+	defer mu.Unlock()
+	sort.SliceStable(paths, func(i, j int) bool {
+		return games[paths[i]].Seconds > games[paths[j]].Seconds
+	})
+}
+
+// LastPlayed sorts paths of games by the last time they were played, the
+// most recent first
+func LastPlayed(paths []string) {
+	mu.Lock()
+	defer mu.Unlock()
+	sort.SliceStable(paths, func(i, j int) bool {
+		return games[paths[i]].LastPlayed.After(games[paths[j]].LastPlayed)
+	})
+}
+
+// Playtime formats a play time, like 2h 05m
+func Playtime(seconds float64) string {
+	m := int(seconds / 60)
+	if m < 60 {
+		return fmt.Sprintf("%dm", m)
+	}
+	return fmt.Sprintf("%dh %02dm", m/60, m%60)
+}
@@ -0,0 +1,118 @@
+package stats
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/adrg/xdg"
+)
+
+func Test_saveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	played := time.Date(2020, 1, 2, 10, 0, 0, 0, time.UTC)
+	want := map[string]Game{
+		"/roms/Nintendo - Game Boy/Tetris (World).gb": {Sessions: 2, Seconds: 90.5, LastPlayed: played, Achievements: 3},
+		`C:\roms\Sonic.md`: {Sessions: 1, Seconds: 10, LastPlayed: played},
+	}
+	path := filepath.Join(dir, "stats.csv")
+	if err := save(path, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, g := range got {
+		g.LastPlayed = g.LastPlayed.UTC()
+		got[k] = g
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("load() = %v, want %v", got, want)
+	}
+}
+
+func TestSession(t *testing.T) {
+	games = map[string]Game{"b.gb": {Sessions: 1, Seconds: 30}}
+	defer func() { games, current = map[string]Game{}, "" }()
+
+	Start("a.gb")
+	Tick(60)
+	Tick(0.5)
+	Earned(2)
+	Earned(1)
+	current = ""
+	Tick(10)
+
+	g := Get("a.gb")
+	if g.Sessions != 1 || g.Seconds != 60.5 || g.Achievements != 2 || g.LastPlayed.IsZero() {
+		t.Errorf("Get() = %+v", g)
+	}
+	if got, want := Totals(), (Total{Games: 2, Sessions: 2, Seconds: 90.5, Achievements: 2}); got != want {
+		t.Errorf("Totals() = %+v, want %+v", got, want)
+	}
+
+	paths := []string{"c.gb", "b.gb", "a.gb"}
+	MostPlayed(paths)
+	if want := []string{"a.gb", "b.gb", "c.gb"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("MostPlayed() = %v, want %v", paths, want)
+	}
+	paths = []string{"b.gb", "c.gb", "a.gb"}
+	LastPlayed(paths)
+	if want := []string{"a.gb", "b.gb", "c.gb"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("LastPlayed() = %v, want %v", paths, want)
+	}
+}
+
+func TestPlaytime(t *testing.T) {
+	tests := []struct {
+		seconds float64
+		want    string
+	}{
+		{0, "0m"},
+		{125, "2m"},
+		{3600, "1h 00m"},
+		{7500, "2h 05m"},
+	}
+	for _, tt := range tests {
+		if got := Playtime(tt.seconds); got != tt.want {
+			t.Errorf("Playtime(%v) = %v, want %v", tt.seconds, got, tt.want)
+		}
+	}
+}
+
+func TestSave(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(home string) { xdg.DataHome = home }(xdg.DataHome)
+	xdg.DataHome = dir
+	defer func() { games, current = map[string]Game{}, "" }()
+
+	if err := Load(); err != nil {
+		t.Errorf("Load() without statistics = %v", err)
+	}
+
+	Start("a.gb")
+	Tick(12)
+	if err := Save(); err != nil {
+		t.Fatal(err)
+	}
+	m, err := load(statsPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m["a.gb"].Seconds != 12 {
+		t.Errorf("saved = %+v, want the running session", m["a.gb"])
+	}
+}
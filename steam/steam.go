@@ -0,0 +1,214 @@
+// Package steam adds games to the library of Steam as non-Steam shortcuts,
+// with their artwork, so that they can be launched from Big Picture.
+package steam
+
+import (
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/thumbnails"
+	"github.com/libretro/ludo/utils"
+)
+
+// Tag is the collection the exported games are listed in
+const Tag = "Ludo"
+
+// Shortcut is a game launched by Steam. Grid and Portrait are the paths of
+// its artwork, empty if there is none.
+type Shortcut struct {
+	AppName       string
+	Exe           string
+	StartDir      string
+	LaunchOptions string
+	Grid          string
+	Portrait      string
+}
+
+func quote(s string) string {
+	return `"` + s + `"`
+}
+
+// NewShortcut creates the shortcut of a game of a playlist, launched
+// fullscreen by the given executable of Ludo. The artwork comes from the
+// thumbnails cache of the menu, renamed games are matched by their normalized
+// name.
+func NewShortcut(exe, system, gamePath, name string) Shortcut {
+	s := Shortcut{
+		AppName:       name,
+		Exe:           quote(exe),
+		StartDir:      quote(filepath.Dir(exe)),
+		LaunchOptions: "--fullscreen " + quote(gamePath),
+	}
+	legalName := utils.ScrubIllegalChars(name) + ".png"
+	dir := filepath.Join(settings.Current.ThumbnailsDirectory, system)
+	if p := filepath.Join(dir, "Named_Snaps", legalName); utils.Exists(p) {
+		s.Grid = p
+	} else {
+		s.Grid = thumbnails.Match(filepath.Join(dir, "Named_Snaps"), name)
+	}
+	if p := filepath.Join(dir, "Named_Boxarts", legalName); utils.Exists(p) {
+		s.Portrait = p
+	} else {
+		s.Portrait = thumbnails.Match(filepath.Join(dir, "Named_Boxarts"), name)
+	}
+	return s
+}
+
+// AppID computes the id Steam gives to a shortcut, it names the artwork of
+// the shortcut
+func (s Shortcut) AppID() uint32 {
+	return crc32.ChecksumIEEE([]byte(s.Exe+s.AppName)) | 0x80000000
+}
+
+func (s Shortcut) node(key string) *Node {
+	str := func(k, v string) *Node {
+		return &Node{Key: k, Type: typeString, String: v}
+	}
+	num := func(k string, v uint32) *Node {
+		return &Node{Key: k, Type: typeInt, Int: v}
+	}
+	return &Node{Key: key, Type: typeMap, Children: []*Node{
+		num("appid", s.AppID()),
+		str("AppName", s.AppName),
+		str("Exe", s.Exe),
+		str("StartDir", s.StartDir),
+		str("icon", ""),
+		str("ShortcutPath", ""),
+		str("LaunchOptions", s.LaunchOptions),
+		num("IsHidden", 0),
+		num("AllowDesktopConfig", 1),
+		num("AllowOverlay", 1),
+		num("OpenVR", 0),
+		num("Devkit", 0),
+		str("DevkitGameID", ""),
+		num("DevkitOverrideAppID", 0),
+		num("LastPlayTime", 0),
+		str("FlatpakAppID", ""),
+		{Key: "tags", Type: typeMap, Children: []*Node{str("0", Tag)}},
+	}}
+}
+
+// Merge adds shortcuts to the content of a shortcuts.vdf file. The shortcuts
+// already exported are replaced, the others are kept.
+func Merge(root *Node, shortcuts []Shortcut) {
+	list := root.Get("shortcuts")
+	if list == nil {
+		list = &Node{Key: "shortcuts", Type: typeMap}
+		root.Children = append(root.Children, list)
+	}
+
+	ids := map[uint32]bool{}
+	for _, s := range shortcuts {
+		ids[s.AppID()] = true
+	}
+	var kept []*Node
+	for _, n := range list.Children {
+		id := Shortcut{Exe: n.Str("Exe"), AppName: n.Str("AppName")}.AppID()
+		if !ids[id] {
+			kept = append(kept, n)
+		}
+	}
+	for _, s := range shortcuts {
+		kept = append(kept, s.node(""))
+	}
+	for i, n := range kept {
+		n.Key = strconv.Itoa(i)
+	}
+	list.Children = kept
+}
+
+// Dir finds the installation directory of Steam, it returns an empty string
+// if Steam isn't installed
+func Dir() string {
+	home, _ := os.UserHomeDir()
+	var dirs []string
+	switch runtime.GOOS {
+	case "linux":
+		dirs = []string{
+			filepath.Join(home, ".local", "share", "Steam"),
+			filepath.Join(home, ".steam", "steam"),
+			filepath.Join(home, ".var", "app", "com.valvesoftware.Steam", ".local", "share", "Steam"),
+		}
+	case "darwin":
+		dirs = []string{filepath.Join(home, "Library", "Application Support", "Steam")}
+	case "windows":
+		dirs = []string{filepath.Join(os.Getenv("ProgramFiles(x86)"), "Steam")}
+	}
+	for _, d := range dirs {
+		if utils.Exists(filepath.Join(d, "userdata")) {
+			return d
+		}
+	}
+	return ""
+}
+
+// UserDirs lists the data directories of the Steam accounts logged in on
+// this computer
+func UserDirs(steamDir string) []string {
+	paths, _ := filepath.Glob(filepath.Join(steamDir, "userdata", "*"))
+	var dirs []string
+	for _, p := range paths {
+		if id, err := strconv.Atoi(filepath.Base(p)); err == nil && id > 0 {
+			dirs = append(dirs, p)
+		}
+	}
+	return dirs
+}
+
+// Export adds shortcuts to the library of a Steam account, with their
+// artwork. Steam has to be restarted to list them, and overwrites the
+// shortcuts if it is running.
+func Export(userDir string, shortcuts []Shortcut) error {
+	config := filepath.Join(userDir, "config")
+	grid := filepath.Join(config, "grid")
+	if err := os.MkdirAll(grid, os.ModePerm); err != nil {
+		return err
+	}
+
+	path := filepath.Join(config, "shortcuts.vdf")
+	root := &Node{Type: typeMap}
+	if f, err := os.Open(path); err == nil {
+		root, err = Decode(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	Merge(root, shortcuts)
+
+	tmp, err := ioutil.TempFile(config, "shortcuts")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := Encode(tmp, root); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	for _, s := range shortcuts {
+		id := strconv.FormatUint(uint64(s.AppID()), 10)
+		if s.Grid != "" {
+			if err := utils.CopyFile(s.Grid, filepath.Join(grid, id+".png")); err != nil {
+				return err
+			}
+		}
+		if s.Portrait != "" {
+			if err := utils.CopyFile(s.Portrait, filepath.Join(grid, id+"p.png")); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package steam
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	root := &Node{Type: typeMap}
+	Merge(root, []Shortcut{{AppName: "Tetris", Exe: `"/usr/bin/ludo"`, LaunchOptions: `--fullscreen "/roms/Tetris.gb"`}})
+
+	var b bytes.Buffer
+	if err := Encode(&b, root); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decode(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, root) {
+		t.Errorf("got = %v, want %v", got, root)
+	}
+
+	if _, err := Decode(bytes.NewReader([]byte{typeMap, 's'})); err != ErrFormat {
+		t.Errorf("got = %v, want %v", err, ErrFormat)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	other := Shortcut{AppName: "Other", Exe: `"/usr/bin/other"`}
+	tetris := Shortcut{AppName: "Tetris", Exe: `"/usr/bin/ludo"`}
+	root := &Node{Type: typeMap}
+	Merge(root, []Shortcut{other, tetris})
+
+	tetris.LaunchOptions = `--fullscreen "/roms/Tetris.gb"`
+	Merge(root, []Shortcut{tetris})
+
+	list := root.Get("shortcuts").Children
+	if len(list) != 2 {
+		t.Fatalf("got %d shortcuts, want 2", len(list))
+	}
+	if list[0].Key != "0" || list[0].Str("AppName") != "Other" {
+		t.Errorf("got = %v, want the other shortcut first", list[0].Str("AppName"))
+	}
+	if list[1].Key != "1" || list[1].Str("LaunchOptions") != tetris.LaunchOptions {
+		t.Errorf("got = %v, want %v", list[1].Str("LaunchOptions"), tetris.LaunchOptions)
+	}
+	if id := list[1].Get("appid").Int; id != tetris.AppID() || id&0x80000000 == 0 {
+		t.Errorf("got = %x, want %x", id, tetris.AppID())
+	}
+}
+
+func TestExport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "steam")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	art := filepath.Join(dir, "snap.png")
+	ioutil.WriteFile(art, []byte("png"), 0644)
+	s := Shortcut{AppName: "Tetris", Exe: `"/usr/bin/ludo"`, Grid: art}
+	user := filepath.Join(dir, "userdata", "1234")
+	if err := Export(user, []Shortcut{s}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Export(user, []Shortcut{s}); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(filepath.Join(user, "config", "shortcuts.vdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	root, err := Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(root.Get("shortcuts").Children); n != 1 {
+		t.Errorf("got %d shortcuts, want 1", n)
+	}
+	if _, err := os.Stat(filepath.Join(user, "config", "grid", "3691067717.png")); err != nil {
+		t.Errorf("grid artwork not copied: %v", err)
+	}
+	if got := UserDirs(dir); !reflect.DeepEqual(got, []string{user}) {
+		t.Errorf("got = %v, want %v", got, []string{user})
+	}
+}
@@ -0,0 +1,127 @@
+package steam
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Types of the values of a binary VDF file
+const (
+	typeMap    byte = 0x00
+	typeString byte = 0x01
+	typeInt    byte = 0x02
+	typeEnd    byte = 0x08
+)
+
+// ErrFormat is returned when a VDF file can't be parsed
+var ErrFormat = errors.New("invalid vdf file")
+
+// Node is a value of a binary VDF file, like shortcuts.vdf. Maps keep the
+// order of their children.
+type Node struct {
+	Key      string
+	Type     byte
+	String   string
+	Int      uint32
+	Children []*Node
+}
+
+// Get returns the child of a map with the given key, or nil
+func (n *Node) Get(key string) *Node {
+	for _, c := range n.Children {
+		if c.Key == key {
+			return c
+		}
+	}
+	return nil
+}
+
+// Str returns the string value of a child, or an empty string
+func (n *Node) Str(key string) string {
+	if c := n.Get(key); c != nil && c.Type == typeString {
+		return c.String
+	}
+	return ""
+}
+
+// readString reads a string terminated by a NUL byte
+func readString(r *bufio.Reader) (string, error) {
+	s, err := r.ReadString(0)
+	if err != nil {
+		return "", ErrFormat
+	}
+	return s[:len(s)-1], nil
+}
+
+// decodeChildren reads the values of a map until its end marker
+func decodeChildren(r *bufio.Reader) ([]*Node, error) {
+	var children []*Node
+	for {
+		t, err := r.ReadByte()
+		if err != nil {
+			return nil, ErrFormat
+		}
+		if t == typeEnd {
+			return children, nil
+		}
+		key, err := readString(r)
+		if err != nil {
+			return nil, err
+		}
+		n := &Node{Key: key, Type: t}
+		switch t {
+		case typeMap:
+			if n.Children, err = decodeChildren(r); err != nil {
+				return nil, err
+			}
+		case typeString:
+			if n.String, err = readString(r); err != nil {
+				return nil, err
+			}
+		case typeInt:
+			if err := binary.Read(r, binary.LittleEndian, &n.Int); err != nil {
+				return nil, ErrFormat
+			}
+		default:
+			return nil, ErrFormat
+		}
+		children = append(children, n)
+	}
+}
+
+// Decode reads a binary VDF file, the returned map holds its top level
+// values
+func Decode(r io.Reader) (*Node, error) {
+	children, err := decodeChildren(bufio.NewReader(r))
+	if err != nil {
+		return nil, err
+	}
+	return &Node{Type: typeMap, Children: children}, nil
+}
+
+func encodeChildren(w *bufio.Writer, children []*Node) {
+	for _, n := range children {
+		w.WriteByte(n.Type)
+		w.WriteString(n.Key)
+		w.WriteByte(0)
+		switch n.Type {
+		case typeMap:
+			encodeChildren(w, n.Children)
+		case typeString:
+			w.WriteString(n.String)
+			w.WriteByte(0)
+		case typeInt:
+			binary.Write(w, binary.LittleEndian, n.Int)
+		}
+	}
+	w.WriteByte(typeEnd)
+}
+
+// Encode writes the values of a map as a binary VDF file
+func Encode(w io.Writer, root *Node) error {
+	bw := bufio.NewWriter(w)
+	encodeChildren(bw, root.Children)
+	return bw.Flush()
+}
@@ -0,0 +1,202 @@
+// Package themes customizes the look of the menu: its colors, background
+// image, font and icons. Besides the built-in light, dark and high contrast
+// themes, themes are the folders of the themes directory holding a
+// theme.toml file. The PNG files of a theme folder replace the icons of the
+// assets having the same name.
+package themes
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/video"
+	"github.com/pelletier/go-toml"
+)
+
+// Theme is the look of the menu. Dir is the folder of the theme, empty for
+// the built-in themes.
+type Theme struct {
+	Name       string
+	Dir        string
+	Background video.Color
+	Cursor     video.Color
+	Text       video.Color
+	Image      string // background image, if any
+	Font       string // font file, the font of the assets is used if empty
+}
+
+// Light is the default theme
+var Light = Theme{
+	Name:       "Light",
+	Background: video.Color{R: 1, G: 1, B: 1, A: 1},
+	Cursor:     video.Color{R: 0.8784, G: 1, B: 1, A: 1},
+	Text:       video.Color{R: 0, G: 0, B: 0, A: 1},
+}
+
+// Dark is the built-in dark theme, also used by the light theme while a game
+// is running
+var Dark = Theme{
+	Name:       "Dark",
+	Background: video.Color{R: 0.1, G: 0.1, B: 0.1, A: 1},
+	Cursor:     video.Color{R: 0.1, G: 0.1, B: 0.4, A: 1},
+	Text:       video.Color{R: 1, G: 1, B: 1, A: 1},
+}
+
+// file is the theme.toml file of a theme, the colors being in the #RRGGBB
+// format and the files relative to the folder of the theme
+type file struct {
+	Name       string `toml:"name"`
+	Background string `toml:"background"`
+	Cursor     string `toml:"cursor"`
+	Text       string `toml:"text"`
+	Image      string `toml:"background_image"`
+	Font       string `toml:"font"`
+}
+
+// HighContrast is a built-in theme for low vision users
+var HighContrast = Theme{
+	Name:       "High Contrast",
+	Background: video.Color{R: 0, G: 0, B: 0, A: 1},
+	Cursor:     video.Color{R: 0, G: 0, B: 0.8, A: 1},
+	Text:       video.Color{R: 1, G: 1, B: 0, A: 1},
+}
+
+// builtins are the themes that don't need a theme folder
+var builtins = []Theme{Light, Dark, HighContrast}
+
+var themes = builtins
+
+// parseColor reads a color like #1E90FF, or an empty string as the fallback
+func parseColor(s string, fallback video.Color) (video.Color, error) {
+	if s == "" {
+		return fallback, nil
+	}
+	s = strings.TrimPrefix(s, "#")
+	if len(s) != 6 {
+		return video.Color{}, fmt.Errorf("invalid color %q", s)
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return video.Color{}, fmt.Errorf("invalid color %q", s)
+	}
+	return video.Color{
+		R: float32(v>>16&0xff) / 255,
+		G: float32(v>>8&0xff) / 255,
+		B: float32(v&0xff) / 255,
+		A: 1,
+	}, nil
+}
+
+// parse reads the theme.toml file of a theme folder. The colors missing
+// from the file are the ones of the light theme.
+func parse(dir string, b []byte) (Theme, error) {
+	var f file
+	if err := toml.Unmarshal(b, &f); err != nil {
+		return Theme{}, err
+	}
+	t := Theme{Name: f.Name, Dir: dir}
+	if t.Name == "" {
+		t.Name = filepath.Base(dir)
+	}
+
+	var err error
+	if t.Background, err = parseColor(f.Background, Light.Background); err != nil {
+		return Theme{}, err
+	}
+	if t.Cursor, err = parseColor(f.Cursor, Light.Cursor); err != nil {
+		return Theme{}, err
+	}
+	if t.Text, err = parseColor(f.Text, Light.Text); err != nil {
+		return Theme{}, err
+	}
+
+	if f.Image != "" {
+		t.Image = filepath.Join(dir, f.Image)
+	}
+	if f.Font != "" {
+		t.Font = filepath.Join(dir, f.Font)
+	}
+	return t, nil
+}
+
+// Load lists the themes of a directory after the built-in ones. Themes that
+// can't be read are skipped.
+func Load(dir string) error {
+	list := append([]Theme{}, builtins...)
+	folders, err := ioutil.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, f := range folders {
+		if !f.IsDir() {
+			continue
+		}
+		p := filepath.Join(dir, f.Name())
+		b, err := ioutil.ReadFile(filepath.Join(p, "theme.toml"))
+		if err != nil {
+			continue
+		}
+		t, err := parse(p, b)
+		if err != nil {
+			continue
+		}
+		list = append(list, t)
+	}
+	custom := list[len(builtins):]
+	sort.SliceStable(custom, func(i, j int) bool {
+		return custom[i].Name < custom[j].Name
+	})
+	themes = list
+	return nil
+}
+
+// Names returns the names of the available themes
+func Names() []string {
+	var names []string
+	for _, t := range themes {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// Find returns the theme having a name
+func Find(name string) (Theme, error) {
+	for _, t := range themes {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	return Theme{}, errors.New("theme not found: " + name)
+}
+
+// Current returns the theme chosen in the settings, or the light theme if it
+// doesn't exist anymore
+func Current() Theme {
+	t, err := Find(settings.Current.MenuTheme)
+	if err != nil {
+		return Light
+	}
+	return t
+}
+
+// Icons returns the PNG files of a theme replacing the icons of the assets
+func (t Theme) Icons() []string {
+	if t.Dir == "" {
+		return nil
+	}
+	paths, _ := filepath.Glob(filepath.Join(t.Dir, "*.png"))
+	var icons []string
+	for _, p := range paths {
+		if p != t.Image {
+			icons = append(icons, p)
+		}
+	}
+	return icons
+}
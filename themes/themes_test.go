@@ -0,0 +1,95 @@
+package themes
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/video"
+)
+
+func Test_parseColor(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    video.Color
+		wantErr bool
+	}{
+		{"#FF0000", video.Color{R: 1, G: 0, B: 0, A: 1}, false},
+		{"0000ff", video.Color{R: 0, G: 0, B: 1, A: 1}, false},
+		{"", Light.Text, false},
+		{"#FFF", video.Color{}, true},
+		{"#GGGGGG", video.Color{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := parseColor(tt.s, Light.Text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ludo-themes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func() { themes = builtins }()
+
+	ocean := filepath.Join(dir, "ocean")
+	os.MkdirAll(ocean, os.ModePerm)
+	ioutil.WriteFile(filepath.Join(ocean, "theme.toml"), []byte(`name = "Ocean"
+background = "#000080"
+text = "#FFFFFF"
+background_image = "waves.png"
+font = "font.ttf"
+`), 0644)
+	ioutil.WriteFile(filepath.Join(ocean, "waves.png"), nil, 0644)
+	ioutil.WriteFile(filepath.Join(ocean, "setting.png"), nil, 0644)
+
+	broken := filepath.Join(dir, "broken")
+	os.MkdirAll(broken, os.ModePerm)
+	ioutil.WriteFile(filepath.Join(broken, "theme.toml"), []byte(`text = "#12"`), 0644)
+
+	os.MkdirAll(filepath.Join(dir, "empty"), os.ModePerm)
+
+	if err := Load(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := Names(), []string{"Light", "Dark", "High Contrast", "Ocean"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	settings.Current.MenuTheme = "Ocean"
+	defer func() { settings.Current.MenuTheme = "" }()
+	got := Current()
+	want := Theme{
+		Name:       "Ocean",
+		Dir:        ocean,
+		Background: video.Color{R: 0, G: 0, B: float32(0x80) / 255, A: 1},
+		Cursor:     Light.Cursor,
+		Text:       video.Color{R: 1, G: 1, B: 1, A: 1},
+		Image:      filepath.Join(ocean, "waves.png"),
+		Font:       filepath.Join(ocean, "font.ttf"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if icons := got.Icons(); !reflect.DeepEqual(icons, []string{filepath.Join(ocean, "setting.png")}) {
+		t.Errorf("got icons %v", icons)
+	}
+
+	settings.Current.MenuTheme = "Removed"
+	if got := Current(); got.Name != Light.Name {
+		t.Errorf("got %v, want the light theme", got.Name)
+	}
+}
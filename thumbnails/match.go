@@ -0,0 +1,88 @@
+package thumbnails
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+var tags = regexp.MustCompile(`\([^)]*\)|\[[^\]]*\]`)
+
+// Normalize reduces a game name to what is left when renaming a file: the
+// tags in parentheses or brackets, the case, the punctuation and the article
+// "the", that dats move after a comma, are dropped. "Legend of Zelda, The
+// (USA) [!]" and "the_legend_of_zelda" are the same.
+func Normalize(name string) string {
+	name = strings.ToLower(tags.ReplaceAllString(name, " "))
+	name = strings.NewReplacer("&", " and ", "_", " ", "-", " ", ".", " ").Replace(name)
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var kept []string
+	for _, w := range words {
+		if w != "the" {
+			kept = append(kept, w)
+		}
+	}
+	return strings.Join(kept, "")
+}
+
+// index maps the normalized names of the images of a folder to their paths
+type index struct {
+	modTime time.Time
+	paths   map[string]string
+}
+
+var indexes = struct {
+	sync.Mutex
+	dirs map[string]index
+}{dirs: map[string]index{}}
+
+// Match returns the image of a folder whose normalized name is the one of
+// name, or an empty string. The listing of the folder is kept until the
+// folder changes.
+func Match(dir, name string) string {
+	key := Normalize(name)
+	if key == "" {
+		return ""
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return ""
+	}
+
+	indexes.Lock()
+	defer indexes.Unlock()
+	idx, ok := indexes.dirs[dir]
+	if !ok || !idx.modTime.Equal(info.ModTime()) {
+		idx = index{info.ModTime(), map[string]string{}}
+		files, _ := ioutil.ReadDir(dir)
+		var names []string
+		for _, f := range files {
+			if !f.IsDir() && strings.EqualFold(filepath.Ext(f.Name()), ".png") {
+				names = append(names, f.Name())
+			}
+		}
+		// The shortest name wins, like "Game (USA)" over "Game (USA) (Rev 1)"
+		sort.Slice(names, func(i, j int) bool {
+			if len(names[i]) != len(names[j]) {
+				return len(names[i]) < len(names[j])
+			}
+			return names[i] < names[j]
+		})
+		for _, n := range names {
+			k := Normalize(strings.TrimSuffix(n, filepath.Ext(n)))
+			if _, ok := idx.paths[k]; !ok {
+				idx.paths[k] = filepath.Join(dir, n)
+			}
+		}
+		indexes.dirs[dir] = idx
+	}
+	return idx.paths[key]
+}
@@ -0,0 +1,174 @@
+// Package thumbnails manages the cache of the downloaded thumbnails. The
+// cache is split in a folder per system, its least recently used images are
+// evicted when it grows past a maximum size. Images found in a local artwork
+// directory take precedence over the downloaded ones.
+package thumbnails
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// mu serializes the evictions and the purges
+var mu sync.Mutex
+
+// SystemSize is the size of the cached thumbnails of a system
+type SystemSize struct {
+	System string
+	Files  int
+	Size   int64
+}
+
+// image is a cached thumbnail
+type image struct {
+	system string
+	path   string
+	size   int64
+	used   time.Time
+}
+
+// images lists the cached thumbnails of a system, or of all the systems if
+// system is empty. Only the images of the cache, like
+// system/Named_Snaps/name.png, are listed, so that a cache directory pointed
+// to the wrong place doesn't lose other files.
+func images(dir, system string) ([]image, error) {
+	systems := []string{system}
+	if system == "" {
+		dirs, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		systems = nil
+		for _, d := range dirs {
+			if d.IsDir() {
+				systems = append(systems, d.Name())
+			}
+		}
+	}
+	var list []image
+	for _, sys := range systems {
+		kinds, _ := ioutil.ReadDir(filepath.Join(dir, sys))
+		for _, kind := range kinds {
+			if !kind.IsDir() || !strings.HasPrefix(kind.Name(), "Named_") {
+				continue
+			}
+			folder := filepath.Join(dir, sys, kind.Name())
+			files, _ := ioutil.ReadDir(folder)
+			for _, f := range files {
+				if f.IsDir() || !strings.EqualFold(filepath.Ext(f.Name()), ".png") {
+					continue
+				}
+				list = append(list, image{sys, filepath.Join(folder, f.Name()), f.Size(), f.ModTime()})
+			}
+		}
+	}
+	return list, nil
+}
+
+// Sizes returns the size of the cache of each system, sorted by system. The
+// systems without thumbnails are left out.
+func Sizes(dir string) ([]SystemSize, error) {
+	list, err := images(dir, "")
+	if err != nil {
+		return nil, err
+	}
+	bySystem := map[string]*SystemSize{}
+	var sizes []SystemSize
+	for _, img := range list {
+		s, ok := bySystem[img.system]
+		if !ok {
+			s = &SystemSize{System: img.system}
+			bySystem[img.system] = s
+		}
+		s.Files++
+		s.Size += img.size
+	}
+	for _, s := range bySystem {
+		sizes = append(sizes, *s)
+	}
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i].System < sizes[j].System })
+	return sizes, nil
+}
+
+// Purge removes the cached thumbnails of a system, or of all the systems if
+// system is empty. The folders left empty are removed too.
+func Purge(dir, system string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	list, err := images(dir, system)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, img := range list {
+		if err := os.Remove(img.path); err != nil {
+			return err
+		}
+		// Remove fails on the folders that aren't empty
+		os.Remove(filepath.Dir(img.path))
+		os.Remove(filepath.Join(dir, img.system))
+	}
+	return nil
+}
+
+// Touch marks a thumbnail as used, the least recently used ones are evicted
+// first
+func Touch(path string) {
+	now := time.Now()
+	os.Chtimes(path, now, now)
+}
+
+// Evict removes the least recently used thumbnails until the cache fits in
+// max bytes. A max of 0 means no limit. It returns the count of removed files.
+func Evict(dir string, max int64) (int, error) {
+	if max <= 0 {
+		return 0, nil
+	}
+	mu.Lock()
+	defer mu.Unlock()
+
+	files, err := images(dir, "")
+	if err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].used.Before(files[j].used) })
+	removed := 0
+	for _, f := range files {
+		if total <= max {
+			break
+		}
+		if err := os.Remove(f.path); err != nil {
+			return removed, err
+		}
+		total -= f.size
+		removed++
+	}
+	return removed, nil
+}
+
+// Local returns the image of a game in the local artwork directory, or an
+// empty string. The images can be sorted in the folders of the thumbnails,
+// like system/Named_Snaps/name.png, or directly in the folder of the system.
+func Local(artworkDir, system, kind, name string) string {
+	if artworkDir == "" {
+		return ""
+	}
+	for _, path := range []string{
+		filepath.Join(artworkDir, system, kind, name+".png"),
+		filepath.Join(artworkDir, system, name+".png"),
+	} {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
@@ -0,0 +1,179 @@
+package thumbnails
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func write(t *testing.T, path string, size int, used time.Time) {
+	os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err := ioutil.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(path, used, used)
+}
+
+func TestSizes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "thumbnails")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write(t, filepath.Join(dir, "Nintendo - Game Boy", "Named_Snaps", "Tetris.png"), 100, time.Now())
+	write(t, filepath.Join(dir, "Nintendo - Game Boy", "Named_Snaps", "Alleyway.png"), 50, time.Now())
+	write(t, filepath.Join(dir, "Atari - Lynx", "Named_Snaps", "Chip.png"), 10, time.Now())
+
+	got, err := Sizes(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []SystemSize{{"Atari - Lynx", 1, 10}, {"Nintendo - Game Boy", 2, 150}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got = %v, want %v", got, want)
+	}
+
+	if err := Purge(dir, "Atari - Lynx"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := Sizes(dir); len(got) != 1 {
+		t.Errorf("got = %v, want a single system after the purge", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Atari - Lynx")); !os.IsNotExist(err) {
+		t.Error("the folder of the purged system was left behind")
+	}
+}
+
+func TestPurgeOnlyImages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "thumbnails")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write(t, filepath.Join(dir, "Nintendo - Game Boy", "Named_Snaps", "Tetris.png"), 100, time.Now())
+	write(t, filepath.Join(dir, "Nintendo - Game Boy", "Tetris.gb"), 100, time.Now())
+	write(t, filepath.Join(dir, "notes.txt"), 10, time.Now())
+
+	if err := Purge(dir, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Nintendo - Game Boy", "Named_Snaps", "Tetris.png")); !os.IsNotExist(err) {
+		t.Error("the thumbnail wasn't purged")
+	}
+	for _, path := range []string{filepath.Join(dir, "Nintendo - Game Boy", "Tetris.gb"), filepath.Join(dir, "notes.txt")} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s was removed", path)
+		}
+	}
+}
+
+func TestEvict(t *testing.T) {
+	dir, err := ioutil.TempDir("", "thumbnails")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	snaps := filepath.Join(dir, "Nintendo - Game Boy", "Named_Snaps")
+	write(t, filepath.Join(snaps, "old.png"), 100, time.Unix(1000, 0))
+	write(t, filepath.Join(snaps, "recent.png"), 100, time.Unix(3000, 0))
+	write(t, filepath.Join(snaps, "used.png"), 100, time.Unix(2000, 0))
+	Touch(filepath.Join(snaps, "used.png"))
+
+	n, err := Evict(dir, 250)
+	if err != nil || n != 1 {
+		t.Fatalf("got %d removed, %v", n, err)
+	}
+	if _, err := os.Stat(filepath.Join(snaps, "old.png")); !os.IsNotExist(err) {
+		t.Error("the least recently used thumbnail wasn't evicted")
+	}
+
+	n, _ = Evict(dir, 120)
+	if n != 1 {
+		t.Errorf("got %d removed, want 1", n)
+	}
+	if _, err := os.Stat(filepath.Join(snaps, "used.png")); err != nil {
+		t.Error("the touched thumbnail was evicted")
+	}
+
+	if n, _ := Evict(dir, 0); n != 0 {
+		t.Errorf("got %d removed without limit", n)
+	}
+}
+
+func TestLocal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "artwork")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write(t, filepath.Join(dir, "Nintendo - Game Boy", "Tetris.png"), 1, time.Now())
+	write(t, filepath.Join(dir, "Nintendo - Game Boy", "Named_Snaps", "Alleyway.png"), 1, time.Now())
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Tetris", filepath.Join(dir, "Nintendo - Game Boy", "Tetris.png")},
+		{"Alleyway", filepath.Join(dir, "Nintendo - Game Boy", "Named_Snaps", "Alleyway.png")},
+		{"Kirby", ""},
+	}
+	for _, tt := range tests {
+		if got := Local(dir, "Nintendo - Game Boy", "Named_Snaps", tt.name); got != tt.want {
+			t.Errorf("Local(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if got := Local("", "Nintendo - Game Boy", "Named_Snaps", "Tetris"); got != "" {
+		t.Errorf("got = %v without artwork directory", got)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{"Legend of Zelda, The (USA) [!]", "the_legend_of_zelda"},
+		{"Sonic & Knuckles (World)", "sonic-and-knuckles"},
+		{"Super Mario Bros. 3 (USA) (Rev 1)", "super mario bros 3"},
+		{"Pokémon Pinball (Europe)", "pokémon_pinball"},
+	}
+	for _, tt := range tests {
+		if Normalize(tt.a) != Normalize(tt.b) {
+			t.Errorf("Normalize(%q) = %q, Normalize(%q) = %q", tt.a, Normalize(tt.a), tt.b, Normalize(tt.b))
+		}
+	}
+	if Normalize("Tetris") == Normalize("Tetris 2") {
+		t.Error("different games have the same name")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "thumbnails")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write(t, filepath.Join(dir, "Legend of Zelda, The (USA) (Rev 1).png"), 1, time.Now())
+	write(t, filepath.Join(dir, "Legend of Zelda, The (USA).png"), 1, time.Now())
+
+	if got, want := Match(dir, "the legend of zelda [h1]"), filepath.Join(dir, "Legend of Zelda, The (USA).png"); got != want {
+		t.Errorf("got = %v, want %v", got, want)
+	}
+	if got := Match(dir, "Metroid"); got != "" {
+		t.Errorf("got = %v, want no match", got)
+	}
+
+	// The listing is read again when the folder changes
+	write(t, filepath.Join(dir, "Metroid (USA).png"), 1, time.Now())
+	os.Chtimes(dir, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	if got := Match(dir, "metroid"); got == "" {
+		t.Error("the new image wasn't found")
+	}
+}
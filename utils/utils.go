@@ -44,11 +44,42 @@ func FileName(path string) string {
 // DatedName returns the name of a file with a date appended, without extension.
 // It is used for savestates and screenshot names.
 func DatedName(path string) string {
-	name := FileName(path)
+	return Dated(FileName(path))
+}
+
+// Dated appends the date to a name, like DatedName
+func Dated(name string) string {
 	date := time.Now().Format("2006-01-02-15-04-05")
 	return name + "@" + date
 }
 
+// Exists tells if a file exists
+func Exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// CopyFile copies the file src to dst, creating the directory of dst
+func CopyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
 type logWriter struct {
 }
 
@@ -80,6 +111,21 @@ func AllFilesIn(dir string) ([]string, error) {
 	return file, err
 }
 
+// HumanSize formats a file size in bytes with the most fitting unit
+func HumanSize(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	v := float64(n)
+	for _, unit := range []string{"KB", "MB", "GB"} {
+		v /= 1024
+		if v < 1024 || unit == "GB" {
+			return fmt.Sprintf("%.1f %s", v, unit)
+		}
+	}
+	return ""
+}
+
 // CoreExt returns the libretro core extension for the current OS
 func CoreExt() string {
 	exts := map[string]string{
@@ -110,3 +156,18 @@ func LinesInFile(r io.Reader) (int, error) {
 		}
 	}
 }
+
+// ScrubIllegalChars scrubs characters that are not cross-platform and/or
+// violate the No-Intro filename standard.
+func ScrubIllegalChars(str string) string {
+	str = strings.Replace(str, "&", "_", -1)
+	str = strings.Replace(str, "*", "_", -1)
+	str = strings.Replace(str, "/", "_", -1)
+	str = strings.Replace(str, ":", "_", -1)
+	str = strings.Replace(str, "`", "_", -1)
+	str = strings.Replace(str, "<", "_", -1)
+	str = strings.Replace(str, ">", "_", -1)
+	str = strings.Replace(str, "?", "_", -1)
+	str = strings.Replace(str, "|", "_", -1)
+	return str
+}
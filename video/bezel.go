@@ -0,0 +1,28 @@
+package video
+
+import (
+	"github.com/go-gl/gl/v2.1/gl"
+	"github.com/libretro/ludo/bezels"
+)
+
+// drawBezel draws the bezel of the running game around its cutout
+func (video *Video) drawBezel(fbw, fbh int) {
+	b := bezels.Current()
+	if b == nil || !bezels.Active() {
+		return
+	}
+
+	if b.Path != video.bezelPath {
+		if video.bezelTex != 0 {
+			gl.DeleteTextures(1, &video.bezelTex)
+		}
+		video.bezelTex = NewImage(b.Path)
+		video.bezelPath = b.Path
+	}
+	if video.bezelTex == 0 {
+		return
+	}
+
+	x, y, w, h := b.Rect(float32(fbw), float32(fbh))
+	video.DrawImage(video.bezelTex, x, y, w, h, 1, Color{R: 1, G: 1, B: 1, A: 1})
+}
@@ -0,0 +1,91 @@
+package video
+
+import (
+	"github.com/go-gl/gl/v2.1/gl"
+	ntf "github.com/libretro/ludo/notifications"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+)
+
+// MaxBlackFrames is the highest number of black frames inserted after each
+// game frame
+const MaxBlackFrames = 3
+
+// blackFrames returns how many black frames can be inserted after each game
+// frame, n being the number requested and interval the swap interval in use.
+// Black frames are only possible if the display refreshes fast enough to
+// show them all, the game keeping its speed. Without vertical sync, or with
+// VRR, the swaps aren't paced by the display and the black frames would
+// flicker at random.
+func blackFrames(n, interval, refresh int, fps float64) int {
+	if n <= 0 || interval <= 0 || fps <= 0 {
+		return 0
+	}
+	if float64(refresh) < fps*float64((n+1)*interval)*0.98 {
+		return 0
+	}
+	return n
+}
+
+// blackFramesProblem explains why the black frames can't be inserted, it
+// returns an empty string if they can
+func blackFramesProblem(n, interval, refresh int, fps float64) string {
+	switch {
+	case n <= 0:
+		return ""
+	case interval <= 0:
+		return "Black frames need vertical sync and can't be used with variable refresh rate."
+	case blackFrames(n, interval, refresh, fps) == 0:
+		return "The display refresh rate is too low, black frames won't be inserted."
+	}
+	return ""
+}
+
+// BlackFramesProblem tells why the display can't show the black frames
+// requested in the settings for the running game, or returns an empty
+// string if it can
+func (video *Video) BlackFramesProblem() string {
+	// The frames are displayed by the presentation thread, at its own pace
+	if video.presenter != nil {
+		return "Black frames can't be inserted with threaded presentation."
+	}
+	s := settings.Current
+	fps := video.Timing.FPS
+	if fps <= 0 {
+		fps = 60
+	}
+	refresh := video.RefreshRate()
+	interval := swapInterval(s.VideoSwapInterval, s.VideoVRR, true, refresh, fps)
+	return blackFramesProblem(s.VideoBlackFrames, interval, refresh, fps)
+}
+
+// InsertBlackFrames displays black frames after the game frame, to reduce
+// the motion blur of sample and hold displays. It has to be called after
+// swapping buffers.
+func (video *Video) InsertBlackFrames() {
+	requested := settings.Current.VideoBlackFrames
+	if requested == 0 || !gameRunning() || state.FastForward || video.presenter != nil {
+		return
+	}
+
+	refresh := video.RefreshRate()
+	n := blackFrames(requested, video.swapInterval, refresh, video.Timing.FPS)
+	if n == 0 {
+		if !video.bfiDisabled {
+			msg := blackFramesProblem(requested, video.swapInterval, refresh, video.Timing.FPS)
+			ntf.DisplayAndLog(ntf.Warning, "Video", msg)
+			video.bfiDisabled = true
+		}
+		return
+	}
+	video.bfiDisabled = false
+
+	fbw, fbh := video.Window.GetFramebufferSize()
+	for i := 0; i < n; i++ {
+		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+		gl.Viewport(0, 0, int32(fbw), int32(fbh))
+		gl.ClearColor(0, 0, 0, 1)
+		gl.Clear(gl.COLOR_BUFFER_BIT)
+		video.Window.SwapBuffers()
+	}
+}
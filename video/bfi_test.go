@@ -0,0 +1,47 @@
+package video
+
+import (
+	"testing"
+)
+
+func Test_blackFrames(t *testing.T) {
+	tests := []struct {
+		name     string
+		n        int
+		interval int
+		refresh  int
+		fps      float64
+		want     int
+	}{
+		{"Disabled", 0, 1, 120, 60, 0},
+		{"120Hz", 1, 1, 120, 60, 1},
+		{"119.88Hz display", 1, 1, 119, 60.0988, 1},
+		{"60Hz display", 1, 1, 60, 60, 0},
+		{"Too many black frames", 2, 1, 120, 60, 0},
+		{"240Hz", 3, 1, 240, 59.94, 3},
+		{"Swap interval 2 on 240Hz", 1, 2, 240, 60, 1},
+		{"Swap interval 2 on 120Hz", 1, 2, 120, 60, 0},
+		{"No vertical sync or VRR", 1, 0, 240, 60, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := blackFrames(tt.n, tt.interval, tt.refresh, tt.fps); got != tt.want {
+				t.Errorf("blackFrames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_blackFramesProblem(t *testing.T) {
+	if got := blackFramesProblem(1, 1, 120, 60); got != "" {
+		t.Errorf("expected no problem, got %q", got)
+	}
+	if got := blackFramesProblem(0, 0, 60, 60); got != "" {
+		t.Errorf("expected no problem when disabled, got %q", got)
+	}
+	vsync := blackFramesProblem(1, 0, 240, 60)
+	slow := blackFramesProblem(1, 1, 60, 60)
+	if vsync == "" || slow == "" || vsync == slow {
+		t.Errorf("expected two different problems, got %q and %q", vsync, slow)
+	}
+}
@@ -6,11 +6,13 @@ import (
 	"image/draw"
 	"io"
 	"io/ioutil"
+	"math"
 	"os"
 
 	"github.com/go-gl/gl/v2.1/gl"
 	"github.com/golang/freetype"
 	"github.com/golang/freetype/truetype"
+	"github.com/libretro/ludo/logs"
 	"golang.org/x/image/font"
 	"golang.org/x/image/math/fixed"
 )
@@ -44,6 +46,8 @@ type Font struct {
 	color       Color
 	atlasWidth  float32
 	atlasHeight float32
+	size        float32 // multiplies the scale of the text, 1 if zero
+	density     float32 // size the glyphs are rasterized at, 1 if zero
 }
 
 type point [4]float32
@@ -81,9 +85,11 @@ func LoadTrueTypeFont(program uint32, r io.Reader, scale int32, low, high rune,
 		Hinting: font.HintingFull,
 	})
 
+	// The glyphs fit in 1024x1024 at the size of the menu font, the atlas
+	// grows with the size of the glyphs
 	var lineHeight float32
-	f.atlasWidth = 1024
-	f.atlasHeight = 1024
+	f.atlasWidth = 1024 * float32(math.Ceil(float64(scale)/72))
+	f.atlasHeight = f.atlasWidth
 	for ch := low; ch <= high; ch++ {
 		gBnd, _, ok := ttfFace.GlyphBounds(ch)
 		if !ok {
@@ -230,6 +236,14 @@ func LoadFont(file string, scale int32, windowWidth int, windowHeight int) (*Fon
 	return LoadTrueTypeFont(program, fd, scale, 32, 256, LeftToRight)
 }
 
+// Delete frees the texture, buffers and program of the font
+func (f *Font) Delete() {
+	gl.DeleteTextures(1, &f.textureID)
+	gl.DeleteBuffers(1, &f.vbo)
+	deleteVertexArrays(1, &f.vao)
+	gl.DeleteProgram(f.program)
+}
+
 // SetColor allows you to set the text color to be used when you draw the text
 func (f *Font) SetColor(color Color) {
 	f.color = color
@@ -243,8 +257,26 @@ func (f *Font) UpdateResolution(windowWidth int, windowHeight int) {
 	gl.UseProgram(0)
 }
 
+// SetSize enlarges or shrinks all the text drawn with the font
+func (f *Font) SetSize(size float32) {
+	f.size = size
+}
+
+// zoom applies the size of the font to a scale. The glyphs of a denser font
+// are larger, they are scaled down by as much.
+func (f *Font) zoom(scale float32) float32 {
+	if f.size > 0 {
+		scale *= f.size
+	}
+	if f.density > 0 {
+		scale /= f.density
+	}
+	return scale
+}
+
 // Printf draws a string to the screen, takes a list of arguments like printf
 func (f *Font) Printf(x, y float32, scale float32, fs string, argv ...interface{}) error {
+	scale = f.zoom(scale)
 	indices := []rune(fmt.Sprintf(fs, argv...))
 
 	if len(indices) == 0 {
@@ -318,6 +350,7 @@ func (f *Font) Printf(x, y float32, scale float32, fs string, argv ...interface{
 
 // Width returns the width of a piece of text in pixels
 func (f *Font) Width(scale float32, fs string, argv ...interface{}) float32 {
+	scale = f.zoom(scale)
 	var width float32
 
 	indices := []rune(fmt.Sprintf(fs, argv...))
@@ -336,7 +369,7 @@ func (f *Font) Width(scale float32, fs string, argv ...interface{}) float32 {
 
 		// Skip runes that are not in font chacter range
 		if int(runeIndex)-int(lowChar) > len(f.fontChar) || runeIndex < lowChar {
-			fmt.Printf("%c %d\n", runeIndex, runeIndex)
+			logs.Debugf("Video", "Rune out of the font range: %c %d", runeIndex, runeIndex)
 			continue
 		}
 
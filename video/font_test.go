@@ -0,0 +1,34 @@
+package video
+
+import "testing"
+
+func Test_fontDensity(t *testing.T) {
+	tests := []struct {
+		d    float32
+		want float32
+	}{
+		{0.4, 1},
+		{1, 1},
+		{1.2, 1.5},
+		{1.5, 1.5},
+		{2, 2},
+		{3.5, 2},
+	}
+	for _, tt := range tests {
+		if got := fontDensity(tt.d); got != tt.want {
+			t.Errorf("fontDensity(%v) = %v, want %v", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestFont_zoom(t *testing.T) {
+	f := &Font{}
+	if got := f.zoom(0.5); got != 0.5 {
+		t.Errorf("zoom() = %v, want 0.5", got)
+	}
+	f.SetSize(1.5)
+	f.density = 2
+	if got := f.zoom(0.5); got != 0.375 {
+		t.Errorf("zoom() = %v, want 0.375", got)
+	}
+}
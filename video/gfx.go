@@ -65,6 +65,15 @@ func rotateUV(va []float32, rot uint) []float32 {
 	return va
 }
 
+// cropUV shrinks the texture coordinates of a quad by a margin on each side
+func cropUV(va []float32, cx, cy float32) []float32 {
+	for i := 0; i < len(va); i += 4 {
+		va[i+2] = cx + va[i+2]*(1-2*cx)
+		va[i+3] = cy + va[i+3]*(1-2*cy)
+	}
+	return va
+}
+
 // DrawImage draws an image with x, y, w, h
 func (video *Video) DrawImage(image uint32, x, y, w, h float32, scale float32, c Color) {
 
@@ -98,7 +107,7 @@ func (video *Video) ScissorEnd() {
 }
 
 func (video *Video) vertexArray(x, y, w, h, scale float32) []float32 {
-	fbw, fbh := video.Window.GetFramebufferSize()
+	fbw, fbh := video.GetFramebufferSize()
 	ffbw := float32(fbw)
 	ffbh := float32(fbh)
 
@@ -210,7 +219,11 @@ func NewImage(file string) uint32 {
 	if err != nil {
 		return 0
 	}
+	return NewImageFrom(img)
+}
 
+// NewImageFrom uploads a decoded image to the GPU and returns the texture id
+func NewImageFrom(img image.Image) uint32 {
 	rgba := image.NewRGBA(img.Bounds())
 	if rgba.Stride != rgba.Rect.Size().X*4 {
 		return 0
@@ -67,3 +67,39 @@ func TestXYWHTo4points(t *testing.T) {
 		})
 	}
 }
+
+func Test_unrotate(t *testing.T) {
+	tests := []struct {
+		name  string
+		rot   uint
+		wantX float32
+		wantY float32
+	}{
+		{"No rotation", 0, 0.25, 0},
+		{"Quarter turn", 1, 1, 0.25},
+		{"Half turn", 2, 0.75, 1},
+		{"Three quarter turns", 3, 0, 0.75},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, y := unrotate(0.25, 0, tt.rot)
+			if x != tt.wantX || y != tt.wantY {
+				t.Errorf("unrotate() = %v, %v, want %v, %v", x, y, tt.wantX, tt.wantY)
+			}
+		})
+	}
+}
+
+func Test_quarterTurns(t *testing.T) {
+	tests := []struct {
+		degrees int
+		want    uint
+	}{
+		{0, 0}, {90, 1}, {180, 2}, {270, 3}, {360, 0}, {-90, 3},
+	}
+	for _, tt := range tests {
+		if got := quarterTurns(tt.degrees); got != tt.want {
+			t.Errorf("quarterTurns(%v) = %v, want %v", tt.degrees, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package video
+
+import (
+	"math"
+
+	"github.com/libretro/ludo/logs"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+)
+
+// maxRateError is the relative error tolerated between the framerate of a
+// game and the refresh rate picked for it
+const maxRateError = 0.005
+
+// contentRefreshRate picks the refresh rate closest to a multiple of the
+// framerate of the game, so each frame is displayed the same number of
+// times. It returns 0 if none is close enough.
+func contentRefreshRate(rates []int, fps float64) int {
+	best, bestErr := 0, math.Inf(1)
+	for _, r := range rates {
+		n := math.Round(float64(r) / fps)
+		if n < 1 {
+			continue
+		}
+		err := math.Abs(float64(r)/n-fps) / fps
+		if err < bestErr || err == bestErr && r < best {
+			best, bestErr = r, err
+		}
+	}
+	if bestErr > maxRateError {
+		return 0
+	}
+	return best
+}
+
+// MatchRefreshRate switches the exclusive fullscreen mode to the refresh
+// rate of the running game, and back once it is closed. It has to be called
+// once per frame.
+func (video *Video) MatchRefreshRate() {
+	var fps float64
+	if settings.Current.VideoMatchRefreshRate && state.CoreRunning {
+		fps = video.Timing.FPS
+	}
+	if fps == video.matchedFPS {
+		return
+	}
+	video.matchedFPS = fps
+
+	m := video.Window.GetMonitor()
+	if m == nil {
+		return
+	}
+	cur := m.GetVideoMode()
+	if video.fullscreenRate == 0 {
+		video.fullscreenRate = cur.RefreshRate
+	}
+
+	want := video.fullscreenRate
+	if fps > 0 {
+		var rates []int
+		for _, vm := range m.GetVideoModes() {
+			if vm.Width == cur.Width && vm.Height == cur.Height {
+				rates = append(rates, vm.RefreshRate)
+			}
+		}
+		if r := contentRefreshRate(rates, fps); r != 0 {
+			want = r
+		}
+	}
+	if want == cur.RefreshRate {
+		return
+	}
+	video.Window.SetMonitor(m, 0, 0, cur.Width, cur.Height, want)
+	logs.Infof("Video", "Display mode: %dx%d@%dHz", cur.Width, cur.Height, want)
+}
@@ -0,0 +1,124 @@
+package video
+
+import (
+	"time"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+)
+
+// MaxSwapInterval is the highest number of screen refreshes to wait for
+// between two buffer swaps
+const MaxSwapInterval = 2
+
+// RefreshRate returns the refresh rate of the monitor showing the window
+func (video *Video) RefreshRate() int {
+	m := video.Window.GetMonitor()
+	if m == nil {
+		m = glfw.GetPrimaryMonitor()
+	}
+	if m == nil {
+		return 0
+	}
+	vm := m.GetVideoMode()
+	if vm == nil {
+		return 0
+	}
+	return vm.RefreshRate
+}
+
+// vrrUsable tells if a variable refresh rate display can follow a game
+// running at fps frames per second. GLFW doesn't tell if the display
+// supports VRR, but it can't go faster than its highest refresh rate.
+func vrrUsable(refresh int, fps float64) bool {
+	return refresh > 0 && fps > 0 && fps <= float64(refresh)+0.5
+}
+
+// swapInterval returns the number of screen refreshes to wait for between
+// two buffer swaps. When the game is running on a VRR display, the loop is
+// paced instead, and the display follows.
+func swapInterval(interval int, vrr, running bool, refresh int, fps float64) int {
+	if running && state.FastForward {
+		return 0
+	}
+	if running && vrr && vrrUsable(refresh, fps) {
+		return 0
+	}
+	return interval
+}
+
+// paced tells if the main loop has to be throttled to the game framerate
+func paced(vrr, exact, running bool, refresh int, fps float64) bool {
+	if !running || state.FastForward || fps <= 0 {
+		return false
+	}
+	return exact || (vrr && vrrUsable(refresh, fps))
+}
+
+// gameRunning tells if the game is displayed, as opposed to the menu
+func gameRunning() bool {
+	return state.CoreRunning && !state.MenuActive
+}
+
+// UpdateSwapInterval applies the vertical sync settings. It has to be called
+// once per frame, before swapping buffers.
+func (video *Video) UpdateSwapInterval() {
+	s := settings.Current
+	i := swapInterval(s.VideoSwapInterval, s.VideoVRR, gameRunning(), video.RefreshRate(), video.Timing.FPS)
+	if i != video.swapInterval {
+		// The presentation thread applies it to its own context
+		if video.presenter == nil {
+			glfw.SwapInterval(i)
+		}
+		video.swapInterval = i
+	}
+}
+
+// presentRate returns the framerate of the main loop with threaded
+// presentation. The buffer swaps don't throttle it, it follows the game, or
+// the display in the menu.
+func presentRate(running bool, refresh int, fps float64) float64 {
+	switch {
+	case running && state.FastForward:
+		return 0
+	case running && fps > 0:
+		return fps
+	case refresh > 0:
+		return float64(refresh)
+	}
+	return 60
+}
+
+// Threaded tells if the presentation is threaded. The main loop is then
+// throttled by Pace, even when the game runs on the core thread.
+func (video *Video) Threaded() bool {
+	return video.presenter != nil
+}
+
+// Pace waits so frames are displayed at the exact framerate of the game,
+// when syncing to the content framerate or using a VRR display, or with
+// threaded presentation. It has to be called after swapping buffers.
+func (video *Video) Pace() {
+	s := settings.Current
+	fps := video.Timing.FPS
+	if video.presenter != nil {
+		fps = presentRate(gameRunning(), video.RefreshRate(), fps)
+	} else if !paced(s.VideoVRR, s.VideoSyncExactFPS, gameRunning(), video.RefreshRate(), fps) {
+		fps = 0
+	}
+	if fps <= 0 {
+		video.nextFrame = time.Time{}
+		return
+	}
+
+	period := time.Duration(float64(time.Second) / fps)
+	now := time.Now()
+	video.nextFrame = video.nextFrame.Add(period)
+	// Start over after a hiccup instead of running fast to catch up
+	if video.nextFrame.Before(now.Add(-period)) {
+		video.nextFrame = now
+		return
+	}
+	time.Sleep(video.nextFrame.Sub(now))
+}
@@ -0,0 +1,104 @@
+package video
+
+import (
+	"testing"
+
+	"github.com/libretro/ludo/state"
+)
+
+func Test_swapInterval(t *testing.T) {
+	tests := []struct {
+		name        string
+		interval    int
+		vrr         bool
+		running     bool
+		fastForward bool
+		refresh     int
+		fps         float64
+		want        int
+	}{
+		{"Menu", 1, true, false, false, 60, 60, 1},
+		{"Vsync", 2, false, true, false, 60, 60, 2},
+		{"Fast forward", 1, false, true, true, 60, 60, 0},
+		{"VRR", 1, true, true, false, 144, 60, 0},
+		{"VRR display too slow", 1, true, true, false, 60, 75, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state.FastForward = tt.fastForward
+			defer func() { state.FastForward = false }()
+			if got := swapInterval(tt.interval, tt.vrr, tt.running, tt.refresh, tt.fps); got != tt.want {
+				t.Errorf("swapInterval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_paced(t *testing.T) {
+	tests := []struct {
+		name    string
+		vrr     bool
+		exact   bool
+		running bool
+		refresh int
+		fps     float64
+		want    bool
+	}{
+		{"Vsync", false, false, true, 60, 60, false},
+		{"Exact framerate", false, true, true, 60, 59.94, true},
+		{"VRR", true, false, true, 144, 60, true},
+		{"VRR display too slow", true, false, true, 60, 75, false},
+		{"Menu", true, true, false, 144, 60, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := paced(tt.vrr, tt.exact, tt.running, tt.refresh, tt.fps); got != tt.want {
+				t.Errorf("paced() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_presentRate(t *testing.T) {
+	tests := []struct {
+		name    string
+		running bool
+		refresh int
+		fps     float64
+		want    float64
+	}{
+		{"Game", true, 144, 59.94, 59.94},
+		{"Menu", false, 144, 59.94, 144},
+		{"Unknown display", false, 0, 0, 60},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := presentRate(tt.running, tt.refresh, tt.fps); got != tt.want {
+				t.Errorf("presentRate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_contentRefreshRate(t *testing.T) {
+	tests := []struct {
+		name  string
+		rates []int
+		fps   float64
+		want  int
+	}{
+		{"Same rate", []int{50, 60, 144}, 60, 60},
+		{"PAL game", []int{50, 60, 144}, 50, 50},
+		{"Multiple", []int{100, 144}, 50, 100},
+		{"NTSC game", []int{59, 60, 75}, 60.0988, 60},
+		{"None close enough", []int{60, 75}, 54.8, 0},
+		{"Lowest multiple", []int{60, 120}, 60, 60},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := contentRefreshRate(tt.rates, tt.fps); got != tt.want {
+				t.Errorf("contentRefreshRate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
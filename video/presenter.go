@@ -0,0 +1,351 @@
+package video
+
+import (
+	"runtime"
+
+	"github.com/go-gl/gl/v2.1/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/libretro/ludo/crash"
+	"github.com/libretro/ludo/logs"
+)
+
+// screenBuffers is the number of screen textures of the threaded
+// presentation: one drawn by the main thread, one waiting and one displayed
+const screenBuffers = 3
+
+// gameBuffers is the number of game textures: one drawn by the main thread
+// and one uploaded by the presentation thread
+const gameBuffers = 2
+
+// presented is a screen texture handed to the presentation thread. The fence
+// is signaled once the main thread is done drawing it.
+type presented struct {
+	tex      int
+	fence    uintptr
+	w, h     int32 // framebuffer size of the window
+	rot      uint
+	interval int
+}
+
+// released is a screen texture the presentation thread is done with. The
+// fence is signaled once it is displayed.
+type released struct {
+	tex   int
+	fence uintptr
+}
+
+// upload is a frame of the core for the presentation thread to upload. The
+// data is a copy, the buffer of the core is only valid during the refresh.
+// Once uploaded, tex is the game texture holding it, and the fence is
+// signaled when the upload is done.
+type upload struct {
+	data                 []byte
+	width, height, pitch int32
+	bpp                  int32
+	pixType, pixFmt      uint32
+	tex                  int
+	fence                uintptr
+}
+
+// presenter displays the screen drawn by the main thread on its own thread,
+// and uploads the frames of the core. The main loop never waits for the
+// display, the last screen it drew replaces the one waiting, so a slow
+// buffer swap or upload doesn't stall it. The main thread draws in a hidden
+// window sharing its textures with the real one.
+type presenter struct {
+	context  *glfw.Window // hidden window of the context of the main thread
+	fbo      uint32
+	textures [screenBuffers]uint32
+	sizes    [screenBuffers][2]int32
+	current  int // texture drawn by the main thread
+	fences   bool
+
+	games [gameBuffers]uint32
+	game  int    // game texture drawn by the main thread
+	spare []byte // buffer of the last uploaded frame, for the next copy
+
+	ready    chan presented
+	free     chan released
+	uploads  chan upload   // frame waiting to be uploaded
+	uploaded chan upload   // uploaded frame waiting to be drawn
+	unused   chan released // game textures the main thread is done with
+	done     chan struct{}
+}
+
+// newPresenter creates the hidden window of the main thread. It returns nil
+// if the context can't be shared.
+func newPresenter(window *glfw.Window) *presenter {
+	glfw.WindowHint(glfw.Visible, glfw.False)
+	context, err := glfw.CreateWindow(16, 16, "Ludo", nil, window)
+	glfw.WindowHint(glfw.Visible, glfw.True)
+	if err != nil {
+		logs.Warnf("Video", "Threaded video disabled, the context can't be shared: %v", err)
+		return nil
+	}
+	p := &presenter{
+		context:  context,
+		ready:    make(chan presented, 1),
+		free:     make(chan released, screenBuffers),
+		uploads:  make(chan upload, 1),
+		uploaded: make(chan upload, 1),
+		unused:   make(chan released, gameBuffers),
+		done:     make(chan struct{}),
+	}
+	for i := 1; i < screenBuffers; i++ {
+		p.free <- released{tex: i}
+	}
+	for i := 1; i < gameBuffers; i++ {
+		p.unused <- released{tex: i}
+	}
+	return p
+}
+
+// start creates the screen textures and launches the presentation thread.
+// The GL context of the main thread must be current.
+func (p *presenter) start(window *glfw.Window) {
+	p.fences = fencesSupported()
+	gl.GenFramebuffers(1, &p.fbo)
+	gl.GenTextures(screenBuffers, &p.textures[0])
+	gl.GenTextures(gameBuffers, &p.games[0])
+	go p.run(window)
+}
+
+// run uploads the frames of the core and displays the screen textures in the
+// window until stop
+func (p *presenter) run(window *glfw.Window) {
+	defer crash.Recover()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	defer close(p.done)
+
+	window.MakeContextCurrent()
+	defer glfw.DetachCurrentContext()
+
+	program, err := newProgram(vertexShader, defaultFragmentShader)
+	if err != nil {
+		logs.Errorf("Video", "Failed to create the presentation program: %v", err)
+		for f := range p.ready {
+			p.free <- released{tex: f.tex, fence: f.fence}
+		}
+		return
+	}
+	var vao, vbo uint32
+	genVertexArrays(1, &vao)
+	bindVertexArray(vao)
+	gl.GenBuffers(1, &vbo)
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices), gl.STATIC_DRAW)
+	vertAttrib := uint32(gl.GetAttribLocation(program, gl.Str("vert\x00")))
+	gl.EnableVertexAttribArray(vertAttrib)
+	gl.VertexAttribPointerWithOffset(vertAttrib, 2, gl.FLOAT, false, 4*4, 0)
+	texCoordAttrib := uint32(gl.GetAttribLocation(program, gl.Str("vertTexCoord\x00")))
+	gl.EnableVertexAttribArray(texCoordAttrib)
+	gl.VertexAttribPointerWithOffset(texCoordAttrib, 2, gl.FLOAT, false, 4*4, 2*4)
+	gl.UseProgram(program)
+	gl.Uniform1i(gl.GetUniformLocation(program, gl.Str("Texture\x00")), 0)
+
+	interval := -1
+	var unused []released // game textures to upload the frames to
+	for {
+		// Frames are only taken while there is a texture to upload them to
+		var uploads chan upload
+		if len(unused) > 0 {
+			uploads = p.uploads
+		}
+		select {
+		case r := <-p.unused:
+			unused = append(unused, r)
+		case u := <-uploads:
+			p.upload(u, unused[0])
+			unused = unused[1:]
+		case f, ok := <-p.ready:
+			if !ok {
+				return
+			}
+			p.display(window, f, program, vao, vbo, &interval)
+		}
+	}
+}
+
+// upload copies a frame to a game texture, on the presentation thread, and
+// hands it to the main thread
+func (p *presenter) upload(u upload, r released) {
+	if r.fence != 0 {
+		gl.WaitSync(r.fence, 0, gl.TIMEOUT_IGNORED)
+		gl.DeleteSync(r.fence)
+	}
+	gl.BindTexture(gl.TEXTURE_2D, p.games[r.tex])
+	gl.PixelStorei(gl.UNPACK_ROW_LENGTH, u.pitch/u.bpp)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA8, u.width, u.height, 0, u.pixType, u.pixFmt, gl.Ptr(u.data))
+	u.tex = r.tex
+	if p.fences {
+		u.fence = gl.FenceSync(gl.SYNC_GPU_COMMANDS_COMPLETE, 0)
+		gl.Flush()
+	} else {
+		gl.Finish()
+	}
+	p.uploaded <- u
+}
+
+// display draws a screen texture in the window and swaps its buffers
+func (p *presenter) display(window *glfw.Window, f presented, program, vao, vbo uint32, interval *int) {
+	if f.fence != 0 {
+		gl.WaitSync(f.fence, 0, gl.TIMEOUT_IGNORED)
+	}
+	if f.interval != *interval {
+		glfw.SwapInterval(f.interval)
+		*interval = f.interval
+	}
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.Viewport(0, 0, f.w, f.h)
+	gl.ClearColor(0, 0, 0, 1)
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+	gl.Disable(gl.BLEND)
+
+	va := screenQuad(f.rot)
+	gl.UseProgram(program)
+	bindVertexArray(vao)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, p.textures[f.tex])
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(va)*4, gl.Ptr(va), gl.STATIC_DRAW)
+	gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
+
+	r := released{tex: f.tex}
+	if f.fence != 0 {
+		gl.DeleteSync(f.fence)
+		r.fence = gl.FenceSync(gl.SYNC_GPU_COMMANDS_COMPLETE, 0)
+	}
+	window.SwapBuffers()
+	if r.fence == 0 {
+		gl.Finish()
+	}
+	p.free <- r
+}
+
+// bind prepares the screen texture drawn by the main thread
+func (p *presenter) bind(video *Video) {
+	w, h := video.GetFramebufferSize()
+	tex := p.textures[p.current]
+	gl.BindFramebuffer(gl.FRAMEBUFFER, p.fbo)
+	if size := [2]int32{int32(w), int32(h)}; p.sizes[p.current] != size {
+		p.sizes[p.current] = size
+		gl.BindTexture(gl.TEXTURE_2D, tex)
+		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA8, size[0], size[1], 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+		setFilter(tex, false)
+	}
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, tex, 0)
+	video.screenFBO, video.screenTex = p.fbo, tex
+}
+
+// queue hands a screen texture to the presentation thread without waiting.
+// A screen still waiting to be displayed is replaced, its texture can be
+// drawn again.
+func (p *presenter) queue(f presented) {
+	for {
+		select {
+		case p.ready <- f:
+			return
+		case old := <-p.ready:
+			p.free <- released{tex: old.tex, fence: old.fence}
+		}
+	}
+}
+
+// acquire takes the next screen texture to draw, once the presentation
+// thread is done displaying it
+func (p *presenter) acquire() released {
+	r := <-p.free
+	p.current = r.tex
+	return r
+}
+
+// submit hands the screen of the frame to the presentation thread and picks
+// the texture of the next frame
+func (p *presenter) submit(video *Video) {
+	f := presented{
+		tex:      p.current,
+		rot:      screenRotation(),
+		interval: video.swapInterval,
+	}
+	if f.interval < 0 {
+		f.interval = 1
+	}
+	w, h := video.Window.GetFramebufferSize()
+	f.w, f.h = int32(w), int32(h)
+	if p.fences {
+		f.fence = gl.FenceSync(gl.SYNC_GPU_COMMANDS_COMPLETE, 0)
+		gl.Flush()
+	} else {
+		gl.Finish()
+	}
+	p.queue(f)
+
+	if r := p.acquire(); r.fence != 0 {
+		gl.WaitSync(r.fence, 0, gl.TIMEOUT_IGNORED)
+		gl.DeleteSync(r.fence)
+	}
+	// Whatever is drawn until the next frame, like a screenshot, must not
+	// land in the screen handed to the presentation thread
+	p.bind(video)
+}
+
+// send hands a frame of the core to the presentation thread to upload it. A
+// frame still waiting is replaced.
+func (p *presenter) send(f capturedFrame, bpp int32, pixType uint32) {
+	u := upload{
+		data:    append(p.spare[:0], f.data...),
+		width:   f.width,
+		height:  f.height,
+		pitch:   f.pitch,
+		bpp:     bpp,
+		pixType: pixType,
+		pixFmt:  f.pixFmt,
+	}
+	p.spare = nil
+	for {
+		select {
+		case p.uploads <- u:
+			return
+		case old := <-p.uploads:
+			p.spare = old.data
+		}
+	}
+}
+
+// take switches to the last uploaded frame, if any. The game texture drawn
+// so far goes back to the presentation thread once the GPU is done with it.
+func (p *presenter) take() (upload, bool) {
+	var u upload
+	select {
+	case u = <-p.uploaded:
+	default:
+		return u, false
+	}
+	if u.fence != 0 {
+		gl.WaitSync(u.fence, 0, gl.TIMEOUT_IGNORED)
+		gl.DeleteSync(u.fence)
+	}
+	r := released{tex: p.game}
+	if p.fences {
+		r.fence = gl.FenceSync(gl.SYNC_GPU_COMMANDS_COMPLETE, 0)
+		gl.Flush()
+	} else {
+		gl.Finish()
+	}
+	p.unused <- r
+	p.game = u.tex
+	if p.spare == nil {
+		p.spare = u.data
+	}
+	return u, true
+}
+
+// stop waits for the presentation thread to end and destroys the hidden
+// window
+func (p *presenter) stop() {
+	close(p.ready)
+	<-p.done
+	p.context.Destroy()
+}
@@ -0,0 +1,64 @@
+package video
+
+import "testing"
+
+func testPresenter() *presenter {
+	p := &presenter{
+		ready:    make(chan presented, 1),
+		free:     make(chan released, screenBuffers),
+		uploads:  make(chan upload, 1),
+		uploaded: make(chan upload, 1),
+		unused:   make(chan released, gameBuffers),
+	}
+	for i := 1; i < screenBuffers; i++ {
+		p.free <- released{tex: i}
+	}
+	return p
+}
+
+func Test_presenter_queue(t *testing.T) {
+	p := testPresenter()
+
+	// The main thread never waits for the display, the last screen replaces
+	// the waiting one
+	for i := 0; i < 10; i++ {
+		p.queue(presented{tex: p.current})
+		p.acquire()
+	}
+	if len(p.ready) != 1 {
+		t.Fatalf("expected one waiting screen, got %d", len(p.ready))
+	}
+
+	// The presentation thread displays the screens in order, the main thread
+	// never draws in one it holds
+	var displayed []int
+	for i := 0; i < 10; i++ {
+		f := <-p.ready
+		displayed = append(displayed, f.tex)
+		if p.current == f.tex {
+			t.Fatalf("drawing in the displayed screen %d", f.tex)
+		}
+		p.queue(presented{tex: p.current})
+		p.acquire()
+		p.free <- released{tex: f.tex}
+	}
+	for i := 1; i < len(displayed); i++ {
+		if displayed[i] == displayed[i-1] {
+			t.Errorf("screen %d displayed twice in a row", displayed[i])
+		}
+	}
+}
+
+func Test_presenter_send(t *testing.T) {
+	p := testPresenter()
+
+	p.send(capturedFrame{data: []byte{1, 2}, width: 1, height: 1, pitch: 2}, 2, 0)
+	p.send(capturedFrame{data: []byte{3, 4}, width: 1, height: 1, pitch: 2}, 2, 0)
+	u := <-p.uploads
+	if u.data[0] != 3 || u.bpp != 2 {
+		t.Errorf("expected the last frame, got %+v", u)
+	}
+	if len(p.spare) == 0 || p.spare[0] != 1 {
+		t.Errorf("the replaced buffer should be reused, got %v", p.spare)
+	}
+}
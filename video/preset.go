@@ -0,0 +1,163 @@
+package video
+
+import (
+	"github.com/go-gl/gl/v2.1/gl"
+	"github.com/libretro/ludo/shaders"
+)
+
+// pass is a compiled pass of a shader preset. Every pass but the last one
+// renders to a texture through a framebuffer object.
+type pass struct {
+	shaders.Pass
+	program uint32
+	fbo     uint32
+	tex     uint32
+	w, h    int32
+}
+
+// identity is the MVP matrix expected by RetroArch shaders
+var identity = [16]float32{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1}
+
+// fboVertices draws a full quad in a framebuffer object. The V axis is
+// flipped so that intermediate textures keep the orientation of the core
+// framebuffer.
+var fboVertices = []float32{
+	-1.0, -1.0, 0.0, 0.0,
+	-1.0, 1.0, 0.0, 1.0,
+	1.0, -1.0, 1.0, 0.0,
+	1.0, 1.0, 1.0, 1.0,
+}
+
+// SetPreset compiles the passes of a shader preset and uses them to render
+// the game. A nil preset restores the video filter from the settings.
+func (video *Video) SetPreset(p *shaders.Preset) error {
+	video.deletePasses()
+	video.preset = p
+	if p == nil {
+		video.UpdateFilter(video.filter)
+		return nil
+	}
+
+	vertLoc := uint32(gl.GetAttribLocation(video.defaultProgram, gl.Str("vert\x00")))
+	texLoc := uint32(gl.GetAttribLocation(video.defaultProgram, gl.Str("vertTexCoord\x00")))
+	bind := func(program uint32) {
+		gl.BindAttribLocation(program, vertLoc, gl.Str("vert\x00"))
+		gl.BindAttribLocation(program, vertLoc, gl.Str("VertexCoord\x00"))
+		gl.BindAttribLocation(program, texLoc, gl.Str("vertTexCoord\x00"))
+		gl.BindAttribLocation(program, texLoc, gl.Str("TexCoord\x00"))
+	}
+
+	for i, sp := range p.Passes {
+		vs, fs := shaders.Stages(sp.Source)
+		if vs == "" {
+			vs = vertexShader
+		} else {
+			vs += "\x00"
+		}
+		program, err := linkProgram(vs, fs+"\x00", bind)
+		if err != nil {
+			video.deletePasses()
+			video.preset = nil
+			video.UpdateFilter(video.filter)
+			return err
+		}
+		ps := pass{Pass: sp, program: program}
+		if i < len(p.Passes)-1 {
+			gl.GenFramebuffers(1, &ps.fbo)
+			gl.GenTextures(1, &ps.tex)
+		}
+		video.passes = append(video.passes, ps)
+	}
+	video.params = p.Parameters
+	return nil
+}
+
+// deletePasses frees the GL objects of the current preset
+func (video *Video) deletePasses() {
+	for _, ps := range video.passes {
+		gl.DeleteProgram(ps.program)
+		if ps.fbo != 0 {
+			gl.DeleteFramebuffers(1, &ps.fbo)
+			gl.DeleteTextures(1, &ps.tex)
+		}
+	}
+	video.passes = nil
+	video.params = nil
+}
+
+// setFilter sets the filtering of a texture
+func setFilter(tex uint32, linear bool) {
+	f := int32(gl.NEAREST)
+	if linear {
+		f = gl.LINEAR
+	}
+	gl.BindTexture(gl.TEXTURE_2D, tex)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, f)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, f)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+}
+
+// setUniforms sets the uniforms of a pass program
+func (video *Video) setUniforms(program uint32, inW, inH int32, outW, outH float32) {
+	gl.UseProgram(program)
+	gl.Uniform1i(gl.GetUniformLocation(program, gl.Str("Texture\x00")), 0)
+	gl.Uniform2f(gl.GetUniformLocation(program, gl.Str("TextureSize\x00")), float32(inW), float32(inH))
+	gl.Uniform2f(gl.GetUniformLocation(program, gl.Str("InputSize\x00")), float32(inW), float32(inH))
+	gl.Uniform2f(gl.GetUniformLocation(program, gl.Str("OutputSize\x00")), outW, outH)
+	gl.Uniform1i(gl.GetUniformLocation(program, gl.Str("FrameCount\x00")), int32(video.frameCount))
+	gl.Uniform1i(gl.GetUniformLocation(program, gl.Str("FrameDirection\x00")), 1)
+	gl.UniformMatrix4fv(gl.GetUniformLocation(program, gl.Str("MVPMatrix\x00")), 1, false, &identity[0])
+	for _, p := range video.params {
+		gl.Uniform1f(gl.GetUniformLocation(program, gl.Str(p.Name+"\x00")), shaders.Value(p))
+	}
+}
+
+// renderPasses draws the game through the passes of the preset. The last
+// pass draws in the viewport computed for the game.
+func (video *Video) renderPasses(fbw, fbh int) {
+	_, _, vw, vh := video.contentRect(fbw, fbh)
+
+	input := video.texID
+	inW, inH := video.width, video.height
+
+	bindVertexArray(video.vao)
+	gl.ActiveTexture(gl.TEXTURE0)
+
+	for i := range video.passes {
+		ps := &video.passes[i]
+		setFilter(input, ps.Linear)
+
+		if ps.fbo == 0 {
+			gl.BindFramebuffer(gl.FRAMEBUFFER, video.screenFBO)
+			gl.Viewport(0, 0, int32(fbw), int32(fbh))
+			video.coreRatioViewport(fbw, fbh)
+			video.setUniforms(ps.program, inW, inH, vw, vh)
+			gl.BindTexture(gl.TEXTURE_2D, input)
+			gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
+			break
+		}
+
+		w, h := ps.OutputSize(inW, inH, int32(vw), int32(vh))
+		if w != ps.w || h != ps.h {
+			ps.w, ps.h = w, h
+			gl.BindTexture(gl.TEXTURE_2D, ps.tex)
+			gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA8, w, h, 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+			gl.BindFramebuffer(gl.FRAMEBUFFER, ps.fbo)
+			gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, ps.tex, 0)
+		}
+
+		gl.BindFramebuffer(gl.FRAMEBUFFER, ps.fbo)
+		gl.Viewport(0, 0, w, h)
+		gl.BindBuffer(gl.ARRAY_BUFFER, video.vbo)
+		gl.BufferData(gl.ARRAY_BUFFER, len(fboVertices)*4, gl.Ptr(fboVertices), gl.STATIC_DRAW)
+		video.setUniforms(ps.program, inW, inH, float32(w), float32(h))
+		gl.BindTexture(gl.TEXTURE_2D, input)
+		gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
+
+		input = ps.tex
+		inW, inH = w, h
+	}
+
+	gl.UseProgram(video.program)
+}
@@ -0,0 +1,105 @@
+package video
+
+import (
+	"math"
+	"time"
+
+	"github.com/libretro/ludo/logs"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/state"
+)
+
+// refreshSamples is the number of frames the refresh rate is measured over,
+// about five seconds
+const refreshSamples = 300
+
+// maxSkew is the largest relative difference between the framerate of a game
+// and the rate it is displayed at that the audio makes up for. Beyond, the
+// game doesn't follow the display.
+const maxSkew = 0.05
+
+// refreshEstimator measures the actual refresh rate of the display from the
+// times the frames are displayed. The monitors only report a rounded rate,
+// 60 Hz for 59.94 Hz.
+type refreshEstimator struct {
+	nominal  int // refresh rate reported by the monitor
+	interval int // swap interval the frames are displayed at
+	last     time.Time
+	total    time.Duration
+	count    int
+	rate     float64 // estimated refresh rate, 0 until measured
+}
+
+// add records the time a frame was displayed. It tells if this frame
+// completed the measure.
+func (e *refreshEstimator) add(now time.Time) bool {
+	if e.rate > 0 || e.nominal <= 0 || e.interval <= 0 {
+		return false
+	}
+	last := e.last
+	e.last = now
+	if last.IsZero() {
+		return false
+	}
+	d := now.Sub(last)
+	period := time.Duration(e.interval) * time.Second / time.Duration(e.nominal)
+	// Missed or doubled swaps would skew the average
+	if d < period*9/10 || d > period*11/10 {
+		return false
+	}
+	e.total += d
+	e.count++
+	if e.count < refreshSamples {
+		return false
+	}
+	e.rate = float64(e.count*e.interval) / e.total.Seconds()
+	return true
+}
+
+// rateSkew returns how much faster than its framerate a game runs when it is
+// displayed at every interval refreshes of the display, 1 if it doesn't
+// follow the display
+func rateSkew(refresh float64, interval int, fps float64) float64 {
+	if refresh <= 0 || interval <= 0 || fps <= 0 {
+		return 1
+	}
+	skew := refresh / float64(interval) / fps
+	if math.Abs(skew-1) > maxSkew {
+		return 1
+	}
+	return skew
+}
+
+// followsDisplay tells if the game runs at the pace of the buffer swaps
+func (video *Video) followsDisplay() bool {
+	s := settings.Current
+	return gameRunning() && !state.FastForward && !state.Paused && video.swapInterval > 0 &&
+		!paced(s.VideoVRR, s.VideoSyncExactFPS, true, video.RefreshRate(), video.Timing.FPS)
+}
+
+// EstimateRefreshRate measures the refresh rate of the display during the
+// first seconds of play. It has to be called once per frame, after swapping
+// buffers.
+func (video *Video) EstimateRefreshRate() {
+	e := &video.estimator
+	if !video.followsDisplay() {
+		e.last = time.Time{}
+		return
+	}
+	if nominal := video.RefreshRate(); nominal != e.nominal || video.swapInterval != e.interval {
+		*e = refreshEstimator{nominal: nominal, interval: video.swapInterval}
+	}
+	if e.add(time.Now()) {
+		logs.Infof("Video", "Estimated refresh rate: %.3f Hz", e.rate)
+	}
+}
+
+// RateSkew returns how much faster than its framerate the game runs, from
+// the estimated refresh rate of the display. It is 1 until the refresh rate
+// is measured, or if the game doesn't follow the display.
+func (video *Video) RateSkew() float64 {
+	if !video.followsDisplay() {
+		return 1
+	}
+	return rateSkew(video.estimator.rate, video.swapInterval, video.Timing.FPS)
+}
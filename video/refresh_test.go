@@ -0,0 +1,71 @@
+package video
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func Test_refreshEstimator(t *testing.T) {
+	e := refreshEstimator{nominal: 60, interval: 1}
+	hz := 59.94
+	period := time.Duration(float64(time.Second) / hz)
+	now := time.Now()
+	done := false
+	for i := 0; i <= refreshSamples+1; i++ {
+		// A missed swap is ignored
+		if i == 100 {
+			now = now.Add(2 * period)
+			e.add(now)
+			continue
+		}
+		now = now.Add(period)
+		if e.add(now) {
+			done = true
+		}
+	}
+	if !done {
+		t.Fatal("expected the measure to complete")
+	}
+	if math.Abs(e.rate-59.94) > 0.01 {
+		t.Errorf("rate = %v, want 59.94", e.rate)
+	}
+	if e.add(now.Add(period)) {
+		t.Error("the measure should complete once")
+	}
+}
+
+func Test_refreshEstimator_interval(t *testing.T) {
+	e := refreshEstimator{nominal: 120, interval: 2}
+	now := time.Now()
+	for i := 0; i <= refreshSamples; i++ {
+		now = now.Add(time.Second / 60)
+		e.add(now)
+	}
+	if math.Abs(e.rate-120) > 0.01 {
+		t.Errorf("rate = %v, want 120", e.rate)
+	}
+}
+
+func Test_rateSkew(t *testing.T) {
+	tests := []struct {
+		name     string
+		refresh  float64
+		interval int
+		fps      float64
+		want     float64
+	}{
+		{"Not measured", 0, 1, 60, 1},
+		{"NTSC game on a 60 Hz display", 60, 1, 60.0988, 60 / 60.0988},
+		{"60 Hz game on a 59.94 Hz display", 59.94, 1, 60, 0.999},
+		{"Half rate", 119.88, 2, 60, 0.999},
+		{"PAL game on a 60 Hz display", 60, 1, 50, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rateSkew(tt.refresh, tt.interval, tt.fps); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("rateSkew() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
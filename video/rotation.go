@@ -0,0 +1,136 @@
+package video
+
+import (
+	"github.com/go-gl/gl/v2.1/gl"
+	"github.com/libretro/ludo/settings"
+)
+
+// quarterTurns converts a rotation setting in degrees to a number of
+// counter-clockwise quarter turns
+func quarterTurns(degrees int) uint {
+	return uint(((degrees/90)%4 + 4) % 4)
+}
+
+// gameRotation is the rotation of the game image, combining the rotation
+// requested by the core and the one set by the user
+func (video *Video) gameRotation() uint {
+	return (video.rot + quarterTurns(settings.Current.VideoRotation)) % 4
+}
+
+// screenRotation is the rotation of the whole screen, menu included
+func screenRotation() uint {
+	return quarterTurns(settings.Current.VideoScreenRotation)
+}
+
+// unrotate maps a point of the window to the screen before its rotation.
+// Coordinates are relative to the window size, with the origin at the top
+// left corner.
+func unrotate(x, y float32, rot uint) (float32, float32) {
+	for i := uint(0); i < rot; i++ {
+		x, y = 1-y, x
+	}
+	return x, y
+}
+
+// screenQuad returns the vertices used to draw the rotated screen texture
+// in the window
+func screenQuad(rot uint) []float32 {
+	corners := [4][2]float32{{0, 1}, {0, 0}, {1, 1}, {1, 0}} // LB, LT, RB, RT
+	var va []float32
+	for _, c := range corners {
+		u, v := unrotate(c[0], c[1], rot)
+		// The screen texture has its origin at the bottom left corner
+		va = append(va, c[0]*2-1, 1-c[1]*2, u, 1-v)
+	}
+	return va
+}
+
+// GetSize returns the size of the window in screen coordinates, swapped
+// when the screen is rotated by a quarter turn
+func (video *Video) GetSize() (int, int) {
+	if video.Window == nil {
+		return 0, 0
+	}
+	w, h := video.Window.GetSize()
+	if screenRotation()%2 == 1 {
+		return h, w
+	}
+	return w, h
+}
+
+// CursorPos returns the position of the cursor on the screen, taking the
+// screen rotation into account
+func (video *Video) CursorPos() (float64, float64) {
+	cx, cy := video.Window.GetCursorPos()
+	ww, wh := video.Window.GetSize()
+	if ww == 0 || wh == 0 {
+		return cx, cy
+	}
+	u, v := unrotate(float32(cx)/float32(ww), float32(cy)/float32(wh), screenRotation())
+	w, h := video.GetSize()
+	return float64(u) * float64(w), float64(v) * float64(h)
+}
+
+// bindScreen prepares the framebuffer everything is drawn to. When the
+// screen is rotated, or presented on another thread, it is a texture later
+// drawn by Present.
+func (video *Video) bindScreen() {
+	if video.presenter != nil {
+		video.presenter.bind(video)
+		return
+	}
+	if screenRotation() == 0 {
+		if video.screenFBO != 0 {
+			gl.DeleteFramebuffers(1, &video.screenFBO)
+			gl.DeleteTextures(1, &video.screenTex)
+			video.screenFBO, video.screenTex = 0, 0
+			video.screenW, video.screenH = 0, 0
+		}
+		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+		return
+	}
+
+	w, h := video.GetFramebufferSize()
+	if video.screenFBO == 0 {
+		gl.GenFramebuffers(1, &video.screenFBO)
+		gl.GenTextures(1, &video.screenTex)
+	}
+	if int32(w) != video.screenW || int32(h) != video.screenH {
+		video.screenW, video.screenH = int32(w), int32(h)
+		gl.BindTexture(gl.TEXTURE_2D, video.screenTex)
+		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA8, video.screenW, video.screenH, 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+		setFilter(video.screenTex, false)
+		gl.BindFramebuffer(gl.FRAMEBUFFER, video.screenFBO)
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, video.screenTex, 0)
+	}
+	gl.BindFramebuffer(gl.FRAMEBUFFER, video.screenFBO)
+}
+
+// Present draws the rotated screen in the window, or hands the screen to the
+// presentation thread. It has to be called once everything is drawn, before
+// swapping buffers.
+func (video *Video) Present() {
+	if video.presenter != nil {
+		video.presenter.submit(video)
+		return
+	}
+	if video.screenFBO == 0 {
+		return
+	}
+
+	fbw, fbh := video.Window.GetFramebufferSize()
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.Viewport(0, 0, int32(fbw), int32(fbh))
+	gl.ClearColor(0, 0, 0, 1)
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+	gl.Disable(gl.BLEND)
+
+	va := screenQuad(screenRotation())
+	gl.UseProgram(video.defaultProgram)
+	bindVertexArray(video.vao)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, video.screenTex)
+	gl.BindBuffer(gl.ARRAY_BUFFER, video.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(va)*4, gl.Ptr(va), gl.STATIC_DRAW)
+	gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
+}
@@ -1,10 +1,13 @@
 package video
 
 import (
+	"errors"
 	"image"
+	"image/color"
 	"image/png"
 	"os"
 	"path/filepath"
+	"unsafe"
 
 	"github.com/disintegration/imaging"
 	"github.com/go-gl/gl/v2.1/gl"
@@ -40,7 +43,7 @@ func (video *Video) TakeScreenshot(name string) error {
 
 	img := image.NewRGBA(image.Rect(0, 0, video.Geom.BaseWidth, video.Geom.BaseHeight))
 
-	_, fbh := video.Window.GetFramebufferSize()
+	_, fbh := video.GetFramebufferSize()
 
 	gl.ReadPixels(
 		0, int32(fbh-video.Geom.BaseHeight),
@@ -64,3 +67,124 @@ func (video *Video) TakeScreenshot(name string) error {
 
 	return png.Encode(fd, flipped)
 }
+
+// SaveScreenshot writes the last game frame to a PNG file. The raw frame sent
+// by the core is saved, or the image displayed in the window, shaders
+// included, if postShader is true.
+func (video *Video) SaveScreenshot(path string, postShader bool) error {
+	var img image.Image
+	if postShader {
+		img = video.readViewport()
+	} else {
+		var err error
+		img, err = video.Frame()
+		if err != nil {
+			return err
+		}
+	}
+
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	fd, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	return png.Encode(fd, img)
+}
+
+// Frame returns the last frame sent by the core, rotated the way it is
+// displayed
+func (video *Video) Frame() (image.Image, error) {
+	rgba, err := video.rawFrame()
+	if err != nil {
+		return nil, err
+	}
+	var img image.Image = rgba
+	for i := uint(0); i < video.gameRotation(); i++ {
+		img = imaging.Rotate90(img)
+	}
+	return img, nil
+}
+
+// capturedFrame is a copy of a frame sent by the core. The buffer of the core
+// is only valid during the refresh callback.
+type capturedFrame struct {
+	data                 []byte
+	width, height, pitch int32
+	pixFmt               uint32
+}
+
+// capture copies a frame of the core. Duplicated frames, with nil data, keep
+// the previous copy.
+func (f *capturedFrame) capture(data unsafe.Pointer, width, height, pitch int32, pixFmt uint32) {
+	if data == nil || height <= 0 || pitch <= 0 {
+		return
+	}
+	n := int(pitch) * int(height)
+	if cap(f.data) < n {
+		f.data = make([]byte, n)
+	}
+	f.data = f.data[:n]
+	copy(f.data, (*[1 << 30]byte)(data)[:n:n])
+	f.width, f.height, f.pitch, f.pixFmt = width, height, pitch, pixFmt
+}
+
+// rawFrame converts the last frame sent by the core to an image
+func (video *Video) rawFrame() (*image.RGBA, error) {
+	f := video.last
+	if len(f.data) == 0 {
+		return nil, errors.New("no frame to capture")
+	}
+	return convertFrame(f.data, int(f.width), int(f.height), int(f.pitch), f.pixFmt), nil
+}
+
+// convertFrame converts a core framebuffer in one of the libretro pixel
+// formats to an image
+func convertFrame(data []byte, width, height, pitch int, pixFmt uint32) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	expand5 := func(v uint16) uint8 { return uint8(v<<3 | v>>2) }
+	expand6 := func(v uint16) uint8 { return uint8(v<<2 | v>>4) }
+
+	for y := 0; y < height; y++ {
+		row := data[y*pitch:]
+		for x := 0; x < width; x++ {
+			var c color.RGBA
+			switch pixFmt {
+			case gl.UNSIGNED_INT_8_8_8_8_REV: // XRGB8888
+				p := row[x*4:]
+				c = color.RGBA{p[2], p[1], p[0], 0xff}
+			case gl.UNSIGNED_SHORT_5_6_5: // RGB565
+				v := uint16(row[x*2]) | uint16(row[x*2+1])<<8
+				c = color.RGBA{expand5(v >> 11 & 0x1f), expand6(v >> 5 & 0x3f), expand5(v & 0x1f), 0xff}
+			default: // 0RGB1555
+				v := uint16(row[x*2]) | uint16(row[x*2+1])<<8
+				c = color.RGBA{expand5(v >> 10 & 0x1f), expand5(v >> 5 & 0x1f), expand5(v & 0x1f), 0xff}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// readViewport renders the last frame and reads back the part of the screen
+// where the game is displayed
+func (video *Video) readViewport() image.Image {
+	video.ResizeViewport()
+	video.Render()
+
+	fbw, fbh := video.GetFramebufferSize()
+	x, y, w, h := video.contentRect(fbw, fbh)
+	img := image.NewRGBA(image.Rect(0, 0, int(w), int(h)))
+
+	gl.ReadPixels(
+		int32(x), int32(float32(fbh)-y-h),
+		int32(w), int32(h),
+		gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+
+	return imaging.FlipV(img)
+}
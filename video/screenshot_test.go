@@ -0,0 +1,65 @@
+package video
+
+import (
+	"image/color"
+	"testing"
+	"unsafe"
+
+	"github.com/go-gl/gl/v2.1/gl"
+)
+
+func Test_convertFrame(t *testing.T) {
+	tests := []struct {
+		name   string
+		pixFmt uint32
+		data   []byte
+		pitch  int
+		want   color.RGBA
+	}{
+		{
+			name:   "XRGB8888",
+			pixFmt: gl.UNSIGNED_INT_8_8_8_8_REV,
+			data:   []byte{0x30, 0x20, 0x10, 0x00, 0, 0, 0, 0},
+			pitch:  8,
+			want:   color.RGBA{0x10, 0x20, 0x30, 0xff},
+		},
+		{
+			name:   "RGB565",
+			pixFmt: gl.UNSIGNED_SHORT_5_6_5,
+			data:   []byte{0x1f, 0xf8, 0, 0},
+			pitch:  4,
+			want:   color.RGBA{0xff, 0x00, 0xff, 0xff},
+		},
+		{
+			name:   "0RGB1555",
+			pixFmt: gl.UNSIGNED_SHORT_5_5_5_1,
+			data:   []byte{0xe0, 0x03, 0, 0},
+			pitch:  4,
+			want:   color.RGBA{0x00, 0xff, 0x00, 0xff},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := convertFrame(tt.data, 1, 1, tt.pitch, tt.pixFmt)
+			if got := img.RGBAAt(0, 0); got != tt.want {
+				t.Errorf("convertFrame() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_capturedFrame(t *testing.T) {
+	var f capturedFrame
+	pixels := []byte{1, 2, 3, 4}
+	f.capture(unsafe.Pointer(&pixels[0]), 1, 2, 2, gl.UNSIGNED_SHORT_5_6_5)
+	// The core reuses its buffer, and sends duplicated frames
+	pixels[0] = 9
+	f.capture(nil, 1, 2, 2, gl.UNSIGNED_SHORT_5_6_5)
+
+	if len(f.data) != 4 || f.data[0] != 1 {
+		t.Errorf("got = %v, want a copy of the first frame", f.data)
+	}
+	if f.width != 1 || f.height != 2 || f.pitch != 2 {
+		t.Errorf("got = %+v", f)
+	}
+}
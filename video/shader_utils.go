@@ -8,6 +8,12 @@ import (
 )
 
 func newProgram(vertexShaderSource, fragmentShaderSource string) (uint32, error) {
+	return linkProgram(vertexShaderSource, fragmentShaderSource, nil)
+}
+
+// linkProgram compiles and links a program. bind is called before linking,
+// to set attribute locations.
+func linkProgram(vertexShaderSource, fragmentShaderSource string, bind func(program uint32)) (uint32, error) {
 	vertexShader, err := compileShader(vertexShaderSource, gl.VERTEX_SHADER)
 	if err != nil {
 		return 0, err
@@ -22,6 +28,9 @@ func newProgram(vertexShaderSource, fragmentShaderSource string) (uint32, error)
 
 	gl.AttachShader(program, vertexShader)
 	gl.AttachShader(program, fragmentShader)
+	if bind != nil {
+		bind(program)
+	}
 	gl.LinkProgram(program)
 
 	var status int32
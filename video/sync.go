@@ -0,0 +1,86 @@
+package video
+
+import (
+	"strings"
+	"time"
+
+	"github.com/go-gl/gl/v2.1/gl"
+	"github.com/libretro/ludo/settings"
+)
+
+// MaxFrameDelay is the highest frame delay that can be set, in milliseconds
+const MaxFrameDelay = 15
+
+// syncTimeout is how long to wait for the GPU before giving up, in
+// nanoseconds
+const syncTimeout = 1000000000
+
+// frameDelay returns how long to wait after a buffer swap before running the
+// core, for a game running at fps frames per second. Some time is kept for
+// the core to run and the frame to be drawn.
+func frameDelay(ms int, fps float64) time.Duration {
+	if ms <= 0 || fps <= 0 {
+		return 0
+	}
+	period := time.Duration(float64(time.Second) / fps)
+	max := period - 4*time.Millisecond
+	d := time.Duration(ms) * time.Millisecond
+	if d > max {
+		d = max
+	}
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// FrameDelay waits before running the next frame of the core, so the input
+// is polled as late as possible. It has to be called after swapping buffers.
+func (video *Video) FrameDelay() {
+	if d := frameDelay(settings.Current.VideoFrameDelay, video.Timing.FPS); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// fencesSupported tells if the GL context can create sync objects
+func fencesSupported() bool {
+	if v := gl.GoStr(gl.GetString(gl.VERSION)); v >= "3.2" {
+		return true
+	}
+	return strings.Contains(gl.GoStr(gl.GetString(gl.EXTENSIONS)), "GL_ARB_sync")
+}
+
+// SyncGPU prevents the CPU from running ahead of the GPU by more than the
+// number of frames set in the settings. It has to be called after swapping
+// buffers.
+func (video *Video) SyncGPU() {
+	if !settings.Current.VideoHardSync {
+		video.deleteFences()
+		return
+	}
+
+	frames := settings.Current.VideoHardSyncFrames
+	if !video.syncChecked {
+		video.syncChecked = true
+		video.hasFences = fencesSupported()
+	}
+	if frames == 0 || !video.hasFences {
+		gl.Finish()
+		return
+	}
+
+	video.fences = append(video.fences, gl.FenceSync(gl.SYNC_GPU_COMMANDS_COMPLETE, 0))
+	for len(video.fences) > frames {
+		gl.ClientWaitSync(video.fences[0], gl.SYNC_FLUSH_COMMANDS_BIT, syncTimeout)
+		gl.DeleteSync(video.fences[0])
+		video.fences = video.fences[1:]
+	}
+}
+
+// deleteFences frees the pending sync objects
+func (video *Video) deleteFences() {
+	for _, f := range video.fences {
+		gl.DeleteSync(f)
+	}
+	video.fences = nil
+}
@@ -0,0 +1,28 @@
+package video
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_frameDelay(t *testing.T) {
+	tests := []struct {
+		name string
+		ms   int
+		fps  float64
+		want time.Duration
+	}{
+		{"Disabled", 0, 60, 0},
+		{"Fits in the frame", 8, 60, 8 * time.Millisecond},
+		{"Capped by the frame period", 8, 100, 6 * time.Millisecond},
+		{"Frame too short", 5, 300, 0},
+		{"No game", 5, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := frameDelay(tt.ms, tt.fps); got != tt.want {
+				t.Errorf("frameDelay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
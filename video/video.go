@@ -4,21 +4,27 @@
 package video
 
 import (
-	"log"
+	"math"
 	"path/filepath"
+	"time"
 	"unsafe"
 
 	"github.com/go-gl/gl/v2.1/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/libretro/ludo/bezels"
 	"github.com/libretro/ludo/libretro"
+	"github.com/libretro/ludo/logs"
 	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/shaders"
 	"github.com/libretro/ludo/state"
+	"github.com/libretro/ludo/viewport"
 )
 
 // Video holds the state of the video package
 type Video struct {
 	Window *glfw.Window
 	Geom   libretro.GameGeometry
+	Timing libretro.SystemTiming
 	Font   *Font
 
 	program              uint32 // current program used for the game quad
@@ -43,29 +49,215 @@ type Video struct {
 
 	needUpload bool
 	data       unsafe.Pointer
+
+	last capturedFrame // copy of the last frame, see Frame
+
+	filter     string              // video filter used when no preset is set
+	preset     *shaders.Preset     // shader preset in use, if any
+	passes     []pass              // passes of the shader preset
+	params     []shaders.Parameter // parameters of the shader preset
+	frameCount uint
+
+	screenFBO, screenTex uint32 // rotated screen, see Present
+	screenW, screenH     int32
+
+	bezelTex  uint32 // texture of the bezel image at bezelPath
+	bezelPath string
+
+	fontFile string  // font of the menu theme, the font of the assets if empty
+	fontSize float32 // size of the text, see Font.SetSize
+
+	fontDensity float32 // size the font is rasterized at, see SetFontDensity
+
+	fences      []uintptr // pending GPU syncs, see SyncGPU
+	syncChecked bool
+	hasFences   bool
+
+	swapInterval int       // swap interval in use, -1 if not set yet
+	nextFrame    time.Time // deadline of the next frame, see Pace
+
+	bfiDisabled bool // black frames are requested but not displayable
+
+	matchedFPS     float64 // framerate the display mode follows, see MatchRefreshRate
+	fullscreenRate int     // refresh rate of the fullscreen mode before matching
+
+	estimator refreshEstimator // see EstimateRefreshRate
+
+	windowed bool // the window isn't fullscreen, its geometry is remembered
+
+	presenter *presenter // threaded presentation, see VideoThreaded
 }
 
 // Init instanciates the video package
 func Init(fullscreen bool) *Video {
-	vid := &Video{}
+	vid := &Video{swapInterval: -1, matchedFPS: -1}
 	vid.Configure(fullscreen)
 	return vid
 }
 
+// fontPath returns the font of the menu
+func (video *Video) fontPath() string {
+	if video.fontFile != "" {
+		return video.fontFile
+	}
+	return filepath.Join(settings.Current.AssetsDirectory, "font.ttf")
+}
+
+// SetFont replaces the font of the menu. An empty path restores the font of
+// the assets.
+func (video *Video) SetFont(path string) error {
+	if path == video.fontFile {
+		return nil
+	}
+	prev := video.fontFile
+	video.fontFile = path
+	f, err := video.loadFont(video.fontDensity)
+	if err != nil {
+		video.fontFile = prev
+		return err
+	}
+	f.SetSize(video.fontSize)
+	if video.Font != nil {
+		video.Font.Delete()
+	}
+	video.Font = f
+	return nil
+}
+
+// loadFont rasterizes the font of the menu, density times larger than the
+// reference size
+func (video *Video) loadFont(density float32) (*Font, error) {
+	if density <= 0 {
+		density = 1
+	}
+	fbw, fbh := video.Window.GetFramebufferSize()
+	// LoadFont (fontfile, font scale, window width, window height)
+	f, err := LoadFont(video.fontPath(), int32(36*2*density), fbw, fbh)
+	if err != nil {
+		return nil, err
+	}
+	f.density = density
+	return f, nil
+}
+
+// fontDensity rounds the density of the font to half steps, to not reload
+// the font on every resize. Past twice the reference size, the atlas would
+// get too large.
+func fontDensity(d float32) float32 {
+	q := float32(math.Ceil(float64(d)*2) / 2)
+	if q < 1 {
+		return 1
+	}
+	if q > 2 {
+		return 2
+	}
+	return q
+}
+
+// SetFontDensity rasterizes the font at the size it is displayed, so the text
+// stays sharp on high resolution screens. d is the scale of the layout, the
+// size of the text is applied on top.
+func (video *Video) SetFontDensity(d float32) {
+	if video.fontSize > 0 {
+		d *= video.fontSize
+	}
+	d = fontDensity(d)
+	if d == video.fontDensity || video.Window == nil {
+		return
+	}
+	f, err := video.loadFont(d)
+	if err != nil {
+		logs.Warnf("Video", "Failed to load the font: %v", err)
+		return
+	}
+	f.SetSize(video.fontSize)
+	if video.Font != nil {
+		video.Font.Delete()
+	}
+	video.Font = f
+	video.fontDensity = d
+}
+
+// DisplayScale returns the scale factor of the monitor that the framebuffer
+// doesn't already include. It is 1 on Retina or Wayland displays, where the
+// framebuffer is larger than the window, and the scaling of the desktop on
+// Windows or X11, where they have the same size.
+func (video *Video) DisplayScale() float32 {
+	if video.Window == nil {
+		return 1
+	}
+	xs, _ := video.Window.GetContentScale()
+	ww, _ := video.Window.GetSize()
+	fbw, _ := video.Window.GetFramebufferSize()
+	if xs <= 0 || ww <= 0 || fbw <= 0 {
+		return 1
+	}
+	return xs * float32(ww) / float32(fbw)
+}
+
+// SetFontSize enlarges or shrinks the text of the menu
+func (video *Video) SetFontSize(size float32) {
+	video.fontSize = size
+	if video.Font != nil {
+		video.Font.SetSize(size)
+	}
+}
+
 // Reconfigure destroys and recreates the window with new attributes
 func (video *Video) Reconfigure(fullscreen bool) {
 	if video.Window != nil {
+		video.SaveWindowGeometry()
+		video.Close()
 		video.Window.Destroy()
 	}
+	// The GL objects of the preset are lost with the context
+	preset := video.preset
+	video.passes = nil
+	video.screenFBO, video.screenTex = 0, 0
+	video.screenW, video.screenH = 0, 0
+	video.bezelTex, video.bezelPath = 0, ""
+	video.fences, video.syncChecked = nil, false
+	video.swapInterval = -1
+	video.matchedFPS, video.fullscreenRate = -1, 0
+	video.estimator = refreshEstimator{}
 	video.Configure(fullscreen)
+	if preset != nil {
+		if err := video.SetPreset(preset); err != nil {
+			logs.Warnf("Video", "Failed to restore the shader preset: %v", err)
+		}
+	}
+}
+
+// Close stops the threaded presentation, it has to be called before
+// destroying the window
+func (video *Video) Close() {
+	if video.presenter == nil {
+		return
+	}
+	video.presenter.stop()
+	video.presenter = nil
+}
+
+// SwapBuffers displays the frame. With threaded presentation, the frame is
+// displayed by the presentation thread instead, see Present.
+func (video *Video) SwapBuffers() {
+	if video.presenter != nil {
+		return
+	}
+	video.Window.SwapBuffers()
 }
 
 // GetFramebufferSize retrieves the size, in pixels, of the framebuffer of the specified window.
+// The size is swapped when the screen is rotated by a quarter turn.
 func (video *Video) GetFramebufferSize() (int, int) {
 	if video.Window == nil {
 		return 0, 0
 	}
-	return video.Window.GetFramebufferSize()
+	w, h := video.Window.GetFramebufferSize()
+	if screenRotation()%2 == 1 {
+		return h, w
+	}
+	return w, h
 }
 
 // SetTitle sets the window title, encoded as UTF-8, of the window.
@@ -76,6 +268,18 @@ func (video *Video) SetTitle(title string) {
 	video.Window.SetTitle(title)
 }
 
+// Raise restores the window if it is iconified, and brings it to the front
+func (video *Video) Raise() {
+	if video.Window == nil {
+		return
+	}
+	if video.Window.GetAttrib(glfw.Iconified) == glfw.True {
+		video.Window.Restore()
+	}
+	video.Window.Show()
+	video.Window.Focus()
+}
+
 // SetShouldClose sets the value of the close flag of the window.
 func (video *Video) SetShouldClose(b bool) {
 	if video.Window == nil {
@@ -86,18 +290,40 @@ func (video *Video) SetShouldClose(b bool) {
 
 // Configure instanciates the video package
 func (video *Video) Configure(fullscreen bool) {
-	var width, height int
 	var m *glfw.Monitor
-
-	if fullscreen {
-		m = glfw.GetMonitors()[settings.Current.VideoMonitorIndex]
+	s := settings.Current
+	width, height := 384*2, 240*2
+	x, y := s.VideoWindowX, s.VideoWindowY
+	positioned := false
+	video.windowed = !fullscreen
+
+	glfw.WindowHint(glfw.Decorated, glfw.True)
+	glfw.WindowHint(glfw.ScaleToMonitor, glfw.False)
+	switch {
+	// A borderless window covering the monitor has to be positioned, Wayland
+	// only has the fullscreen of the compositor
+	case fullscreen && s.VideoFullscreenMode == "Borderless" && positionable:
+		mon := monitor(s.VideoMonitorIndex)
+		vm := mon.GetVideoMode()
+		width, height = vm.Width, vm.Height
+		x, y = mon.GetPos()
+		positioned = true
+		glfw.WindowHint(glfw.Decorated, glfw.False)
+	case fullscreen:
+		m = monitor(s.VideoMonitorIndex)
 		vms := m.GetVideoModes()
 		vm := vms[len(vms)-1]
 		width = vm.Width
 		height = vm.Height
-	} else {
-		width = 384 * 2
-		height = 240 * 2
+	case s.VideoWindowWidth > 0 && s.VideoWindowHeight > 0:
+		width, height = s.VideoWindowWidth, s.VideoWindowHeight
+		// The monitors may have changed since the position was saved
+		x, y = placeWindow(x, y, width, height, workAreas())
+		positioned = true
+	default:
+		// The default size is enlarged on high density monitors, the saved
+		// size already is
+		glfw.WindowHint(glfw.ScaleToMonitor, glfw.True)
 	}
 
 	var err error
@@ -105,8 +331,21 @@ func (video *Video) Configure(fullscreen bool) {
 	if err != nil {
 		panic("Window creation failed:" + err.Error())
 	}
+	xs, ys := video.Window.GetContentScale()
+	logs.Debugf("Video", "Content scale: %.2fx%.2f", xs, ys)
+
+	if positioned && positionable {
+		video.Window.SetPos(x, y)
+	}
 
-	video.Window.MakeContextCurrent()
+	if settings.Current.VideoThreaded {
+		video.presenter = newPresenter(video.Window)
+	}
+	if video.presenter != nil {
+		video.presenter.context.MakeContextCurrent()
+	} else {
+		video.Window.MakeContextCurrent()
+	}
 
 	// Force a minimum size for the window.
 	video.Window.SetSizeLimits(160, 120, glfw.DontCare, glfw.DontCare)
@@ -118,14 +357,22 @@ func (video *Video) Configure(fullscreen bool) {
 		panic(err)
 	}
 
+	if video.presenter != nil {
+		video.presenter.start(video.Window)
+	}
+
 	fbw, fbh := video.Window.GetFramebufferSize()
 
-	// LoadFont (fontfile, font scale, window width, window height)
-	fontPath := filepath.Join(settings.Current.AssetsDirectory, "font.ttf")
-	video.Font, err = LoadFont(fontPath, int32(36*2), fbw, fbh)
+	video.Font, err = video.loadFont(video.fontDensity)
+	if err != nil && video.fontFile != "" {
+		logs.Warnf("Video", "Failed to load the font of the theme: %v", err)
+		video.fontFile = ""
+		video.Font, err = video.loadFont(video.fontDensity)
+	}
 	if err != nil {
 		panic(err)
 	}
+	video.Font.SetSize(video.fontSize)
 
 	// Configure the vertex and fragment shaders
 	video.defaultProgram, err = newProgram(vertexShader, defaultFragmentShader)
@@ -196,11 +443,16 @@ func (video *Video) Configure(fullscreen bool) {
 		video.bpp = 2
 	}
 
-	gl.GenTextures(1, &video.texID)
+	if video.presenter != nil {
+		// The frames are uploaded by the presentation thread, see Refresh
+		video.texID = video.presenter.games[video.presenter.game]
+	} else {
+		gl.GenTextures(1, &video.texID)
+	}
 
 	gl.ActiveTexture(gl.TEXTURE0)
-	if video.texID == 0 && state.Verbose {
-		log.Println("[Video]: Failed to create the vid texture")
+	if video.texID == 0 {
+		logs.Errorf("Video", "Failed to create the vid texture")
 	}
 
 	gl.BindTexture(gl.TEXTURE_2D, video.texID)
@@ -210,7 +462,7 @@ func (video *Video) Configure(fullscreen bool) {
 	video.coreRatioViewport(fbw, fbh)
 
 	if e := gl.GetError(); e != gl.NO_ERROR {
-		log.Printf("[Video] OpenGL error: %d\n", e)
+		logs.Errorf("Video", "OpenGL error: %d", e)
 	}
 }
 
@@ -222,33 +474,30 @@ func (video *Video) Configure(fullscreen bool) {
 // CRT: zfast-crt
 // LCD: zfast-lcd
 func (video *Video) UpdateFilter(filter string) {
-	gl.BindTexture(gl.TEXTURE_2D, video.texID)
+	video.filter = filter
+	linear := true
 	switch filter {
 	case "Smooth":
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
 		video.program = video.defaultProgram
 	case "Pixel Perfect":
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
 		video.program = video.sharpBilinearProgram
 	case "CRT":
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
 		video.program = video.zfastCRTProgram
 	case "LCD":
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
 		video.program = video.zfastLCDProgram
 	case "Raw":
 		fallthrough
 	default:
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+		linear = false
 		video.program = video.defaultProgram
 	}
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	textures := []uint32{video.texID}
+	if video.presenter != nil {
+		textures = video.presenter.games[:]
+	}
+	for _, tex := range textures {
+		setFilter(tex, linear)
+	}
 	gl.UseProgram(video.program)
 	gl.Uniform2f(gl.GetUniformLocation(video.program, gl.Str("TextureSize\x00")), float32(video.width), float32(video.height))
 	gl.Uniform2f(gl.GetUniformLocation(video.program, gl.Str("InputSize\x00")), float32(video.width), float32(video.height))
@@ -257,9 +506,7 @@ func (video *Video) UpdateFilter(filter string) {
 // SetPixelFormat is a callback passed to the libretro implementation.
 // It allows the core or the game to tell us which pixel format should be used for the display.
 func (video *Video) SetPixelFormat(format uint32) bool {
-	if state.Verbose {
-		log.Printf("[Video]: Set Pixel Format: %v\n", format)
-	}
+	logs.Debugf("Video", "Set Pixel Format: %v", format)
 
 	// PixelStorei also needs to be updated whenever bpp changes
 	defer func() { video.needUpload = true }()
@@ -281,7 +528,7 @@ func (video *Video) SetPixelFormat(format uint32) bool {
 		video.bpp = 2
 		return true
 	default:
-		log.Printf("Unknown pixel type %v", format)
+		logs.Errorf("Video", "Unknown pixel type %v", format)
 	}
 
 	return false
@@ -302,38 +549,66 @@ func (video *Video) ResetRot() {
 // coreRatioViewport configures the vertex array to display the game at the center of the window
 // while preserving the original ascpect ratio of the game or core
 func (video *Video) coreRatioViewport(fbWidth int, fbHeight int) (x, y, w, h float32) {
-	// Scale the content to fit in the viewport.
-	fbw := float32(fbWidth)
-	fbh := float32(fbHeight)
-
-	// NXEngine workaround
-	aspectRatio := float32(video.Geom.AspectRatio)
-	if aspectRatio == 0 {
-		aspectRatio = float32(video.Geom.BaseWidth) / float32(video.Geom.BaseHeight)
-	}
-
-	h = fbh
-	w = fbh * aspectRatio
-	if w > fbw {
-		h = fbw / aspectRatio
-		w = fbw
-	}
-
-	// Place the content in the middle of the window.
-	x = (fbw - w) / 2
-	y = (fbh - h) / 2
+	x, y, w, h = video.contentRect(fbWidth, fbHeight)
 
 	va := video.vertexArray(x, y, w, h, 1.0)
-	va = rotateUV(va, video.rot)
+	va = rotateUV(va, video.gameRotation())
+	cx, cy := viewport.Current().Crop(video.width, video.height)
+	va = cropUV(va, cx, cy)
 	gl.BindBuffer(gl.ARRAY_BUFFER, video.vbo)
 	gl.BufferData(gl.ARRAY_BUFFER, len(va)*4, gl.Ptr(va), gl.STATIC_DRAW)
 
 	return
 }
 
-// ResizeViewport resizes the GL viewport to the framebuffer size
+// contentRect computes where the game is drawn in the framebuffer, respecting
+// the core aspect ratio and the viewport settings
+func (video *Video) contentRect(fbWidth int, fbHeight int) (x, y, w, h float32) {
+	vp := viewport.Current()
+
+	aspectRatio := vp.AspectRatio(float32(video.Geom.AspectRatio), float32(video.Geom.BaseWidth), float32(video.Geom.BaseHeight))
+
+	// Cropping the overscan changes the shape of the image
+	cx, cy := vp.Crop(video.width, video.height)
+	aspectRatio *= (1 - 2*cx) / (1 - 2*cy)
+	baseH := float32(video.Geom.BaseHeight) * (1 - 2*cy)
+
+	// Vertical games are displayed with a quarter turn
+	if video.gameRotation()%2 == 1 {
+		aspectRatio = 1 / aspectRatio
+		baseH = float32(video.Geom.BaseWidth) * (1 - 2*cx)
+	}
+
+	fw, fh := float32(fbWidth), float32(fbHeight)
+
+	// Fit the game in the cutout of the bezel
+	if b := bezels.Current(); b != nil && bezels.Active() {
+		bx, by, bw, bh := b.CutoutRect(fw, fh)
+		x, y, w, h = vp.Rect(bw, bh, baseH, aspectRatio)
+		return bx + x, by + y, w, h
+	}
+
+	return vp.Rect(fw, fh, baseH, aspectRatio)
+}
+
+// ContentRect returns the area of the window where the game is drawn, in
+// window coordinates, so it can be compared to the cursor position
+func (video *Video) ContentRect() (x, y, w, h float32) {
+	fbw, fbh := video.GetFramebufferSize()
+	ww, _ := video.GetSize()
+	x, y, w, h = video.contentRect(fbw, fbh)
+	if fbw == 0 || ww == 0 {
+		return
+	}
+	scale := float32(ww) / float32(fbw)
+	return x * scale, y * scale, w * scale, h * scale
+}
+
+// ResizeViewport resizes the GL viewport to the framebuffer size, and binds
+// the screen texture if the screen is rotated
 func (video *Video) ResizeViewport() {
-	fbw, fbh := video.Window.GetFramebufferSize()
+	video.bindScreen()
+	fbw, fbh := video.GetFramebufferSize()
 	gl.Viewport(0, 0, int32(fbw), int32(fbh))
 }
 
@@ -354,8 +629,14 @@ func (video *Video) Render() {
 	}
 
 	video.uploadTexture()
+	video.frameCount++
 
-	fbw, fbh := video.Window.GetFramebufferSize()
+	fbw, fbh := video.GetFramebufferSize()
+	if len(video.passes) > 0 {
+		video.renderPasses(fbw, fbh)
+		video.drawBezel(fbw, fbh)
+		return
+	}
 	_, _, w, h := video.coreRatioViewport(fbw, fbh)
 
 	gl.UseProgram(video.program)
@@ -367,6 +648,8 @@ func (video *Video) Render() {
 	gl.BindBuffer(gl.ARRAY_BUFFER, video.vbo)
 
 	gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
+
+	video.drawBezel(fbw, fbh)
 }
 
 // Refresh the texture framebuffer
@@ -376,9 +659,17 @@ func (video *Video) Refresh(data unsafe.Pointer, width int32, height int32, pitc
 	video.height = height
 	video.pitch = pitch
 	video.data = data // maybe need a full copy
+	video.last.capture(data, width, height, pitch, video.pixFmt)
+	if video.presenter != nil && data != nil {
+		video.presenter.send(video.last, video.bpp, video.pixType)
+	}
 }
 
 func (video *Video) uploadTexture() {
+	if video.presenter != nil {
+		video.takeUpload()
+		return
+	}
 	if !video.needUpload || video.data == nil {
 		return
 	}
@@ -393,14 +684,24 @@ func (video *Video) uploadTexture() {
 	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA8, video.width, video.height, 0, video.pixType, video.pixFmt, video.data)
 }
 
+// takeUpload draws the last frame uploaded by the presentation thread
+func (video *Video) takeUpload() {
+	u, ok := video.presenter.take()
+	if !ok {
+		return
+	}
+	video.texID = video.presenter.games[u.tex]
+	gl.UseProgram(video.program)
+	gl.Uniform2f(gl.GetUniformLocation(video.program, gl.Str("TextureSize\x00")), float32(u.width), float32(u.height))
+	gl.Uniform2f(gl.GetUniformLocation(video.program, gl.Str("InputSize\x00")), float32(u.width), float32(u.height))
+}
+
 // SetRotation rotates the game image as requested by the core
 func (video *Video) SetRotation(rot uint) bool {
 	// limit to valid values (0, 1, 2, 3, which rotates screen by 0, 90, 180 270 degrees counter-clockwise)
 	video.rot = rot % 4
 
-	if state.Verbose {
-		log.Printf("[Video]: Set Rotation: %v", video.rot)
-	}
+	logs.Debugf("Video", "Set Rotation: %v", video.rot)
 
 	return true
 }
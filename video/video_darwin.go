@@ -13,3 +13,7 @@ func genVertexArrays(n int32, arrays *uint32) {
 func bindVertexArray(array uint32) {
 	gl.BindVertexArrayAPPLE(array)
 }
+
+func deleteVertexArrays(n int32, arrays *uint32) {
+	gl.DeleteVertexArraysAPPLE(n, arrays)
+}
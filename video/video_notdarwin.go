@@ -13,3 +13,7 @@ func genVertexArrays(n int32, arrays *uint32) {
 func bindVertexArray(array uint32) {
 	gl.BindVertexArray(array)
 }
+
+func deleteVertexArrays(n int32, arrays *uint32) {
+	gl.DeleteVertexArrays(n, arrays)
+}
@@ -0,0 +1,102 @@
+package video
+
+import (
+	"image"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/libretro/ludo/logs"
+	"github.com/libretro/ludo/settings"
+)
+
+// FullscreenModes are the ways of going fullscreen. Exclusive changes the
+// video mode of the monitor, Borderless covers it with an undecorated window,
+// which makes switching between apps faster.
+var FullscreenModes = []string{"Exclusive", "Borderless"}
+
+// monitor returns the monitor at index, or the primary monitor if it has
+// been unplugged
+func monitor(index int) *glfw.Monitor {
+	monitors := glfw.GetMonitors()
+	if index < 0 || index >= len(monitors) {
+		return glfw.GetPrimaryMonitor()
+	}
+	return monitors[index]
+}
+
+// MonitorName returns the name of the monitor at index
+func MonitorName(index int) string {
+	m := monitor(index)
+	if m == nil {
+		return ""
+	}
+	return m.GetName()
+}
+
+// workAreas returns the areas of the monitors not covered by the task bars
+// and docks, the one of the primary monitor first
+func workAreas() []image.Rectangle {
+	var areas []image.Rectangle
+	primary := glfw.GetPrimaryMonitor()
+	if primary != nil {
+		x, y, w, h := primary.GetWorkarea()
+		areas = append(areas, image.Rect(x, y, x+w, y+h))
+	}
+	for _, m := range glfw.GetMonitors() {
+		if m == primary {
+			continue
+		}
+		x, y, w, h := m.GetWorkarea()
+		areas = append(areas, image.Rect(x, y, x+w, y+h))
+	}
+	return areas
+}
+
+// placeWindow returns where to open a window of size w*h saved at x, y. The
+// window is moved inside the work area it overlaps the most, and centered on
+// the first work area if it overlaps none, like when its monitor has been
+// unplugged.
+func placeWindow(x, y, w, h int, areas []image.Rectangle) (int, int) {
+	if len(areas) == 0 {
+		return x, y
+	}
+	win := image.Rect(x, y, x+w, y+h)
+	best, overlap := -1, 0
+	for i, a := range areas {
+		in := win.Intersect(a)
+		if o := in.Dx() * in.Dy(); o > overlap {
+			best, overlap = i, o
+		}
+	}
+	if best < 0 {
+		a := areas[0]
+		return a.Min.X + (a.Dx()-w)/2, a.Min.Y + (a.Dy()-h)/2
+	}
+
+	a := areas[best]
+	clamp := func(v, size, min, max int) int {
+		if v+size > max {
+			v = max - size
+		}
+		if v < min {
+			v = min
+		}
+		return v
+	}
+	return clamp(x, w, a.Min.X, a.Max.X), clamp(y, h, a.Min.Y, a.Max.Y)
+}
+
+// SaveWindowGeometry remembers the size and position of the window, to
+// restore them on the next launch. It does nothing in fullscreen.
+func (video *Video) SaveWindowGeometry() {
+	if video.Window == nil || !video.windowed {
+		return
+	}
+	s := &settings.Current
+	if positionable {
+		s.VideoWindowX, s.VideoWindowY = video.Window.GetPos()
+	}
+	s.VideoWindowWidth, s.VideoWindowHeight = video.Window.GetSize()
+	if err := settings.Save(); err != nil {
+		logs.Errorf("Video", "Failed to save the window geometry: %v", err)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !linux || !wayland
+// +build !linux !wayland
+
+package video
+
+// positionable tells if the windows can be placed by the application
+const positionable = true
@@ -0,0 +1,35 @@
+package video
+
+import (
+	"image"
+	"testing"
+)
+
+func Test_placeWindow(t *testing.T) {
+	areas := []image.Rectangle{
+		image.Rect(0, 30, 1920, 1080),
+		image.Rect(1920, 0, 3200, 1024),
+	}
+	tests := []struct {
+		name  string
+		x, y  int
+		areas []image.Rectangle
+		wx    int
+		wy    int
+	}{
+		{"Inside", 100, 100, areas, 100, 100},
+		{"On the second monitor", 2000, 100, areas, 2000, 100},
+		{"Under the task bar", 100, 0, areas, 100, 30},
+		{"Past the right edge", 3000, 100, areas, 2432, 100},
+		{"Unplugged monitor", -2000, 100, areas, 576, 315},
+		{"No monitor", -2000, 100, nil, -2000, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, y := placeWindow(tt.x, tt.y, 768, 480, tt.areas)
+			if x != tt.wx || y != tt.wy {
+				t.Errorf("placeWindow() = %v, %v, want %v, %v", x, y, tt.wx, tt.wy)
+			}
+		})
+	}
+}
@@ -0,0 +1,8 @@
+//go:build linux && wayland
+// +build linux,wayland
+
+package video
+
+// positionable tells if the windows can be placed by the application. On
+// Wayland, the compositor places the windows and fullscreens them itself.
+const positionable = false
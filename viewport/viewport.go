@@ -0,0 +1,211 @@
+// Package viewport computes where the game image is drawn in the window. The
+// video settings give the default behavior, which can be overridden for a
+// core.
+package viewport
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/adrg/xdg"
+	"github.com/libretro/ludo/settings"
+	"github.com/libretro/ludo/utils"
+	"github.com/pelletier/go-toml"
+)
+
+// OverscanPixels is the number of pixels cropped on each side of the game
+// image when overscan cropping is enabled
+const OverscanPixels = 8
+
+// AspectRatios are the aspect ratio modes. Core Provided uses the geometry
+// reported by the core, 1:1 PAR displays square pixels.
+var AspectRatios = []string{"Core Provided", "4:3", "16:9", "1:1 PAR", "Custom"}
+
+// Config tells how the game image is placed in the window.
+// IntegerScale restricts the scaling factor to whole numbers. Custom uses
+// Width and Height as the size of the image, offset from the center by X
+// and Y. CropOverscan hides the borders of the image. Aspect is one of
+// AspectRatios, CustomAspect being used for Custom.
+type Config struct {
+	IntegerScale bool    `toml:"integer_scale"`
+	CropOverscan bool    `toml:"crop_overscan"`
+	Custom       bool    `toml:"custom"`
+	Width        int     `toml:"width"`
+	Height       int     `toml:"height"`
+	X            int     `toml:"x"`
+	Y            int     `toml:"y"`
+	Aspect       string  `toml:"aspect_ratio"`
+	CustomAspect float32 `toml:"custom_aspect_ratio"`
+}
+
+// override is the config of the running core, if it has one
+var override *Config
+
+// fromSettings returns the config set in the video settings
+func fromSettings() Config {
+	s := settings.Current
+	return Config{
+		IntegerScale: s.VideoIntegerScale,
+		CropOverscan: s.VideoCropOverscan,
+		Custom:       s.VideoCustomViewport,
+		Width:        s.VideoViewportWidth,
+		Height:       s.VideoViewportHeight,
+		X:            s.VideoViewportX,
+		Y:            s.VideoViewportY,
+		Aspect:       s.VideoAspectRatio,
+		CustomAspect: s.VideoCustomAspectRatio,
+	}
+}
+
+// Current returns the config in use
+func Current() Config {
+	if override != nil {
+		return *override
+	}
+	return fromSettings()
+}
+
+// Overridden tells if the running core has its own config
+func Overridden() bool {
+	return override != nil
+}
+
+// Set overrides the video settings for the running core
+func Set(c Config) {
+	override = &c
+}
+
+// path returns the location of the viewport file of a core
+func path(corePath string) string {
+	name := utils.FileName(corePath)
+	return filepath.Join(xdg.ConfigHome, "ludo", "viewports", name, name+".toml")
+}
+
+// Load reads the config of a core, if it exists
+func Load(core string) {
+	override = nil
+
+	b, err := ioutil.ReadFile(path(core))
+	if err != nil {
+		return
+	}
+
+	c := fromSettings()
+	if err := toml.Unmarshal(b, &c); err != nil {
+		return
+	}
+	override = &c
+}
+
+// Save writes the current config for a core
+func Save(core string) error {
+	b, err := toml.Marshal(Current())
+	if err != nil {
+		return err
+	}
+
+	p := path(core)
+	err = os.MkdirAll(filepath.Dir(p), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	fd, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	_, err = io.Copy(fd, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	return fd.Sync()
+}
+
+// Reset removes the config of a core, the video settings are used again
+func Reset(core string) error {
+	override = nil
+	err := os.Remove(path(core))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// AspectRatio returns the aspect ratio to display a game image of size
+// baseW*baseH with, core being the aspect ratio reported by the core
+func (c Config) AspectRatio(core, baseW, baseH float32) float32 {
+	square := float32(1)
+	if baseW > 0 && baseH > 0 {
+		square = baseW / baseH
+	}
+	switch c.Aspect {
+	case "4:3":
+		return 4.0 / 3.0
+	case "16:9":
+		return 16.0 / 9.0
+	case "1:1 PAR":
+		return square
+	case "Custom":
+		if c.CustomAspect > 0 {
+			return c.CustomAspect
+		}
+	}
+	// NXEngine workaround
+	if core == 0 {
+		return square
+	}
+	return core
+}
+
+// Crop returns the part of the texture to display, as the margins to remove
+// on each side, relative to the texture size
+func (c Config) Crop(texW, texH int32) (cx, cy float32) {
+	if !c.CropOverscan || texW <= 2*OverscanPixels || texH <= 2*OverscanPixels {
+		return 0, 0
+	}
+	return float32(OverscanPixels) / float32(texW), float32(OverscanPixels) / float32(texH)
+}
+
+// Rect computes where the game is drawn in a framebuffer of size fbw*fbh,
+// for a game image baseH pixels high displayed with the given aspect ratio
+func (c Config) Rect(fbw, fbh, baseH, aspect float32) (x, y, w, h float32) {
+	switch {
+	case c.Custom && c.Width > 0 && c.Height > 0:
+		w = float32(c.Width)
+		h = float32(c.Height)
+	case c.IntegerScale && baseH > 0:
+		n := float32(math.Floor(float64(fbh / baseH)))
+		for n > 1 && (n*baseH*aspect > fbw) {
+			n--
+		}
+		if n < 1 {
+			n = 1
+		}
+		h = n * baseH
+		w = h * aspect
+	default:
+		h = fbh
+		w = fbh * aspect
+		if w > fbw {
+			h = fbw / aspect
+			w = fbw
+		}
+	}
+
+	// Place the content in the middle of the window.
+	x = (fbw - w) / 2
+	y = (fbh - h) / 2
+	if c.Custom {
+		x += float32(c.X)
+		y += float32(c.Y)
+	}
+
+	return
+}
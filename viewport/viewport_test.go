@@ -0,0 +1,81 @@
+package viewport
+
+import (
+	"testing"
+)
+
+func TestConfig_Rect(t *testing.T) {
+	type want struct {
+		x, y, w, h float32
+	}
+	tests := []struct {
+		name   string
+		config Config
+		want   want
+	}{
+		{
+			name:   "Fits the window",
+			config: Config{},
+			want:   want{x: 0, y: 60, w: 1440, h: 960},
+		},
+		{
+			name:   "Integer scaling",
+			config: Config{IntegerScale: true},
+			want:   want{x: 120, y: 140, w: 1200, h: 800},
+		},
+		{
+			name:   "Custom viewport",
+			config: Config{Custom: true, Width: 640, Height: 480, X: 16, Y: -8},
+			want:   want{x: 416, y: 292, w: 640, h: 480},
+		},
+		{
+			name:   "Custom viewport without a size",
+			config: Config{Custom: true, X: 16},
+			want:   want{x: 16, y: 60, w: 1440, h: 960},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, y, w, h := tt.config.Rect(1440, 1080, 200, 1.5)
+			got := want{x, y, w, h}
+			if got != tt.want {
+				t.Errorf("Rect() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_Crop(t *testing.T) {
+	cx, cy := Config{}.Crop(256, 240)
+	if cx != 0 || cy != 0 {
+		t.Errorf("Crop() = %v, %v, want 0, 0", cx, cy)
+	}
+	cx, cy = Config{CropOverscan: true}.Crop(256, 240)
+	if cx != 8.0/256 || cy != 8.0/240 {
+		t.Errorf("Crop() = %v, %v, want %v, %v", cx, cy, 8.0/256, 8.0/240)
+	}
+}
+
+func TestConfig_AspectRatio(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		core   float32
+		want   float32
+	}{
+		{"Core provided", Config{Aspect: "Core Provided"}, 1.5, 1.5},
+		{"Core provided without geometry", Config{Aspect: "Core Provided"}, 0, 256.0 / 224.0},
+		{"4:3", Config{Aspect: "4:3"}, 1.5, 4.0 / 3.0},
+		{"16:9", Config{Aspect: "16:9"}, 1.5, 16.0 / 9.0},
+		{"Square pixels", Config{Aspect: "1:1 PAR"}, 1.5, 256.0 / 224.0},
+		{"Custom", Config{Aspect: "Custom", CustomAspect: 1.25}, 1.5, 1.25},
+		{"Unset custom", Config{Aspect: "Custom"}, 1.5, 1.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.AspectRatio(tt.core, 256, 224); got != tt.want {
+				t.Errorf("AspectRatio() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}